@@ -0,0 +1,435 @@
+package blackscholes
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+// AmericanMethod selects how American options are priced
+type AmericanMethod uint8
+
+const (
+	// AmericanTree uses a Leisen-Reimer tree of AmericanTreeSteps steps
+	AmericanTree AmericanMethod = iota
+	// AmericanBAW uses the Barone-Adesi-Whaley quadratic approximation
+	AmericanBAW
+	// AmericanBS2002 uses the Bjerksund-Stensland 2002 approximation
+	AmericanBS2002
+)
+
+func (m AmericanMethod) String() string {
+	switch m {
+	case AmericanTree:
+		return "tree"
+	case AmericanBAW:
+		return "BAW"
+	case AmericanBS2002:
+		return "BS2002"
+	}
+	return fmt.Sprintf("AmericanMethod(%d)", uint8(m))
+}
+
+var ErrAmericanMethod = errors.New("Unknown American pricing method")
+
+// AmericanTreeSteps is the Leisen-Reimer step count of AmericanTree
+const AmericanTreeSteps int = 201
+
+// AmericanIVStepsDefault is the Leisen-Reimer step count used by
+// ImpliedVolAmerican when AmericanIVConfig.Steps is not set
+const AmericanIVStepsDefault int = AmericanTreeSteps
+
+// EarlyExerciseTol is the size, relative to max(1, european), below which
+// a negative early exercise premium is taken as pricing noise and
+// clamped to 0 without a warning
+const EarlyExerciseTol float64 = 1e-4
+
+// ClampWarning is returned by EarlyExercisePremium, along with valid
+// prices, when an American price fell short of the European price by more
+// than EarlyExerciseTol and the premium was clamped to 0. It usually means
+// too coarse a tree or an approximation used outside its accuracy range.
+type ClampWarning struct {
+	Premium float64 // the negative premium before clamping
+}
+
+func (w *ClampWarning) Error() string {
+	return fmt.Sprintf("Negative early exercise premium %v clamped to 0", w.Premium)
+}
+
+// PriceAmerican returns the price of an American option by the given
+// method. A Straddle is an American call plus an American put, each
+// exercised on its own. At zero vol the price is the best discounted
+// payoff along the deterministic forward path, and below TimeFloor it is
+// the spot intrinsic value.
+func PriceAmerican(v, t, x, k, r, q float64, o OptionType, method AmericanMethod) (float64, error) {
+
+	if v < 0 {
+		return nan(), ErrNegVol
+	}
+	if err := checkParams(t, x, k, r, q, o); err != nil {
+		return nan(), err
+	}
+	if method > AmericanBS2002 {
+		return nan(), ErrAmericanMethod
+	}
+
+	if o == Straddle {
+		c, err := PriceAmerican(v, t, x, k, r, q, Call, method)
+		if err != nil {
+			return nan(), err
+		}
+		p, err := PriceAmerican(v, t, x, k, r, q, Put, method)
+		return c + p, err
+	}
+
+	switch {
+	case t < TimeFloor || x == 0 || k == 0:
+		return max(payoff(x, k, o), BSPrice(v, t, x, k, r, q, o)), nil
+	case v == 0:
+		return zeroVolAmerican(t, x, k, r, q, o), nil
+	}
+
+	var p float64
+	switch method {
+	case AmericanTree:
+		return PriceLeisenReimer(v, t, x, k, r, q, o, American, AmericanTreeSteps)
+	case AmericanBAW:
+		p = priceBAW(v, t, x, k, r, q, o)
+	default:
+		// put-call transformation P(x, k, r, q) = C(k, x, q, r)
+		if o == Put {
+			x, k, r, q = k, x, q, r
+		}
+		p = priceBS2002Call(v, t, x, k, r, q)
+	}
+
+	if math.IsNaN(p) || math.IsInf(p, 0) {
+		return nan(), ErrNoncovergence
+	}
+	return p, nil
+}
+
+// EarlyExercisePremium splits an American option's price into the
+// European price and the early exercise premium american - european.
+// A negative premium is clamped to 0, with a *ClampWarning error if it was
+// larger than EarlyExerciseTol; the prices are valid either way.
+func EarlyExercisePremium(
+	vol, timeToExpiry, spot, strike, interestRate, dividendYield float64,
+	optionType OptionType, method AmericanMethod,
+) (european, american, premium float64, err error) {
+
+	american, err = PriceAmerican(vol, timeToExpiry, spot, strike, interestRate, dividendYield, optionType, method)
+	if err != nil {
+		return nan(), nan(), nan(), err
+	}
+	european = BSPrice(vol, timeToExpiry, spot, strike, interestRate, dividendYield, optionType)
+
+	if premium = american - european; premium < 0 {
+		if -premium > EarlyExerciseTol*max(1, european) {
+			err = &ClampWarning{Premium: premium}
+		}
+		premium = 0
+	}
+
+	return
+}
+
+// zeroVolAmerican maximizes the discounted payoff of exercise at time s
+// along the forward path, where s*Exp(-q*s) - k*Exp(-r*s) is stationary at
+// most once
+func zeroVolAmerican(t, x, k, r, q float64, o OptionType) float64 {
+
+	sign := 1.0
+	if o == Put {
+		sign = -1
+	}
+	value := func(s float64) float64 {
+		return max(sign*(discounted(x, q, s)-discounted(k, r, s)), 0)
+	}
+
+	best := max(value(0), value(t))
+	if r != q && r*q > 0 {
+		if s := log(r*k/q/x) / (r - q); s > 0 && s < t {
+			best = max(best, value(s))
+		}
+	}
+
+	return best
+}
+
+// priceBAW is the Barone-Adesi-Whaley price of a call or put for x, k,
+// v > 0 and t >= TimeFloor
+func priceBAW(v, t, x, k, r, q float64, o OptionType) float64 {
+
+	european := BSPrice(v, t, x, k, r, q, o)
+
+	// early exercise never pays for a call without dividends or a put
+	// without positive rates
+	if o == Call && q <= 0 || o == Put && r <= 0 {
+		return european
+	}
+
+	b, v2, vs := r-q, v*v, v*sqrt(t)
+	n := 2 * b / v2
+	mk := 2 / v2 / t // M/K = 2r/v2/(1 - exp(-r*t)) as r -> 0
+	if r != 0 {
+		mk = 2 * r / v2 / -math.Expm1(-r*t)
+	}
+	dfq := DiscountFactor(q, t)
+	d1 := func(s float64) float64 { return (log(s/k) + (b+v2/2)*t) / vs }
+
+	sign := 1.0
+	if o == Put {
+		sign = -1
+	}
+	// q2 for a call and q1 for a put
+	qq := (-(n - 1) + sign*sqrt((n-1)*(n-1)+4*mk)) / 2
+
+	// Newton iteration for the critical price seeded as in Barone-Adesi
+	// and Whaley
+	qinf := (-(n - 1) + sign*sqrt((n-1)*(n-1)+8*r/v2)) / 2
+	sinf := k / (1 - 1/qinf)
+	var si float64
+	if o == Call {
+		si = k + (sinf-k)*(1-exp(-(b*t+2*vs)*k/(sinf-k)))
+	} else {
+		si = sinf + (k-sinf)*exp((b*t-2*vs)*k/(k-sinf))
+	}
+
+	for i := 0; i < 100; i++ {
+
+		nd1, pd1 := NormCDF(sign*d1(si)), NormPDF(d1(si))
+		lhs := sign * (si - k)
+		rhs := BSPrice(v, t, si, k, r, q, o) + sign*(1-dfq*nd1)*si/qq
+		if abs(lhs-rhs) <= 1e-12*k {
+			break
+		}
+
+		// slope of rhs in si
+		bi := dfq*nd1*(1-1/qq) + (1-dfq*pd1/vs)/qq
+		if o == Put {
+			bi = -dfq*nd1*(1-1/qq) - (1+dfq*pd1/vs)/qq
+		}
+		si = (k + sign*(rhs-bi*si)) / (1 - sign*bi)
+	}
+
+	if sign*(x-si) >= 0 {
+		return sign * (x - k)
+	}
+
+	a := sign * (si / qq) * (1 - dfq*NormCDF(sign*d1(si)))
+	return european + a*pow(x/si, qq)
+}
+
+// priceBS2002Call is the Bjerksund-Stensland 2002 price of a call for x,
+// k, v > 0 and t >= TimeFloor
+func priceBS2002Call(v, t, x, k, r, q float64) float64 {
+
+	b, v2 := r-q, v*v
+
+	if b >= r {
+		return BSPrice(v, t, x, k, r, q, Call)
+	}
+
+	beta := (0.5 - b/v2) + sqrt((b/v2-0.5)*(b/v2-0.5)+2*r/v2)
+	binf := beta / (beta - 1) * k
+	b0 := max(k, r/(r-b)*k)
+
+	t1 := 0.5 * (math.Sqrt(5) - 1) * t
+	h1 := -(b*t1 + 2*v*sqrt(t1)) * k * k / ((binf - b0) * b0)
+	h2 := -(b*t + 2*v*sqrt(t)) * k * k / ((binf - b0) * b0)
+	i1 := b0 + (binf-b0)*(1-exp(h1))
+	i2 := b0 + (binf-b0)*(1-exp(h2))
+
+	if x >= i2 {
+		return x - k
+	}
+
+	alpha1 := (i1 - k) * pow(i1, -beta)
+	alpha2 := (i2 - k) * pow(i2, -beta)
+
+	phi := func(gamma, h, i float64) float64 {
+		return bs2002Phi(x, t1, gamma, h, i, r, b, v)
+	}
+	ksi := func(gamma, h float64) float64 {
+		return bs2002Ksi(x, t, gamma, h, i2, i1, t1, r, b, v)
+	}
+
+	return alpha2*pow(x, beta) - alpha2*phi(beta, i2, i2) +
+		phi(1, i2, i2) - phi(1, i1, i2) -
+		k*phi(0, i2, i2) + k*phi(0, i1, i2) +
+		alpha1*phi(beta, i1, i2) - alpha1*ksi(beta, i1) +
+		ksi(1, i1) - ksi(1, k) -
+		k*ksi(0, i1) + k*ksi(0, k)
+}
+
+func bs2002Phi(x, t, gamma, h, i, r, b, v float64) float64 {
+
+	v2, vs := v*v, v*sqrt(t)
+	lambda := -r + gamma*b + 0.5*gamma*(gamma-1)*v2
+	kappa := 2*b/v2 + 2*gamma - 1
+	d := -(log(x/h) + (b+(gamma-0.5)*v2)*t) / vs
+
+	return exp(lambda*t) * pow(x, gamma) *
+		(NormCDF(d) - pow(i/x, kappa)*NormCDF(d-2*log(i/x)/vs))
+}
+
+func bs2002Ksi(x, t2, gamma, h, i2, i1, t1, r, b, v float64) float64 {
+
+	v2 := v * v
+	vs1, vs2 := v*sqrt(t1), v*sqrt(t2)
+	drift := b + (gamma-0.5)*v2
+
+	e1 := (log(x/i1) + drift*t1) / vs1
+	e2 := (log(i2*i2/x/i1) + drift*t1) / vs1
+	e3 := (log(x/i1) - drift*t1) / vs1
+	e4 := (log(i2*i2/x/i1) - drift*t1) / vs1
+
+	f1 := (log(x/h) + drift*t2) / vs2
+	f2 := (log(i2*i2/x/h) + drift*t2) / vs2
+	f3 := (log(i1*i1/x/h) + drift*t2) / vs2
+	f4 := (log(x*i1*i1/h/i2/i2) + drift*t2) / vs2
+
+	rho := sqrt(t1 / t2)
+	lambda := -r + gamma*b + 0.5*gamma*(gamma-1)*v2
+	kappa := 2*b/v2 + 2*gamma - 1
+
+	return exp(lambda*t2) * pow(x, gamma) * (bivariateNormCDF(-e1, -f1, rho) -
+		pow(i2/x, kappa)*bivariateNormCDF(-e2, -f2, rho) -
+		pow(i1/x, kappa)*bivariateNormCDF(-e3, -f3, -rho) +
+		pow(i1/i2, kappa)*bivariateNormCDF(-e4, -f4, -rho))
+}
+
+// AmericanIVConfig holds the search settings of ImpliedVolAmerican. Zero
+// fields take the defaults of ImpliedVol and AmericanIVStepsDefault.
+type AmericanIVConfig struct {
+	Steps int
+	LB    float64
+	UB    float64
+	Tol   float64
+	MaxIt int
+}
+
+// ImpliedVolAmerican returns the vol at which the Leisen-Reimer price of
+// an American option equals premium. The premium must lie between the
+// spot intrinsic value, max(x - k, 0) for a call, and the underlying (call)
+// or strike (put) price, otherwise ErrArbitrage is returned. Only
+// non-negative vols are searched: a premium at intrinsic value, or below
+// the tree price at every vol, has no positive implied vol and returns 0
+// or an error respectively.
+func ImpliedVolAmerican(
+	premium, timeToExpiry, spot, strike, interestRate, dividendYield float64,
+	optionType OptionType, cfg AmericanIVConfig,
+) (float64, error) {
+
+	p, t, x, k, r, q, o := premium, timeToExpiry, spot, strike, interestRate, dividendYield, optionType
+	if err := checkParams(t, x, k, r, q, o); err != nil {
+		return nan(), err
+	}
+	if p < 0 {
+		return nan(), ErrNegPremium
+	}
+
+	intrval := payoff(x, k, o)
+	ubound := x + k
+	switch o {
+	case Call:
+		ubound = x
+	case Put:
+		ubound = k
+	}
+	if p < intrval || p > ubound {
+		return nan(), ErrArbitrage
+	}
+
+	if t < TimeFloor || x == 0 || k == 0 || p-intrval <= 0 {
+		return 0, nil
+	}
+
+	steps, lb, ub := AmericanIVStepsDefault, lbDefault, ubDefault
+	if cfg.Steps > 0 {
+		steps = cfg.Steps
+	}
+	if cfg.LB > 0 {
+		lb = cfg.LB
+	}
+	if cfg.UB > 0 {
+		ub = cfg.UB
+	}
+	tol, maxit := cfg.Tol, cfg.MaxIt
+	CheckVolSearchParams(&lb, &ub, &tol, &maxit)
+
+	price := func(v float64) (float64, error) {
+		return PriceLeisenReimer(v, t, x, k, r, q, o, American, steps)
+	}
+
+	return bisectVol(p, price, lb, ub, tol, maxit)
+}
+
+// bisectVol solves price(vol) == p by bisection for an increasing price
+// function of non-negative vols. The lower bound is halved down to
+// volFloor and the upper bound raised in the steps of ImpliedVol until
+// they bracket p. Errors from price are returned as they are.
+func bisectVol(
+	p float64, price func(float64) (float64, error), lb, ub, tol float64, maxit int,
+) (float64, error) {
+
+	const volFloor = 1e-4
+
+	var it int
+	plo, err := price(lb)
+	for ; err == nil && plo > p && lb > volFloor && it < maxit; it++ {
+		lb /= 2
+		plo, err = price(lb)
+	}
+	if err != nil {
+		return nan(), err
+	}
+	if !(plo <= p) {
+		return nan(), fmt.Errorf(
+			"Failed to find lower bound - lb price, lb vol, iters: %v, %v, %d",
+			plo, lb, it,
+		)
+	}
+
+	phi, err := price(ub)
+	for it = 0; err == nil && phi < p && it < maxit; it++ {
+		ub += 0.47
+		phi, err = price(ub)
+	}
+	if err != nil {
+		return nan(), err
+	}
+	if !(p <= phi) {
+		return nan(), fmt.Errorf(
+			"Failed to find upper bound - uprice, uvol, iters: %v, %v, %d",
+			phi, ub, it,
+		)
+	}
+
+	var vol, pmid float64
+	for it = 0; it < maxit; it++ {
+
+		vol = 0.5 * (lb + ub)
+		if pmid, err = price(vol); err != nil {
+			return nan(), err
+		}
+
+		switch {
+		case ub-lb < tol, pmid == p:
+			return vol, nil
+		case p < pmid:
+			ub = vol
+		case pmid < p:
+			lb = vol
+		default:
+			return nan(), ErrNoncovergence
+		}
+	}
+
+	return nan(), fmt.Errorf(
+		"Did not converge - lb, ub, mid, iters: %v, %v, %v, %d",
+		lb, ub, pmid, it,
+	)
+}
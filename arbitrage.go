@@ -0,0 +1,109 @@
+package blackscholes
+
+import "fmt"
+
+// ArbKind is the kind of a static arbitrage found in a vol surface
+type ArbKind uint8
+
+const (
+	// ButterflyArb is a negative risk neutral density within a smile
+	ButterflyArb ArbKind = iota
+	// CalendarArb is total variance falling with expiry at fixed moneyness
+	CalendarArb
+)
+
+func (a ArbKind) String() string {
+	switch a {
+	case ButterflyArb:
+		return "butterfly"
+	case CalendarArb:
+		return "calendar"
+	}
+	return fmt.Sprintf("ArbKind(%d)", uint8(a))
+}
+
+// ArbViolation locates one static arbitrage in a vol surface: the expiry,
+// strike and log-moneyness log(strike/forward) where it was found and its
+// magnitude, the negative density for a butterfly and the drop in total
+// variance from the previous expiry for a calendar violation
+type ArbViolation struct {
+	Kind      ArbKind
+	T         float64
+	Strike    float64
+	Moneyness float64
+	Magnitude float64
+}
+
+// defaultMoneynessGrid is the log-moneyness grid of CheckSurfaceArbitrage
+// when SurfaceConfig.MoneynessGrid is nil: -1 to 1 in steps of 0.05
+var defaultMoneynessGrid = func() []float64 {
+	g := make([]float64, 41)
+	for i := range g {
+		g[i] = -1 + 0.05*float64(i)
+	}
+	return g
+}()
+
+// arbTol is the size below which a violation is taken as rounding noise
+const arbTol float64 = 1e-8
+
+// CheckSurfaceArbitrage checks the surface for static arbitrage at its
+// expiries on the log-moneyness grid of its SurfaceConfig, with forwards
+// spot*exp((r-q)*t). Butterfly arbitrage is a negative Breeden-Litzenberger
+// density exp(r*t)*d2C/dK2, taken by central differences of call prices
+// with step 1e-3*strike. Calendar arbitrage is a total variance below that
+// of the previous expiry at the same moneyness. Grid points where the
+// surface does not extrapolate are skipped.
+func CheckSurfaceArbitrage(s VolSurface, spot, r, q float64) []ArbViolation {
+
+	grid := s.cfg.MoneynessGrid
+	if grid == nil {
+		grid = defaultMoneynessGrid
+	}
+
+	var arbs []ArbViolation
+
+	for i, t := range s.ts {
+
+		f := Forward(spot, r, q, t)
+
+		call := func(k float64) (float64, bool) {
+			v, err := s.Vol(k, t)
+			if err != nil {
+				return nan(), false
+			}
+			return BSPrice(v, t, spot, k, r, q, Call), true
+		}
+
+		for _, m := range grid {
+
+			k := f * exp(m)
+			h := 1e-3 * k
+
+			cd, okd := call(k - h)
+			c, ok := call(k)
+			cu, oku := call(k + h)
+			if okd && ok && oku {
+				if d := (cu - 2*c + cd) / DiscountFactor(r, t) / h / h; d < -arbTol {
+					arbs = append(arbs, ArbViolation{ButterflyArb, t, k, m, -d})
+				}
+			}
+
+			if i == 0 {
+				continue
+			}
+
+			t0 := s.ts[i-1]
+			v0, err0 := s.Vol(Forward(spot, r, q, t0)*exp(m), t0)
+			v1, err1 := s.Vol(k, t)
+			if err0 != nil || err1 != nil {
+				continue
+			}
+			if dw := v0*v0*t0 - v1*v1*t; dw > arbTol {
+				arbs = append(arbs, ArbViolation{CalendarArb, t, k, m, dw})
+			}
+		}
+	}
+
+	return arbs
+}
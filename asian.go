@@ -0,0 +1,246 @@
+package blackscholes
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/pkg/errors"
+)
+
+// AsianMethod selects how the Asian pricers value an average price option
+type AsianMethod uint8
+
+const (
+	// AsianGeometric is the closed form price of the option on the
+	// geometric average of the fixings
+	AsianGeometric AsianMethod = iota
+	// AsianArithmeticMC is the Monte Carlo price of the option on the
+	// arithmetic average over the antithetic pairs of paths of an
+	// AsianConfig, with the geometric average option as control variate
+	AsianArithmeticMC
+)
+
+func (m AsianMethod) String() string {
+	switch m {
+	case AsianGeometric:
+		return "geometric"
+	case AsianArithmeticMC:
+		return "arithmetic MC"
+	}
+	return fmt.Sprintf("AsianMethod(%d)", uint8(m))
+}
+
+var (
+	ErrAsian       = errors.New("Invalid Asian fixing schedule")
+	ErrAsianMethod = errors.New("Unknown Asian pricing method")
+)
+
+// AsianPathsDefault is the number of antithetic pairs of paths of
+// AsianArithmeticMC under a zero AsianConfig.Paths
+const AsianPathsDefault int = 20000
+
+// AsianConfig holds the Monte Carlo settings of AsianArithmeticMC. Paths
+// is the number of antithetic pairs of paths, AsianPathsDefault at 0, and
+// Seed seeds them, so that prices are repeatable. The Asian pricers use
+// the zero AsianConfig.
+type AsianConfig struct {
+	Paths int
+	Seed  int64
+}
+
+// paths returns the number of antithetic pairs of paths under c
+func (c AsianConfig) paths() int {
+	if c.Paths == 0 {
+		return AsianPathsDefault
+	}
+	return c.Paths
+}
+
+// PriceAsian returns the price of a European option on the average of the
+// spot at fixings equally spaced fixings, the last at expiry. A Straddle
+// pays |average - strike|.
+func PriceAsian(
+	vol, timeToExpiry, spot, strike, r, q float64, optionType OptionType, fixings int, method AsianMethod,
+) (float64, error) {
+	return AsianConfig{}.PriceAsian(vol, timeToExpiry, spot, strike, r, q, optionType, fixings, method)
+}
+
+// PriceAsian is PriceAsian simulated under c
+func (c AsianConfig) PriceAsian(
+	vol, timeToExpiry, spot, strike, r, q float64, optionType OptionType, fixings int, method AsianMethod,
+) (float64, error) {
+	return c.PriceAsianSeasoned(vol, timeToExpiry, spot, strike, r, q, optionType, 0, 0, fixings, method)
+}
+
+// PriceAsianSeasoned is PriceAsian for an option of fixingsTotal fixings
+// of which fixingsDone have been fixed with average pastAverage, the
+// remaining ones being equally spaced until expiry. The payoff is that of
+// the remaining fixings' average, weighted (total - done)/total, struck
+// at (total*strike - done*pastAverage)/(total - done). A call whose
+// effective strike is not positive is worth the discounted forward of its
+// payoff, and with every fixing done the price is the discounted payoff.
+func PriceAsianSeasoned(
+	vol, timeToExpiry, spot, strike, r, q float64, optionType OptionType,
+	pastAverage float64, fixingsDone, fixingsTotal int, method AsianMethod,
+) (float64, error) {
+	return AsianConfig{}.PriceAsianSeasoned(
+		vol, timeToExpiry, spot, strike, r, q, optionType, pastAverage, fixingsDone, fixingsTotal, method,
+	)
+}
+
+// PriceAsianSeasoned is PriceAsianSeasoned simulated under c
+func (c AsianConfig) PriceAsianSeasoned(
+	vol, timeToExpiry, spot, strike, r, q float64, optionType OptionType,
+	pastAverage float64, fixingsDone, fixingsTotal int, method AsianMethod,
+) (float64, error) {
+
+	v, t, x, k, o := vol, timeToExpiry, spot, strike, optionType
+
+	if err := c.check(v, t, x, k, r, q, o, method); err != nil {
+		return nan(), err
+	}
+	if fixingsTotal < 1 || fixingsDone < 0 || fixingsDone > fixingsTotal ||
+		fixingsDone > 0 && (!(pastAverage >= 0) || pastAverage == inf(1)) {
+		return nan(), ErrAsian
+	}
+
+	n, m := float64(fixingsTotal), float64(fixingsDone)
+
+	if fixingsDone == fixingsTotal {
+		return discounted(payoff(pastAverage, k, o), r, t), nil
+	}
+
+	// the remaining fixings all fix at spot at expiry
+	if t < TimeFloor {
+		return discounted(payoff((m*pastAverage+(n-m)*x)/n, k, o), r, t), nil
+	}
+
+	times := make([]float64, fixingsTotal-fixingsDone)
+	for i := range times {
+		times[i] = t * float64(i+1) / (n - m)
+	}
+
+	return c.price(v, t, x, (n*k-m*pastAverage)/(n-m), r, q, o, times, (n-m)/n, method), nil
+}
+
+// PriceAsianForwardStart is PriceAsian for an averaging window starting
+// windowStart years from now, in [0, timeToExpiry], with fixings equally
+// spaced fixings after it, the last at expiry
+func PriceAsianForwardStart(
+	vol, timeToExpiry, spot, strike, r, q float64, optionType OptionType,
+	windowStart float64, fixings int, method AsianMethod,
+) (float64, error) {
+	return AsianConfig{}.PriceAsianForwardStart(
+		vol, timeToExpiry, spot, strike, r, q, optionType, windowStart, fixings, method,
+	)
+}
+
+// PriceAsianForwardStart is PriceAsianForwardStart simulated under c
+func (c AsianConfig) PriceAsianForwardStart(
+	vol, timeToExpiry, spot, strike, r, q float64, optionType OptionType,
+	windowStart float64, fixings int, method AsianMethod,
+) (float64, error) {
+
+	v, t, x, k, o := vol, timeToExpiry, spot, strike, optionType
+
+	if err := c.check(v, t, x, k, r, q, o, method); err != nil {
+		return nan(), err
+	}
+	if fixings < 1 || !(windowStart >= 0 && windowStart <= t) {
+		return nan(), ErrAsian
+	}
+
+	if t < TimeFloor {
+		return discounted(payoff(x, k, o), r, t), nil
+	}
+
+	times := make([]float64, fixings)
+	for i := range times {
+		times[i] = windowStart + (t-windowStart)*float64(i+1)/float64(fixings)
+	}
+
+	return c.price(v, t, x, k, r, q, o, times, 1, method), nil
+}
+
+func (c AsianConfig) check(v, t, x, k, r, q float64, o OptionType, method AsianMethod) error {
+
+	if v < 0 {
+		return ErrNegVol
+	}
+	if err := checkParams(t, x, k, r, q, o); err != nil {
+		return err
+	}
+	if method > AsianArithmeticMC {
+		return ErrAsianMethod
+	}
+	if method == AsianArithmeticMC && c.paths() < 1 {
+		return ErrZeroPaths
+	}
+
+	return nil
+}
+
+// price returns the price at expiry t of weight times the payoff on the
+// average A of the spot at the increasing times in (0, t] struck at k.
+// For k <= 0 a call or straddle pays A - k and a put nothing.
+func (c AsianConfig) price(
+	v, t, x, k, r, q float64, o OptionType, times []float64, weight float64, method AsianMethod,
+) float64 {
+
+	n := float64(len(times))
+
+	if k <= 0 {
+		if o == Put {
+			return 0
+		}
+		var fa float64
+		for _, ti := range times {
+			fa += Forward(x, r, q, ti) / n
+		}
+		return weight * discounted(fa-k, r, t)
+	}
+
+	// log G is normal with mean lg and variance sg2
+	var mt, cov float64
+	for _, ti := range times {
+		mt += ti / n
+		for _, tj := range times {
+			cov += min(ti, tj) / n / n
+		}
+	}
+	lg, sg2 := log(x)+(r-q-v*v/2)*mt, v*v*cov
+	geo := BSPrice(sqrt(sg2), 1, exp(lg+sg2/2), k, 0, 0, o)
+
+	if method == AsianGeometric {
+		return weight * discounted(geo, r, t)
+	}
+
+	// steps of the log spot between fixings
+	drift, vol := make([]float64, len(times)), make([]float64, len(times))
+	prev := 0.0
+	for i, ti := range times {
+		drift[i], vol[i] = (r-q-v*v/2)*(ti-prev), v*sqrt(ti-prev)
+		prev = ti
+	}
+
+	rng := rand.New(rand.NewSource(c.Seed))
+	lx := log(x)
+	paths := c.paths()
+
+	var sum float64
+	for p := 0; p < paths; p++ {
+		la, lb := lx, lx
+		var sa, sb, ga, gb float64
+		for i := range times {
+			z := rng.NormFloat64()
+			la += drift[i] + vol[i]*z
+			lb += drift[i] - vol[i]*z
+			sa, sb = sa+exp(la), sb+exp(lb)
+			ga, gb = ga+la, gb+lb
+		}
+		sum += payoff(sa/n, k, o) - payoff(exp(ga/n), k, o)
+		sum += payoff(sb/n, k, o) - payoff(exp(gb/n), k, o)
+	}
+
+	return weight * discounted(geo+sum/float64(2*paths), r, t)
+}
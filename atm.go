@@ -0,0 +1,221 @@
+package blackscholes
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// ATMConvention selects the strike called at the money. The zero value is
+// the delta neutral straddle, the FX market standard.
+type ATMConvention uint8
+
+const (
+	// ATMDNS is the delta neutral straddle strike f*exp(v*v*t/2), at
+	// which the call and put spot or forward deltas cancel
+	ATMDNS ATMConvention = iota
+	// ATMForward is the forward f = x*exp((r-q)*t)
+	ATMForward
+	// ATMSpot is the spot x
+	ATMSpot
+)
+
+var ErrATMConvention = errors.New("Unknown ATM convention")
+
+func (c ATMConvention) String() string {
+	switch c {
+	case ATMDNS:
+		return "delta neutral straddle"
+	case ATMForward:
+		return "forward"
+	case ATMSpot:
+		return "spot"
+	}
+	return fmt.Sprintf("ATMConvention(%d)", uint8(c))
+}
+
+// ATMStrike returns the at the money strike under conv. For positive vol
+// and r > q the strikes are ordered spot < forward < delta neutral
+// straddle. The vol is only used by ATMDNS, where it should be the vol at
+// the strike returned, see DNSStrike.
+func ATMStrike(
+	conv ATMConvention, vol, timeToExpiry, spot, interestRate, dividendYield float64,
+) (float64, error) {
+
+	v, t, x, r, q := vol, timeToExpiry, spot, interestRate, dividendYield
+
+	switch {
+	case v < 0:
+		return nan(), ErrNegVol
+	case !(t >= 0):
+		return nan(), ErrNegTimeToExp
+	case !(x > 0):
+		return nan(), ErrNegPrice
+	}
+	if err := checkExponents(t, x, 0, r, q); err != nil {
+		return nan(), err
+	}
+
+	switch conv {
+	case ATMSpot:
+		return x, nil
+	case ATMForward:
+		return Forward(x, r, q, t), nil
+	case ATMDNS:
+		return Forward(x, r, q, t) * exp(v*v*t/2), nil
+	}
+
+	return nan(), ErrATMConvention
+}
+
+// DNSStrike returns the delta neutral straddle strike of a smile, the
+// fixed point k = f*exp(v(k)*v(k)*t/2) of ATMStrike with the smile vol at
+// k. Iteration starts from the forward and returns ErrNoncovergence if it
+// has not settled to a relative 1e-12 within 100 steps.
+func DNSStrike(s *SmileInterp) (float64, error) {
+	return dnsFixedPoint(func(k float64) (float64, error) { return s.Vol(k), nil }, s.forward, s.t)
+}
+
+// dnsFixedPoint iterates the delta neutral straddle strike of forward f
+// and expiry t against the strike dependent vol
+func dnsFixedPoint(vol func(strike float64) (float64, error), f, t float64) (float64, error) {
+
+	k := f
+	for i := 0; i < 100; i++ {
+
+		v, err := vol(k)
+		if err != nil {
+			return nan(), err
+		}
+
+		next, err := ATMStrike(ATMDNS, v, t, f, 0, 0)
+		if err != nil {
+			return nan(), err
+		}
+
+		if abs(next-k) <= 1e-12*k {
+			return next, nil
+		}
+		k = next
+	}
+
+	return nan(), ErrNoncovergence
+}
+
+// ATMInterp selects how ATMVolFromChainWithInterp interpolates between the quoted
+// strikes on either side of the at the money strike
+type ATMInterp uint8
+
+const (
+	// ATMInterpStrike is linear in strike
+	ATMInterpStrike ATMInterp = iota
+	// ATMInterpDelta is linear in the forward call delta N(d1), each
+	// strike taking the delta at its own vol
+	ATMInterpDelta
+)
+
+func (m ATMInterp) String() string {
+	switch m {
+	case ATMInterpStrike:
+		return "strike"
+	case ATMInterpDelta:
+		return "delta"
+	}
+	return fmt.Sprintf("ATMInterp(%d)", uint8(m))
+}
+
+var ErrATMInterp = errors.New("Unknown ATM interpolation")
+
+// ATMExtrapolationWarning is returned by ATMVolFromChain, along with a
+// valid vol, when the at the money strike is outside the out of the money
+// quotes of the chain and the vol of the nearest one was used
+type ATMExtrapolationWarning struct {
+	Strike float64 // the at the money strike
+}
+
+func (w *ATMExtrapolationWarning) Error() string {
+	return fmt.Sprintf("ATM strike %v outside the quoted strikes, vol extrapolated flat", w.Strike)
+}
+
+// ATMVolFromChain returns the implied vol at the at the money strike of
+// the chain under conv. The forward is implied from put-call parity by
+// chain.Forward, or is Forward(spot, r, q, chain.T) if the chain has no
+// strike quoted on both sides. The out of the money quotes on either side
+// of the strike are inverted against the forward and their vols
+// interpolated linearly in strike; the delta neutral straddle strike is
+// iterated to the fixed point of the interpolated vol as in DNSStrike. A
+// strike outside the quoted strikes takes the vol of the nearest one,
+// with an *ATMExtrapolationWarning error.
+func ATMVolFromChain(chain OptionChain, spot, r, q float64, conv ATMConvention) (float64, error) {
+	return ATMVolFromChainWithInterp(chain, spot, r, q, conv, ATMInterpStrike)
+}
+
+// ATMVolFromChainWithInterp is ATMVolFromChain interpolating the vols of
+// the quotes either side of the at the money strike by interp
+func ATMVolFromChainWithInterp(
+	chain OptionChain, spot, r, q float64, conv ATMConvention, interp ATMInterp,
+) (float64, error) {
+
+	if !(spot > 0) {
+		return nan(), ErrNegPrice
+	}
+	if interp > ATMInterpDelta {
+		return nan(), ErrATMInterp
+	}
+
+	f, err := chain.Forward()
+	if err == ErrChain {
+		if err = checkExponents(chain.T, spot, 0, r, q); err != nil {
+			return nan(), err
+		}
+		f = Forward(spot, r, q, chain.T)
+	}
+	if err != nil {
+		return nan(), err
+	}
+
+	var extrapolated bool
+	vol := func(k float64) (float64, error) {
+		v, e, err := chain.interpVol(f, k, interp)
+		extrapolated = e
+		return v, err
+	}
+
+	var k float64
+	switch conv {
+	case ATMSpot:
+		k = spot
+	case ATMForward:
+		k = f
+	case ATMDNS:
+		k, err = dnsFixedPoint(vol, f, chain.T)
+	default:
+		err = ErrATMConvention
+	}
+	if err != nil {
+		return nan(), err
+	}
+
+	v, err := vol(k)
+	if err != nil {
+		return nan(), err
+	}
+	if extrapolated {
+		return v, &ATMExtrapolationWarning{Strike: k}
+	}
+
+	return v, nil
+}
+
+// VolIndexSingleExpiry returns the at the money vol of ATMVolFromChain in
+// annualized vol points, 100 times the vol, as vol indices are quoted.
+// An *ATMExtrapolationWarning is passed through with the index.
+func VolIndexSingleExpiry(chain OptionChain, spot, r, q float64, conv ATMConvention) (float64, error) {
+
+	v, err := ATMVolFromChain(chain, spot, r, q, conv)
+	if _, ok := err.(*ATMExtrapolationWarning); err != nil && !ok {
+		return nan(), err
+	}
+
+	return 100 * v, err
+}
@@ -0,0 +1,395 @@
+package blackscholes
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// BarrierType is the knock in or knock out condition of a single barrier
+// option, monitored continuously until expiry
+type BarrierType uint8
+
+const (
+	DownAndIn BarrierType = iota
+	DownAndOut
+	UpAndIn
+	UpAndOut
+)
+
+var ErrBarrier = errors.New("Invalid barrier")
+
+// RebateTiming selects when the rebate of a knocked out option, or the
+// payout of a one touch, is paid: when the barrier is hit or at expiry.
+// Knock in rebates are paid at expiry if the barrier was never hit.
+type RebateTiming uint8
+
+const (
+	RebateAtHit RebateTiming = iota
+	RebateAtExpiry
+)
+
+func (r RebateTiming) String() string {
+	switch r {
+	case RebateAtHit:
+		return "at hit"
+	case RebateAtExpiry:
+		return "at expiry"
+	}
+	return fmt.Sprintf("RebateTiming(%d)", uint8(r))
+}
+
+func (b BarrierType) String() string {
+	switch b {
+	case DownAndIn:
+		return "down-and-in"
+	case DownAndOut:
+		return "down-and-out"
+	case UpAndIn:
+		return "up-and-in"
+	case UpAndOut:
+		return "up-and-out"
+	}
+	return fmt.Sprintf("BarrierType(%d)", uint8(b))
+}
+
+func (b BarrierType) up() bool { return b == UpAndIn || b == UpAndOut }
+func (b BarrierType) in() bool { return b == DownAndIn || b == UpAndIn }
+
+// PriceBarrier returns the price of a single barrier option with barrier h
+// and no rebate using the Reiner-Rubinstein formulas. A Straddle is the
+// sum of the call and the put with the same barrier. An option whose
+// barrier has been reached, including h == x, is already knocked: knock
+// outs are worth 0 and knock ins are vanilla options.
+func PriceBarrier(v, t, x, k, h, r, q float64, o OptionType, b BarrierType) (float64, error) {
+	g, err := barrierGreeks(v, t, x, k, h, r, q, o, b, 0, RebateAtExpiry, false)
+	return g.Price, err
+}
+
+// DeltaBarrier differentiates the closed form exactly in x
+func DeltaBarrier(v, t, x, k, h, r, q float64, o OptionType, b BarrierType) (float64, error) {
+	g, err := barrierGreeks(v, t, x, k, h, r, q, o, b, 0, RebateAtExpiry, false)
+	return g.Delta, err
+}
+
+// GammaBarrier differentiates DeltaBarrier numerically, see BarrierGreeks
+func GammaBarrier(v, t, x, k, h, r, q float64, o OptionType, b BarrierType) (float64, error) {
+	g, err := barrierGreeks(v, t, x, k, h, r, q, o, b, 0, RebateAtExpiry, true)
+	return g.Gamma, err
+}
+
+// VegaBarrier differentiates the closed form exactly in v
+func VegaBarrier(v, t, x, k, h, r, q float64, o OptionType, b BarrierType) (float64, error) {
+	g, err := barrierGreeks(v, t, x, k, h, r, q, o, b, 0, RebateAtExpiry, false)
+	return g.Vega, err
+}
+
+// BarrierGreeks returns the price and greeks of a barrier option. Delta,
+// vega, theta and rho are exact derivatives of the closed form, computed
+// by forward mode differentiation. Gamma is the central difference of delta
+// with step 1e-4*x, or the second order one sided difference
+// (-3*delta(x) + 4*delta(x+s) - delta(x+2*s)) / (2*s) stepping away from
+// the barrier when x is within a step of it. Knocked options and the zero
+// vol and expiry limits take the greeks of their vanilla or zero payoff.
+func BarrierGreeks(v, t, x, k, h, r, q float64, o OptionType, b BarrierType) (Greeks, error) {
+	return barrierGreeks(v, t, x, k, h, r, q, o, b, 0, RebateAtExpiry, true)
+}
+
+// PriceBarrierRebate is PriceBarrier for an option paying a rebate: a
+// knock out pays it when knocked, at hit or at expiry by timing, and a
+// knock in pays it at expiry if never knocked in. These are the E and F
+// terms of the Reiner-Rubinstein formulas. A Straddle pays one rebate,
+// and a knocked out option is worth the rebate, paid now at hit or
+// discounted from expiry.
+func PriceBarrierRebate(
+	v, t, x, k, h, rebate, r, q float64, o OptionType, b BarrierType, timing RebateTiming,
+) (float64, error) {
+	g, err := barrierGreeks(v, t, x, k, h, r, q, o, b, rebate, timing, false)
+	return g.Price, err
+}
+
+// BarrierRebateGreeks is BarrierGreeks for PriceBarrierRebate. The zero
+// vol and expiry limits only carry the price of the rebate and the theta
+// of its discounting from expiry.
+func BarrierRebateGreeks(
+	v, t, x, k, h, rebate, r, q float64, o OptionType, b BarrierType, timing RebateTiming,
+) (Greeks, error) {
+	return barrierGreeks(v, t, x, k, h, r, q, o, b, rebate, timing, true)
+}
+
+func barrierGreeks(
+	v, t, x, k, h, r, q float64, o OptionType, b BarrierType,
+	rebate float64, timing RebateTiming, gamma bool,
+) (Greeks, error) {
+
+	if v < 0 {
+		return nanGreeks(), ErrNegVol
+	}
+
+	if err := checkParams(t, x, k, r, q, o); err != nil {
+		return nanGreeks(), err
+	}
+
+	if !(h > 0) || h == inf(1) || b > UpAndOut ||
+		!(rebate >= 0) || rebate == inf(1) || timing > RebateAtExpiry {
+		return nanGreeks(), ErrBarrier
+	}
+
+	if o == Straddle {
+		c, _ := barrierGreeks(v, t, x, k, h, r, q, Call, b, rebate, timing, gamma)
+		p, _ := barrierGreeks(v, t, x, k, h, r, q, Put, b, 0, timing, gamma)
+		return addGreeks(c, p), nil
+	}
+
+	knocked := barrierKnocked(v, t, x, h, r, q, b)
+
+	if knocked || v == 0 || t < TimeFloor {
+		if knocked == b.in() {
+			return BSGreeks(v, t, x, k, r, q, o), nil
+		}
+		return knockedRebate(t, x, h, rebate, r, q, b, timing), nil
+	}
+
+	value := func(v, t, x, r dual) dual {
+		return barrierDual(v, t, x, r, k, h, q, o, b).add(rebateDual(v, t, x, r, h, rebate, q, b, timing))
+	}
+
+	p := value(constant(v), constant(t), variable(x), constant(r))
+	g := Greeks{
+		Price: p.v,
+		Delta: p.d,
+		Vega:  value(variable(v), constant(t), constant(x), constant(r)).d,
+		Theta: -value(constant(v), variable(t), constant(x), constant(r)).d,
+		Rho:   value(constant(v), constant(t), constant(x), variable(r)).d,
+	}
+
+	if gamma {
+		g.Gamma = barrierGamma(func(x float64) float64 {
+			return value(constant(v), constant(t), variable(x), constant(r)).d
+		}, x, h, b, g.Delta)
+	}
+
+	return g, nil
+}
+
+// barrierKnocked reports whether the barrier has been reached already, or
+// is reached along the deterministic path of the zero vol and expiry
+// limits
+func barrierKnocked(v, t, x, h, r, q float64, b BarrierType) bool {
+
+	knocked := x <= h
+	if b.up() {
+		knocked = x >= h
+	}
+	if !knocked && (v == 0 || t < TimeFloor) {
+		if f := Forward(x, r, q, t); b.up() {
+			knocked = f >= h
+		} else {
+			knocked = f <= h
+		}
+	}
+
+	return knocked
+}
+
+// knockedRebate returns the rebate of an option knocked out, or never
+// knocked in, with certainty. A knock out not yet on the barrier is
+// knocked on the forward path when it reaches h.
+func knockedRebate(t, x, h, rebate, r, q float64, b BarrierType, timing RebateTiming) Greeks {
+
+	if b.in() || timing == RebateAtExpiry {
+		p := discounted(rebate, r, t)
+		return Greeks{Price: p, Theta: r * p, Rho: -t * p}
+	}
+
+	hit := b.up() && x >= h || !b.up() && x <= h
+	if hit || r == q {
+		return Greeks{Price: rebate}
+	}
+
+	// paid at the hitting time log(h/x)/(r-q) of the forward, if before t
+	l := log(h / x)
+	if tau := l / (r - q); tau < t {
+		p := discounted(rebate, r, tau)
+		return Greeks{Price: p, Rho: q * l / (r - q) / (r - q) * p}
+	}
+
+	p := discounted(rebate, r, t)
+	return Greeks{Price: p, Rho: -t * p}
+}
+
+// barrierGamma differentiates the exact delta d numerically with the
+// stencils documented on BarrierGreeks
+func barrierGamma(d func(x float64) float64, x, h float64, b BarrierType, delta float64) float64 {
+
+	s := 1e-4 * x
+
+	if abs(x-h) > s {
+		return (d(x+s) - d(x-s)) / 2 / s
+	}
+
+	if b.up() {
+		s = -s
+	}
+
+	return (-3*delta + 4*d(x+s) - d(x+2*s)) / 2 / s
+}
+
+// barrierDual evaluates the Reiner-Rubinstein price of a call or put for
+// x strictly on the live side of h, v > 0 and t >= TimeFloor
+func barrierDual(v, t, x, r dual, k, h, q float64, o OptionType, b BarrierType) dual {
+
+	phi, eta := 1.0, 1.0
+	if o == Put {
+		phi = -1
+	}
+	if b.up() {
+		eta = -1
+	}
+
+	vs := v.mul(t.sqrt())
+	v2 := v.mul(v)
+	mu := v2.scale(-0.5).add(r).shift(-q).div(v2)
+	drift := mu.shift(1).mul(vs)
+
+	xq := x.mul(t.scale(-q).exp())
+	kr := t.mul(r).scale(-1).exp().scale(k)
+
+	hx := x.inv().scale(h)
+	p1, p2 := hx.pow(mu.shift(1).scale(2)), hx.pow(mu.scale(2))
+	one := constant(1)
+
+	// term is phi*xq*p1*N(s*z) - phi*kr*p2*N(s*z - s*vs)
+	term := func(z dual, s float64, p1, p2 dual) dual {
+		a := xq.mul(p1).mul(z.scale(s).normCDF())
+		c := kr.mul(p2).mul(z.sub(vs).scale(s).normCDF())
+		return a.sub(c).scale(phi)
+	}
+
+	// log(x/k) and log(h*h/(x*k)) are +Inf for a zero strike
+	x1, y1 := constant(inf(1)), constant(inf(1))
+	if k > 0 {
+		x1 = x.scale(1 / k).log().div(vs).add(drift)
+		y1 = x.inv().scale(h * h / k).log().div(vs).add(drift)
+	}
+	x2 := x.scale(1 / h).log().div(vs).add(drift)
+	y2 := hx.log().div(vs).add(drift)
+
+	A := term(x1, phi, one, one)
+	B := term(x2, phi, one, one)
+	C := term(y1, eta, p1, p2)
+	D := term(y2, eta, p1, p2)
+
+	above := k >= h
+
+	switch {
+	case o == Call && b == DownAndIn && above, o == Put && b == UpAndIn && !above:
+		return C
+	case o == Call && b == DownAndIn, o == Put && b == UpAndIn:
+		return A.sub(B).add(D)
+	case o == Call && b == UpAndIn && above, o == Put && b == DownAndIn && !above:
+		return A
+	case o == Call && b == UpAndIn, o == Put && b == DownAndIn:
+		return B.sub(C).add(D)
+	case o == Call && b == DownAndOut && above, o == Put && b == UpAndOut && !above:
+		return A.sub(C)
+	case o == Call && b == DownAndOut, o == Put && b == UpAndOut:
+		return B.sub(D)
+	case o == Call && b == UpAndOut && above, o == Put && b == DownAndOut && !above:
+		return constant(0)
+	}
+
+	// up-and-out call below the barrier, down-and-out put above it
+	return A.sub(B).add(C).sub(D)
+}
+
+// rebateDual evaluates the value of the rebate of a barrier option for x
+// strictly on the live side of h, v > 0 and t >= TimeFloor: the
+// discounted rebate times the probability of never hitting h for a knock
+// in, of hitting it for a knock out paid at expiry, and the expected
+// discount factor at the hitting time for one paid at hit
+func rebateDual(v, t, x, r dual, h, rebate, q float64, b BarrierType, timing RebateTiming) dual {
+
+	if rebate == 0 {
+		return constant(0)
+	}
+
+	eta := 1.0
+	if b.up() {
+		eta = -1
+	}
+
+	vs := v.mul(t.sqrt())
+	v2 := v.mul(v)
+	mu := v2.scale(-0.5).add(r).shift(-q).div(v2)
+	hx := x.inv().scale(h)
+	lhx := hx.log()
+
+	if b.in() || timing == RebateAtExpiry {
+		// z1 and z2 are x2 - vs and y2 - vs of the E term
+		z1 := lhx.scale(-1).div(vs).add(mu.mul(vs))
+		z2 := lhx.div(vs).add(mu.mul(vs))
+		noHit := z1.scale(eta).normCDF().sub(hx.pow(mu.scale(2)).mul(z2.scale(eta).normCDF()))
+		df := t.mul(r).scale(-1).exp().scale(rebate)
+		if b.in() {
+			return df.mul(noHit)
+		}
+		return df.sub(df.mul(noHit))
+	}
+
+	lambda := mu.mul(mu).add(r.scale(2).div(v2)).sqrt()
+	z := lhx.div(vs).add(lambda.mul(vs))
+	a := hx.pow(mu.add(lambda)).mul(z.scale(eta).normCDF())
+	c := hx.pow(mu.sub(lambda)).mul(z.sub(lambda.mul(vs).scale(2)).scale(eta).normCDF())
+
+	return a.add(c).scale(rebate)
+}
+
+// PriceOneTouch returns the price of a one touch paying payout, at hit or
+// at expiry by timing, if the spot reaches the barrier h before expiry.
+// The barrier is above the spot if h > x and below it otherwise, so
+// h == x has been touched. It is the rebate of a knock out option.
+func PriceOneTouch(v, t, x, h, payout, r, q float64, timing RebateTiming) (float64, error) {
+
+	b := DownAndOut
+	if h > x {
+		b = UpAndOut
+	}
+
+	return touchPrice(v, t, x, h, payout, r, q, b, timing)
+}
+
+// PriceNoTouch returns the price of a no touch paying payout at expiry if
+// the spot never reaches the barrier h, the rebate of a knock in option
+func PriceNoTouch(v, t, x, h, payout, r, q float64) (float64, error) {
+
+	b := DownAndIn
+	if h > x {
+		b = UpAndIn
+	}
+
+	return touchPrice(v, t, x, h, payout, r, q, b, RebateAtExpiry)
+}
+
+// touchPrice returns the value of the rebate alone of a barrier option
+func touchPrice(v, t, x, h, payout, r, q float64, b BarrierType, timing RebateTiming) (float64, error) {
+
+	if v < 0 {
+		return nan(), ErrNegVol
+	}
+	if err := checkParams(t, x, 0, r, q, Call); err != nil {
+		return nan(), err
+	}
+	if !(h > 0) || h == inf(1) || !(payout >= 0) || payout == inf(1) || timing > RebateAtExpiry {
+		return nan(), ErrBarrier
+	}
+
+	if knocked := barrierKnocked(v, t, x, h, r, q, b); knocked || v == 0 || t < TimeFloor {
+		if knocked == b.in() {
+			return 0, nil
+		}
+		return knockedRebate(t, x, h, payout, r, q, b, timing).Price, nil
+	}
+
+	return rebateDual(constant(v), constant(t), constant(x), constant(r), h, payout, q, b, timing).v, nil
+}
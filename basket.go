@@ -0,0 +1,68 @@
+package blackscholes
+
+import "github.com/pkg/errors"
+
+var ErrBasket = errors.New("Invalid basket")
+
+// PriceBasketTwoAsset returns the price of a European option on the
+// basket weights[0]*S0 + weights[1]*S1 of two lognormal assets with the
+// given correlation, by moment matching: the basket is replaced by a
+// lognormal asset with the same forward
+//
+//	M1 = w0*F0 + w1*F1
+//
+// and second moment
+//
+//	M2 = w0^2*F0^2*exp(v0^2*t) + w1^2*F1^2*exp(v1^2*t)
+//	     + 2*w0*w1*F0*F1*exp(rho*v0*v1*t)
+//
+// for the forwards Fi of the assets, which is priced by BSPrice at the
+// vol sqrt(log(M2/M1^2)/t). Negative weights are allowed, for spreads,
+// but the lognormal fit degrades as the basket's distribution moves away
+// from lognormal; for a spread Kirk's approximation is usually closer. A
+// basket forward that is not positive cannot be matched and, with a
+// correlation outside [-1, 1], non-finite or all zero weights, or
+// negative vols, returns ErrBasket.
+func PriceBasketTwoAsset(
+	weights [2]float64, spots, vols [2]float64, correlation, timeToExpiry, strike, r float64,
+	dividendYields [2]float64, optionType OptionType,
+) (float64, error) {
+
+	t, k, rho, o := timeToExpiry, strike, correlation, optionType
+
+	for i := range spots {
+		if err := checkParams(t, spots[i], k, r, dividendYields[i], o); err != nil {
+			return nan(), err
+		}
+		if vols[i] < 0 {
+			return nan(), ErrNegVol
+		}
+		if w := weights[i]; w != w || abs(w) == inf(1) {
+			return nan(), ErrBasket
+		}
+	}
+	if !(abs(rho) <= 1) || weights[0] == 0 && weights[1] == 0 {
+		return nan(), ErrBasket
+	}
+
+	var f [2]float64
+	for i := range f {
+		f[i] = weights[i] * Forward(spots[i], r, dividendYields[i], t)
+	}
+
+	m1 := f[0] + f[1]
+	if !(m1 > 0) {
+		return nan(), ErrBasket
+	}
+	if t == 0 {
+		return BSPrice(0, 0, m1, k, r, 0, o), nil
+	}
+
+	v0, v1 := vols[0], vols[1]
+	m2 := f[0]*f[0]*exp(v0*v0*t) + f[1]*f[1]*exp(v1*v1*t) + 2*f[0]*f[1]*exp(rho*v0*v1*t)
+
+	// a nearly riskless basket can round to m2 < m1*m1
+	v := sqrt(max(log(m2/m1/m1), 0) / t)
+
+	return BSPrice(v, t, m1*DiscountFactor(r, t), k, r, 0, o), nil
+}
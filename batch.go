@@ -0,0 +1,217 @@
+package blackscholes
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+)
+
+var ErrLengthMismatch = errors.New("Slice length mismatch")
+
+// IndexError is the error for a single element of a batch
+type IndexError struct {
+	Index int
+	Err   error
+}
+
+func (e IndexError) Error() string {
+	return fmt.Sprintf("index %d: %v", e.Index, e.Err)
+}
+
+// MultiError collects the per-element errors of a batch in index order
+type MultiError []IndexError
+
+func (m MultiError) Error() string {
+	switch len(m) {
+	case 0:
+		return "no errors"
+	case 1:
+		return m[0].Error()
+	}
+	return fmt.Sprintf("%d errors, first %v", len(m), m[0])
+}
+
+// Batch runs the rows of a batch over a bounded pool of goroutines.
+// Workers is the bound; at 0 or 1, as for the zero Batch that the batch
+// functions use, the rows run in order on the calling goroutine. Normal
+// is the distribution of NormCDFSlice and PriceChain, StdNormal if nil;
+// the rows of PriceInto and GreeksInto take the Normal of their inputs.
+type Batch struct {
+	Workers int
+	Normal  Normal
+}
+
+// workers returns the number of goroutines for n rows
+func (b Batch) workers(n int) int {
+
+	w := b.Workers
+	if w <= 0 {
+		w = 1
+	}
+	if w > n {
+		w = n
+	}
+
+	return w
+}
+
+// Run calls row for each index from 0 to n-1 and returns the errors of
+// the rows that fail in a MultiError in index order. A failed row does
+// not stop the others. With more than one worker each takes the next
+// unclaimed index until none are left, so row must be safe to call
+// concurrently for different indices, and results written by index are
+// aligned with the rows.
+func (b Batch) Run(n int, row func(i int) error) error {
+
+	w := b.workers(n)
+	if w <= 1 {
+		return eachRow(n, row)
+	}
+
+	var (
+		next = int64(-1)
+		errs MultiError
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+	)
+
+	wg.Add(w)
+	for g := 0; g < w; g++ {
+		go func() {
+			defer wg.Done()
+			var local MultiError
+			for i := int(atomic.AddInt64(&next, 1)); i < n; i = int(atomic.AddInt64(&next, 1)) {
+				if err := row(i); err != nil {
+					local = append(local, IndexError{Index: i, Err: err})
+				}
+			}
+			if local != nil {
+				mu.Lock()
+				errs = append(errs, local...)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if errs != nil {
+		sort.Slice(errs, func(a, c int) bool { return errs[a].Index < errs[c].Index })
+		return errs
+	}
+	return nil
+}
+
+// eachRow is Run on the calling goroutine. Taking row without letting it
+// escape, it lets the batch functions run sequentially without
+// allocating.
+func eachRow(n int, row func(i int) error) error {
+
+	var errs MultiError
+
+	for i := 0; i < n; i++ {
+		if err := row(i); err != nil {
+			errs = append(errs, IndexError{Index: i, Err: err})
+		}
+	}
+
+	if errs != nil {
+		return errs
+	}
+	return nil
+}
+
+// PriceInto writes the price of inputs[i] into dst[i]. Invalid rows are set
+// to NaN and reported in a MultiError; the other rows are still priced.
+// The rows are run by a zero Batch, so in order, and nothing is allocated
+// unless some row is invalid.
+func PriceInto(dst []float64, inputs []PriceParams) error {
+	return Batch{}.PriceInto(dst, inputs)
+}
+
+// PriceInto is PriceInto run over the goroutines of b
+func (b Batch) PriceInto(dst []float64, inputs []PriceParams) error {
+
+	if len(dst) != len(inputs) {
+		return ErrLengthMismatch
+	}
+
+	if b.workers(len(inputs)) <= 1 {
+		return eachRow(len(inputs), func(i int) error { return priceRow(dst, inputs, i) })
+	}
+	return b.Run(len(inputs), func(i int) error { return priceRow(dst, inputs, i) })
+}
+
+// priceRow is row i of PriceInto
+func priceRow(dst []float64, inputs []PriceParams, i int) error {
+
+	p := &inputs[i]
+	t, x, k, r, q := p.TimeToExpiry, p.Underlying, p.Strike, p.Rate, p.Dividend
+	if err := checkParams(t, x, k, r, q, p.Type); err != nil {
+		dst[i] = nan()
+		return err
+	}
+	dst[i] = priceKernel(p.model(), p.Vol, t, x, k, r, q, p.Type)
+
+	return nil
+}
+
+// GreeksInto is the Greeks analogue of PriceInto
+func GreeksInto(dst []Greeks, inputs []PriceParams) error {
+	return Batch{}.GreeksInto(dst, inputs)
+}
+
+// GreeksInto is GreeksInto run over the goroutines of b
+func (b Batch) GreeksInto(dst []Greeks, inputs []PriceParams) error {
+
+	if len(dst) != len(inputs) {
+		return ErrLengthMismatch
+	}
+
+	if b.workers(len(inputs)) <= 1 {
+		return eachRow(len(inputs), func(i int) error { return greeksRow(dst, inputs, i) })
+	}
+	return b.Run(len(inputs), func(i int) error { return greeksRow(dst, inputs, i) })
+}
+
+// greeksRow is row i of GreeksInto
+func greeksRow(dst []Greeks, inputs []PriceParams, i int) error {
+
+	p := &inputs[i]
+	t, x, k, r, q := p.TimeToExpiry, p.Underlying, p.Strike, p.Rate, p.Dividend
+	dfq, dfr, err := checkParamsDF(t, x, k, r, q, p.Type)
+	if err != nil {
+		dst[i] = nanGreeks()
+		return err
+	}
+	dst[i] = greeksKernel(p.model(), p.Vol, t, x, k, r, q, dfq, dfr, p.Type)
+
+	return nil
+}
+
+// ImpliedVolInto writes the implied vol of inputs[i] into dst[i], the
+// batch analogue of ImpliedVol. Failed rows are set to NaN and reported in
+// a MultiError; the other rows are still solved.
+func ImpliedVolInto(dst []float64, inputs []ImpliedVolParams) error {
+	return Batch{}.ImpliedVolInto(dst, inputs)
+}
+
+// ImpliedVolInto is ImpliedVolInto run over the goroutines of b
+func (b Batch) ImpliedVolInto(dst []float64, inputs []ImpliedVolParams) error {
+
+	if len(dst) != len(inputs) {
+		return ErrLengthMismatch
+	}
+
+	return b.Run(len(inputs), func(i int) error {
+		v, err := ImpliedVol(&inputs[i])
+		if err != nil {
+			dst[i] = nan()
+			return err
+		}
+		dst[i] = v
+		return nil
+	})
+}
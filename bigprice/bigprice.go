@@ -0,0 +1,323 @@
+// Package bigprice computes Black Scholes prices in arbitrary precision
+// floating point as a reference for validating the float64 functions of
+// package blackscholes. It is slow and meant for tests and tooling.
+//
+// The float64 inputs are taken as exact, so the result is the exact price
+// for those inputs rounded to the requested precision, not the price the
+// float64 functions should return bit for bit.
+package bigprice
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/pkg/errors"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+// DefaultPrec is a working precision of 256 bits, about 77 digits
+const DefaultPrec uint = 256
+
+// guardBits is the extra working precision of intermediate results
+const guardBits uint = 64
+
+var ErrNonFinite = errors.New("Non-finite input")
+
+func newFloat(prec uint) *big.Float {
+	return new(big.Float).SetPrec(prec)
+}
+
+// Price returns the Black Scholes price of the option in prec bits, with
+// the same argument conventions and boundary cases as bs.BSPrice except
+// that only t == 0, rather than t below bs.TimeFloor, prices at intrinsic
+func Price(v, t, x, k, r, q float64, o bs.OptionType, prec uint) (*big.Float, error) {
+
+	for _, f := range []float64{v, t, x, k, r, q} {
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, ErrNonFinite
+		}
+	}
+
+	if err := bs.CheckPriceParams(t, x, k, o); err != nil {
+		return nil, err
+	}
+
+	wp := prec + guardBits
+
+	tb := newFloat(wp).SetFloat64(t)
+	xq := Exp(newFloat(wp).Mul(newFloat(wp).SetFloat64(-q), tb))
+	xq.Mul(xq, newFloat(wp).SetFloat64(x))
+	kr := Exp(newFloat(wp).Mul(newFloat(wp).SetFloat64(-r), tb))
+	kr.Mul(kr, newFloat(wp).SetFloat64(k))
+
+	intr := intrinsic(xq, kr, o)
+
+	switch {
+	case v < 0:
+		// reflect in the intrinsic value like bs.BSPrice
+		p, err := Price(-v, t, x, k, r, q, o, wp)
+		if err != nil {
+			return nil, err
+		}
+		p.Sub(newFloat(wp).Mul(intr, big.NewFloat(2)), p)
+		return newFloat(prec).Set(p), nil
+	case v == 0, t == 0, x == 0, k == 0:
+		return newFloat(prec).Set(intr), nil
+	}
+
+	// d1 = (log(x/k) + (r - q + v^2/2) t) / (v sqrt(t)), d2 = d1 - v sqrt(t)
+	vb := newFloat(wp).SetFloat64(v)
+	vs := newFloat(wp).Sqrt(tb)
+	vs.Mul(vs, vb)
+
+	drift := newFloat(wp).Mul(vb, vb)
+	drift.Quo(drift, big.NewFloat(2))
+	drift.Add(drift, newFloat(wp).SetFloat64(r))
+	drift.Sub(drift, newFloat(wp).SetFloat64(q))
+	drift.Mul(drift, tb)
+
+	d1 := Log(newFloat(wp).Quo(newFloat(wp).SetFloat64(x), newFloat(wp).SetFloat64(k)))
+	d1.Add(d1, drift)
+	d1.Quo(d1, vs)
+	d2 := newFloat(wp).Sub(d1, vs)
+
+	call := newFloat(wp).Mul(xq, NormCDF(d1))
+	call.Sub(call, newFloat(wp).Mul(kr, NormCDF(d2)))
+
+	// put from N(-d) directly rather than parity to keep relative accuracy
+	put := newFloat(wp).Mul(kr, NormCDF(newFloat(wp).Neg(d2)))
+	put.Sub(put, newFloat(wp).Mul(xq, NormCDF(newFloat(wp).Neg(d1))))
+
+	switch o {
+	case bs.Call:
+		return newFloat(prec).Set(call), nil
+	case bs.Put:
+		return newFloat(prec).Set(put), nil
+	}
+
+	return newFloat(prec).Add(call, put), nil
+}
+
+func intrinsic(xq, kr *big.Float, o bs.OptionType) *big.Float {
+
+	p := newFloat(xq.Prec()).Sub(xq, kr)
+
+	switch o {
+	case bs.Call:
+		if p.Sign() < 0 {
+			p.SetInt64(0)
+		}
+	case bs.Put:
+		if p.Neg(p); p.Sign() < 0 {
+			p.SetInt64(0)
+		}
+	default:
+		p.Abs(p)
+	}
+
+	return p
+}
+
+// small reports whether term is negligible against sum at prec bits
+func small(term, sum *big.Float, prec uint) bool {
+	return term.Sign() == 0 || sum.Sign() != 0 && term.MantExp(nil) < sum.MantExp(nil)-int(prec)
+}
+
+// Exp returns e^x at the precision of x
+func Exp(x *big.Float) *big.Float {
+
+	prec := x.Prec()
+
+	if x.IsInf() {
+		if x.Sign() < 0 {
+			return newFloat(prec)
+		}
+		return newFloat(prec).SetInf(false)
+	}
+
+	// e^x = (e^(x/2^m))^(2^m) with |x/2^m| < 2^-8, and m extra bits
+	// to absorb the rounding error growth of the m squarings
+	m := 0
+	if e := x.MantExp(nil); x.Sign() != 0 && e > -8 {
+		m = e + 8
+	}
+	wp := prec + guardBits + uint(m)
+
+	y := newFloat(wp).SetMantExp(x, -m)
+	sum := newFloat(wp).SetInt64(1)
+	term := newFloat(wp).SetInt64(1)
+
+	for i := int64(1); ; i++ {
+		term.Mul(term, y)
+		term.Quo(term, newFloat(wp).SetInt64(i))
+		if small(term, sum, wp) {
+			break
+		}
+		sum.Add(sum, term)
+	}
+
+	for i := 0; i < m; i++ {
+		sum.Mul(sum, sum)
+	}
+
+	return newFloat(prec).Set(sum)
+}
+
+// Log returns the natural logarithm of x > 0 at the precision of x.
+// It panics for x <= 0.
+func Log(x *big.Float) *big.Float {
+
+	if x.Sign() <= 0 {
+		panic(errors.New("Log of non-positive number"))
+	}
+
+	prec := x.Prec()
+	wp := prec + guardBits
+
+	mant := newFloat(53)
+	e := x.MantExp(mant)
+	f, _ := mant.Float64()
+	y := newFloat(wp).SetFloat64(math.Log(f) + float64(e)*math.Ln2)
+
+	// Halley's iteration y += 2 (x - e^y) / (x + e^y) triples the
+	// correct bits each step
+	a := newFloat(wp).Set(x)
+	for i := 0; i < 64; i++ {
+		ey := Exp(y)
+		d := newFloat(wp).Sub(a, ey)
+		d.Quo(d, newFloat(wp).Add(a, ey))
+		d.Mul(d, big.NewFloat(2))
+		y.Add(y, d)
+		if small(d, y, wp) || y.Sign() == 0 && d.MantExp(nil) < -int(wp) {
+			break
+		}
+	}
+
+	return newFloat(prec).Set(y)
+}
+
+// Pi returns pi to prec bits by Machin's formula
+// pi = 16 atan(1/5) - 4 atan(1/239)
+func Pi(prec uint) *big.Float {
+
+	wp := prec + guardBits
+
+	atanInv := func(n int64) *big.Float {
+		n2 := newFloat(wp).SetInt64(n * n)
+		pow := newFloat(wp).Quo(newFloat(wp).SetInt64(1), newFloat(wp).SetInt64(n))
+		sum := newFloat(wp).Set(pow)
+		for i := int64(1); ; i++ {
+			pow.Quo(pow, n2)
+			term := newFloat(wp).Quo(pow, newFloat(wp).SetInt64(2*i+1))
+			if small(term, sum, wp) {
+				break
+			}
+			if i%2 == 1 {
+				sum.Sub(sum, term)
+			} else {
+				sum.Add(sum, term)
+			}
+		}
+		return sum
+	}
+
+	pi := atanInv(5)
+	pi.Mul(pi, big.NewFloat(16))
+	pi.Sub(pi, newFloat(wp).Mul(atanInv(239), big.NewFloat(4)))
+
+	return newFloat(prec).Set(pi)
+}
+
+// NormPDF returns the standard normal density at the precision of x
+func NormPDF(x *big.Float) *big.Float {
+
+	prec := x.Prec()
+	wp := prec + guardBits
+
+	y := newFloat(wp).Mul(x, x)
+	y.Quo(y, big.NewFloat(-2))
+
+	twoPi := Pi(wp)
+	twoPi.Mul(twoPi, big.NewFloat(2))
+
+	p := Exp(y)
+	return newFloat(prec).Quo(p, twoPi.Sqrt(twoPi))
+}
+
+// cfThreshold is the |x| above which NormCDF uses the continued fraction
+const cfThreshold float64 = 5
+
+// NormCDF returns the standard normal CDF at the precision of x. For
+// |x| <= 5 it sums the series N(x) = 1/2 + n(x) (x + x^3/3 + x^5/(3 5) + ...),
+// whose terms all have the sign of x, with x^2 / (2 log 2) extra working
+// bits covering the cancellation against 1/2 in the lower tail. Further
+// out it uses the continued fraction of upperTail.
+func NormCDF(x *big.Float) *big.Float {
+
+	prec := x.Prec()
+
+	if x.IsInf() {
+		if x.Sign() < 0 {
+			return newFloat(prec)
+		}
+		return newFloat(prec).SetInt64(1)
+	}
+
+	xf, _ := x.Float64()
+
+	if math.Abs(xf) > cfThreshold {
+		q := upperTail(newFloat(prec + guardBits).Abs(x))
+		if x.Sign() < 0 {
+			return newFloat(prec).Set(q)
+		}
+		return newFloat(prec).Sub(big.NewFloat(1), q)
+	}
+
+	wp := prec + guardBits + uint(xf*xf/(2*math.Ln2))
+
+	xw := newFloat(wp).Set(x)
+	x2 := newFloat(wp).Mul(xw, xw)
+	term := newFloat(wp).Set(xw)
+	sum := newFloat(wp).Set(xw)
+
+	for i := int64(3); !small(term, sum, wp); i += 2 {
+		term.Mul(term, x2)
+		term.Quo(term, newFloat(wp).SetInt64(i))
+		sum.Add(sum, term)
+	}
+
+	sum.Mul(sum, NormPDF(xw))
+	sum.Add(sum, big.NewFloat(0.5))
+
+	return newFloat(prec).Set(sum)
+}
+
+// upperTail returns 1 - N(x) for x > 0 from Laplace's continued fraction
+// n(x) / (x + 1/(x + 2/(x + 3/(x + ...)))), evaluated backwards from a
+// depth that is doubled until two evaluations agree to the precision of x
+func upperTail(x *big.Float) *big.Float {
+
+	wp := x.Prec() + guardBits
+
+	cf := func(depth int64) *big.Float {
+		d := newFloat(wp).Set(x)
+		for n := depth; n > 0; n-- {
+			d.Quo(newFloat(wp).SetInt64(n), d)
+			d.Add(d, x)
+		}
+		return d
+	}
+
+	prev := cf(32)
+	for depth := int64(64); ; depth *= 2 {
+		d := cf(depth)
+		if small(newFloat(wp).Sub(d, prev), d, x.Prec()) {
+			prev = d
+			break
+		}
+		prev = d
+	}
+
+	return newFloat(x.Prec()).Quo(NormPDF(newFloat(wp).Set(x)), prev)
+}
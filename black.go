@@ -0,0 +1,251 @@
+package blackscholes
+
+import (
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+var ErrNegVariance = errors.New("Negative total variance")
+
+// NormalizedBlack returns the undiscounted Black value of an option
+// divided by the forward, as a function of the log-moneyness
+// x = log(f/k) and the total vol s = v*sqrt(t):
+//
+//	call = N(x/s + s/2) - exp(-x)*N(x/s - s/2)
+//	put  = exp(-x)*N(s/2 - x/s) - N(-x/s - s/2)
+//
+// A Straddle is the sum of the two. The price of an option is
+// df*f*NormalizedBlack(log(f/k), v*sqrt(t), o). At s == 0 it is the
+// intrinsic value, and a negative or NaN s returns NaN.
+func NormalizedBlack(logMoneyness, totalVol float64, optionType OptionType) float64 {
+
+	x, s, o := logMoneyness, totalVol, optionType
+
+	if !(s >= 0) || o != Call && o != Put && o != Straddle {
+		return nan()
+	}
+
+	ex := exp(-x)
+	if s == 0 {
+		return payoff(1, ex, o)
+	}
+
+	d1 := x/s + s/2
+	d2 := d1 - s
+
+	switch o {
+	case Call:
+		return NormCDF(d1) - ex*NormCDF(d2)
+	case Put:
+		return ex*NormCDF(-d2) - NormCDF(-d1)
+	}
+	return NormCDF(d1) - ex*NormCDF(d2) + ex*NormCDF(-d2) - NormCDF(-d1)
+}
+
+// NormalizedBlackVega is the derivative of NormalizedBlack in the total
+// vol, n(x/s + s/2) for calls and puts and twice that for a Straddle
+func NormalizedBlackVega(logMoneyness, totalVol float64, optionType OptionType) float64 {
+
+	x, s, o := logMoneyness, totalVol, optionType
+
+	if !(s >= 0) || o != Call && o != Put && o != Straddle {
+		return nan()
+	}
+
+	// at s == 0, n(d1) is n(0) at the money and 0 away from it
+	d1 := s / 2
+	if x != 0 {
+		d1 += x / s
+	}
+
+	vega := NormPDF(d1)
+	if o == Straddle {
+		return 2 * vega
+	}
+	return vega
+}
+
+// PriceTotalVariance returns the Black Scholes price as a function of the
+// total variance w = v*v*t and the discount factors exp(-r*t) and
+// exp(-q*t), through NormalizedBlack. Only w enters the price, so there
+// are no special cases at t == 0. The discount factors are checked as in
+// PriceFromForward.
+func PriceTotalVariance(
+	totalVariance, spot, strike, discountFactorRate, discountFactorDiv float64, optionType OptionType,
+) (float64, error) {
+	return DiscountConfig{}.PriceTotalVariance(
+		totalVariance, spot, strike, discountFactorRate, discountFactorDiv, optionType,
+	)
+}
+
+// PriceTotalVariance is PriceTotalVariance with the discount factors
+// checked under c
+func (c DiscountConfig) PriceTotalVariance(
+	totalVariance, spot, strike, discountFactorRate, discountFactorDiv float64, optionType OptionType,
+) (float64, error) {
+
+	w, x, k, dfr, dfq, o := totalVariance, spot, strike, discountFactorRate, discountFactorDiv, optionType
+
+	if err := c.checkTotalVariance(x, k, dfr, dfq, o); err != nil {
+		return nan(), err
+	}
+	if !(w >= 0) || w == inf(1) {
+		return nan(), ErrNegVariance
+	}
+
+	if x == 0 || k == 0 {
+		return dfr * payoff(x*dfq/dfr, k, o), nil
+	}
+
+	return x * dfq * NormalizedBlack(log(x*dfq/dfr/k), sqrt(w), o), nil
+}
+
+// ImpliedTotalVariance returns the total variance at which
+// PriceTotalVariance gives premium, solving for the total vol by
+// bisection to 1e-15. A premium at the intrinsic value has zero variance,
+// and one below it or at or above the upper bound, the discounted spot
+// for a call, the discounted strike for a put and their sum for a
+// Straddle, returns ErrArbitrage.
+// Zero spots and strikes have no vol dependence and return 0.
+func ImpliedTotalVariance(
+	premium, spot, strike, discountFactorRate, discountFactorDiv float64, optionType OptionType,
+) (float64, error) {
+	return DiscountConfig{}.ImpliedTotalVariance(
+		premium, spot, strike, discountFactorRate, discountFactorDiv, optionType,
+	)
+}
+
+// ImpliedTotalVariance is ImpliedTotalVariance with the discount factors
+// checked under dc
+func (dc DiscountConfig) ImpliedTotalVariance(
+	premium, spot, strike, discountFactorRate, discountFactorDiv float64, optionType OptionType,
+) (float64, error) {
+
+	p, x, k, dfr, dfq, o := premium, spot, strike, discountFactorRate, discountFactorDiv, optionType
+
+	if err := dc.checkTotalVariance(x, k, dfr, dfq, o); err != nil {
+		return nan(), err
+	}
+	if !(p >= 0) {
+		return nan(), ErrNegPremium
+	}
+
+	if x == 0 || k == 0 {
+		return 0, nil
+	}
+
+	xq, kr := x*dfq, k*dfr
+	ub := xq
+	switch o {
+	case Put:
+		ub = kr
+	case Straddle:
+		ub += kr
+	}
+
+	intr := payoff(xq, kr, o)
+	switch {
+	case p < intr || p >= ub:
+		return nan(), ErrArbitrage
+	case p == intr:
+		return 0, nil
+	}
+
+	lm, c := log(xq/kr), p/xq
+	f := func(s float64) float64 { return NormalizedBlack(lm, s, o) - c }
+	hi := 1.0
+	for f(hi) < 0 {
+		hi *= 2
+	}
+	s := bisect(f, 0, hi, 1e-15)
+
+	return s * s, nil
+}
+
+func (c DiscountConfig) checkTotalVariance(x, k, dfr, dfq float64, o OptionType) error {
+	if err := CheckPriceParams(0, x, k, o); err != nil {
+		return err
+	}
+	if err := c.checkDiscountFactor(dfr); err != nil {
+		return err
+	}
+	return c.checkDiscountFactor(dfq)
+}
+
+// VarianceVega returns the derivative of the price in the variance v*v,
+// Vega/(2*v), see BSVarianceVega
+func VarianceVega(pars *PriceParams) (float64, error) {
+
+	if pars == nil {
+		return nan(), ErrNilPtrArg
+	}
+
+	v, t, x, k, r, q := GetFloatPriceParams(pars)
+
+	if err := checkParams(t, x, k, r, q, pars.Type); err != nil {
+		return nan(), err
+	}
+	if v < 0 {
+		return nan(), ErrNegVol
+	}
+
+	return BSVarianceVega(v, t, x, k, r, q, pars.Type), nil
+}
+
+// TotalVarianceVega returns the derivative of the price in the total
+// variance w = v*v*t, see BSTotalVarianceVega
+func TotalVarianceVega(pars *PriceParams) (float64, error) {
+
+	if pars == nil {
+		return nan(), ErrNilPtrArg
+	}
+
+	v, t, x, k, r, q := GetFloatPriceParams(pars)
+
+	if err := checkParams(t, x, k, r, q, pars.Type); err != nil {
+		return nan(), err
+	}
+	if v < 0 {
+		return nan(), ErrNegVol
+	}
+
+	return BSTotalVarianceVega(v, t, x, k, r, q, pars.Type), nil
+}
+
+// BSVarianceVega is t*BSTotalVarianceVega, the derivative in v*v at
+// fixed t, and NaN for negative vols
+func BSVarianceVega(v, t, x, k, r, q float64, o OptionType) float64 {
+	tv := BSTotalVarianceVega(v, t, x, k, r, q, o)
+	if t == 0 && !math.IsNaN(tv) {
+		return 0
+	}
+	return t * tv
+}
+
+// BSTotalVarianceVega returns exp(-q*t)*x*NormalizedBlackVega(m, s, o)/(2*s)
+// for the log-moneyness m = log(f/k) and total vol s = v*sqrt(t). As s
+// falls to 0 it goes to 0 away from the money and to +Inf at it, which
+// are its values at zero vol and below TimeFloor. Negative vols return
+// NaN.
+func BSTotalVarianceVega(v, t, x, k, r, q float64, o OptionType) float64 {
+
+	if v < 0 || checkParams(t, x, k, r, q, o) != nil {
+		return nan()
+	}
+
+	if x == 0 || k == 0 {
+		return 0
+	}
+
+	xq := discounted(x, q, t)
+	if v == 0 || t < TimeFloor {
+		if xq != discounted(k, r, t) {
+			return 0
+		}
+		return inf(1)
+	}
+
+	s := v * sqrt(t)
+	return xq * NormalizedBlackVega(log(x/k)+(r-q)*t, s, o) / 2 / s
+}
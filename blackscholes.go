@@ -1,7 +1,9 @@
 package blackscholes
 
 import (
+	"fmt"
 	"math"
+	"strings"
 
 	"github.com/pkg/errors"
 )
@@ -14,16 +16,58 @@ const (
 	Straddle = OptionType('s')
 )
 
+// ParseOptionType parses "call", "put" or "straddle", or their first
+// letters, in any case
+func ParseOptionType(s string) (OptionType, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "c", "call":
+		return Call, nil
+	case "p", "put":
+		return Put, nil
+	case "s", "straddle":
+		return Straddle, nil
+	}
+	return 0, ErrUnknownOptionType
+}
+
+func (o OptionType) String() string {
+	switch o {
+	case Call:
+		return "call"
+	case Put:
+		return "put"
+	case Straddle:
+		return "straddle"
+	}
+	return fmt.Sprintf("OptionType(%q)", rune(o))
+}
+
 const InvSqrt2PI float64 = 1.0 / math.Sqrt2 / math.SqrtPi
 
+// MaxDiscountExponent bounds |r*t| and |q*t|; beyond it the discount
+// factors under- or overflow float64.
+const MaxDiscountExponent float64 = 700
+
+// TimeFloor is the time to expiry below which options are treated as
+// expired: prices equal intrinsic value and greeks take their zero-vol limits.
+// The TimeFloor of PriceParams and ImpliedVolParams raises it for a call.
+const TimeFloor float64 = 1e-10
+
 var (
+	ErrArbitrage         = errors.New("Premium outside arbitrage bounds")
 	ErrNegPremium        = errors.New("Negative option premium")
 	ErrNegPrice          = errors.New("Negative underlying price")
 	ErrNegStrike         = errors.New("Negative strike")
+	ErrNegVol            = errors.New("Negative volatility")
 	ErrNegTimeToExp      = errors.New("Negative time to expiry")
 	ErrUnknownOptionType = errors.New("Unknown option type")
 	ErrNilPtrArg         = errors.New("Nil pointer argument")
 	ErrNoncovergence     = errors.New("Did not converge")
+	ErrDiscountExponent  = errors.New("Discount exponent out of range")
+	ErrZeroPaths         = errors.New("Zero simulation paths")
+	ErrZeroVolAtMoney    = errors.New("Zero volatility at the money")
+	ErrZeroTimeToExp     = errors.New("Zero time to expiry")
+	ErrZeroPremium       = errors.New("Zero option premium")
 )
 
 var (
@@ -32,10 +76,15 @@ var (
 	inf  func(int) float64              = math.Inf
 	log  func(float64) float64          = math.Log
 	max  func(float64, float64) float64 = math.Max
+	min  func(float64, float64) float64 = math.Min
 	nan  func() float64                 = math.NaN
+	pow  func(float64, float64) float64 = math.Pow
 	sqrt func(float64) float64          = math.Sqrt
 )
 
+// PriceParams are the inputs of Price, the greeks taking PriceParams and
+// PriceAndGreeks. Normal and TimeFloor set the distribution and the
+// time floor for the call.
 type PriceParams struct {
 	Vol          float64
 	TimeToExpiry float64
@@ -44,6 +93,50 @@ type PriceParams struct {
 	Rate         float64
 	Dividend     float64
 	Type         OptionType
+	Normal       Normal // nil for StdNormal
+
+	// TimeFloor, if above the package TimeFloor, is the time to expiry
+	// below which the option is treated as expired. 0 keeps TimeFloor.
+	TimeFloor float64
+
+	// NearExpiryTotalVol is the crossover to the near expiry forms for
+	// the call. 0 keeps NearExpiryTotalVol and a negative value turns the
+	// near expiry forms off.
+	NearExpiryTotalVol float64
+}
+
+func (pars *PriceParams) model() model {
+	return model{n: pars.Normal, floor: pars.TimeFloor, crossover: pars.NearExpiryTotalVol}
+}
+
+// model holds the per call settings of the kernels behind the BS
+// functions, priceKernel, deltaKernel and so on: the Normal, nil for
+// StdNormal, the time floor and the near expiry crossover. The zero
+// model is that of the BS functions.
+type model struct {
+	n         Normal
+	floor     float64
+	crossover float64
+}
+
+// expired reports whether t is below the time floor of m, which is
+// never below TimeFloor
+func (m model) expired(t float64) bool {
+	return t < TimeFloor || t < m.floor
+}
+
+// nearExpiry is nearExpiry at the crossover of m
+func (m model) nearExpiry(v, t float64) bool {
+	return m.belowCrossover(v * sqrt(t))
+}
+
+// belowCrossover reports whether the total vol s is below the near
+// expiry crossover of m
+func (m model) belowCrossover(s float64) bool {
+	if m.crossover == 0 {
+		return s < NearExpiryTotalVol
+	}
+	return s < m.crossover
 }
 
 func Price(pars *PriceParams) (price float64, err error) {
@@ -54,11 +147,11 @@ func Price(pars *PriceParams) (price float64, err error) {
 
 	v, t, x, k, r, q := GetFloatPriceParams(pars)
 
-	if err = CheckPriceParams(t, x, k, pars.Type); err != nil {
+	if err = checkParams(t, x, k, r, q, pars.Type); err != nil {
 		return nan(), err
 	}
 
-	price = BSPriceNoErrorCheck(v, t, x, k, r, q, pars.Type)
+	price = priceKernel(pars.model(), v, t, x, k, r, q, pars.Type)
 	return
 }
 
@@ -70,11 +163,11 @@ func Delta(pars *PriceParams) (delta float64, err error) {
 
 	v, t, x, k, r, q := GetFloatPriceParams(pars)
 
-	if err = CheckPriceParams(t, x, k, pars.Type); err != nil {
+	if err = checkParams(t, x, k, r, q, pars.Type); err != nil {
 		return nan(), err
 	}
 
-	delta = BSDelta(v, t, x, k, r, q, pars.Type)
+	delta = deltaKernel(pars.model(), v, t, x, k, r, q, pars.Type)
 
 	return
 }
@@ -87,11 +180,11 @@ func Gamma(pars *PriceParams) (gamma float64, err error) {
 
 	v, t, x, k, r, q := GetFloatPriceParams(pars)
 
-	if err = CheckPriceParams(t, x, k, pars.Type); err != nil {
+	if err = checkParams(t, x, k, r, q, pars.Type); err != nil {
 		return nan(), err
 	}
 
-	gamma = BSGamma(v, t, x, k, r, q, pars.Type)
+	gamma = gammaKernel(pars.model(), v, t, x, k, r, q, pars.Type)
 	return
 }
 
@@ -103,11 +196,11 @@ func Vega(pars *PriceParams) (vega float64, err error) {
 
 	v, t, x, k, r, q := GetFloatPriceParams(pars)
 
-	if err = CheckPriceParams(t, x, k, pars.Type); err != nil {
+	if err = checkParams(t, x, k, r, q, pars.Type); err != nil {
 		return nan(), err
 	}
 
-	vega = BSVega(v, t, x, k, r, q, pars.Type)
+	vega = vegaKernel(pars.model(), v, t, x, k, r, q, pars.Type)
 
 	return
 }
@@ -120,11 +213,271 @@ func Theta(pars *PriceParams) (theta float64, err error) {
 
 	v, t, x, k, r, q := GetFloatPriceParams(pars)
 
-	if err = CheckPriceParams(t, x, k, pars.Type); err != nil {
+	if err = checkParams(t, x, k, r, q, pars.Type); err != nil {
+		return nan(), err
+	}
+
+	theta = thetaKernel(pars.model(), v, t, x, k, r, q, pars.Type)
+
+	return
+}
+
+// Rho returns the derivative of the price in the interest rate, see BSRho
+func Rho(pars *PriceParams) (rho float64, err error) {
+
+	if pars == nil {
+		return nan(), ErrNilPtrArg
+	}
+
+	v, t, x, k, r, q := GetFloatPriceParams(pars)
+
+	if err = checkParams(t, x, k, r, q, pars.Type); err != nil {
+		return nan(), err
+	}
+
+	rho = rhoKernel(pars.model(), v, t, x, k, r, q, pars.Type)
+
+	return
+}
+
+// Epsilon returns the derivative of the price in the dividend yield, see
+// BSEpsilon
+func Epsilon(pars *PriceParams) (epsilon float64, err error) {
+
+	if pars == nil {
+		return nan(), ErrNilPtrArg
+	}
+
+	v, t, x, k, r, q := GetFloatPriceParams(pars)
+
+	if err = checkParams(t, x, k, r, q, pars.Type); err != nil {
+		return nan(), err
+	}
+
+	epsilon = epsilonKernel(pars.model(), v, t, x, k, r, q, pars.Type)
+
+	return
+}
+
+// Vanna returns the derivative of the delta in the vol, see BSVanna
+func Vanna(pars *PriceParams) (vanna float64, err error) {
+
+	if pars == nil {
+		return nan(), ErrNilPtrArg
+	}
+
+	v, t, x, k, r, q := GetFloatPriceParams(pars)
+
+	if err = checkParams(t, x, k, r, q, pars.Type); err != nil {
+		return nan(), err
+	}
+
+	vanna = vannaKernel(pars.model(), v, t, x, k, r, q, pars.Type)
+
+	return
+}
+
+// Volga returns the second derivative of the price in the vol, see
+// BSVolga
+func Volga(pars *PriceParams) (volga float64, err error) {
+
+	if pars == nil {
+		return nan(), ErrNilPtrArg
+	}
+
+	v, t, x, k, r, q := GetFloatPriceParams(pars)
+
+	if err = checkParams(t, x, k, r, q, pars.Type); err != nil {
+		return nan(), err
+	}
+
+	volga = volgaKernel(pars.model(), v, t, x, k, r, q, pars.Type)
+
+	return
+}
+
+// Ultima returns the third derivative of the price in the vol, see
+// BSUltima
+func Ultima(pars *PriceParams) (ultima float64, err error) {
+
+	if pars == nil {
+		return nan(), ErrNilPtrArg
+	}
+
+	v, t, x, k, r, q := GetFloatPriceParams(pars)
+
+	if err = checkParams(t, x, k, r, q, pars.Type); err != nil {
+		return nan(), err
+	}
+
+	ultima = ultimaKernel(pars.model(), v, t, x, k, r, q, pars.Type)
+
+	return
+}
+
+// Veta returns the decay of the vega per unit of time, see BSVeta
+func Veta(pars *PriceParams) (veta float64, err error) {
+
+	if pars == nil {
+		return nan(), ErrNilPtrArg
+	}
+
+	v, t, x, k, r, q := GetFloatPriceParams(pars)
+
+	if err = checkParams(t, x, k, r, q, pars.Type); err != nil {
+		return nan(), err
+	}
+
+	veta = vetaKernel(pars.model(), v, t, x, k, r, q, pars.Type)
+
+	return
+}
+
+// Lambda returns the elasticity of the price in the underlying,
+// delta*x/price, the percentage change in the price for a percentage
+// change in the underlying. A zero price, as out of the money at zero
+// vol, returns ErrZeroPremium.
+func Lambda(pars *PriceParams) (lambda float64, err error) {
+
+	if pars == nil {
+		return nan(), ErrNilPtrArg
+	}
+
+	v, t, x, k, r, q := GetFloatPriceParams(pars)
+
+	if err = checkParams(t, x, k, r, q, pars.Type); err != nil {
+		return nan(), err
+	}
+
+	price := priceKernel(pars.model(), v, t, x, k, r, q, pars.Type)
+	if price == 0 {
+		return nan(), ErrZeroPremium
+	}
+
+	lambda = deltaKernel(pars.model(), v, t, x, k, r, q, pars.Type) * x / price
+
+	return
+}
+
+// DualDelta returns the derivative of the price in the strike, see
+// BSDualDelta
+func DualDelta(pars *PriceParams) (dualDelta float64, err error) {
+
+	if pars == nil {
+		return nan(), ErrNilPtrArg
+	}
+
+	v, t, x, k, r, q := GetFloatPriceParams(pars)
+
+	if err = checkParams(t, x, k, r, q, pars.Type); err != nil {
+		return nan(), err
+	}
+
+	dualDelta = dualDeltaKernel(pars.model(), v, t, x, k, r, q, pars.Type)
+
+	return
+}
+
+// DualGamma returns the second derivative of the price in the strike,
+// see BSDualGamma
+func DualGamma(pars *PriceParams) (dualGamma float64, err error) {
+
+	if pars == nil {
+		return nan(), ErrNilPtrArg
+	}
+
+	v, t, x, k, r, q := GetFloatPriceParams(pars)
+
+	if err = checkParams(t, x, k, r, q, pars.Type); err != nil {
+		return nan(), err
+	}
+
+	dualGamma = dualGammaKernel(pars.model(), v, t, x, k, r, q, pars.Type)
+
+	return
+}
+
+// Charm returns the decay of the delta per unit of time, see BSCharm
+func Charm(pars *PriceParams) (charm float64, err error) {
+
+	if pars == nil {
+		return nan(), ErrNilPtrArg
+	}
+
+	v, t, x, k, r, q := GetFloatPriceParams(pars)
+
+	if err = checkParams(t, x, k, r, q, pars.Type); err != nil {
+		return nan(), err
+	}
+
+	charm = charmKernel(pars.model(), v, t, x, k, r, q, pars.Type)
+
+	return
+}
+
+// Speed returns the derivative of the gamma in the underlying, see
+// BSSpeed
+func Speed(pars *PriceParams) (speed float64, err error) {
+
+	if pars == nil {
+		return nan(), ErrNilPtrArg
+	}
+
+	v, t, x, k, r, q := GetFloatPriceParams(pars)
+
+	if err = checkParams(t, x, k, r, q, pars.Type); err != nil {
+		return nan(), err
+	}
+
+	speed = speedKernel(pars.model(), v, t, x, k, r, q, pars.Type)
+
+	return
+}
+
+// Zomma returns the derivative of the gamma in the vol, see BSZomma, or
+// ErrZeroVolAtMoney where the gamma is infinite at zero vol
+func Zomma(pars *PriceParams) (zomma float64, err error) {
+
+	if pars == nil {
+		return nan(), ErrNilPtrArg
+	}
+
+	v, t, x, k, r, q := GetFloatPriceParams(pars)
+
+	if err = checkParams(t, x, k, r, q, pars.Type); err != nil {
+		return nan(), err
+	}
+
+	zomma = zommaKernel(pars.model(), v, t, x, k, r, q, pars.Type)
+	if zomma != zomma {
+		return nan(), ErrZeroVolAtMoney
+	}
+
+	return
+}
+
+// Color returns the decay of the gamma per unit of time, see BSColor. It
+// returns ErrZeroTimeToExp at expiry and ErrZeroVolAtMoney where the
+// gamma is infinite at zero vol.
+func Color(pars *PriceParams) (color float64, err error) {
+
+	if pars == nil {
+		return nan(), ErrNilPtrArg
+	}
+
+	v, t, x, k, r, q := GetFloatPriceParams(pars)
+
+	if err = checkParams(t, x, k, r, q, pars.Type); err != nil {
 		return nan(), err
 	}
+	if t == 0 {
+		return nan(), ErrZeroTimeToExp
+	}
 
-	theta = BSTheta(v, t, x, k, r, q, pars.Type)
+	color = colorKernel(pars.model(), v, t, x, k, r, q, pars.Type)
+	if color != color {
+		return nan(), ErrZeroVolAtMoney
+	}
 
 	return
 }
@@ -140,9 +493,9 @@ func AtmApprox(v, t, x, q float64, o OptionType) float64 {
 
 	switch o {
 	case Call, Put:
-		return exp(-q*t) * v * x * sqrt(t) * InvSqrt2PI
+		return discounted(x, q, t) * v * sqrt(t) * InvSqrt2PI
 	case Straddle:
-		return 2 * exp(-q*t) * v * x * sqrt(t) * InvSqrt2PI
+		return 2 * discounted(x, q, t) * v * sqrt(t) * InvSqrt2PI
 	}
 
 	return nan()
@@ -167,6 +520,35 @@ func CheckPriceParams(t, x, k float64, o OptionType) error {
 	return nil
 }
 
+// CheckDiscountExponents checks whether r*t and q*t are small enough in
+// magnitude for the discount factors to be representable, returning a
+// *DiscountExponentError naming the first that is not
+func CheckDiscountExponents(t, r, q float64) error {
+	switch {
+	case abs(r*t) > MaxDiscountExponent:
+		return &DiscountExponentError{Term: DiscountRate, Exponent: r * t}
+	case abs(q*t) > MaxDiscountExponent:
+		return &DiscountExponentError{Term: DiscountDividend, Exponent: q * t}
+	}
+	return nil
+}
+
+func checkParams(t, x, k, r, q float64, o OptionType) error {
+	if err := CheckPriceParams(t, x, k, o); err != nil {
+		return err
+	}
+	return checkExponents(t, x, k, r, q)
+}
+
+// checkParamsDF is checkParams returning the discount factors exp(-q*t)
+// and exp(-r*t) it computes, see checkedDiscountFactors
+func checkParamsDF(t, x, k, r, q float64, o OptionType) (dfq, dfr float64, err error) {
+	if err = CheckPriceParams(t, x, k, o); err != nil {
+		return nan(), nan(), err
+	}
+	return checkedDiscountFactors(t, x, k, r, q)
+}
+
 func GetFloatPriceParams(pars *PriceParams) (v, t, x, k, r, q float64) {
 	if pars == nil {
 		panic(ErrNilPtrArg)
@@ -186,7 +568,7 @@ func GetFloatPriceParams(pars *PriceParams) (v, t, x, k, r, q float64) {
 // o = option type (Call, Put, Straddle)
 func BSPrice(v, t, x, k, r, q float64, o OptionType) float64 {
 
-	if CheckPriceParams(t, x, k, o) != nil {
+	if checkParams(t, x, k, r, q, o) != nil {
 		return nan()
 	}
 
@@ -194,33 +576,46 @@ func BSPrice(v, t, x, k, r, q float64, o OptionType) float64 {
 }
 
 func BSPriceNoErrorCheck(v, t, x, k, r, q float64, o OptionType) float64 {
+	return priceKernel(model{}, v, t, x, k, r, q, o)
+}
+
+// priceKernel is BSPriceNoErrorCheck through n
+func priceKernel(m model, v, t, x, k, r, q float64, o OptionType) float64 {
 
 	if v < 0 {
-		p := BSPrice(-v, t, x, k, r, q, o)
+		p := priceKernel(m, -v, t, x, k, r, q, o)
 		i := Intrinsic(t, x, k, r, q, o)
 		e := p - i
 		return i - e
 	}
 
 	switch {
+	case t == 0:
+		return PriceZeroTime(x, k, o)
 	case x == 0:
 		return ZeroUnderlyingBSPrice(t, k, r, o)
 	case k == 0:
 		return ZeroStrikeBSPrice(t, x, q, o)
-	case v == 0:
+	case v == 0, m.expired(t):
 		return Intrinsic(t, x, k, r, q, o)
+	case m.nearExpiry(v, t):
+		return priceNearExpiry(m.n, v, t, x, k, r, q, o)
 	}
 
-	d1 := D1(v, t, x, k, r, q)
-	d2 := D2fromD1(d1, v, t)
-	Nd1, Nd2 := NormCDF(d1), NormCDF(d2)
-	x, k = exp(-q*t)*x, exp(-r*t)*k
+	sqrtt := sqrt(t)
+	d1 := (log(x/k) + (r-q+0.5*v*v)*t) / v / sqrtt
+	d2 := d1 - v*sqrtt
+	x, k = discounted(x, q, t), discounted(k, r, t)
 
-	switch o {
-	case Call:
+	// puts take N(-d1) and N(-d2) rather than 1 - N(d1) and 1 - N(d2),
+	// which lose the digits of puts far out of the money
+	if o == Put {
+		return normCDF(m.n, -d2)*k - normCDF(m.n, -d1)*x
+	}
+
+	Nd1, Nd2 := normCDF(m.n, d1), normCDF(m.n, d2)
+	if o == Call {
 		return Nd1*x - Nd2*k
-	case Put:
-		return (Nd1-1)*x - (Nd2-1)*k
 	}
 
 	return (2*Nd1-1)*x - (2*Nd2-1)*k
@@ -228,273 +623,660 @@ func BSPriceNoErrorCheck(v, t, x, k, r, q float64, o OptionType) float64 {
 
 func BSDelta(v, t, x, k, r, q float64, o OptionType) float64 {
 
-	if v < 0 {
-		return 2*ZeroVolBSDelta(t, x, k, r, q, o) - BSDelta(-v, t, x, k, r, q, o)
+	if checkParams(t, x, k, r, q, o) != nil {
+		return nan()
 	}
 
-	if CheckPriceParams(t, x, k, o) != nil {
-		return nan()
+	return deltaKernel(model{}, v, t, x, k, r, q, o)
+}
+
+// deltaKernel is BSDelta through n
+func deltaKernel(m model, v, t, x, k, r, q float64, o OptionType) float64 {
+
+	if v < 0 {
+		return 2*ZeroVolBSDelta(t, x, k, r, q, o) - deltaKernel(m, -v, t, x, k, r, q, o)
 	}
 
 	switch {
+	case t == 0:
+		return DeltaZeroTime(x, k, o)
 	case x == 0:
 		return ZeroUnderlyingBSDelta(t, q, o)
 	case k == 0:
 		return ZeroStrikeBSDelta(t, q, o)
-	case v == 0:
+	case v == 0, m.expired(t):
 		return ZeroVolBSDelta(t, x, k, r, q, o)
 	}
 
-	Nd1 := NormCDF(D1(v, t, x, k, r, q))
+	Nd1 := normCDF(m.n, D1(v, t, x, k, r, q))
 
 	switch o {
 	case Call:
-		return exp(-q*t) * Nd1
+		return DiscountFactor(q, t) * Nd1
 	case Put:
-		return exp(-q*t) * (Nd1 - 1)
+		return DiscountFactor(q, t) * (Nd1 - 1)
 	}
 
-	return exp(-q*t) * (2*Nd1 - 1)
+	return DiscountFactor(q, t) * (2*Nd1 - 1)
 }
 
 func BSGamma(v, t, x, k, r, q float64, o OptionType) float64 {
 
-	if v < 0 {
-		return 2*ZeroVolBSGamma(t, x, k, r, q) - BSGamma(-v, t, x, k, r, q, o)
+	if checkParams(t, x, k, r, q, o) != nil {
+		return nan()
 	}
 
-	if CheckPriceParams(t, x, k, o) != nil {
-		return nan()
+	return gammaKernel(model{}, v, t, x, k, r, q, o)
+}
+
+// gammaKernel is BSGamma through n
+func gammaKernel(m model, v, t, x, k, r, q float64, o OptionType) float64 {
+
+	if v < 0 {
+		return 2*ZeroVolBSGamma(t, x, k, r, q) - gammaKernel(m, -v, t, x, k, r, q, o)
 	}
 
 	switch {
-	case x == 0, k == 0:
-		return 0
-	case v == 0:
+	case t == 0:
+		return GammaZeroTime(x, k, o)
+	case x == 0:
+		return ZeroUnderlyingBSGamma(o)
+	case k == 0:
+		return ZeroStrikeBSGamma(o)
+	case v == 0, m.expired(t):
 		return ZeroVolBSGamma(t, x, k, r, q)
+	case m.nearExpiry(v, t):
+		return gammaNearExpiry(m.n, v, t, x, k, r, q, o)
 	}
 
 	d1 := D1(v, t, x, k, r, q)
 
 	if o == Call || o == Put {
-		return exp(-q*t-d1*d1/2) / x / v / sqrt(t) * InvSqrt2PI
+		return DiscountFactor(q, t) * normPDF(m.n, d1) / x / v / sqrt(t)
 	}
 
-	return 2 * exp(-q*t-d1*d1/2) / x / v / sqrt(t) * InvSqrt2PI
+	return 2 * DiscountFactor(q, t) * normPDF(m.n, d1) / x / v / sqrt(t)
 }
 
-func BSTheta(v, t, x, k, r, q float64, o OptionType) float64 {
+// BSSpeed returns the derivative of the gamma in x,
+// -gamma/x*(1 + d1/(v*sqrt(t))), through BSGamma so that a straddle is
+// doubled. It is 0 at zero underlying, strike and vol and below
+// TimeFloor, where the gamma is 0 away from the money, and odd in v.
+func BSSpeed(v, t, x, k, r, q float64, o OptionType) float64 {
+
+	if checkParams(t, x, k, r, q, o) != nil {
+		return nan()
+	}
+
+	return speedKernel(model{}, v, t, x, k, r, q, o)
+}
+
+// speedKernel is BSSpeed through n
+func speedKernel(m model, v, t, x, k, r, q float64, o OptionType) float64 {
+
+	if v < 0 {
+		return -speedKernel(m, -v, t, x, k, r, q, o)
+	}
+
+	if x == 0 || k == 0 || v == 0 || m.expired(t) {
+		return byType(0, 0, o)
+	}
+
+	d1 := D1(v, t, x, k, r, q)
+
+	return -gammaKernel(m, v, t, x, k, r, q, o) / x * (1 + d1/v/sqrt(t))
+}
+
+// BSZomma returns the derivative of the gamma in v,
+// gamma*(d1*d2 - 1)/v, through BSGamma so that a straddle is doubled. It
+// is even in v. At zero underlying and strike it is 0, as it is at zero
+// vol, below TimeFloor and at expiry away from the money, and NaN at the
+// money there, where the gamma is infinite.
+func BSZomma(v, t, x, k, r, q float64, o OptionType) float64 {
+
+	if checkParams(t, x, k, r, q, o) != nil {
+		return nan()
+	}
+
+	return zommaKernel(model{}, v, t, x, k, r, q, o)
+}
+
+// zommaKernel is BSZomma through n
+func zommaKernel(m model, v, t, x, k, r, q float64, o OptionType) float64 {
+
+	v = abs(v)
+
+	switch {
+	case x == 0, k == 0:
+		return byType(0, 0, o)
+	case v == 0, m.expired(t):
+		if discounted(x, q, t) == discounted(k, r, t) {
+			return nan()
+		}
+		return byType(0, 0, o)
+	}
+
+	d1 := D1(v, t, x, k, r, q)
+	d2 := D2fromD1(d1, v, t)
+
+	return gammaKernel(m, v, t, x, k, r, q, o) * (d1*d2 - 1) / v
+}
+
+// BSColor returns the change in the gamma as time passes, the derivative
+// of the gamma in t with the sign of BSTheta,
+//
+//	gamma*(2*q*t + 1 + d1*(2*(r-q)*t - d2*v*sqrt(t))/(v*sqrt(t)))/(2*t)
+//
+// through BSGamma so that a straddle is doubled. It is odd in v. It is 0
+// at zero underlying and strike, as it is at zero vol and below
+// TimeFloor away from the money, and NaN at the money there and at
+// expiry.
+func BSColor(v, t, x, k, r, q float64, o OptionType) float64 {
+
+	if checkParams(t, x, k, r, q, o) != nil {
+		return nan()
+	}
+
+	return colorKernel(model{}, v, t, x, k, r, q, o)
+}
+
+// colorKernel is BSColor through n
+func colorKernel(m model, v, t, x, k, r, q float64, o OptionType) float64 {
 
 	if v < 0 {
-		return 2*ZeroVolBSTheta(t, x, k, r, q, o) - BSTheta(-v, t, x, k, r, q, o)
+		return -colorKernel(m, -v, t, x, k, r, q, o)
 	}
 
-	if CheckPriceParams(t, x, k, o) != nil {
+	switch {
+	case t == 0:
+		return nan()
+	case x == 0, k == 0:
+		return byType(0, 0, o)
+	case v == 0, m.expired(t):
+		if discounted(x, q, t) == discounted(k, r, t) {
+			return nan()
+		}
+		return byType(0, 0, o)
+	}
+
+	vsqrtt := v * sqrt(t)
+	d1 := D1(v, t, x, k, r, q)
+	d2 := d1 - vsqrtt
+
+	return gammaKernel(m, v, t, x, k, r, q, o) * (2*q*t + 1 + d1*(2*(r-q)*t-d2*vsqrtt)/vsqrtt) / (2 * t)
+}
+
+func BSTheta(v, t, x, k, r, q float64, o OptionType) float64 {
+
+	if checkParams(t, x, k, r, q, o) != nil {
 		return nan()
 	}
 
+	return thetaKernel(model{}, v, t, x, k, r, q, o)
+}
+
+// thetaKernel is BSTheta through n
+func thetaKernel(m model, v, t, x, k, r, q float64, o OptionType) float64 {
+
+	if v < 0 {
+		return 2*ZeroVolBSTheta(t, x, k, r, q, o) - thetaKernel(m, -v, t, x, k, r, q, o)
+	}
+
 	switch {
+	case t == 0:
+		return ThetaZeroTime(v, x, k, r, q, o)
 	case x == 0:
 		return ZeroUnderlyingBSTheta(t, k, r, o)
 	case k == 0:
 		return ZeroStrikeBSTheta(t, x, q, o)
 	case v == 0:
 		return ZeroVolBSTheta(t, x, k, r, q, o)
-	case t == 0:
-		return inf(-1)
+	case m.expired(t):
+		if discounted(x, q, t) == discounted(k, r, t) {
+			return inf(-1)
+		}
+		return ZeroVolBSTheta(t, x, k, r, q, o)
+	case m.nearExpiry(v, t):
+		return thetaNearExpiry(m.n, v, t, x, k, r, q, o)
 	}
 
 	d1 := D1(v, t, x, k, r, q)
 	d2 := D2fromD1(d1, v, t)
 
-	x, k = exp(-q*t)*x, exp(-r*t)*k
-	theta := -v * x * exp(-d1*d1/2) / 2 / sqrt(t) * InvSqrt2PI
-	theta += q*x*NormCDF(d1) - r*k*NormCDF(d2)
+	x, k = discounted(x, q, t), discounted(k, r, t)
+	theta := -v * x * normPDF(m.n, d1) / 2 / sqrt(t)
+	Nd1, Nd2 := normCDF(m.n, d1), normCDF(m.n, d2)
 
-	if o == Call || o == Put {
-		return theta
+	switch o {
+	case Call:
+		return theta + q*x*Nd1 - r*k*Nd2
+	case Put:
+		return theta + q*x*(Nd1-1) - r*k*(Nd2-1)
 	}
 
-	return 2 * theta
+	return 2*theta + q*x*(2*Nd1-1) - r*k*(2*Nd2-1)
 }
 
 func BSVega(v, t, x, k, r, q float64, o OptionType) float64 {
 
+	if checkParams(t, x, k, r, q, o) != nil {
+		return nan()
+	}
+
+	return vegaKernel(model{}, v, t, x, k, r, q, o)
+}
+
+// vegaKernel is BSVega through n
+func vegaKernel(m model, v, t, x, k, r, q float64, o OptionType) float64 {
+
 	if v < 0 {
-		return -BSVega(-v, t, x, k, r, q, o)
+		return -vegaKernel(m, -v, t, x, k, r, q, o)
 	}
 
-	if CheckPriceParams(t, x, k, o) != nil {
+	switch {
+	case t == 0:
+		return VegaZeroTime(o)
+	case v == 0:
+		return ZeroVolBSVega(t, x, k, r, q, o)
+	case x == 0:
+		return ZeroUnderlyingBSVega(o)
+	case k == 0:
+		return ZeroStrikeBSVega(o)
+	case m.expired(t):
+		return 0
+	}
+
+	vega, _, _ := vegaD1D2(m.n, v, t, x, k, r, q)
+
+	return byType(vega, vega, o)
+}
+
+// vegaD1D2 returns the vega of a call or put with d1 and d2, from which
+// BSVega, BSVolga, BSUltima and BSVeta are built
+func vegaD1D2(n Normal, v, t, x, k, r, q float64) (vega, d1, d2 float64) {
+
+	d1 = D1(v, t, x, k, r, q)
+	d2 = D2fromD1(d1, v, t)
+	vega = discounted(x, q, t) * normPDF(n, d1) * sqrt(t)
+
+	return vega, d1, d2
+}
+
+// BSVanna returns the derivative of the delta in v, or of the vega in x,
+// -exp(-q*t)*n(d1)*d2/v for a call or put and twice that for a straddle.
+// Like BSVega it is odd in v.
+func BSVanna(v, t, x, k, r, q float64, o OptionType) float64 {
+
+	if checkParams(t, x, k, r, q, o) != nil {
 		return nan()
 	}
 
-	if v == 0 || t == 0 || x == 0 || k == 0 {
-		return 0
+	return vannaKernel(model{}, v, t, x, k, r, q, o)
+}
+
+// vannaKernel is BSVanna through n
+func vannaKernel(m model, v, t, x, k, r, q float64, o OptionType) float64 {
+
+	if v < 0 {
+		return -vannaKernel(m, -v, t, x, k, r, q, o)
+	}
+
+	switch {
+	case t == 0, x == 0, k == 0:
+		return byType(0, 0, o)
+	case v == 0, m.expired(t):
+		return ZeroVolBSVanna(t, x, k, r, q, o)
 	}
 
 	d1 := D1(v, t, x, k, r, q)
+	d2 := D2fromD1(d1, v, t)
+	vanna := -DiscountFactor(q, t) * normPDF(m.n, d1) * d2 / v
 
-	if o == Call || o == Put {
-		return x * exp(-q*t-d1*d1/2) * sqrt(t) * InvSqrt2PI
+	return byType(vanna, vanna, o)
+}
+
+// BSVolga returns the derivative of the vega in v, vega*d1*d2/v, twice
+// that for a straddle through the vega. It is even in v and 0 at the
+// boundaries, including zero vol, where it is -vega*v*t/4 -> 0 at the
+// money and vanishes faster than any power of v away from it.
+func BSVolga(v, t, x, k, r, q float64, o OptionType) float64 {
+
+	if checkParams(t, x, k, r, q, o) != nil {
+		return nan()
 	}
 
-	return 2 * x * exp(-q*t-d1*d1/2) * sqrt(t) * InvSqrt2PI
+	return volgaKernel(model{}, v, t, x, k, r, q, o)
 }
 
-func D2fromD1(d1, v, t float64) float64 {
-	return d1 - v*sqrt(t)
+// volgaKernel is BSVolga through n
+func volgaKernel(m model, v, t, x, k, r, q float64, o OptionType) float64 {
+
+	v = abs(v)
+
+	if v == 0 || m.expired(t) || x == 0 || k == 0 {
+		return byType(0, 0, o)
+	}
+
+	vega, d1, d2 := vegaD1D2(m.n, v, t, x, k, r, q)
+
+	return byType(vega, vega, o) * d1 * d2 / v
 }
 
-func D1(v, t, x, k, r, q float64) float64 {
-	return (log(x/k) + (r-q+0.5*v*v)*t) / v / sqrt(t)
+// BSUltima returns the derivative of the volga in v,
+// -vega*(d1*d2*(1 - d1*d2) + d1*d1 + d2*d2)/(v*v), twice that for a
+// straddle. It is odd in v. At zero vol it is the limit
+// -t/4*ZeroVolBSVega, nonzero at the money forward only, and it is 0 at
+// the other boundaries.
+func BSUltima(v, t, x, k, r, q float64, o OptionType) float64 {
+
+	if checkParams(t, x, k, r, q, o) != nil {
+		return nan()
+	}
+
+	return ultimaKernel(model{}, v, t, x, k, r, q, o)
 }
 
-func D2(v, t, x, k, r, q float64) float64 {
-	return (log(x/k) + (r-q-0.5*v*v)*t) / v / sqrt(t)
+// ultimaKernel is BSUltima through n
+func ultimaKernel(m model, v, t, x, k, r, q float64, o OptionType) float64 {
+
+	if v < 0 {
+		return -ultimaKernel(m, -v, t, x, k, r, q, o)
+	}
+
+	switch {
+	case t == 0, x == 0, k == 0:
+		return byType(0, 0, o)
+	case v == 0:
+		return -t / 4 * ZeroVolBSVega(t, x, k, r, q, o)
+	case m.expired(t):
+		return byType(0, 0, o)
+	}
+
+	vega, d1, d2 := vegaD1D2(m.n, v, t, x, k, r, q)
+	d1d2 := d1 * d2
+	ultima := -vega * (d1d2*(1-d1d2) + d1*d1 + d2*d2) / (v * v)
+
+	return byType(ultima, ultima, o)
 }
 
-func Intrinsic(t, x, k, r, q float64, o OptionType) float64 {
+// BSVeta returns the change in the vega as time passes, the derivative of
+// the vega in t with the sign of BSTheta,
+//
+//	vega*(q + (r-q)*d1/(v*sqrt(t)) - (1 + d1*d2)/(2*t))
+//
+// twice that for a straddle. It is odd in v. At zero vol it is the limit
+// ZeroVolBSVega*((r+q)/2 - 1/(2*t)), nonzero at the money forward only,
+// and it is 0 at the other boundaries.
+func BSVeta(v, t, x, k, r, q float64, o OptionType) float64 {
+
+	if checkParams(t, x, k, r, q, o) != nil {
+		return nan()
+	}
 
-	p := exp(-q*t)*x - exp(-r*t)*k
+	return vetaKernel(model{}, v, t, x, k, r, q, o)
+}
 
-	switch o {
-	case Call:
-		return max(0, +p)
-	case Put:
-		return max(0, -p)
+// vetaKernel is BSVeta through n
+func vetaKernel(m model, v, t, x, k, r, q float64, o OptionType) float64 {
+
+	if v < 0 {
+		return -vetaKernel(m, -v, t, x, k, r, q, o)
 	}
-	return abs(p)
+
+	switch {
+	case t == 0, x == 0, k == 0:
+		return byType(0, 0, o)
+	case v == 0:
+		return ZeroVolBSVega(t, x, k, r, q, o) * ((r+q)/2 - 1/(2*t))
+	case m.expired(t):
+		return byType(0, 0, o)
+	}
+
+	vega, d1, d2 := vegaD1D2(m.n, v, t, x, k, r, q)
+	veta := vega * (q + (r-q)*d1/(v*sqrt(t)) - (1+d1*d2)/(2*t))
+
+	return byType(veta, veta, o)
 }
 
-func ValidOptionType(o OptionType) bool {
-	return o == Call || o == Put || o == Straddle
+// BSDualDelta returns the derivative of the price in k, -exp(-r*t)*N(d2)
+// for a call and exp(-r*t)*N(-d2) for a put, the discounted probability
+// of exercise with the sign of the strike's part of the payoff
+func BSDualDelta(v, t, x, k, r, q float64, o OptionType) float64 {
+
+	if checkParams(t, x, k, r, q, o) != nil {
+		return nan()
+	}
+
+	return dualDeltaKernel(model{}, v, t, x, k, r, q, o)
 }
 
-func ZeroStrikeBSPrice(t, x, q float64, o OptionType) float64 {
+// dualDeltaKernel is BSDualDelta through n
+func dualDeltaKernel(m model, v, t, x, k, r, q float64, o OptionType) float64 {
+
+	if v < 0 {
+		return 2*ZeroVolBSDualDelta(t, x, k, r, q, o) - dualDeltaKernel(m, -v, t, x, k, r, q, o)
+	}
+
+	switch {
+	case t == 0:
+		return DualDeltaZeroTime(x, k, o)
+	case x == 0:
+		return ZeroUnderlyingBSDualDelta(t, r, o)
+	case k == 0:
+		return ZeroStrikeBSDualDelta(t, r, o)
+	case v == 0, m.expired(t):
+		return ZeroVolBSDualDelta(t, x, k, r, q, o)
+	}
+
+	d2 := D2(v, t, x, k, r, q)
+	dfr := DiscountFactor(r, t)
+
 	switch o {
-	case Call, Straddle:
-		return exp(-q*t) * x
+	case Call:
+		return -dfr * normCDF(m.n, d2)
 	case Put:
-		return 0
+		return dfr * normCDF(m.n, -d2)
 	}
-	return nan()
+
+	return dfr * (1 - 2*normCDF(m.n, d2))
 }
 
-func ZeroUnderlyingBSPrice(t, k, r float64, o OptionType) float64 {
-	switch o {
-	case Call:
-		return 0
-	case Put, Straddle:
-		return exp(-r*t) * k
+// BSDualGamma returns the second derivative of the price in k,
+// exp(-r*t)*n(d2)/(k*v*sqrt(t)) for a call or put and twice that for a
+// straddle, the discounted risk neutral density of the underlying at
+// expiry at k. At the boundaries it takes the values of BSGamma.
+func BSDualGamma(v, t, x, k, r, q float64, o OptionType) float64 {
+
+	if checkParams(t, x, k, r, q, o) != nil {
+		return nan()
 	}
-	return nan()
+
+	return dualGammaKernel(model{}, v, t, x, k, r, q, o)
 }
 
-func ZeroStrikeBSDelta(t, q float64, o OptionType) float64 {
-	switch o {
-	case Call, Straddle:
-		return exp(-q * t)
-	case Put:
-		return 0
+// dualGammaKernel is BSDualGamma through n
+func dualGammaKernel(m model, v, t, x, k, r, q float64, o OptionType) float64 {
+
+	if v < 0 {
+		return 2*ZeroVolBSGamma(t, x, k, r, q) - dualGammaKernel(m, -v, t, x, k, r, q, o)
 	}
-	return nan()
+
+	switch {
+	case t == 0:
+		return GammaZeroTime(x, k, o)
+	case x == 0, k == 0:
+		return byType(0, 0, o)
+	case v == 0, m.expired(t):
+		return ZeroVolBSGamma(t, x, k, r, q)
+	}
+
+	vsqrtt := v * sqrt(t)
+	d2 := D2(v, t, x, k, r, q)
+	dualGamma := DiscountFactor(r, t) * normPDF(m.n, d2) / (k * vsqrtt)
+
+	return byType(dualGamma, dualGamma, o)
 }
 
-func ZeroUnderlyingBSDelta(t, q float64, o OptionType) float64 {
-	switch o {
-	case Call:
-		return 0
-	case Put, Straddle:
-		return -exp(-q * t)
+// BSCharm returns the change in the delta as time passes, the derivative
+// of the delta in t with the sign of BSTheta,
+//
+//	q*exp(-q*t)*N(d1) - exp(-q*t)*n(d1)*(2*(r-q)*t - d2*v*sqrt(t))/(2*t*v*sqrt(t))
+//
+// for a call, with q*exp(-q*t)*(N(d1) - 1) in place of the first term for
+// a put. At the boundaries the delta only decays with its discount
+// factor, so the charm is q times the delta there, except at expiry at
+// the money with a positive vol, see CharmZeroTime.
+func BSCharm(v, t, x, k, r, q float64, o OptionType) float64 {
+
+	if checkParams(t, x, k, r, q, o) != nil {
+		return nan()
 	}
-	return nan()
+
+	return charmKernel(model{}, v, t, x, k, r, q, o)
 }
 
-func ZeroVolBSDelta(t, x, k, r, q float64, o OptionType) float64 {
+// charmKernel is BSCharm through n
+func charmKernel(m model, v, t, x, k, r, q float64, o OptionType) float64 {
+
+	if v < 0 {
+		return 2*ZeroVolBSCharm(t, x, k, r, q, o) - charmKernel(m, -v, t, x, k, r, q, o)
+	}
+
+	switch {
+	case t == 0:
+		return CharmZeroTime(v, x, k, r, q, o)
+	case x == 0:
+		return q * ZeroUnderlyingBSDelta(t, q, o)
+	case k == 0:
+		return q * ZeroStrikeBSDelta(t, q, o)
+	case v == 0, m.expired(t):
+		return ZeroVolBSCharm(t, x, k, r, q, o)
+	}
+
+	sqrtt := sqrt(t)
+	d1 := D1(v, t, x, k, r, q)
+	d2 := D2fromD1(d1, v, t)
+	dfq := DiscountFactor(q, t)
 
-	dfq := exp(-q * t)
-	x, k = dfq*x, exp(-r*t)*k
+	decay := dfq * normPDF(m.n, d1) * (2*(r-q)*t - d2*v*sqrtt) / (2 * t * v * sqrtt)
 
 	switch o {
 	case Call:
-		if x < k {
-			return 0
-		}
-		return dfq
+		return q*dfq*normCDF(m.n, d1) - decay
 	case Put:
-		if x < k {
-			return -dfq
-		}
-		return 0
-	case Straddle:
-		if x < k {
-			return -dfq
-		}
-		return dfq
+		return -q*dfq*normCDF(m.n, -d1) - decay
 	}
-	return nan()
+
+	return q*dfq*(2*normCDF(m.n, d1)-1) - 2*decay
 }
 
-func ZeroVolBSGamma(t, x, k, r, q float64) float64 {
-	if exp(-q*t)*x-exp(-r*t)*k != 0 {
-		return 0
+// BSRho returns the derivative of the price in r, t*exp(-r*t)*k*N(d2)
+// for a call
+func BSRho(v, t, x, k, r, q float64, o OptionType) float64 {
+
+	if checkParams(t, x, k, r, q, o) != nil {
+		return nan()
 	}
-	return inf(1)
+
+	return rhoKernel(model{}, v, t, x, k, r, q, o)
 }
 
-func ZeroStrikeBSTheta(t, x, q float64, o OptionType) float64 {
+// rhoKernel is BSRho through n
+func rhoKernel(m model, v, t, x, k, r, q float64, o OptionType) float64 {
+
+	if v < 0 {
+		return 2*ZeroVolBSRho(t, x, k, r, q, o) - rhoKernel(m, -v, t, x, k, r, q, o)
+	}
+
+	switch {
+	case x == 0:
+		return ZeroUnderlyingBSRho(t, k, r, o)
+	case k == 0:
+		return ZeroStrikeBSRho(o)
+	case v == 0, m.expired(t):
+		return ZeroVolBSRho(t, x, k, r, q, o)
+	}
+
+	Nd2 := normCDF(m.n, D2(v, t, x, k, r, q))
+	tk := t * discounted(k, r, t)
+
 	switch o {
-	case Call, Straddle:
-		return q * x * exp(-q*t)
+	case Call:
+		return tk * Nd2
 	case Put:
-		return 0
+		return tk * (Nd2 - 1)
 	}
-	return nan()
+
+	return tk * (2*Nd2 - 1)
 }
 
-func ZeroUnderlyingBSTheta(t, k, r float64, o OptionType) float64 {
+// BSEpsilon returns the derivative of the price in q,
+// -t*exp(-q*t)*x*N(d1) for a call, which is -t*x times the delta
+func BSEpsilon(v, t, x, k, r, q float64, o OptionType) float64 {
+
+	if checkParams(t, x, k, r, q, o) != nil {
+		return nan()
+	}
+
+	return epsilonKernel(model{}, v, t, x, k, r, q, o)
+}
+
+// epsilonKernel is BSEpsilon through n
+func epsilonKernel(m model, v, t, x, k, r, q float64, o OptionType) float64 {
+
+	if v < 0 {
+		return 2*ZeroVolBSEpsilon(t, x, k, r, q, o) - epsilonKernel(m, -v, t, x, k, r, q, o)
+	}
+
+	switch {
+	case x == 0:
+		return ZeroUnderlyingBSEpsilon(o)
+	case k == 0:
+		return ZeroStrikeBSEpsilon(t, x, q, o)
+	case v == 0, m.expired(t):
+		return ZeroVolBSEpsilon(t, x, k, r, q, o)
+	}
+
+	Nd1 := normCDF(m.n, D1(v, t, x, k, r, q))
+	tx := t * discounted(x, q, t)
+
 	switch o {
 	case Call:
-		return 0
-	case Put, Straddle:
-		return r * k * exp(-r*t)
+		return -tx * Nd1
+	case Put:
+		return tx * (1 - Nd1)
 	}
-	return nan()
+
+	return tx * (1 - 2*Nd1)
+}
+
+func D2fromD1(d1, v, t float64) float64 {
+	return d1 - v*sqrt(t)
+}
+
+func D1(v, t, x, k, r, q float64) float64 {
+	return (log(x/k) + (r-q+0.5*v*v)*t) / v / sqrt(t)
 }
 
-func ZeroVolBSTheta(t, x, k, r, q float64, o OptionType) float64 {
+func D2(v, t, x, k, r, q float64) float64 {
+	return (log(x/k) + (r-q-0.5*v*v)*t) / v / sqrt(t)
+}
 
-	x, k = exp(-q*t)*x, exp(-r*t)*k
+func Intrinsic(t, x, k, r, q float64, o OptionType) float64 {
+
+	p := discounted(x, q, t) - discounted(k, r, t)
 
 	switch o {
 	case Call:
-		switch {
-		case x > k:
-			return q*x - r*k
-		case x < k:
-			return 0
-		default:
-			return q * x
-		}
+		return max(0, +p)
 	case Put:
-		switch {
-		case x > k:
-			return 0
-		case x < k:
-			return r*k - q*x
-		default:
-			return r * k
-		}
+		return max(0, -p)
 	case Straddle:
-		switch {
-		case x > k:
-			return q*x - r*k
-		case x < k:
-			return r*k - q*x
-		default:
-			return q*x + r*k
-		}
+		return abs(p)
 	}
-
 	return nan()
 }
+
+func ValidOptionType(o OptionType) bool {
+	return o == Call || o == Put || o == Straddle
+}
@@ -0,0 +1,330 @@
+package blackscholes
+
+// The closed forms below give the prices and greeks at the boundaries of
+// the Black Scholes formulas, where d1 and d2 are undefined: zero strike,
+// zero underlying, and zero vol, which also covers times to expiry below
+// TimeFloor. Each is the limit of the formula as the boundary is
+// approached, and BSPrice, BSDelta, BSGamma, BSVega, BSTheta, BSRho,
+// BSEpsilon, BSVanna and BSGreeks return them there.
+//
+// At zero vol the underlying finishes at its forward, so the values
+// depend on whether the discounted underlying exp(-q*t)*x is above, below
+// or at the discounted strike exp(-r*t)*k. At the money forward they are
+// the limits as the vol falls to 0, at which N(d1) and N(d2) go to 1/2:
+//
+//   - the price is 0 for calls, puts and straddles
+//   - delta is exp(-q*t)/2 for a call, -exp(-q*t)/2 for a put and 0 for a
+//     straddle
+//   - gamma is +Inf
+//   - vega is the slope exp(-q*t)*x*sqrt(t)/sqrt(2*Pi) of AtmApprox,
+//     doubled for a straddle
+//   - theta is (q*x - r*k)/2 in discounted terms for a call, the opposite
+//     for a put and 0 for a straddle
+//   - rho is t*exp(-r*t)*k/2 for a call, the opposite for a put and 0 for
+//     a straddle
+//   - vanna is half the vega slope, exp(-q*t)*sqrt(t)/sqrt(2*Pi)/2,
+//     doubled for a straddle
+//   - epsilon is -t*exp(-q*t)*x/2 for a call, the opposite for a put and 0
+//     for a straddle
+//
+// The straddle is the sum of the call and the put throughout. Below
+// TimeFloor with a positive vol BSTheta is -Inf at the money forward,
+// the limit as the time to expiry falls to 0.
+//
+// At expiry, t == 0, the BS functions take the ZeroTime forms, which are
+// the zero vol values with no discounting: the price is the payoff, delta
+// is 1 in the money, 0 out of it and 1/2 at the money for a call, gamma
+// is 0 except +Inf at the money, vega is 0, and theta is the carry
+// q*x - r*k of the position held to expiry, halved at the money, or -Inf
+// at the money with a positive vol.
+
+// PriceZeroTime returns the payoff of an option at expiry
+func PriceZeroTime(x, k float64, o OptionType) float64 {
+	return byType(max(x-k, 0), max(k-x, 0), o)
+}
+
+// DeltaZeroTime returns the delta at expiry, 1 for a call in the money,
+// 0 out of it and 1/2 at the money, and the opposites for a put
+func DeltaZeroTime(x, k float64, o OptionType) float64 {
+	return ZeroVolBSDelta(0, x, k, 0, 0, o)
+}
+
+// GammaZeroTime returns the gamma at expiry, 0 except +Inf at the money
+func GammaZeroTime(x, k float64, o OptionType) float64 {
+	if !ValidOptionType(o) {
+		return nan()
+	}
+	return ZeroVolBSGamma(0, x, k, 0, 0)
+}
+
+// VegaZeroTime is 0 for every option type
+func VegaZeroTime(o OptionType) float64 {
+	return byType(0, 0, o)
+}
+
+// ThetaZeroTime returns the theta at expiry: the carry q*x - r*k of a
+// call in the money and r*k - q*x of a put, halved at the money at zero
+// vol, and -Inf at the money with a positive vol
+func ThetaZeroTime(v, x, k, r, q float64, o OptionType) float64 {
+	if v > 0 && x == k && ValidOptionType(o) {
+		return inf(-1)
+	}
+	return ZeroVolBSTheta(0, x, k, r, q, o)
+}
+
+// CharmZeroTime returns the charm at expiry, q times DeltaZeroTime,
+// except at the money with a positive vol, where the delta moves without
+// bound as expiry nears: -Inf if r - q + v*v/2 is positive, +Inf if it is
+// negative
+func CharmZeroTime(v, x, k, r, q float64, o OptionType) float64 {
+	if v > 0 && x == k && ValidOptionType(o) {
+		switch drift := r - q + v*v/2; {
+		case drift > 0:
+			return inf(-1)
+		case drift < 0:
+			return inf(1)
+		}
+	}
+	return q * DeltaZeroTime(x, k, o)
+}
+
+func ZeroStrikeBSPrice(t, x, q float64, o OptionType) float64 {
+	switch o {
+	case Call, Straddle:
+		return discounted(x, q, t)
+	case Put:
+		return 0
+	}
+	return nan()
+}
+
+func ZeroUnderlyingBSPrice(t, k, r float64, o OptionType) float64 {
+	switch o {
+	case Call:
+		return 0
+	case Put, Straddle:
+		return discounted(k, r, t)
+	}
+	return nan()
+}
+
+func ZeroStrikeBSDelta(t, q float64, o OptionType) float64 {
+	switch o {
+	case Call, Straddle:
+		return DiscountFactor(q, t)
+	case Put:
+		return 0
+	}
+	return nan()
+}
+
+func ZeroUnderlyingBSDelta(t, q float64, o OptionType) float64 {
+	switch o {
+	case Call:
+		return 0
+	case Put, Straddle:
+		return -DiscountFactor(q, t)
+	}
+	return nan()
+}
+
+func ZeroVolBSDelta(t, x, k, r, q float64, o OptionType) float64 {
+
+	if !ValidOptionType(o) {
+		return nan()
+	}
+
+	dfq := DiscountFactor(q, t)
+	x, k = discounted(x, q, t), discounted(k, r, t)
+
+	// the call and put deltas, whose sum is the straddle delta
+	c, p := dfq/2, -dfq/2
+	switch {
+	case x > k:
+		c, p = dfq, 0
+	case x < k:
+		c, p = 0, -dfq
+	}
+
+	return byType(c, p, o)
+}
+
+// DualDeltaZeroTime returns the dual delta at expiry, -1 for a call in
+// the money, 0 out of it and -1/2 at the money, and the opposites for a
+// put
+func DualDeltaZeroTime(x, k float64, o OptionType) float64 {
+	return ZeroVolBSDualDelta(0, x, k, 0, 0, o)
+}
+
+func ZeroStrikeBSDualDelta(t, r float64, o OptionType) float64 {
+	return byType(-DiscountFactor(r, t), 0, o)
+}
+
+func ZeroUnderlyingBSDualDelta(t, r float64, o OptionType) float64 {
+	return byType(0, DiscountFactor(r, t), o)
+}
+
+func ZeroVolBSDualDelta(t, x, k, r, q float64, o OptionType) float64 {
+
+	dfr := DiscountFactor(r, t)
+	x, k = discounted(x, q, t), discounted(k, r, t)
+
+	c, p := -dfr/2, dfr/2
+	switch {
+	case x > k:
+		c, p = -dfr, 0
+	case x < k:
+		c, p = 0, dfr
+	}
+
+	return byType(c, p, o)
+}
+
+// ZeroStrikeBSGamma is 0 for every option type
+func ZeroStrikeBSGamma(o OptionType) float64 {
+	return byType(0, 0, o)
+}
+
+// ZeroUnderlyingBSGamma is 0 for every option type
+func ZeroUnderlyingBSGamma(o OptionType) float64 {
+	return byType(0, 0, o)
+}
+
+func ZeroVolBSGamma(t, x, k, r, q float64) float64 {
+	if discounted(x, q, t) != discounted(k, r, t) {
+		return 0
+	}
+	return inf(1)
+}
+
+// ZeroStrikeBSVega is 0 for every option type
+func ZeroStrikeBSVega(o OptionType) float64 {
+	return byType(0, 0, o)
+}
+
+// ZeroUnderlyingBSVega is 0 for every option type
+func ZeroUnderlyingBSVega(o OptionType) float64 {
+	return byType(0, 0, o)
+}
+
+// ZeroVolBSVega returns the limit of vega as v -> 0, which is the
+// AtmApprox slope when exp(-q*t)*x == exp(-r*t)*k and 0 otherwise
+func ZeroVolBSVega(t, x, k, r, q float64, o OptionType) float64 {
+
+	if !ValidOptionType(o) {
+		return nan()
+	}
+
+	if discounted(x, q, t) != discounted(k, r, t) {
+		return 0
+	}
+
+	return AtmApprox(1, t, x, q, o)
+}
+
+// ZeroVolBSVanna returns the limit of vanna as v -> 0, which is half the
+// vega slope exp(-q*t)*sqrt(t)/sqrt(2*Pi) when exp(-q*t)*x == exp(-r*t)*k,
+// where d2 = -v*sqrt(t)/2, and 0 otherwise
+func ZeroVolBSVanna(t, x, k, r, q float64, o OptionType) float64 {
+
+	if !ValidOptionType(o) {
+		return nan()
+	}
+
+	if discounted(x, q, t) != discounted(k, r, t) {
+		return 0
+	}
+
+	return AtmApprox(1, t, 1, q, o) / 2
+}
+
+// ZeroVolBSCharm returns the charm at zero vol, q times ZeroVolBSDelta,
+// the decay of its discount factor
+func ZeroVolBSCharm(t, x, k, r, q float64, o OptionType) float64 {
+	return q * ZeroVolBSDelta(t, x, k, r, q, o)
+}
+
+func ZeroStrikeBSTheta(t, x, q float64, o OptionType) float64 {
+	return byType(q*discounted(x, q, t), 0, o)
+}
+
+func ZeroUnderlyingBSTheta(t, k, r float64, o OptionType) float64 {
+	return byType(0, r*discounted(k, r, t), o)
+}
+
+func ZeroVolBSTheta(t, x, k, r, q float64, o OptionType) float64 {
+
+	x, k = discounted(x, q, t), discounted(k, r, t)
+
+	c, p := (q*x-r*k)/2, (r*k-q*x)/2
+	switch {
+	case x > k:
+		c, p = q*x-r*k, 0
+	case x < k:
+		c, p = 0, r*k-q*x
+	}
+
+	return byType(c, p, o)
+}
+
+// ZeroStrikeBSRho is 0 for every option type
+func ZeroStrikeBSRho(o OptionType) float64 {
+	return byType(0, 0, o)
+}
+
+func ZeroUnderlyingBSRho(t, k, r float64, o OptionType) float64 {
+	return byType(0, -t*discounted(k, r, t), o)
+}
+
+func ZeroVolBSRho(t, x, k, r, q float64, o OptionType) float64 {
+
+	x, k = discounted(x, q, t), discounted(k, r, t)
+
+	c, p := t*k/2, -t*k/2
+	switch {
+	case x > k:
+		c, p = t*k, 0
+	case x < k:
+		c, p = 0, -t*k
+	}
+
+	return byType(c, p, o)
+}
+
+func ZeroStrikeBSEpsilon(t, x, q float64, o OptionType) float64 {
+	return byType(-t*discounted(x, q, t), 0, o)
+}
+
+// ZeroUnderlyingBSEpsilon is 0 for every option type
+func ZeroUnderlyingBSEpsilon(o OptionType) float64 {
+	return byType(0, 0, o)
+}
+
+func ZeroVolBSEpsilon(t, x, k, r, q float64, o OptionType) float64 {
+
+	x, k = discounted(x, q, t), discounted(k, r, t)
+
+	c, p := -t*x/2, t*x/2
+	switch {
+	case x > k:
+		c, p = -t*x, 0
+	case x < k:
+		c, p = 0, t*x
+	}
+
+	return byType(c, p, o)
+}
+
+// byType returns the call value c, the put value p, their sum for a
+// straddle, or NaN for an unknown option type
+func byType(c, p float64, o OptionType) float64 {
+	switch o {
+	case Call:
+		return c
+	case Put:
+		return p
+	case Straddle:
+		return c + p
+	}
+	return nan()
+}
@@ -0,0 +1,70 @@
+package blackscholes
+
+// The static no arbitrage bounds of a European option price hold under
+// any model of the underlying: a call is worth at least the intrinsic
+// value of the forward, max(0, exp(-q*t)*x - exp(-r*t)*k), and at most
+// the discounted underlying exp(-q*t)*x, a put at least
+// max(0, exp(-r*t)*k - exp(-q*t)*x) and at most the discounted strike
+// exp(-r*t)*k, and a straddle their sums. Black Scholes prices reach the
+// lower bounds at zero vol and approach the upper bounds as the vol grows
+// without limit. ImpliedVol, ImpliedVolQuote, the Solver and CleanChain
+// test premia against these bounds.
+
+// PriceLowerBound returns the no arbitrage lower bound of the price, the
+// discounted intrinsic value of Intrinsic. Invalid inputs return NaN.
+func PriceLowerBound(timeToExpiry, spot, strike, r, q float64, o OptionType) float64 {
+
+	t, x, k := timeToExpiry, spot, strike
+	if checkParams(t, x, k, r, q, o) != nil {
+		return nan()
+	}
+
+	return Intrinsic(t, x, k, r, q, o)
+}
+
+// PriceUpperBound returns the no arbitrage upper bound of the price, the
+// discounted underlying for a call, the discounted strike for a put and
+// their sum for a straddle. Invalid inputs return NaN.
+func PriceUpperBound(timeToExpiry, spot, strike, r, q float64, o OptionType) float64 {
+
+	t, x, k := timeToExpiry, spot, strike
+	if checkParams(t, x, k, r, q, o) != nil {
+		return nan()
+	}
+
+	return upperBound(t, x, k, r, q, o)
+}
+
+// ClampToBounds returns the premium moved into the no arbitrage bounds
+// of PriceLowerBound and PriceUpperBound, and whether it was outside
+// them. Invalid inputs and a NaN premium return NaN and false.
+func ClampToBounds(
+	premium, timeToExpiry, spot, strike, r, q float64, o OptionType,
+) (clamped float64, wasClamped bool) {
+
+	t, x, k := timeToExpiry, spot, strike
+	if checkParams(t, x, k, r, q, o) != nil || premium != premium {
+		return nan(), false
+	}
+
+	lo, hi := Intrinsic(t, x, k, r, q, o), upperBound(t, x, k, r, q, o)
+	switch {
+	case premium < lo:
+		return lo, true
+	case premium > hi:
+		return hi, true
+	}
+
+	return premium, false
+}
+
+// upperBound is PriceUpperBound without the checks
+func upperBound(t, x, k, r, q float64, o OptionType) float64 {
+	switch o {
+	case Call:
+		return discounted(x, q, t)
+	case Put:
+		return discounted(k, r, t)
+	}
+	return discounted(x, q, t) + discounted(k, r, t)
+}
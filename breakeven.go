@@ -0,0 +1,156 @@
+package blackscholes
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrHorizon   = errors.New("Horizon outside the life of the option")
+	ErrRebalance = errors.New("Non-positive rebalancing frequency")
+)
+
+// BreakevenError is returned by BreakevenVol when no realized vol pays
+// for the time decay of the option: its gamma is zero or not finite, as
+// in the deep wings, at zero vol and at expiry, or its theta is positive
+type BreakevenError struct {
+	Gamma, Theta float64
+}
+
+func (e *BreakevenError) Error() string {
+	return fmt.Sprintf("No breakeven vol for gamma %v and theta %v", e.Gamma, e.Theta)
+}
+
+// BreakevenVol returns the annualized realized vol at which the gamma P&L
+// of a delta hedged option offsets its theta, sqrt(-2*theta/(gamma*x*x)).
+// Theta is the time decay of the price, r*price - (r-q)*x*delta -
+// v*v*x*x*gamma/2 by the Black Scholes equation, so the breakeven differs
+// from the implied vol v by the carry terms of theta and equals it when
+// they vanish.
+func BreakevenVol(
+	vol, timeToExpiry, spot, strike, interestRate, dividendYield float64, optionType OptionType,
+) (float64, error) {
+
+	v, t, x, k, r, q, o := vol, timeToExpiry, spot, strike, interestRate, dividendYield, optionType
+
+	if v < 0 {
+		return nan(), ErrNegVol
+	}
+	if err := checkParams(t, x, k, r, q, o); err != nil {
+		return nan(), err
+	}
+
+	g := BSGreeks(v, t, x, k, r, q, o)
+	theta := pdeTheta(g, v, x, r, q)
+
+	if !(g.Gamma > 0) || g.Gamma == inf(1) || !(theta <= 0) {
+		return nan(), &BreakevenError{Gamma: g.Gamma, Theta: theta}
+	}
+
+	return sqrt(-2 * theta / (g.Gamma * x * x)), nil
+}
+
+// BreakevenMove returns the breakeven vol as an absolute one day spot
+// move, spot*BreakevenVol/sqrt(DaysPerYear)
+func BreakevenMove(
+	vol, timeToExpiry, spot, strike, interestRate, dividendYield float64, optionType OptionType,
+) (float64, error) {
+
+	b, err := BreakevenVol(vol, timeToExpiry, spot, strike, interestRate, dividendYield, optionType)
+	if err != nil {
+		return nan(), err
+	}
+
+	return spot * b / sqrt(DaysPerYear), nil
+}
+
+// pdeTheta is the theta r*price - (r-q)*x*delta - v*v*x*x*gamma/2 given by
+// the Black Scholes equation from the price, delta and gamma in g
+func pdeTheta(g Greeks, v, x, r, q float64) float64 {
+	return r*g.Price - (r-q)*x*g.Delta - v*v*x*x*g.Gamma/2
+}
+
+// ExpectedGammaPnL returns the mean and an approximate standard deviation
+// of the P&L, discounted to now, of a long option bought at impliedVol and
+// delta hedged rebalancesPerDay times a day for timeHorizon years while
+// the spot follows a lognormal path at realizedVol with drift r - q. The
+// mean is the integral over the horizon of the expected discounted cash
+// gamma exp(-r*s)*gamma*x*x times (realizedVol^2 - impliedVol^2)/2, with
+// the gamma taken at vol, the vol of the hedge ratios, usually
+// impliedVol. The expectation has a closed form: it is the time 0 cash
+// gamma at the vol whose total variance to expiry is realizedVol^2*s +
+// vol^2*(timeToExpiry - s), the variance of the path to s plus that left
+// in the option, so it follows the gamma carried along the forward and
+// spread by the realized moves. The standard deviation is that of the
+// discrete rebalancing error, whose variance is dt/2 times the integral of
+// the expected squared discounted cash gamma times realizedVol^4 for a
+// rebalancing interval dt of 1/(DaysPerYear*rebalancesPerDay) years, so it
+// falls as one over the square root of the frequency. It leaves out the
+// spread of the continuous gamma P&L across paths when the vols differ,
+// and is +Inf at vol 0 with a positive realized vol, where the hedge
+// ratio jumps at the strike.
+func ExpectedGammaPnL(
+	impliedVol, realizedVol, timeHorizon, vol, timeToExpiry, spot, strike, r, q float64,
+	optionType OptionType, rebalancesPerDay float64,
+) (mean, stdev float64, err error) {
+
+	vi, vr, h, v, t, x, k, o := impliedVol, realizedVol, timeHorizon, vol, timeToExpiry, spot, strike, optionType
+
+	if vi < 0 || vr < 0 || v < 0 {
+		return nan(), nan(), ErrNegVol
+	}
+	if err = checkParams(t, x, k, r, q, o); err != nil {
+		return nan(), nan(), err
+	}
+	if !(h >= 0 && h <= t) {
+		return nan(), nan(), ErrHorizon
+	}
+	if !(rebalancesPerDay > 0) {
+		return nan(), nan(), ErrRebalance
+	}
+	if h == 0 || x == 0 {
+		return 0, 0, nil
+	}
+
+	dt := 1 / (DaysPerYear * rebalancesPerDay)
+	tol := 1e-12 * max(1, x)
+
+	// the expected discounted cash gamma at time s, integrated in w =
+	// sqrt(s) for the 1/sqrt(s) growth at the money when vol is 0
+	g := adaptiveSimpson(func(w float64) float64 {
+		s := w * w
+		ve := sqrt((vr*vr*s + v*v*(t-s)) / t)
+		return 2 * w * x * x * BSGamma(ve, t, x, k, r, q, o)
+	}, 0, sqrt(h), tol, 50)
+
+	mean = (vr*vr - vi*vi) / 2 * g
+
+	if vr == 0 {
+		return mean, 0, nil
+	}
+	if v == 0 {
+		return mean, inf(1), nil
+	}
+
+	legs := 1.0
+	if o == Straddle {
+		legs = 2
+	}
+	xq, f := discounted(x, q, t), Forward(x, r, q, t)
+
+	// the expected squared discounted cash gamma at time s, integrated in
+	// u = sqrt(t - s) for the 1/sqrt(t - s) growth near expiry
+	g2 := adaptiveSimpson(func(u float64) float64 {
+		s := t - u*u
+		w, sig := vr*vr*s, v*u
+		s2 := sqrt(sig*sig/2 + w)
+		z := (log(f/k) + 1.5*w + sig*sig/2) / s2
+		return 2 * u * legs * legs * xq * xq * exp(w) * NormPDF(z) / (2 * math.SqrtPi * sig * s2)
+	}, max(sqrt(t-h), sqrt(TimeFloor)), sqrt(t), tol, 50)
+
+	stdev = vr * vr * sqrt(dt/2*g2)
+
+	return mean, stdev, nil
+}
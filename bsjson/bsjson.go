@@ -0,0 +1,243 @@
+// Package bsjson defines JSON request and response types for serving the
+// blackscholes package, and pure functions dispatching requests to it.
+// Option types are the strings "call", "put" and "straddle". Omitted
+// optional fields take their defaults: zero rate and dividend yield, and
+// the ImpliedVol search defaults. Responses carry either results or an
+// Error, never both. Non-finite results, such as the infinite gamma of an
+// at the money option with zero vol, are the strings "NaN", "+Inf" and
+// "-Inf".
+package bsjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/pkg/errors"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+// Error codes
+const (
+	CodeInvalidParameter = "invalid_parameter"
+	CodeNoSolution       = "no_solution"
+)
+
+type Error struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Param   string `json:"param,omitempty"`
+}
+
+func (e *Error) Error() string {
+	if e.Param == "" {
+		return e.Code + ": " + e.Message
+	}
+	return e.Code + ": " + e.Param + ": " + e.Message
+}
+
+// Number is a float64 that marshals NaN and the infinities, which JSON
+// numbers cannot represent, as the strings "NaN", "+Inf" and "-Inf", and
+// unmarshals from numbers or those strings
+type Number float64
+
+func (n Number) MarshalJSON() ([]byte, error) {
+
+	switch f := float64(n); {
+	case math.IsNaN(f):
+		return []byte(`"NaN"`), nil
+	case math.IsInf(f, 1):
+		return []byte(`"+Inf"`), nil
+	case math.IsInf(f, -1):
+		return []byte(`"-Inf"`), nil
+	default:
+		return json.Marshal(f)
+	}
+}
+
+func (n *Number) UnmarshalJSON(b []byte) error {
+
+	var s string
+	if json.Unmarshal(b, &s) != nil {
+		return json.Unmarshal(b, (*float64)(n))
+	}
+
+	switch s {
+	case "NaN":
+		*n = Number(math.NaN())
+	case "+Inf":
+		*n = Number(math.Inf(1))
+	case "-Inf":
+		*n = Number(math.Inf(-1))
+	default:
+		return fmt.Errorf("bsjson: invalid number %q", s)
+	}
+
+	return nil
+}
+
+// number returns a pointer to f as a Number, for the result fields of
+// the responses, which are nil on errors
+func number(f float64) *Number {
+	n := Number(f)
+	return &n
+}
+
+type PriceRequest struct {
+	Vol          float64 `json:"vol"`
+	TimeToExpiry float64 `json:"timeToExpiry"`
+	Underlying   float64 `json:"underlying"`
+	Strike       float64 `json:"strike"`
+	Rate         float64 `json:"rate,omitempty"`
+	Dividend     float64 `json:"dividend,omitempty"`
+	Type         string  `json:"type"`
+}
+
+type PriceResponse struct {
+	Price *Number `json:"price,omitempty"`
+	Error *Error  `json:"error,omitempty"`
+}
+
+type GreeksRequest PriceRequest
+
+type GreeksResponse struct {
+	Price *Number `json:"price,omitempty"`
+	Delta *Number `json:"delta,omitempty"`
+	Gamma *Number `json:"gamma,omitempty"`
+	Vega  *Number `json:"vega,omitempty"`
+	Theta *Number `json:"theta,omitempty"`
+	Rho   *Number `json:"rho,omitempty"`
+	Error *Error  `json:"error,omitempty"`
+}
+
+type ImpliedVolRequest struct {
+	Premium      float64  `json:"premium"`
+	TimeToExpiry float64  `json:"timeToExpiry"`
+	Underlying   float64  `json:"underlying"`
+	Strike       float64  `json:"strike"`
+	Rate         float64  `json:"rate,omitempty"`
+	Dividend     float64  `json:"dividend,omitempty"`
+	Type         string   `json:"type"`
+	LB           *float64 `json:"lb,omitempty"`
+	UB           *float64 `json:"ub,omitempty"`
+	Tol          *float64 `json:"tol,omitempty"`
+	MaxIt        *int     `json:"maxIt,omitempty"`
+}
+
+type ImpliedVolResponse struct {
+	Vol   *Number `json:"vol,omitempty"`
+	Error *Error  `json:"error,omitempty"`
+}
+
+func HandlePrice(req PriceRequest) PriceResponse {
+
+	pars, e := priceParams(req)
+	if e != nil {
+		return PriceResponse{Error: e}
+	}
+
+	price, err := bs.Price(pars)
+	if err != nil {
+		return PriceResponse{Error: newError(err)}
+	}
+
+	return PriceResponse{Price: number(price)}
+}
+
+func HandleGreeks(req GreeksRequest) GreeksResponse {
+
+	pars, e := priceParams(PriceRequest(req))
+	if e != nil {
+		return GreeksResponse{Error: e}
+	}
+
+	g, err := bs.PriceAndGreeks(pars)
+	if err != nil {
+		return GreeksResponse{Error: newError(err)}
+	}
+
+	return GreeksResponse{
+		Price: number(g.Price),
+		Delta: number(g.Delta),
+		Gamma: number(g.Gamma),
+		Vega:  number(g.Vega),
+		Theta: number(g.Theta),
+		Rho:   number(g.Rho),
+	}
+}
+
+func HandleImpliedVol(req ImpliedVolRequest) ImpliedVolResponse {
+
+	o, err := bs.ParseOptionType(req.Type)
+	if err != nil {
+		return ImpliedVolResponse{Error: newError(err)}
+	}
+
+	vol, err := bs.ImpliedVol(&bs.ImpliedVolParams{
+		Premium:      req.Premium,
+		TimeToExpiry: req.TimeToExpiry,
+		Underlying:   req.Underlying,
+		Strike:       req.Strike,
+		Rate:         req.Rate,
+		Dividend:     req.Dividend,
+		Type:         o,
+		LB:           req.LB,
+		UB:           req.UB,
+		Tol:          req.Tol,
+		MaxIt:        req.MaxIt,
+	})
+	if err != nil {
+		return ImpliedVolResponse{Error: newError(err)}
+	}
+
+	return ImpliedVolResponse{Vol: number(vol)}
+}
+
+func priceParams(req PriceRequest) (*bs.PriceParams, *Error) {
+
+	o, err := bs.ParseOptionType(req.Type)
+	if err != nil {
+		return nil, newError(err)
+	}
+	if req.Vol < 0 {
+		return nil, newError(bs.ErrNegVol)
+	}
+
+	return &bs.PriceParams{
+		Vol:          req.Vol,
+		TimeToExpiry: req.TimeToExpiry,
+		Underlying:   req.Underlying,
+		Strike:       req.Strike,
+		Rate:         req.Rate,
+		Dividend:     req.Dividend,
+		Type:         o,
+	}, nil
+}
+
+// params maps core validation errors to the offending request field.
+// Discount exponent errors are mapped by their term, see newError.
+var params = map[error]string{
+	bs.ErrNegPremium:        "premium",
+	bs.ErrNegPrice:          "underlying",
+	bs.ErrNegStrike:         "strike",
+	bs.ErrNegVol:            "vol",
+	bs.ErrNegTimeToExp:      "timeToExpiry",
+	bs.ErrUnknownOptionType: "type",
+}
+
+func newError(err error) *Error {
+
+	var de *bs.DiscountExponentError
+	if errors.As(err, &de) {
+		return &Error{Code: CodeInvalidParameter, Message: err.Error(), Param: de.Term.String()}
+	}
+
+	for target, param := range params {
+		if errors.Is(err, target) {
+			return &Error{Code: CodeInvalidParameter, Message: err.Error(), Param: param}
+		}
+	}
+
+	return &Error{Code: CodeNoSolution, Message: err.Error()}
+}
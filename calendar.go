@@ -0,0 +1,60 @@
+package blackscholes
+
+import "github.com/pkg/errors"
+
+var ErrCalendar = errors.New("Near expiry not before far expiry")
+
+// CalendarGreeks holds the price and greeks of each leg of a calendar
+// spread, the short near option and the long far option, and their sum
+type CalendarGreeks struct {
+	Near, Far, Net Greeks
+}
+
+// PriceCalendarSpread returns the price of a calendar spread, long the
+// option expiring at tFar at volFar and short the option of the same
+// strike and type expiring at tNear at volNear. As tNear goes to 0 the
+// near leg tends to its intrinsic value.
+func PriceCalendarSpread(
+	volNear, volFar, tNear, tFar, spot, strike, r, q float64, optionType OptionType,
+) (float64, error) {
+
+	g, err := GreeksCalendarSpread(volNear, volFar, tNear, tFar, spot, strike, r, q, optionType)
+	return g.Net.Price, err
+}
+
+// GreeksCalendarSpread returns the BSGreeks of each leg of the calendar
+// spread of PriceCalendarSpread, the near leg's negated as it is short,
+// and their net. Net vega is usually positive, the far option having the
+// longer life, unless volNear is well above volFar.
+func GreeksCalendarSpread(
+	volNear, volFar, tNear, tFar, spot, strike, r, q float64, optionType OptionType,
+) (CalendarGreeks, error) {
+
+	bad := CalendarGreeks{Near: nanGreeks(), Far: nanGreeks(), Net: nanGreeks()}
+
+	if volNear < 0 || volFar < 0 {
+		return bad, ErrNegVol
+	}
+	if err := checkParams(tNear, spot, strike, r, q, optionType); err != nil {
+		return bad, err
+	}
+	if err := checkParams(tFar, spot, strike, r, q, optionType); err != nil {
+		return bad, err
+	}
+	if !(tNear < tFar) {
+		return bad, ErrCalendar
+	}
+
+	near := BSGreeks(volNear, tNear, spot, strike, r, q, optionType)
+	near = Greeks{
+		Price: -near.Price,
+		Delta: -near.Delta,
+		Gamma: -near.Gamma,
+		Vega:  -near.Vega,
+		Theta: -near.Theta,
+		Rho:   -near.Rho,
+	}
+	far := BSGreeks(volFar, tFar, spot, strike, r, q, optionType)
+
+	return CalendarGreeks{Near: near, Far: far, Net: addGreeks(near, far)}, nil
+}
@@ -0,0 +1,31 @@
+package blackscholes
+
+// DiscountFactor returns exp(-rate*t), the value now of 1 paid at
+// timeToExpiry under continuous compounding. Negative rates give factors
+// above 1, clamped to +Inf past the float64 range.
+func DiscountFactor(rate, timeToExpiry float64) float64 {
+	return discounted(1, rate, timeToExpiry)
+}
+
+// Forward returns the forward spot*exp((rate-dividendYield)*t) of a spot
+// carried at the rate less the dividend yield
+func Forward(spot, rate, dividendYield, timeToExpiry float64) float64 {
+	return discounted(spot, dividendYield-rate, timeToExpiry)
+}
+
+// ImpliedCarry returns the continuously compounded carry r - q implied
+// by a forward, log(forward/spot)/t. It is NaN unless the forward, spot
+// and time to expiry are positive.
+func ImpliedCarry(forward, spot, timeToExpiry float64) float64 {
+	if !(forward > 0 && spot > 0 && timeToExpiry > 0) {
+		return nan()
+	}
+	return log(forward/spot) / timeToExpiry
+}
+
+// discounted returns amount*exp(-rate*t) for amount >= 0, clamped as in
+// scaledExp. It is the one place the continuous compounding convention
+// of the package lives.
+func discounted(amount, rate, t float64) float64 {
+	return scaledExp(amount, -rate*t)
+}
@@ -0,0 +1,244 @@
+package blackscholes
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+var ErrChain = errors.New("Invalid option chain")
+
+// OptionChain holds the quotes of one expiry, T years away, sorted by
+// strike with calls before puts at the same strike. Rate is the
+// continuously compounded rate to expiry used to undiscount premia.
+type OptionChain struct {
+	T      float64
+	Rate   float64
+	Quotes []Quote
+}
+
+// NewOptionChain returns the chain of quotes sorted by strike, copying the
+// quotes. Strikes must be positive, types Call or Put, and bids no greater
+// than asks.
+func NewOptionChain(t, rate float64, quotes []Quote) (OptionChain, error) {
+
+	if !(t > 0) {
+		return OptionChain{}, ErrNegTimeToExp
+	}
+	if err := CheckDiscountExponents(t, rate, 0); err != nil {
+		return OptionChain{}, err
+	}
+
+	qs := append([]Quote(nil), quotes...)
+	for i := range qs {
+		q := &qs[i]
+		if !(q.Strike > 0) || q.Type != Call && q.Type != Put || q.Bid > q.Ask || q.mid() < 0 {
+			return OptionChain{}, ErrChain
+		}
+	}
+
+	sort.SliceStable(qs, func(i, j int) bool {
+		if qs[i].Strike != qs[j].Strike {
+			return qs[i].Strike < qs[j].Strike
+		}
+		return qs[i].Type == Call && qs[j].Type == Put
+	})
+
+	return OptionChain{T: t, Rate: rate, Quotes: qs}, nil
+}
+
+// Filter returns the chain of the quotes for which keep returns true
+func (c OptionChain) Filter(keep func(Quote) bool) OptionChain {
+
+	f := OptionChain{T: c.T, Rate: c.Rate}
+	for _, q := range c.Quotes {
+		if keep(q) {
+			f.Quotes = append(f.Quotes, q)
+		}
+	}
+
+	return f
+}
+
+// ImpliedVols returns the implied vol of each quote's mid, in quote order,
+// solved by ImpliedVolInto. Quotes that fail are NaN and reported in a
+// MultiError.
+func (c OptionChain) ImpliedVols(spot, r, q float64) ([]float64, error) {
+
+	inputs := make([]ImpliedVolParams, len(c.Quotes))
+	for i := range c.Quotes {
+		qt := &c.Quotes[i]
+		inputs[i] = ImpliedVolParams{
+			Premium:      qt.mid(),
+			TimeToExpiry: c.T,
+			Underlying:   spot,
+			Strike:       qt.Strike,
+			Rate:         r,
+			Dividend:     q,
+			Type:         qt.Type,
+		}
+	}
+
+	vols := make([]float64, len(inputs))
+	if err := ImpliedVolInto(vols, inputs); err != nil {
+		return vols, err
+	}
+
+	return vols, nil
+}
+
+// Forward returns the forward implied by put-call parity,
+// k + exp(Rate*T)*(call - put), at the strike whose call and put have the
+// smallest combined bid-ask spread. It returns ErrChain if no strike has
+// both.
+func (c OptionChain) Forward() (float64, error) {
+
+	f, best := nan(), inf(1)
+
+	for i := 0; i+1 < len(c.Quotes); i++ {
+		call, put := &c.Quotes[i], &c.Quotes[i+1]
+		if call.Strike != put.Strike || call.Type != Call || put.Type != Put {
+			continue
+		}
+		if s := call.Ask - call.Bid + put.Ask - put.Bid; s < best {
+			f, best = call.Strike+(call.mid()-put.mid())/DiscountFactor(c.Rate, c.T), s
+		}
+	}
+
+	if best == inf(1) {
+		return nan(), ErrChain
+	}
+	return f, nil
+}
+
+// ATMVol returns the implied vol at the at the money strike under conv,
+// see smileVol. The spot is only used by ATMSpot, and the delta neutral
+// straddle strike is iterated to the fixed point of the interpolated vol
+// as in DNSStrike.
+func (c OptionChain) ATMVol(conv ATMConvention, spot float64) (float64, error) {
+
+	f, err := c.Forward()
+	if err != nil {
+		return nan(), err
+	}
+
+	var k float64
+	switch conv {
+	case ATMSpot:
+		k, err = ATMStrike(conv, 0, c.T, spot, 0, 0)
+	case ATMForward:
+		k = f
+	case ATMDNS:
+		k, err = dnsFixedPoint(func(k float64) (float64, error) { return c.smileVol(f, k) }, f, c.T)
+	default:
+		err = ErrATMConvention
+	}
+	if err != nil {
+		return nan(), err
+	}
+
+	return c.smileVol(f, k)
+}
+
+// smileVol returns the implied vol at strike k, interpolated linearly in
+// strike by interpVol. A strike outside the out of the money quotes
+// returns ErrExtrapolation.
+func (c OptionChain) smileVol(f, k float64) (float64, error) {
+
+	v, extrapolated, err := c.interpVol(f, k, ATMInterpStrike)
+	switch {
+	case err != nil:
+		return nan(), err
+	case extrapolated:
+		return nan(), ErrExtrapolation
+	}
+
+	return v, nil
+}
+
+// interpVol returns the implied vol at strike k, interpolated by method
+// between the out of the money quotes, puts below the forward f and calls
+// above it, at the nearest strikes on either side of k. Vols are implied
+// from undiscounted mids against the forward, so no spot or dividend
+// yield is needed. A strike outside the out of the money quotes takes the
+// vol of the nearest one and reports extrapolated; a chain with none
+// returns ErrExtrapolation.
+func (c OptionChain) interpVol(f, k float64, method ATMInterp) (vol float64, extrapolated bool, err error) {
+
+	otm := func(q *Quote) bool {
+		return q.Strike <= f && q.Type == Put || q.Strike >= f && q.Type == Call
+	}
+
+	lo, hi := -1, -1
+	for i := range c.Quotes {
+		q := &c.Quotes[i]
+		if !otm(q) {
+			continue
+		}
+		if q.Strike <= k {
+			lo = i
+		}
+		if q.Strike >= k && hi < 0 {
+			hi = i
+		}
+	}
+
+	switch {
+	case lo < 0 && hi < 0:
+		return nan(), false, ErrExtrapolation
+	case lo < 0:
+		vol, err = c.forwardVol(f, &c.Quotes[hi])
+		return vol, true, err
+	case hi < 0:
+		vol, err = c.forwardVol(f, &c.Quotes[lo])
+		return vol, true, err
+	}
+
+	vlo, err := c.forwardVol(f, &c.Quotes[lo])
+	if err != nil {
+		return nan(), false, err
+	}
+	vhi, err := c.forwardVol(f, &c.Quotes[hi])
+	if err != nil {
+		return nan(), false, err
+	}
+
+	klo, khi := c.Quotes[lo].Strike, c.Quotes[hi].Strike
+	if khi == klo {
+		return (vlo + vhi) / 2, false, nil
+	}
+
+	v := vlo + (vhi-vlo)*(k-klo)/(khi-klo)
+	if method == ATMInterpStrike || vlo == vhi {
+		return v, false, nil
+	}
+
+	// linear in delta, where the delta of k depends on the vol at k
+	delta := func(k, v float64) float64 {
+		vs := v * sqrt(c.T)
+		return NormCDF(log(f/k)/vs + vs/2)
+	}
+	dlo, dhi := delta(klo, vlo), delta(khi, vhi)
+
+	for i := 0; i < 100; i++ {
+		next := vlo + (vhi-vlo)*(delta(k, v)-dlo)/(dhi-dlo)
+		if abs(next-v) <= 1e-14 {
+			return next, false, nil
+		}
+		v = next
+	}
+
+	return nan(), false, ErrNoncovergence
+}
+
+// forwardVol returns the implied vol of the undiscounted mid of q against
+// the forward f
+func (c OptionChain) forwardVol(f float64, q *Quote) (float64, error) {
+	return ImpliedVol(&ImpliedVolParams{
+		Premium:      q.mid() / DiscountFactor(c.Rate, c.T),
+		TimeToExpiry: c.T,
+		Underlying:   f,
+		Strike:       q.Strike,
+		Type:         q.Type,
+	})
+}
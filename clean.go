@@ -0,0 +1,344 @@
+package blackscholes
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+var ErrCleanConfig = errors.New("Invalid chain cleaning config")
+
+// RejectReason is why CleanChain rejected a quote
+type RejectReason uint8
+
+const (
+	// RejectNoVol is a mid with no implied vol, at or beyond the no
+	// arbitrage bounds of the premium
+	RejectNoVol RejectReason = iota
+	// RejectOutlier is an implied vol too far from the median of the
+	// vols of its neighbours
+	RejectOutlier
+	// RejectMonotonicity is a premium rising with strike for a call or
+	// falling for a put, or changing faster than the strike
+	RejectMonotonicity
+	// RejectConvexity is a premium above the line between the premia of
+	// its neighbours
+	RejectConvexity
+)
+
+func (r RejectReason) String() string {
+	switch r {
+	case RejectNoVol:
+		return "no vol"
+	case RejectOutlier:
+		return "outlier"
+	case RejectMonotonicity:
+		return "monotonicity"
+	case RejectConvexity:
+		return "convexity"
+	}
+	return fmt.Sprintf("RejectReason(%d)", uint8(r))
+}
+
+// Rejection is a quote rejected by CleanChain with the reason, its implied
+// vol against the forward, NaN if it has none, and whether it was
+// replaced by the parity value of the other side
+type Rejection struct {
+	Quote    Quote
+	Reason   RejectReason
+	Vol      float64
+	Replaced bool
+}
+
+// CleanConfig sets up CleanChain. Forward is the forward of the chain,
+// taken from OptionChain.Forward if 0. A vol is an outlier when it is
+// further than MADs scaled median absolute deviations plus VolTol from
+// the median vol of the Window quotes of the same type on either side,
+// fewer at the ends of the chain. Zero Window, MADs and VolTol take 2, 5
+// and 0.005. PriceTol is the undiscounted premium by which monotonicity
+// and convexity may be violated. Replace replaces rejected quotes by put
+// call parity from the quote of the other type at the same strike, when
+// that one is kept.
+type CleanConfig struct {
+	Forward  float64
+	Window   int
+	MADs     float64
+	VolTol   float64
+	PriceTol float64
+	Replace  bool
+}
+
+// CleanChain returns the chain without the quotes whose mids have no
+// implied vol, whose vols are outliers, or, among the remaining quotes of
+// each type, whose removal most reduces the violations of monotonicity
+// and convexity in strike, removed one at a time until none is left
+// beyond PriceTol. Vols are implied against the forward as in
+// ATMVolFromChain. The rejections are in chain order.
+func CleanChain(chain OptionChain, cfg CleanConfig) (OptionChain, []Rejection, error) {
+
+	if cfg.Window < 0 || cfg.MADs < 0 || cfg.VolTol < 0 || cfg.PriceTol < 0 || cfg.Forward < 0 {
+		return OptionChain{}, nil, ErrCleanConfig
+	}
+	if cfg.Window == 0 {
+		cfg.Window = 2
+	}
+	if cfg.MADs == 0 {
+		cfg.MADs = 5
+	}
+	if cfg.VolTol == 0 {
+		cfg.VolTol = 0.005
+	}
+
+	f := cfg.Forward
+	if f == 0 {
+		var err error
+		if f, err = chain.Forward(); err != nil {
+			return OptionChain{}, nil, err
+		}
+	}
+
+	n := len(chain.Quotes)
+	vols := make([]float64, n)
+	rejected := make([]bool, n)
+	reasons := make([]RejectReason, n)
+
+	df := DiscountFactor(chain.Rate, chain.T)
+	for i := range chain.Quotes {
+		qt := &chain.Quotes[i]
+		if _, outside := ClampToBounds(qt.mid()/df, chain.T, f, qt.Strike, 0, 0, qt.Type); outside {
+			vols[i], rejected[i], reasons[i] = nan(), true, RejectNoVol
+			continue
+		}
+		v, err := chain.forwardVol(f, qt)
+		if err != nil || !(v > 0) || v == inf(1) {
+			vols[i], rejected[i], reasons[i] = nan(), true, RejectNoVol
+			continue
+		}
+		vols[i] = v
+	}
+
+	for _, o := range []OptionType{Call, Put} {
+
+		var idx []int
+		for i := range chain.Quotes {
+			if chain.Quotes[i].Type == o && !rejected[i] {
+				idx = append(idx, i)
+			}
+		}
+
+		for _, i := range volOutliers(idx, vols, cfg) {
+			rejected[i], reasons[i] = true, RejectOutlier
+		}
+
+		var kept []int
+		for _, i := range idx {
+			if !rejected[i] {
+				kept = append(kept, i)
+			}
+		}
+
+		for {
+			i, reason := worstArbitrage(chain.Quotes, kept, o, df, cfg.PriceTol)
+			if i < 0 {
+				break
+			}
+			rejected[kept[i]], reasons[kept[i]] = true, reason
+			kept = append(kept[:i], kept[i+1:]...)
+		}
+	}
+
+	clean := OptionChain{T: chain.T, Rate: chain.Rate}
+	var rejections []Rejection
+
+	for i, q := range chain.Quotes {
+
+		if !rejected[i] {
+			clean.Quotes = append(clean.Quotes, q)
+			continue
+		}
+
+		rej := Rejection{Quote: q, Reason: reasons[i], Vol: vols[i]}
+		if cfg.Replace {
+			if p, ok := parityQuote(chain, rejected, i, f); ok {
+				clean.Quotes = append(clean.Quotes, p)
+				rej.Replaced = true
+			}
+		}
+		rejections = append(rejections, rej)
+	}
+
+	return clean, rejections, nil
+}
+
+// volOutliers returns the indices in idx, quotes of one type sorted by
+// strike, whose vols are outliers under cfg
+func volOutliers(idx []int, vols []float64, cfg CleanConfig) []int {
+
+	n, w := len(idx), cfg.Window
+	if n < 4 {
+		return nil
+	}
+
+	var out []int
+	neighbours := make([]float64, 0, 2*w)
+	devs := make([]float64, 0, 2*w)
+
+	for j, i := range idx {
+
+		lo := j - w
+		if lo > n-1-2*w {
+			lo = n - 1 - 2*w
+		}
+		if lo < 0 {
+			lo = 0
+		}
+		hi := lo + 2*w
+		if hi > n-1 {
+			hi = n - 1
+		}
+
+		neighbours = neighbours[:0]
+		for l := lo; l <= hi; l++ {
+			if l != j {
+				neighbours = append(neighbours, vols[idx[l]])
+			}
+		}
+		m := median(neighbours)
+
+		devs = devs[:0]
+		for _, v := range neighbours {
+			devs = append(devs, abs(v-m))
+		}
+
+		// 1.4826 scales the MAD to the standard deviation of a normal
+		if abs(vols[i]-m) > cfg.MADs*1.4826*median(devs)+cfg.VolTol {
+			out = append(out, i)
+		}
+	}
+
+	return out
+}
+
+// median returns the median of xs, reordering them
+func median(xs []float64) float64 {
+
+	sort.Float64s(xs)
+	n := len(xs)
+	if n%2 == 1 {
+		return xs[n/2]
+	}
+
+	return (xs[n/2-1] + xs[n/2]) / 2
+}
+
+// worstArbitrage returns the position in kept, quotes of type o sorted by
+// strike, of the quote whose removal most reduces the total violation of
+// monotonicity and convexity of the undiscounted premia beyond tol, and
+// the kind of violation it takes part in, or -1 if there is none
+func worstArbitrage(quotes []Quote, kept []int, o OptionType, df, tol float64) (int, RejectReason) {
+
+	ks, ps := make([]float64, len(kept)), make([]float64, len(kept))
+	for j, i := range kept {
+		ks[j], ps[j] = quotes[i].Strike, quotes[i].mid()/df
+	}
+
+	total, _ := arbViolation(ks, ps, o, tol, -1, -1)
+	if total == 0 {
+		return -1, 0
+	}
+
+	best, bestTotal := -1, total
+	for j := range kept {
+		if t, _ := arbViolation(ks, ps, o, tol, j, -1); t < bestTotal {
+			best, bestTotal = j, t
+		}
+	}
+	if best < 0 {
+		return -1, 0
+	}
+
+	if _, monotone := arbViolation(ks, ps, o, tol, -1, best); monotone {
+		return best, RejectMonotonicity
+	}
+
+	return best, RejectConvexity
+}
+
+// arbViolation returns the total violation beyond tol of monotonicity and
+// convexity of the premia ps at increasing strikes ks, skipping position
+// skip, and whether position watch takes part in a monotonicity
+// violation. Negative positions are none.
+func arbViolation(ks, ps []float64, o OptionType, tol float64, skip, watch int) (total float64, monotone bool) {
+
+	var pos []int
+	for j := range ks {
+		if j != skip {
+			pos = append(pos, j)
+		}
+	}
+
+	// a call falls with strike, a put rises, at most one for one
+	sign := -1.0
+	if o == Put {
+		sign = 1
+	}
+
+	for l := 1; l < len(pos); l++ {
+		a, b := pos[l-1], pos[l]
+		dp := sign * (ps[b] - ps[a])
+		for _, v := range []float64{-dp - tol, dp - (ks[b] - ks[a]) - tol} {
+			if v > 0 {
+				total += v
+				monotone = monotone || a == watch || b == watch
+			}
+		}
+	}
+
+	for l := 1; l+1 < len(pos); l++ {
+		a, b, c := pos[l-1], pos[l], pos[l+1]
+		w := (ks[b] - ks[a]) / (ks[c] - ks[a])
+		if v := ps[b] - (1-w)*ps[a] - w*ps[c] - tol; v > 0 {
+			total += v
+		}
+	}
+
+	return total, monotone
+}
+
+// parityQuote returns the quote i of chain implied by put-call parity
+// from the kept quote of the other type at its strike, shifting the bid,
+// ask and mid by the discounted forward less strike, if there is one and
+// its mid and ask are not negative
+func parityQuote(chain OptionChain, rejected []bool, i int, f float64) (Quote, bool) {
+
+	q := chain.Quotes[i]
+
+	j := i + 1
+	if q.Type == Put {
+		j = i - 1
+	}
+	if j < 0 || j >= len(chain.Quotes) || rejected[j] || chain.Quotes[j].Strike != q.Strike {
+		return Quote{}, false
+	}
+	other := chain.Quotes[j]
+
+	// call - put = df*(f - k)
+	shift := DiscountFactor(chain.Rate, chain.T) * (f - q.Strike)
+	if q.Type == Put {
+		shift = -shift
+	}
+
+	p := Quote{
+		Strike: q.Strike,
+		Bid:    max(other.Bid+shift, 0),
+		Ask:    other.Ask + shift,
+		Mid:    other.mid() + shift,
+		Type:   q.Type,
+	}
+	if p.Mid < 0 || p.Ask < p.Bid {
+		return Quote{}, false
+	}
+
+	return p, true
+}
@@ -0,0 +1,227 @@
+// Command blackscholes prices European options, computes their greeks and
+// inverts premia to implied volatilities from the command line.
+//
+//	blackscholes price --vol 0.2 --t 0.5 --spot 100 --strike 110 --type call
+//	blackscholes greeks --vol 0.2 --expiry 2025-06-20 --spot 100 --strike 110
+//	blackscholes implied-vol --premium 4.2 --t 0.5 --spot 100 --strike 110
+//
+// Time to expiry is given either in years with --t or as an --expiry date,
+// measured actual/365 from --now (default today). Vols are annualized
+// unless --vol-periods gives the periods per year they are quoted over,
+// e.g. 252 for daily vols. Output is plain text unless --json is set,
+// where non-finite values are the strings "NaN", "+Inf" and "-Inf" as in
+// package bsjson. Validation errors go to stderr with exit code 1; usage
+// errors exit with code 2.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"time"
+
+	bs "github.com/uscott/go-blackscholes"
+	"github.com/uscott/go-blackscholes/bsjson"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+type options struct {
+	vol, t, spot, strike, rate, div, premium float64
+	volPeriods                               float64
+	expiry, now, optType                     string
+	json                                     bool
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, "usage: blackscholes price|greeks|implied-vol [flags]")
+		return 2
+	}
+
+	cmd := args[0]
+	switch cmd {
+	case "price", "greeks", "implied-vol":
+	default:
+		fmt.Fprintf(stderr, "unknown command %q\n", cmd)
+		return 2
+	}
+
+	opts := new(options)
+	fs := flag.NewFlagSet(cmd, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Float64Var(&opts.t, "t", math.NaN(), "time to expiry in years")
+	fs.StringVar(&opts.expiry, "expiry", "", "expiry date YYYY-MM-DD, alternative to --t")
+	fs.StringVar(&opts.now, "now", "", "valuation date YYYY-MM-DD for --expiry, default today")
+	fs.Float64Var(&opts.spot, "spot", math.NaN(), "underlying price")
+	fs.Float64Var(&opts.strike, "strike", math.NaN(), "strike price")
+	fs.Float64Var(&opts.rate, "rate", 0, "continuously compounded interest rate")
+	fs.Float64Var(&opts.div, "div", 0, "continuous dividend yield")
+	fs.StringVar(&opts.optType, "type", "call", "call, put or straddle")
+	fs.BoolVar(&opts.json, "json", false, "write JSON output")
+	fs.Float64Var(&opts.volPeriods, "vol-periods", 1, "periods per year of the vol, e.g. 252 for daily vols")
+	if cmd == "implied-vol" {
+		fs.Float64Var(&opts.premium, "premium", math.NaN(), "option premium")
+	} else {
+		fs.Float64Var(&opts.vol, "vol", math.NaN(), "volatility")
+	}
+
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	out, err := evaluate(cmd, opts)
+	if err != nil {
+		fmt.Fprintf(stderr, "blackscholes %s: %v\n", cmd, err)
+		return 1
+	}
+
+	if opts.json {
+		enc := json.NewEncoder(stdout)
+		if err := enc.Encode(out); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		return 0
+	}
+
+	for _, kv := range out {
+		fmt.Fprintf(stdout, "%-8s %.10g\n", kv.key, kv.value)
+	}
+
+	return 0
+}
+
+type field struct {
+	key   string
+	value float64
+}
+
+// result keeps output fields in order for plain text and marshals to a
+// JSON object of bsjson.Numbers
+type result []field
+
+func (r result) MarshalJSON() ([]byte, error) {
+
+	buf := []byte{'{'}
+
+	for i, kv := range r {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		k, _ := json.Marshal(kv.key)
+		v, err := json.Marshal(bsjson.Number(kv.value))
+		if err != nil {
+			return nil, err
+		}
+		buf = append(append(append(buf, k...), ':'), v...)
+	}
+
+	return append(buf, '}'), nil
+}
+
+func evaluate(cmd string, opts *options) (result, error) {
+
+	o, err := bs.ParseOptionType(opts.optType)
+	if err != nil {
+		return nil, fmt.Errorf("--type %q: %v", opts.optType, err)
+	}
+
+	t, err := timeToExpiry(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if !(opts.volPeriods > 0) {
+		return nil, fmt.Errorf("--vol-periods must be positive")
+	}
+
+	for _, f := range []struct {
+		name  string
+		value float64
+	}{{"spot", opts.spot}, {"strike", opts.strike}} {
+		if math.IsNaN(f.value) {
+			return nil, fmt.Errorf("--%s is required", f.name)
+		}
+	}
+
+	if cmd == "implied-vol" {
+
+		if math.IsNaN(opts.premium) {
+			return nil, fmt.Errorf("--premium is required")
+		}
+
+		vol, err := bs.ImpliedVol(&bs.ImpliedVolParams{
+			Premium: opts.premium, TimeToExpiry: t, Underlying: opts.spot,
+			Strike: opts.strike, Rate: opts.rate, Dividend: opts.div, Type: o,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return result{{"vol", vol / math.Sqrt(opts.volPeriods)}}, nil
+	}
+
+	if math.IsNaN(opts.vol) {
+		return nil, fmt.Errorf("--vol is required")
+	}
+
+	pars := &bs.PriceParams{
+		Vol: opts.vol * math.Sqrt(opts.volPeriods), TimeToExpiry: t, Underlying: opts.spot,
+		Strike: opts.strike, Rate: opts.rate, Dividend: opts.div, Type: o,
+	}
+
+	if cmd == "price" {
+		price, err := bs.Price(pars)
+		if err != nil {
+			return nil, err
+		}
+		return result{{"price", price}}, nil
+	}
+
+	g, err := bs.PriceAndGreeks(pars)
+	if err != nil {
+		return nil, err
+	}
+
+	return result{
+		{"price", g.Price},
+		{"delta", g.Delta},
+		{"gamma", g.Gamma},
+		{"vega", g.Vega},
+		{"theta", g.Theta},
+		{"rho", g.Rho},
+	}, nil
+}
+
+func timeToExpiry(opts *options) (float64, error) {
+
+	switch {
+	case opts.expiry == "" && math.IsNaN(opts.t):
+		return 0, fmt.Errorf("one of --t or --expiry is required")
+	case opts.expiry != "" && !math.IsNaN(opts.t):
+		return 0, fmt.Errorf("--t and --expiry are mutually exclusive")
+	case opts.expiry == "":
+		return opts.t, nil
+	}
+
+	expiry, err := time.Parse(bs.DateLayout, opts.expiry)
+	if err != nil {
+		return 0, fmt.Errorf("--expiry: %v", err)
+	}
+
+	now := time.Now().UTC().Truncate(24 * time.Hour)
+	if opts.now != "" {
+		if now, err = time.Parse(bs.DateLayout, opts.now); err != nil {
+			return 0, fmt.Errorf("--now: %v", err)
+		}
+	}
+
+	return bs.YearFraction(now, expiry), nil
+}
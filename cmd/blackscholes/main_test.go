@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+	"github.com/uscott/go-blackscholes/bsjson"
+)
+
+func runArgs(args string) (code int, stdout, stderr string) {
+	var out, errOut bytes.Buffer
+	code = run(strings.Fields(args), &out, &errOut)
+	return code, out.String(), errOut.String()
+}
+
+func Test_Price(t *testing.T) {
+
+	code, out, errOut := runArgs(
+		"price --vol 0.2 --t 0.5 --spot 100 --strike 110 --rate 0.05 --div 0.01 --type call --json",
+	)
+	if code != 0 {
+		t.Fatalf("code = %d, stderr = %s", code, errOut)
+	}
+
+	var got map[string]float64
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := bs.BSPrice(0.2, 0.5, 100, 110, 0.05, 0.01, bs.Call); got["price"] != want {
+		t.Errorf("price = %v, want %v", got["price"], want)
+	}
+
+	// a daily vol
+	code, out, errOut = runArgs(
+		"price --vol 0.0125 --vol-periods 256 --t 0.5 --spot 100 --strike 110 --rate 0.05 --div 0.01 --json",
+	)
+	if err := json.Unmarshal([]byte(out), &got); code != 0 || err != nil {
+		t.Fatalf("code = %d, stderr = %s", code, errOut)
+	}
+	if want := bs.BSPrice(0.2, 0.5, 100, 110, 0.05, 0.01, bs.Call); got["price"] != want {
+		t.Errorf("daily vol price = %v, want %v", got["price"], want)
+	}
+
+	code, out, _ = runArgs("price --vol 0.2 --t 0.5 --spot 100 --strike 110 --type p")
+	if code != 0 || !strings.HasPrefix(out, "price ") {
+		t.Errorf("code = %d, stdout = %q", code, out)
+	}
+}
+
+func Test_Greeks(t *testing.T) {
+
+	code, out, errOut := runArgs(
+		"greeks --vol 0.3 --expiry 2025-06-20 --now 2025-01-01 --spot 100 --strike 95 --type straddle --json",
+	)
+	if code != 0 {
+		t.Fatalf("code = %d, stderr = %s", code, errOut)
+	}
+
+	var got bs.Greeks
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := bs.BSGreeks(0.3, 170.0/365, 100, 95, 0, 0, bs.Straddle); got != want {
+		t.Errorf("greeks = %+v, want %+v", got, want)
+	}
+
+	code, out, _ = runArgs("greeks --vol 0.3 --t 1 --spot 100 --strike 95")
+	if lines := strings.Split(strings.TrimSpace(out), "\n"); code != 0 || len(lines) != 6 {
+		t.Errorf("code = %d, stdout = %q", code, out)
+	}
+
+	// the gamma of an at the money option with zero vol is infinite
+	code, out, errOut = runArgs("greeks --vol 0 --t 1 --spot 100 --strike 100 --json")
+	if code != 0 {
+		t.Fatalf("code = %d, stderr = %s", code, errOut)
+	}
+
+	var raw map[string]bsjson.Number
+	if err := json.Unmarshal([]byte(out), &raw); err != nil {
+		t.Fatal(err)
+	}
+	if !math.IsInf(float64(raw["gamma"]), 1) || raw["delta"] != 0.5 {
+		t.Errorf("greeks = %s", out)
+	}
+}
+
+func Test_ImpliedVol(t *testing.T) {
+
+	premium := bs.BSPrice(0.25, 0.5, 100, 105, 0.03, 0, bs.Put)
+	args := []string{
+		"implied-vol", "--premium", strconv.FormatFloat(premium, 'g', -1, 64),
+		"--t", "0.5", "--spot", "100", "--strike", "105", "--rate", "0.03",
+		"--type", "put", "--json",
+	}
+
+	var out, errOut bytes.Buffer
+	if code := run(args, &out, &errOut); code != 0 {
+		t.Fatalf("code = %d, stderr = %s", code, errOut.String())
+	}
+
+	var got map[string]float64
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if d := got["vol"] - 0.25; d > 1e-8 || d < -1e-8 {
+		t.Errorf("vol = %v", got["vol"])
+	}
+}
+
+func Test_Errors(t *testing.T) {
+
+	cases := []struct {
+		args string
+		code int
+		msg  string
+	}{
+		{"", 2, "usage"},
+		{"delta --vol 0.2", 2, "unknown command"},
+		{"price --vol 0.2 --t 0.5 --spot 100 --bogus 1", 2, "bogus"},
+		{"price --vol 0.2 --spot 100 --strike 100", 1, "--t or --expiry"},
+		{"price --vol 0.2 --t 1 --expiry 2025-01-01 --spot 100 --strike 100", 1, "mutually exclusive"},
+		{"price --vol 0.2 --expiry 20250101 --spot 100 --strike 100", 1, "--expiry"},
+		{"price --vol 0.2 --t 1 --spot 100", 1, "--strike is required"},
+		{"price --t 1 --spot 100 --strike 100", 1, "--vol is required"},
+		{"price --vol 0.2 --t 1 --spot 100 --strike 100 --type x", 1, "--type"},
+		{"price --vol 0.2 --t 1 --spot 100 --strike -100", 1, bs.ErrNegStrike.Error()},
+		{"greeks --vol 0.2 --t -1 --spot 100 --strike 100", 1, bs.ErrNegTimeToExp.Error()},
+		{"implied-vol --t 1 --spot 100 --strike 100", 1, "--premium is required"},
+		{"price --vol 0.2 --vol-periods 0 --t 1 --spot 100 --strike 100", 1, "--vol-periods"},
+	}
+
+	for _, c := range cases {
+		code, out, errOut := runArgs(c.args)
+		if code != c.code || out != "" || !strings.Contains(errOut, c.msg) {
+			t.Errorf("%q: code = %d, stdout = %q, stderr = %q", c.args, code, out, errOut)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package blackscholes
+
+import "fmt"
+
+// StrikeRangeError is returned by ImpliedStrike when no strike between Lo
+// and Hi prices to the premium
+type StrikeRangeError struct {
+	Premium, Lo, Hi float64
+}
+
+func (e *StrikeRangeError) Error() string {
+	return fmt.Sprintf("No strike in [%g, %g] prices to premium %g", e.Lo, e.Hi, e.Premium)
+}
+
+// ImpliedStrike returns the strike at which a call or put priced at the
+// vol of the source for that strike is worth premium. The strike is
+// bisected in log strike to a relative 1e-12 within 8 standard
+// deviations, at the source's vol at the forward or at least 1%, either
+// side of the forward, and a premium not priced in that range returns a
+// *StrikeRangeError.
+func ImpliedStrike(
+	premium float64, vol VolSource, timeToExpiry, spot, r, q float64, optionType OptionType,
+) (float64, error) {
+
+	t, x, o := timeToExpiry, spot, optionType
+
+	if vol == nil {
+		return nan(), ErrNilPtrArg
+	}
+	if err := checkParams(t, x, 0, r, q, o); err != nil {
+		return nan(), err
+	}
+	if o != Call && o != Put {
+		return nan(), ErrUnknownOptionType
+	}
+	if !(premium > 0) {
+		return nan(), ErrNegPremium
+	}
+
+	f := Forward(x, r, q, t)
+	v, err := vol.Vol(f, t)
+	if err != nil {
+		return nan(), err
+	}
+	w := 8 * max(v*sqrt(t), 0.01)
+
+	var verr error
+	diff := func(logk float64) float64 {
+		k := f * exp(logk)
+		v, err := vol.Vol(k, t)
+		if err != nil {
+			verr = err
+			return nan()
+		}
+		return BSPrice(v, t, x, k, r, q, o) - premium
+	}
+
+	lo, hi := diff(-w), diff(w)
+	if verr != nil {
+		return nan(), verr
+	}
+	if !(lo*hi <= 0) {
+		return nan(), &StrikeRangeError{Premium: premium, Lo: f * exp(-w), Hi: f * exp(w)}
+	}
+
+	logk := bisect(diff, -w, w, 1e-12)
+	if verr != nil {
+		return nan(), verr
+	}
+
+	return f * exp(logk), nil
+}
+
+// SolveCollar returns the strike of the call that costs the same as the
+// put at putStrike, both priced at the vols of the source for their
+// strikes, so that long the put and short the call is a zero cost
+// collar. A downside skew makes the put dearer and moves the call strike
+// closer to the spot. It returns a *StrikeRangeError if ImpliedStrike
+// finds no such call.
+func SolveCollar(
+	vol VolSource, timeToExpiry, spot, r, q float64, putStrike float64,
+) (callStrike float64, err error) {
+	return solveCollar(vol, timeToExpiry, spot, r, q, putStrike, Put)
+}
+
+// SolveCollarPut is SolveCollar given the call strike, returning the
+// strike of the put of the same premium
+func SolveCollarPut(
+	vol VolSource, timeToExpiry, spot, r, q float64, callStrike float64,
+) (putStrike float64, err error) {
+	return solveCollar(vol, timeToExpiry, spot, r, q, callStrike, Call)
+}
+
+// solveCollar returns the strike of the option of the other type from o
+// with the premium of o at strike k
+func solveCollar(vol VolSource, t, x, r, q, k float64, o OptionType) (float64, error) {
+
+	if vol == nil {
+		return nan(), ErrNilPtrArg
+	}
+	if err := checkParams(t, x, k, r, q, o); err != nil {
+		return nan(), err
+	}
+
+	v, err := vol.Vol(k, t)
+	if err != nil {
+		return nan(), err
+	}
+
+	other := Call
+	if o == Call {
+		other = Put
+	}
+
+	return ImpliedStrike(BSPrice(v, t, x, k, r, q, o), vol, t, x, r, q, other)
+}
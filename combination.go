@@ -0,0 +1,102 @@
+package blackscholes
+
+// A strip is one call and two puts, and a strap two calls and one put, at
+// the same strike and expiry. Their prices and greeks are those of
+// BSGreeks summed over the legs, so they take the zero vol, zero spot and
+// zero strike closed forms of the legs at the boundaries.
+
+// PriceStrip returns the price of one call and two puts
+func PriceStrip(vol, timeToExpiry, spot, strike, r, q float64) (float64, error) {
+	g, err := comboGreeks(1, 2, vol, timeToExpiry, spot, strike, r, q)
+	return g.Price, err
+}
+
+// StripGreeks returns the price and greeks of one call and two puts
+func StripGreeks(vol, timeToExpiry, spot, strike, r, q float64) (Greeks, error) {
+	return comboGreeks(1, 2, vol, timeToExpiry, spot, strike, r, q)
+}
+
+// ImpliedVolStrip returns the vol at which one call and two puts are
+// worth premium, see ImpliedVolStrap
+func ImpliedVolStrip(premium, timeToExpiry, spot, strike, r, q float64) (float64, error) {
+	return comboImpliedVol(1, 2, premium, timeToExpiry, spot, strike, r, q)
+}
+
+// PriceStrap returns the price of two calls and one put
+func PriceStrap(vol, timeToExpiry, spot, strike, r, q float64) (float64, error) {
+	g, err := comboGreeks(2, 1, vol, timeToExpiry, spot, strike, r, q)
+	return g.Price, err
+}
+
+// StrapGreeks returns the price and greeks of two calls and one put
+func StrapGreeks(vol, timeToExpiry, spot, strike, r, q float64) (Greeks, error) {
+	return comboGreeks(2, 1, vol, timeToExpiry, spot, strike, r, q)
+}
+
+// ImpliedVolStrap returns the vol at which two calls and one put are worth
+// premium. The premium must lie between the intrinsic value of the legs,
+// which has vol 0, and the discounted spot per call plus the discounted
+// strike per put, which no vol reaches; otherwise ErrArbitrage is
+// returned. At zero spot or strike and below TimeFloor the price does not
+// depend on the vol, which is returned as 0 as in ImpliedVol.
+func ImpliedVolStrap(premium, timeToExpiry, spot, strike, r, q float64) (float64, error) {
+	return comboImpliedVol(2, 1, premium, timeToExpiry, spot, strike, r, q)
+}
+
+// comboGreeks returns the greeks of calls calls and puts puts
+func comboGreeks(calls, puts, v, t, x, k, r, q float64) (Greeks, error) {
+
+	if v < 0 {
+		return nanGreeks(), ErrNegVol
+	}
+	if err := checkParams(t, x, k, r, q, Call); err != nil {
+		return nanGreeks(), err
+	}
+
+	c, p := BSGreeks(v, t, x, k, r, q, Call), BSGreeks(v, t, x, k, r, q, Put)
+
+	return Greeks{
+		Price: calls*c.Price + puts*p.Price,
+		Delta: calls*c.Delta + puts*p.Delta,
+		Gamma: calls*c.Gamma + puts*p.Gamma,
+		Vega:  calls*c.Vega + puts*p.Vega,
+		Theta: calls*c.Theta + puts*p.Theta,
+		Rho:   calls*c.Rho + puts*p.Rho,
+	}, nil
+}
+
+// comboImpliedVol bisects the vol of calls calls and puts puts worth
+// premium, doubling the upper vol until it brackets the premium
+func comboImpliedVol(calls, puts, premium, t, x, k, r, q float64) (float64, error) {
+
+	if err := checkParams(t, x, k, r, q, Call); err != nil {
+		return nan(), err
+	}
+
+	if t < TimeFloor || x == 0 || k == 0 {
+		return 0, nil
+	}
+
+	price := func(v float64) float64 {
+		return calls*BSPriceNoErrorCheck(v, t, x, k, r, q, Call) + puts*BSPriceNoErrorCheck(v, t, x, k, r, q, Put)
+	}
+
+	lo := price(0)
+	hi := calls*discounted(x, q, t) + puts*discounted(k, r, t)
+
+	switch {
+	case !(premium >= lo && premium < hi):
+		return nan(), ErrArbitrage
+	case premium == lo:
+		return 0, nil
+	}
+
+	ub := 1.0
+	for price(ub) < premium {
+		if ub *= 2; ub > 1e6 {
+			return nan(), ErrNoncovergence
+		}
+	}
+
+	return bisect(func(v float64) float64 { return price(v) - premium }, 0, ub, 1e-14), nil
+}
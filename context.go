@@ -0,0 +1,184 @@
+package blackscholes
+
+// PricingContext prices options sharing time to expiry, underlying, rate
+// and dividend yield. sqrt(t) and the discount factors are computed once
+// by NewPricingContext and the context is immutable afterwards, so it is
+// safe for concurrent use. Methods return NaN for invalid strikes or
+// option types, like the BS functions.
+//
+// Normal CDFs and densities are evaluated through the context's Normal,
+// StdNormal unless replaced with WithNormal, on the boundary cases and
+// below NearExpiryTotalVol as well.
+type PricingContext struct {
+	t, x, r, q float64
+	sqrtt      float64
+	dfq, dfr   float64
+	xq         float64
+	boundary   bool
+	n          Normal // nil for StdNormal
+}
+
+// NewPricingContext validates and caches the strike independent inputs
+// t = time to expiry
+// x = value of spot/underlying
+// r = continuously compounded interest rate in same units as t
+// q = continuous dividend yield in same units as t
+func NewPricingContext(t, x, r, q float64) (*PricingContext, error) {
+
+	switch {
+	case t < 0:
+		return nil, ErrNegTimeToExp
+	case x < 0:
+		return nil, ErrNegPrice
+	}
+
+	if err := checkExponents(t, x, 0, r, q); err != nil {
+		return nil, err
+	}
+
+	dfq := DiscountFactor(q, t)
+
+	return &PricingContext{
+		t: t, x: x, r: r, q: q,
+		sqrtt:    sqrt(t),
+		dfq:      dfq,
+		dfr:      DiscountFactor(r, t),
+		xq:       discounted(x, q, t),
+		boundary: x == 0 || t < TimeFloor,
+	}, nil
+}
+
+func (c *PricingContext) TimeToExpiry() float64 { return c.t }
+func (c *PricingContext) Underlying() float64   { return c.x }
+func (c *PricingContext) Rate() float64         { return c.r }
+func (c *PricingContext) Dividend() float64     { return c.q }
+
+// Normal returns the distribution backend of the context
+func (c *PricingContext) Normal() Normal {
+	if c.n == nil {
+		return StdNormal{}
+	}
+	return c.n
+}
+
+// WithNormal returns a copy of the context evaluating the normal
+// distribution through n, or through StdNormal if n is nil. The receiver
+// is unchanged.
+func (c *PricingContext) WithNormal(n Normal) *PricingContext {
+	cp := *c
+	cp.n = n
+	if _, ok := n.(StdNormal); ok {
+		cp.n = nil
+	}
+	return &cp
+}
+
+func (c *PricingContext) cdf(x float64) float64 { return normCDF(c.n, x) }
+func (c *PricingContext) pdf(x float64) float64 { return normPDF(c.n, x) }
+
+// interior reports whether (v, k, o) can use the cached fast path
+func (c *PricingContext) interior(v, k float64, o OptionType) bool {
+	return v > 0 && k > 0 && !c.boundary && ValidOptionType(o) && v*c.sqrtt >= NearExpiryTotalVol
+}
+
+// fallback evaluates kernel through the context's Normal off the fast
+// path, NaN for an invalid strike or option type as in the BS functions
+func (c *PricingContext) fallback(
+	kernel func(m model, v, t, x, k, r, q float64, o OptionType) float64, v, k float64, o OptionType,
+) float64 {
+
+	if checkParams(c.t, c.x, k, c.r, c.q, o) != nil {
+		return nan()
+	}
+
+	return kernel(model{n: c.n}, v, c.t, c.x, k, c.r, c.q, o)
+}
+
+func (c *PricingContext) d1(v, k float64) float64 {
+	return (log(c.x/k) + (c.r-c.q+0.5*v*v)*c.t) / v / c.sqrtt
+}
+
+func (c *PricingContext) Price(v, k float64, o OptionType) float64 {
+
+	if !c.interior(v, k, o) {
+		return c.fallback(priceKernel, v, k, o)
+	}
+
+	d1 := c.d1(v, k)
+	d2 := d1 - v*c.sqrtt
+	kr := c.dfr * k
+
+	if o == Put {
+		return c.cdf(-d2)*kr - c.cdf(-d1)*c.xq
+	}
+
+	Nd1, Nd2 := c.cdf(d1), c.cdf(d2)
+	if o == Call {
+		return Nd1*c.xq - Nd2*kr
+	}
+
+	return (2*Nd1-1)*c.xq - (2*Nd2-1)*kr
+}
+
+func (c *PricingContext) Delta(v, k float64, o OptionType) float64 {
+
+	if !c.interior(v, k, o) {
+		return c.fallback(deltaKernel, v, k, o)
+	}
+
+	Nd1 := c.cdf(c.d1(v, k))
+
+	switch o {
+	case Call:
+		return c.dfq * Nd1
+	case Put:
+		return c.dfq * (Nd1 - 1)
+	}
+
+	return c.dfq * (2*Nd1 - 1)
+}
+
+func (c *PricingContext) Gamma(v, k float64, o OptionType) float64 {
+
+	if !c.interior(v, k, o) {
+		return c.fallback(gammaKernel, v, k, o)
+	}
+
+	gamma := c.dfq * c.pdf(c.d1(v, k)) / c.x / v / c.sqrtt
+
+	if o == Straddle {
+		return 2 * gamma
+	}
+	return gamma
+}
+
+func (c *PricingContext) Vega(v, k float64, o OptionType) float64 {
+
+	if !c.interior(v, k, o) {
+		return c.fallback(vegaKernel, v, k, o)
+	}
+
+	vega := c.xq * c.pdf(c.d1(v, k)) * c.sqrtt
+
+	if o == Straddle {
+		return 2 * vega
+	}
+	return vega
+}
+
+func (c *PricingContext) Theta(v, k float64, o OptionType) float64 {
+	return c.Greeks(v, k, o).Theta
+}
+
+// Greeks returns the same values as BSGreeks
+func (c *PricingContext) Greeks(v, k float64, o OptionType) Greeks {
+
+	if !c.interior(v, k, o) {
+		if checkParams(c.t, c.x, k, c.r, c.q, o) != nil {
+			return nanGreeks()
+		}
+		return greeksKernel(model{n: c.n}, v, c.t, c.x, k, c.r, c.q, c.dfq, c.dfr, o)
+	}
+
+	return interiorGreeks(c.n, v, c.t, c.x, k, c.r, c.q, c.sqrtt, c.dfq, c.dfr, o)
+}
@@ -0,0 +1,132 @@
+package blackscholes
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrDefault            = errors.New("Invalid default parameters")
+	ErrRecoveryConvention = errors.New("Unknown recovery convention")
+)
+
+// RecoveryConvention is what the holder of an option recovers when its
+// writer defaults
+type RecoveryConvention uint8
+
+const (
+	// RecoveryMarketValue recovers the recovery rate times the default
+	// free value of the option at default, the same as discounting at the
+	// rate plus hazardRate*(1 - recoveryRate)
+	RecoveryMarketValue RecoveryConvention = iota
+	// RecoveryFixed recovers the recovery rate times the payoff at expiry
+	// on default at any time before
+	RecoveryFixed
+)
+
+func (c RecoveryConvention) String() string {
+	switch c {
+	case RecoveryMarketValue:
+		return "market value"
+	case RecoveryFixed:
+		return "fixed"
+	}
+	return fmt.Sprintf("RecoveryConvention(%d)", uint8(c))
+}
+
+// PriceWithDefault returns the price of a European option written by a
+// counterparty that defaults at an exponential time with intensity
+// hazardRate, independent of the underlying, under RecoveryMarketValue.
+// See PriceWithDefaultConvention.
+func PriceWithDefault(
+	vol, timeToExpiry, spot, strike, interestRate, dividendYield, hazardRate, recoveryRate float64,
+	optionType OptionType,
+) (float64, error) {
+	return PriceWithDefaultConvention(
+		RecoveryMarketValue, vol, timeToExpiry, spot, strike, interestRate, dividendYield,
+		hazardRate, recoveryRate, optionType,
+	)
+}
+
+// PriceWithDefaultConvention is PriceWithDefault under the recovery
+// convention conv. The option is worth its BSPrice times
+// exp(-hazardRate*(1 - recoveryRate)*t) under RecoveryMarketValue and
+// times the survival probability exp(-hazardRate*t) plus recoveryRate
+// times the default probability under RecoveryFixed. Either is BSPrice at
+// a zero hazard rate or a full recovery. A negative hazard rate or a
+// recovery rate outside [0, 1] returns ErrDefault.
+func PriceWithDefaultConvention(
+	conv RecoveryConvention,
+	vol, timeToExpiry, spot, strike, interestRate, dividendYield, hazardRate, recoveryRate float64,
+	optionType OptionType,
+) (float64, error) {
+
+	v, t, x, k, r, q, o := vol, timeToExpiry, spot, strike, interestRate, dividendYield, optionType
+
+	factor, _, err := defaultFactor(conv, v, t, x, k, r, q, hazardRate, recoveryRate, o)
+	if err != nil {
+		return nan(), err
+	}
+
+	return factor * BSPrice(v, t, x, k, r, q, o), nil
+}
+
+// HazardSensitivity returns the derivative of PriceWithDefault in the
+// hazard rate, which is never positive
+func HazardSensitivity(
+	vol, timeToExpiry, spot, strike, interestRate, dividendYield, hazardRate, recoveryRate float64,
+	optionType OptionType,
+) (float64, error) {
+	return HazardSensitivityWithConvention(
+		RecoveryMarketValue, vol, timeToExpiry, spot, strike, interestRate, dividendYield,
+		hazardRate, recoveryRate, optionType,
+	)
+}
+
+// HazardSensitivityWithConvention is HazardSensitivity for
+// PriceWithDefaultConvention
+func HazardSensitivityWithConvention(
+	conv RecoveryConvention,
+	vol, timeToExpiry, spot, strike, interestRate, dividendYield, hazardRate, recoveryRate float64,
+	optionType OptionType,
+) (float64, error) {
+
+	v, t, x, k, r, q, o := vol, timeToExpiry, spot, strike, interestRate, dividendYield, optionType
+
+	_, slope, err := defaultFactor(conv, v, t, x, k, r, q, hazardRate, recoveryRate, o)
+	if err != nil {
+		return nan(), err
+	}
+
+	return slope * BSPrice(v, t, x, k, r, q, o), nil
+}
+
+// defaultFactor checks the inputs of PriceWithDefaultConvention and
+// returns the factor applied to the default free price and its derivative
+// in the hazard rate
+func defaultFactor(
+	conv RecoveryConvention, v, t, x, k, r, q, h, rec float64, o OptionType,
+) (factor, slope float64, err error) {
+
+	if v < 0 {
+		return nan(), nan(), ErrNegVol
+	}
+	if err = checkParams(t, x, k, r, q, o); err != nil {
+		return nan(), nan(), err
+	}
+	if !(h >= 0) || h == inf(1) || !(rec >= 0 && rec <= 1) {
+		return nan(), nan(), ErrDefault
+	}
+
+	switch conv {
+	case RecoveryMarketValue:
+		factor = DiscountFactor(h*(1-rec), t)
+		return factor, -(1 - rec) * t * factor, nil
+	case RecoveryFixed:
+		survival := DiscountFactor(h, t)
+		return survival + rec*(1-survival), -(1 - rec) * t * survival, nil
+	}
+
+	return nan(), nan(), ErrRecoveryConvention
+}
@@ -0,0 +1,312 @@
+package blackscholes
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// CSVColumns names the input columns of ProcessCSV
+type CSVColumns struct {
+	Spot     string
+	Strike   string
+	Expiry   string // ISO date, used when the T column is absent or empty
+	T        string // time to expiry in years
+	Rate     string
+	Dividend string
+	Type     string
+	Premium  string
+	Vol      string
+}
+
+// DefaultCSVColumns holds the column names used for empty CSVColumns fields
+var DefaultCSVColumns = CSVColumns{
+	Spot:     "spot",
+	Strike:   "strike",
+	Expiry:   "expiry",
+	T:        "t",
+	Rate:     "rate",
+	Dividend: "dividend",
+	Type:     "type",
+	Premium:  "premium",
+	Vol:      "vol",
+}
+
+// CSVConfig configures ProcessCSV. If none of Price, Greeks and ImpliedVol
+// is set, prices are computed when there is a vol column and implied vols
+// when there is a premium column.
+type CSVConfig struct {
+	Columns    CSVColumns
+	Now        time.Time // valuation time for expiry dates, zero means today UTC
+	Price      bool      // append a price column
+	Greeks     bool      // append delta, gamma, vega and theta columns
+	ImpliedVol bool      // append an implied_vol column
+
+	// VolPeriodsPerYear, if positive, quotes the vol and implied_vol
+	// columns per period, e.g. 252 for daily vols, instead of annualized
+	VolPeriodsPerYear float64
+}
+
+// ProcessCSV reads option rows from r and writes them to w with the
+// requested outputs and an error column appended. Rate, dividend and type
+// cells are optional and default to 0, 0 and call. A bad row leaves its
+// outputs empty and its error in the error column; only malformed CSV or
+// missing required columns abort the file.
+func ProcessCSV(r io.Reader, w io.Writer, cfg CSVConfig) error {
+
+	in := csv.NewReader(r)
+	in.FieldsPerRecord = -1
+
+	header, err := in.Read()
+	if err != nil {
+		return errors.Wrap(err, "reading CSV header")
+	}
+
+	p, err := newCSVProcessor(header, &cfg)
+	if err != nil {
+		return err
+	}
+
+	out := csv.NewWriter(w)
+	if err := out.Write(append(header, p.outputs...)); err != nil {
+		return err
+	}
+
+	for {
+		row, err := in.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if err := out.Write(p.process(row)); err != nil {
+			return err
+		}
+	}
+
+	out.Flush()
+	return out.Error()
+}
+
+type csvProcessor struct {
+	cfg     *CSVConfig
+	names   CSVColumns
+	index   map[string]int
+	width   int
+	now     time.Time
+	outputs []string
+}
+
+func newCSVProcessor(header []string, cfg *CSVConfig) (*csvProcessor, error) {
+
+	p := &csvProcessor{
+		cfg: cfg, names: cfg.Columns, index: make(map[string]int), width: len(header),
+	}
+
+	for i, name := range header {
+		p.index[name] = i
+	}
+
+	d := DefaultCSVColumns
+	for _, f := range []struct {
+		name *string
+		def  string
+	}{
+		{&p.names.Spot, d.Spot}, {&p.names.Strike, d.Strike},
+		{&p.names.Expiry, d.Expiry}, {&p.names.T, d.T},
+		{&p.names.Rate, d.Rate}, {&p.names.Dividend, d.Dividend},
+		{&p.names.Type, d.Type}, {&p.names.Premium, d.Premium},
+		{&p.names.Vol, d.Vol},
+	} {
+		if *f.name == "" {
+			*f.name = f.def
+		}
+	}
+
+	if !cfg.Price && !cfg.Greeks && !cfg.ImpliedVol {
+		cfg.Price, cfg.ImpliedVol = p.has(p.names.Vol), p.has(p.names.Premium)
+	}
+
+	required := []string{p.names.Spot, p.names.Strike}
+	if cfg.Price || cfg.Greeks {
+		required = append(required, p.names.Vol)
+	}
+	if cfg.ImpliedVol {
+		required = append(required, p.names.Premium)
+	}
+	for _, name := range required {
+		if !p.has(name) {
+			return nil, errors.Errorf("CSV column %q not found", name)
+		}
+	}
+	if !p.has(p.names.T) && !p.has(p.names.Expiry) {
+		return nil, errors.Errorf("CSV column %q or %q not found", p.names.T, p.names.Expiry)
+	}
+
+	if cfg.VolPeriodsPerYear < 0 {
+		return nil, errors.Errorf("negative vol periods per year %v", cfg.VolPeriodsPerYear)
+	}
+
+	p.now = cfg.Now
+	if p.now.IsZero() {
+		p.now = time.Now().UTC().Truncate(24 * time.Hour)
+	}
+
+	if cfg.Price {
+		p.outputs = append(p.outputs, "price")
+	}
+	if cfg.Greeks {
+		p.outputs = append(p.outputs, "delta", "gamma", "vega", "theta")
+	}
+	if cfg.ImpliedVol {
+		p.outputs = append(p.outputs, "implied_vol")
+	}
+	p.outputs = append(p.outputs, "error")
+
+	return p, nil
+}
+
+func (p *csvProcessor) has(name string) bool {
+	_, ok := p.index[name]
+	return ok
+}
+
+// cell returns the named cell of row, empty if the column or cell is absent
+func (p *csvProcessor) cell(row []string, name string) string {
+	if i, ok := p.index[name]; ok && i < len(row) {
+		return row[i]
+	}
+	return ""
+}
+
+// float parses the named cell, returning def for an empty optional cell
+func (p *csvProcessor) float(row []string, name string, required bool, def float64) (float64, error) {
+
+	s := p.cell(row, name)
+	if s == "" {
+		if required {
+			return 0, errors.Errorf("%s: missing", name)
+		}
+		return def, nil
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, errors.Errorf("%s: invalid number %q", name, s)
+	}
+
+	return f, nil
+}
+
+func (p *csvProcessor) process(row []string) []string {
+
+	out := make([]string, len(p.outputs))
+	values, err := p.evaluate(row)
+
+	if err != nil {
+		out[len(out)-1] = err.Error()
+	} else {
+		for i, v := range values {
+			out[i] = strconv.FormatFloat(v, 'g', -1, 64)
+		}
+	}
+
+	// pad short rows so the outputs line up with the header
+	for len(row) < p.width {
+		row = append(row, "")
+	}
+
+	return append(row, out...)
+}
+
+func (p *csvProcessor) evaluate(row []string) (values []float64, err error) {
+
+	var x, k, t, r, q, v, premium float64
+
+	if x, err = p.float(row, p.names.Spot, true, 0); err != nil {
+		return nil, err
+	}
+	if k, err = p.float(row, p.names.Strike, true, 0); err != nil {
+		return nil, err
+	}
+	if r, err = p.float(row, p.names.Rate, false, 0); err != nil {
+		return nil, err
+	}
+	if q, err = p.float(row, p.names.Dividend, false, 0); err != nil {
+		return nil, err
+	}
+	if t, err = p.timeToExpiry(row); err != nil {
+		return nil, err
+	}
+
+	o := Call
+	if s := p.cell(row, p.names.Type); s != "" {
+		if o, err = ParseOptionType(s); err != nil {
+			return nil, errors.Errorf("%s: %v %q", p.names.Type, err, s)
+		}
+	}
+
+	if err = checkParams(t, x, k, r, q, o); err != nil {
+		return nil, err
+	}
+
+	if p.cfg.Price || p.cfg.Greeks {
+		if v, err = p.float(row, p.names.Vol, true, 0); err != nil {
+			return nil, err
+		}
+		if n := p.cfg.VolPeriodsPerYear; n > 0 {
+			v *= sqrt(n)
+		}
+	}
+
+	if p.cfg.Price {
+		values = append(values, BSPriceNoErrorCheck(v, t, x, k, r, q, o))
+	}
+
+	if p.cfg.Greeks {
+		g := BSGreeks(v, t, x, k, r, q, o)
+		values = append(values, g.Delta, g.Gamma, g.Vega, g.Theta)
+	}
+
+	if p.cfg.ImpliedVol {
+		if premium, err = p.float(row, p.names.Premium, true, 0); err != nil {
+			return nil, err
+		}
+		vol, err := ImpliedVol(&ImpliedVolParams{
+			Premium: premium, TimeToExpiry: t, Underlying: x, Strike: k,
+			Rate: r, Dividend: q, Type: o,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if n := p.cfg.VolPeriodsPerYear; n > 0 {
+			vol /= sqrt(n)
+		}
+		values = append(values, vol)
+	}
+
+	return values, nil
+}
+
+func (p *csvProcessor) timeToExpiry(row []string) (float64, error) {
+
+	if p.cell(row, p.names.T) != "" {
+		return p.float(row, p.names.T, true, 0)
+	}
+
+	s := p.cell(row, p.names.Expiry)
+	if s == "" {
+		return 0, errors.Errorf("%s: missing", p.names.T)
+	}
+
+	expiry, err := time.Parse(DateLayout, s)
+	if err != nil {
+		return 0, errors.Errorf("%s: invalid date %q", p.names.Expiry, s)
+	}
+
+	return YearFraction(p.now, expiry), nil
+}
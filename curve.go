@@ -0,0 +1,129 @@
+package blackscholes
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+var ErrCurve = errors.New("Invalid curve")
+
+// Curve is a piecewise constant curve of continuously compounded
+// instantaneous rates: Rates[i] applies from Times[i-1], or 0, to
+// Times[i], and the last rate beyond the last time
+type Curve struct {
+	Times []float64
+	Rates []float64
+}
+
+// NewCurve returns the curve of rates at strictly increasing positive
+// times, copying both
+func NewCurve(times, rates []float64) (Curve, error) {
+
+	if len(times) == 0 || len(times) != len(rates) {
+		return Curve{}, ErrCurve
+	}
+	for i, t := range times {
+		if !(t > 0) || i > 0 && !(t > times[i-1]) || math.IsNaN(rates[i]) {
+			return Curve{}, ErrCurve
+		}
+	}
+
+	return Curve{
+		Times: append([]float64(nil), times...),
+		Rates: append([]float64(nil), rates...),
+	}, nil
+}
+
+// Integral returns the integral of the rate from 0 to t
+func (c Curve) Integral(t float64) float64 {
+
+	sum, prev := 0.0, 0.0
+	for i, ti := range c.Times {
+		if t <= ti {
+			return sum + c.Rates[i]*(t-prev)
+		}
+		sum += c.Rates[i] * (ti - prev)
+		prev = ti
+	}
+
+	return sum + c.Rates[len(c.Rates)-1]*(t-prev)
+}
+
+// ZeroRate returns the average rate Integral(t)/t to t > 0, the flat rate
+// to use for t in the flat rate pricers
+func (c Curve) ZeroRate(t float64) float64 {
+	if !(t > 0) {
+		return nan()
+	}
+	return c.Integral(t) / t
+}
+
+// ParityPair is a call and put premium at one strike and expiry T, with
+// the spot and the zero rate to T used to discount
+type ParityPair struct {
+	T      float64
+	Strike float64
+	Call   float64
+	Put    float64
+	Spot   float64
+	Rate   float64
+}
+
+// ForwardError reports a parity pair whose implied forward is not
+// positive
+type ForwardError struct {
+	T       float64
+	Forward float64
+}
+
+func (e *ForwardError) Error() string {
+	return fmt.Sprintf("Non-positive implied forward %g at expiry %g", e.Forward, e.T)
+}
+
+// ImpliedCarryCurve bootstraps the carry r - q from put-call parity. Each
+// pair implies the forward k + (call - put)/DiscountFactor(Rate, T) and
+// the average carry ImpliedCarry(forward, Spot, T) to its expiry, and the
+// curve holds the constant carry between consecutive expiries that
+// reproduces them. Pairs may come in any order, and an expiry without a
+// pair is covered by the carry of the next one. Repeated or non-positive
+// expiries return ErrCurve and a non-positive forward a ForwardError.
+func ImpliedCarryCurve(pairs []ParityPair) (Curve, error) {
+
+	if len(pairs) == 0 {
+		return Curve{}, ErrCurve
+	}
+
+	ps := append([]ParityPair(nil), pairs...)
+	sort.SliceStable(ps, func(i, j int) bool { return ps[i].T < ps[j].T })
+
+	times, rates := make([]float64, len(ps)), make([]float64, len(ps))
+	prevT, prevW := 0.0, 0.0
+
+	for i, p := range ps {
+
+		if !(p.T > 0) || i > 0 && !(p.T > ps[i-1].T) {
+			return Curve{}, ErrCurve
+		}
+		if err := checkParams(p.T, p.Spot, p.Strike, p.Rate, 0, Call); err != nil {
+			return Curve{}, err
+		}
+		if !(p.Spot > 0) {
+			return Curve{}, ErrNegPrice
+		}
+
+		f := p.Strike + (p.Call-p.Put)/DiscountFactor(p.Rate, p.T)
+		if !(f > 0) {
+			return Curve{}, &ForwardError{T: p.T, Forward: f}
+		}
+
+		// w is the integrated carry to p.T
+		w := ImpliedCarry(f, p.Spot, p.T) * p.T
+		times[i], rates[i] = p.T, (w-prevW)/(p.T-prevT)
+		prevT, prevW = p.T, w
+	}
+
+	return Curve{Times: times, Rates: rates}, nil
+}
@@ -0,0 +1,166 @@
+package blackscholes
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// DeltaConvention is the definition of delta used to quote options by
+// delta, chiefly in FX where q is the foreign rate
+type DeltaConvention uint8
+
+const (
+	// SpotDelta is the Black-Scholes delta, exp(-q*t)*N(d1) for a call
+	SpotDelta DeltaConvention = iota
+	// ForwardDelta is the delta to the forward, N(d1) for a call
+	ForwardDelta
+	// SpotDeltaPA is the premium adjusted spot delta,
+	// exp(-q*t)*(k/f)*N(d2) for a call
+	SpotDeltaPA
+	// ForwardDeltaPA is the premium adjusted forward delta,
+	// (k/f)*N(d2) for a call
+	ForwardDeltaPA
+)
+
+func (c DeltaConvention) String() string {
+	switch c {
+	case SpotDelta:
+		return "spot"
+	case ForwardDelta:
+		return "forward"
+	case SpotDeltaPA:
+		return "spot premium adjusted"
+	case ForwardDeltaPA:
+		return "forward premium adjusted"
+	}
+	return fmt.Sprintf("DeltaConvention(%d)", uint8(c))
+}
+
+func (c DeltaConvention) premiumAdjusted() bool { return c == SpotDeltaPA || c == ForwardDeltaPA }
+
+var (
+	ErrDelta           = errors.New("Delta out of range")
+	ErrDeltaConvention = errors.New("Unknown delta convention")
+)
+
+// deltaScale is the discount factor multiplying the forward deltas of
+// the convention
+func (c DeltaConvention) deltaScale(t, q float64) float64 {
+	if c == SpotDelta || c == SpotDeltaPA {
+		return DiscountFactor(q, t)
+	}
+	return 1
+}
+
+// DeltaWithConvention returns the delta of an option under conv. The
+// premium adjusted deltas subtract the premium in units of the
+// underlying, BSPrice/x, from the spot delta, and the forward deltas
+// undiscount by exp(q*t). A Straddle's delta under every convention is
+// the sum of its call and put deltas, so its premium adjusted delta
+// subtracts the whole straddle premium. The premium adjusted deltas need
+// x > 0.
+func DeltaWithConvention(conv DeltaConvention, v, t, x, k, r, q float64, o OptionType) (float64, error) {
+
+	if err := checkParams(t, x, k, r, q, o); err != nil {
+		return nan(), err
+	}
+
+	switch {
+	case v < 0:
+		return nan(), ErrNegVol
+	case conv > ForwardDeltaPA:
+		return nan(), ErrDeltaConvention
+	case conv.premiumAdjusted() && x == 0:
+		return nan(), ErrNegPrice
+	}
+
+	delta := BSDelta(v, t, x, k, r, q, o)
+	if conv.premiumAdjusted() {
+		delta -= BSPrice(v, t, x, k, r, q, o) / x
+	}
+
+	return delta / DiscountFactor(q, t) * conv.deltaScale(t, q), nil
+}
+
+// StrikeFromDelta returns the strike at which a call or put has the given
+// delta under conv. Unadjusted deltas are inverted in closed form and
+// premium adjusted deltas by bisection in log strike. The premium adjusted
+// call delta is not monotone in strike: it rises from 0 to a maximum and
+// falls back to 0 as the strike grows, and the strike above the maximum
+// is returned. Deltas outside the range of the convention return ErrDelta.
+func StrikeFromDelta(
+	delta, vol, timeToExpiry, spot, r, q float64, o OptionType, conv DeltaConvention,
+) (float64, error) {
+
+	v, t, x := vol, timeToExpiry, spot
+
+	switch {
+	case !(v > 0):
+		return nan(), ErrNegVol
+	case !(t >= TimeFloor):
+		return nan(), ErrNegTimeToExp
+	case !(x > 0):
+		return nan(), ErrNegPrice
+	case o != Call && o != Put:
+		return nan(), ErrUnknownOptionType
+	case conv > ForwardDeltaPA:
+		return nan(), ErrDeltaConvention
+	}
+	if err := checkExponents(t, x, 0, r, q); err != nil {
+		return nan(), err
+	}
+
+	f := Forward(x, r, q, t)
+	vs := v * sqrt(t)
+	s := conv.deltaScale(t, q)
+	d := delta / s
+
+	if o == Put {
+		d = -d
+	}
+	if !(d > 0) || !conv.premiumAdjusted() && !(d < 1) {
+		return nan(), ErrDelta
+	}
+
+	if !conv.premiumAdjusted() {
+		if o == Call {
+			return f * exp(-vs*NormCDFInverse(d)+vs*vs/2), nil
+		}
+		return f * exp(vs*NormCDFInverse(d)+vs*vs/2), nil
+	}
+
+	// premium adjusted deltas in terms of log(k/f)
+	pa := func(m float64) float64 {
+		d2 := -m/vs - vs/2
+		if o == Call {
+			return exp(m)*NormCDF(d2) - d
+		}
+		return exp(m)*NormCDF(-d2) - d
+	}
+
+	// the put delta rises from 0 to Inf, and the call delta falls to 0
+	// from its maximum, where vs*N(d2) = n(d2)
+	var lo, hi float64
+	if o == Put {
+		lo, hi = -vs*vs/2, vs*vs/2
+		for pa(lo) > 0 {
+			lo -= vs
+		}
+		for pa(hi) < 0 {
+			hi += vs
+		}
+	} else {
+		d2 := bisect(func(d2 float64) float64 { return vs*NormCDF(d2) - NormPDF(d2) }, -5-min(vs, 30), 5, 1e-15)
+		lo = -vs*d2 - vs*vs/2
+		if pa(lo) < 0 {
+			return nan(), ErrDelta
+		}
+		hi = lo + vs
+		for pa(hi) > 0 {
+			hi += vs
+		}
+	}
+
+	return f * exp(bisect(pa, lo, hi, 1e-15)), nil
+}
@@ -0,0 +1,277 @@
+package blackscholes
+
+import "github.com/pkg/errors"
+
+var ErrStrikeOrder = errors.New("Lower strike not below upper strike")
+
+// PriceDigital returns the price of a cash-or-nothing digital paying
+// payout at expiry if the underlying finishes above the strike (Call) or
+// below it (Put); a Straddle always pays. DeltaDigital, GammaDigital,
+// VegaDigital, ThetaDigital and RhoDigital are its greeks. Negative vols
+// return ErrNegVol.
+//
+// At zero vol or t < TimeFloor the price steps at the forward, worth half
+// the discounted payout there. Off the forward theta is r and rho -t times
+// the price and the other greeks are 0; at it they take their vol -> 0
+// limits, which may be infinite.
+func PriceDigital(v, t, x, k, payout, r, q float64, o OptionType) (float64, error) {
+	g, err := digitalGreeks(v, t, x, k, payout, r, q, o)
+	return g.Price, err
+}
+
+func DeltaDigital(v, t, x, k, payout, r, q float64, o OptionType) (float64, error) {
+	g, err := digitalGreeks(v, t, x, k, payout, r, q, o)
+	return g.Delta, err
+}
+
+func GammaDigital(v, t, x, k, payout, r, q float64, o OptionType) (float64, error) {
+	g, err := digitalGreeks(v, t, x, k, payout, r, q, o)
+	return g.Gamma, err
+}
+
+func VegaDigital(v, t, x, k, payout, r, q float64, o OptionType) (float64, error) {
+	g, err := digitalGreeks(v, t, x, k, payout, r, q, o)
+	return g.Vega, err
+}
+
+func ThetaDigital(v, t, x, k, payout, r, q float64, o OptionType) (float64, error) {
+	g, err := digitalGreeks(v, t, x, k, payout, r, q, o)
+	return g.Theta, err
+}
+
+func RhoDigital(v, t, x, k, payout, r, q float64, o OptionType) (float64, error) {
+	g, err := digitalGreeks(v, t, x, k, payout, r, q, o)
+	return g.Rho, err
+}
+
+// PriceAssetDigital returns the price of an asset-or-nothing digital
+// paying the underlying at expiry if it finishes above the strike (Call)
+// or below it (Put), exp(-q*t)*x*N(d1) for a call. A Straddle pays the
+// underlying in either case. DeltaAssetDigital is its delta. At zero vol
+// and below TimeFloor a forward at the strike counts half, as for
+// PriceDigital, and the delta there is +Inf for a call and -Inf for a
+// put. Negative vols return ErrNegVol.
+func PriceAssetDigital(v, t, x, k, r, q float64, o OptionType) (float64, error) {
+	price, _, err := assetDigital(v, t, x, k, r, q, o)
+	return price, err
+}
+
+func DeltaAssetDigital(v, t, x, k, r, q float64, o OptionType) (float64, error) {
+	_, delta, err := assetDigital(v, t, x, k, r, q, o)
+	return delta, err
+}
+
+func assetDigital(v, t, x, k, r, q float64, o OptionType) (price, delta float64, err error) {
+
+	if v < 0 {
+		return nan(), nan(), ErrNegVol
+	}
+	if err = checkParams(t, x, k, r, q, o); err != nil {
+		return nan(), nan(), err
+	}
+
+	dfq := DiscountFactor(q, t)
+	xq := dfq * x
+
+	if o == Straddle {
+		return xq, dfq, nil
+	}
+
+	sign := 1.0
+	if o == Put {
+		sign = -1
+	}
+
+	if x == 0 || k == 0 || v == 0 || t < TimeFloor {
+		kr := discounted(k, r, t)
+		switch {
+		case xq > kr && sign > 0, xq < kr && sign < 0:
+			return xq, dfq, nil
+		case xq != kr, x == 0:
+			return 0, 0, nil
+		}
+		return xq / 2, sign * inf(1), nil
+	}
+
+	vs := v * sqrt(t)
+	d1 := D1(v, t, x, k, r, q)
+	n := NormCDF(sign * d1)
+
+	return xq * n, dfq * (n + sign*NormPDF(d1)/vs), nil
+}
+
+// PriceSupershare returns the price of a supershare paying the underlying
+// over lowerStrike at expiry if it finishes in [lowerStrike, upperStrike),
+// the asset digital call at the lower strike less the one at the upper
+// strike, over the lower strike. DeltaSupershare is its delta. A lower
+// strike that is not positive returns ErrNegStrike and one not below the
+// upper strike ErrStrikeOrder.
+func PriceSupershare(
+	vol, timeToExpiry, spot, lowerStrike, upperStrike, interestRate, dividendYield float64,
+) (float64, error) {
+	price, _, err := supershare(vol, timeToExpiry, spot, lowerStrike, upperStrike, interestRate, dividendYield)
+	return price, err
+}
+
+func DeltaSupershare(
+	vol, timeToExpiry, spot, lowerStrike, upperStrike, interestRate, dividendYield float64,
+) (float64, error) {
+	_, delta, err := supershare(vol, timeToExpiry, spot, lowerStrike, upperStrike, interestRate, dividendYield)
+	return delta, err
+}
+
+func supershare(v, t, x, kl, ku, r, q float64) (price, delta float64, err error) {
+
+	switch {
+	case !(kl > 0):
+		return nan(), nan(), ErrNegStrike
+	case !(kl < ku):
+		return nan(), nan(), ErrStrikeOrder
+	}
+
+	plo, dlo, err := assetDigital(v, t, x, kl, r, q, Call)
+	if err != nil {
+		return nan(), nan(), err
+	}
+	phi, dhi, err := assetDigital(v, t, x, ku, r, q, Call)
+	if err != nil {
+		return nan(), nan(), err
+	}
+
+	return (plo - phi) / kl, (dlo - dhi) / kl, nil
+}
+
+// PriceRangeBinary returns the price of a double digital paying payout at
+// expiry if the underlying finishes between lowerStrike and upperStrike,
+// the call digital at the lower strike less the call digital at the
+// upper one. At zero vol a forward at either strike counts half, as for
+// PriceDigital. DeltaRangeBinary and VegaRangeBinary are its greeks. A
+// lowerStrike not below upperStrike returns ErrStrikeOrder.
+func PriceRangeBinary(
+	vol, timeToExpiry, spot, lowerStrike, upperStrike, payout, interestRate, dividendYield float64,
+) (float64, error) {
+	g, err := rangeBinaryGreeks(vol, timeToExpiry, spot, lowerStrike, upperStrike, payout, interestRate, dividendYield)
+	return g.Price, err
+}
+
+func DeltaRangeBinary(
+	vol, timeToExpiry, spot, lowerStrike, upperStrike, payout, interestRate, dividendYield float64,
+) (float64, error) {
+	g, err := rangeBinaryGreeks(vol, timeToExpiry, spot, lowerStrike, upperStrike, payout, interestRate, dividendYield)
+	return g.Delta, err
+}
+
+func VegaRangeBinary(
+	vol, timeToExpiry, spot, lowerStrike, upperStrike, payout, interestRate, dividendYield float64,
+) (float64, error) {
+	g, err := rangeBinaryGreeks(vol, timeToExpiry, spot, lowerStrike, upperStrike, payout, interestRate, dividendYield)
+	return g.Vega, err
+}
+
+func rangeBinaryGreeks(v, t, x, kl, ku, payout, r, q float64) (Greeks, error) {
+
+	if !(kl < ku) {
+		return nanGreeks(), ErrStrikeOrder
+	}
+
+	lo, err := digitalGreeks(v, t, x, kl, payout, r, q, Call)
+	if err != nil {
+		return nanGreeks(), err
+	}
+	hi, err := digitalGreeks(v, t, x, ku, payout, r, q, Call)
+	if err != nil {
+		return nanGreeks(), err
+	}
+
+	return Greeks{
+		Price: lo.Price - hi.Price,
+		Delta: lo.Delta - hi.Delta,
+		Gamma: lo.Gamma - hi.Gamma,
+		Vega:  lo.Vega - hi.Vega,
+		Theta: lo.Theta - hi.Theta,
+		Rho:   lo.Rho - hi.Rho,
+	}, nil
+}
+
+func digitalGreeks(v, t, x, k, payout, r, q float64, o OptionType) (Greeks, error) {
+
+	if v < 0 {
+		return nanGreeks(), ErrNegVol
+	}
+
+	if err := checkParams(t, x, k, r, q, o); err != nil {
+		return nanGreeks(), err
+	}
+
+	df := discounted(payout, r, t)
+
+	if o == Straddle {
+		return Greeks{Price: df, Theta: r * df, Rho: -t * df}, nil
+	}
+
+	sign := 1.0
+	if o == Put {
+		sign = -1
+	}
+
+	if x == 0 || k == 0 || v == 0 || t < TimeFloor {
+		return zeroVolDigitalGreeks(v, t, x, k, r, q, df, sign), nil
+	}
+
+	vs := v * sqrt(t)
+	d1 := D1(v, t, x, k, r, q)
+	d2 := d1 - vs
+	nd2 := NormPDF(d2)
+	dd2 := (r-q-0.5*v*v)/vs - d2/2/t // d(d2)/dt
+
+	g := Greeks{
+		Delta: sign * df * nd2 / x / vs,
+		Gamma: -sign * df * nd2 * d1 / x / x / vs / vs,
+		Vega:  -sign * df * nd2 * d1 / v,
+		Rho:   sign * df * nd2 * t / vs,
+	}
+
+	if o == Call {
+		g.Price = df * NormCDF(d2)
+	} else {
+		g.Price = df * NormCDF(-d2)
+	}
+	g.Theta = r*g.Price - sign*df*nd2*dd2
+	g.Rho -= t * g.Price
+
+	return g, nil
+}
+
+// zeroVolDigitalGreeks is the step limit of digitalGreeks for a call
+// (sign 1) or put (sign -1), also covering x == 0 or k == 0 where the
+// underlying never crosses the strike
+func zeroVolDigitalGreeks(v, t, x, k, r, q, df, sign float64) Greeks {
+
+	xq, kr := discounted(x, q, t), discounted(k, r, t)
+
+	switch {
+	case xq > kr && sign > 0, xq < kr && sign < 0:
+		return Greeks{Price: df, Theta: r * df, Rho: -t * df}
+	case xq != kr, x == 0:
+		return Greeks{}
+	}
+
+	g := Greeks{
+		Price: df / 2,
+		Delta: sign * inf(1),
+		Gamma: -sign * inf(1),
+		Vega:  -sign * df * InvSqrt2PI * sqrt(t) / 2,
+		Rho:   sign * inf(1),
+	}
+
+	switch c := 4*(r-q) - v*v; {
+	case c > 0:
+		g.Theta = -sign * inf(1)
+	case c < 0:
+		g.Theta = sign * inf(1)
+	default:
+		g.Theta = r * g.Price
+	}
+
+	return g
+}
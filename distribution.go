@@ -0,0 +1,102 @@
+package blackscholes
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+var ErrDistribution = errors.New("Invalid terminal distribution")
+
+// TerminalDistribution is a distribution of the spot at a horizon, such
+// as a Lognormal or an empirical or mixture distribution. PDF and CDF
+// are 0 at negative spots.
+type TerminalDistribution interface {
+	PDF(x float64) float64
+	CDF(x float64) float64
+	Quantile(p float64) float64
+}
+
+// Lognormal is the TerminalDistribution of a spot with the given vol and
+// expected growth rate Drift after T years. Drift r - q is risk neutral.
+// Its PDF needs Vol*T > 0.
+type Lognormal struct {
+	Spot, Drift, Vol, T float64
+}
+
+// z returns the standardized log spot of x
+func (d Lognormal) z(x float64) float64 {
+	return (log(x/d.Spot) - (d.Drift-d.Vol*d.Vol/2)*d.T) / d.Vol / sqrt(d.T)
+}
+
+func (d Lognormal) PDF(x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	return NormPDF(d.z(x)) / x / d.Vol / sqrt(d.T)
+}
+
+func (d Lognormal) CDF(x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	return NormCDF(d.z(x))
+}
+
+func (d Lognormal) Quantile(p float64) float64 {
+	return d.Spot * exp((d.Drift-d.Vol*d.Vol/2)*d.T+d.Vol*sqrt(d.T)*NormCDFInverse(p))
+}
+
+// ExpectedPnL returns the mean and standard deviation of the P&L of the
+// portfolio at its first expiry, valued as in PayoffProfile, when the
+// spot then has the distribution dist. The moments are integrated by
+// adaptive Simpson quadrature of the density between the 1e-12 and
+// 1 - 1e-12 quantiles, split at the strikes. A distribution whose
+// quantiles are not finite, nonnegative and increasing returns
+// ErrDistribution.
+func ExpectedPnL(positions Portfolio, dist TerminalDistribution) (mean, stdev float64, err error) {
+
+	p := positions
+	mean, stdev = nan(), nan()
+
+	if err = p.check(); err != nil {
+		return
+	}
+	if dist == nil {
+		err = ErrNilPtrArg
+		return
+	}
+
+	lo, hi := dist.Quantile(1e-12), dist.Quantile(1-1e-12)
+	if !(lo >= 0 && hi > lo) || hi == inf(1) {
+		err = ErrDistribution
+		return
+	}
+
+	breaks := []float64{lo, hi}
+	for i := range p.Positions {
+		if k := p.Positions[i].Strike; k > lo && k < hi {
+			breaks = append(breaks, k)
+		}
+	}
+	sort.Float64s(breaks)
+
+	t := p.firstExpiry()
+	cost := p.valueAt(p.Spot, 0) / DiscountFactor(p.Rate, t)
+	tol := 1e-10 * max(1, abs(cost))
+
+	integrate := func(f func(float64) float64) float64 {
+		sum := 0.0
+		for i := 1; i < len(breaks); i++ {
+			sum += adaptiveSimpson(func(x float64) float64 {
+				return f(p.valueAt(x, t)-cost) * dist.PDF(x)
+			}, breaks[i-1], breaks[i], tol, 50)
+		}
+		return sum
+	}
+
+	m := integrate(func(pnl float64) float64 { return pnl })
+	v := integrate(func(pnl float64) float64 { return (pnl - m) * (pnl - m) })
+
+	return m, sqrt(max(v, 0)), nil
+}
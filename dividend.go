@@ -0,0 +1,353 @@
+package blackscholes
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// Dividend is a known cash dividend of Amount paid at Time years
+type Dividend struct {
+	Time, Amount float64
+}
+
+// DiscreteDivMethod selects how PriceDiscreteDividend adjusts the Black
+// Scholes inputs for cash dividends in a model where the spot itself,
+// dividends included, has the given vol
+type DiscreteDivMethod uint8
+
+const (
+	// DivEscrowed subtracts the present value of the dividends from the
+	// spot. It is close when dividends are near and underprices options
+	// whose dividends are near expiry, as the vol applies to less than
+	// the whole spot until they are paid.
+	DivEscrowed DiscreteDivMethod = iota
+	// DivForward adds the dividends carried to expiry to the strike. It
+	// is close for dividends near expiry and overprices near dividends.
+	DivForward
+	// DivBosVandermark splits each dividend between the two, in
+	// proportion to (T - Time)/T for the spot and Time/T for the strike.
+	// It is the closest for dividends late in the life.
+	DivBosVandermark
+	// DivVolAdjusted is escrowed with the vol raised before each dividend
+	// by spot/(spot - escrow), the escrow being the present value of the
+	// dividends yet to be paid, and averaged in variance over the life.
+	// It is the closest for dividends early in the life.
+	DivVolAdjusted
+)
+
+func (m DiscreteDivMethod) String() string {
+	switch m {
+	case DivEscrowed:
+		return "escrowed"
+	case DivForward:
+		return "forward"
+	case DivBosVandermark:
+		return "Bos-Vandermark"
+	case DivVolAdjusted:
+		return "vol adjusted"
+	}
+	return fmt.Sprintf("DiscreteDivMethod(%d)", uint8(m))
+}
+
+var (
+	ErrDividend          = errors.New("Invalid dividend")
+	ErrDiscreteDivMethod = errors.New("Unknown discrete dividend method")
+)
+
+// PriceDiscreteDividend returns the price of a European option on a spot
+// paying cash dividends by method. Dividends after expiry are ignored and
+// one paid at expiry is included. Negative amounts or times, and
+// dividends whose present value is not below the spot, return
+// ErrDividend.
+func PriceDiscreteDividend(
+	vol, timeToExpiry, spot, strike, interestRate float64,
+	dividends []Dividend, optionType OptionType, method DiscreteDivMethod,
+) (float64, error) {
+
+	v, t, x, k, r, o := vol, timeToExpiry, spot, strike, interestRate, optionType
+
+	if v < 0 {
+		return nan(), ErrNegVol
+	}
+	if err := checkParams(t, x, k, r, 0, o); err != nil {
+		return nan(), err
+	}
+	if method > DivVolAdjusted {
+		return nan(), ErrDiscreteDivMethod
+	}
+
+	divs, escrow, err := dividendsTo(t, x, r, dividends)
+	if err != nil {
+		return nan(), err
+	}
+
+	if t == 0 {
+		method = DivEscrowed
+	}
+
+	switch method {
+	case DivForward:
+		k += escrow / DiscountFactor(r, t)
+	case DivBosVandermark:
+		var near, far float64
+		for _, d := range divs {
+			pv := discounted(d.Amount, r, d.Time)
+			near += pv * (t - d.Time) / t
+			far += pv * d.Time / t
+		}
+		x, k = x-near, k+far/DiscountFactor(r, t)
+	case DivVolAdjusted:
+		variance, prev, left := 0.0, 0.0, escrow
+		for _, d := range divs {
+			m := x / (x - left)
+			variance += m * m * (d.Time - prev)
+			left -= discounted(d.Amount, r, d.Time)
+			prev = d.Time
+		}
+		variance += t - prev
+		v *= sqrt(variance / t)
+		x -= escrow
+	default:
+		x -= escrow
+	}
+
+	return BSPrice(v, t, x, k, r, 0, o), nil
+}
+
+// dividendsTo returns the dividends paid by t, sorted by time, and their
+// present value, checking them as PriceDiscreteDividend does
+func dividendsTo(t, x, r float64, dividends []Dividend) ([]Dividend, float64, error) {
+
+	var divs []Dividend
+	for _, d := range dividends {
+		if !(d.Time >= 0) || !(d.Amount >= 0) {
+			return nil, nan(), ErrDividend
+		}
+		if d.Time <= t {
+			divs = append(divs, d)
+		}
+	}
+	sort.Slice(divs, func(i, j int) bool { return divs[i].Time < divs[j].Time })
+
+	var escrow float64
+	for _, d := range divs {
+		escrow += discounted(d.Amount, r, d.Time)
+	}
+	if !(escrow < x) {
+		return nil, nan(), ErrDividend
+	}
+
+	return divs, escrow, nil
+}
+
+// escrowAt returns the function of time s giving the present value at s
+// of the dividends paid after s
+func escrowAt(divs []Dividend, r float64) func(float64) float64 {
+	return func(s float64) float64 {
+		var pv float64
+		for _, d := range divs {
+			if d.Time > s {
+				pv += discounted(d.Amount, r, d.Time-s)
+			}
+		}
+		return pv
+	}
+}
+
+// PriceBinomialDividends returns the price of an option on a spot paying
+// cash dividends on a Cox-Ross-Rubinstein tree with the given number of
+// steps. To keep the tree recombining it is built on the spot less the
+// present value of the dividends to expiry, which has the vol as in
+// DivEscrowed, and early exercise is on that plus the present value of
+// the dividends yet to be paid. European prices converge to
+// PriceDiscreteDividend with DivEscrowed. Dividends are checked as there.
+func PriceBinomialDividends(
+	vol, timeToExpiry, spot, strike, interestRate float64,
+	dividends []Dividend, optionType OptionType, style ExerciseStyle, steps int,
+) (float64, error) {
+
+	v, t, x, k, r, o := vol, timeToExpiry, spot, strike, interestRate, optionType
+
+	divs, escrow, err := escrowedLattice(v, t, x, k, r, dividends, o, steps)
+	if err != nil {
+		return nan(), err
+	}
+	if t < TimeFloor {
+		return BSPrice(v, t, x-escrow, k, r, 0, o), nil
+	}
+
+	dt := t / float64(steps)
+	u := exp(v * sqrt(dt))
+	d := 1 / u
+	p := (Forward(1, r, 0, dt) - d) / (u - d)
+	if !(p > 0 && p < 1) {
+		return nan(), ErrLattice
+	}
+	df := DiscountFactor(r, dt)
+
+	return binomialTree(x-escrow, k, o, style, steps, dt, u, d, df*p, df*(1-p), escrowAt(divs, r)), nil
+}
+
+// PriceLeisenReimerDividends is PriceBinomialDividends on the
+// Leisen-Reimer tree of PriceLeisenReimer
+func PriceLeisenReimerDividends(
+	vol, timeToExpiry, spot, strike, interestRate float64,
+	dividends []Dividend, optionType OptionType, style ExerciseStyle, steps int,
+) (float64, error) {
+
+	v, t, x, k, r, o := vol, timeToExpiry, spot, strike, interestRate, optionType
+
+	divs, escrow, err := escrowedLattice(v, t, x, k, r, dividends, o, steps)
+	if err != nil {
+		return nan(), err
+	}
+	if t < TimeFloor {
+		return BSPrice(v, t, x-escrow, k, r, 0, o), nil
+	}
+
+	if k == 0 {
+		if style == American {
+			return payoff(x, k, o), nil
+		}
+		return BSPrice(v, t, x-escrow, k, r, 0, o), nil
+	}
+
+	return leisenReimer(v, t, x-escrow, k, r, 0, o, style, steps, escrowAt(divs, r))
+}
+
+// escrowedLattice checks the inputs of the dividend lattices and returns
+// the dividends to expiry and their present value
+func escrowedLattice(
+	v, t, x, k, r float64, dividends []Dividend, o OptionType, steps int,
+) ([]Dividend, float64, error) {
+
+	if err := checkLattice(v, t, x, k, r, 0, o, steps); err != nil {
+		return nil, nan(), err
+	}
+
+	return dividendsTo(t, x, r, dividends)
+}
+
+// ImpliedVolAmericanDividends is ImpliedVolAmerican for a spot paying
+// cash dividends, inverting PriceLeisenReimerDividends. The premium must
+// lie between the spot intrinsic value and the spot (call) or strike
+// (put), otherwise ErrArbitrage is returned.
+func ImpliedVolAmericanDividends(
+	premium, timeToExpiry, spot, strike, interestRate float64,
+	dividends []Dividend, optionType OptionType, cfg AmericanIVConfig,
+) (float64, error) {
+
+	p, t, x, k, r, o := premium, timeToExpiry, spot, strike, interestRate, optionType
+
+	if err := checkParams(t, x, k, r, 0, o); err != nil {
+		return nan(), err
+	}
+	if _, _, err := dividendsTo(t, x, r, dividends); err != nil {
+		return nan(), err
+	}
+	if p < 0 {
+		return nan(), ErrNegPremium
+	}
+	intrval := payoff(x, k, o)
+	if p < intrval || p > upperBound(0, x, k, 0, 0, o) {
+		return nan(), ErrArbitrage
+	}
+
+	if t < TimeFloor || k == 0 || p-intrval <= 0 {
+		return 0, nil
+	}
+
+	steps, lb, ub := AmericanIVStepsDefault, lbDefault, ubDefault
+	if cfg.Steps > 0 {
+		steps = cfg.Steps
+	}
+	if cfg.LB > 0 {
+		lb = cfg.LB
+	}
+	if cfg.UB > 0 {
+		ub = cfg.UB
+	}
+	tol, maxit := cfg.Tol, cfg.MaxIt
+	CheckVolSearchParams(&lb, &ub, &tol, &maxit)
+
+	price := func(v float64) (float64, error) {
+		return PriceLeisenReimerDividends(v, t, x, k, r, dividends, o, American, steps)
+	}
+
+	return bisectVol(p, price, lb, ub, tol, maxit)
+}
+
+// PriceAmericanCallRGW returns the Roll-Geske-Whaley price of an American
+// call on a spot paying one cash dividend of dividendAmount at
+// dividendTime, before expiry. The spot less the present value of the
+// dividend has the given vol, as in DivEscrowed. The call is only worth
+// exercising just before the dividend, when the spot is above the critical
+// ex-dividend spot at which the European call left is worth the spot plus
+// the dividend less the strike. That makes it a compound option, priced
+// with the bivariate normal CDF. A dividend of at most
+// strike*(1 - exp(-interestRate*(timeToExpiry - dividendTime))) is never
+// worth exercising for, and the price is then the escrowed European
+// price. A dividend time outside (0, timeToExpiry), a negative amount or
+// one whose present value is not below the spot return ErrDividend.
+func PriceAmericanCallRGW(
+	vol, timeToExpiry, spot, strike, interestRate float64, dividendAmount, dividendTime float64,
+) (float64, error) {
+
+	v, t, x, k, r, d, t1 := vol, timeToExpiry, spot, strike, interestRate, dividendAmount, dividendTime
+
+	if v < 0 {
+		return nan(), ErrNegVol
+	}
+	if err := checkParams(t, x, k, r, 0, Call); err != nil {
+		return nan(), err
+	}
+	if !(t1 > 0 && t1 < t) || !(d >= 0) {
+		return nan(), ErrDividend
+	}
+
+	xe := x - discounted(d, r, t1)
+	if !(xe > 0) {
+		return nan(), ErrDividend
+	}
+
+	european := BSPrice(v, t, xe, k, r, 0, Call)
+	t2 := t - t1
+
+	if d <= k*(1-DiscountFactor(r, t2)) {
+		return european, nil
+	}
+
+	// exercising just before the dividend pays x - k*exp(-r*t1) on every
+	// path at zero vol or strike, and the call is then worth the better of
+	// that and holding to expiry
+	if v == 0 || k == 0 {
+		return max(european, x-discounted(k, r, t1)), nil
+	}
+
+	// the ex-dividend spot at which exercise and holding are worth the
+	// same, exercise being better above it
+	gap := func(s float64) float64 { return BSPrice(v, t2, s, k, r, 0, Call) - s - d + k }
+	if gap(0) <= 0 {
+		// the dividend is at least the strike, so the call is always
+		// exercised
+		return x - discounted(k, r, t1), nil
+	}
+	ub := k
+	for gap(ub) > 0 {
+		ub *= 2
+	}
+	crit := bisect(gap, 0, ub, 1e-13*k)
+
+	vt, v1 := v*sqrt(t), v*sqrt(t1)
+	a1 := (log(xe/k) + (r+v*v/2)*t) / vt
+	a2 := a1 - vt
+	b1 := (log(xe/crit) + (r+v*v/2)*t1) / v1
+	b2 := b1 - v1
+	rho := -sqrt(t1 / t)
+
+	return xe*NormCDF(b1) + xe*bivariateNormCDF(a1, -b1, rho) -
+		discounted(k, r, t)*bivariateNormCDF(a2, -b2, rho) -
+		(k-d)*DiscountFactor(r, t1)*NormCDF(b2), nil
+}
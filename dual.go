@@ -0,0 +1,22 @@
+package blackscholes
+
+// dual is a forward mode automatic differentiation number v + d*e with
+// e*e = 0. Evaluating a formula on duals with d = 1 for one input gives
+// the formula's value and its exact derivative in that input.
+type dual struct{ v, d float64 }
+
+func constant(x float64) dual { return dual{x, 0} }
+func variable(x float64) dual { return dual{x, 1} }
+
+func (a dual) add(b dual) dual      { return dual{a.v + b.v, a.d + b.d} }
+func (a dual) sub(b dual) dual      { return dual{a.v - b.v, a.d - b.d} }
+func (a dual) mul(b dual) dual      { return dual{a.v * b.v, a.d*b.v + a.v*b.d} }
+func (a dual) scale(f float64) dual { return dual{f * a.v, f * a.d} }
+func (a dual) shift(f float64) dual { return dual{a.v + f, a.d} }
+func (a dual) div(b dual) dual      { return dual{a.v / b.v, (a.d*b.v - a.v*b.d) / b.v / b.v} }
+func (a dual) inv() dual            { return dual{1 / a.v, -a.d / a.v / a.v} }
+func (a dual) sqrt() dual           { s := sqrt(a.v); return dual{s, a.d / 2 / s} }
+func (a dual) exp() dual            { e := exp(a.v); return dual{e, e * a.d} }
+func (a dual) log() dual            { return dual{log(a.v), a.d / a.v} }
+func (a dual) pow(b dual) dual      { return b.mul(a.log()).exp() }
+func (a dual) normCDF() dual        { return dual{NormCDF(a.v), NormPDF(a.v) * a.d} }
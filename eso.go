@@ -0,0 +1,76 @@
+package blackscholes
+
+import "github.com/pkg/errors"
+
+var ErrESO = errors.New("Invalid employee stock option parameters")
+
+// PriceESO returns the Hull-White price of an employee stock option, a
+// call valued on a Cox-Ross-Rubinstein tree with the given number of
+// steps. The holder leaves the firm at exitRate per year. Before
+// vestingYears the option cannot be exercised and is forfeited on
+// leaving. Once vested it is exercised as soon as the spot reaches
+// exerciseMultiple times the strike, if in the money, and on leaving if
+// it is in the money. An option vesting after expiry is worthless. With
+// no exits, no vesting period and an infinite exerciseMultiple it is the
+// European call on the tree. Negative vestingYears or exitRate and an
+// exerciseMultiple below 1 return ErrESO.
+func PriceESO(
+	vol, timeToExpiry, spot, strike, interestRate, dividendYield float64,
+	vestingYears, exitRate, exerciseMultiple float64, steps int,
+) (float64, error) {
+
+	v, t, x, k, r, q := vol, timeToExpiry, spot, strike, interestRate, dividendYield
+	vest, m := vestingYears, exerciseMultiple
+
+	if err := checkLattice(v, t, x, k, r, q, Call, steps); err != nil {
+		return nan(), err
+	}
+	if !(vest >= 0) || !(exitRate >= 0) || !(m >= 1) {
+		return nan(), ErrESO
+	}
+
+	if vest > t {
+		return 0, nil
+	}
+	if t < TimeFloor || x == 0 {
+		return BSPrice(v, t, x, k, r, q, Call), nil
+	}
+
+	dt := t / float64(steps)
+	u := exp(v * sqrt(dt))
+	d := 1 / u
+	p := (Forward(1, r, q, dt) - d) / (u - d)
+	if !(p > 0 && p < 1) {
+		return nan(), ErrLattice
+	}
+	pu, pd := DiscountFactor(r, dt)*p, DiscountFactor(r, dt)*(1-p)
+
+	// the probability of leaving over a step
+	leave := 1 - exp(-exitRate*dt)
+
+	vals := make([]float64, steps+1)
+	s := x * pow(d, float64(steps))
+	for j := range vals {
+		vals[j] = payoff(s, k, Call)
+		s *= u * u
+	}
+
+	for i := steps - 1; i >= 0; i-- {
+		vested := float64(i)*dt >= vest
+		s = x * pow(d, float64(i))
+		for j := 0; j <= i; j++ {
+			hold := (1 - leave) * (pu*vals[j+1] + pd*vals[j])
+			switch {
+			case !vested:
+				vals[j] = hold
+			case s >= m*k && s > k:
+				vals[j] = payoff(s, k, Call)
+			default:
+				vals[j] = hold + leave*payoff(s, k, Call)
+			}
+			s *= u * u
+		}
+	}
+
+	return vals[0], nil
+}
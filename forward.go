@@ -0,0 +1,132 @@
+package blackscholes
+
+import "github.com/pkg/errors"
+
+var ErrDiscountFactor = errors.New("Discount factor out of range")
+
+// DiscountConfig configures the check of the discount factors passed to
+// the pricers that take them in place of rates. The zero DiscountConfig,
+// used by the package functions, takes discount factors in (0, 1].
+// AllowNegativeRates accepts any positive finite discount factor.
+type DiscountConfig struct {
+	AllowNegativeRates bool
+}
+
+// PriceFromForward returns the Black price of an option on the forward f
+// discounted by discountFactor, which must lie in (0, 1]. No rates are
+// exponentiated, so the price agrees with Price at x*exp((r-q)*t) and
+// exp(-r*t) to rounding.
+func PriceFromForward(
+	vol, timeToExpiry, forward, strike, discountFactor float64, optionType OptionType,
+) (float64, error) {
+	return DiscountConfig{}.PriceFromForward(vol, timeToExpiry, forward, strike, discountFactor, optionType)
+}
+
+// PriceFromForward is PriceFromForward with the discount factor checked
+// under c
+func (c DiscountConfig) PriceFromForward(
+	vol, timeToExpiry, forward, strike, discountFactor float64, optionType OptionType,
+) (float64, error) {
+	g, err := c.GreeksFromForward(vol, timeToExpiry, forward, strike, discountFactor, optionType)
+	return g.Price, err
+}
+
+// GreeksFromForward returns the Black price and greeks off a forward and
+// discount factor. Delta and gamma are with respect to the forward, and
+// theta is minus the derivative in time to expiry holding the forward and
+// discount factor fixed. Rho is the derivative in the rate holding the
+// forward fixed, -t times the price. At zero vol, strike or forward and below
+// TimeFloor the price is the discounted forward intrinsic value, delta
+// its slope and the other greeks 0, except at the money, where they take
+// their vol -> 0 limits as in ZeroVolBSDelta: the call delta is half the
+// discount factor, gamma is +Inf and at zero vol vega is the AtmApprox
+// slope.
+func GreeksFromForward(
+	vol, timeToExpiry, forward, strike, discountFactor float64, optionType OptionType,
+) (Greeks, error) {
+	return DiscountConfig{}.GreeksFromForward(vol, timeToExpiry, forward, strike, discountFactor, optionType)
+}
+
+// GreeksFromForward is GreeksFromForward with the discount factor checked
+// under c
+func (c DiscountConfig) GreeksFromForward(
+	vol, timeToExpiry, forward, strike, discountFactor float64, optionType OptionType,
+) (Greeks, error) {
+
+	v, t, f, k, df, o := vol, timeToExpiry, forward, strike, discountFactor, optionType
+
+	if err := CheckPriceParams(t, f, k, o); err != nil {
+		return nanGreeks(), err
+	}
+	if v < 0 {
+		return nanGreeks(), ErrNegVol
+	}
+	if err := c.checkDiscountFactor(df); err != nil {
+		return nanGreeks(), err
+	}
+
+	if v == 0 || t < TimeFloor || f == 0 || k == 0 {
+		g := Greeks{
+			Price: df * payoff(f, k, o),
+			Delta: df * ZeroVolBSDelta(0, f, k, 0, 0, o),
+		}
+		if f == k && f > 0 {
+			g.Gamma = inf(1)
+			if v == 0 {
+				g.Vega = df * ZeroVolBSVega(t, f, k, 0, 0, o)
+			}
+		}
+		g.Rho = -t * g.Price
+		return g, nil
+	}
+
+	sqrtt := sqrt(t)
+	vs := v * sqrtt
+	d1 := log(f/k)/vs + vs/2
+	d2 := d1 - vs
+
+	// puts take N(-d1) and N(-d2), see priceKernel
+	sign := 1.0
+	if o == Put {
+		sign = -1
+	}
+	Nd1, Nd2, nd1 := NormCDF(sign*d1), NormCDF(sign*d2), NormPDF(d1)
+	fd, kd := df*f, df*k
+
+	g := Greeks{
+		Gamma: df * nd1 / f / vs,
+		Vega:  fd * nd1 * sqrtt,
+		Theta: -v * fd * nd1 / 2 / sqrtt,
+	}
+
+	switch o {
+	case Call:
+		g.Price = Nd1*fd - Nd2*kd
+		g.Delta = df * Nd1
+		g.Rho = -t * g.Price
+		return g, nil
+	case Put:
+		g.Price = Nd2*kd - Nd1*fd
+		g.Delta = -df * Nd1
+		g.Rho = -t * g.Price
+		return g, nil
+	}
+
+	g.Price = (2*Nd1-1)*fd - (2*Nd2-1)*kd
+	g.Delta = df * (2*Nd1 - 1)
+	g.Gamma *= 2
+	g.Vega *= 2
+	g.Theta *= 2
+	g.Rho = -t * g.Price
+
+	return g, nil
+}
+
+// checkDiscountFactor checks that df lies in (0, 1], or is positive and
+// finite when c.AllowNegativeRates is set
+func (c DiscountConfig) checkDiscountFactor(df float64) error {
+	if !(df > 0) || df > 1 && !c.AllowNegativeRates || df == inf(1) {
+		return ErrDiscountFactor
+	}
+	return nil
+}
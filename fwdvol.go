@@ -0,0 +1,146 @@
+package blackscholes
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+var ErrTermStructure = errors.New("Invalid vol term structure")
+
+// VolPillar is an implied vol, usually at the money, to expiry T
+type VolPillar struct {
+	T   float64
+	Vol float64
+}
+
+// ForwardVolSegment is the constant forward vol between Start and End
+type ForwardVolSegment struct {
+	Start float64
+	End   float64
+	Vol   float64
+}
+
+// CalendarArbitrageError is returned by BootstrapForwardVols for two
+// consecutive pillars whose total variance Vol*Vol*T falls from Near to
+// Far, which has no real forward vol
+type CalendarArbitrageError struct {
+	Near, Far VolPillar
+}
+
+func (e *CalendarArbitrageError) Error() string {
+	return fmt.Sprintf(
+		"Total variance falls from %g at expiry %g to %g at expiry %g",
+		e.Near.Vol*e.Near.Vol*e.Near.T, e.Near.T, e.Far.Vol*e.Far.Vol*e.Far.T, e.Far.T,
+	)
+}
+
+// BootstrapForwardVols returns the forward vols between consecutive
+// pillars, the first from 0, whose total variances add up to those of the
+// pillars: the segment from t0 to t1 has variance (v1*v1*t1 -
+// v0*v0*t0)/(t1 - t0). Pillars may come in any order. Repeated or
+// non-positive expiries return ErrTermStructure, a negative vol ErrNegVol
+// and a total variance falling between pillars a *CalendarArbitrageError.
+func BootstrapForwardVols(pillars []VolPillar) ([]ForwardVolSegment, error) {
+
+	if len(pillars) == 0 {
+		return nil, ErrTermStructure
+	}
+
+	ps := append([]VolPillar(nil), pillars...)
+	sort.SliceStable(ps, func(i, j int) bool { return ps[i].T < ps[j].T })
+
+	segs := make([]ForwardVolSegment, len(ps))
+	prev := VolPillar{}
+
+	for i, p := range ps {
+
+		if !(p.T > 0) || i > 0 && !(p.T > ps[i-1].T) || p.T == inf(1) {
+			return nil, ErrTermStructure
+		}
+		if !(p.Vol >= 0) || p.Vol == inf(1) {
+			return nil, ErrNegVol
+		}
+
+		dw := p.Vol*p.Vol*p.T - prev.Vol*prev.Vol*prev.T
+		if dw < 0 {
+			return nil, &CalendarArbitrageError{Near: prev, Far: p}
+		}
+
+		segs[i] = ForwardVolSegment{Start: prev.T, End: p.T, Vol: sqrt(dw / (p.T - prev.T))}
+		prev = p
+	}
+
+	return segs, nil
+}
+
+// VolTermStructure returns the implied vols at the ends of contiguous
+// forward vol segments, the inverse of BootstrapForwardVols. The first
+// segment must start at 0 and each of the others where the previous one
+// ends, otherwise ErrTermStructure is returned.
+func VolTermStructure(segments []ForwardVolSegment) ([]VolPillar, error) {
+
+	if err := checkSegments(segments); err != nil {
+		return nil, err
+	}
+
+	pillars := make([]VolPillar, len(segments))
+	w := 0.0
+	for i, s := range segments {
+		w += s.Vol * s.Vol * (s.End - s.Start)
+		pillars[i] = VolPillar{T: s.End, Vol: sqrt(w / s.End)}
+	}
+
+	return pillars, nil
+}
+
+// TermVol returns the implied vol to t of contiguous forward vol segments,
+// sqrt of the variance accumulated to t over t. The last forward vol
+// holds beyond the last segment, and at t = 0 the vol is that of the
+// first segment.
+func TermVol(segments []ForwardVolSegment, t float64) (float64, error) {
+
+	if err := checkSegments(segments); err != nil {
+		return nan(), err
+	}
+	if !(t >= 0) {
+		return nan(), ErrNegTimeToExp
+	}
+	if t == 0 {
+		return segments[0].Vol, nil
+	}
+
+	w := 0.0
+	for _, s := range segments {
+		if t <= s.End {
+			return sqrt((w + s.Vol*s.Vol*(t-s.Start)) / t), nil
+		}
+		w += s.Vol * s.Vol * (s.End - s.Start)
+	}
+
+	last := segments[len(segments)-1]
+	return sqrt((w + last.Vol*last.Vol*(t-last.End)) / t), nil
+}
+
+// checkSegments checks that segments are non-empty, contiguous from 0 and
+// have finite non-negative vols
+func checkSegments(segments []ForwardVolSegment) error {
+
+	if len(segments) == 0 {
+		return ErrTermStructure
+	}
+
+	start := 0.0
+	for _, s := range segments {
+		if s.Start != start || !(s.End > s.Start) || s.End == inf(1) {
+			return ErrTermStructure
+		}
+		if !(s.Vol >= 0) || s.Vol == inf(1) {
+			return ErrNegVol
+		}
+		start = s.End
+	}
+
+	return nil
+}
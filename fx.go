@@ -0,0 +1,141 @@
+package blackscholes
+
+// FXQuotes are the market quotes of an FX smile at one expiry: the ATM
+// vol and the 25 and 10 delta risk reversals and
+// butterflies. Butterflies are smile strangles, so the 25 delta call vol
+// is ATM + BF25 + RR25/2 and the put vol ATM + BF25 - RR25/2.
+type FXQuotes struct {
+	ATM        float64
+	RR25, BF25 float64
+	RR10, BF10 float64
+}
+
+// Conventions are the quoting conventions of an FX smile. The zero value
+// quotes spot deltas and the delta neutral straddle.
+type Conventions struct {
+	Delta DeltaConvention
+	ATM   ATMConvention
+}
+
+// fxDeltas are the pillar deltas of an FX smile in increasing strike
+// order, with 0 for the ATM pillar
+var fxDeltas = [5]float64{-0.1, -0.25, 0, 0.25, 0.1}
+
+// FXSmileFromQuotes returns the five pillars of an FX smile, the 10 and
+// 25 delta puts, the ATM straddle and the 25 and 10 delta calls, in
+// increasing strike order. Strikes come from StrikeFromDelta under the
+// delta convention of conv with the foreign rate as dividend yield and
+// the ATM strike from ATMStrike under conv.ATM, except that the delta
+// neutral straddle strike is f*exp(-v*v*t/2) under premium adjusted
+// deltas.
+func FXSmileFromQuotes(
+	q FXQuotes, spot, domesticRate, foreignRate, timeToExpiry float64, conv Conventions,
+) ([]SmilePoint, error) {
+
+	t := timeToExpiry
+	vols := [5]float64{
+		q.ATM + q.BF10 - q.RR10/2,
+		q.ATM + q.BF25 - q.RR25/2,
+		q.ATM,
+		q.ATM + q.BF25 + q.RR25/2,
+		q.ATM + q.BF10 + q.RR10/2,
+	}
+
+	points := make([]SmilePoint, 5)
+	for i, d := range fxDeltas {
+
+		if !(vols[i] > 0) {
+			return nil, ErrSmile
+		}
+
+		var k float64
+		var err error
+		switch {
+		case d == 0:
+			k, err = fxATMStrike(vols[i], t, spot, domesticRate, foreignRate, conv)
+		case d < 0:
+			k, err = StrikeFromDelta(d, vols[i], t, spot, domesticRate, foreignRate, Put, conv.Delta)
+		default:
+			k, err = StrikeFromDelta(d, vols[i], t, spot, domesticRate, foreignRate, Call, conv.Delta)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 && !(k > points[i-1].Strike) {
+			return nil, ErrSmile
+		}
+
+		points[i] = SmilePoint{Strike: k, Vol: vols[i]}
+	}
+
+	return points, nil
+}
+
+// FXQuotesFromSmile is the inverse of FXSmileFromQuotes. The points must be
+// the five pillars in increasing strike order with vols, and ErrSmile is
+// returned unless each strike has its pillar delta, or is the ATM strike,
+// under conv to 1e-8.
+func FXQuotesFromSmile(
+	points []SmilePoint, spot, domesticRate, foreignRate, timeToExpiry float64, conv Conventions,
+) (FXQuotes, error) {
+
+	if len(points) != 5 {
+		return FXQuotes{}, ErrSmile
+	}
+
+	t := timeToExpiry
+	for i, d := range fxDeltas {
+
+		p := points[i]
+		if !(p.Vol > 0) || !(p.Strike > 0) {
+			return FXQuotes{}, ErrSmile
+		}
+
+		if d == 0 {
+			k, err := fxATMStrike(p.Vol, t, spot, domesticRate, foreignRate, conv)
+			if err != nil {
+				return FXQuotes{}, err
+			}
+			if abs(p.Strike/k-1) > 1e-8 {
+				return FXQuotes{}, ErrSmile
+			}
+			continue
+		}
+
+		o := Call
+		if d < 0 {
+			o = Put
+		}
+		got, err := DeltaWithConvention(conv.Delta, p.Vol, t, spot, p.Strike, domesticRate, foreignRate, o)
+		if err != nil {
+			return FXQuotes{}, err
+		}
+		if abs(got-d) > 1e-8 {
+			return FXQuotes{}, ErrSmile
+		}
+	}
+
+	v10p, v25p, atm, v25c, v10c := points[0].Vol, points[1].Vol, points[2].Vol, points[3].Vol, points[4].Vol
+
+	return FXQuotes{
+		ATM:  atm,
+		RR25: v25c - v25p,
+		BF25: (v25c+v25p)/2 - atm,
+		RR10: v10c - v10p,
+		BF10: (v10c+v10p)/2 - atm,
+	}, nil
+}
+
+// fxATMStrike is the ATM strike under conv
+func fxATMStrike(v, t, x, r, q float64, conv Conventions) (float64, error) {
+
+	if conv.ATM != ATMDNS || !conv.Delta.premiumAdjusted() {
+		return ATMStrike(conv.ATM, v, t, x, r, q)
+	}
+
+	f, err := ATMStrike(ATMForward, v, t, x, r, q)
+	if err != nil {
+		return nan(), err
+	}
+	return f * exp(-v*v*t/2), nil
+}
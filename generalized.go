@@ -0,0 +1,93 @@
+package blackscholes
+
+// The generalized Black Scholes model prices an option off a single cost
+// of carry b, the drift of the underlying under the pricing measure,
+// and the rate r the payoff is discounted at. It is the Black Scholes
+// model with a dividend yield of r - b, so each specialization below is
+// a choice of b and r:
+//
+//   - b = r for a spot paying no dividends
+//   - b = r - q for a spot paying the dividend yield q
+//   - b = 0 for a future, the Black-76 model
+//   - b = r - rf for a currency with foreign rate rf, Garman-Kohlhagen
+//   - b = 0 and r = 0 for a futures-style margined option
+
+// PriceGeneralized returns the generalized Black Scholes price of an
+// option on an underlying with the given cost of carry, discounted at
+// discountRate. It is Price with dividend yield discountRate -
+// costOfCarry, as are its boundary values.
+func PriceGeneralized(
+	vol, timeToExpiry, spot, strike, costOfCarry, discountRate float64, optionType OptionType,
+) (float64, error) {
+	g, err := GreeksGeneralized(vol, timeToExpiry, spot, strike, costOfCarry, discountRate, optionType)
+	return g.Price, err
+}
+
+// GreeksGeneralized returns the price and greeks of PriceGeneralized,
+// those of BSGreeks with dividend yield discountRate - costOfCarry.
+// Theta holds the cost of carry and discount rate fixed.
+func GreeksGeneralized(
+	vol, timeToExpiry, spot, strike, costOfCarry, discountRate float64, optionType OptionType,
+) (Greeks, error) {
+
+	v, t, x, k, b, r, o := vol, timeToExpiry, spot, strike, costOfCarry, discountRate, optionType
+
+	if v < 0 {
+		return nanGreeks(), ErrNegVol
+	}
+	if err := checkParams(t, x, k, r, r-b, o); err != nil {
+		return nanGreeks(), err
+	}
+
+	return BSGreeks(v, t, x, k, r, r-b, o), nil
+}
+
+// PriceBlack76 returns the Black-76 price of an option on a future, the
+// generalized price with zero cost of carry
+func PriceBlack76(
+	vol, timeToExpiry, futuresPrice, strike, interestRate float64, optionType OptionType,
+) (float64, error) {
+	return PriceGeneralized(vol, timeToExpiry, futuresPrice, strike, 0, interestRate, optionType)
+}
+
+// GreeksBlack76 returns the price and greeks of PriceBlack76, with delta
+// and gamma with respect to the futures price
+func GreeksBlack76(
+	vol, timeToExpiry, futuresPrice, strike, interestRate float64, optionType OptionType,
+) (Greeks, error) {
+	return GreeksGeneralized(vol, timeToExpiry, futuresPrice, strike, 0, interestRate, optionType)
+}
+
+// PriceGarmanKohlhagen returns the Garman-Kohlhagen price of an option to
+// buy (call) or sell (put) one unit of foreign currency at spot, in
+// domestic currency per unit, the generalized price with cost of carry
+// domesticRate - foreignRate
+func PriceGarmanKohlhagen(
+	vol, timeToExpiry, spot, strike, domesticRate, foreignRate float64, optionType OptionType,
+) (float64, error) {
+	return PriceGeneralized(vol, timeToExpiry, spot, strike, domesticRate-foreignRate, domesticRate, optionType)
+}
+
+// GreeksGarmanKohlhagen returns the price and greeks of
+// PriceGarmanKohlhagen
+func GreeksGarmanKohlhagen(
+	vol, timeToExpiry, spot, strike, domesticRate, foreignRate float64, optionType OptionType,
+) (Greeks, error) {
+	return GreeksGeneralized(vol, timeToExpiry, spot, strike, domesticRate-foreignRate, domesticRate, optionType)
+}
+
+// PriceFuturesStyle returns the price of a futures-style option, whose
+// premium is margined like the future and so neither carried nor
+// discounted: the generalized price with zero carry and discount rate
+func PriceFuturesStyle(
+	vol, timeToExpiry, futuresPrice, strike float64, optionType OptionType,
+) (float64, error) {
+	return PriceGeneralized(vol, timeToExpiry, futuresPrice, strike, 0, 0, optionType)
+}
+
+// GreeksFuturesStyle returns the price and greeks of PriceFuturesStyle
+func GreeksFuturesStyle(
+	vol, timeToExpiry, futuresPrice, strike float64, optionType OptionType,
+) (Greeks, error) {
+	return GreeksGeneralized(vol, timeToExpiry, futuresPrice, strike, 0, 0, optionType)
+}
@@ -0,0 +1,199 @@
+package blackscholes
+
+import (
+	"fmt"
+	"math"
+)
+
+// GramCharlierError is returned for a skew and excess kurtosis at which
+// the Gram-Charlier density of the standardized log return,
+// n(z)*(1 + skew/6*He3(z) + kurtosis/24*He4(z)), goes negative. The
+// admissible region needs a kurtosis between 0 and 4, and a skew that
+// narrows to 0 at both ends, at most about 1.05 in size near kurtosis 2.4.
+type GramCharlierError struct {
+	Skew, Kurtosis float64
+}
+
+func (e *GramCharlierError) Error() string {
+	return fmt.Sprintf("Negative Gram-Charlier density at skew %g and excess kurtosis %g", e.Skew, e.Kurtosis)
+}
+
+// GramCharlierParams is a Gram-Charlier fit to one expiry with its root
+// mean square price error
+type GramCharlierParams struct {
+	Vol      float64
+	Skew     float64
+	Kurtosis float64
+	RMSE     float64
+}
+
+// PriceGramCharlier returns the Corrado-Su price of an option whose log
+// return to expiry has the Gram-Charlier density with standard deviation
+// vol*sqrt(t), the given skew and excess kurtosis, which is Black Scholes
+// plus third and fourth moment corrections. The mean of the log return is
+// shifted, as in Brown and Robinson, so the underlying stays a martingale
+// and put-call parity holds. At zero skew and kurtosis it is BSPrice, as
+// it is at zero vol, zero spot or strike and below TimeFloor, where the
+// corrections vanish. A skew and kurtosis outside the positive density
+// region return a *GramCharlierError.
+func PriceGramCharlier(
+	vol, skew, kurtosis, timeToExpiry, spot, strike, interestRate, dividendYield float64,
+	optionType OptionType,
+) (float64, error) {
+
+	v, t, x, k, r, q, o := vol, timeToExpiry, spot, strike, interestRate, dividendYield, optionType
+
+	if v < 0 {
+		return nan(), ErrNegVol
+	}
+	if err := checkParams(t, x, k, r, q, o); err != nil {
+		return nan(), err
+	}
+	if !gramCharlierAdmissible(skew, kurtosis) {
+		return nan(), &GramCharlierError{Skew: skew, Kurtosis: kurtosis}
+	}
+
+	return gramCharlierPrice(v, skew, kurtosis, t, x, k, r, q, o), nil
+}
+
+// gramCharlierPrice is PriceGramCharlier without the checks
+func gramCharlierPrice(v, g3, g4, t, x, k, r, q float64, o OptionType) float64 {
+
+	if g3 == 0 && g4 == 0 || v == 0 || t < TimeFloor || x == 0 || k == 0 {
+		return BSPrice(v, t, x, k, r, q, o)
+	}
+
+	s, f, df := v*sqrt(t), Forward(x, r, q, t), DiscountFactor(r, t)
+
+	// E[exp(s*z)] = exp(s*s/2)*(1 + w) under the density, so m makes the
+	// mean of the terminal underlying exp(m + s*z) the forward
+	w := g3/6*s*s*s + g4/24*s*s*s*s
+	m := log(f) - s*s/2 - math.Log1p(w)
+
+	// z0 is the standardized log return at the strike. With
+	// I[j] = int_z0^inf exp(s*z)*He_j(z)*n(z) dz and
+	// J[j] = int_z0^inf He_j(z)*n(z) dz, integration by parts gives
+	// J[j] = He_{j-1}(z0)*n(z0) and I[j] = exp(s*z0)*J[j] + s*I[j-1].
+	z0 := (log(k) - m) / s
+	n0 := NormPDF(z0)
+	he := [4]float64{1, z0, z0*z0 - 1, z0*z0*z0 - 3*z0}
+
+	var in, jn [5]float64
+	in[0], jn[0] = exp(s*s/2)*NormCDF(s-z0), NormCDF(-z0)
+	for j := 1; j <= 4; j++ {
+		jn[j] = he[j-1] * n0
+		in[j] = exp(s*z0)*jn[j] + s*in[j-1]
+	}
+
+	call := df * (exp(m)*(in[0]+g3/6*in[3]+g4/24*in[4]) - k*(jn[0]+g3/6*jn[3]+g4/24*jn[4]))
+	put := call - df*(f-k)
+
+	return byType(call, put, o)
+}
+
+// gramCharlierAdmissible reports whether the Gram-Charlier density with
+// skew g3 and excess kurtosis g4 is nowhere negative. For g4 > 0 its
+// polynomial part is a quartic with minima at the outer roots of its
+// derivative, which lie either side of [-1, 1].
+func gramCharlierAdmissible(g3, g4 float64) bool {
+
+	switch {
+	case math.IsNaN(g3) || math.IsNaN(g4) || g4 < 0:
+		return false
+	case g4 == 0:
+		return g3 == 0
+	}
+
+	p := func(z float64) float64 {
+		return 1 + g3/6*(z*z*z-3*z) + g4/24*(z*z*z*z-6*z*z+3)
+	}
+
+	// the derivative over g4/6 is z^3 + a*z^2 - 3z - a, which is 2 at -1
+	// and -2 at 1, with roots bounded by 1 + max(|a|, 3)
+	a := 3 * g3 / g4
+	dp := func(z float64) float64 { return ((z+a)*z-3)*z - a }
+	b := 1 + max(abs(a), 3)
+
+	lo := bisect(dp, -b, -1, 1e-12)
+	hi := bisect(dp, 1, b, 1e-12)
+
+	return p(lo) >= 0 && p(hi) >= 0
+}
+
+// CalibrateGramCharlier fits the vol, skew and excess kurtosis of
+// PriceGramCharlier to the mids of at least three quotes of one expiry by
+// least squares in price, searching log(vol), skew and kurtosis by
+// Nelder-Mead from several admissible starts. The vol is seeded from the
+// implied vol of the quote nearest the forward.
+func CalibrateGramCharlier(
+	quotes []Quote, timeToExpiry, spot, interestRate, dividendYield float64,
+) (GramCharlierParams, error) {
+
+	t, x, r, q := timeToExpiry, spot, interestRate, dividendYield
+
+	if len(quotes) < 3 {
+		return GramCharlierParams{}, ErrSmile
+	}
+	if err := checkParams(t, x, 0, r, q, Call); err != nil {
+		return GramCharlierParams{}, err
+	}
+	if t < TimeFloor || x == 0 {
+		return GramCharlierParams{}, ErrSmile
+	}
+
+	f := Forward(x, r, q, t)
+	atm := 0
+	for i := range quotes {
+		qt := &quotes[i]
+		if err := checkParams(t, x, qt.Strike, r, q, qt.Type); err != nil {
+			return GramCharlierParams{}, err
+		}
+		if qt.Type != Call && qt.Type != Put {
+			return GramCharlierParams{}, ErrUnknownOptionType
+		}
+		if abs(log(qt.Strike/f)) < abs(log(quotes[atm].Strike/f)) {
+			atm = i
+		}
+	}
+
+	v0, err := ImpliedVol(&ImpliedVolParams{
+		Premium: quotes[atm].mid(), TimeToExpiry: t, Underlying: x, Strike: quotes[atm].Strike,
+		Rate: r, Dividend: q, Type: quotes[atm].Type,
+	})
+	if err != nil || !(v0 > 0) {
+		v0 = 0.2
+	}
+
+	sse := func(p []float64) float64 {
+		if !gramCharlierAdmissible(p[1], p[2]) {
+			return inf(1)
+		}
+		s := 0.0
+		for i := range quotes {
+			qt := &quotes[i]
+			e := gramCharlierPrice(exp(p[0]), p[1], p[2], t, x, qt.Strike, r, q, qt.Type) - qt.mid()
+			s += e * e
+		}
+		if math.IsNaN(s) {
+			return inf(1)
+		}
+		return s
+	}
+
+	best, bestSSE := []float64(nil), inf(1)
+	for _, g3 := range []float64{-0.3, 0, 0.3} {
+		for _, g4 := range []float64{0.3, 1.5} {
+			p, s := nelderMead(sse, []float64{log(v0), g3, g4}, []float64{0.1, 0.1, 0.2}, 1e-16, 5000)
+			if s < bestSSE {
+				best, bestSSE = p, s
+			}
+		}
+	}
+
+	return GramCharlierParams{
+		Vol:      exp(best[0]),
+		Skew:     best[1],
+		Kurtosis: best[2],
+		RMSE:     sqrt(bestSSE / float64(len(quotes))),
+	}, nil
+}
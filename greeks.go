@@ -0,0 +1,127 @@
+package blackscholes
+
+// Greeks holds an option price together with its first order sensitivities.
+type Greeks struct {
+	Price float64
+	Delta float64
+	Gamma float64
+	Vega  float64
+	Theta float64
+	Rho   float64
+}
+
+func PriceAndGreeks(pars *PriceParams) (g Greeks, err error) {
+
+	if pars == nil {
+		return nanGreeks(), ErrNilPtrArg
+	}
+
+	v, t, x, k, r, q := GetFloatPriceParams(pars)
+
+	dfq, dfr, err := checkParamsDF(t, x, k, r, q, pars.Type)
+	if err != nil {
+		return nanGreeks(), err
+	}
+
+	g = greeksKernel(pars.model(), v, t, x, k, r, q, dfq, dfr, pars.Type)
+	return
+}
+
+// AllGreeks returns the price and greeks of BSGreeks, or NaN greeks and
+// the error for invalid parameters
+func AllGreeks(
+	vol, timeToExpiry, spot, strike, interestRate, dividendYield float64, optionType OptionType,
+) (Greeks, error) {
+
+	v, t, x, k, r, q, o := vol, timeToExpiry, spot, strike, interestRate, dividendYield, optionType
+
+	dfq, dfr, err := checkParamsDF(t, x, k, r, q, o)
+	if err != nil {
+		return nanGreeks(), err
+	}
+
+	return greeksKernel(model{}, v, t, x, k, r, q, dfq, dfr, o), nil
+}
+
+// BSGreeks returns the Black Scholes price and greeks evaluating
+// d1, d2, N(d1), N(d2), n(d1) and the discount factors only once.
+// Each field matches the corresponding standalone function.
+func BSGreeks(v, t, x, k, r, q float64, o OptionType) Greeks {
+
+	dfq, dfr, err := checkParamsDF(t, x, k, r, q, o)
+	if err != nil {
+		return nanGreeks()
+	}
+
+	return greeksKernel(model{}, v, t, x, k, r, q, dfq, dfr, o)
+}
+
+// greeksKernel is BSGreeks through m given the discount factors
+// exp(-q*t) and exp(-r*t) of checkParamsDF
+func greeksKernel(m model, v, t, x, k, r, q, dfq, dfr float64, o OptionType) Greeks {
+
+	sqrtt := sqrt(t)
+	if v <= 0 || x == 0 || k == 0 || m.expired(t) || m.belowCrossover(v*sqrtt) {
+		return Greeks{
+			Price: priceKernel(m, v, t, x, k, r, q, o),
+			Delta: deltaKernel(m, v, t, x, k, r, q, o),
+			Gamma: gammaKernel(m, v, t, x, k, r, q, o),
+			Vega:  vegaKernel(m, v, t, x, k, r, q, o),
+			Theta: thetaKernel(m, v, t, x, k, r, q, o),
+			Rho:   rhoKernel(m, v, t, x, k, r, q, o),
+		}
+	}
+
+	return interiorGreeks(m.n, v, t, x, k, r, q, sqrtt, dfq, dfr, o)
+}
+
+// interiorGreeks is greeksKernel given sqrt(t) and the discount factors,
+// for v > 0 and x, k, t past their boundary cases
+func interiorGreeks(n Normal, v, t, x, k, r, q, sqrtt, dfq, dfr float64, o OptionType) Greeks {
+
+	d1 := (log(x/k) + (r-q+0.5*v*v)*t) / v / sqrtt
+	d2 := d1 - v*sqrtt
+	xq, kr := dfq*x, dfr*k
+
+	// puts take N(-d1) and N(-d2), see priceKernel
+	sign := 1.0
+	if o == Put {
+		sign = -1
+	}
+
+	Nd1, Nd2, nd1 := normCDF(n, sign*d1), normCDF(n, sign*d2), normPDF(n, d1)
+
+	g := Greeks{
+		Gamma: dfq * nd1 / (x * v * sqrtt),
+		Vega:  xq * nd1 * sqrtt,
+		Theta: -v * xq * nd1 / (2 * sqrtt),
+	}
+
+	switch o {
+	case Call:
+		g.Price = Nd1*xq - Nd2*kr
+		g.Delta = dfq * Nd1
+		g.Theta += q*xq*Nd1 - r*kr*Nd2
+		g.Rho = t * kr * Nd2
+		return g
+	case Put:
+		g.Price = Nd2*kr - Nd1*xq
+		g.Delta = -dfq * Nd1
+		g.Theta += r*kr*Nd2 - q*xq*Nd1
+		g.Rho = -t * kr * Nd2
+		return g
+	}
+
+	g.Price = (2*Nd1-1)*xq - (2*Nd2-1)*kr
+	g.Delta = dfq * (2*Nd1 - 1)
+	g.Gamma *= 2
+	g.Vega *= 2
+	g.Theta = 2*g.Theta + q*xq*(2*Nd1-1) - r*kr*(2*Nd2-1)
+	g.Rho = t * kr * (2*Nd2 - 1)
+
+	return g
+}
+
+func nanGreeks() Greeks {
+	return Greeks{Price: nan(), Delta: nan(), Gamma: nan(), Vega: nan(), Theta: nan(), Rho: nan()}
+}
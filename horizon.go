@@ -0,0 +1,65 @@
+package blackscholes
+
+// HorizonGreeks returns the greeks of an option held for horizonDays with
+// spot and vol unchanged, repricing it at the time to expiry left at the
+// end of the horizon rather than extrapolating the instantaneous greeks:
+//
+//   - Price is the price now
+//   - Delta is the delta at the end of the horizon, the delta now plus
+//     its charm over the horizon
+//   - Gamma and Vega are their averages over the horizon, so that half
+//     of Gamma*x*x*v*v times the horizon in years is the expected gamma
+//     P&L of a delta hedge
+//   - Theta is the change in price over the horizon, the decay, not a
+//     rate
+//   - Rho is the rho now
+//
+// A horizon past expiry stops at expiry, where the greeks take their
+// ZeroTime values and Theta is the payoff less the price. A zero horizon
+// gives the BSGreeks with zero Theta. Negative or NaN horizons return
+// ErrHorizon.
+func HorizonGreeks(
+	vol, timeToExpiry, spot, strike, interestRate, dividendYield float64,
+	optionType OptionType, horizonDays float64,
+) (Greeks, error) {
+
+	v, t, x, k, r, q, o := vol, timeToExpiry, spot, strike, interestRate, dividendYield, optionType
+
+	if v < 0 {
+		return nanGreeks(), ErrNegVol
+	}
+	if err := checkParams(t, x, k, r, q, o); err != nil {
+		return nanGreeks(), err
+	}
+	if !(horizonDays >= 0) {
+		return nanGreeks(), ErrHorizon
+	}
+
+	now := BSGreeks(v, t, x, k, r, q, o)
+	now.Theta = 0
+
+	h := min(horizonDays/DaysPerYear, t)
+	if h == 0 {
+		return now, nil
+	}
+
+	end := BSGreeks(v, t-h, x, k, r, q, o)
+	tol := 1e-12 * max(1, x)
+
+	// the averages are integrated in w = sqrt(s) for the time to expiry
+	// s, for the 1/sqrt(s) growth of gamma at the money near expiry
+	average := func(greek func(v, t, x, k, r, q float64, o OptionType) float64) float64 {
+		return adaptiveSimpson(func(w float64) float64 {
+			return 2 * w * greek(v, w*w, x, k, r, q, o)
+		}, max(sqrt(t-h), sqrt(TimeFloor)), sqrt(t), tol, 50) / h
+	}
+
+	return Greeks{
+		Price: now.Price,
+		Delta: end.Delta,
+		Gamma: average(BSGamma),
+		Vega:  average(BSVega),
+		Theta: end.Price - now.Price,
+		Rho:   now.Rho,
+	}, nil
+}
@@ -24,6 +24,23 @@ type ImpliedVolParams struct {
 	UB           *float64
 	Tol          *float64
 	MaxIt        *int
+
+	// Normal, if not nil, prices every step of the search in place of
+	// StdNormal, without the NormCDFFast screen and the wing expansion
+	// that approximate StdNormal.
+	Normal Normal
+
+	// TimeFloor, if above the package TimeFloor, is the time to expiry
+	// below which the option is treated as expired, with a zero vol.
+	// 0 keeps TimeFloor.
+	TimeFloor float64
+
+	// OnIteration, if not nil, is called after every bisection step with
+	// the step number, the bracket [lb, ub] containing the midpoint vol
+	// and its price. The vol of the last call is the returned vol up to
+	// sign correction. It is for debugging only: it runs inside the solver
+	// loop, so an expensive callback slows every solve.
+	OnIteration func(iter int, lb, ub, vol, price float64)
 }
 
 func ImpliedVol(pars *ImpliedVolParams) (vol float64, err error) {
@@ -34,14 +51,24 @@ func ImpliedVol(pars *ImpliedVolParams) (vol float64, err error) {
 
 	p, t, x, k, r, q := GetFloatVolParams(pars)
 	o := pars.Type
-	if err = CheckPriceParams(t, x, k, o); err != nil {
+	if err = checkParams(t, x, k, r, q, o); err != nil {
 		return nan(), err
 	}
 
-	if t == 0 || x == 0 || k == 0 {
+	m := model{n: pars.Normal, floor: pars.TimeFloor}
+	if m.expired(t) || x == 0 || k == 0 {
 		return 0, nil
 	}
 
+	if p >= upperBound(t, x, k, r, q, o) {
+		return nan(), ErrArbitrage
+	}
+	if m.n == nil {
+		if v, ok := wingImpliedVol(p, t, x, k, r, q, o); ok {
+			return v, nil
+		}
+	}
+
 	intrval := Intrinsic(t, x, k, r, q, o)
 	extrval := p - intrval
 
@@ -56,38 +83,58 @@ func ImpliedVol(pars *ImpliedVolParams) (vol float64, err error) {
 	var (
 		it             int
 		plo, phi, pmid float64
+		vp             = newVolPricer(m.n, t, x, k, r, q, o)
 	)
 	for it = 0; it < maxit; it++ {
-		plo = BSPriceNoErrorCheck(lb, t, x, k, r, q, o)
-		if plo <= p {
+		if c := vp.cmp(lb, p); c < 0 || c == 0 && vp.price(lb) <= p {
 			break
 		}
 		lb -= 0.47
 	}
-	if p < plo {
-		return nan(), fmt.Errorf(
-			"Failed to find lower bound - lb price, lb vol, iters: %v, %v, %d",
-			plo, lb, it,
-		)
+	if it == maxit {
+		if plo = vp.price(lb); p < plo {
+			return nan(), fmt.Errorf(
+				"Failed to find lower bound - lb price, lb vol, iters: %v, %v, %d",
+				plo, lb, it,
+			)
+		}
 	}
 	for it = 0; it < maxit; it++ {
-		phi = BSPriceNoErrorCheck(ub, t, x, k, r, q, o)
-		if p <= phi {
+		if c := vp.cmp(ub, p); c > 0 || c == 0 && p <= vp.price(ub) {
 			break
 		}
 		ub += 0.47
 	}
-	if phi < p {
-		return nan(), fmt.Errorf(
-			"Failed to find upper bound - uprice, uvol, iters: %v, %v, %d",
-			phi, ub, it,
-		)
+	if it == maxit {
+		if phi = vp.price(ub); phi < p {
+			return nan(), fmt.Errorf(
+				"Failed to find upper bound - uprice, uvol, iters: %v, %v, %d",
+				phi, ub, it,
+			)
+		}
 	}
 
 	for it = 0; it < maxit; it++ {
 
 		vol = 0.5 * (lb + ub)
-		pmid = BSPriceNoErrorCheck(vol, t, x, k, r, q, o)
+
+		// far from the premium the approximate price is enough to tell
+		// which half of the bracket to keep
+		if ub-lb >= tol && pars.OnIteration == nil {
+			if c := vp.cmp(vol, p); c > 0 {
+				ub = vol
+				continue
+			} else if c < 0 {
+				lb = vol
+				continue
+			}
+		}
+
+		pmid = vp.price(vol)
+
+		if pars.OnIteration != nil {
+			pars.OnIteration(it, lb, ub, vol, pmid)
+		}
 
 		switch {
 		case ub-lb < tol, pmid == p:
@@ -100,13 +147,124 @@ func ImpliedVol(pars *ImpliedVolParams) (vol float64, err error) {
 		}
 	}
 
-	plo, phi = BSPrice(lb, t, x, k, r, q, o), BSPrice(ub, t, x, k, r, q, o)
+	pmid = vp.price(vol)
+	plo, phi = vp.price(lb), vp.price(ub)
 	return nan(), fmt.Errorf(
 		"Did not converge - lb, ub, lb price, ub price, mid, iters: %v, %v, %v, %v, %v, %d",
 		lb, ub, plo, phi, pmid, it,
 	)
 }
 
+// volPricer evaluates priceKernel as a function of the volatility alone,
+// with the vol independent terms computed once. It requires x, k > 0 and
+// t >= TimeFloor.
+type volPricer struct {
+	n           Normal // nil for StdNormal
+	t, sqrtt    float64
+	lnxk, drift float64
+	xq, kr      float64
+	intr        float64
+	o           OptionType
+	approxErr   float64 // bounds |approxPrice - price|
+}
+
+// fastCDFErr bounds the absolute error of NormCDFFast, whose cubic
+// interpolation error is at most about 9e-11
+const fastCDFErr float64 = 2e-10
+
+func newVolPricer(n Normal, t, x, k, r, q float64, o OptionType) volPricer {
+
+	xq, kr := discounted(x, q, t), discounted(k, r, t)
+
+	return volPricer{
+		n:         n,
+		t:         t,
+		sqrtt:     sqrt(t),
+		lnxk:      log(x / k),
+		drift:     r - q,
+		xq:        xq,
+		kr:        kr,
+		intr:      Intrinsic(t, x, k, r, q, o),
+		o:         o,
+		approxErr: 2 * fastCDFErr * (xq + kr),
+	}
+}
+
+func (p *volPricer) price(v float64) float64 {
+
+	switch {
+	case v < 0:
+		e := p.price(-v) - p.intr
+		return p.intr - e
+	case v == 0:
+		return p.intr
+	}
+
+	d1, d2 := p.d1d2(v)
+
+	if p.o == Put {
+		return normCDF(p.n, -d2)*p.kr - normCDF(p.n, -d1)*p.xq
+	}
+
+	Nd1, Nd2 := normCDF(p.n, d1), normCDF(p.n, d2)
+	if p.o == Call {
+		return Nd1*p.xq - Nd2*p.kr
+	}
+
+	return (2*Nd1-1)*p.xq - (2*Nd2-1)*p.kr
+}
+
+// approxPrice is price through NormCDFFast, within approxErr of it
+func (p *volPricer) approxPrice(v float64) float64 {
+
+	switch {
+	case v < 0:
+		e := p.approxPrice(-v) - p.intr
+		return p.intr - e
+	case v == 0:
+		return p.intr
+	}
+
+	s := v * p.sqrtt
+	d1 := (p.lnxk + (p.drift+0.5*v*v)*p.t) / s
+	d2 := d1 - s
+
+	if p.o == Put {
+		return NormCDFFast(-d2)*p.kr - NormCDFFast(-d1)*p.xq
+	}
+
+	Nd1, Nd2 := NormCDFFast(d1), NormCDFFast(d2)
+	if p.o == Call {
+		return Nd1*p.xq - Nd2*p.kr
+	}
+
+	return (2*Nd1-1)*p.xq - (2*Nd2-1)*p.kr
+}
+
+// cmp returns the sign of price(v) - premium if approxPrice tells it,
+// and 0 if the two are too close to tell without price or if price is
+// not through StdNormal
+func (p *volPricer) cmp(v, premium float64) int {
+
+	if p.n != nil {
+		return 0
+	}
+
+	switch e := p.approxPrice(v) - premium; {
+	case e > p.approxErr:
+		return 1
+	case e < -p.approxErr:
+		return -1
+	}
+
+	return 0
+}
+
+func (p *volPricer) d1d2(v float64) (d1, d2 float64) {
+	d1 = (p.lnxk + (p.drift+0.5*v*v)*p.t) / v / p.sqrtt
+	return d1, d1 - v*p.sqrtt
+}
+
 func CheckVolSearchParams(lb, ub, tol *float64, maxit *int) {
 
 	if lb == nil || ub == nil || tol == nil || maxit == nil {
@@ -0,0 +1,117 @@
+package blackscholes
+
+import "sort"
+
+// LadderResult holds the prices, vols and greeks of a ladder of options
+// over Strikes and Expiries. Each slice is a column major matrix with a
+// column per expiry, so the cell of strike i and expiry j is at index
+// j*len(Strikes) + i.
+type LadderResult struct {
+	Strikes  []float64
+	Expiries []float64
+	Vols     []float64
+	Prices   []float64
+	Deltas   []float64
+	Gammas   []float64
+	Vegas    []float64
+	Thetas   []float64
+	Rhos     []float64
+}
+
+// Index returns the slice index of the cell of strike i and expiry j
+func (l *LadderResult) Index(i, j int) int {
+	return j*len(l.Strikes) + i
+}
+
+// Vol returns the vol of the cell of strike i and expiry j
+func (l *LadderResult) Vol(i, j int) float64 {
+	return l.Vols[l.Index(i, j)]
+}
+
+// Price returns the price of the cell of strike i and expiry j
+func (l *LadderResult) Price(i, j int) float64 {
+	return l.Prices[l.Index(i, j)]
+}
+
+// Greeks returns the price and greeks of the cell of strike i and
+// expiry j
+func (l *LadderResult) Greeks(i, j int) Greeks {
+	n := l.Index(i, j)
+	return Greeks{
+		Price: l.Prices[n],
+		Delta: l.Deltas[n],
+		Gamma: l.Gammas[n],
+		Vega:  l.Vegas[n],
+		Theta: l.Thetas[n],
+		Rho:   l.Rhos[n],
+	}
+}
+
+// Ladder prices options of one type at every strike and expiry, each at
+// the vol of the source for its strike and expiry, and returns the prices
+// and greeks of BSGreeks. Cells whose vol lookup or parameters fail are
+// NaN and reported in a MultiError by cell index; the other cells are
+// still priced.
+func Ladder(
+	vol VolSource, spot float64, strikes, expiries []float64, r, q float64, optionType OptionType,
+) (LadderResult, error) {
+
+	n := len(strikes) * len(expiries)
+
+	l := LadderResult{
+		Strikes:  append([]float64(nil), strikes...),
+		Expiries: append([]float64(nil), expiries...),
+		Vols:     make([]float64, n),
+		Prices:   make([]float64, n),
+		Deltas:   make([]float64, n),
+		Gammas:   make([]float64, n),
+		Vegas:    make([]float64, n),
+		Thetas:   make([]float64, n),
+		Rhos:     make([]float64, n),
+	}
+
+	var errs MultiError
+
+	inputs := make([]PriceParams, len(strikes))
+	greeks := make([]Greeks, len(strikes))
+	failed := make([]bool, len(strikes))
+
+	for j, t := range expiries {
+
+		for i, k := range strikes {
+			v, err := vol.Vol(k, t)
+			if failed[i] = err != nil; failed[i] {
+				errs = append(errs, IndexError{Index: l.Index(i, j), Err: err})
+			}
+			l.Vols[l.Index(i, j)] = v
+			inputs[i] = PriceParams{
+				Vol: v, TimeToExpiry: t, Underlying: spot, Strike: k, Rate: r, Dividend: q, Type: optionType,
+			}
+		}
+
+		err := GreeksInto(greeks, inputs)
+		if me, ok := err.(MultiError); ok {
+			for _, e := range me {
+				if !failed[e.Index] {
+					failed[e.Index] = true
+					errs = append(errs, IndexError{Index: l.Index(e.Index, j), Err: e.Err})
+				}
+			}
+		}
+
+		for i, g := range greeks {
+			c := l.Index(i, j)
+			if failed[i] {
+				g = nanGreeks()
+			}
+			l.Prices[c], l.Deltas[c], l.Gammas[c], l.Vegas[c], l.Thetas[c] = g.Price, g.Delta, g.Gamma, g.Vega, g.Theta
+			l.Rhos[c] = g.Rho
+		}
+	}
+
+	if errs != nil {
+		sort.Slice(errs, func(a, b int) bool { return errs[a].Index < errs[b].Index })
+		return l, errs
+	}
+	return l, nil
+}
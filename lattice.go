@@ -0,0 +1,335 @@
+package blackscholes
+
+import "github.com/pkg/errors"
+
+// ExerciseStyle selects European or American exercise for lattice pricers
+type ExerciseStyle uint8
+
+const (
+	European ExerciseStyle = iota
+	American
+)
+
+var (
+	ErrSteps   = errors.New("Too few lattice steps")
+	ErrLattice = errors.New("Lattice probabilities out of range")
+)
+
+// MinLatticeSteps is the fewest steps accepted by the lattice pricers
+const MinLatticeSteps int = 2
+
+// PriceBinomial returns the option price on a Cox-Ross-Rubinstein
+// binomial tree with the given number of steps. An American Straddle may
+// be exercised once for the whole |S - K| payoff.
+func PriceBinomial(v, t, x, k, r, q float64, o OptionType, style ExerciseStyle, steps int) (float64, error) {
+	price, _, _, _, err := GreeksBinomial(v, t, x, k, r, q, o, style, steps)
+	return price, err
+}
+
+// GreeksBinomial returns the binomial price with delta, gamma and theta
+// read off the tree. The tree is extended two steps before time zero so
+// that its step two nodes x*u*u, x, x/u/u sit at time zero: delta and
+// gamma are the finite differences across them and theta is the change
+// of the center node value from time zero to the step four center node
+// at time 2*dt. Below TimeFloor the values are those of BSGreeks.
+func GreeksBinomial(
+	v, t, x, k, r, q float64, o OptionType, style ExerciseStyle, steps int,
+) (price, delta, gamma, theta float64, err error) {
+
+	price, delta, gamma, theta = nan(), nan(), nan(), nan()
+
+	if err = checkLattice(v, t, x, k, r, q, o, steps); err != nil {
+		return
+	}
+
+	if t < TimeFloor || x == 0 {
+		g := BSGreeks(v, t, x, k, r, q, o)
+		return g.Price, g.Delta, g.Gamma, g.Theta, nil
+	}
+
+	dt := t / float64(steps)
+	u := exp(v * sqrt(dt))
+	d := 1 / u
+	p := (Forward(1, r, q, dt) - d) / (u - d)
+	if !(p > 0 && p < 1) {
+		err = ErrLattice
+		return
+	}
+	pu, pd := DiscountFactor(r, dt)*p, DiscountFactor(r, dt)*(1-p)
+
+	// step i runs from time (i-2)*dt and node j has x*u^(2j-i)
+	n := steps + 2
+	vals := make([]float64, n+1)
+	for j := range vals {
+		vals[j] = payoff(x*pow(u, float64(2*j-n)), k, o)
+	}
+
+	var center4 float64
+	for i := n - 1; i >= 2; i-- {
+		for j := 0; j <= i; j++ {
+			vals[j] = pu*vals[j+1] + pd*vals[j]
+			if style == American {
+				vals[j] = max(vals[j], payoff(x*pow(u, float64(2*j-i)), k, o))
+			}
+		}
+		if i == 4 {
+			center4 = vals[2]
+		}
+	}
+	if n == 4 {
+		center4 = payoff(x, k, o)
+	}
+
+	xu, xd := x*u*u, x*d*d
+	vd, vm, vu := vals[0], vals[1], vals[2]
+
+	price = vm
+	delta = (vu - vd) / (xu - xd)
+	gamma = ((vu-vm)/(xu-x) - (vm-vd)/(x-xd)) / (xu - xd) * 2
+	theta = (center4 - vm) / 2 / dt
+
+	return
+}
+
+// PriceTrinomial returns the option price on a Boyle trinomial tree with
+// the given number of steps
+func PriceTrinomial(v, t, x, k, r, q float64, o OptionType, style ExerciseStyle, steps int) (float64, error) {
+	price, _, _, _, err := GreeksTrinomial(v, t, x, k, r, q, o, style, steps)
+	return price, err
+}
+
+// GreeksTrinomial is the trinomial analogue of GreeksBinomial. The tree
+// is extended one step before time zero, so its step one nodes x*u, x,
+// x/u sit at time zero, and theta is the change of the center node value
+// from time zero to time dt.
+func GreeksTrinomial(
+	v, t, x, k, r, q float64, o OptionType, style ExerciseStyle, steps int,
+) (price, delta, gamma, theta float64, err error) {
+
+	price, delta, gamma, theta = nan(), nan(), nan(), nan()
+
+	if err = checkLattice(v, t, x, k, r, q, o, steps); err != nil {
+		return
+	}
+
+	if t < TimeFloor || x == 0 {
+		g := BSGreeks(v, t, x, k, r, q, o)
+		return g.Price, g.Delta, g.Gamma, g.Theta, nil
+	}
+
+	dt := t / float64(steps)
+	u := exp(v * sqrt(2*dt))
+	a, b, c := Forward(1, r, q, dt/2), exp(v*sqrt(dt/2)), exp(-v*sqrt(dt/2))
+	pu, pd := (a-c)/(b-c), (b-a)/(b-c)
+	pu, pd = pu*pu, pd*pd
+	pm := 1 - pu - pd
+	if !(pu > 0 && pd > 0 && pm > 0) {
+		err = ErrLattice
+		return
+	}
+	df := DiscountFactor(r, dt)
+	pu, pm, pd = df*pu, df*pm, df*pd
+
+	// step i runs from time (i-1)*dt and node j has x*u^(j-i)
+	n := steps + 1
+	vals := make([]float64, 2*n+1)
+	for j := range vals {
+		vals[j] = payoff(x*pow(u, float64(j-n)), k, o)
+	}
+
+	// steps >= 2 so the loop passes step 2
+	var center2 float64
+	for i := n - 1; i >= 1; i-- {
+		for j := 0; j <= 2*i; j++ {
+			vals[j] = pu*vals[j+2] + pm*vals[j+1] + pd*vals[j]
+			if style == American {
+				vals[j] = max(vals[j], payoff(x*pow(u, float64(j-i)), k, o))
+			}
+		}
+		if i == 2 {
+			center2 = vals[2]
+		}
+	}
+
+	xu, xd := x*u, x/u
+	vd, vm, vu := vals[0], vals[1], vals[2]
+
+	price = vm
+	delta = (vu - vd) / (xu - xd)
+	gamma = ((vu-vm)/(xu-x) - (vm-vd)/(x-xd)) / (xu - xd) * 2
+	theta = (center2 - vm) / dt
+
+	return
+}
+
+// PriceLeisenReimer returns the option price on a Leisen-Reimer binomial
+// tree, whose Peizer-Pratt probabilities center the tree on the strike
+// and make prices converge smoothly at order 1/steps². An even number of
+// steps is rounded up to the next odd number. A Straddle is the tree
+// value of the |S - K| payoff.
+func PriceLeisenReimer(v, t, x, k, r, q float64, o OptionType, style ExerciseStyle, steps int) (float64, error) {
+
+	if err := checkLattice(v, t, x, k, r, q, o, steps); err != nil {
+		return nan(), err
+	}
+
+	if t < TimeFloor || x == 0 || k == 0 {
+		return BSPrice(v, t, x, k, r, q, o), nil
+	}
+
+	return leisenReimer(v, t, x, k, r, q, o, style, steps, nil)
+}
+
+// leisenReimer is the Leisen-Reimer tree of PriceLeisenReimer for a
+// positive spot, strike and time, with the escrow of binomialTree
+func leisenReimer(
+	v, t, x, k, r, q float64, o OptionType, style ExerciseStyle, steps int, escrow func(float64) float64,
+) (float64, error) {
+
+	if steps%2 == 0 {
+		steps++
+	}
+
+	dt := t / float64(steps)
+	vs := v * sqrt(t)
+	d1 := (log(x/k) + (r-q+0.5*v*v)*t) / vs
+	d2 := d1 - vs
+
+	// h is the Peizer-Pratt inversion and its complement, kept separately
+	// since p rounds to 0 or 1 far from the money at low vol
+	n := float64(steps)
+	h := func(z float64) (float64, float64) {
+		w := z / (n + 1.0/3 + 0.1/(n+1))
+		e := exp(-w * w * (n + 1.0/6))
+		c := 0.5 * e / (1 + sqrt(1-e))
+		if z < 0 {
+			return c, 1 - c
+		}
+		return 1 - c, c
+	}
+
+	p, pc := h(d2)
+	pp, ppc := h(d1)
+	g := Forward(1, r, q, dt)
+	u := g * pp / p
+	d := g * ppc / pc
+	if !(p > 0 && pc > 0 && d > 0 && d < u) {
+		return nan(), ErrLattice
+	}
+
+	return binomialTree(x, k, o, style, steps, dt, u, d, DiscountFactor(r, dt)*p, DiscountFactor(r, dt)*pc, escrow), nil
+}
+
+// binomialTree rolls the payoff back through a recombining tree of steps
+// of dt whose step i node j has underlying x*u^j*d^(i-j), with discounted
+// up and down probabilities pu and pd. If escrow is not nil the tree is
+// of the spot less the present value of the dividends to come, and
+// escrow(t) is that present value at time t, added back to the node spot
+// for early exercise.
+func binomialTree(
+	x, k float64, o OptionType, style ExerciseStyle, steps int, dt, u, d, pu, pd float64,
+	escrow func(float64) float64,
+) float64 {
+
+	ud := u / d
+	vals := make([]float64, steps+1)
+	s := x * pow(d, float64(steps))
+	for j := range vals {
+		vals[j] = payoff(s, k, o)
+		s *= ud
+	}
+
+	for i := steps - 1; i >= 0; i-- {
+		s = x * pow(d, float64(i))
+		var pv float64
+		if escrow != nil {
+			pv = escrow(float64(i) * dt)
+		}
+		for j := 0; j <= i; j++ {
+			vals[j] = pu*vals[j+1] + pd*vals[j]
+			if style == American {
+				vals[j] = max(vals[j], payoff(s+pv, k, o))
+				s *= ud
+			}
+		}
+	}
+
+	return vals[0]
+}
+
+// Fugit returns the risk neutral expected exercise time of an American
+// option on a Cox-Ross-Rubinstein tree. Expiry is assigned at the
+// terminal nodes and the node time at nodes where exercise beats holding,
+// and the times are rolled back with the risk neutral probabilities but
+// without discounting. An option never worth exercising early has a fugit
+// of t.
+func Fugit(v, t, x, k, r, q float64, o OptionType, steps int) (float64, error) {
+
+	if err := checkLattice(v, t, x, k, r, q, o, steps); err != nil {
+		return nan(), err
+	}
+
+	if t < TimeFloor {
+		return t, nil
+	}
+
+	dt := t / float64(steps)
+	u := exp(v * sqrt(dt))
+	d := 1 / u
+	p := (Forward(1, r, q, dt) - d) / (u - d)
+	if !(p > 0 && p < 1) {
+		return nan(), ErrLattice
+	}
+	df := DiscountFactor(r, dt)
+
+	vals, times := make([]float64, steps+1), make([]float64, steps+1)
+	s := x * pow(d, float64(steps))
+	for j := range vals {
+		vals[j], times[j] = payoff(s, k, o), t
+		s *= u * u
+	}
+
+	for i := steps - 1; i >= 0; i-- {
+		s = x * pow(d, float64(i))
+		for j := 0; j <= i; j++ {
+			hold := df * (p*vals[j+1] + (1-p)*vals[j])
+			if e := payoff(s, k, o); e > hold {
+				vals[j], times[j] = e, float64(i)*dt
+			} else {
+				vals[j], times[j] = hold, p*times[j+1]+(1-p)*times[j]
+			}
+			s *= u * u
+		}
+	}
+
+	return times[0], nil
+}
+
+func checkLattice(v, t, x, k, r, q float64, o OptionType, steps int) error {
+
+	if err := checkParams(t, x, k, r, q, o); err != nil {
+		return err
+	}
+
+	switch {
+	case v < 0:
+		return ErrNegVol
+	case steps < MinLatticeSteps:
+		return ErrSteps
+	case v == 0 && t >= TimeFloor:
+		return ErrLattice
+	}
+
+	return nil
+}
+
+// payoff is the exercise value of an option at underlying price x
+func payoff(x, k float64, o OptionType) float64 {
+	switch o {
+	case Call:
+		return max(x-k, 0)
+	case Put:
+		return max(k-x, 0)
+	}
+	return abs(x - k)
+}
@@ -0,0 +1,218 @@
+package blackscholes
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// The moments of the log return R = log(S/f) of the underlying S at
+// expiry against the forward f are model free, after Bakshi, Kapadia and
+// Madan: any twice differentiable payoff H(S) with H(f) = H'(f) = 0 is a
+// strip of out of the money options, so its undiscounted value is
+//
+//   E[H(S)] = int_0^f H''(k)*P(k) dk + int_f^Inf H''(k)*C(k) dk
+//
+// with P and C the undiscounted put and call prices. For H = R^n, n = 1
+// to 4, H''(k) is (n*(n-1)*l^(n-2) - n*l^(n-1))/k^2 at l = log(k/f), the
+// log, variance, cubic and quartic contracts, and the central moments
+// follow from these raw moments.
+
+var ErrMomentsConfig = errors.New("Invalid implied moments config")
+
+// MomentsExtrapolation is how ImpliedMoments treats strikes beyond the
+// out of the money quotes of a chain
+type MomentsExtrapolation uint8
+
+const (
+	// MomentsFlatVol prices the strikes beyond the quotes at the vol of
+	// the nearest quote, out to MomentsConfig.Width standard deviations
+	MomentsFlatVol MomentsExtrapolation = iota
+	// MomentsTruncate integrates over the quoted strikes only, which
+	// understates the variance and the size of the higher moments
+	MomentsTruncate
+)
+
+func (e MomentsExtrapolation) String() string {
+	switch e {
+	case MomentsFlatVol:
+		return "flat vol"
+	case MomentsTruncate:
+		return "truncate"
+	}
+	return fmt.Sprintf("MomentsExtrapolation(%d)", uint8(e))
+}
+
+// MomentsConfig sets up ChainMoments. A zero Width is 10 standard
+// deviations of the log return at the vol of the end quote.
+type MomentsConfig struct {
+	Extrapolation MomentsExtrapolation
+	Width         float64
+}
+
+// Moments are the risk neutral moments of the log return to expiry
+// implied by a chain, with the strikes of the quotes in the strip and
+// the range of strikes integrated over, the quoted range widened by any
+// extrapolation
+type Moments struct {
+	Variance   float64
+	Skewness   float64
+	Kurtosis   float64 // excess kurtosis, 0 for a normal log return
+	Strikes    []float64
+	LowStrike  float64
+	HighStrike float64
+}
+
+// ImpliedMoments returns the variance, skewness and excess kurtosis of
+// the log return to expiry implied by the chain, with the defaults of
+// ChainMoments. The variance is that of the log return over
+// timeToExpiry, not annualized.
+func ImpliedMoments(
+	chain OptionChain, timeToExpiry, spot, r, q float64,
+) (variance, skewness, kurtosis float64, err error) {
+
+	m, err := ChainMoments(chain, timeToExpiry, spot, r, q, MomentsConfig{})
+	if err != nil {
+		return nan(), nan(), nan(), err
+	}
+
+	return m.Variance, m.Skewness, m.Kurtosis, nil
+}
+
+// ChainMoments returns the moments of the log return to expiry implied
+// by the out of the money quotes of the chain, puts below the forward and
+// calls above it. The forward is implied by chain.Forward, or
+// is Forward(spot, r, q, timeToExpiry) if no strike is quoted on both
+// sides, and premia are undiscounted at r over timeToExpiry, which take
+// the place of chain.Rate and chain.T. The mids are inverted against the
+// forward and their vols interpolated linearly in strike, so that the
+// strip is integrated smoothly between the quotes and, under
+// MomentsFlatVol, beyond them. A chain with fewer than two out of the
+// money quotes returns ErrChain.
+func ChainMoments(
+	chain OptionChain, timeToExpiry, spot, r, q float64, cfg MomentsConfig,
+) (Moments, error) {
+
+	t := timeToExpiry
+
+	if cfg.Extrapolation > MomentsTruncate || cfg.Width < 0 {
+		return Moments{}, ErrMomentsConfig
+	}
+	if cfg.Width == 0 {
+		cfg.Width = 10
+	}
+	if !(t > 0) {
+		return Moments{}, ErrNegTimeToExp
+	}
+	if !(spot > 0) {
+		return Moments{}, ErrNegPrice
+	}
+	if err := checkExponents(t, spot, 0, r, q); err != nil {
+		return Moments{}, err
+	}
+
+	c := OptionChain{T: t, Rate: r, Quotes: chain.Quotes}
+
+	f, err := c.Forward()
+	if err == ErrChain {
+		f, err = Forward(spot, r, q, t), nil
+	}
+	if err != nil {
+		return Moments{}, err
+	}
+
+	// the out of the money strikes and vols, a strike quoted on both
+	// sides at the forward taking the average vol
+	var ks, vs []float64
+	for i := range c.Quotes {
+		qt := &c.Quotes[i]
+		if !(qt.Strike <= f && qt.Type == Put || qt.Strike >= f && qt.Type == Call) {
+			continue
+		}
+		v, err := c.forwardVol(f, qt)
+		if err != nil {
+			return Moments{}, err
+		}
+		if n := len(ks); n > 0 && ks[n-1] == qt.Strike {
+			vs[n-1] = (vs[n-1] + v) / 2
+			continue
+		}
+		ks, vs = append(ks, qt.Strike), append(vs, v)
+	}
+	if len(ks) < 2 {
+		return Moments{}, ErrChain
+	}
+
+	n := len(ks)
+	lo, hi := ks[0], ks[n-1]
+	if cfg.Extrapolation == MomentsFlatVol {
+		lo = min(lo, f*exp(-cfg.Width*vs[0]*sqrt(t)))
+		hi = max(hi, f*exp(cfg.Width*vs[n-1]*sqrt(t)))
+	}
+
+	vol := func(k float64) float64 {
+		switch {
+		case k <= ks[0]:
+			return vs[0]
+		case k >= ks[n-1]:
+			return vs[n-1]
+		}
+		i := 1
+		for ks[i] < k {
+			i++
+		}
+		return vs[i-1] + (vs[i]-vs[i-1])*(k-ks[i-1])/(ks[i]-ks[i-1])
+	}
+
+	// the segments between the kinks of the interpolated vols and the
+	// switch from puts to calls at the forward, in l = log(k/f)
+	nodes := []float64{lo}
+	for _, k := range ks {
+		if k > lo && k < hi && k != f {
+			nodes = append(nodes, k)
+		}
+	}
+	nodes = append(nodes, hi)
+	for i := 1; i < len(nodes); i++ {
+		if nodes[i-1] < f && f < nodes[i] {
+			nodes = append(nodes[:i], append([]float64{f}, nodes[i:]...)...)
+			break
+		}
+	}
+
+	// raw moments of R, the integrand in l being H''(k)*O(k)*k
+	var raw [5]float64
+	for p := 1; p <= 4; p++ {
+		pf := float64(p)
+		h := func(l float64) float64 {
+			k := f * exp(l)
+			o := Call
+			if k < f {
+				o = Put
+			}
+			d2 := -pf * pow(l, pf-1)
+			if p > 1 {
+				d2 += pf * (pf - 1) * pow(l, pf-2)
+			}
+			return d2 * BSPriceNoErrorCheck(vol(k), t, f, k, 0, 0, o) / k
+		}
+		for i := 1; i < len(nodes); i++ {
+			raw[p] += adaptiveSimpson(h, log(nodes[i-1]/f), log(nodes[i]/f), 1e-14, 30)
+		}
+	}
+
+	m1, m2, m3, m4 := raw[1], raw[2], raw[3], raw[4]
+	variance := m2 - m1*m1
+	if !(variance > 0) {
+		return Moments{}, ErrChain
+	}
+
+	return Moments{
+		Variance:   variance,
+		Skewness:   (m3 - 3*m1*m2 + 2*m1*m1*m1) / pow(variance, 1.5),
+		Kurtosis:   (m4-4*m1*m3+6*m1*m1*m2-3*m1*m1*m1*m1)/(variance*variance) - 3,
+		Strikes:    ks,
+		LowStrike:  lo,
+		HighStrike: hi,
+	}, nil
+}
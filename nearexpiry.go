@@ -0,0 +1,170 @@
+package blackscholes
+
+// Near expiry the Black Scholes formulas subtract numbers of the size of
+// the underlying to get a time value of the size of x*v*sqrt(t), losing
+// the digits of the price as the total vol s = v*sqrt(t) falls, while d1
+// and d2 run off to +-Inf. With the log-moneyness m = log(f/k) of the
+// forward f and the standardized moneyness z = m/s, the price is the
+// discounted intrinsic value of the forward plus the time value
+//
+//   sqrt(exp(-q*t)*x * exp(-r*t)*k) * c(-|z|, s)
+//   c(z, s) = exp(z*s/2)*N(z + s/2) - exp(-z*s/2)*N(z - s/2)
+//
+// of the out of the money option. c is odd in s at fixed z and
+// PriceNearExpiry sums its expansion in powers of s, whose leading term
+// s*(z*N(z) + n(z)) is the Bachelier time value, without cancellation.
+// BSPrice, BSGamma, BSTheta and BSGreeks switch to the near expiry forms
+// below NearExpiryTotalVol, so that the price and greek surfaces are
+// smooth down to TimeFloor.
+
+// NearExpiryTotalVol is the total vol v*sqrt(t) below which the BS
+// functions price with PriceNearExpiry, GammaNearExpiry and
+// ThetaNearExpiry. The NearExpiryTotalVol of PriceParams moves the
+// crossover for a call, or turns it off.
+const NearExpiryTotalVol float64 = 1e-3
+
+// nearExpiryOrder is the highest power of s summed by PriceNearExpiry
+const nearExpiryOrder = 25
+
+// PriceNearExpiry returns the Black Scholes price from the expansion of
+// the time value in the total vol s = v*sqrt(t), which agrees with
+// BSPrice to rounding for s up to about 1 and degrades beyond.
+// Boundary cases take the BSPrice values and invalid inputs return NaN.
+func PriceNearExpiry(v, t, x, k, r, q float64, o OptionType) float64 {
+
+	if checkParams(t, x, k, r, q, o) != nil {
+		return nan()
+	}
+	if v <= 0 || x == 0 || k == 0 || t < TimeFloor {
+		return BSPriceNoErrorCheck(v, t, x, k, r, q, o)
+	}
+
+	return priceNearExpiry(nil, v, t, x, k, r, q, o)
+}
+
+// GammaNearExpiry returns the Black Scholes gamma as the leading term
+// exp(-q*t)*n(z)/(x*s), which is exp(-q*t)/(x*v*sqrt(2*Pi*t)) at the
+// money forward, times its exact correction exp(-m/2 - s*s/8).
+// Boundary cases take the BSGamma values and invalid inputs return NaN.
+func GammaNearExpiry(v, t, x, k, r, q float64, o OptionType) float64 {
+
+	if checkParams(t, x, k, r, q, o) != nil {
+		return nan()
+	}
+	if v <= 0 || x == 0 || k == 0 || t < TimeFloor {
+		return BSGamma(v, t, x, k, r, q, o)
+	}
+
+	return gammaNearExpiry(nil, v, t, x, k, r, q, o)
+}
+
+// ThetaNearExpiry returns the Black Scholes theta as the leading term
+// -exp(-q*t)*x*v*n(z)/(2*sqrt(t)), which is -exp(-q*t)*x*v/(2*sqrt(2*Pi*t))
+// at the money forward, times exp(-m/2 - s*s/8) as in GammaNearExpiry,
+// plus the carry of the discounted underlying and strike weighted by the
+// probabilities of finishing in the money, taken from the tail of the
+// normal CDF. Boundary cases take the BSTheta values and invalid inputs
+// return NaN.
+func ThetaNearExpiry(v, t, x, k, r, q float64, o OptionType) float64 {
+
+	if checkParams(t, x, k, r, q, o) != nil {
+		return nan()
+	}
+	if v <= 0 || x == 0 || k == 0 || t < TimeFloor {
+		return BSTheta(v, t, x, k, r, q, o)
+	}
+
+	return thetaNearExpiry(nil, v, t, x, k, r, q, o)
+}
+
+// nearExpiry reports whether v and t are below the near expiry crossover
+func nearExpiry(v, t float64) bool {
+	return v*sqrt(t) < NearExpiryTotalVol
+}
+
+// priceNearExpiry is PriceNearExpiry for v, x, k > 0 and t >= TimeFloor
+func priceNearExpiry(n Normal, v, t, x, k, r, q float64, o OptionType) float64 {
+
+	s := v * sqrt(t)
+	m := log(x/k) + (r-q)*t
+	xq, kr := discounted(x, q, t), discounted(k, r, t)
+
+	tv := sqrt(xq*kr) * nearExpiryTimeValue(n, -abs(m), s)
+
+	switch o {
+	case Call:
+		return max(xq-kr, 0) + tv
+	case Put:
+		return max(kr-xq, 0) + tv
+	}
+
+	return abs(xq-kr) + 2*tv
+}
+
+// nearExpiryTimeValue returns c(m/s, s) for m <= 0 as twice the odd
+// powers of u = s/2 in the product of the series at fixed z = m/s
+//
+//	exp(z*u) = sum (m/2)^i/i!
+//	N(z + u) = N(z) + sum (-1)^(j-1) He_(j-1)(z) n(z) u^j/j!
+//
+// He being the probabilists' Hermite polynomials
+func nearExpiryTimeValue(n Normal, m, s float64) float64 {
+
+	z, u := m/s, s/2
+	nz := normPDF(n, z)
+
+	// a[i] and b[j] are the terms in u^i and u^j of the two series
+	var a, b [nearExpiryOrder + 1]float64
+	a[0], b[0] = 1, normCDF(n, z)
+
+	// he and hePrev are He_(j-1)(z) and He_(j-2)(z), uj is (-1)^(j-1)*u^j/j!
+	he, hePrev, uj := 1.0, 0.0, -1.0
+	for j := 1; j <= nearExpiryOrder; j++ {
+		a[j] = a[j-1] * m / 2 / float64(j)
+		uj *= -u / float64(j)
+		b[j] = uj * he * nz
+		he, hePrev = z*he-float64(j-1)*hePrev, he
+	}
+
+	var c float64
+	for p := nearExpiryOrder; p >= 1; p -= 2 {
+		for i := 0; i <= p; i++ {
+			c += a[i] * b[p-i]
+		}
+	}
+
+	return 2 * c
+}
+
+// gammaNearExpiry is GammaNearExpiry for v, x, k > 0 and t >= TimeFloor
+func gammaNearExpiry(n Normal, v, t, x, k, r, q float64, o OptionType) float64 {
+
+	s := v * sqrt(t)
+	m := log(x/k) + (r-q)*t
+	z := m / s
+
+	gamma := DiscountFactor(q, t) * normPDF(n, z) / x / s * exp(-m/2-s*s/8)
+
+	if o == Straddle {
+		return 2 * gamma
+	}
+	return gamma
+}
+
+// thetaNearExpiry is ThetaNearExpiry for v, x, k > 0 and t >= TimeFloor
+func thetaNearExpiry(n Normal, v, t, x, k, r, q float64, o OptionType) float64 {
+
+	sqrtt := sqrt(t)
+	s := v * sqrtt
+	m := log(x/k) + (r-q)*t
+	z := m / s
+	xq, kr := discounted(x, q, t), discounted(k, r, t)
+
+	decay := -xq * v * normPDF(n, z) / 2 / sqrtt * exp(-m/2-s*s/8)
+	d1, d2 := z+s/2, z-s/2
+
+	c := decay + q*xq*normCDF(n, d1) - r*kr*normCDF(n, d2)
+	p := decay - q*xq*normCDF(n, -d1) + r*kr*normCDF(n, -d2)
+
+	return byType(c, p, o)
+}
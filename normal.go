@@ -2,10 +2,304 @@ package blackscholes
 
 import "math"
 
+// Coefficients of Wichura's algorithm AS241 (PPND16)
+const (
+	icdfA0 = 3.3871328727963666080e0
+	icdfA1 = 1.3314166789178437745e+2
+	icdfA2 = 1.9715909503065514427e+3
+	icdfA3 = 1.3731693765509461125e+4
+	icdfA4 = 4.5921953931549871457e+4
+	icdfA5 = 6.7265770927008700853e+4
+	icdfA6 = 3.3430575583588128105e+4
+	icdfA7 = 2.5090809287301226727e+3
+	icdfB1 = 4.2313330701600911252e+1
+	icdfB2 = 6.8718700749205790830e+2
+	icdfB3 = 5.3941960214247511077e+3
+	icdfB4 = 2.1213794301586595867e+4
+	icdfB5 = 3.9307895800092710610e+4
+	icdfB6 = 2.8729085735721942674e+4
+	icdfB7 = 5.2264952788528545610e+3
+	icdfC0 = 1.42343711074968357734e0
+	icdfC1 = 4.63033784615654529590e0
+	icdfC2 = 5.76949722146069140550e0
+	icdfC3 = 3.64784832476320460504e0
+	icdfC4 = 1.27045825245236838258e0
+	icdfC5 = 2.41780725177450611770e-1
+	icdfC6 = 2.27238449892691845833e-2
+	icdfC7 = 7.74545014278341407640e-4
+	icdfD1 = 2.05319162663775882187e0
+	icdfD2 = 1.67638483018380384940e0
+	icdfD3 = 6.89767334985100004550e-1
+	icdfD4 = 1.48103976427480074590e-1
+	icdfD5 = 1.51986665636164571966e-2
+	icdfD6 = 5.47593808499534494600e-4
+	icdfD7 = 1.05075007164441684324e-9
+	icdfE0 = 6.65790464350110377720e0
+	icdfE1 = 5.46378491116411436990e0
+	icdfE2 = 1.78482653991729133580e0
+	icdfE3 = 2.96560571828504891230e-1
+	icdfE4 = 2.65321895265761230930e-2
+	icdfE5 = 1.24266094738807843860e-3
+	icdfE6 = 2.71155556874348757815e-5
+	icdfE7 = 2.01033439929228813265e-7
+	icdfF1 = 5.99832206555887937690e-1
+	icdfF2 = 1.36929880922735805310e-1
+	icdfF3 = 1.48753612908506148525e-2
+	icdfF4 = 7.86869131145613259100e-4
+	icdfF5 = 1.84631831751005468180e-5
+	icdfF6 = 1.42151175831644588870e-7
+	icdfF7 = 2.04426310338993978564e-15
+)
+
+// Normal is a standard normal distribution backend. The Black Scholes
+// prices and greeks evaluate their CDFs and densities through the Normal
+// of PriceParams, ImpliedVolParams, Batch or PricingContext, and PriceSim
+// and PayoffSim draw through the Quantile of SimConfig. A nil Normal is
+// StdNormal.
+type Normal interface {
+	CDF(x float64) float64
+	PDF(x float64) float64
+	Quantile(p float64) float64
+}
+
+// normCDF returns the CDF of n at x, calling NormCDF directly for a nil n
+func normCDF(n Normal, x float64) float64 {
+	if n == nil {
+		return NormCDF(x)
+	}
+	return n.CDF(x)
+}
+
+// normPDF is normCDF for the density
+func normPDF(n Normal, x float64) float64 {
+	if n == nil {
+		return NormPDF(x)
+	}
+	return n.PDF(x)
+}
+
+// normQuantile is normCDF for the quantile
+func normQuantile(n Normal, p float64) float64 {
+	if n == nil {
+		return NormCDFInverse(p)
+	}
+	return n.Quantile(p)
+}
+
+// StdNormal is the default Normal backed by NormCDF, NormPDF and
+// NormCDFInverse
+type StdNormal struct{}
+
+func (StdNormal) CDF(x float64) float64      { return NormCDF(x) }
+func (StdNormal) PDF(x float64) float64      { return NormPDF(x) }
+func (StdNormal) Quantile(p float64) float64 { return NormCDFInverse(p) }
+
+// FastNormal is the Normal whose CDF is NormCDFFast, for latency critical
+// pricing that can give up full accuracy: prices are off by at most about
+// 1e-10 times the discounted underlying plus strike. Its density and
+// quantile are those of StdNormal.
+type FastNormal struct{}
+
+func (FastNormal) CDF(x float64) float64      { return NormCDFFast(x) }
+func (FastNormal) PDF(x float64) float64      { return NormPDF(x) }
+func (FastNormal) Quantile(p float64) float64 { return NormCDFInverse(p) }
+
+// NormCDFFast interpolates a table of the CDF over [-fastCDFMax, fastCDFMax]
+// with fastCDFScale points per unit
+const (
+	fastCDFMax   float64 = 8
+	fastCDFScale float64 = 64
+)
+
+var fastCDFTable [2*int(fastCDFMax*fastCDFScale) + 1]struct{ p, d float64 }
+
+func init() {
+	for i := range fastCDFTable {
+		x := float64(i)/fastCDFScale - fastCDFMax
+		fastCDFTable[i].p = 0.5 * math.Erfc(-x/math.Sqrt2)
+		fastCDFTable[i].d = NormPDF(x) / fastCDFScale
+	}
+}
+
+// NormCDF returns the standard normal CDF evaluated through erfc,
+// which keeps full relative accuracy in the lower tail
 func NormCDF(x float64) float64 {
-	return 0.5 + 0.5*math.Erf(x/math.Sqrt2)
+	return 0.5 * math.Erfc(-x/math.Sqrt2)
+}
+
+// NormCDFFast approximates the standard normal CDF to about 1e-10 absolute
+// by cubic Hermite interpolation of a table of CDF and density values,
+// avoiding any exp or erfc evaluation
+func NormCDFFast(x float64) float64 {
+
+	switch {
+	case x >= fastCDFMax:
+		return 1
+	case x > -fastCDFMax:
+	case math.IsNaN(x):
+		return x
+	default:
+		return 0
+	}
+
+	u := (x + fastCDFMax) * fastCDFScale
+	i := int(u)
+	s := u - float64(i)
+	a, b := &fastCDFTable[i], &fastCDFTable[i+1]
+
+	return a.p + s*(a.d+s*(3*(b.p-a.p)-2*a.d-b.d+s*(2*(a.p-b.p)+a.d+b.d)))
+}
+
+// NormPDF returns the standard normal density
+func NormPDF(x float64) float64 {
+	return exp(-x*x/2) * InvSqrt2PI
+}
+
+// NormCDFInverse returns the standard normal quantile of p using
+// Wichura's AS241 rational approximations, accurate to about 1e-16
+// relative. NormCDFInverse(0) = -Inf, NormCDFInverse(1) = +Inf and
+// p outside [0, 1] returns NaN.
+func NormCDFInverse(p float64) float64 {
+
+	switch {
+	case math.IsNaN(p), p < 0, p > 1:
+		return nan()
+	case p == 0:
+		return inf(-1)
+	case p == 1:
+		return inf(1)
+	}
+
+	q := p - 0.5
+
+	if abs(q) <= 0.425 {
+		r := 0.180625 - q*q
+		return q * (((((((icdfA7*r+icdfA6)*r+icdfA5)*r+icdfA4)*r+icdfA3)*r+
+			icdfA2)*r+icdfA1)*r + icdfA0) /
+			(((((((icdfB7*r+icdfB6)*r+icdfB5)*r+icdfB4)*r+icdfB3)*r+
+				icdfB2)*r+icdfB1)*r + 1)
+	}
+
+	r := p
+	if q > 0 {
+		r = 1 - p
+	}
+	r = sqrt(-log(r))
+
+	var x float64
+	if r <= 5 {
+		r -= 1.6
+		x = (((((((icdfC7*r+icdfC6)*r+icdfC5)*r+icdfC4)*r+icdfC3)*r+
+			icdfC2)*r+icdfC1)*r + icdfC0) /
+			(((((((icdfD7*r+icdfD6)*r+icdfD5)*r+icdfD4)*r+icdfD3)*r+
+				icdfD2)*r+icdfD1)*r + 1)
+	} else {
+		r -= 5
+		x = (((((((icdfE7*r+icdfE6)*r+icdfE5)*r+icdfE4)*r+icdfE3)*r+
+			icdfE2)*r+icdfE1)*r + icdfE0) /
+			(((((((icdfF7*r+icdfF6)*r+icdfF5)*r+icdfF4)*r+icdfF3)*r+
+				icdfF2)*r+icdfF1)*r + 1)
+	}
+
+	if q < 0 {
+		return -x
+	}
+	return x
 }
 
-func NormCDFInverse(q float64) float64 {
-	return math.Sqrt2 * math.Erfinv(2*q-1)
+// Gauss-Legendre nodes (negative half) and weights of 6, 12 and 20 points
+// for bivariateNormCDF
+var (
+	bvnX = [3][]float64{
+		{-0.9324695142031522, -0.6612093864662647, -0.2386191860831970},
+		{-0.9815606342467191, -0.9041172563704750, -0.7699026741943050,
+			-0.5873179542866171, -0.3678314989981802, -0.1252334085114692},
+		{-0.9931285991850949, -0.9639719272779138, -0.9122344282513259,
+			-0.8391169718222188, -0.7463319064601508, -0.6360536807265150,
+			-0.5108670019508271, -0.3737060887154196, -0.2277858511416451,
+			-0.07652652113349733},
+	}
+	bvnW = [3][]float64{
+		{0.1713244923791705, 0.3607615730481384, 0.4679139345726904},
+		{0.04717533638651177, 0.1069393259953183, 0.1600783285433464,
+			0.2031674267230659, 0.2334925365383547, 0.2491470458134029},
+		{0.01761400713915212, 0.04060142980038694, 0.06267204833410906,
+			0.08327674157670475, 0.1019301198172404, 0.1181945319615184,
+			0.1316886384491766, 0.1420961093183821, 0.1491729864726037,
+			0.1527533871307259},
+	}
+)
+
+// bivariateNormCDF returns P(X < a, Y < b) for standard normals X and Y
+// with correlation rho, using Genz's refinement of the Drezner-Wesolowsky
+// algorithm, accurate to about 1e-15 absolute
+func bivariateNormCDF(a, b, rho float64) float64 {
+
+	var ng int
+	switch ar := abs(rho); {
+	case ar < 0.3:
+		ng = 0
+	case ar < 0.75:
+		ng = 1
+	default:
+		ng = 2
+	}
+	xs, ws := bvnX[ng], bvnW[ng]
+
+	// Genz computes the upper probability P(X > h, Y > k)
+	h, k := -a, -b
+	hk := h * k
+	var bvn float64
+
+	if abs(rho) < 0.925 {
+		hs := (h*h + k*k) / 2
+		asr := math.Asin(rho)
+		for i, x := range xs {
+			for _, s := range [2]float64{-1, 1} {
+				sn := math.Sin(asr * (s*x + 1) / 2)
+				bvn += ws[i] * exp((sn*hk-hs)/(1-sn*sn))
+			}
+		}
+		return bvn*asr/(4*math.Pi) + NormCDF(-h)*NormCDF(-k)
+	}
+
+	if rho < 0 {
+		k, hk = -k, -hk
+	}
+
+	if abs(rho) < 1 {
+		as := (1 - rho) * (1 + rho)
+		sa := sqrt(as)
+		bs := (h - k) * (h - k)
+		c, d := (4-hk)/8, (12-hk)/16
+		bvn = sa * exp(-(bs/as+hk)/2) * (1 - c*(bs-as)*(1-d*bs/5)/3 + c*d*as*as/5)
+		if hk > -160 {
+			sb := sqrt(bs)
+			bvn -= exp(-hk/2) * math.Sqrt(2*math.Pi) * NormCDF(-sb/sa) * sb * (1 - c*bs*(1-d*bs/5)/3)
+		}
+		sa /= 2
+		for i, x := range xs {
+			for _, s := range [2]float64{-1, 1} {
+				xx := sa * (s*x + 1)
+				xx *= xx
+				rs := sqrt(1 - xx)
+				bvn += sa * ws[i] * exp(-(bs/xx+hk)/2) *
+					(exp(-hk*(1-rs)/(2*(1+rs)))/rs - (1 + c*xx*(1+d*xx)))
+			}
+		}
+		bvn /= -2 * math.Pi
+	}
+
+	if rho > 0 {
+		return bvn + NormCDF(-math.Max(h, k))
+	}
+
+	bvn = -bvn
+	if k > h {
+		if h < 0 {
+			return NormCDF(k) - NormCDF(h) + bvn
+		}
+		return NormCDF(-h) - NormCDF(-k) + bvn
+	}
+	return bvn
 }
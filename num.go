@@ -79,3 +79,16 @@ func BSThetaNum(v, t, x, k, r, q float64, o OptionType, eps float64) float64 {
 
 	return (pu - pd) / 2 / e
 }
+
+func BSVolgaNum(v, t, x, k, r, q float64, o OptionType, eps float64) float64 {
+
+	if CheckPriceParams(t, x, k, o) != nil {
+		return nan()
+	}
+
+	pu := BSPriceNoErrorCheck(v+eps, t, x, k, r, q, o)
+	pm := BSPriceNoErrorCheck(v, t, x, k, r, q, o)
+	pd := BSPriceNoErrorCheck(v-eps, t, x, k, r, q, o)
+
+	return (pu - 2*pm + pd) / (eps * eps)
+}
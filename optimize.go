@@ -0,0 +1,130 @@
+package blackscholes
+
+import "sort"
+
+// nelderMead minimizes f from x0 with the Nelder-Mead simplex method,
+// starting from the simplex x0, x0 + step[i]*e_i. It stops when the spread
+// of f over the simplex falls below tol or after maxit iterations and
+// returns the best vertex and its value.
+func nelderMead(f func([]float64) float64, x0, step []float64, tol float64, maxit int) ([]float64, float64) {
+
+	n := len(x0)
+
+	type vertex struct {
+		x []float64
+		f float64
+	}
+
+	simplex := make([]vertex, n+1)
+	for i := range simplex {
+		x := append([]float64(nil), x0...)
+		if i > 0 {
+			x[i-1] += step[i-1]
+		}
+		simplex[i] = vertex{x, f(x)}
+	}
+
+	// point returns c + a*(x - c)
+	point := func(c, x []float64, a float64) vertex {
+		p := make([]float64, n)
+		for i := range p {
+			p[i] = c[i] + a*(x[i]-c[i])
+		}
+		return vertex{p, f(p)}
+	}
+
+	centroid := make([]float64, n)
+
+	for it := 0; it < maxit; it++ {
+
+		sort.Slice(simplex, func(i, j int) bool { return simplex[i].f < simplex[j].f })
+
+		best, worst := simplex[0], simplex[n]
+		if abs(worst.f-best.f) <= tol*(abs(best.f)+tol) {
+			break
+		}
+
+		for i := range centroid {
+			centroid[i] = 0
+			for _, v := range simplex[:n] {
+				centroid[i] += v.x[i] / float64(n)
+			}
+		}
+
+		r := point(centroid, worst.x, -1)
+		switch {
+		case r.f < best.f:
+			if e := point(centroid, worst.x, -2); e.f < r.f {
+				simplex[n] = e
+			} else {
+				simplex[n] = r
+			}
+		case r.f < simplex[n-1].f:
+			simplex[n] = r
+		default:
+			c := point(centroid, worst.x, 0.5)
+			if r.f < worst.f {
+				c = point(centroid, worst.x, -0.5)
+			}
+			if c.f < min(r.f, worst.f) {
+				simplex[n] = c
+				continue
+			}
+			for i := 1; i <= n; i++ {
+				simplex[i] = point(best.x, simplex[i].x, 0.5)
+			}
+		}
+	}
+
+	sort.Slice(simplex, func(i, j int) bool { return simplex[i].f < simplex[j].f })
+
+	return simplex[0].x, simplex[0].f
+}
+
+// bisect returns a root of f in [lo, hi], where f(lo) and f(hi) have
+// opposite signs, to within tol of its argument
+func bisect(f func(float64) float64, lo, hi, tol float64) float64 {
+
+	flo := f(lo)
+	for i := 0; i < 200 && hi-lo > tol; i++ {
+		mid := (lo + hi) / 2
+		fm := f(mid)
+		if fm == 0 {
+			return mid
+		}
+		if fm*flo < 0 {
+			hi = mid
+		} else {
+			lo, flo = mid, fm
+		}
+	}
+
+	return (lo + hi) / 2
+}
+
+// adaptiveSimpson integrates f over [a, b] by adaptive Simpson's rule to
+// within about tol, bisecting intervals at most depth times
+func adaptiveSimpson(f func(float64) float64, a, b, tol float64, depth int) float64 {
+
+	m := (a + b) / 2
+	fa, fm, fb := f(a), f(m), f(b)
+
+	return simpsonStep(f, a, b, fa, fm, fb, (b-a)/6*(fa+4*fm+fb), tol, depth)
+}
+
+// simpsonStep refines the Simpson estimate whole of [a, b] given f at a,
+// the midpoint and b
+func simpsonStep(f func(float64) float64, a, b, fa, fm, fb, whole, tol float64, depth int) float64 {
+
+	m := (a + b) / 2
+	lm, rm := (a+m)/2, (m+b)/2
+	flm, frm := f(lm), f(rm)
+	left, right := (m-a)/6*(fa+4*flm+fm), (b-m)/6*(fm+4*frm+fb)
+
+	if d := left + right - whole; depth <= 0 || abs(d) <= 15*tol {
+		return left + right + d/15
+	}
+
+	return simpsonStep(f, a, m, fa, flm, fm, left, tol/2, depth-1) +
+		simpsonStep(f, m, b, fm, frm, fb, right, tol/2, depth-1)
+}
@@ -0,0 +1,104 @@
+package blackscholes
+
+import (
+	"fmt"
+	"math"
+)
+
+// maxExponent is the largest a with exp(a) finite
+var maxExponent = math.Log(math.MaxFloat64)
+
+// DiscountTerm names the exponent of a DiscountExponentError
+type DiscountTerm uint8
+
+const (
+	// DiscountRate is the rate exponent r*t
+	DiscountRate DiscountTerm = iota
+	// DiscountDividend is the dividend exponent q*t
+	DiscountDividend
+	// DiscountUnderlying is the log of the discounted underlying
+	// x*exp(-q*t) or of the forward x*exp((r-q)*t)
+	DiscountUnderlying
+	// DiscountStrike is the log of the discounted strike k*exp(-r*t)
+	DiscountStrike
+)
+
+func (d DiscountTerm) String() string {
+	switch d {
+	case DiscountRate:
+		return "rate"
+	case DiscountDividend:
+		return "dividend"
+	case DiscountUnderlying:
+		return "underlying"
+	case DiscountStrike:
+		return "strike"
+	}
+	return fmt.Sprintf("DiscountTerm(%d)", uint8(d))
+}
+
+// DiscountExponentError is the ErrDiscountExponent of inputs whose rate
+// or dividend exponent is beyond MaxDiscountExponent, or whose discounted
+// underlying, forward or discounted strike overflows float64. Term names
+// the offending exponent and Exponent is its value, r*t, q*t or the log
+// of the overflowing amount. errors.Is matches it to ErrDiscountExponent.
+type DiscountExponentError struct {
+	Term     DiscountTerm
+	Exponent float64
+}
+
+func (e *DiscountExponentError) Error() string {
+
+	if e.Term == DiscountRate || e.Term == DiscountDividend {
+		return fmt.Sprintf("%v: %v exponent %g beyond %g", ErrDiscountExponent, e.Term, e.Exponent, MaxDiscountExponent)
+	}
+	return fmt.Sprintf("%v: exponent %g of discounted %v above %g", ErrDiscountExponent, e.Exponent, e.Term, maxExponent)
+}
+
+func (e *DiscountExponentError) Is(target error) bool { return target == ErrDiscountExponent }
+
+// checkExponents extends CheckDiscountExponents to the discounted
+// underlying, discounted strike and forward, returning a
+// *DiscountExponentError if any of them overflows. Zero prices and
+// strikes are skipped.
+func checkExponents(t, x, k, r, q float64) error {
+	_, _, err := checkedDiscountFactors(t, x, k, r, q)
+	return err
+}
+
+// checkedDiscountFactors is checkExponents returning the discount factors
+// exp(-q*t) and exp(-r*t) it checks the discounted amounts with, which
+// are finite and nonzero once CheckDiscountExponents passes
+func checkedDiscountFactors(t, x, k, r, q float64) (dfq, dfr float64, err error) {
+
+	if err = CheckDiscountExponents(t, r, q); err != nil {
+		return nan(), nan(), err
+	}
+
+	dfq, dfr = exp(-q*t), exp(-r*t)
+
+	// the forward x*dfq/dfr overflows when x*dfq > MaxFloat64*dfr
+	if xq := x * dfq; xq > math.MaxFloat64 || xq > math.MaxFloat64*dfr {
+		return nan(), nan(), &DiscountExponentError{Term: DiscountUnderlying, Exponent: log(x) + max(-q*t, (r-q)*t)}
+	}
+	if k*dfr > math.MaxFloat64 {
+		return nan(), nan(), &DiscountExponentError{Term: DiscountStrike, Exponent: log(k) - r*t}
+	}
+
+	return dfq, dfr, nil
+}
+
+// scaledExp returns x*exp(a) for x >= 0, clamped to +Inf past the float64
+// range and to 0 below it. A zero x is 0 whatever a is, and exp(a) alone
+// may overflow or underflow while x*exp(a) does not.
+func scaledExp(x, a float64) float64 {
+
+	if x == 0 {
+		return 0
+	}
+
+	if e := exp(a); e != 0 && e != inf(1) {
+		return x * e
+	}
+	return exp(log(x) + a)
+}
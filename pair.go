@@ -0,0 +1,128 @@
+package blackscholes
+
+import "fmt"
+
+// PairVolTol is the gap between the call and put vols of
+// ImpliedVolAndDividend beyond which a *VolGapWarning is returned
+const PairVolTol float64 = 1e-8
+
+// StalePairError is returned by ImpliedVolAndDividend for a call and put
+// that parity fits with a positive dividend-implied forward but that are
+// not both inside their no arbitrage bounds under it, usually because
+// one of them was not updated after the underlying moved
+type StalePairError struct {
+	Call, Put float64
+	Forward   float64 // the forward implied by parity
+}
+
+func (e *StalePairError) Error() string {
+	return fmt.Sprintf(
+		"Call %g and put %g outside arbitrage bounds at implied forward %g", e.Call, e.Put, e.Forward,
+	)
+}
+
+// VolGapWarning is returned by ImpliedVolAndDividend, along with a valid
+// vol and dividend yield, when the vols of the call and the put under the
+// implied dividend differ by more than PairVolTol
+type VolGapWarning struct {
+	CallVol, PutVol float64
+}
+
+func (w *VolGapWarning) Error() string {
+	return fmt.Sprintf("Call vol %v and put vol %v differ by %g", w.CallVol, w.PutVol, w.CallVol-w.PutVol)
+}
+
+// ImpliedVolAndDividend returns the vol and dividend yield at which a call
+// and a put with the same strike and expiry are worth their premia. The
+// dividend yield comes from put-call parity, which gives the forward
+// strike + (call - put)/DiscountFactor(interestRate, t), and the vol is
+// then inverted from the out of the money leg. A pair whose forward is
+// not positive, such as a crossed pair with the put far above the call,
+// returns a *ForwardError and a pair with a premium at or beyond its
+// bounds under that forward, such as a zero premium, a *StalePairError.
+// The spot, strike and time to expiry must be positive, at least
+// TimeFloor for the time, for the pair to imply a dividend; otherwise
+// ErrArbitrage is returned. Both legs are inverted, and if their vols
+// differ by more than PairVolTol, beyond the error from rounding the
+// premia, the vol of the out of the money leg is returned with a
+// *VolGapWarning reporting both.
+func ImpliedVolAndDividend(
+	callPremium, putPremium, timeToExpiry, spot, strike, interestRate float64,
+) (vol, dividendYield float64, err error) {
+	return ImpliedVolAndDividendWithTol(callPremium, putPremium, timeToExpiry, spot, strike, interestRate, PairVolTol)
+}
+
+// ImpliedVolAndDividendWithTol is ImpliedVolAndDividend with the vol gap
+// beyond which a *VolGapWarning is returned given in place of PairVolTol
+func ImpliedVolAndDividendWithTol(
+	callPremium, putPremium, timeToExpiry, spot, strike, interestRate, volTol float64,
+) (vol, dividendYield float64, err error) {
+
+	c, p, t, x, k, r := callPremium, putPremium, timeToExpiry, spot, strike, interestRate
+
+	if err = checkParams(t, x, k, r, 0, Call); err != nil {
+		return nan(), nan(), err
+	}
+	if c < 0 || p < 0 {
+		return nan(), nan(), ErrNegPremium
+	}
+	if t < TimeFloor || x == 0 || k == 0 {
+		return nan(), nan(), ErrArbitrage
+	}
+
+	f := k + (c-p)/DiscountFactor(r, t)
+	if !(f > 0) {
+		return nan(), nan(), &ForwardError{T: t, Forward: f}
+	}
+
+	q := r - ImpliedCarry(f, x, t)
+	if err = CheckDiscountExponents(t, r, q); err != nil {
+		return nan(), nan(), err
+	}
+
+	// the call is worth more than df*(f - k)+ exactly when the put is
+	// worth more than df*(k - f)+, and less than exp(-q*t)*x exactly when
+	// the put is worth less than the discounted strike
+	if !(c > 0 && p > 0 && p < discounted(k, r, t)) {
+		return nan(), nan(), &StalePairError{Call: c, Put: p, Forward: f}
+	}
+
+	cv, err := pairVol(c, t, x, k, r, q, Call)
+	if err != nil {
+		return nan(), nan(), err
+	}
+	pv, err := pairVol(p, t, x, k, r, q, Put)
+	if err != nil {
+		return nan(), nan(), err
+	}
+
+	vol = pv
+	if f < k {
+		vol = cv
+	}
+
+	// the vol of a leg is only known to the rounding of its premium over
+	// the vega, which vanishes deep in or out of the money
+	slack := 1e-15 * (c + p + x + k) / BSVega(vol, t, x, k, r, q, Call)
+	if abs(cv-pv) > volTol+slack {
+		return vol, q, &VolGapWarning{CallVol: cv, PutVol: pv}
+	}
+
+	return vol, q, nil
+}
+
+// pairVol bisects the vol of a call or put worth premium, strictly inside
+// its bounds, doubling the upper vol until it brackets the premium
+func pairVol(premium, t, x, k, r, q float64, o OptionType) (float64, error) {
+
+	price := func(v float64) float64 { return BSPriceNoErrorCheck(v, t, x, k, r, q, o) - premium }
+
+	ub := 1.0
+	for price(ub) < 0 {
+		if ub *= 2; ub > 1e6 {
+			return nan(), ErrNoncovergence
+		}
+	}
+
+	return bisect(price, 0, ub, 1e-14), nil
+}
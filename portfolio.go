@@ -0,0 +1,284 @@
+package blackscholes
+
+import (
+	"math"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+var ErrPortfolio = errors.New("Invalid portfolio")
+
+// Position is a quantity of one European option, negative when short,
+// with the vol it is valued at
+type Position struct {
+	Quantity     float64
+	Type         OptionType
+	Strike       float64
+	TimeToExpiry float64
+	Vol          float64
+}
+
+// Portfolio is a set of option positions and a holding of Shares of one
+// underlying, all valued at the same spot, rate and dividend yield
+type Portfolio struct {
+	Spot      float64
+	Rate      float64
+	Dividend  float64
+	Shares    float64
+	Positions []Position
+}
+
+// check validates the market data and every position of the portfolio
+func (p Portfolio) check() error {
+
+	if !(p.Spot > 0) {
+		return ErrNegPrice
+	}
+	if math.IsInf(p.Shares, 0) || math.IsNaN(p.Shares) {
+		return ErrPortfolio
+	}
+
+	for i := range p.Positions {
+		if err := p.checkPosition(i); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkPosition validates position i against the market data of the
+// portfolio
+func (p Portfolio) checkPosition(i int) error {
+
+	ps := &p.Positions[i]
+	if err := checkParams(ps.TimeToExpiry, p.Spot, ps.Strike, p.Rate, p.Dividend, ps.Type); err != nil {
+		return err
+	}
+	if ps.Vol < 0 {
+		return ErrNegVol
+	}
+	if math.IsInf(ps.Quantity, 0) || math.IsNaN(ps.Quantity) {
+		return ErrPortfolio
+	}
+
+	return nil
+}
+
+// Value returns the model value of the portfolio
+func (p Portfolio) Value() (float64, error) {
+
+	if err := p.check(); err != nil {
+		return nan(), err
+	}
+
+	return p.valueAt(p.Spot, 0), nil
+}
+
+// valueAt returns the value of the portfolio at spot x once elapsed years
+// have passed, each option at its model price for its remaining time,
+// which is intrinsic value at or past expiry. Shares are valued with
+// their dividends reinvested.
+func (p Portfolio) valueAt(x, elapsed float64) float64 {
+
+	value := p.Shares * x * exp(p.Dividend*elapsed)
+	for i := range p.Positions {
+		ps := &p.Positions[i]
+		t := max(ps.TimeToExpiry-elapsed, 0)
+		value += ps.Quantity * BSPriceNoErrorCheck(ps.Vol, t, x, ps.Strike, p.Rate, p.Dividend, ps.Type)
+	}
+
+	return value
+}
+
+// firstExpiry returns the earliest expiry of the positions, 0 if there
+// are none
+func (p Portfolio) firstExpiry() float64 {
+
+	t := inf(1)
+	for i := range p.Positions {
+		t = min(t, p.Positions[i].TimeToExpiry)
+	}
+	if t == inf(1) {
+		return 0
+	}
+
+	return t
+}
+
+// Greeks returns the summed price and greeks of the positions and
+// shares, each option at BSGreeks times its quantity
+func (p Portfolio) Greeks() (Greeks, error) {
+
+	if err := p.check(); err != nil {
+		return nanGreeks(), err
+	}
+
+	g := Greeks{Price: p.Shares * p.Spot, Delta: p.Shares}
+	for i := range p.Positions {
+		g = addGreeks(g, p.positionGreeks(i))
+	}
+
+	return g, nil
+}
+
+// PositionGreeks returns the greeks of each position times its quantity,
+// in position order. Invalid positions are NaN and reported in a
+// MultiError by index; the others are still valued. An invalid spot
+// returns ErrNegPrice alone.
+func (p Portfolio) PositionGreeks() ([]Greeks, error) {
+	return Batch{}.PositionGreeks(p)
+}
+
+// PositionGreeks is p.PositionGreeks run over the goroutines of b
+func (b Batch) PositionGreeks(p Portfolio) ([]Greeks, error) {
+
+	if !(p.Spot > 0) {
+		return nil, ErrNegPrice
+	}
+
+	greeks := make([]Greeks, len(p.Positions))
+
+	err := b.Run(len(p.Positions), func(i int) error {
+		if err := p.checkPosition(i); err != nil {
+			greeks[i] = nanGreeks()
+			return err
+		}
+		greeks[i] = p.positionGreeks(i)
+		return nil
+	})
+
+	return greeks, err
+}
+
+// positionGreeks returns the greeks of position i times its quantity
+func (p Portfolio) positionGreeks(i int) Greeks {
+
+	ps := &p.Positions[i]
+	g := BSGreeks(ps.Vol, ps.TimeToExpiry, p.Spot, ps.Strike, p.Rate, p.Dividend, ps.Type)
+
+	return Greeks{
+		Price: ps.Quantity * g.Price,
+		Delta: ps.Quantity * g.Delta,
+		Gamma: ps.Quantity * g.Gamma,
+		Vega:  ps.Quantity * g.Vega,
+		Theta: ps.Quantity * g.Theta,
+		Rho:   ps.Quantity * g.Rho,
+	}
+}
+
+func addGreeks(a, b Greeks) Greeks {
+	return Greeks{
+		Price: a.Price + b.Price,
+		Delta: a.Delta + b.Delta,
+		Gamma: a.Gamma + b.Gamma,
+		Vega:  a.Vega + b.Vega,
+		Theta: a.Theta + b.Theta,
+		Rho:   a.Rho + b.Rho,
+	}
+}
+
+// ExpiryBucket holds the summed greeks of the positions expiring in
+// (From, To], or at From == To for an exact expiry
+type ExpiryBucket struct {
+	From, To float64
+	Greeks   Greeks
+}
+
+// GreeksByExpiry returns the greeks of the positions by expiry bucket.
+// With no edges each distinct expiry is its own bucket. Otherwise the
+// strictly increasing positive edges, in years, split the expiries into
+// [0, edges[0]], (edges[0], edges[1]] and so on, with a last bucket to
+// +Inf if any position expires after the last edge. Buckets are in
+// increasing order and empty ones are kept. Shares have no expiry and
+// are in no bucket, so the bucketed gammas, vegas and thetas add up to
+// those of Greeks.
+func (p Portfolio) GreeksByExpiry(edges []float64) ([]ExpiryBucket, error) {
+
+	if err := p.check(); err != nil {
+		return nil, err
+	}
+
+	var buckets []ExpiryBucket
+
+	if len(edges) == 0 {
+		for i := range p.Positions {
+			t := p.Positions[i].TimeToExpiry
+			buckets = append(buckets, ExpiryBucket{From: t, To: t})
+		}
+		sort.Slice(buckets, func(i, j int) bool { return buckets[i].From < buckets[j].From })
+		n := 0
+		for i := range buckets {
+			if n == 0 || buckets[i].From != buckets[n-1].From {
+				buckets[n] = buckets[i]
+				n++
+			}
+		}
+		buckets = buckets[:n]
+	} else {
+		from := 0.0
+		for _, e := range edges {
+			if !(e > from) {
+				return nil, ErrPortfolio
+			}
+			buckets = append(buckets, ExpiryBucket{From: from, To: e})
+			from = e
+		}
+		for i := range p.Positions {
+			if p.Positions[i].TimeToExpiry > from {
+				buckets = append(buckets, ExpiryBucket{From: from, To: inf(1)})
+				break
+			}
+		}
+	}
+
+	for i := range p.Positions {
+		t := p.Positions[i].TimeToExpiry
+		j := sort.Search(len(buckets), func(j int) bool { return buckets[j].To >= t })
+		buckets[j].Greeks = addGreeks(buckets[j].Greeks, p.positionGreeks(i))
+	}
+
+	return buckets, nil
+}
+
+// ParallelVega returns the vega of the portfolio to a parallel shift of
+// every vol, by revaluation with central differences of 1e-4 in vol
+func (p Portfolio) ParallelVega() (float64, error) {
+
+	const h = 1e-4
+
+	up, err := p.VegaLadder([]float64{h, -h})
+	if err != nil {
+		return nan(), err
+	}
+
+	return (up[0] - up[1]) / 2 / h, nil
+}
+
+// VegaLadder returns the change in value of the portfolio when every vol
+// is shifted in parallel by each of volShifts. A shift taking any vol
+// below 0 returns ErrNegVol.
+func (p Portfolio) VegaLadder(volShifts []float64) ([]float64, error) {
+
+	if err := p.check(); err != nil {
+		return nil, err
+	}
+
+	base := p.valueAt(p.Spot, 0)
+	ladder := make([]float64, len(volShifts))
+
+	shifted := p
+	shifted.Positions = make([]Position, len(p.Positions))
+	for i, dv := range volShifts {
+		copy(shifted.Positions, p.Positions)
+		for j := range shifted.Positions {
+			if shifted.Positions[j].Vol += dv; !(shifted.Positions[j].Vol >= 0) {
+				return nil, ErrNegVol
+			}
+		}
+		ladder[i] = shifted.valueAt(p.Spot, 0) - base
+	}
+
+	return ladder, nil
+}
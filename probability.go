@@ -0,0 +1,93 @@
+package blackscholes
+
+import (
+	"math"
+	"sort"
+)
+
+// ProbabilityOfProfit returns the probability that the P&L of the
+// portfolio at horizon years is positive when the spot is lognormal with
+// the given vol and drift, the expected growth rate of the spot. A NaN
+// drift is the risk neutral drift Rate - Dividend. The P&L is valued as in
+// PayoffProfile: options expired by the horizon at intrinsic value, later
+// ones at their model price, less the current value carried at the rate.
+// The breakeven spots are found by scanning the P&L across the strikes
+// and 12 standard deviations either side of the expected log spot, and
+// the terminal probability is summed over the spots where it is positive.
+func ProbabilityOfProfit(positions Portfolio, vol, drift float64, horizon float64) (float64, error) {
+
+	p := positions
+
+	if err := p.check(); err != nil {
+		return nan(), err
+	}
+	switch {
+	case vol < 0:
+		return nan(), ErrNegVol
+	case !(horizon >= 0):
+		return nan(), ErrNegTimeToExp
+	}
+	if math.IsNaN(drift) {
+		drift = p.Rate - p.Dividend
+	}
+	if err := CheckDiscountExponents(horizon, drift, p.Rate); err != nil {
+		return nan(), err
+	}
+
+	cost := p.valueAt(p.Spot, 0) / DiscountFactor(p.Rate, horizon)
+	profit := func(x float64) bool { return p.valueAt(x, horizon)-cost > 0 }
+
+	m, s := log(p.Spot)+(drift-vol*vol/2)*horizon, vol*sqrt(horizon)
+	if s == 0 {
+		if profit(exp(m)) {
+			return 1, nil
+		}
+		return 0, nil
+	}
+	cdf := func(x float64) float64 { return NormCDF((log(x) - m) / s) }
+
+	grid := make([]float64, 0, len(p.Positions)+49)
+	for i := range p.Positions {
+		if k := p.Positions[i].Strike; k > 0 {
+			grid = append(grid, k)
+		}
+	}
+	for z := -12.0; z <= 12; z += 0.5 {
+		grid = append(grid, exp(m+s*z))
+	}
+	sort.Float64s(grid)
+
+	// prob adds up the terminal probability from each crossing where the
+	// P&L turns positive, or zero spot, to the next where it stops
+	prob, from := 0.0, 0.0
+	up := profit(grid[0])
+	for i := 1; i < len(grid); i++ {
+
+		a, b := grid[i-1], grid[i]
+		if profit(b) == up {
+			continue
+		}
+
+		// bisect for the crossing, keeping a on the side of up
+		for j := 0; j < 200 && b-a > 1e-12*b; j++ {
+			if mid := (a + b) / 2; profit(mid) == up {
+				a = mid
+			} else {
+				b = mid
+			}
+		}
+		x := (a + b) / 2
+
+		if up {
+			prob += cdf(x) - from
+		} else {
+			from = cdf(x)
+		}
+		up = !up
+	}
+	if up {
+		prob += 1 - from
+	}
+
+	return prob, nil
+}
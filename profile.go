@@ -0,0 +1,92 @@
+package blackscholes
+
+import "github.com/pkg/errors"
+
+var ErrSpotGrid = errors.New("Invalid spot grid")
+
+// ProfilePoint is one spot of a payoff profile: the value of the
+// portfolio at its first expiry, its model value now and the P&L at the
+// first expiry against its current value carried to that expiry
+type ProfilePoint struct {
+	Spot   float64
+	Expiry float64
+	Value  float64
+	PnL    float64
+}
+
+// PayoffProfile returns the profile of the portfolio at each spot of the
+// grid. The expiry value is taken at the first expiry of the positions:
+// options expiring then are worth their intrinsic value and later ones
+// their model price for the time left, and shares hold their dividends
+// reinvested. The P&L is the expiry value less the current value of the
+// portfolio at its own spot carried at the rate to the first expiry.
+func PayoffProfile(positions Portfolio, spotGrid []float64) ([]ProfilePoint, error) {
+
+	if err := positions.check(); err != nil {
+		return nil, err
+	}
+	for _, x := range spotGrid {
+		if !(x >= 0) || x == inf(1) {
+			return nil, ErrNegPrice
+		}
+	}
+
+	t := positions.firstExpiry()
+	cost := positions.valueAt(positions.Spot, 0) / DiscountFactor(positions.Rate, t)
+
+	points := make([]ProfilePoint, len(spotGrid))
+	for i, x := range spotGrid {
+		e := positions.valueAt(x, t)
+		points[i] = ProfilePoint{
+			Spot:   x,
+			Expiry: e,
+			Value:  positions.valueAt(x, 0),
+			PnL:    e - cost,
+		}
+	}
+
+	return points, nil
+}
+
+// OptionPayoffProfile is PayoffProfile for a single long option
+func OptionPayoffProfile(
+	vol, timeToExpiry, spot, strike, interestRate, dividendYield float64,
+	optionType OptionType, spotGrid []float64,
+) ([]ProfilePoint, error) {
+
+	return PayoffProfile(Portfolio{
+		Spot:     spot,
+		Rate:     interestRate,
+		Dividend: dividendYield,
+		Positions: []Position{{
+			Quantity:     1,
+			Type:         optionType,
+			Strike:       strike,
+			TimeToExpiry: timeToExpiry,
+			Vol:          vol,
+		}},
+	}, spotGrid)
+}
+
+// SpotGridAround returns points evenly spaced spots from
+// spot*(1 - widthPct/100) to spot*(1 + widthPct/100), for a width of at
+// most 100 percent and at least 2 points
+func SpotGridAround(spot, widthPct float64, points int) ([]float64, error) {
+
+	switch {
+	case !(spot > 0) || spot == inf(1):
+		return nil, ErrNegPrice
+	case !(widthPct > 0 && widthPct <= 100) || points < 2:
+		return nil, ErrSpotGrid
+	}
+
+	lo, hi := spot*(1-widthPct/100), spot*(1+widthPct/100)
+
+	grid := make([]float64, points)
+	for i := range grid {
+		grid[i] = lo + (hi-lo)*float64(i)/float64(points-1)
+	}
+	grid[points-1] = hi
+
+	return grid, nil
+}
@@ -0,0 +1,100 @@
+package blackscholes
+
+import "fmt"
+
+// Quote is one option quote of a chain. Mid, if 0, is taken as the
+// average of Bid and Ask, and Vol is an optional vendor implied vol.
+type Quote struct {
+	Strike   float64
+	Bid, Ask float64
+	Mid      float64
+	Vol      float64
+	Type     OptionType
+}
+
+// mid returns the quote mid price
+func (q *Quote) mid() float64 {
+	if q.Mid != 0 {
+		return q.Mid
+	}
+	return (q.Bid + q.Ask) / 2
+}
+
+// CrossedQuoteError is returned for a quote whose bid is above its ask
+type CrossedQuoteError struct {
+	Bid, Ask float64
+}
+
+func (e *CrossedQuoteError) Error() string {
+	return fmt.Sprintf("Crossed quote: bid %v above ask %v", e.Bid, e.Ask)
+}
+
+// ImpliedVolQuote inverts the bid, ask and mid of q for an option with the
+// given strike and type; q.Strike and q.Type are not used. A side at or
+// below intrinsic value, such as a zero bid, has vol 0, and a side at or
+// above the no arbitrage upper bound, the discounted underlying for a
+// call or strike for a put, has vol +Inf. Neither is an error, so one bad
+// side does not fail a batch. A crossed quote returns *CrossedQuoteError.
+func ImpliedVolQuote(
+	q Quote, timeToExpiry, spot, strike, r, qDiv float64, o OptionType,
+) (bidVol, askVol, midVol float64, err error) {
+
+	bidVol, askVol, midVol = nan(), nan(), nan()
+
+	if q.Bid > q.Ask {
+		err = &CrossedQuoteError{q.Bid, q.Ask}
+		return
+	}
+
+	t, x, k := timeToExpiry, spot, strike
+	if err = checkParams(t, x, k, r, qDiv, o); err != nil {
+		return
+	}
+
+	lo, hi := Intrinsic(t, x, k, r, qDiv, o), upperBound(t, x, k, r, qDiv, o)
+
+	vol := func(p float64) (float64, error) {
+		switch {
+		case p <= lo:
+			return 0, nil
+		case p >= hi:
+			return inf(1), nil
+		}
+		return ImpliedVol(&ImpliedVolParams{
+			Premium: p, TimeToExpiry: t, Underlying: x, Strike: k, Rate: r, Dividend: qDiv, Type: o,
+		})
+	}
+
+	if bidVol, err = vol(q.Bid); err != nil {
+		return nan(), nan(), nan(), err
+	}
+	if askVol, err = vol(q.Ask); err != nil {
+		return nan(), nan(), nan(), err
+	}
+	if midVol, err = vol(q.mid()); err != nil {
+		return nan(), nan(), nan(), err
+	}
+
+	return
+}
+
+// ImpliedVolInterval returns the vols of the bid and the ask of a quote
+// with a Solver of the default configuration, see
+// Solver.ImpliedVolInterval
+func ImpliedVolInterval(
+	bid, ask, timeToExpiry, spot, strike, r, q float64, o OptionType,
+) (volLow, volHigh float64, err error) {
+
+	s, _ := NewSolver(SolverConfig{})
+	return s.ImpliedVolInterval(bid, ask, timeToExpiry, spot, strike, r, q, o)
+}
+
+// VolSpread returns the bid-ask spread of q in vol points, 100 times the
+// difference of the vols of ImpliedVolQuote
+func VolSpread(q Quote, timeToExpiry, spot, strike, r, qDiv float64, o OptionType) (float64, error) {
+	bidVol, askVol, _, err := ImpliedVolQuote(q, timeToExpiry, spot, strike, r, qDiv, o)
+	if err != nil {
+		return nan(), err
+	}
+	return 100 * (askVol - bidVol), nil
+}
@@ -0,0 +1,133 @@
+package blackscholes
+
+import (
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+var ErrSABR = errors.New("Invalid SABR parameters")
+
+// SABRParams is a SABR smile for the expiry T and forward of a
+// calibration, with the root mean square vol error RMSE of the fit
+type SABRParams struct {
+	Alpha, Beta, Rho, Nu float64
+	Forward, T           float64
+	RMSE                 float64
+}
+
+// Vol returns the Hagan lognormal implied vol of the smile at strike, NaN
+// for invalid parameters
+func (p SABRParams) Vol(strike float64) float64 {
+	v, err := SABRImpliedVol(p.Alpha, p.Beta, p.Rho, p.Nu, p.Forward, strike, p.T)
+	if err != nil {
+		return nan()
+	}
+	return v
+}
+
+// SABRImpliedVol returns the Black implied vol of the SABR model by the
+// lognormal expansion of Hagan et al. (2002). The factor z/x(z) is 0/0 at
+// the money and is replaced by its series 1 - rho*z/2 + (2 - 3*rho^2)*z^2/12
+// for |z| < 1e-4, so the ATM vol is
+// alpha/F^(1-beta)*(1 + ((1-beta)^2/24*alpha^2/F^(2-2*beta) +
+// rho*beta*nu*alpha/(4*F^(1-beta)) + (2-3*rho^2)/24*nu^2)*t).
+// It requires alpha > 0, 0 <= beta <= 1, |rho| < 1 and nu >= 0.
+func SABRImpliedVol(alpha, beta, rho, nu, forward, strike, timeToExpiry float64) (float64, error) {
+
+	switch {
+	case !(alpha > 0) || !(beta >= 0 && beta <= 1) || !(abs(rho) < 1) || !(nu >= 0):
+		return nan(), ErrSABR
+	case !(forward > 0):
+		return nan(), ErrNegPrice
+	case !(strike > 0):
+		return nan(), ErrNegStrike
+	case !(timeToExpiry >= 0):
+		return nan(), ErrNegTimeToExp
+	}
+
+	f, k, t := forward, strike, timeToExpiry
+	b1 := 1 - beta
+	fkb := pow(f*k, b1/2) // (F*K)^((1-beta)/2)
+	lfk := log(f / k)
+	l2 := lfk * lfk
+
+	z := nu / alpha * fkb * lfk
+
+	var zx float64
+	if abs(z) < 1e-4 {
+		zx = 1 - rho*z/2 + (2-3*rho*rho)*z*z/12
+	} else {
+		zx = z / log((sqrt(1-2*rho*z+z*z)+z-rho)/(1-rho))
+	}
+
+	den := fkb * (1 + b1*b1/24*l2 + b1*b1*b1*b1/1920*l2*l2)
+	corr := 1 + (b1*b1/24*alpha*alpha/fkb/fkb+rho*beta*nu*alpha/4/fkb+(2-3*rho*rho)/24*nu*nu)*t
+
+	return alpha / den * zx * corr, nil
+}
+
+// CalibrateSABR fits alpha, rho and nu to the points of one expiry by
+// least squares in implied vol with beta fixed, searching log(alpha),
+// atanh(rho) and log(nu) by Nelder-Mead from several starts. Alpha is
+// seeded from the vol quoted nearest the forward. At least three points
+// are needed.
+func CalibrateSABR(points []SmilePoint, beta, forward, t float64) (SABRParams, error) {
+
+	if !(beta >= 0 && beta <= 1) {
+		return SABRParams{}, ErrSABR
+	}
+
+	ks, ws, err := smileVariances(points, forward, t)
+	if err != nil {
+		return SABRParams{}, err
+	}
+	if len(ks) < 3 {
+		return SABRParams{}, ErrSmile
+	}
+
+	vols := make([]float64, len(ws))
+	atm := 0
+	for i, w := range ws {
+		vols[i] = sqrt(w / t)
+		if abs(ks[i]) < abs(ks[atm]) {
+			atm = i
+		}
+	}
+
+	params := func(x []float64) SABRParams {
+		return SABRParams{
+			Alpha: exp(x[0]), Beta: beta, Rho: math.Tanh(x[1]), Nu: exp(x[2]),
+			Forward: forward, T: t,
+		}
+	}
+	sse := func(x []float64) float64 {
+		p, s := params(x), 0.0
+		for i, k := range ks {
+			e := p.Vol(forward*exp(k)) - vols[i]
+			s += e * e
+		}
+		if math.IsNaN(s) {
+			return inf(1)
+		}
+		return s
+	}
+
+	alpha0 := log(vols[atm] * pow(forward, 1-beta))
+
+	best, bestSSE := []float64(nil), inf(1)
+	for _, rho := range []float64{-0.5, 0, 0.5} {
+		for _, nu := range []float64{0.2, 1} {
+			x0 := []float64{alpha0, math.Atanh(rho), log(nu)}
+			x, s := nelderMead(sse, x0, []float64{0.1, 0.3, 0.5}, 1e-16, 5000)
+			if s < bestSSE {
+				best, bestSSE = x, s
+			}
+		}
+	}
+
+	p := params(best)
+	p.RMSE = sqrt(bestSSE / float64(len(ks)))
+
+	return p, nil
+}
@@ -0,0 +1,265 @@
+package blackscholes
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrShiftMode = errors.New("Unknown shift mode")
+	ErrSpotBump  = errors.New("Invalid spot bump")
+)
+
+// ShiftMode is how the vols of a VolSource move when the spot is shocked
+type ShiftMode uint8
+
+const (
+	// StickyStrike keeps the vol of each strike, so an option is revalued
+	// at the vol it had before the shock
+	StickyStrike ShiftMode = iota
+	// StickyDelta keeps the vol of each moneyness strike/spot, so an
+	// option at strike k and shocked spot x is revalued at the vol of
+	// strike k*spot/x, the strike that had its moneyness, and so its
+	// delta at a fixed vol, before the shock
+	StickyDelta
+)
+
+func (m ShiftMode) String() string {
+	switch m {
+	case StickyStrike:
+		return "sticky strike"
+	case StickyDelta:
+		return "sticky delta"
+	}
+	return fmt.Sprintf("ShiftMode(%d)", uint8(m))
+}
+
+// shiftedVol is a VolSource read at strikes scaled by scale, the vols of
+// a source after a sticky delta spot shock
+type shiftedVol struct {
+	src   VolSource
+	scale float64
+}
+
+func (s shiftedVol) Vol(strike, timeToExpiry float64) (float64, error) {
+	return s.src.Vol(strike*s.scale, timeToExpiry)
+}
+
+// ShiftedVolSource returns the vols of vol once the spot moves from spot
+// to shockedSpot under mode: vol itself under StickyStrike and vol read at
+// the strike of the same moneyness under StickyDelta. Both spots must be
+// positive and finite.
+func ShiftedVolSource(vol VolSource, spot, shockedSpot float64, mode ShiftMode) (VolSource, error) {
+
+	switch {
+	case vol == nil:
+		return nil, ErrNilPtrArg
+	case !(spot > 0) || !(shockedSpot > 0) || spot == inf(1) || shockedSpot == inf(1):
+		return nil, ErrNegPrice
+	}
+
+	switch mode {
+	case StickyStrike:
+		return vol, nil
+	case StickyDelta:
+		return shiftedVol{src: vol, scale: spot / shockedSpot}, nil
+	}
+
+	return nil, ErrShiftMode
+}
+
+// ScenarioPoint is one shocked spot of SpotScenarios with the vol the
+// option is revalued at and its price and greeks there
+type ScenarioPoint struct {
+	Spot   float64
+	Vol    float64
+	Greeks Greeks
+}
+
+// SpotScenarios revalues one option at each shocked spot, reading its vol
+// from the source under mode as in ShiftedVolSource, and returns the
+// BSGreeks there. Points whose vol lookup or parameters fail are NaN and
+// reported in a MultiError by index; the others are still valued.
+func SpotScenarios(
+	vol VolSource, spot float64, shockedSpots []float64,
+	strike, timeToExpiry, r, q float64, optionType OptionType, mode ShiftMode,
+) ([]ScenarioPoint, error) {
+	return Batch{}.SpotScenarios(vol, spot, shockedSpots, strike, timeToExpiry, r, q, optionType, mode)
+}
+
+// SpotScenarios is SpotScenarios run over the goroutines of b; with more
+// than one worker the source must be safe for concurrent use.
+func (b Batch) SpotScenarios(
+	vol VolSource, spot float64, shockedSpots []float64,
+	strike, timeToExpiry, r, q float64, optionType OptionType, mode ShiftMode,
+) ([]ScenarioPoint, error) {
+
+	if vol == nil {
+		return nil, ErrNilPtrArg
+	}
+	if mode != StickyStrike && mode != StickyDelta {
+		return nil, ErrShiftMode
+	}
+
+	points := make([]ScenarioPoint, len(shockedSpots))
+
+	err := b.Run(len(shockedSpots), func(i int) error {
+
+		x := shockedSpots[i]
+		points[i] = ScenarioPoint{Spot: x, Vol: nan(), Greeks: nanGreeks()}
+
+		src, err := ShiftedVolSource(vol, spot, x, mode)
+		if err != nil {
+			return err
+		}
+		v, err := src.Vol(strike, timeToExpiry)
+		if err != nil {
+			return err
+		}
+		points[i].Vol = v
+
+		g, err := PriceAndGreeks(&PriceParams{
+			Vol: v, TimeToExpiry: timeToExpiry, Underlying: x, Strike: strike, Rate: r, Dividend: q, Type: optionType,
+		})
+		if err != nil {
+			return err
+		}
+		points[i].Greeks = g
+
+		return nil
+	})
+
+	return points, err
+}
+
+// LadderShifted is Ladder after the spot moves from spot to shockedSpot,
+// with the vols read from the source under mode as in ShiftedVolSource
+func LadderShifted(
+	vol VolSource, spot, shockedSpot float64, strikes, expiries []float64,
+	r, q float64, optionType OptionType, mode ShiftMode,
+) (LadderResult, error) {
+
+	src, err := ShiftedVolSource(vol, spot, shockedSpot, mode)
+	if err != nil {
+		return LadderResult{}, err
+	}
+
+	return Ladder(src, shockedSpot, strikes, expiries, r, q, optionType)
+}
+
+// SmileSpotBump is the relative spot move over which SmileDelta and
+// SmileGamma difference the vol source
+const SmileSpotBump float64 = 1e-4
+
+// SmileDelta returns the delta of an option whose vol moves with the spot
+// as the source does under mode, the Black Scholes delta plus vega times
+// the derivative of the vol in spot, taken by central differences of the
+// source over SmileSpotBump. Under StickyStrike the vol does not move and
+// it is BSDelta; under StickyDelta a downward skew raises the vol of the
+// strike as the spot rises, and so the delta.
+func SmileDelta(
+	vol VolSource, spot, strike, timeToExpiry, r, q float64, optionType OptionType, mode ShiftMode,
+) (float64, error) {
+
+	x, k, t, o := spot, strike, timeToExpiry, optionType
+
+	vols, err := smileVols(vol, x, k, t, r, q, o, SmileSpotBump, mode)
+	if err != nil {
+		return nan(), err
+	}
+
+	h := SmileSpotBump * x
+	dvdx := (vols[2] - vols[0]) / 2 / h
+
+	return BSDelta(vols[1], t, x, k, r, q, o) + BSVega(vols[1], t, x, k, r, q, o)*dvdx, nil
+}
+
+// SmileGamma returns the gamma of an option whose vol moves with the spot
+// as the source does under mode, ShadowGamma over SmileSpotBump. It
+// includes the vanna, volga and skew curvature terms that SmileDelta's
+// first order correction leaves out.
+func SmileGamma(
+	vol VolSource, spot, strike, timeToExpiry, r, q float64, optionType OptionType, mode ShiftMode,
+) (float64, error) {
+	return shadowGamma(vol, spot, strike, timeToExpiry, r, q, optionType, SmileSpotBump, mode)
+}
+
+// ShadowGammaBumpPct is the spot bump of ShadowGamma, in percent of the
+// spot, when spotBumpPct is 0
+const ShadowGammaBumpPct float64 = 1
+
+// ShadowGamma returns the shadow gamma of an option, the second central
+// difference of its price over spot moves of spotBumpPct percent, with
+// the vol at each bumped spot read again from the source under mode as in
+// ShiftedVolSource. A zero spotBumpPct takes ShadowGammaBumpPct. Under
+// StickyStrike it is the finite difference BSGamma; under StickyDelta it
+// carries the move of the vol of the strike along the smile, which on a
+// straight downward skew lowers the gamma at the money and on a convex
+// smile raises it. The bump must be between 0
+// and 100 percent, otherwise ErrSpotBump is returned.
+func ShadowGamma(
+	vol VolSource, spot, strike, timeToExpiry, r, q float64, optionType OptionType,
+	spotBumpPct float64, mode ShiftMode,
+) (float64, error) {
+
+	if spotBumpPct == 0 {
+		spotBumpPct = ShadowGammaBumpPct
+	}
+	if !(spotBumpPct > 0 && spotBumpPct < 100) {
+		return nan(), ErrSpotBump
+	}
+
+	return shadowGamma(vol, spot, strike, timeToExpiry, r, q, optionType, spotBumpPct/100, mode)
+}
+
+// shadowGamma is the second difference of the price over the relative
+// spot bump with the vols of smileVols
+func shadowGamma(vol VolSource, x, k, t, r, q float64, o OptionType, bump float64, mode ShiftMode) (float64, error) {
+
+	vols, err := smileVols(vol, x, k, t, r, q, o, bump, mode)
+	if err != nil {
+		return nan(), err
+	}
+
+	h := bump * x
+	pd := BSPrice(vols[0], t, x-h, k, r, q, o)
+	pm := BSPrice(vols[1], t, x, k, r, q, o)
+	pu := BSPrice(vols[2], t, x+h, k, r, q, o)
+
+	return (pu - 2*pm + pd) / h / h, nil
+}
+
+// smileVols returns the vols of the option at spot and at the spot moved
+// down and up by the relative bump under mode
+func smileVols(
+	vol VolSource, x, k, t, r, q float64, o OptionType, bump float64, mode ShiftMode,
+) ([3]float64, error) {
+
+	var vols [3]float64
+
+	if vol == nil {
+		return vols, ErrNilPtrArg
+	}
+	if err := checkParams(t, x, k, r, q, o); err != nil {
+		return vols, err
+	}
+	if !(x > 0) {
+		return vols, ErrNegPrice
+	}
+
+	for i, s := range []float64{x * (1 - bump), x, x * (1 + bump)} {
+		src, err := ShiftedVolSource(vol, x, s, mode)
+		if err != nil {
+			return vols, err
+		}
+		if vols[i], err = src.Vol(k, t); err != nil {
+			return vols, err
+		}
+		if vols[i] < 0 {
+			return vols, ErrNegVol
+		}
+	}
+
+	return vols, nil
+}
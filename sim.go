@@ -1,45 +1,228 @@
 package blackscholes
 
 import (
+	"fmt"
+	"math"
 	"math/rand"
-	"sync"
-	"time"
 )
 
+// SimConfig holds the settings of the payoff simulations. Seed seeds the
+// draws within the strata, so that estimates are repeatable, and the
+// Quantile of Normal, StdNormal if nil, maps them to normal draws; the
+// Normal of the PriceParams of PriceSim is not used.
+// PriceSim, BSPriceSim and PayoffSim use the zero SimConfig.
+type SimConfig struct {
+	Seed   int64
+	Normal Normal
+
+	// OnBatch, if not nil, is called after every stratum with the number
+	// of antithetic pairs drawn so far and the estimate and standard error
+	// over them. The last call is the returned estimate. It only observes
+	// the simulation, and it runs inside the path loop, so an expensive
+	// callback slows every simulation.
+	OnBatch func(paths int, value, stdErr float64)
+}
+
+// SimEstimate is a Monte Carlo estimate and its standard error. The
+// standard error is that of the mean of the antithetic pairs as if they
+// were drawn independently, which overstates it for stratified draws, and
+// is NaN for a single pair.
+type SimEstimate struct {
+	Value  float64
+	StdErr float64
+}
+
+// SimOverflowError is returned by PriceSim and PayoffSim when the sum of
+// the simulated payoffs stops being finite, identifying the inputs and
+// the stratum at which it did. Strike is 0 for PayoffSim.
+type SimOverflowError struct {
+	Vol          float64
+	TimeToExpiry float64
+	Underlying   float64
+	Strike       float64
+	Path         int
+}
+
+func (e *SimOverflowError) Error() string {
+	return fmt.Sprintf(
+		"Simulated payoffs overflow at path %d - vol, time to expiry, underlying, strike: %v, %v, %v, %v",
+		e.Path, e.Vol, e.TimeToExpiry, e.Underlying, e.Strike,
+	)
+}
+
+// PriceSim returns the Monte Carlo estimate of the option price
+// using n stratified antithetic pairs of terminal prices, or a
+// SimOverflowError if the payoffs overflow
+func PriceSim(pars *PriceParams, n uint) (price float64, err error) {
+	e, err := SimConfig{}.PriceSim(pars, n)
+	return e.Value, err
+}
+
+// PriceSim is PriceSim drawn under c, with the standard error of the
+// estimate
+func (c SimConfig) PriceSim(pars *PriceParams, n uint) (SimEstimate, error) {
+
+	if pars == nil {
+		return nanEstimate(), ErrNilPtrArg
+	}
+
+	v, t, x, k, r, q := GetFloatPriceParams(pars)
+
+	if err := checkParams(t, x, k, r, q, pars.Type); err != nil {
+		return nanEstimate(), err
+	}
+
+	if n == 0 {
+		return nanEstimate(), ErrZeroPaths
+	}
+
+	return c.priceSim(v, t, x, k, r, q, pars.Type, n)
+}
+
+// BSPriceSim is PriceSim without the parameter checks, returning NaN
+// where PriceSim returns an error
 func BSPriceSim(v, t, x, k, r, q float64, o OptionType, n uint) float64 {
 
 	if !ValidOptionType(o) || n == 0 {
 		return nan()
 	}
 
-	rand.Seed(time.Now().UnixNano())
-
-	mu, wg := new(sync.Mutex), new(sync.WaitGroup)
-	sum, x0 := 0.0, exp(-q*t)*x
-	m, s := x0*exp((r-0.5*v*v)*t), v*sqrt(t)
-
-	wg.Add(int(n - 1))
-	for i := 1; i < int(n); i++ {
-		go func(i int) {
-			mu.Lock()
-			u := (float64(i) - 0.5 + rand.Float64()) / float64(n)
-			e := exp(s * NormCDFInverse(u))
-			x = m * e
-			sum += Intrinsic(0, x, k, 0, 0, o)
-			x = m / e
-			sum += Intrinsic(0, x, k, 0, 0, o)
-			mu.Unlock()
-			wg.Done()
-		}(i)
-	}
-	wg.Wait()
-
-	u := 0.5 * rand.Float64() / float64(n)
-	e := exp(s * NormCDFInverse(u))
-	x = m * e
-	sum += Intrinsic(0, x, k, 0, 0, o)
-	x = m / e
-	sum += Intrinsic(0, x, k, 0, 0, o)
-
-	return exp(-r*t) * sum / float64(2*n)
+	e, err := SimConfig{}.priceSim(v, t, x, k, r, q, o, n)
+	if err != nil {
+		return nan()
+	}
+	return e.Value
+}
+
+// priceSim sums the payoffs of the stratified antithetic pairs with
+// compensated summation, so that the rounding error of the sum does not
+// grow with n, and accumulates the variance of the pair means by
+// Welford's algorithm. Each of the n equal strata of (0, 1) is sampled
+// once, and its antithetic draw lands in the mirror stratum, so the 2n
+// payoffs weigh every stratum equally.
+func (c SimConfig) priceSim(v, t, x, k, r, q float64, o OptionType, n uint) (SimEstimate, error) {
+
+	m := discounted(x, q, t) * exp((r-0.5*v*v)*t)
+
+	return c.simulate(v, t, r, n, func(e float64) (float64, float64) {
+		return Intrinsic(0, m*e, k, 0, 0, o), Intrinsic(0, m/e, k, 0, 0, o)
+	}, &SimOverflowError{Vol: v, TimeToExpiry: t, Underlying: x, Strike: k})
+}
+
+// PayoffSim returns the Monte Carlo estimate of the discounted expected
+// payoff of the underlying at expiry, using n stratified antithetic pairs
+// of terminal prices as BSPriceSim, or a SimOverflowError if the payoffs
+// overflow
+func PayoffSim(v, t, x, r, q float64, payoff func(float64) float64, n uint) (float64, error) {
+	e, err := SimConfig{}.PayoffSim(v, t, x, r, q, payoff, n)
+	return e.Value, err
+}
+
+// PayoffSim is PayoffSim drawn under c, with the standard error of the
+// estimate
+func (c SimConfig) PayoffSim(v, t, x, r, q float64, payoff func(float64) float64, n uint) (SimEstimate, error) {
+
+	if payoff == nil {
+		return nanEstimate(), ErrNilPtrArg
+	}
+	if v < 0 {
+		return nanEstimate(), ErrNegVol
+	}
+	if err := checkParams(t, x, 0, r, q, Call); err != nil {
+		return nanEstimate(), err
+	}
+	if n == 0 {
+		return nanEstimate(), ErrZeroPaths
+	}
+
+	m := Forward(x, r, q, t) * exp(-0.5*v*v*t)
+
+	return c.simulate(v, t, r, n, func(e float64) (float64, float64) {
+		return payoff(m * e), payoff(m / e)
+	}, &SimOverflowError{Vol: v, TimeToExpiry: t, Underlying: x})
+}
+
+// simulate returns the discounted mean of the payoffs of n stratified
+// antithetic pairs of lognormal factors exp(+-v*sqrt(t)*z), or overflow
+// with its Path set if their sum stops being finite
+func (c SimConfig) simulate(
+	v, t, r float64, n uint, pair func(e float64) (float64, float64), overflow *SimOverflowError,
+) (SimEstimate, error) {
+
+	rng := rand.New(rand.NewSource(c.Seed))
+	s := v * sqrt(t)
+	df := DiscountFactor(r, t)
+
+	var (
+		sum      compensatedSum
+		mean, m2 float64
+	)
+	for i := 0; i < int(n); i++ {
+		a, b := pair(exp(s * normQuantile(c.Normal, stratifiedUniform(rng, i, int(n)))))
+		sum.add(a)
+		sum.add(b)
+		if !sum.finite() {
+			overflow.Path = i
+			return nanEstimate(), overflow
+		}
+		y := (a + b) / 2
+		d := y - mean
+		mean += d / float64(i+1)
+		m2 += d * (y - mean)
+		if c.OnBatch != nil {
+			e := estimate(df, sum.value(), m2, i+1)
+			c.OnBatch(i+1, e.Value, e.StdErr)
+		}
+	}
+
+	return estimate(df, sum.value(), m2, int(n)), nil
+}
+
+// estimate is the discounted mean of the payoffs of n pairs summing to sum,
+// with the standard error of the pair means of squared deviations m2
+func estimate(df, sum, m2 float64, n int) SimEstimate {
+	return SimEstimate{
+		Value:  df * sum / float64(2*n),
+		StdErr: df * sqrt(m2/float64(n-1)/float64(n)),
+	}
+}
+
+func nanEstimate() SimEstimate {
+	return SimEstimate{Value: nan(), StdErr: nan()}
+}
+
+// stratifiedUniform returns a uniform draw of rng, seeded by SimConfig,
+// from the i-th of n equal strata of (0, 1), or the midpoint of the
+// stratum if the draw rounds to 0 or 1, where the quantile is infinite
+func stratifiedUniform(rng *rand.Rand, i, n int) float64 {
+	u := (float64(i) + rng.Float64()) / float64(n)
+	if u <= 0 || u >= 1 {
+		u = (float64(i) + 0.5) / float64(n)
+	}
+	return u
+}
+
+// compensatedSum accumulates a sum with Neumaier's compensated summation,
+// carrying the rounding error of each addition in c
+type compensatedSum struct {
+	sum, c float64
+}
+
+func (s *compensatedSum) add(x float64) {
+	t := s.sum + x
+	if abs(s.sum) >= abs(x) {
+		s.c += (s.sum - t) + x
+	} else {
+		s.c += (x - t) + s.sum
+	}
+	s.sum = t
+}
+
+// finite reports whether the sum and its correction are finite
+func (s *compensatedSum) finite() bool {
+	return !math.IsInf(s.sum, 0) && !math.IsNaN(s.sum) && !math.IsInf(s.c, 0) && !math.IsNaN(s.c)
+}
+
+func (s *compensatedSum) value() float64 {
+	return s.sum + s.c
 }
@@ -0,0 +1,123 @@
+package blackscholes
+
+// D1D2Slice fills d1[i] and d2[i] for volatility v[i] and strike k[i],
+// computing log(x), sqrt(t) and the drift once for the whole slice.
+// Rows with a negative strike are set to NaN and reported in a MultiError.
+func D1D2Slice(v []float64, t, x float64, k []float64, r, q float64, d1, d2 []float64) error {
+
+	n := len(k)
+	if len(v) != n || len(d1) != n || len(d2) != n {
+		return ErrLengthMismatch
+	}
+
+	switch {
+	case t < 0:
+		return ErrNegTimeToExp
+	case x < 0:
+		return ErrNegPrice
+	}
+
+	var errs MultiError
+	logx, sqrtt, drift := log(x), sqrt(t), (r-q)*t
+
+	for i := 0; i < n; i++ {
+		if k[i] < 0 {
+			d1[i], d2[i] = nan(), nan()
+			errs = append(errs, IndexError{Index: i, Err: ErrNegStrike})
+			continue
+		}
+		vsqrtt := v[i] * sqrtt
+		d1[i] = (logx - log(k[i]) + drift + 0.5*v[i]*v[i]*t) / vsqrtt
+		d2[i] = d1[i] - vsqrtt
+	}
+
+	if errs != nil {
+		return errs
+	}
+	return nil
+}
+
+// NormCDFSlice fills nd[i] with NormCDF(d[i])
+func NormCDFSlice(nd, d []float64) error {
+	return Batch{}.NormCDFSlice(nd, d)
+}
+
+// NormCDFSlice is NormCDFSlice through the Normal of b, in order on the
+// calling goroutine
+func (b Batch) NormCDFSlice(nd, d []float64) error {
+
+	if len(nd) != len(d) {
+		return ErrLengthMismatch
+	}
+
+	for i := range d {
+		nd[i] = normCDF(b.Normal, d[i])
+	}
+
+	return nil
+}
+
+// PriceChain writes into dst[i] the price of the option with volatility
+// v[i] and strike k[i] on top of D1D2Slice. Rows on the zero vol, zero
+// strike or expiry boundaries, or below NearExpiryTotalVol, are priced as
+// by BSPrice; invalid rows are set to NaN and reported in a MultiError. The
+// rows are priced in order once d1 and d2 are filled in.
+func PriceChain(dst, v []float64, t, x float64, k []float64, r, q float64, o OptionType) error {
+	return Batch{}.PriceChain(dst, v, t, x, k, r, q, o)
+}
+
+// PriceChain is PriceChain with the rows priced over the goroutines of b
+// and through its Normal
+func (b Batch) PriceChain(dst, v []float64, t, x float64, k []float64, r, q float64, o OptionType) error {
+
+	n := len(k)
+	if len(v) != n || len(dst) != n {
+		return ErrLengthMismatch
+	}
+
+	if err := checkParams(t, 0, 0, r, q, o); err != nil {
+		return err
+	}
+
+	d1, d2 := make([]float64, n), make([]float64, n)
+	err := D1D2Slice(v, t, x, k, r, q, d1, d2)
+	if _, ok := err.(MultiError); err != nil && !ok {
+		return err
+	}
+
+	// puts take N(-d1) and N(-d2), see priceKernel
+	if o == Put {
+		for i := range d1 {
+			d1[i], d2[i] = -d1[i], -d2[i]
+		}
+	}
+	b.NormCDFSlice(d1, d1)
+	b.NormCDFSlice(d2, d2)
+
+	xq, dfr := discounted(x, q, t), DiscountFactor(r, t)
+	boundary := x == 0 || t < TimeFloor
+
+	return b.Run(n, func(i int) error {
+
+		switch {
+		case k[i] < 0:
+			dst[i] = nan()
+			return ErrNegStrike
+		case boundary, v[i] <= 0, k[i] == 0, nearExpiry(v[i], t):
+			dst[i] = priceKernel(model{n: b.Normal}, v[i], t, x, k[i], r, q, o)
+			return nil
+		}
+
+		Nd1, Nd2, kr := d1[i], d2[i], dfr*k[i]
+
+		switch o {
+		case Call:
+			dst[i] = Nd1*xq - Nd2*kr
+		case Put:
+			dst[i] = Nd2*kr - Nd1*xq
+		default:
+			dst[i] = (2*Nd1-1)*xq - (2*Nd2-1)*kr
+		}
+		return nil
+	})
+}
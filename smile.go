@@ -0,0 +1,242 @@
+package blackscholes
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+var ErrSmile = errors.New("Invalid smile points")
+
+// SmilePoint is one quote of a single expiry smile: an implied vol at a
+// strike, or, when Vol is 0, a premium of the given Type from which the
+// vol is implied. Premia are forward (undiscounted) prices.
+type SmilePoint struct {
+	Strike  float64
+	Vol     float64
+	Premium float64
+	Type    OptionType
+}
+
+// smileVariances returns the log-moneyness log(k/f) and total variance
+// v*v*t of each point, implying vols from premia where needed
+func smileVariances(points []SmilePoint, forward, t float64) (ks, ws []float64, err error) {
+
+	switch {
+	case !(forward > 0):
+		return nil, nil, ErrNegPrice
+	case !(t > 0):
+		return nil, nil, ErrNegTimeToExp
+	}
+
+	ks, ws = make([]float64, len(points)), make([]float64, len(points))
+
+	for i, p := range points {
+
+		if !(p.Strike > 0) || p.Vol < 0 {
+			return nil, nil, ErrSmile
+		}
+
+		v := p.Vol
+		if v == 0 {
+			if v, err = ImpliedVol(&ImpliedVolParams{
+				Premium:      p.Premium,
+				TimeToExpiry: t,
+				Underlying:   forward,
+				Strike:       p.Strike,
+				Type:         p.Type,
+			}); err != nil {
+				return nil, nil, err
+			}
+			if !(v > 0) {
+				return nil, nil, ErrSmile
+			}
+		}
+
+		ks[i], ws[i] = log(p.Strike/forward), v*v*t
+	}
+
+	return ks, ws, nil
+}
+
+// InterpConfig sets up a SmileInterp: the interpolation method and the
+// forward and time to expiry of the smile
+type InterpConfig struct {
+	Method  InterpMethod
+	Forward float64
+	T       float64
+}
+
+// SmileInterp interpolates the implied vols of one expiry in
+// (log-moneyness, total variance), holding the end vols flat beyond the
+// quoted wings
+type SmileInterp struct {
+	method  InterpMethod
+	forward float64
+	t       float64
+	ks, ws  []float64
+	ds      []float64 // node slopes dw/dk of the cubic methods
+}
+
+// NewSmileInterp builds a SmileInterp from at least one point, at
+// strictly increasing strikes
+func NewSmileInterp(points []SmilePoint, cfg InterpConfig) (*SmileInterp, error) {
+
+	if len(points) == 0 || cfg.Method > InterpMonotone {
+		return nil, ErrSmile
+	}
+
+	ks, ws, err := smileVariances(points, cfg.Forward, cfg.T)
+	if err != nil {
+		return nil, err
+	}
+	for i := 1; i < len(ks); i++ {
+		if !(ks[i] > ks[i-1]) {
+			return nil, ErrSmile
+		}
+	}
+
+	s := &SmileInterp{method: cfg.Method, forward: cfg.Forward, t: cfg.T, ks: ks, ws: ws}
+
+	switch cfg.Method {
+	case InterpNaturalSpline, InterpClampedSpline:
+		s.ds = splineSlopes(ks, ws, cfg.Method == InterpClampedSpline)
+	case InterpMonotone:
+		s.ds = steffenSlopes(ks, ws)
+	}
+
+	return s, nil
+}
+
+// Vol returns the interpolated vol at strike
+func (s *SmileInterp) Vol(strike float64) float64 {
+	return sqrt(s.variance(log(strike/s.forward)) / s.t)
+}
+
+// inRange reports whether log-moneyness k lies within the quoted wings
+func (s *SmileInterp) inRange(k float64) bool {
+	return k >= s.ks[0] && k <= s.ks[len(s.ks)-1]
+}
+
+// variance returns the total variance at log-moneyness k
+func (s *SmileInterp) variance(k float64) float64 {
+
+	ks, ws := s.ks, s.ws
+	n := len(ks)
+
+	j := sort.SearchFloat64s(ks, k)
+	switch {
+	case j < n && ks[j] == k:
+		return ws[j]
+	case j == 0:
+		return ws[0]
+	case j == n:
+		return ws[n-1]
+	}
+
+	h := ks[j] - ks[j-1]
+	a := (k - ks[j-1]) / h
+	w0, w1 := ws[j-1], ws[j]
+
+	switch s.method {
+	case InterpLinear:
+		return w0 + a*(w1-w0)
+	case InterpLinearVol:
+		v0, v1 := sqrt(w0), sqrt(w1)
+		v := v0 + a*(v1-v0)
+		return v * v
+	}
+
+	// cubic Hermite
+	d0, d1 := s.ds[j-1]*h, s.ds[j]*h
+	a2, a3 := a*a, a*a*a
+	return (2*a3-3*a2+1)*w0 + (a3-2*a2+a)*d0 + (-2*a3+3*a2)*w1 + (a3-a2)*d1
+}
+
+// splineSlopes returns the node slopes of the C2 cubic spline through
+// (xs, ys) with natural (zero second derivative) or clamped (zero slope)
+// ends, solving the tridiagonal system by the Thomas algorithm
+func splineSlopes(xs, ys []float64, clamped bool) []float64 {
+
+	n := len(xs)
+	ds := make([]float64, n)
+	if n < 2 {
+		return ds
+	}
+
+	sub, diag, sup, rhs := make([]float64, n), make([]float64, n), make([]float64, n), make([]float64, n)
+	h := func(i int) float64 { return xs[i+1] - xs[i] }
+	sl := func(i int) float64 { return (ys[i+1] - ys[i]) / h(i) }
+
+	if clamped {
+		diag[0], diag[n-1] = 1, 1
+	} else {
+		diag[0], sup[0], rhs[0] = 2, 1, 3*sl(0)
+		sub[n-1], diag[n-1], rhs[n-1] = 1, 2, 3*sl(n-2)
+	}
+	for i := 1; i < n-1; i++ {
+		sub[i], diag[i], sup[i] = h(i), 2*(h(i-1)+h(i)), h(i-1)
+		rhs[i] = 3 * (h(i)*sl(i-1) + h(i-1)*sl(i))
+	}
+
+	for i := 1; i < n; i++ {
+		m := sub[i] / diag[i-1]
+		diag[i] -= m * sup[i-1]
+		rhs[i] -= m * rhs[i-1]
+	}
+	ds[n-1] = rhs[n-1] / diag[n-1]
+	for i := n - 2; i >= 0; i-- {
+		ds[i] = (rhs[i] - sup[i]*ds[i+1]) / diag[i]
+	}
+
+	return ds
+}
+
+// steffenSlopes returns Steffen's (1990) node slopes, which keep a cubic
+// Hermite interpolant monotone between monotone nodes
+func steffenSlopes(xs, ys []float64) []float64 {
+
+	n := len(xs)
+	ds := make([]float64, n)
+	if n < 2 {
+		return ds
+	}
+
+	h := func(i int) float64 { return xs[i+1] - xs[i] }
+	sl := func(i int) float64 { return (ys[i+1] - ys[i]) / h(i) }
+	sign := func(x float64) float64 {
+		switch {
+		case x > 0:
+			return 1
+		case x < 0:
+			return -1
+		}
+		return 0
+	}
+
+	if n == 2 {
+		ds[0], ds[1] = sl(0), sl(0)
+		return ds
+	}
+
+	for i := 1; i < n-1; i++ {
+		s0, s1 := sl(i-1), sl(i)
+		p := (s0*h(i) + s1*h(i-1)) / (h(i-1) + h(i))
+		ds[i] = (sign(s0) + sign(s1)) * min(min(abs(s0), abs(s1)), 0.5*abs(p))
+	}
+
+	end := func(s0, s1, h0, h1 float64) float64 {
+		p := s0*(1+h0/(h0+h1)) - s1*h0/(h0+h1)
+		switch {
+		case p*s0 <= 0:
+			return 0
+		case abs(p) > 2*abs(s0):
+			return 2 * s0
+		}
+		return p
+	}
+	ds[0] = end(sl(0), sl(1), h(0), h(1))
+	ds[n-1] = end(sl(n-2), sl(n-3), h(n-2), h(n-3))
+
+	return ds
+}
@@ -0,0 +1,336 @@
+package blackscholes
+
+import (
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrSolverConfig = errors.New("Invalid solver config")
+	ErrVolBracket   = errors.New("Premium outside the prices of the vol bounds")
+)
+
+// SolverMethod is the root finder of a Solver
+type SolverMethod uint8
+
+const (
+	// SolverBisection is the bisection of ImpliedVol, with the same
+	// results
+	SolverBisection SolverMethod = iota
+	// SolverNewton takes Newton steps in the vol, falling back to
+	// bisection when a step leaves the bracket, and stops when the step
+	// or the bracket is below the tolerance
+	SolverNewton
+)
+
+func (m SolverMethod) String() string {
+	switch m {
+	case SolverBisection:
+		return "bisection"
+	case SolverNewton:
+		return "newton"
+	}
+	return fmt.Sprintf("SolverMethod(%d)", uint8(m))
+}
+
+// BoundsPolicy is what a Solver does when the premium is outside the
+// prices at its vol bounds
+type BoundsPolicy uint8
+
+const (
+	// BoundsExpand moves the bounds out in steps of 0.47 as ImpliedVol
+	// does, up to MaxIt times each
+	BoundsExpand BoundsPolicy = iota
+	// BoundsFixed returns ErrVolBracket
+	BoundsFixed
+)
+
+func (b BoundsPolicy) String() string {
+	switch b {
+	case BoundsExpand:
+		return "expand"
+	case BoundsFixed:
+		return "fixed"
+	}
+	return fmt.Sprintf("BoundsPolicy(%d)", uint8(b))
+}
+
+// SolverConfig sets up a Solver. Zero LB, UB, Tol and MaxIt take the
+// defaults of ImpliedVol, and the other values are adjusted as by
+// CheckVolSearchParams.
+type SolverConfig struct {
+	Method SolverMethod
+	Bounds BoundsPolicy
+	LB     float64
+	UB     float64
+	Tol    float64
+	MaxIt  int
+}
+
+// Solver inverts Black Scholes prices for implied vols with a fixed
+// configuration, keeping the vol independent terms of each solve in the
+// Solver rather than allocating them, so that a successful solve does
+// not allocate. Errors are the package sentinels rather than the
+// formatted errors of ImpliedVol. A Solver is not safe for concurrent
+// use: use one per goroutine, or a SolverPool.
+type Solver struct {
+	cfg SolverConfig
+	vp  volPricer
+}
+
+// NewSolver returns a Solver with the given configuration, or
+// ErrSolverConfig for an unknown method or bounds policy
+func NewSolver(cfg SolverConfig) (*Solver, error) {
+
+	if cfg.Method > SolverNewton || cfg.Bounds > BoundsFixed {
+		return nil, ErrSolverConfig
+	}
+
+	if cfg.LB == 0 {
+		cfg.LB = lbDefault
+	}
+	if cfg.UB == 0 {
+		cfg.UB = ubDefault
+	}
+	CheckVolSearchParams(&cfg.LB, &cfg.UB, &cfg.Tol, &cfg.MaxIt)
+
+	return &Solver{cfg: cfg}, nil
+}
+
+// Config returns the configuration of the Solver with its defaults
+// filled in
+func (s *Solver) Config() SolverConfig {
+	return s.cfg
+}
+
+// ImpliedVol returns the implied vol of premium, which with
+// SolverBisection is exactly that of ImpliedVol with the same bounds,
+// tolerance and iterations. Premia at or above PriceUpperBound return
+// ErrArbitrage as in ImpliedVol. Premia outside the vol bounds under
+// BoundsFixed, or beyond MaxIt steps of them under BoundsExpand, return
+// ErrVolBracket, and a search that does not converge within MaxIt
+// iterations ErrNoncovergence.
+func (s *Solver) ImpliedVol(premium, t, spot, strike, r, q float64, o OptionType) (float64, error) {
+
+	p, x, k := premium, spot, strike
+	if err := checkParams(t, x, k, r, q, o); err != nil {
+		return nan(), err
+	}
+
+	if t < TimeFloor || x == 0 || k == 0 {
+		return 0, nil
+	}
+
+	s.vp = newVolPricer(nil, t, x, k, r, q, o)
+	vp := &s.vp
+
+	if abs(p-vp.intr) <= math.SmallestNonzeroFloat64 {
+		return 0, nil
+	}
+	if p >= upperBound(t, x, k, r, q, o) {
+		return nan(), ErrArbitrage
+	}
+	if v, ok := wingImpliedVol(p, t, x, k, r, q, o); ok {
+		return v, nil
+	}
+
+	return s.solve(p, s.cfg.LB)
+}
+
+// solve finds the vol pricing at p with the volPricer of the Solver,
+// bracketed by lb and the larger of lb and the configured upper bound,
+// widened as the bounds policy allows
+func (s *Solver) solve(p, lb float64) (float64, error) {
+
+	vp := &s.vp
+	ub, tol, maxit := max(s.cfg.UB, lb), s.cfg.Tol, s.cfg.MaxIt
+	expand := s.cfg.Bounds == BoundsExpand
+
+	var it int
+	plo := vp.price(lb)
+	for ; expand && plo > p && it < maxit; it++ {
+		lb -= 0.47
+		plo = vp.price(lb)
+	}
+	phi := vp.price(ub)
+	for it = 0; expand && phi < p && it < maxit; it++ {
+		ub += 0.47
+		phi = vp.price(ub)
+	}
+	if !(plo <= p && p <= phi) {
+		return nan(), ErrVolBracket
+	}
+
+	if s.cfg.Method == SolverNewton {
+		return vp.newton(p, lb, ub, tol, maxit)
+	}
+
+	var vol, pmid float64
+	for it = 0; it < maxit; it++ {
+
+		vol = 0.5 * (lb + ub)
+		pmid = vp.price(vol)
+
+		switch {
+		case ub-lb < tol, pmid == p:
+			CorrectVolSign(pmid-vp.intr, &vol)
+			return vol, nil
+		case p < pmid:
+			ub = vol
+		case pmid < p:
+			lb = vol
+		}
+	}
+
+	return nan(), ErrNoncovergence
+}
+
+// ImpliedVolInterval returns the vols of the bid and the ask of a quote,
+// the range of vols consistent with the market. A side at or below the
+// intrinsic value has vol 0 and a side at or above the no arbitrage upper
+// bound, the discounted underlying for a call or strike for a put, +Inf,
+// as in ImpliedVolQuote. The ask is solved from the bid vol up, since the
+// price rises with the vol, and its vol is never below the bid vol. A
+// crossed quote returns *CrossedQuoteError, and failed solves
+// ErrVolBracket or ErrNoncovergence.
+func (s *Solver) ImpliedVolInterval(
+	bid, ask, t, spot, strike, r, q float64, o OptionType,
+) (volLow, volHigh float64, err error) {
+
+	x, k := spot, strike
+
+	if bid > ask {
+		return nan(), nan(), &CrossedQuoteError{Bid: bid, Ask: ask}
+	}
+	if err = checkParams(t, x, k, r, q, o); err != nil {
+		return nan(), nan(), err
+	}
+
+	lo, hi := Intrinsic(t, x, k, r, q, o), upperBound(t, x, k, r, q, o)
+	interior := t >= TimeFloor && x > 0 && k > 0
+	if interior {
+		s.vp = newVolPricer(nil, t, x, k, r, q, o)
+	}
+
+	vol := func(p, lb float64) (float64, error) {
+		switch {
+		case p <= lo, !interior:
+			return 0, nil
+		case p >= hi:
+			return inf(1), nil
+		}
+		return s.solve(p, lb)
+	}
+
+	if volLow, err = vol(bid, s.cfg.LB); err != nil {
+		return nan(), nan(), err
+	}
+	// the bid vol is within Tol of the vol of the bid, which is below
+	// that of the ask
+	lb := s.cfg.LB
+	if volLow > s.cfg.Tol {
+		lb = volLow - s.cfg.Tol
+	}
+	if volHigh, err = vol(ask, lb); err != nil {
+		return nan(), nan(), err
+	}
+
+	return volLow, max(volLow, volHigh), nil
+}
+
+// newton solves for the vol pricing at p from the midpoint of the bracket
+// [lb, ub], bisecting whenever a Newton step leaves it
+func (vp *volPricer) newton(p, lb, ub, tol float64, maxit int) (float64, error) {
+
+	vol := 0.5 * (lb + ub)
+
+	for it := 0; it < maxit; it++ {
+
+		pv := vp.price(vol)
+		switch {
+		case pv == p:
+			CorrectVolSign(pv-vp.intr, &vol)
+			return vol, nil
+		case p < pv:
+			ub = vol
+		default:
+			lb = vol
+		}
+
+		next := vol - (pv-p)/vp.vega(vol)
+		if !(next > lb && next < ub) {
+			next = 0.5 * (lb + ub)
+		}
+
+		if abs(next-vol) < tol || ub-lb < tol {
+			CorrectVolSign(vp.price(next)-vp.intr, &next)
+			return next, nil
+		}
+		vol = next
+	}
+
+	return nan(), ErrNoncovergence
+}
+
+// vega returns the derivative of price in v, which is even in v
+func (vp *volPricer) vega(v float64) float64 {
+
+	v = abs(v)
+	if v == 0 {
+		return 0
+	}
+
+	d1 := (vp.lnxk + (vp.drift+0.5*v*v)*vp.t) / v / vp.sqrtt
+	vega := vp.xq * normPDF(vp.n, d1) * vp.sqrtt
+
+	if vp.o == Straddle {
+		return 2 * vega
+	}
+	return vega
+}
+
+// SolverPool hands out Solvers sharing one configuration through a
+// sync.Pool, for solving from many goroutines. It is safe for concurrent
+// use.
+type SolverPool struct {
+	cfg  SolverConfig
+	pool sync.Pool
+}
+
+// NewSolverPool returns a pool of Solvers with the given configuration,
+// or ErrSolverConfig as in NewSolver
+func NewSolverPool(cfg SolverConfig) (*SolverPool, error) {
+
+	s, err := NewSolver(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	sp := &SolverPool{cfg: s.cfg}
+	sp.pool.New = func() interface{} { return &Solver{cfg: sp.cfg} }
+	sp.pool.Put(s)
+
+	return sp, nil
+}
+
+// Get returns a Solver for the exclusive use of the caller until it is
+// handed back with Put
+func (sp *SolverPool) Get() *Solver {
+	return sp.pool.Get().(*Solver)
+}
+
+// Put returns a Solver taken with Get to the pool
+func (sp *SolverPool) Put(s *Solver) {
+	sp.pool.Put(s)
+}
+
+// ImpliedVol solves with a Solver from the pool, see Solver.ImpliedVol
+func (sp *SolverPool) ImpliedVol(premium, t, spot, strike, r, q float64, o OptionType) (float64, error) {
+	s := sp.Get()
+	vol, err := s.ImpliedVol(premium, t, spot, strike, r, q, o)
+	sp.Put(s)
+	return vol, err
+}
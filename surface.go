@@ -0,0 +1,258 @@
+package blackscholes
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrSurface       = errors.New("Invalid volatility surface")
+	ErrExtrapolation = errors.New("Outside the volatility surface")
+)
+
+// VolSource is a source of implied vols by strike and time to expiry,
+// such as a FlatVol or a *VolSurface
+type VolSource interface {
+	Vol(strike, timeToExpiry float64) (float64, error)
+}
+
+// FlatVol is a VolSource with the same vol at every strike and expiry
+type FlatVol float64
+
+// Vol returns the flat vol, or ErrNegVol if it is negative
+func (v FlatVol) Vol(strike, timeToExpiry float64) (float64, error) {
+	if v < 0 {
+		return nan(), ErrNegVol
+	}
+	return float64(v), nil
+}
+
+// InterpMethod selects how vols are interpolated across strikes within a
+// smile, in log-moneyness log(strike/forward)
+type InterpMethod uint8
+
+const (
+	// InterpLinear is linear in total variance
+	InterpLinear InterpMethod = iota
+	// InterpLinearVol is linear in vol
+	InterpLinearVol
+	// InterpNaturalSpline is the natural cubic spline of total variance
+	InterpNaturalSpline
+	// InterpClampedSpline is the cubic spline of total variance with zero
+	// slope at the wings, matching the flat extrapolation
+	InterpClampedSpline
+	// InterpMonotone is Steffen's monotone cubic interpolation of total
+	// variance, which does not overshoot the quotes
+	InterpMonotone
+)
+
+// Extrapolation is the policy of a VolSurface beyond its quoted range
+type Extrapolation uint8
+
+const (
+	// ExtrapFlat holds the vol at the end of the range
+	ExtrapFlat Extrapolation = iota
+	// ExtrapError returns ErrExtrapolation
+	ExtrapError
+)
+
+// Smile is the positive vols of one expiry T, indexed either by
+// increasing Strikes or by Deltas. Deltas are under Convention, spot
+// deltas by default, with negative values taken as put deltas, and are
+// converted to strikes with their own vols by StrikeFromDelta. Either way
+// the strikes must end up strictly increasing.
+type Smile struct {
+	T          float64
+	Strikes    []float64
+	Deltas     []float64
+	Vols       []float64
+	Convention DeltaConvention
+}
+
+// SurfaceConfig holds the interpolation and extrapolation settings of a
+// VolSurface and the log-moneyness grid CheckSurfaceArbitrage checks it
+// on, -1 to 1 in steps of 0.05 if nil
+type SurfaceConfig struct {
+	StrikeInterp  InterpMethod
+	StrikeExtrap  Extrapolation
+	TimeExtrap    Extrapolation
+	MoneynessGrid []float64
+}
+
+// VolSurface is an implied vol surface built from smiles at increasing
+// expiries. Each smile is a SmileInterp in log-moneyness against the
+// forward spot*exp((r-q)*t) of its expiry. Between expiries total variance is
+// interpolated linearly in time at fixed moneyness.
+type VolSurface struct {
+	spot, r, q float64
+	cfg        SurfaceConfig
+	ts         []float64
+	smiles     []*SmileInterp
+}
+
+// NewVolSurface builds a VolSurface from smiles sorted by strictly
+// increasing positive expiry, returning ErrSurface for unsorted or
+// mismatched inputs
+func NewVolSurface(spot, r, q float64, smiles []Smile, cfg SurfaceConfig) (*VolSurface, error) {
+
+	if !(spot > 0) {
+		return nil, ErrNegPrice
+	}
+	if len(smiles) == 0 || cfg.StrikeInterp > InterpMonotone ||
+		cfg.StrikeExtrap > ExtrapError || cfg.TimeExtrap > ExtrapError {
+		return nil, ErrSurface
+	}
+
+	s := &VolSurface{spot: spot, r: r, q: q, cfg: cfg}
+
+	for i, sm := range smiles {
+
+		if !(sm.T > 0) || i > 0 && !(sm.T > smiles[i-1].T) || len(sm.Vols) == 0 {
+			return nil, ErrSurface
+		}
+		if err := checkExponents(sm.T, spot, 0, r, q); err != nil {
+			return nil, err
+		}
+
+		strikes := sm.Strikes
+		if strikes == nil {
+			var err error
+			if strikes, err = deltaStrikes(sm, spot, r, q); err != nil {
+				return nil, err
+			}
+		}
+		if len(strikes) != len(sm.Vols) {
+			return nil, ErrSurface
+		}
+
+		points := make([]SmilePoint, len(strikes))
+		for j, k := range strikes {
+			if !(k > 0) || j > 0 && !(k > strikes[j-1]) || !(sm.Vols[j] > 0) {
+				return nil, ErrSurface
+			}
+			points[j] = SmilePoint{Strike: k, Vol: sm.Vols[j]}
+		}
+
+		si, err := NewSmileInterp(points, InterpConfig{
+			Method: cfg.StrikeInterp, Forward: s.forward(sm.T), T: sm.T,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		s.ts = append(s.ts, sm.T)
+		s.smiles = append(s.smiles, si)
+	}
+
+	return s, nil
+}
+
+// deltaStrikes converts the deltas of a smile to strikes
+func deltaStrikes(sm Smile, spot, r, q float64) ([]float64, error) {
+
+	if len(sm.Deltas) != len(sm.Vols) {
+		return nil, ErrSurface
+	}
+
+	strikes := make([]float64, len(sm.Deltas))
+
+	for i, d := range sm.Deltas {
+		o := Call
+		if d < 0 {
+			o = Put
+		}
+		k, err := StrikeFromDelta(d, sm.Vols[i], sm.T, spot, r, q, o, sm.Convention)
+		switch err {
+		case nil:
+		case ErrDelta, ErrNegVol:
+			return nil, ErrSurface
+		default:
+			return nil, err
+		}
+		strikes[i] = k
+	}
+
+	return strikes, nil
+}
+
+func (s *VolSurface) forward(t float64) float64 {
+	return Forward(s.spot, s.r, s.q, t)
+}
+
+// Vol returns the implied vol at strike and time to expiry t. Strikes are
+// looked up at the same log-moneyness on the neighbouring expiries.
+func (s *VolSurface) Vol(strike, t float64) (float64, error) {
+
+	switch {
+	case !(strike > 0):
+		return nan(), ErrNegStrike
+	case !(t >= 0):
+		return nan(), ErrNegTimeToExp
+	}
+
+	k := log(strike / s.forward(t))
+	n := len(s.ts)
+
+	i := sort.SearchFloat64s(s.ts, t)
+	switch {
+	case i < n && s.ts[i] == t:
+		return s.smileVol(i, k)
+	case i == 0 || i == n:
+		if s.cfg.TimeExtrap == ExtrapError {
+			return nan(), ErrExtrapolation
+		}
+		if i == n {
+			i--
+		}
+		return s.smileVol(i, k)
+	}
+
+	v0, err := s.smileVol(i-1, k)
+	if err != nil {
+		return nan(), err
+	}
+	v1, err := s.smileVol(i, k)
+	if err != nil {
+		return nan(), err
+	}
+
+	t0, t1 := s.ts[i-1], s.ts[i]
+	w0, w1 := v0*v0*t0, v1*v1*t1
+	w := w0 + (w1-w0)*(t-t0)/(t1-t0)
+
+	return sqrt(w / t), nil
+}
+
+// smileVol interpolates the vol of smile i at log-moneyness k
+func (s *VolSurface) smileVol(i int, k float64) (float64, error) {
+
+	si := s.smiles[i]
+	if s.cfg.StrikeExtrap == ExtrapError && !si.inRange(k) {
+		return nan(), ErrExtrapolation
+	}
+
+	return sqrt(si.variance(k) / si.t), nil
+}
+
+// PriceFromSurface prices an option at the surface vol for strike and
+// expiry. The vol is looked up sticky strike, against the forwards of
+// the surface, so spot may differ from the spot the surface was built
+// with.
+func (s *VolSurface) PriceFromSurface(spot, strike, expiry, r, q float64, o OptionType) (float64, error) {
+
+	v, err := s.Vol(strike, expiry)
+	if err != nil {
+		return nan(), err
+	}
+
+	return Price(&PriceParams{
+		Vol:          v,
+		TimeToExpiry: expiry,
+		Underlying:   spot,
+		Strike:       strike,
+		Rate:         r,
+		Dividend:     q,
+		Type:         o,
+	})
+}
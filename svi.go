@@ -0,0 +1,206 @@
+package blackscholes
+
+import "math"
+
+// SVIParams is a raw SVI smile of total variance in log-moneyness
+// k = log(strike/forward),
+//
+//	w(k) = A + B*(Rho*(k - M) + sqrt((k - M)^2 + Sigma^2))
+//
+// for the expiry T and forward of a calibration. RMSE is the root mean
+// square total variance error of the fit and ButterflyFree reports whether
+// Gatheral's density condition g(k) >= 0 holds, with positive total
+// variance, on the check grid described at CalibrateSVI.
+type SVIParams struct {
+	A, B, Rho, M, Sigma float64
+	Forward, T          float64
+	RMSE                float64
+	ButterflyFree       bool
+}
+
+// SVIConfig holds the search settings of CalibrateSVI. Zero fields take
+// the defaults sviStartsDefault, sviTolDefault and sviMaxItDefault.
+type SVIConfig struct {
+	Starts int
+	Tol    float64
+	MaxIt  int
+}
+
+const (
+	sviStartsDefault int     = 6
+	sviTolDefault    float64 = 1e-14
+	sviMaxItDefault  int     = 2000
+)
+
+// TotalVariance returns w(k) at log-moneyness k
+func (p SVIParams) TotalVariance(k float64) float64 {
+	d := k - p.M
+	return p.A + p.B*(p.Rho*d+sqrt(d*d+p.Sigma*p.Sigma))
+}
+
+// Vol returns the implied vol sqrt(w(k)/T) at strike, NaN where the total
+// variance is negative
+func (p SVIParams) Vol(strike float64) float64 {
+	w := p.TotalVariance(log(strike / p.Forward))
+	if w < 0 {
+		return nan()
+	}
+	return sqrt(w / p.T)
+}
+
+// g is Gatheral's butterfly density function, which is non-negative
+// exactly when call prices are convex in strike
+func (p SVIParams) g(k float64) float64 {
+	d := k - p.M
+	r := sqrt(d*d + p.Sigma*p.Sigma)
+	w := p.TotalVariance(k)
+	w1 := p.B * (p.Rho + d/r)
+	w2 := p.B * p.Sigma * p.Sigma / r / r / r
+	a := 1 - k*w1/2/w
+	return a*a - w1*w1/4*(1/w+0.25) + w2/2
+}
+
+// CalibrateSVI fits raw SVI to the points of one expiry by least squares
+// in total variance. For fixed M and Sigma the fit is linear in A, B*Rho
+// and B, so those are solved exactly and Nelder-Mead searches M and
+// log(Sigma) from cfg.Starts starting points spread over the quoted
+// log-moneyness range. B >= 0, |Rho| <= 1 and a non-negative minimum
+// variance A + B*Sigma*sqrt(1 - Rho^2) are enforced; the butterfly
+// condition is checked after the fit on 201 points spanning the quoted
+// range widened by 1 on each side and reported in ButterflyFree. At least
+// five points are needed.
+func CalibrateSVI(points []SmilePoint, forward, timeToExpiry float64, cfg SVIConfig) (SVIParams, error) {
+
+	ks, ws, err := smileVariances(points, forward, timeToExpiry)
+	if err != nil {
+		return SVIParams{}, err
+	}
+	if len(ks) < 5 {
+		return SVIParams{}, ErrSmile
+	}
+
+	starts, tol, maxit := sviStartsDefault, sviTolDefault, sviMaxItDefault
+	if cfg.Starts > 0 {
+		starts = cfg.Starts
+	}
+	if cfg.Tol > 0 {
+		tol = cfg.Tol
+	}
+	if cfg.MaxIt > 0 {
+		maxit = cfg.MaxIt
+	}
+
+	kmin, kmax := ks[0], ks[0]
+	for _, k := range ks {
+		kmin, kmax = min(kmin, k), max(kmax, k)
+	}
+
+	f := func(x []float64) float64 {
+		_, sse := sviInner(ks, ws, x[0], exp(x[1]))
+		return sse
+	}
+
+	best, bestSSE := []float64(nil), math.Inf(1)
+	sigmas := [2]float64{0.1, 0.5}
+	for i := 0; i < starts; i++ {
+		m := kmin
+		if n := (starts + 1) / 2; n > 1 {
+			m += (kmax - kmin) * float64(i/2) / float64(n-1)
+		}
+		x, sse := nelderMead(f, []float64{m, log(sigmas[i%2])}, []float64{0.1, 0.5}, tol, maxit)
+		if sse < bestSSE {
+			best, bestSSE = x, sse
+		}
+	}
+
+	p, sse := sviInner(ks, ws, best[0], exp(best[1]))
+	p.Forward, p.T = forward, timeToExpiry
+	p.RMSE = sqrt(sse / float64(len(ks)))
+
+	p.ButterflyFree = p.A+p.B*p.Sigma*sqrt(1-p.Rho*p.Rho) >= 0
+	lo, hi := kmin-1, kmax+1
+	for i := 0; i <= 200 && p.ButterflyFree; i++ {
+		k := lo + (hi-lo)*float64(i)/200
+		p.ButterflyFree = p.TotalVariance(k) > 0 && p.g(k) >= -1e-12
+	}
+
+	return p, nil
+}
+
+// sviInner solves the linear least squares fit of
+// w = a + d*y + c*sqrt(y*y + 1), y = (k - m)/sigma, with c = B*Sigma and
+// d = Rho*c, projected onto the constraints, and returns the parameters
+// and the sum of squared errors
+func sviInner(ks, ws []float64, m, sigma float64) (SVIParams, float64) {
+
+	// normal equations in (a, d, c)
+	var A [3][3]float64
+	var b [3]float64
+	for i, k := range ks {
+		y := (k - m) / sigma
+		u := [3]float64{1, y, sqrt(y*y + 1)}
+		for r := range u {
+			for c := range u {
+				A[r][c] += u[r] * u[c]
+			}
+			b[r] += u[r] * ws[i]
+		}
+	}
+	a, d, c := solve3(A, b)
+
+	mean := func(d, c float64) float64 {
+		s := 0.0
+		for i, k := range ks {
+			y := (k - m) / sigma
+			s += ws[i] - d*y - c*sqrt(y*y+1)
+		}
+		return s / float64(len(ks))
+	}
+
+	if math.IsNaN(a+d+c) || c < 0 || abs(d) > c || a+sqrt(c*c-d*d) < 0 {
+		c = max(c, 0)
+		if math.IsNaN(c) {
+			c = 0
+		}
+		d = math.Max(-c, math.Min(c, d))
+		if math.IsNaN(d) {
+			d = 0
+		}
+		a = max(mean(d, c), -sqrt(c*c-d*d))
+	}
+
+	p := SVIParams{A: a, B: c / sigma, M: m, Sigma: sigma}
+	if c > 0 {
+		p.Rho = d / c
+	}
+
+	sse := 0.0
+	for i, k := range ks {
+		e := p.TotalVariance(k) - ws[i]
+		sse += e * e
+	}
+
+	return p, sse
+}
+
+// solve3 solves the 3x3 system A*x = b by Cramer's rule
+func solve3(A [3][3]float64, b [3]float64) (x0, x1, x2 float64) {
+
+	det := func(m [3][3]float64) float64 {
+		return m[0][0]*(m[1][1]*m[2][2]-m[1][2]*m[2][1]) -
+			m[0][1]*(m[1][0]*m[2][2]-m[1][2]*m[2][0]) +
+			m[0][2]*(m[1][0]*m[2][1]-m[1][1]*m[2][0])
+	}
+
+	d := det(A)
+	var x [3]float64
+	for j := range x {
+		m := A
+		for i := range m {
+			m[i][j] = b[i]
+		}
+		x[j] = det(m) / d
+	}
+
+	return x[0], x[1], x[2]
+}
@@ -0,0 +1,302 @@
+package americantest
+
+import (
+	"math"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+func Test_ImpliedVolAmerican(t *testing.T) {
+
+	const x, r, q = 100.0, 0.05, 0.03
+
+	for _, o := range []bs.OptionType{bs.Call, bs.Put, bs.Straddle} {
+		for _, v := range []float64{0.1, 0.3, 0.8} {
+			for _, tau := range []float64{0.1, 1} {
+				for _, k := range []float64{70, 100, 130} {
+
+					premium, err := bs.PriceLeisenReimer(v, tau, x, k, r, q, o, bs.American, bs.AmericanIVStepsDefault)
+					if err != nil {
+						t.Fatal(err)
+					}
+					// skip premia with no vol information, such as
+					// options exercised at once
+					bumped, _ := bs.PriceLeisenReimer(1.01*v, tau, x, k, r, q, o, bs.American, bs.AmericanIVStepsDefault)
+					if bumped-premium < 1e-6 {
+						continue
+					}
+
+					vol, err := bs.ImpliedVolAmerican(premium, tau, x, k, r, q, o, bs.AmericanIVConfig{})
+					if err != nil {
+						t.Fatal(err)
+					}
+					if math.Abs(vol-v) > 1e-6 {
+						t.Errorf("Type = %c, Vol = %v, T = %v, Strike = %v: implied vol = %v",
+							o, v, tau, k, vol)
+					}
+				}
+			}
+		}
+	}
+}
+
+func Test_ImpliedVolAmericanEuropeanBias(t *testing.T) {
+
+	// an in the money put with a high rate and a big dividend yield
+	const v, tau, x, k, r, q = 0.25, 1.0, 100.0, 120.0, 0.08, 0.06
+
+	premium, err := bs.PriceLeisenReimer(v, tau, x, k, r, q, bs.Put, bs.American, 501)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	am, err := bs.ImpliedVolAmerican(premium, tau, x, k, r, q, bs.Put, bs.AmericanIVConfig{Steps: 501})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	eu, err := bs.ImpliedVol(&bs.ImpliedVolParams{
+		Premium: premium, TimeToExpiry: tau, Underlying: x, Strike: k, Rate: r, Dividend: q, Type: bs.Put,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Logf("American implied vol = %v, European implied vol = %v", am, eu)
+
+	if math.Abs(am-v) > 1e-6 {
+		t.Errorf("American implied vol = %v, want %v", am, v)
+	}
+	// the early exercise premium is read as extra vol
+	if eu-v < 0.01 {
+		t.Errorf("European implied vol = %v, want biased above %v", eu, v)
+	}
+}
+
+func Test_ImpliedVolAmericanBounds(t *testing.T) {
+
+	const tau, x, k, r, q = 1.0, 100.0, 120.0, 0.05, 0.0
+	cfg := bs.AmericanIVConfig{}
+
+	for _, c := range []struct {
+		premium float64
+		o       bs.OptionType
+		err     error
+	}{
+		{19, bs.Put, bs.ErrArbitrage},  // below spot intrinsic
+		{121, bs.Put, bs.ErrArbitrage}, // above the strike
+		{101, bs.Call, bs.ErrArbitrage},
+		{-1, bs.Call, bs.ErrNegPremium},
+	} {
+		if _, err := bs.ImpliedVolAmerican(c.premium, tau, x, k, r, q, c.o, cfg); err != c.err {
+			t.Errorf("Premium = %v, Type = %c: err = %v, want %v", c.premium, c.o, err, c.err)
+		}
+	}
+
+	// at intrinsic the vol is 0
+	if vol, err := bs.ImpliedVolAmerican(20, tau, x, k, r, q, bs.Put, cfg); err != nil || vol != 0 {
+		t.Errorf("vol = %v, err = %v", vol, err)
+	}
+}
+
+func Test_PriceLeisenReimer(t *testing.T) {
+
+	const x, r, q = 100.0, 0.05, 0.02
+
+	for _, o := range []bs.OptionType{bs.Call, bs.Put} {
+		for _, k := range []float64{80, 100, 120} {
+			p, err := bs.PriceLeisenReimer(0.3, 1, x, k, r, q, o, bs.European, 201)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := bs.BSPrice(0.3, 1, x, k, r, q, o); math.Abs(p-want) > 1e-4 {
+				t.Errorf("Type = %c, Strike = %v: price = %v, want %v", o, k, p, want)
+			}
+		}
+	}
+}
+
+func Benchmark_ImpliedVolAmerican(b *testing.B) {
+	premium, _ := bs.PriceLeisenReimer(0.25, 1, 100, 110, 0.05, 0.02, bs.Put, bs.American, 201)
+	for i := 0; i < b.N; i++ {
+		bs.ImpliedVolAmerican(premium, 1, 100, 110, 0.05, 0.02, bs.Put, bs.AmericanIVConfig{})
+	}
+}
+
+func Test_EarlyExercisePremium(t *testing.T) {
+
+	const x = 100.0
+
+	// stated accuracy of each method against a 1001 step tree on a grid of
+	// maturities up to a year and vols up to 40%
+	tols := map[bs.AmericanMethod]float64{
+		bs.AmericanTree:   0.01,
+		bs.AmericanBAW:    0.2,
+		bs.AmericanBS2002: 0.2,
+	}
+
+	for _, o := range []bs.OptionType{bs.Call, bs.Put, bs.Straddle} {
+		for _, v := range []float64{0.1, 0.4} {
+			for _, tau := range []float64{0.1, 1} {
+				for _, k := range []float64{80, 100, 120} {
+					for _, rq := range [][2]float64{{0.05, 0}, {0.08, 0.04}, {0.02, 0.06}, {0, 0.03}} {
+
+						r, q := rq[0], rq[1]
+						ref := treePrice(t, v, tau, x, k, r, q, o)
+
+						for m, tol := range tols {
+							eu, am, prem, err := bs.EarlyExercisePremium(v, tau, x, k, r, q, o, m)
+							if err != nil {
+								t.Fatal(err)
+							}
+							if eu != bs.BSPrice(v, tau, x, k, r, q, o) || prem < 0 || prem < am-eu {
+								t.Errorf("%v: european = %v, american = %v, premium = %v", m, eu, am, prem)
+							}
+							if math.Abs(am-ref) > tol {
+								t.Errorf("%v Type = %c, Vol = %v, T = %v, Strike = %v, r = %v, q = %v: "+
+									"american = %v, tree %v", m, o, v, tau, k, r, q, am, ref)
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func treePrice(t *testing.T, v, tau, x, k, r, q float64, o bs.OptionType) float64 {
+	if o == bs.Straddle {
+		return treePrice(t, v, tau, x, k, r, q, bs.Call) + treePrice(t, v, tau, x, k, r, q, bs.Put)
+	}
+	p, err := bs.PriceLeisenReimer(v, tau, x, k, r, q, o, bs.American, 1001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func Test_EarlyExercisePremiumClamp(t *testing.T) {
+
+	// a call without dividends has no early exercise premium, but the tree
+	// price differs from the European price by a little discretization noise
+	const v, tau, x, k, r = 0.3, 1.0, 100.0, 105.0, 0.05
+
+	eu, am, prem, err := bs.EarlyExercisePremium(v, tau, x, k, r, 0, bs.Call, bs.AmericanTree)
+	if err != nil || prem != 0 || am >= eu {
+		t.Fatalf("european = %v, american = %v, premium = %v, err = %v", eu, am, prem, err)
+	}
+
+	// the analytic methods reduce to Black-Scholes exactly
+	for _, m := range []bs.AmericanMethod{bs.AmericanBAW, bs.AmericanBS2002} {
+		if eu, am, prem, err := bs.EarlyExercisePremium(v, tau, x, k, r, 0, bs.Call, m); err != nil || am != eu || prem != 0 {
+			t.Errorf("%v: european = %v, american = %v, premium = %v, err = %v", m, eu, am, prem, err)
+		}
+	}
+
+	// BS2002 undervalues a low vol call when the dividend yield is well
+	// above the rate, by more than EarlyExerciseTol
+	eu, am, prem, err = bs.EarlyExercisePremium(0.05, 1, 100, 100, 0.05, 0.2, bs.Call, bs.AmericanBS2002)
+	w, ok := err.(*bs.ClampWarning)
+	if !ok || w.Premium != am-eu || !(-w.Premium > bs.EarlyExerciseTol) || prem != 0 || math.IsNaN(eu) || math.IsNaN(am) {
+		t.Errorf("european = %v, american = %v, premium = %v, err = %v", eu, am, prem, err)
+	}
+
+	if _, _, _, err := bs.EarlyExercisePremium(v, tau, x, k, r, 0, bs.Call, bs.AmericanMethod(9)); err != bs.ErrAmericanMethod {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func Test_PriceAmericanLimits(t *testing.T) {
+
+	const x, k = 100.0, 110.0
+
+	for _, m := range []bs.AmericanMethod{bs.AmericanTree, bs.AmericanBAW, bs.AmericanBS2002} {
+
+		// at expiry, spot intrinsic value
+		if p, err := bs.PriceAmerican(0.2, 0, x, k, 0.05, 0, bs.Put, m); err != nil || p != 10 {
+			t.Errorf("%v: expired put = %v, err = %v", m, p, err)
+		}
+
+		// at zero vol a put with positive rates is exercised at once
+		if p, err := bs.PriceAmerican(0, 1, x, k, 0.05, 0, bs.Put, m); err != nil || p != 10 {
+			t.Errorf("%v: zero vol put = %v, err = %v", m, p, err)
+		}
+
+		// and a call without dividends is held to expiry
+		want := x - k*math.Exp(-0.2)
+		if p, err := bs.PriceAmerican(0, 1, x, k, 0.2, 0, bs.Call, m); err != nil || math.Abs(p-want) > 1e-12 {
+			t.Errorf("%v: zero vol call = %v, want %v, err = %v", m, p, want, err)
+		}
+	}
+}
+
+// escrowedTree prices the American call of PriceAmericanCallRGW on a
+// binomial tree of the spot less the present value of the dividend, to
+// the dividend time, where the call is exercised cum dividend or held as
+// a European call to expiry
+func escrowedTree(v, tau, x, k, r, d, td float64, steps int) float64 {
+
+	xe := x - d*math.Exp(-r*td)
+	dt := td / float64(steps)
+	u := math.Exp(v * math.Sqrt(dt))
+	p := (math.Exp(r*dt) - 1/u) / (u - 1/u)
+	df := math.Exp(-r * dt)
+
+	vals := make([]float64, steps+1)
+	for j := range vals {
+		s := xe * math.Pow(u, float64(2*j-steps))
+		vals[j] = math.Max(bs.BSPrice(v, tau-td, s, k, r, 0, bs.Call), s+d-k)
+	}
+	for i := steps - 1; i >= 0; i-- {
+		s, pv := xe*math.Pow(u, float64(-i)), d*math.Exp(-r*(td-float64(i)*dt))
+		for j := 0; j <= i; j++ {
+			vals[j] = math.Max(df*(p*vals[j+1]+(1-p)*vals[j]), s+pv-k)
+			s *= u * u
+		}
+	}
+
+	return vals[0]
+}
+
+func Test_PriceAmericanCallRGW(t *testing.T) {
+
+	const x, r = 100.0, 0.05
+
+	for _, v := range []float64{0.15, 0.3} {
+		for _, td := range []float64{0.25, 0.75} {
+			for _, k := range []float64{80, 100, 120} {
+				for _, d := range []float64{0.5, 4, 8} {
+
+					got, err := bs.PriceAmericanCallRGW(v, 1, x, k, r, d, td)
+					if err != nil {
+						t.Fatal(err)
+					}
+					want := escrowedTree(v, 1, x, k, r, d, td, 1000)
+					if math.Abs(got-want) > 1e-3 {
+						t.Errorf("v %v, td %v, k %v, d %v: %v, tree %v", v, td, k, d, got, want)
+					}
+
+					european := bs.BSPrice(v, 1, x-d*math.Exp(-r*td), k, r, 0, bs.Call)
+					switch {
+					case d <= k*(1-math.Exp(-r*(1-td))) && got != european:
+						t.Errorf("v %v, td %v, k %v, d %v: %v, European %v", v, td, k, d, got, european)
+					case !(got >= european):
+						t.Errorf("v %v, td %v, k %v, d %v: %v below European %v", v, td, k, d, got, european)
+					}
+				}
+			}
+		}
+	}
+
+	// a dividend above the strike makes exercise certain
+	if got, _ := bs.PriceAmericanCallRGW(0.2, 1, x, 5, r, 6, 0.5); math.Abs(got-(x-5*math.Exp(-r*0.5))) > 1e-12 {
+		t.Errorf("certain exercise: %v", got)
+	}
+
+	for _, c := range []struct{ d, td float64 }{{d: 2, td: 0}, {d: 2, td: 1}, {d: -1, td: 0.5}, {d: 200, td: 0.5}} {
+		if _, err := bs.PriceAmericanCallRGW(0.2, 1, x, 100, r, c.d, c.td); err != bs.ErrDividend {
+			t.Errorf("%+v: %v", c, err)
+		}
+	}
+}
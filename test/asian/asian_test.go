@@ -0,0 +1,248 @@
+package asiantest
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+const vol, tau, spot, r, q = 0.25, 1.0, 100.0, 0.05, 0.02
+
+var types = []bs.OptionType{bs.Call, bs.Put, bs.Straddle}
+
+var methods = []bs.AsianMethod{bs.AsianGeometric, bs.AsianArithmeticMC}
+
+// simulate prices the seasoned arithmetic Asian by plain Monte Carlo,
+// simulating the spot at the remaining fixings
+func simulate(k, past float64, done, total, paths int, o bs.OptionType) (float64, float64) {
+
+	rng := rand.New(rand.NewSource(42))
+	left := total - done
+	dt := tau / float64(left)
+
+	var sum, sum2 float64
+	for p := 0; p < paths; p++ {
+		x, s := spot, past*float64(done)
+		for i := 0; i < left; i++ {
+			x *= math.Exp((r-q-vol*vol/2)*dt + vol*math.Sqrt(dt)*rng.NormFloat64())
+			s += x
+		}
+		a := s / float64(total)
+		var v float64
+		switch o {
+		case bs.Call:
+			v = math.Max(a-k, 0)
+		case bs.Put:
+			v = math.Max(k-a, 0)
+		default:
+			v = math.Abs(a - k)
+		}
+		sum += v
+		sum2 += v * v
+	}
+
+	n, df := float64(paths), math.Exp(-r*tau)
+	mean := sum / n
+
+	return df * mean, df * math.Sqrt((sum2/n-mean*mean)/n)
+}
+
+func Test_PriceAsianSeasoned(t *testing.T) {
+
+	// every fixing done is the discounted payoff
+	for _, o := range types {
+		for _, m := range methods {
+			got, err := bs.PriceAsianSeasoned(vol, tau, spot, 100, r, q, o, 108, 12, 12, m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := math.Exp(-r*tau) * 8
+			if o == bs.Put {
+				want = 0
+			}
+			if math.Abs(got-want) > 1e-12 {
+				t.Errorf("%v %v all fixed: got %v, want %v", o, m, got, want)
+			}
+		}
+	}
+
+	// no fixing done is the unseasoned price
+	for _, o := range types {
+		for _, m := range methods {
+			got, err := bs.PriceAsianSeasoned(vol, tau, spot, 95, r, q, o, 80, 0, 12, m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := bs.PriceAsian(vol, tau, spot, 95, r, q, o, 12, m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != want {
+				t.Errorf("%v %v unseasoned: got %v, want %v", o, m, got, want)
+			}
+		}
+	}
+
+	// half seasoned against an independent simulation
+	for _, o := range types {
+		for _, past := range []float64{90, 104} {
+			got, err := bs.PriceAsianSeasoned(vol, tau, spot, 100, r, q, o, past, 6, 12, bs.AsianArithmeticMC)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, se := simulate(100, past, 6, 12, 200000, o)
+			if math.Abs(got-want) > 4*se {
+				t.Errorf("%v past %v: got %v, want %v ± %v", o, past, got, want, se)
+			}
+		}
+	}
+
+	// a realized average far above the strike leaves no optionality
+	got, err := bs.PriceAsianSeasoned(vol, tau, spot, 100, r, q, bs.Call, 250, 6, 12, bs.AsianGeometric)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fwd float64
+	for i := 1; i <= 6; i++ {
+		fwd += bs.Forward(spot, r, q, tau*float64(i)/6) / 12
+	}
+	if want := math.Exp(-r*tau) * (125 + fwd - 100); math.Abs(got-want) > 1e-10 {
+		t.Errorf("deep call: got %v, want %v", got, want)
+	}
+	if got, _ := bs.PriceAsianSeasoned(vol, tau, spot, 100, r, q, bs.Put, 250, 6, 12, bs.AsianGeometric); got != 0 {
+		t.Errorf("deep put: got %v, want 0", got)
+	}
+
+	for _, c := range []struct {
+		past        float64
+		done, total int
+	}{
+		{past: 100, done: 3, total: 0},
+		{past: 100, done: -1, total: 12},
+		{past: 100, done: 13, total: 12},
+		{past: -1, done: 3, total: 12},
+		{past: math.NaN(), done: 3, total: 12},
+	} {
+		if _, err := bs.PriceAsianSeasoned(vol, tau, spot, 100, r, q, bs.Call, c.past, c.done, c.total, bs.AsianGeometric); err != bs.ErrAsian {
+			t.Errorf("%+v: got %v, want ErrAsian", c, err)
+		}
+	}
+	if _, err := bs.PriceAsian(vol, tau, spot, 100, r, q, bs.Call, 12, bs.AsianMethod(9)); err != bs.ErrAsianMethod {
+		t.Errorf("got %v, want ErrAsianMethod", err)
+	}
+}
+
+func Test_PriceAsian(t *testing.T) {
+
+	// a single fixing at expiry is a European option
+	for _, o := range types {
+		got, err := bs.PriceAsian(vol, tau, spot, 105, r, q, o, 1, bs.AsianGeometric)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := bs.BSPrice(vol, tau, spot, 105, r, q, o); math.Abs(got-want) > 1e-10 {
+			t.Errorf("%v: got %v, want %v", o, got, want)
+		}
+	}
+
+	// the arithmetic average is at least the geometric one
+	for _, k := range []float64{80, 100, 120} {
+		g, err := bs.PriceAsian(vol, tau, spot, k, r, q, bs.Call, 52, bs.AsianGeometric)
+		if err != nil {
+			t.Fatal(err)
+		}
+		a, err := bs.PriceAsian(vol, tau, spot, k, r, q, bs.Call, 52, bs.AsianArithmeticMC)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !(a > g) || a > g*1.2+0.1 {
+			t.Errorf("strike %v: arithmetic %v, geometric %v", k, a, g)
+		}
+		e := bs.BSPrice(vol, tau, spot, k, r, q, bs.Call)
+		if !(a < e) {
+			t.Errorf("strike %v: arithmetic %v above European %v", k, a, e)
+		}
+	}
+}
+
+func Test_AsianConfig(t *testing.T) {
+
+	price := func(c bs.AsianConfig) float64 {
+		p, err := c.PriceAsian(vol, tau, spot, 100, r, q, bs.Call, 12, bs.AsianArithmeticMC)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	// the same seed repeats the price, and the zero config is the default
+	def, _ := bs.PriceAsian(vol, tau, spot, 100, r, q, bs.Call, 12, bs.AsianArithmeticMC)
+	if p := price(bs.AsianConfig{Paths: bs.AsianPathsDefault}); p != def {
+		t.Errorf("default paths %v, want %v", p, def)
+	}
+	if a, b := price(bs.AsianConfig{Paths: 500, Seed: 7}), price(bs.AsianConfig{Paths: 500, Seed: 7}); a != b {
+		t.Errorf("seed 7 gave %v and %v", a, b)
+	}
+
+	// with the control variate few paths stay close to the default
+	if p := price(bs.AsianConfig{Paths: 500, Seed: 7}); p == def || math.Abs(p-def) > 0.01*def {
+		t.Errorf("500 paths %v, default %v", p, def)
+	}
+
+	c := bs.AsianConfig{Paths: -1}
+	if _, err := c.PriceAsian(vol, tau, spot, 100, r, q, bs.Call, 12, bs.AsianArithmeticMC); err != bs.ErrZeroPaths {
+		t.Errorf("got %v, want ErrZeroPaths", err)
+	}
+	if _, err := c.PriceAsian(vol, tau, spot, 100, r, q, bs.Call, 12, bs.AsianGeometric); err != nil {
+		t.Errorf("geometric: %v", err)
+	}
+}
+
+func Test_PriceAsianForwardStart(t *testing.T) {
+
+	for _, o := range types {
+		for _, m := range methods {
+			got, err := bs.PriceAsianForwardStart(vol, tau, spot, 100, r, q, o, 0, 12, m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := bs.PriceAsian(vol, tau, spot, 100, r, q, o, 12, m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(got-want) > 1e-12 {
+				t.Errorf("%v %v: got %v, want %v", o, m, got, want)
+			}
+		}
+	}
+
+	// a window collapsed onto expiry is a European option
+	got, err := bs.PriceAsianForwardStart(vol, tau, spot, 100, r, q, bs.Call, tau, 12, bs.AsianGeometric)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := bs.BSPrice(vol, tau, spot, 100, r, q, bs.Call); math.Abs(got-want) > 1e-10 {
+		t.Errorf("collapsed window: got %v, want %v", got, want)
+	}
+
+	// a later window averages more variance
+	prev := 0.0
+	for _, start := range []float64{0, 0.25, 0.5, 0.75} {
+		p, err := bs.PriceAsianForwardStart(vol, tau, spot, 100, r, q, bs.Straddle, start, 12, bs.AsianGeometric)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !(p > prev) {
+			t.Errorf("start %v: %v not above %v", start, p, prev)
+		}
+		prev = p
+	}
+
+	for _, start := range []float64{-0.1, 1.1, math.NaN()} {
+		if _, err := bs.PriceAsianForwardStart(vol, tau, spot, 100, r, q, bs.Call, start, 12, bs.AsianGeometric); err != bs.ErrAsian {
+			t.Errorf("start %v: got %v, want ErrAsian", start, err)
+		}
+	}
+}
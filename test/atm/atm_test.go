@@ -0,0 +1,85 @@
+package atmtest
+
+import (
+	"math"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+const tau, spot, r, q = 0.5, 100.0, 0.05, 0.01
+
+func Test_ATMStrike(t *testing.T) {
+
+	for _, v := range []float64{0.05, 0.2, 0.8} {
+
+		ks, err := bs.ATMStrike(bs.ATMSpot, v, tau, spot, r, q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		kf, _ := bs.ATMStrike(bs.ATMForward, v, tau, spot, r, q)
+		kd, _ := bs.ATMStrike(bs.ATMDNS, v, tau, spot, r, q)
+
+		if !(kd > kf && kf > ks) {
+			t.Errorf("v = %v: strikes dns %v, forward %v, spot %v out of order", v, kd, kf, ks)
+		}
+		if f := spot * math.Exp((r-q)*tau); ks != spot || math.Abs(kf-f) > 1e-12 {
+			t.Errorf("spot %v, forward %v", ks, kf)
+		}
+
+		// the straddle at the DNS strike is delta neutral
+		c := bs.BSDelta(v, tau, spot, kd, r, q, bs.Call)
+		p := bs.BSDelta(v, tau, spot, kd, r, q, bs.Put)
+		if math.Abs(c+p) > 1e-12 {
+			t.Errorf("v = %v: straddle delta = %v", v, c+p)
+		}
+	}
+
+	if _, err := bs.ATMStrike(bs.ATMSpot+1, 0.2, tau, spot, r, q); err != bs.ErrATMConvention {
+		t.Errorf("err = %v", err)
+	}
+	if _, err := bs.ATMStrike(bs.ATMDNS, -0.2, tau, spot, r, q); err != bs.ErrNegVol {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func Test_DNSStrike(t *testing.T) {
+
+	f := spot * math.Exp((r-q)*tau)
+
+	for _, method := range []bs.InterpMethod{bs.InterpLinear, bs.InterpNaturalSpline, bs.InterpMonotone} {
+
+		// a put skew steep enough that the DNS vol differs visibly from the
+		// forward vol
+		var points []bs.SmilePoint
+		for i, k := range []float64{80, 90, 100, 110, 120, 130} {
+			points = append(points, bs.SmilePoint{Strike: k, Vol: 0.6 - 0.07*float64(i)})
+		}
+
+		s, err := bs.NewSmileInterp(points, bs.InterpConfig{Method: method, Forward: f, T: tau})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		k, err := bs.DNSStrike(s)
+		if err != nil {
+			t.Fatalf("%v: %v", method, err)
+		}
+
+		v := s.Vol(k)
+		if want, _ := bs.ATMStrike(bs.ATMDNS, v, tau, spot, r, q); math.Abs(k-want) > 1e-9 {
+			t.Errorf("%v: strike %v, want %v at vol %v", method, k, want, v)
+		}
+		if fixed, _ := bs.ATMStrike(bs.ATMDNS, s.Vol(f), tau, spot, r, q); !(k < fixed) {
+			t.Errorf("%v: fixed point %v not below the forward vol strike %v", method, k, fixed)
+		}
+	}
+
+	// a flat smile has the closed form strike
+	s, _ := bs.NewSmileInterp([]bs.SmilePoint{{Strike: 90, Vol: 0.3}, {Strike: 110, Vol: 0.3}},
+		bs.InterpConfig{Forward: f, T: tau})
+	k, err := bs.DNSStrike(s)
+	if want, _ := bs.ATMStrike(bs.ATMDNS, 0.3, tau, spot, r, q); err != nil || math.Abs(k-want) > 1e-10 {
+		t.Errorf("strike = %v, want %v, err = %v", k, want, err)
+	}
+}
@@ -0,0 +1,368 @@
+package barriertest
+
+import (
+	"math"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+var (
+	types    = []bs.OptionType{bs.Call, bs.Put, bs.Straddle}
+	barriers = []bs.BarrierType{bs.DownAndIn, bs.DownAndOut, bs.UpAndIn, bs.UpAndOut}
+)
+
+func greeks(t *testing.T, v, tau, x, k, h, r, q float64, o bs.OptionType, b bs.BarrierType) bs.Greeks {
+	g, err := bs.BarrierGreeks(v, tau, x, k, h, r, q, o, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+func Test_BarrierInOutParity(t *testing.T) {
+
+	const tau, x, r, q = 0.5, 100.0, 0.05, 0.02
+
+	for _, o := range types {
+		for _, v := range []float64{0, 0.15, 0.4} {
+			for _, k := range []float64{0, 80, 95, 100, 105, 130} {
+				for _, h := range []float64{70, 90, 99.99, 100, 100.01, 110, 140} {
+
+					up := h > x
+					in, out := bs.DownAndIn, bs.DownAndOut
+					if up {
+						in, out = bs.UpAndIn, bs.UpAndOut
+					}
+
+					gi := greeks(t, v, tau, x, k, h, r, q, o, in)
+					gout := greeks(t, v, tau, x, k, h, r, q, o, out)
+					want := bs.BSGreeks(v, tau, x, k, r, q, o)
+
+					for _, c := range []struct {
+						name          string
+						in, out, want float64
+						tol           float64
+					}{
+						{"Price", gi.Price, gout.Price, want.Price, 1e-12},
+						{"Delta", gi.Delta, gout.Delta, want.Delta, 1e-11},
+						{"Gamma", gi.Gamma, gout.Gamma, want.Gamma, 1e-6},
+						{"Vega", gi.Vega, gout.Vega, want.Vega, 1e-10},
+						{"Theta", gi.Theta, gout.Theta, want.Theta, 1e-10},
+					} {
+						if math.Abs(c.in+c.out-c.want) > c.tol*math.Max(1, math.Abs(c.want)) {
+							t.Errorf("Type = %c, Vol = %v, Strike = %v, Barrier = %v: %s in + out = %v + %v, want %v",
+								o, v, k, h, c.name, c.in, c.out, c.want)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func Test_BarrierGreeks(t *testing.T) {
+
+	const tau, r, q = 0.75, 0.04, 0.01
+
+	for _, o := range types {
+		for _, b := range barriers {
+			for _, v := range []float64{0.1, 0.3} {
+				for _, k := range []float64{85, 100, 120} {
+					for _, x := range []float64{95, 105} {
+
+						h := 80.0
+						if b == bs.UpAndIn || b == bs.UpAndOut {
+							h = 125
+						}
+
+						price := func(v, tau, x float64) float64 {
+							return greeks(t, v, tau, x, k, h, r, q, o, b).Price
+						}
+
+						g := greeks(t, v, tau, x, k, h, r, q, o, b)
+						hx, hv, ht, hr := 1e-4*x, 1e-5, 1e-5, 1e-6
+						pr := func(r float64) float64 { return greeks(t, v, tau, x, k, h, r, q, o, b).Price }
+
+						for _, c := range []struct {
+							name     string
+							got, num float64
+						}{
+							{"Delta", g.Delta, (price(v, tau, x+hx) - price(v, tau, x-hx)) / 2 / hx},
+							{"Gamma", g.Gamma, (price(v, tau, x+hx) - 2*g.Price + price(v, tau, x-hx)) / hx / hx},
+							{"Vega", g.Vega, (price(v+hv, tau, x) - price(v-hv, tau, x)) / 2 / hv},
+							{"Theta", g.Theta, (price(v, tau-ht, x) - price(v, tau+ht, x)) / 2 / ht},
+							{"Rho", g.Rho, (pr(r+hr) - pr(r-hr)) / 2 / hr},
+						} {
+							if math.Abs(c.got-c.num) > 1e-5*math.Max(1, math.Abs(c.got)) {
+								t.Errorf("Type = %c, %v, Vol = %v, Strike = %v, Spot = %v: %s = %v, numeric %v",
+									o, b, v, k, x, c.name, c.got, c.num)
+							}
+						}
+
+						for name, f := range map[string]func(v, t, x, k, h, r, q float64, o bs.OptionType, b bs.BarrierType) (float64, error){
+							"PriceBarrier": bs.PriceBarrier,
+							"DeltaBarrier": bs.DeltaBarrier,
+							"GammaBarrier": bs.GammaBarrier,
+							"VegaBarrier":  bs.VegaBarrier,
+						} {
+							want := map[string]float64{
+								"PriceBarrier": g.Price, "DeltaBarrier": g.Delta,
+								"GammaBarrier": g.Gamma, "VegaBarrier": g.Vega,
+							}[name]
+							if got, err := f(v, tau, x, k, h, r, q, o, b); err != nil || got != want {
+								t.Errorf("%s = %v, %v, want %v", name, got, err, want)
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func Test_BarrierEdgeCases(t *testing.T) {
+
+	const v, tau, x, k, r, q = 0.2, 0.5, 100.0, 100.0, 0.03, 0.0
+
+	// knocked out, including spot on the barrier, is worth nothing
+	for _, c := range []struct {
+		h float64
+		b bs.BarrierType
+	}{{100, bs.DownAndOut}, {105, bs.DownAndOut}, {100, bs.UpAndOut}, {95, bs.UpAndOut}} {
+		if g := greeks(t, v, tau, x, k, c.h, r, q, bs.Call, c.b); g != (bs.Greeks{}) {
+			t.Errorf("%v, Barrier = %v: %+v", c.b, c.h, g)
+		}
+		in := bs.DownAndIn
+		if c.b == bs.UpAndOut {
+			in = bs.UpAndIn
+		}
+		if g, want := greeks(t, v, tau, x, k, c.h, r, q, bs.Put, in), bs.BSGreeks(v, tau, x, k, r, q, bs.Put); g != want {
+			t.Errorf("%v, Barrier = %v: %+v, want %+v", in, c.h, g, want)
+		}
+	}
+
+	// spot just off the barrier uses the one sided gamma stencil and the
+	// price goes to 0 continuously
+	g := greeks(t, v, tau, x, k, x*(1-1e-6), r, q, bs.Call, bs.DownAndOut)
+	if math.IsNaN(g.Gamma) || g.Price > 1e-3 || g.Delta <= 0 {
+		t.Errorf("near barrier: %+v", g)
+	}
+
+	// an up-and-out call struck above the barrier can never pay
+	if g := greeks(t, v, tau, x, 130, 120, r, q, bs.Call, bs.UpAndOut); g.Price != 0 || g.Delta != 0 {
+		t.Errorf("up-and-out call: %+v", g)
+	}
+
+	// a far barrier makes no difference
+	if p, _ := bs.PriceBarrier(v, tau, x, k, 1e-3, r, q, bs.Put, bs.DownAndOut); math.Abs(p-bs.BSPrice(v, tau, x, k, r, q, bs.Put)) > 1e-12 {
+		t.Errorf("down-and-out put with far barrier = %v", p)
+	}
+
+	for _, c := range []struct {
+		v, h float64
+		b    bs.BarrierType
+		err  error
+	}{
+		{-0.1, 90, bs.DownAndIn, bs.ErrNegVol},
+		{0.2, 0, bs.DownAndIn, bs.ErrBarrier},
+		{0.2, math.Inf(1), bs.UpAndIn, bs.ErrBarrier},
+		{0.2, 90, bs.BarrierType(9), bs.ErrBarrier},
+	} {
+		if _, err := bs.PriceBarrier(c.v, tau, x, k, c.h, r, q, bs.Call, c.b); err != c.err {
+			t.Errorf("err = %v, want %v", err, c.err)
+		}
+	}
+}
+
+func Test_BarrierRebateParity(t *testing.T) {
+
+	const tau, x, r, q, rebate = 0.5, 100.0, 0.05, 0.02, 3.0
+
+	for _, o := range types {
+		for _, v := range []float64{0, 0.15, 0.4} {
+			for _, k := range []float64{80, 100, 130} {
+				for _, h := range []float64{70, 90, 100, 110, 140} {
+
+					in, out := bs.DownAndIn, bs.DownAndOut
+					if h > x {
+						in, out = bs.UpAndIn, bs.UpAndOut
+					}
+
+					// with the rebate paid at expiry either way, in + out is
+					// the vanilla plus the discounted rebate
+					gi, err := bs.BarrierRebateGreeks(v, tau, x, k, h, rebate, r, q, o, in, bs.RebateAtHit)
+					if err != nil {
+						t.Fatal(err)
+					}
+					gout, err := bs.BarrierRebateGreeks(v, tau, x, k, h, rebate, r, q, o, out, bs.RebateAtExpiry)
+					if err != nil {
+						t.Fatal(err)
+					}
+					want := bs.BSGreeks(v, tau, x, k, r, q, o)
+					want.Price += rebate * math.Exp(-r*tau)
+					want.Theta += r * rebate * math.Exp(-r*tau)
+
+					if math.Abs(gi.Price+gout.Price-want.Price) > 1e-12*want.Price ||
+						math.Abs(gi.Delta+gout.Delta-want.Delta) > 1e-11 ||
+						math.Abs(gi.Vega+gout.Vega-want.Vega) > 1e-10*math.Max(1, want.Vega) ||
+						math.Abs(gi.Theta+gout.Theta-want.Theta) > 1e-10*math.Max(1, math.Abs(want.Theta)) {
+						t.Errorf("Type = %c, Vol = %v, Strike = %v, Barrier = %v: in %+v + out %+v, want %+v",
+							o, v, k, h, gi, gout, want)
+					}
+				}
+			}
+		}
+	}
+}
+
+func Test_BarrierRebateTouch(t *testing.T) {
+
+	const tau, x, k, r, q, rebate = 0.75, 100.0, 100.0, 0.04, 0.01, 2.5
+
+	for _, v := range []float64{0.1, 0.3} {
+		for _, h := range []float64{80, 95, 105, 125} {
+
+			in, out := bs.DownAndIn, bs.DownAndOut
+			if h > x {
+				in, out = bs.UpAndIn, bs.UpAndOut
+			}
+
+			for _, o := range types {
+
+				// the rebate alone is a one touch for knock outs and a no
+				// touch for knock ins
+				for _, timing := range []bs.RebateTiming{bs.RebateAtHit, bs.RebateAtExpiry} {
+					withRebate, _ := bs.PriceBarrierRebate(v, tau, x, k, h, rebate, r, q, o, out, timing)
+					without, _ := bs.PriceBarrier(v, tau, x, k, h, r, q, o, out)
+					touch, err := bs.PriceOneTouch(v, tau, x, h, rebate, r, q, timing)
+					if err != nil || math.Abs(withRebate-without-touch) > 1e-12 {
+						t.Errorf("%c %v %v %v: rebate %v, one touch %v, err = %v", o, v, h, timing, withRebate-without, touch, err)
+					}
+				}
+				withRebate, _ := bs.PriceBarrierRebate(v, tau, x, k, h, rebate, r, q, o, in, bs.RebateAtHit)
+				without, _ := bs.PriceBarrier(v, tau, x, k, h, r, q, o, in)
+				noTouch, err := bs.PriceNoTouch(v, tau, x, h, rebate, r, q)
+				if err != nil || math.Abs(withRebate-without-noTouch) > 1e-12 {
+					t.Errorf("%c %v %v: rebate %v, no touch %v, err = %v", o, v, h, withRebate-without, noTouch, err)
+				}
+			}
+
+			oneTouch, _ := bs.PriceOneTouch(v, tau, x, h, rebate, r, q, bs.RebateAtExpiry)
+			noTouch, _ := bs.PriceNoTouch(v, tau, x, h, rebate, r, q)
+			if math.Abs(oneTouch+noTouch-rebate*math.Exp(-r*tau)) > 1e-12 {
+				t.Errorf("%v %v: one touch %v + no touch %v", v, h, oneTouch, noTouch)
+			}
+
+			// paying at hit is worth more when rates are positive and the
+			// same without discounting
+			atHit, _ := bs.PriceOneTouch(v, tau, x, h, rebate, r, q, bs.RebateAtHit)
+			if !(atHit > oneTouch) {
+				t.Errorf("%v %v: at hit %v, at expiry %v", v, h, atHit, oneTouch)
+			}
+			atHit, _ = bs.PriceOneTouch(v, tau, x, h, rebate, 0, 0, bs.RebateAtHit)
+			atExpiry, _ := bs.PriceOneTouch(v, tau, x, h, rebate, 0, 0, bs.RebateAtExpiry)
+			if math.Abs(atHit-atExpiry) > 1e-12 {
+				t.Errorf("%v %v: at hit %v, at expiry %v without rates", v, h, atHit, atExpiry)
+			}
+
+			// the reflection principle for the log spot, drift -v*v/2
+			a, s, m := math.Log(h/x), v*math.Sqrt(tau), -v*v/2*tau
+			eta := 1.0
+			if h > x {
+				eta = -1
+			}
+			hit := bs.NormCDF(eta*(a-m)/s) + math.Exp(2*m/tau*a/v/v)*bs.NormCDF(eta*(a+m)/s)
+			if math.Abs(atExpiry-rebate*hit) > 1e-12 {
+				t.Errorf("%v %v: one touch %v, want %v", v, h, atExpiry, rebate*hit)
+			}
+		}
+	}
+}
+
+func Test_BarrierRebateEdgeCases(t *testing.T) {
+
+	const v, tau, x, k, r, q, rebate = 0.2, 0.5, 100.0, 100.0, 0.03, 0.0, 4.0
+
+	// knocked out pays the rebate now or discounted from expiry
+	if p, _ := bs.PriceBarrierRebate(v, tau, x, k, 105, rebate, r, q, bs.Call, bs.DownAndOut, bs.RebateAtHit); p != rebate {
+		t.Errorf("knocked at hit = %v", p)
+	}
+	if p, _ := bs.PriceBarrierRebate(v, tau, x, k, 100, rebate, r, q, bs.Put, bs.UpAndOut, bs.RebateAtExpiry); math.Abs(p-rebate*math.Exp(-r*tau)) > 1e-15 {
+		t.Errorf("knocked at expiry = %v", p)
+	}
+	if p, _ := bs.PriceOneTouch(v, tau, x, x, rebate, r, q, bs.RebateAtHit); p != rebate {
+		t.Errorf("touched one touch = %v", p)
+	}
+	if p, _ := bs.PriceNoTouch(v, tau, x, x, rebate, r, q); p != 0 {
+		t.Errorf("touched no touch = %v", p)
+	}
+
+	// at zero vol the spot drifts up to 101.5 and reaches 101 at time
+	// log(1.01)/r
+	p, _ := bs.PriceBarrierRebate(0, tau, x, k, 101, rebate, r, q, bs.Call, bs.UpAndOut, bs.RebateAtHit)
+	if want := rebate * math.Exp(-math.Log(1.01)); math.Abs(p-want) > 1e-12 {
+		t.Errorf("zero vol at hit = %v, want %v", p, want)
+	}
+	p, _ = bs.PriceBarrierRebate(0, tau, x, k, 102, rebate, r, q, bs.Call, bs.UpAndIn, bs.RebateAtHit)
+	if want := rebate * math.Exp(-r*tau); math.Abs(p-want) > 1e-12 {
+		t.Errorf("zero vol never knocked in = %v, want %v", p, want)
+	}
+
+	// a straddle pays one rebate
+	s, _ := bs.PriceBarrierRebate(v, tau, x, k, 90, rebate, r, q, bs.Straddle, bs.DownAndOut, bs.RebateAtHit)
+	c, _ := bs.PriceBarrierRebate(v, tau, x, k, 90, rebate, r, q, bs.Call, bs.DownAndOut, bs.RebateAtHit)
+	pt, _ := bs.PriceBarrier(v, tau, x, k, 90, r, q, bs.Put, bs.DownAndOut)
+	if math.Abs(s-c-pt) > 1e-12 {
+		t.Errorf("straddle %v, call %v + put %v", s, c, pt)
+	}
+
+	// greeks of the rebate against finite differences
+	g, err := bs.BarrierRebateGreeks(v, tau, x, k, 90, rebate, r, q, bs.Put, bs.DownAndOut, bs.RebateAtHit)
+	price := func(v, tau, x float64) float64 {
+		p, _ := bs.PriceBarrierRebate(v, tau, x, k, 90, rebate, r, q, bs.Put, bs.DownAndOut, bs.RebateAtHit)
+		return p
+	}
+	const e = 1e-5
+	if d := (price(v, tau, x+e) - price(v, tau, x-e)) / 2 / e; err != nil || math.Abs(g.Delta-d) > 1e-7 {
+		t.Errorf("delta %v, want %v, err = %v", g.Delta, d, err)
+	}
+	if gm := (price(v, tau, x+1e-3) - 2*g.Price + price(v, tau, x-1e-3)) / 1e-6; math.Abs(g.Gamma-gm) > 1e-5 {
+		t.Errorf("gamma %v, want %v", g.Gamma, gm)
+	}
+	if vg := (price(v+e, tau, x) - price(v-e, tau, x)) / 2 / e; math.Abs(g.Vega-vg) > 1e-6 {
+		t.Errorf("vega %v, want %v", g.Vega, vg)
+	}
+	if th := (price(v, tau-e, x) - price(v, tau+e, x)) / 2 / e; math.Abs(g.Theta-th) > 1e-6 {
+		t.Errorf("theta %v, want %v", g.Theta, th)
+	}
+	pr := func(r float64) float64 {
+		p, _ := bs.PriceBarrierRebate(v, tau, x, k, 90, rebate, r, q, bs.Put, bs.DownAndOut, bs.RebateAtHit)
+		return p
+	}
+	if rh := (pr(r+1e-6) - pr(r-1e-6)) / 2e-6; math.Abs(g.Rho-rh) > 1e-6 {
+		t.Errorf("rho %v, want %v", g.Rho, rh)
+	}
+
+	// at zero vol the rate moves the hitting time of the forward
+	g, _ = bs.BarrierRebateGreeks(0, tau, x, k, 101, rebate, r, q, bs.Call, bs.UpAndOut, bs.RebateAtHit)
+	zp := func(r float64) float64 {
+		p, _ := bs.PriceBarrierRebate(0, tau, x, k, 101, rebate, r, q, bs.Call, bs.UpAndOut, bs.RebateAtHit)
+		return p
+	}
+	if rh := (zp(r+1e-6) - zp(r-1e-6)) / 2e-6; math.Abs(g.Rho-rh) > 1e-6 {
+		t.Errorf("zero vol rho %v, want %v", g.Rho, rh)
+	}
+
+	for _, c := range []struct {
+		rebate float64
+		timing bs.RebateTiming
+	}{{-1, bs.RebateAtHit}, {math.Inf(1), bs.RebateAtHit}, {1, bs.RebateTiming(5)}} {
+		if _, err := bs.PriceBarrierRebate(v, tau, x, k, 90, c.rebate, r, q, bs.Call, bs.DownAndOut, c.timing); err != bs.ErrBarrier {
+			t.Errorf("%+v: err = %v", c, err)
+		}
+	}
+	if _, err := bs.PriceOneTouch(v, tau, x, 0, rebate, r, q, bs.RebateAtHit); err != bs.ErrBarrier {
+		t.Errorf("err = %v", err)
+	}
+}
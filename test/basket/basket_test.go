@@ -0,0 +1,144 @@
+package baskettest
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+const tau, r = 0.75, 0.03
+
+var (
+	spots = [2]float64{100, 50}
+	vols  = [2]float64{0.25, 0.4}
+	divs  = [2]float64{0.01, 0.02}
+)
+
+// basketMC returns the Monte Carlo price of the option on the basket over
+// n antithetic pairs of correlated lognormal paths, with its standard
+// error
+func basketMC(weights [2]float64, rho, k float64, o bs.OptionType, n int) (price, stderr float64) {
+
+	rng := rand.New(rand.NewSource(1))
+	df := math.Exp(-r * tau)
+
+	terminal := func(z0, z1 float64) float64 {
+		b := 0.0
+		for i, z := range [2]float64{z0, rho*z0 + math.Sqrt(1-rho*rho)*z1} {
+			drift := (r - divs[i] - vols[i]*vols[i]/2) * tau
+			b += weights[i] * spots[i] * math.Exp(drift+vols[i]*math.Sqrt(tau)*z)
+		}
+		switch o {
+		case bs.Call:
+			return math.Max(b-k, 0)
+		case bs.Put:
+			return math.Max(k-b, 0)
+		}
+		return math.Abs(b - k)
+	}
+
+	var sum, sumsq float64
+	for i := 0; i < n; i++ {
+		z0, z1 := rng.NormFloat64(), rng.NormFloat64()
+		p := (terminal(z0, z1) + terminal(-z0, -z1)) / 2
+		sum += p
+		sumsq += p * p
+	}
+
+	mean := sum / float64(n)
+	return df * mean, df * math.Sqrt((sumsq/float64(n)-mean*mean)/float64(n))
+}
+
+// kirk returns Kirk's approximation to the call on S0 - S1 - k
+func kirk(rho, k float64) float64 {
+
+	f0 := spots[0] * math.Exp((r-divs[0])*tau)
+	f1 := spots[1] * math.Exp((r-divs[1])*tau)
+	a := f1 / (f1 + k)
+	v := math.Sqrt(vols[0]*vols[0] - 2*rho*vols[0]*vols[1]*a + vols[1]*vols[1]*a*a)
+
+	// Black on the forward f0 against the strike f1 + k
+	return bs.BSPrice(v, tau, f0*math.Exp(-r*tau), f1+k, r, 0, bs.Call)
+}
+
+func Test_PriceBasketTwoAsset(t *testing.T) {
+
+	// within 4 standard errors of the Monte Carlo plus a 1% moment
+	// matching allowance for positive weights
+	for _, c := range []struct {
+		weights [2]float64
+		rho, k  float64
+		o       bs.OptionType
+	}{
+		{weights: [2]float64{0.5, 1}, rho: 0.6, k: 100, o: bs.Call},
+		{weights: [2]float64{0.5, 1}, rho: 0.6, k: 100, o: bs.Put},
+		{weights: [2]float64{1, 1}, rho: -0.3, k: 160, o: bs.Call},
+		{weights: [2]float64{0.2, 2}, rho: 0.9, k: 110, o: bs.Straddle},
+	} {
+		got, err := bs.PriceBasketTwoAsset(c.weights, spots, vols, c.rho, tau, c.k, r, divs, c.o)
+		mc, se := basketMC(c.weights, c.rho, c.k, c.o, 200000)
+		if err != nil || !(math.Abs(got-mc) <= 4*se+0.01*mc) {
+			t.Errorf("%+v: price %v, Monte Carlo %v +- %v, err = %v", c, got, mc, se, err)
+		}
+	}
+
+	// a single asset is Black Scholes
+	got, err := bs.PriceBasketTwoAsset([2]float64{2, 0}, spots, vols, 0.5, tau, 210, r, divs, bs.Call)
+	if want := 2 * bs.BSPrice(vols[0], tau, spots[0], 105, r, divs[0], bs.Call); err != nil || math.Abs(got-want) > 1e-12 {
+		t.Errorf("one asset: %v, want %v, err = %v", got, want, err)
+	}
+
+	// perfectly correlated assets of equal vol are one asset
+	same := [2]float64{0.3, 0.3}
+	sameDivs := [2]float64{0.01, 0.01}
+	got, _ = bs.PriceBasketTwoAsset([2]float64{1, 2}, spots, same, 1, tau, 190, r, sameDivs, bs.Put)
+	if want := bs.BSPrice(0.3, tau, 200, 190, r, 0.01, bs.Put); math.Abs(got-want) > 1e-10 {
+		t.Errorf("perfect correlation: %v, want %v", got, want)
+	}
+}
+
+func Test_PriceBasketSpread(t *testing.T) {
+
+	// on a spread the moment matching is rougher, about 9% low at zero
+	// correlation: within 12% of Kirk and of the Monte Carlo, which Kirk
+	// tracks to 2%
+	for _, rho := range []float64{0, 0.5, 0.8} {
+		k := 40.0
+		got, err := bs.PriceBasketTwoAsset([2]float64{1, -1}, spots, vols, rho, tau, k, r, divs, bs.Call)
+		mc, se := basketMC([2]float64{1, -1}, rho, k, bs.Call, 200000)
+		kp := kirk(rho, k)
+		if err != nil || !(math.Abs(got-kp) <= 0.12*kp && math.Abs(got-mc) <= 0.12*mc) {
+			t.Errorf("rho %v: price %v, Kirk %v, Monte Carlo %v +- %v, err = %v", rho, got, kp, mc, se, err)
+		}
+		if !(math.Abs(kp-mc) <= 4*se+0.02*mc) {
+			t.Errorf("rho %v: Kirk %v, Monte Carlo %v +- %v", rho, kp, mc, se)
+		}
+	}
+}
+
+func Test_PriceBasketErrors(t *testing.T) {
+
+	for _, c := range []struct {
+		weights [2]float64
+		rho     float64
+		vols    [2]float64
+		want    error
+	}{
+		{weights: [2]float64{1, 1}, rho: 1.1, vols: vols, want: bs.ErrBasket},
+		{weights: [2]float64{0, 0}, rho: 0, vols: vols, want: bs.ErrBasket},
+		{weights: [2]float64{1, math.NaN()}, rho: 0, vols: vols, want: bs.ErrBasket},
+		{weights: [2]float64{0.2, -1}, rho: 0, vols: vols, want: bs.ErrBasket},
+		{weights: [2]float64{1, 1}, rho: 0, vols: [2]float64{0.2, -0.1}, want: bs.ErrNegVol},
+	} {
+		p, err := bs.PriceBasketTwoAsset(c.weights, spots, c.vols, c.rho, tau, 100, r, divs, bs.Call)
+		if err != c.want || !math.IsNaN(p) {
+			t.Errorf("%+v: %v, %v", c, p, err)
+		}
+	}
+
+	if _, err := bs.PriceBasketTwoAsset([2]float64{1, 1}, spots, vols, 0, tau, -1, r, divs, bs.Call); err != bs.ErrNegStrike {
+		t.Errorf("negative strike: %v", err)
+	}
+}
@@ -0,0 +1,268 @@
+package batchtest
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+func makeInputs(n int) []bs.PriceParams {
+
+	types := []bs.OptionType{bs.Call, bs.Put, bs.Straddle}
+	inputs := make([]bs.PriceParams, n)
+
+	for i := range inputs {
+		inputs[i] = bs.PriceParams{
+			Vol:          0.1 + 0.4*float64(i%17)/17,
+			TimeToExpiry: 0.05 + float64(i%11)/11,
+			Underlying:   100,
+			Strike:       60 + 80*float64(i%29)/29,
+			Rate:         0.03,
+			Dividend:     0.01,
+			Type:         types[i%3],
+		}
+	}
+
+	return inputs
+}
+
+func Test_PriceInto(t *testing.T) {
+
+	const N = 1000
+	inputs := makeInputs(N)
+	inputs[7].Strike = -1
+	inputs[500].Type = bs.OptionType('z')
+
+	prices := make([]float64, N)
+	greeks := make([]bs.Greeks, N)
+
+	for _, err := range []error{
+		bs.PriceInto(prices, inputs),
+		bs.GreeksInto(greeks, inputs),
+	} {
+		m, ok := err.(bs.MultiError)
+		if !ok || len(m) != 2 || m[0].Index != 7 || m[1].Index != 500 {
+			t.Fatalf("err = %v", err)
+		}
+		if m[0].Err != bs.ErrNegStrike || m[1].Err != bs.ErrUnknownOptionType {
+			t.Errorf("errs = %v, %v", m[0].Err, m[1].Err)
+		}
+	}
+
+	for i := range inputs {
+		want, _ := bs.Price(&inputs[i])
+		g, _ := bs.PriceAndGreeks(&inputs[i])
+		if i == 7 || i == 500 {
+			if !math.IsNaN(prices[i]) || !math.IsNaN(greeks[i].Delta) {
+				t.Errorf("row %d: %v, %v", i, prices[i], greeks[i])
+			}
+			continue
+		}
+		if prices[i] != want || greeks[i] != g {
+			t.Errorf("row %d: %v, %v, want %v, %v", i, prices[i], greeks[i], want, g)
+		}
+	}
+
+	if err := bs.PriceInto(prices[1:], inputs); err != bs.ErrLengthMismatch {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func Test_PriceIntoAllocs(t *testing.T) {
+
+	inputs := makeInputs(1000)
+	prices := make([]float64, len(inputs))
+	greeks := make([]bs.Greeks, len(inputs))
+
+	if n := testing.AllocsPerRun(10, func() { bs.PriceInto(prices, inputs) }); n != 0 {
+		t.Errorf("PriceInto allocs = %v", n)
+	}
+	if n := testing.AllocsPerRun(10, func() { bs.GreeksInto(greeks, inputs) }); n != 0 {
+		t.Errorf("GreeksInto allocs = %v", n)
+	}
+}
+
+var poisoned = []int{0, 13, 499, 500, 999}
+
+func Test_BatchRun(t *testing.T) {
+
+	const N, workers = 1000, 4
+	errPoison := errors.New("poisoned")
+
+	isPoisoned := make(map[int]bool)
+	for _, i := range poisoned {
+		isPoisoned[i] = true
+	}
+
+	// a counting semaphore of the rows in flight
+	var mu sync.Mutex
+	var active, peak int
+	enter := func() {
+		mu.Lock()
+		if active++; active > peak {
+			peak = active
+		}
+		mu.Unlock()
+	}
+	leave := func() {
+		mu.Lock()
+		active--
+		mu.Unlock()
+	}
+
+	out := make([]int, N)
+	err := bs.Batch{Workers: workers}.Run(N, func(i int) error {
+		enter()
+		defer leave()
+		for j := 0; j < 1000; j++ {
+			out[i] += j % 3
+		}
+		if isPoisoned[i] {
+			return errPoison
+		}
+		out[i] += i
+		return nil
+	})
+
+	m, ok := err.(bs.MultiError)
+	if !ok || len(m) != len(poisoned) {
+		t.Fatalf("err = %v", err)
+	}
+	for j, e := range m {
+		if e.Index != poisoned[j] || e.Err != errPoison {
+			t.Errorf("error %d = %v", j, e)
+		}
+	}
+	for i, v := range out {
+		if want := 999 + i; !isPoisoned[i] && v != want {
+			t.Errorf("row %d = %v, want %v", i, v, want)
+		}
+	}
+	if !(peak >= 1 && peak <= workers) {
+		t.Errorf("peak workers = %v, bound %v", peak, workers)
+	}
+
+	if err := (bs.Batch{Workers: workers}).Run(N, func(int) error { return nil }); err != nil {
+		t.Errorf("err = %v", err)
+	}
+	if err := (bs.Batch{}).Run(0, func(int) error { return errPoison }); err != nil {
+		t.Errorf("empty batch: err = %v", err)
+	}
+}
+
+func Test_BatchWorkers(t *testing.T) {
+
+	const N = 1000
+
+	inputs := makeInputs(N)
+	for _, i := range poisoned {
+		inputs[i].Strike = -1
+	}
+
+	ivs := make([]bs.ImpliedVolParams, N)
+	v, k := make([]float64, N), make([]float64, N)
+	p := bs.Portfolio{Spot: 100, Rate: 0.03, Dividend: 0.01}
+	for i, in := range inputs {
+		ivs[i] = bs.ImpliedVolParams{
+			Premium:      bs.BSPrice(in.Vol, in.TimeToExpiry, in.Underlying, math.Abs(in.Strike), in.Rate, in.Dividend, in.Type),
+			TimeToExpiry: in.TimeToExpiry, Underlying: in.Underlying, Strike: in.Strike,
+			Rate: in.Rate, Dividend: in.Dividend, Type: in.Type,
+		}
+		v[i], k[i] = in.Vol, in.Strike
+		p.Positions = append(p.Positions, bs.Position{
+			Quantity: 2, Type: bs.Put, Strike: in.Strike, TimeToExpiry: 0.5, Vol: in.Vol,
+		})
+	}
+
+	// each batch function run on workers goroutines, its results and error
+	run := func(workers int) (map[string][]float64, map[string]error) {
+
+		b := bs.Batch{Workers: workers}
+
+		got, errs := make(map[string][]float64), make(map[string]error)
+
+		got["PriceInto"] = make([]float64, N)
+		errs["PriceInto"] = b.PriceInto(got["PriceInto"], inputs)
+
+		greeks := make([]bs.Greeks, N)
+		errs["GreeksInto"] = b.GreeksInto(greeks, inputs)
+		for _, g := range greeks {
+			got["GreeksInto"] = append(got["GreeksInto"], g.Delta)
+		}
+
+		got["ImpliedVolInto"] = make([]float64, N)
+		errs["ImpliedVolInto"] = b.ImpliedVolInto(got["ImpliedVolInto"], ivs)
+
+		got["PriceChain"] = make([]float64, N)
+		errs["PriceChain"] = b.PriceChain(got["PriceChain"], v, 0.5, 100, k, 0.03, 0.01, bs.Put)
+
+		greeks, errs["PositionGreeks"] = b.PositionGreeks(p)
+		for _, g := range greeks {
+			got["PositionGreeks"] = append(got["PositionGreeks"], g.Gamma)
+		}
+
+		return got, errs
+	}
+
+	want, _ := run(1)
+	got, errs := run(8)
+
+	for name, err := range errs {
+
+		m, ok := err.(bs.MultiError)
+		if !ok || len(m) != len(poisoned) {
+			t.Errorf("%s: err = %v", name, err)
+			continue
+		}
+		for j, e := range m {
+			if e.Index != poisoned[j] || e.Err != bs.ErrNegStrike {
+				t.Errorf("%s: error %d = %v", name, j, e)
+			}
+		}
+
+		for i, x := range got[name] {
+			y := want[name][i]
+			if x != y && !(math.IsNaN(x) && math.IsNaN(y)) {
+				t.Errorf("%s: row %d = %v, want %v", name, i, x, y)
+			}
+		}
+		for _, i := range poisoned {
+			if !math.IsNaN(got[name][i]) {
+				t.Errorf("%s: row %d = %v", name, i, got[name][i])
+			}
+		}
+	}
+}
+
+func Benchmark_PriceInto(b *testing.B) {
+
+	inputs := makeInputs(50000)
+	prices := make([]float64, len(inputs))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		bs.PriceInto(prices, inputs)
+	}
+
+	b.ReportMetric(float64(len(inputs)), "options/op")
+}
+
+func Benchmark_GreeksInto(b *testing.B) {
+
+	inputs := makeInputs(50000)
+	greeks := make([]bs.Greeks, len(inputs))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		bs.GreeksInto(greeks, inputs)
+	}
+
+	b.ReportMetric(float64(len(inputs)), "options/op")
+}
@@ -0,0 +1,138 @@
+package bigpricetest
+
+import (
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+	"github.com/uscott/go-blackscholes/bigprice"
+)
+
+func bigFloat(x float64) *big.Float {
+	return new(big.Float).SetPrec(bigprice.DefaultPrec).SetFloat64(x)
+}
+
+func Test_Constants(t *testing.T) {
+
+	sqrt2 := bigFloat(2)
+	sqrt2.Sqrt(sqrt2)
+
+	for _, c := range []struct {
+		name string
+		got  *big.Float
+		want string
+	}{
+		{"Pi", bigprice.Pi(bigprice.DefaultPrec), "3.14159265358979323846264338327950288419716939937510"},
+		{"Exp(1)", bigprice.Exp(bigFloat(1)), "2.71828182845904523536028747135266249775724709369995"},
+		{"Log(10)", bigprice.Log(bigFloat(10)), "2.30258509299404568401799145468436420760110148862877"},
+		// N(sqrt(2)) = (1 + erf(1)) / 2
+		{"NormCDF(Sqrt2)", bigprice.NormCDF(sqrt2), "0.921350396474857434670610317541304629648033498983151"},
+	} {
+		if got := c.got.Text('f', 60); !strings.HasPrefix(got, c.want) {
+			t.Errorf("%s = %s, want %s", c.name, got, c.want)
+		}
+	}
+}
+
+func Test_NormCDF(t *testing.T) {
+
+	eps := new(big.Float).SetMantExp(big.NewFloat(1), -int(bigprice.DefaultPrec)+2)
+
+	for x := -30.0; x <= 30; x += 0.75 {
+
+		p, m := bigprice.NormCDF(bigFloat(x)), bigprice.NormCDF(bigFloat(-x))
+		d := new(big.Float).Add(p, m)
+		if d.Sub(d, big.NewFloat(1)); d.Abs(d).Cmp(eps) > 0 {
+			t.Errorf("NormCDF(%v) + NormCDF(%v) - 1 = %v", x, -x, d)
+		}
+
+		// the float64 erfc argument x / sqrt(2) is rounded, which costs
+		// about x^2 ulps of relative accuracy in the lower tail
+		want, _ := p.Float64()
+		if got := bs.NormCDF(x); math.Abs(got-want) > 2e-16*(1+x*x)*want {
+			t.Errorf("bs.NormCDF(%v) = %v, want %v", x, got, want)
+		}
+	}
+}
+
+func price(t *testing.T, v, tau, x, k, r, q float64, o bs.OptionType) float64 {
+
+	p, err := bigprice.Price(v, tau, x, k, r, q, o, bigprice.DefaultPrec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, _ := p.Float64()
+	return f
+}
+
+func Test_PriceBenign(t *testing.T) {
+
+	const x, r, q = 100.0, 0.03, 0.01
+
+	for _, o := range []bs.OptionType{bs.Call, bs.Put, bs.Straddle} {
+		for _, v := range []float64{-0.1, 0, 0.05, 0.2, 0.6} {
+			for _, tau := range []float64{0, 0.05, 0.5, 2} {
+				for k := 0.0; k <= 140; k += 10 {
+					want := price(t, v, tau, x, k, r, q, o)
+					got := bs.BSPrice(v, tau, x, k, r, q, o)
+					if math.Abs(got-want) > 1e-15*math.Max(x, k) {
+						t.Errorf("Type = %c, Vol = %v, T = %v, Strike = %v: Price = %v, want %v",
+							o, v, tau, k, got, want)
+					}
+				}
+			}
+		}
+	}
+
+	for _, c := range []struct {
+		t, x, k float64
+		err     error
+	}{
+		{-1, 100, 100, bs.ErrNegTimeToExp},
+		{1, 100, -1, bs.ErrNegStrike},
+		{1, math.NaN(), 100, bigprice.ErrNonFinite},
+	} {
+		if _, err := bigprice.Price(0.2, c.t, c.x, c.k, 0, 0, bs.Call, 64); err != c.err {
+			t.Errorf("err = %v, want %v", err, c.err)
+		}
+	}
+}
+
+// Test_PriceExtreme records the float64 accuracy of BSPrice where the
+// price is tiny against the underlying. The absolute error stays at the
+// rounding level of the underlying but the relative error grows without
+// bound for far out of the money puts, whose price is a difference of
+// 1 - N(d) terms.
+func Test_PriceExtreme(t *testing.T) {
+
+	const x, r, q = 100.0, 0.03, 0.01
+
+	for _, c := range []struct {
+		v, t, k float64
+		o       bs.OptionType
+		relErr  float64
+	}{
+		{0.1, 0.25, 130, bs.Call, 1e-12},
+		{0.1, 0.25, 150, bs.Call, 1e-11},
+		{0.2, 1e-4, 101, bs.Call, 1e-10},
+		{0.001, 1, 100, bs.Straddle, 1e-14},
+		{0.2, 1e-4, 99, bs.Put, 1e-5},
+		{0.1, 0.25, 70, bs.Put, 0.05},
+		{0.1, 0.25, 50, bs.Put, 1},
+	} {
+		want := price(t, c.v, c.t, x, c.k, r, q, c.o)
+		got := bs.BSPrice(c.v, c.t, x, c.k, r, q, c.o)
+		rel := math.Abs(got-want) / want
+
+		t.Logf("Type = %c, Vol = %v, T = %v, Strike = %v: Price = %v, want %v, relative error %.2g",
+			c.o, c.v, c.t, c.k, got, want, rel)
+
+		if math.Abs(got-want) > 1e-15*x || rel > c.relErr {
+			t.Errorf("Type = %c, Vol = %v, T = %v, Strike = %v: Price = %v, want %v",
+				c.o, c.v, c.t, c.k, got, want)
+		}
+	}
+}
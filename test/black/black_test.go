@@ -0,0 +1,217 @@
+package blacktest
+
+import (
+	"math"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+var types = []bs.OptionType{bs.Call, bs.Put, bs.Straddle}
+
+func Test_NormalizedBlack(t *testing.T) {
+
+	const x, r, q = 100.0, 0.04, 0.015
+
+	for _, v := range []float64{0, 0.01, 0.2, 0.9} {
+		for _, tau := range []float64{0, 0.01, 0.5, 4} {
+			for _, k := range []float64{50, 90, 100, 110, 200} {
+				for _, o := range types {
+
+					f, df := x*math.Exp((r-q)*tau), math.Exp(-r*tau)
+
+					p, err := bs.Price(&bs.PriceParams{
+						Vol: v, TimeToExpiry: tau, Underlying: x, Strike: k, Rate: r, Dividend: q, Type: o,
+					})
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					nb := df * f * bs.NormalizedBlack(math.Log(f/k), v*math.Sqrt(tau), o)
+					if math.Abs(nb-p) > 1e-15*(f+k) {
+						t.Errorf("v = %v, t = %v, k = %v, %c: %v != %v", v, tau, k, o, nb, p)
+					}
+				}
+			}
+		}
+	}
+}
+
+func Test_NormalizedBlackSymmetry(t *testing.T) {
+
+	for _, s := range []float64{0, 0.05, 0.4, 2} {
+		for _, x := range []float64{-1.5, -0.2, 0, 0.3, 2} {
+
+			c := bs.NormalizedBlack(x, s, bs.Call)
+			p := bs.NormalizedBlack(x, s, bs.Put)
+			ex := math.Exp(-x)
+
+			// parity, c - p = 1 - exp(-x), and the reflection
+			// c(x, s) = exp(-x)*p(-x, s)
+			if math.Abs(c-p-(1-ex)) > 1e-15*(1+ex) {
+				t.Errorf("x = %v, s = %v: parity %v", x, s, c-p-(1-ex))
+			}
+			if pr := ex * bs.NormalizedBlack(-x, s, bs.Put); math.Abs(c-pr) > 1e-15*(1+ex) {
+				t.Errorf("x = %v, s = %v: call %v, reflected put %v", x, s, c, pr)
+			}
+			if st := bs.NormalizedBlack(x, s, bs.Straddle); math.Abs(st-c-p) > 1e-15*(1+ex) {
+				t.Errorf("x = %v, s = %v: straddle %v", x, s, st)
+			}
+		}
+	}
+
+	if !math.IsNaN(bs.NormalizedBlack(0, -0.1, bs.Call)) || !math.IsNaN(bs.NormalizedBlack(0, 0.1, 0)) {
+		t.Error("want NaN")
+	}
+}
+
+func Test_NormalizedBlackVega(t *testing.T) {
+
+	for _, s := range []float64{0.01, 0.3, 1.5} {
+		for _, x := range []float64{-1, -0.1, 0, 0.5} {
+			for _, o := range types {
+				h := 1e-6 * s
+				num := (bs.NormalizedBlack(x, s+h, o) - bs.NormalizedBlack(x, s-h, o)) / 2 / h
+				if vega := bs.NormalizedBlackVega(x, s, o); math.Abs(vega-num) > 1e-8 {
+					t.Errorf("x = %v, s = %v, %c: vega %v, want %v", x, s, o, vega, num)
+				}
+			}
+		}
+	}
+
+	if v := bs.NormalizedBlackVega(0, 0, bs.Call); v != bs.InvSqrt2PI {
+		t.Errorf("at the money zero vol vega = %v", v)
+	}
+	if v := bs.NormalizedBlackVega(0.1, 0, bs.Straddle); v != 0 {
+		t.Errorf("zero vol vega = %v", v)
+	}
+}
+
+func Test_PriceTotalVariance(t *testing.T) {
+
+	const x, r, q = 100.0, 0.03, 0.01
+
+	for _, v := range []float64{0, 0.1, 0.45} {
+		for _, tau := range []float64{0, 1e-6, 0.25, 2} {
+			for _, k := range []float64{0, 80, 100, 130} {
+				for _, o := range types {
+
+					dfr, dfq := math.Exp(-r*tau), math.Exp(-q*tau)
+
+					want, err := bs.Price(&bs.PriceParams{
+						Vol: v, TimeToExpiry: tau, Underlying: x, Strike: k, Rate: r, Dividend: q, Type: o,
+					})
+					if err != nil {
+						t.Fatal(err)
+					}
+					got, err := bs.PriceTotalVariance(v*v*tau, x, k, dfr, dfq, o)
+					if err != nil || math.Abs(got-want) > 1e-12 {
+						t.Errorf("v = %v, t = %v, k = %v, %c: %v != %v, err = %v", v, tau, k, o, got, want, err)
+					}
+
+					if v == 0 || tau < 1e-3 || k == 0 {
+						continue
+					}
+
+					// the variance is recovered where the price depends on it
+					w, err := bs.ImpliedTotalVariance(got, x, k, dfr, dfq, o)
+					p, _ := bs.PriceTotalVariance(w, x, k, dfr, dfq, o)
+					if err != nil || math.Abs(p-got) > 1e-12 {
+						t.Errorf("v = %v, t = %v, k = %v, %c: w = %v, err = %v", v, tau, k, o, w, err)
+					}
+					vega := bs.NormalizedBlackVega(math.Log(x*dfq/dfr/k), v*math.Sqrt(tau), o)
+					if vega > 1e-3 && math.Abs(w-v*v*tau) > 1e-12 {
+						t.Errorf("v = %v, t = %v, k = %v, %c: w = %v, want %v", v, tau, k, o, w, v*v*tau)
+					}
+				}
+			}
+		}
+	}
+
+	// only the total variance matters, so a short expiry at high vol
+	// prices as a long one at low vol with the same discount factors
+	a, _ := bs.PriceTotalVariance(0.8*0.8*0.01, x, 105, 0.99, 0.995, bs.Call)
+	b, _ := bs.PriceTotalVariance(0.08*0.08*1, x, 105, 0.99, 0.995, bs.Call)
+	if a != b {
+		t.Errorf("%v != %v", a, b)
+	}
+}
+
+func Test_ImpliedTotalVarianceErrors(t *testing.T) {
+
+	if _, err := bs.PriceTotalVariance(-0.01, 100, 100, 1, 1, bs.Call); err != bs.ErrNegVariance {
+		t.Errorf("err = %v", err)
+	}
+	if _, err := bs.PriceTotalVariance(0.01, 100, 100, 1.1, 1, bs.Call); err != bs.ErrDiscountFactor {
+		t.Errorf("err = %v", err)
+	}
+	if _, err := bs.ImpliedTotalVariance(-1, 100, 100, 1, 1, bs.Call); err != bs.ErrNegPremium {
+		t.Errorf("err = %v", err)
+	}
+	for _, p := range []float64{9, 110} {
+		if _, err := bs.ImpliedTotalVariance(p, 110, 100, 1, 1, bs.Call); err != bs.ErrArbitrage {
+			t.Errorf("premium %v: err = %v", p, err)
+		}
+	}
+	if w, err := bs.ImpliedTotalVariance(10, 110, 100, 1, 1, bs.Call); err != nil || w != 0 {
+		t.Errorf("intrinsic premium: w = %v, err = %v", w, err)
+	}
+}
+
+func Test_VarianceVega(t *testing.T) {
+
+	const x, r, q = 100.0, 0.02, 0.01
+
+	for _, v := range []float64{0.05, 0.25, 0.8} {
+		for _, tau := range []float64{0.05, 1, 3} {
+			for _, k := range []float64{70, 100, 125} {
+				for _, o := range types {
+
+					pars := &bs.PriceParams{Vol: v, TimeToExpiry: tau, Underlying: x, Strike: k, Rate: r, Dividend: q, Type: o}
+
+					vega, _ := bs.Vega(pars)
+					vv, err := bs.VarianceVega(pars)
+					if err != nil || math.Abs(vv-vega/2/v) > 1e-10*(1+vega/v) {
+						t.Errorf("v = %v, t = %v, k = %v, %c: variance vega %v, vega/2v %v", v, tau, k, o, vv, vega/2/v)
+					}
+					tv, _ := bs.TotalVarianceVega(pars)
+					if math.Abs(tv*tau-vv) > 1e-12*(1+vv) {
+						t.Errorf("v = %v, t = %v, k = %v, %c: total variance vega %v", v, tau, k, o, tv)
+					}
+
+					// central differences in v*v
+					h := 1e-5 * v * v
+					price := func(w float64) float64 {
+						return bs.BSPrice(math.Sqrt(w), tau, x, k, r, q, o)
+					}
+					if num := (price(v*v+h) - price(v*v-h)) / 2 / h; math.Abs(num-vv) > 1e-5*(1+vv) {
+						t.Errorf("v = %v, t = %v, k = %v, %c: variance vega %v, want %v", v, tau, k, o, vv, num)
+					}
+				}
+			}
+		}
+	}
+}
+
+func Test_VarianceVegaLimits(t *testing.T) {
+
+	const x, r, q, tau = 100.0, 0.02, 0.01, 0.5
+
+	for _, v := range []float64{0, 1e-10, 1e-4} {
+		vv := bs.BSVarianceVega(v, tau, x, 80, r, q, bs.Call)
+		tv := bs.BSTotalVarianceVega(v, tau, x, 120, r, q, bs.Put)
+		if vv != 0 || tv != 0 {
+			t.Errorf("v = %v: away from the money %v, %v", v, vv, tv)
+		}
+	}
+
+	if v := bs.BSTotalVarianceVega(0, tau, x, x, q, q, bs.Call); !math.IsInf(v, 1) {
+		t.Errorf("at the money zero vol %v", v)
+	}
+	if v := bs.BSVarianceVega(0.2, 0, x, 100, r, q, bs.Call); v != 0 {
+		t.Errorf("expired %v", v)
+	}
+	if _, err := bs.VarianceVega(&bs.PriceParams{Vol: -0.1, TimeToExpiry: tau, Underlying: x, Strike: 100, Type: bs.Call}); err != bs.ErrNegVol {
+		t.Errorf("err = %v", err)
+	}
+}
@@ -0,0 +1,297 @@
+package boundarytest
+
+import (
+	"math"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+const vol, tau, spot, r, q = 0.25, 0.5, 100.0, 0.05, 0.02
+
+var types = []bs.OptionType{bs.Call, bs.Put, bs.Straddle}
+
+var greeks = []struct {
+	name string
+	f    func(v, t, x, k, r, q float64, o bs.OptionType) float64
+}{
+	{"Price", bs.BSPrice},
+	{"Delta", bs.BSDelta},
+	{"Gamma", bs.BSGamma},
+	{"Vega", bs.BSVega},
+	{"Theta", bs.BSTheta},
+	{"Rho", bs.BSRho},
+	{"Epsilon", bs.BSEpsilon},
+}
+
+// atmStrike returns the strike whose discounted value equals the
+// discounted spot exactly
+func atmStrike() float64 {
+	k := spot * math.Exp((r-q)*tau)
+	for i := 0; i < 100; i++ {
+		c, p := bs.Intrinsic(tau, spot, k, r, q, bs.Call), bs.Intrinsic(tau, spot, k, r, q, bs.Put)
+		switch {
+		case c > 0:
+			k = math.Nextafter(k, math.Inf(1))
+		case p > 0:
+			k = math.Nextafter(k, 0)
+		default:
+			return k
+		}
+	}
+	panic("no at the money strike")
+}
+
+func Test_BoundaryLimits(t *testing.T) {
+
+	const eps = 1e-9
+	katm := atmStrike()
+
+	// each boundary and a point approaching it
+	type point struct{ v, t, x, k float64 }
+	boundaries := []struct {
+		name      string
+		at, limit point
+	}{
+		{"zero spot", point{vol, tau, 0, 100}, point{vol, tau, eps, 100}},
+		{"zero strike", point{vol, tau, spot, 0}, point{vol, tau, spot, eps}},
+		{"zero vol in the money", point{0, tau, spot, 80}, point{1e-6, tau, spot, 80}},
+		{"zero vol out of the money", point{0, tau, spot, 120}, point{1e-6, tau, spot, 120}},
+		{"zero vol at the money", point{0, tau, spot, katm}, point{eps, tau, spot, katm}},
+		{"expiry", point{vol, 0, spot, 90}, point{vol, 1e-9, spot, 90}},
+	}
+
+	for _, b := range boundaries {
+		for _, g := range greeks {
+			for _, o := range types {
+
+				got := g.f(b.at.v, b.at.t, b.at.x, b.at.k, r, q, o)
+				lim := g.f(b.limit.v, b.limit.t, b.limit.x, b.limit.k, r, q, o)
+
+				ok := math.Abs(got-lim) <= 1e-5*math.Max(1, math.Abs(got))
+				if math.IsInf(got, 1) {
+					ok = lim > 1e6
+				}
+				if !ok {
+					t.Errorf("%s, %s, %v: %v, limit %v", b.name, g.name, o, got, lim)
+				}
+			}
+
+			// the straddle is the call plus the put
+			c := g.f(b.at.v, b.at.t, b.at.x, b.at.k, r, q, bs.Call)
+			p := g.f(b.at.v, b.at.t, b.at.x, b.at.k, r, q, bs.Put)
+			s := g.f(b.at.v, b.at.t, b.at.x, b.at.k, r, q, bs.Straddle)
+			if s != c+p && !(math.Abs(s-(c+p)) <= 1e-14*math.Max(1, math.Abs(s))) {
+				t.Errorf("%s, %s: straddle %v, call + put %v", b.name, g.name, s, c+p)
+			}
+		}
+	}
+}
+
+func Test_BoundaryClosedForms(t *testing.T) {
+
+	katm := atmStrike()
+	dfq, dfr := math.Exp(-q*tau), math.Exp(-r*tau)
+	xq, kr := spot*dfq, katm*dfr
+
+	for _, c := range []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"ZeroVolBSDelta call", bs.ZeroVolBSDelta(tau, spot, katm, r, q, bs.Call), dfq / 2},
+		{"ZeroVolBSDelta put", bs.ZeroVolBSDelta(tau, spot, katm, r, q, bs.Put), -dfq / 2},
+		{"ZeroVolBSDelta straddle", bs.ZeroVolBSDelta(tau, spot, katm, r, q, bs.Straddle), 0},
+		{"ZeroVolBSTheta call", bs.ZeroVolBSTheta(tau, spot, katm, r, q, bs.Call), (q*xq - r*kr) / 2},
+		{"ZeroVolBSTheta straddle", bs.ZeroVolBSTheta(tau, spot, katm, r, q, bs.Straddle), 0},
+		{"ZeroVolBSRho call", bs.ZeroVolBSRho(tau, spot, katm, r, q, bs.Call), tau * kr / 2},
+		{"ZeroVolBSRho put", bs.ZeroVolBSRho(tau, spot, 120, r, q, bs.Put), -tau * 120 * dfr},
+		{"ZeroUnderlyingBSRho put", bs.ZeroUnderlyingBSRho(tau, 100, r, bs.Put), -tau * 100 * dfr},
+		{"ZeroStrikeBSRho call", bs.ZeroStrikeBSRho(bs.Call), 0},
+		{"ZeroVolBSEpsilon call", bs.ZeroVolBSEpsilon(tau, spot, katm, r, q, bs.Call), -tau * xq / 2},
+		{"ZeroVolBSEpsilon put", bs.ZeroVolBSEpsilon(tau, spot, 120, r, q, bs.Put), tau * xq},
+		{"ZeroStrikeBSEpsilon call", bs.ZeroStrikeBSEpsilon(tau, spot, q, bs.Call), -tau * xq},
+		{"ZeroUnderlyingBSEpsilon put", bs.ZeroUnderlyingBSEpsilon(bs.Put), 0},
+		{"ZeroVolBSVanna call", bs.ZeroVolBSVanna(tau, spot, katm, r, q, bs.Call), dfq * math.Sqrt(tau/2/math.Pi) / 2},
+		{"ZeroVolBSVanna put", bs.ZeroVolBSVanna(tau, spot, 120, r, q, bs.Put), 0},
+		{"ZeroStrikeBSGamma", bs.ZeroStrikeBSGamma(bs.Straddle), 0},
+		{"ZeroUnderlyingBSVega", bs.ZeroUnderlyingBSVega(bs.Put), 0},
+	} {
+		if math.Abs(c.got-c.want) > 1e-14*math.Max(1, math.Abs(c.want)) {
+			t.Errorf("%s = %v, want %v", c.name, c.got, c.want)
+		}
+	}
+
+	for _, f := range []func(bs.OptionType) float64{
+		bs.ZeroStrikeBSGamma, bs.ZeroUnderlyingBSGamma, bs.ZeroStrikeBSVega, bs.ZeroUnderlyingBSVega, bs.ZeroStrikeBSRho,
+		bs.ZeroUnderlyingBSEpsilon,
+	} {
+		if !math.IsNaN(f(bs.OptionType('x'))) {
+			t.Error("unknown option type is not NaN")
+		}
+	}
+
+	// the forward greeks follow the same conventions
+	f := spot * math.Exp((r-q)*tau)
+	for _, o := range types {
+		g, err := bs.GreeksFromForward(0, tau, f, f, dfr, o)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := bs.ZeroVolBSDelta(0, f, f, 0, 0, o) * dfr
+		if g.Delta != want || !math.IsInf(g.Gamma, 1) || g.Vega != dfr*bs.AtmApprox(1, tau, f, 0, o) {
+			t.Errorf("%v: GreeksFromForward = %+v", o, g)
+		}
+	}
+
+	// rho against a central difference in the rate
+	const h = 1e-6
+	for _, o := range types {
+		for _, k := range []float64{80, 100, 120} {
+			num := (bs.BSPrice(vol, tau, spot, k, r+h, q, o) - bs.BSPrice(vol, tau, spot, k, r-h, q, o)) / 2 / h
+			if rho := bs.BSRho(vol, tau, spot, k, r, q, o); math.Abs(rho-num) > 1e-6*math.Max(1, math.Abs(rho)) {
+				t.Errorf("%v, strike %v: BSRho = %v, want %v", o, k, rho, num)
+			}
+			pars := &bs.PriceParams{Vol: vol, TimeToExpiry: tau, Underlying: spot, Strike: k, Rate: r, Dividend: q, Type: o}
+			if rho, err := bs.Rho(pars); err != nil || rho != bs.BSRho(vol, tau, spot, k, r, q, o) {
+				t.Errorf("%v, strike %v: Rho = %v, %v", o, k, rho, err)
+			}
+		}
+	}
+
+	// epsilon against a central difference in the dividend yield
+	for _, o := range types {
+		for _, k := range []float64{80, 100, 120} {
+			num := (bs.BSPrice(vol, tau, spot, k, r, q+h, o) - bs.BSPrice(vol, tau, spot, k, r, q-h, o)) / 2 / h
+			if eps := bs.BSEpsilon(vol, tau, spot, k, r, q, o); math.Abs(eps-num) > 1e-6*math.Max(1, math.Abs(eps)) {
+				t.Errorf("%v, strike %v: BSEpsilon = %v, want %v", o, k, eps, num)
+			}
+			pars := &bs.PriceParams{Vol: vol, TimeToExpiry: tau, Underlying: spot, Strike: k, Rate: r, Dividend: q, Type: o}
+			if eps, err := bs.Epsilon(pars); err != nil || eps != bs.BSEpsilon(vol, tau, spot, k, r, q, o) {
+				t.Errorf("%v, strike %v: Epsilon = %v, %v", o, k, eps, err)
+			}
+		}
+	}
+
+	// vanna against a central difference of delta in the vol, odd in the
+	// vol like vega
+	for _, o := range types {
+		for _, k := range []float64{80, 100, 120} {
+			num := (bs.BSDelta(vol+h, tau, spot, k, r, q, o) - bs.BSDelta(vol-h, tau, spot, k, r, q, o)) / 2 / h
+			vanna := bs.BSVanna(vol, tau, spot, k, r, q, o)
+			if math.Abs(vanna-num) > 1e-6*math.Max(1, math.Abs(vanna)) {
+				t.Errorf("%v, strike %v: BSVanna = %v, want %v", o, k, vanna, num)
+			}
+			if neg := bs.BSVanna(-vol, tau, spot, k, r, q, o); neg != -vanna {
+				t.Errorf("%v, strike %v: BSVanna at -vol = %v, want %v", o, k, neg, -vanna)
+			}
+			pars := &bs.PriceParams{Vol: vol, TimeToExpiry: tau, Underlying: spot, Strike: k, Rate: r, Dividend: q, Type: o}
+			if v, err := bs.Vanna(pars); err != nil || v != vanna {
+				t.Errorf("%v, strike %v: Vanna = %v, %v", o, k, v, err)
+			}
+		}
+	}
+
+	// vanna is singular near the money as the vol falls to 0, so its zero
+	// vol limit at the money is approached from a vol large enough for
+	// the rounding of the strike not to show
+	for _, o := range types {
+		zero := bs.BSVanna(0, tau, spot, katm, r, q, o)
+		if lim := bs.BSVanna(1e-4, tau, spot, katm, r, q, o); math.Abs(zero-lim) > 1e-6*math.Abs(zero) {
+			t.Errorf("%v: zero vol vanna %v, limit %v", o, zero, lim)
+		}
+		for _, at := range [][4]float64{{vol, 0, spot, 90}, {vol, tau, 0, 100}, {vol, tau, spot, 0}, {0, tau, spot, 120}} {
+			if v := bs.BSVanna(at[0], at[1], at[2], at[3], r, q, o); v != 0 {
+				t.Errorf("%v, %v: vanna %v, want 0", o, at, v)
+			}
+		}
+	}
+
+	pars := &bs.PriceParams{Vol: vol, TimeToExpiry: tau, Underlying: spot, Strike: -1, Rate: r, Dividend: q, Type: bs.Call}
+	if eps, err := bs.Epsilon(pars); err == nil || !math.IsNaN(eps) {
+		t.Errorf("negative strike: Epsilon = %v, %v", eps, err)
+	}
+	if vanna, err := bs.Vanna(pars); err == nil || !math.IsNaN(vanna) {
+		t.Errorf("negative strike: Vanna = %v, %v", vanna, err)
+	}
+}
+
+func Test_ZeroTime(t *testing.T) {
+
+	const itm, otm = 90.0, 110.0
+
+	for _, c := range []struct {
+		k     float64
+		o     bs.OptionType
+		price float64
+		delta float64
+		theta float64
+	}{
+		{k: itm, o: bs.Call, price: 10, delta: 1, theta: q*spot - r*itm},
+		{k: spot, o: bs.Call, price: 0, delta: 0.5, theta: math.Inf(-1)},
+		{k: otm, o: bs.Call, price: 0, delta: 0, theta: 0},
+		{k: otm, o: bs.Put, price: 10, delta: -1, theta: r*otm - q*spot},
+		{k: spot, o: bs.Put, price: 0, delta: -0.5, theta: math.Inf(-1)},
+		{k: itm, o: bs.Put, price: 0, delta: 0, theta: 0},
+		{k: itm, o: bs.Straddle, price: 10, delta: 1, theta: q*spot - r*itm},
+		{k: spot, o: bs.Straddle, price: 0, delta: 0, theta: math.Inf(-1)},
+		{k: otm, o: bs.Straddle, price: 10, delta: -1, theta: r*otm - q*spot},
+	} {
+
+		gamma := 0.0
+		if c.k == spot {
+			gamma = math.Inf(1)
+		}
+
+		zero := []float64{
+			bs.PriceZeroTime(spot, c.k, c.o),
+			bs.DeltaZeroTime(spot, c.k, c.o),
+			bs.GammaZeroTime(spot, c.k, c.o),
+			bs.VegaZeroTime(c.o),
+			bs.ThetaZeroTime(vol, spot, c.k, r, q, c.o),
+		}
+		want := []float64{c.price, c.delta, gamma, 0, c.theta}
+
+		for i, g := range greeks[:5] {
+
+			if math.Abs(zero[i]-want[i]) > 1e-12 && zero[i] != want[i] {
+				t.Errorf("%v, strike %v: %sZeroTime = %v, want %v", c.o, c.k, g.name, zero[i], want[i])
+			}
+
+			// the BS functions route t == 0 through the ZeroTime forms
+			if got := g.f(vol, 0, spot, c.k, r, q, c.o); got != zero[i] {
+				t.Errorf("%v, strike %v: BS%s at expiry = %v, want %v", c.o, c.k, g.name, got, zero[i])
+			}
+
+			// and they are the limits as t falls to 0
+			lim := g.f(vol, bs.TimeFloor, spot, c.k, r, q, c.o)
+			ok := math.Abs(lim-zero[i]) <= 1e-3*math.Max(1, math.Abs(zero[i]))
+			switch {
+			case math.IsInf(zero[i], 1):
+				ok = lim > 1e3
+			case math.IsInf(zero[i], -1):
+				ok = lim < -1e3
+			}
+			if !ok {
+				t.Errorf("%v, strike %v: %s at expiry %v, limit %v", c.o, c.k, g.name, zero[i], lim)
+			}
+		}
+
+		// at zero vol the theta at the money is the halved carry
+		if c.k == spot {
+			if th := bs.BSTheta(0, 0, spot, spot, r, q, c.o); th != bs.ZeroVolBSTheta(0, spot, spot, r, q, c.o) {
+				t.Errorf("%v: zero vol theta at expiry %v", c.o, th)
+			}
+		}
+	}
+
+	o := bs.OptionType('x')
+	for _, v := range []float64{
+		bs.PriceZeroTime(spot, 90, o), bs.DeltaZeroTime(spot, 90, o), bs.GammaZeroTime(spot, 90, o),
+		bs.VegaZeroTime(o), bs.ThetaZeroTime(vol, spot, spot, r, q, o),
+	} {
+		if !math.IsNaN(v) {
+			t.Error("unknown option type is not NaN")
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package boundstest
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+func Test_PriceBounds(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(743))
+	types := []bs.OptionType{bs.Call, bs.Put, bs.Straddle}
+
+	for n := 0; n < 20000; n++ {
+
+		tau := math.Pow(10, -4+5*rng.Float64())
+		x := math.Pow(10, 4*rng.Float64()-1)
+		k := x * math.Exp(2*rng.NormFloat64())
+		r, q := 0.2*rng.Float64()-0.05, 0.2*rng.Float64()-0.05
+		o := types[n%3]
+
+		lo, hi := bs.PriceLowerBound(tau, x, k, r, q, o), bs.PriceUpperBound(tau, x, k, r, q, o)
+		if !(lo >= 0 && lo <= hi) {
+			t.Fatalf("%c %v %v %v: bounds %v, %v", o, tau, x, k, lo, hi)
+		}
+
+		tol := 1e-12 * hi
+		for _, v := range []float64{0, 1e-3, 0.2 * rng.Float64(), 2 * rng.Float64(), 50, 1e4} {
+			p := bs.BSPrice(v, tau, x, k, r, q, o)
+			if !(p >= lo-tol && p <= hi+tol) {
+				t.Errorf("%c v=%v t=%v x=%v k=%v: price %v outside %v, %v", o, v, tau, x, k, p, lo, hi)
+			}
+			if c, _ := bs.ClampToBounds(p, tau, x, k, r, q, o); math.Abs(c-p) > tol {
+				t.Errorf("%c: clamp %v to %v", o, p, c)
+			}
+		}
+
+		// the bounds are the zero vol price and the limit in vol
+		if p := bs.BSPrice(0, tau, x, k, r, q, o); p != lo {
+			t.Errorf("%c: zero vol price %v, lower bound %v", o, p, lo)
+		}
+	}
+}
+
+func Test_ClampToBounds(t *testing.T) {
+
+	const tau, x, k, r, q = 0.5, 100.0, 90.0, 0.05, 0.01
+
+	lo := bs.PriceLowerBound(tau, x, k, r, q, bs.Call)
+	hi := bs.PriceUpperBound(tau, x, k, r, q, bs.Call)
+	if want := x*math.Exp(-q*tau) - k*math.Exp(-r*tau); math.Abs(lo-want) > 1e-12 || hi != x*math.Exp(-q*tau) {
+		t.Errorf("bounds %v, %v", lo, hi)
+	}
+	if p, w := bs.PriceUpperBound(tau, x, k, r, q, bs.Straddle), bs.PriceUpperBound(tau, x, k, r, q, bs.Put); p != hi+w || w != k*math.Exp(-r*tau) {
+		t.Errorf("straddle, put upper bounds %v, %v", p, w)
+	}
+
+	for _, c := range []struct {
+		premium, want float64
+		clamped       bool
+	}{
+		{premium: lo - 1, want: lo, clamped: true},
+		{premium: lo, want: lo},
+		{premium: 20, want: 20},
+		{premium: hi, want: hi},
+		{premium: hi + 1, want: hi, clamped: true},
+	} {
+		if p, clamped := bs.ClampToBounds(c.premium, tau, x, k, r, q, bs.Call); p != c.want || clamped != c.clamped {
+			t.Errorf("clamp %v = %v, %v", c.premium, p, clamped)
+		}
+	}
+
+	if p, clamped := bs.ClampToBounds(math.NaN(), tau, x, k, r, q, bs.Call); !math.IsNaN(p) || clamped {
+		t.Errorf("NaN premium: %v, %v", p, clamped)
+	}
+	if p := bs.PriceLowerBound(tau, x, -k, r, q, bs.Call); !math.IsNaN(p) {
+		t.Errorf("negative strike: %v", p)
+	}
+	if p := bs.PriceUpperBound(tau, x, k, r, q, bs.OptionType('z')); !math.IsNaN(p) {
+		t.Errorf("bad type: %v", p)
+	}
+
+	// implied vols reject premia at the upper bound up front
+	if _, err := bs.ImpliedVol(&bs.ImpliedVolParams{
+		Premium: hi, TimeToExpiry: tau, Underlying: x, Strike: k, Rate: r, Dividend: q, Type: bs.Call,
+	}); err != bs.ErrArbitrage {
+		t.Errorf("ImpliedVol err = %v", err)
+	}
+	s, _ := bs.NewSolver(bs.SolverConfig{})
+	if _, err := s.ImpliedVol(hi+1, tau, x, k, r, q, bs.Call); err != bs.ErrArbitrage {
+		t.Errorf("Solver err = %v", err)
+	}
+}
@@ -0,0 +1,163 @@
+package breakeventest
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+func Test_BreakevenVol(t *testing.T) {
+
+	const v, tau, x = 0.3, 0.25, 100.0
+
+	// without carry theta is all gamma decay
+	for _, o := range []bs.OptionType{bs.Call, bs.Put, bs.Straddle} {
+		for _, k := range []float64{80, 100, 125} {
+			if b, err := bs.BreakevenVol(v, tau, x, k, 0, 0, o); err != nil || math.Abs(b-v) > 1e-12 {
+				t.Errorf("%c %v: breakeven = %v, err = %v", o, k, b, err)
+			}
+		}
+	}
+
+	// at the money forward the carry terms move it slightly
+	r, q := 0.05, 0.02
+	k := x * math.Exp((r-q)*tau)
+	for _, o := range []bs.OptionType{bs.Call, bs.Put} {
+
+		b, err := bs.BreakevenVol(v, tau, x, k, r, q, o)
+		if err != nil || math.Abs(b-v) > 0.025 {
+			t.Errorf("%c: breakeven = %v, err = %v", o, b, err)
+		}
+
+		// against the finite difference theta
+		g := bs.BSGreeks(v, tau, x, k, r, q, o)
+		theta := bs.BSThetaNum(v, tau, x, k, r, q, o, 1e-5)
+		if want := math.Sqrt(-2 * theta / (g.Gamma * x * x)); math.Abs(b-want) > 1e-7 {
+			t.Errorf("%c: breakeven = %v, want %v", o, b, want)
+		}
+
+		m, err := bs.BreakevenMove(v, tau, x, k, r, q, o)
+		if want := x * b / math.Sqrt(365); err != nil || math.Abs(m-want) > 1e-12 {
+			t.Errorf("%c: move = %v, want %v, err = %v", o, m, want, err)
+		}
+	}
+}
+
+func Test_BreakevenVolErrors(t *testing.T) {
+
+	// zero gamma far in the wings, at zero vol and at expiry
+	for _, c := range [][3]float64{{0.3, 0.25, 1e6}, {0, 0.25, 120}, {0.3, 0, 120}} {
+		_, err := bs.BreakevenVol(c[0], c[1], 100, c[2], 0.01, 0, bs.Call)
+		if e, ok := err.(*bs.BreakevenError); !ok || e.Gamma != 0 {
+			t.Errorf("%v: err = %v", c, err)
+		}
+	}
+
+	// a deep in the money put at a high rate gains with time
+	_, err := bs.BreakevenVol(0.1, 1, 50, 100, 0.1, 0, bs.Put)
+	if e, ok := err.(*bs.BreakevenError); !ok || !(e.Theta > 0) {
+		t.Errorf("err = %v", err)
+	}
+
+	if _, err := bs.BreakevenVol(-0.1, 1, 100, 100, 0, 0, bs.Call); err != bs.ErrNegVol {
+		t.Errorf("err = %v", err)
+	}
+	if _, err := bs.BreakevenMove(0.2, -1, 100, 100, 0, 0, bs.Call); err != bs.ErrNegTimeToExp {
+		t.Errorf("err = %v", err)
+	}
+}
+
+// hedgeSim returns the mean, standard error of the mean and standard
+// deviation of the discounted P&L of a long option bought at vi and delta
+// hedged at vi every dt over the horizon h, under risk neutral paths at
+// the realized vol vr
+func hedgeSim(vi, vr, h, tau, x, k, r, q float64, o bs.OptionType, dt float64, paths int) (mean, se, sd float64) {
+
+	steps := int(math.Round(h / dt))
+	dt = h / float64(steps)
+	rng := rand.New(rand.NewSource(1))
+	drift, diff := (r-q-vr*vr/2)*dt, vr*math.Sqrt(dt)
+
+	sum, sum2 := 0.0, 0.0
+	for n := 0; n < paths; n++ {
+
+		s, pnl := x, 0.0
+		g := bs.BSGreeks(vi, tau, s, k, r, q, o)
+		for i := 0; i < steps; i++ {
+			t0, t1 := float64(i)*dt, float64(i+1)*dt
+			s1 := s * math.Exp(drift+diff*rng.NormFloat64())
+			g1 := bs.BSGreeks(vi, tau-t1, s1, k, r, q, o)
+			pnl += math.Exp(-r*t1)*g1.Price - math.Exp(-r*t0)*g.Price -
+				g.Delta*(math.Exp(-r*t1+q*dt)*s1-math.Exp(-r*t0)*s)
+			s, g = s1, g1
+		}
+		sum += pnl
+		sum2 += pnl * pnl
+	}
+
+	mean = sum / float64(paths)
+	sd = math.Sqrt(sum2/float64(paths) - mean*mean)
+	return mean, sd / math.Sqrt(float64(paths)), sd
+}
+
+func Test_ExpectedGammaPnL(t *testing.T) {
+
+	const x, k, r, q = 100.0, 100.0, 0.03, 0.01
+
+	for _, c := range []struct {
+		vi, vr, h, tau float64
+		o              bs.OptionType
+	}{
+		{vi: 0.2, vr: 0.3, h: 0.1, tau: 1, o: bs.Call},
+		{vi: 0.3, vr: 0.2, h: 0.1, tau: 0.5, o: bs.Put},
+		{vi: 0.25, vr: 0.35, h: 0.05, tau: 0.25, o: bs.Straddle},
+	} {
+		mean, _, err := bs.ExpectedGammaPnL(c.vi, c.vr, c.h, c.vi, c.tau, x, k, r, q, c.o, 4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sim, se, _ := hedgeSim(c.vi, c.vr, c.h, c.tau, x, k, r, q, c.o, 1/bs.DaysPerYear/4, 4000)
+		if math.Abs(mean-sim) > 4*se {
+			t.Errorf("%+v: mean %v, simulated %v +/- %v", c, mean, sim, se)
+		}
+	}
+}
+
+func Test_GammaPnLStdev(t *testing.T) {
+
+	const v, h, tau, x, k, r, q = 0.2, 0.1, 1.0, 100.0, 100.0, 0.03, 0.01
+
+	prev := math.Inf(1)
+	for _, perDay := range []float64{0.25, 1, 4} {
+
+		mean, sd, err := bs.ExpectedGammaPnL(v, v, h, v, tau, x, k, r, q, bs.Call, perDay)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mean != 0 || !(sd < prev) {
+			t.Errorf("%v a day: mean %v, stdev %v after %v", perDay, mean, sd, prev)
+		}
+		prev = sd
+
+		// with no vol edge the P&L is all rebalancing error
+		_, _, sim := hedgeSim(v, v, h, tau, x, k, r, q, bs.Call, 1/bs.DaysPerYear/perDay, 4000)
+		if math.Abs(sd-sim) > 0.1*sim {
+			t.Errorf("%v a day: stdev %v, simulated %v", perDay, sd, sim)
+		}
+	}
+
+	for _, c := range []struct {
+		h, perDay float64
+		err       error
+	}{
+		{h: -0.1, perDay: 1, err: bs.ErrHorizon},
+		{h: 2, perDay: 1, err: bs.ErrHorizon},
+		{h: 0.1, perDay: 0, err: bs.ErrRebalance},
+	} {
+		if _, _, err := bs.ExpectedGammaPnL(v, v, c.h, v, tau, x, k, r, q, bs.Call, c.perDay); err != c.err {
+			t.Errorf("%+v: %v", c, err)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package bsjsontest
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+	"github.com/uscott/go-blackscholes/bsjson"
+)
+
+func roundTrip(t *testing.T, in, out interface{}) string {
+
+	b, err := json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(b, out); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(reflect.ValueOf(out).Elem().Interface(), in) {
+		t.Errorf("round trip of %s = %+v, want %+v", b, out, in)
+	}
+
+	return string(b)
+}
+
+func Test_RoundTrip(t *testing.T) {
+
+	tol, maxit := 1e-10, 50
+
+	s := roundTrip(t, bsjson.PriceRequest{
+		Vol: 0.2, TimeToExpiry: 0.5, Underlying: 100, Strike: 110, Type: "call",
+	}, new(bsjson.PriceRequest))
+
+	if want := `{"vol":0.2,"timeToExpiry":0.5,"underlying":100,"strike":110,"type":"call"}`; s != want {
+		t.Errorf("PriceRequest = %s, want %s", s, want)
+	}
+
+	roundTrip(t, bsjson.GreeksRequest{
+		Vol: 0.2, TimeToExpiry: 0.5, Underlying: 100, Strike: 110,
+		Rate: 0.01, Dividend: 0.02, Type: "put",
+	}, new(bsjson.GreeksRequest))
+
+	roundTrip(t, bsjson.ImpliedVolRequest{
+		Premium: 4.2, TimeToExpiry: 0.5, Underlying: 100, Strike: 110,
+		Type: "straddle", Tol: &tol, MaxIt: &maxit,
+	}, new(bsjson.ImpliedVolRequest))
+
+	price, vol := bsjson.Number(3.5), bsjson.Number(0.25)
+
+	roundTrip(t, bsjson.PriceResponse{Price: &price}, new(bsjson.PriceResponse))
+
+	s = roundTrip(t, bsjson.GreeksResponse{Error: &bsjson.Error{
+		Code: bsjson.CodeInvalidParameter, Message: "Negative strike", Param: "strike",
+	}}, new(bsjson.GreeksResponse))
+
+	want := `{"error":{"code":"invalid_parameter","message":"Negative strike","param":"strike"}}`
+	if s != want {
+		t.Errorf("GreeksResponse = %s, want %s", s, want)
+	}
+
+	roundTrip(t, bsjson.ImpliedVolResponse{Vol: &vol}, new(bsjson.ImpliedVolResponse))
+}
+
+func Test_Handle(t *testing.T) {
+
+	var req bsjson.PriceRequest
+	if err := json.Unmarshal([]byte(
+		`{"vol":0.2,"timeToExpiry":0.5,"underlying":100,"strike":110,"type":"put"}`,
+	), &req); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := bsjson.HandlePrice(req)
+	if want := bs.BSPrice(0.2, 0.5, 100, 110, 0, 0, bs.Put); resp.Error != nil || float64(*resp.Price) != want {
+		t.Errorf("HandlePrice = %+v, want %v", resp, want)
+	}
+
+	g := bsjson.HandleGreeks(bsjson.GreeksRequest(req))
+	want := bs.BSGreeks(0.2, 0.5, 100, 110, 0, 0, bs.Put)
+	if g.Error != nil || float64(*g.Delta) != want.Delta || float64(*g.Theta) != want.Theta ||
+		float64(*g.Rho) != want.Rho {
+		t.Errorf("HandleGreeks = %+v, want %+v", g, want)
+	}
+
+	iv := bsjson.HandleImpliedVol(bsjson.ImpliedVolRequest{
+		Premium: float64(*resp.Price), TimeToExpiry: 0.5, Underlying: 100, Strike: 110, Type: "p",
+	})
+	if iv.Error != nil || math.Abs(float64(*iv.Vol)-0.2) > 1e-8 {
+		t.Errorf("HandleImpliedVol = %+v", iv)
+	}
+
+	cases := []struct {
+		req   bsjson.PriceRequest
+		code  string
+		param string
+	}{
+		{bsjson.PriceRequest{Vol: 0.2, TimeToExpiry: 1, Underlying: 100, Strike: -1, Type: "c"},
+			bsjson.CodeInvalidParameter, "strike"},
+		{bsjson.PriceRequest{Vol: 0.2, TimeToExpiry: -1, Underlying: 100, Strike: 1, Type: "c"},
+			bsjson.CodeInvalidParameter, "timeToExpiry"},
+		{bsjson.PriceRequest{Vol: 0.2, TimeToExpiry: 1, Underlying: 100, Strike: 1},
+			bsjson.CodeInvalidParameter, "type"},
+		{bsjson.PriceRequest{Vol: -0.2, TimeToExpiry: 1, Underlying: 100, Strike: 100, Type: "c"},
+			bsjson.CodeInvalidParameter, "vol"},
+		{bsjson.PriceRequest{Vol: 0.2, TimeToExpiry: 1, Underlying: 100, Strike: 100, Rate: 900, Type: "c"},
+			bsjson.CodeInvalidParameter, "rate"},
+		{bsjson.PriceRequest{Vol: 0.2, TimeToExpiry: 1, Underlying: 100, Strike: 100, Dividend: -900, Type: "c"},
+			bsjson.CodeInvalidParameter, "dividend"},
+		{bsjson.PriceRequest{Vol: 0.2, TimeToExpiry: 1, Underlying: 1e305, Strike: 100, Rate: 10, Type: "c"},
+			bsjson.CodeInvalidParameter, "underlying"},
+		{bsjson.PriceRequest{Vol: 0.2, TimeToExpiry: 1, Underlying: 100, Strike: 1e305, Rate: -10, Type: "c"},
+			bsjson.CodeInvalidParameter, "strike"},
+	}
+
+	for _, c := range cases {
+		for _, e := range []*bsjson.Error{
+			bsjson.HandlePrice(c.req).Error,
+			bsjson.HandleGreeks(bsjson.GreeksRequest(c.req)).Error,
+		} {
+			if e == nil || e.Code != c.code || e.Param != c.param {
+				t.Errorf("%+v: error = %+v", c.req, e)
+			}
+		}
+		if p := bsjson.HandlePrice(c.req).Price; p != nil {
+			t.Errorf("%+v: price = %v with an error", c.req, *p)
+		}
+		if _, err := json.Marshal(bsjson.HandlePrice(c.req)); err != nil {
+			t.Error(err)
+		}
+	}
+
+	maxit := 3
+	iv = bsjson.HandleImpliedVol(bsjson.ImpliedVolRequest{
+		Premium: 90, TimeToExpiry: 0.5, Underlying: 100, Strike: 110, Type: "call",
+		MaxIt: &maxit,
+	})
+	if iv.Error == nil || iv.Error.Code != bsjson.CodeNoSolution {
+		t.Errorf("HandleImpliedVol = %+v", iv)
+	}
+}
+
+func Test_NonFinite(t *testing.T) {
+
+	g := bsjson.HandleGreeks(bsjson.GreeksRequest{
+		Vol: 0, TimeToExpiry: 1, Underlying: 100, Strike: 100, Type: "call",
+	})
+	if g.Error != nil || !math.IsInf(float64(*g.Gamma), 1) {
+		t.Fatalf("HandleGreeks = %+v", g)
+	}
+
+	b, err := json.Marshal(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got["gamma"] != "+Inf" || got["delta"] != 0.5 {
+		t.Errorf("GreeksResponse = %s", b)
+	}
+
+	for _, f := range []float64{math.NaN(), math.Inf(1), math.Inf(-1), 0, -2.5} {
+		var n bsjson.Number
+		b, err := json.Marshal(bsjson.Number(f))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(b, &n); err != nil {
+			t.Fatal(err)
+		}
+		if float64(n) != f && !(math.IsNaN(f) && math.IsNaN(float64(n))) {
+			t.Errorf("round trip of %s = %v, want %v", b, n, f)
+		}
+	}
+
+	var n bsjson.Number
+	if err := json.Unmarshal([]byte(`"Infinity"`), &n); err == nil {
+		t.Errorf("Number accepted %q", "Infinity")
+	}
+}
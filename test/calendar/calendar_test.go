@@ -0,0 +1,70 @@
+package calendartest
+
+import (
+	"math"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+const spot, r, q = 100.0, 0.03, 0.01
+
+func price(t *testing.T, v, tau, k float64, o bs.OptionType) float64 {
+	p, err := bs.Price(&bs.PriceParams{Vol: v, TimeToExpiry: tau, Underlying: spot, Strike: k, Rate: r, Dividend: q, Type: o})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func Test_CalendarSpread(t *testing.T) {
+
+	for _, o := range []bs.OptionType{bs.Call, bs.Put, bs.Straddle} {
+		for _, k := range []float64{90, 100, 110} {
+
+			// flat, upward and inverted term structures
+			for _, vols := range [][2]float64{{0.2, 0.2}, {0.18, 0.22}, {0.3, 0.25}} {
+
+				p, err := bs.PriceCalendarSpread(vols[0], vols[1], 0.1, 0.35, spot, k, r, q, o)
+				want := price(t, vols[1], 0.35, k, o) - price(t, vols[0], 0.1, k, o)
+				if err != nil || math.Abs(p-want) > 1e-12 {
+					t.Errorf("%c %v %v: price %v, want %v, err = %v", o, k, vols, p, want, err)
+				}
+
+				g, err := bs.GreeksCalendarSpread(vols[0], vols[1], 0.1, 0.35, spot, k, r, q, o)
+				if err != nil || g.Net.Price != p || !(g.Net.Vega > 0) || !(g.Near.Vega < 0 && g.Far.Vega > 0) {
+					t.Errorf("%c %v %v: greeks %+v, err = %v", o, k, vols, g, err)
+				}
+				if d := g.Net.Theta - g.Near.Theta - g.Far.Theta; math.Abs(d) > 1e-12 {
+					t.Errorf("%c %v %v: net theta off by %v", o, k, vols, d)
+				}
+			}
+		}
+	}
+}
+
+func Test_CalendarSpreadNearExpiry(t *testing.T) {
+
+	// the near leg collapses to intrinsic value
+	for _, k := range []float64{90, 110} {
+		far := price(t, 0.25, 0.5, k, bs.Call)
+		for _, tNear := range []float64{1e-3, 1e-6, 0} {
+			p, err := bs.PriceCalendarSpread(0.3, 0.25, tNear, 0.5, spot, k, r, q, bs.Call)
+			if want := far - math.Max(spot-k, 0); err != nil || math.Abs(p-want) > 0.2*math.Sqrt(tNear)+1e-12 {
+				t.Errorf("%v %v: price %v, want %v, err = %v", k, tNear, p, want, err)
+			}
+		}
+	}
+
+	for _, tt := range [][2]float64{{0.5, 0.5}, {0.6, 0.5}} {
+		if _, err := bs.PriceCalendarSpread(0.2, 0.2, tt[0], tt[1], spot, 100, r, q, bs.Call); err != bs.ErrCalendar {
+			t.Errorf("%v: err = %v", tt, err)
+		}
+	}
+	if _, err := bs.PriceCalendarSpread(0.2, 0.2, -0.1, 0.5, spot, 100, r, q, bs.Call); err != bs.ErrNegTimeToExp {
+		t.Errorf("err = %v", err)
+	}
+	if _, err := bs.GreeksCalendarSpread(-0.2, 0.2, 0.1, 0.5, spot, 100, r, q, bs.Call); err != bs.ErrNegVol {
+		t.Errorf("err = %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package carrytest
+
+import (
+	"math"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+// golden values at v = 0.25, t = 0.5, x = 100, k = 105, recorded before
+// discounting moved to DiscountFactor and Forward, with the put and
+// straddle thetas recorded again once BSTheta stopped pricing puts as calls,
+// the rhos once Greeks carried them and the r = 0.05 gammas once BSGreeks
+// divided by x*v*sqrt(t) in one step
+var golden = []struct {
+	r, q      float64
+	o         bs.OptionType
+	g         bs.Greeks
+	intrinsic float64
+}{
+	{0.05, 0.02, bs.Call, bs.Greeks{Price: 5.520494749451025, Delta: 0.45450974561703278, Gamma: 0.022225381356722335, Vega: 27.781726695902922, Theta: -8.0329361733542761, Rho: 19.965239906126126}, 0},
+	{0.05, 0.02, bs.Put, bs.Greeks{Price: 8.9230521375091456, Delta: -0.53554008813213527, Gamma: 0.022225381356722335, Vega: 27.781726695902922, Theta: -4.892658802703867, Rho: -31.238530475361333}, 3.4025573880581135},
+	{0.05, 0.02, bs.Straddle, bs.Greeks{Price: 14.443546886960167, Delta: -0.081030342515102521, Gamma: 0.04445076271344467, Vega: 55.563453391805844, Theta: -12.925594976058143, Rho: -11.27329056923521}, 3.4025573880581135},
+	{-0.01, 0.005, bs.Call, bs.Greeks{Price: 4.7033796067896247, Delta: 0.40801024389116136, Gamma: 0.021923432061193258, Vega: 27.404290076491577, Theta: -6.2860909493540476, Rho: 18.048822391163256}, 0},
+	{-0.01, 0.005, bs.Put, bs.Greeks{Price: 10.479382057280709, Delta: -0.58949287850629883, Gamma: 0.021923432061193258, Vega: 27.404290076491577, Theta: -7.840105657455148, Rho: -34.714334953955294}, 5.7760024504910916},
+	{-0.01, 0.005, bs.Straddle, bs.Greeks{Price: 15.182761664070341, Delta: -0.18148263461513742, Gamma: 0.043846864122386517, Vega: 54.808580152983154, Theta: -14.126196606809195, Rho: -16.665512562792042}, 5.7760024504910916},
+}
+
+func Test_Golden(t *testing.T) {
+
+	const v, tau, x, k = 0.25, 0.5, 100.0, 105.0
+
+	for _, c := range golden {
+
+		pars := &bs.PriceParams{Vol: v, TimeToExpiry: tau, Underlying: x, Strike: k, Rate: c.r, Dividend: c.q, Type: c.o}
+
+		g, err := bs.PriceAndGreeks(pars)
+		if err != nil || g != c.g {
+			t.Errorf("r = %v, %c: %+v, want %+v, err = %v", c.r, c.o, g, c.g, err)
+		}
+		if p, _ := bs.Price(pars); p != c.g.Price {
+			t.Errorf("r = %v, %c: Price = %v, want %v", c.r, c.o, p, c.g.Price)
+		}
+		if d, _ := bs.Delta(pars); d != c.g.Delta {
+			t.Errorf("r = %v, %c: Delta = %v, want %v", c.r, c.o, d, c.g.Delta)
+		}
+		if i := bs.Intrinsic(tau, x, k, c.r, c.q, c.o); i != c.intrinsic {
+			t.Errorf("r = %v, %c: Intrinsic = %v, want %v", c.r, c.o, i, c.intrinsic)
+		}
+
+		vol, err := bs.ImpliedVol(&bs.ImpliedVolParams{
+			Premium: c.g.Price, TimeToExpiry: tau, Underlying: x, Strike: k, Rate: c.r, Dividend: c.q, Type: c.o,
+		})
+		if err != nil || math.Abs(vol-v) > 1e-8 {
+			t.Errorf("r = %v, %c: ImpliedVol = %v, err = %v", c.r, c.o, vol, err)
+		}
+	}
+}
+
+func Test_CarryHelpers(t *testing.T) {
+
+	if df := bs.DiscountFactor(0.05, 2); df != math.Exp(-0.1) {
+		t.Errorf("DiscountFactor = %v", df)
+	}
+	if f := bs.Forward(100, 0.05, 0.02, 2); f != 100*math.Exp(0.06) {
+		t.Errorf("Forward = %v", f)
+	}
+
+	// negative rates discount above 1 and carry the forward down
+	if df := bs.DiscountFactor(-0.01, 3); !(df > 1) || df != math.Exp(0.03) {
+		t.Errorf("DiscountFactor = %v", df)
+	}
+	if f := bs.Forward(100, -0.01, 0.005, 2); !(f < 100) || math.Abs(f-100*math.Exp(-0.03)) > 1e-13 {
+		t.Errorf("Forward = %v", f)
+	}
+
+	for _, c := range [][2]float64{{0.05, 0.02}, {-0.01, 0.005}, {0.01, 0.01}} {
+		f := bs.Forward(100, c[0], c[1], 1.5)
+		if carry := bs.ImpliedCarry(f, 100, 1.5); math.Abs(carry-(c[0]-c[1])) > 1e-15 {
+			t.Errorf("ImpliedCarry = %v, want %v", carry, c[0]-c[1])
+		}
+	}
+	if c := bs.ImpliedCarry(100, 100, 0); !math.IsNaN(c) {
+		t.Errorf("ImpliedCarry at t = 0 = %v", c)
+	}
+
+	// a zero spot has a zero forward however large the carry
+	if f := bs.Forward(0, 900, 0, 1); f != 0 {
+		t.Errorf("Forward = %v", f)
+	}
+	if df := bs.DiscountFactor(-800, 1); !math.IsInf(df, 1) {
+		t.Errorf("DiscountFactor = %v", df)
+	}
+}
@@ -0,0 +1,190 @@
+package chaintest
+
+import (
+	"math"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+const vol, tau, spot, r, q = 0.27, 0.4, 100.0, 0.04, 0.015
+
+// chain quotes calls and puts at strikes 70 to 130 from Price outputs,
+// with a spread of 1% of the premium plus 0.01
+func chain(t *testing.T) bs.OptionChain {
+
+	var quotes []bs.Quote
+	for k := 130.0; k >= 70; k -= 5 {
+		for _, o := range []bs.OptionType{bs.Put, bs.Call} {
+			p, err := bs.Price(&bs.PriceParams{
+				Vol: vol, TimeToExpiry: tau, Underlying: spot, Strike: k, Rate: r, Dividend: q, Type: o,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			s := 0.005*p + 0.005
+			quotes = append(quotes, bs.Quote{Strike: k, Bid: p - s, Ask: p + s, Type: o})
+		}
+	}
+
+	c, err := bs.NewOptionChain(tau, r, quotes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func Test_OptionChain(t *testing.T) {
+
+	c := chain(t)
+
+	for i := 1; i < len(c.Quotes); i++ {
+		if a, b := c.Quotes[i-1], c.Quotes[i]; a.Strike > b.Strike || a.Strike == b.Strike && a.Type != bs.Call {
+			t.Fatalf("quotes out of order at %d", i)
+		}
+	}
+
+	vols, err := c.ImpliedVols(spot, r, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range vols {
+		if math.Abs(v-vol) > 1e-8 {
+			t.Errorf("Strike = %v, Type = %c: vol = %v", c.Quotes[i].Strike, c.Quotes[i].Type, v)
+		}
+	}
+
+	f, err := c.Forward()
+	if want := spot * math.Exp((r-q)*tau); err != nil || math.Abs(f-want) > 1e-10 {
+		t.Errorf("forward = %v, want %v, err = %v", f, want, err)
+	}
+
+	for _, conv := range []bs.ATMConvention{bs.ATMDNS, bs.ATMForward, bs.ATMSpot} {
+		if v, err := c.ATMVol(conv, spot); err != nil || math.Abs(v-vol) > 1e-8 {
+			t.Errorf("%v ATM vol = %v, err = %v", conv, v, err)
+		}
+	}
+
+	calls := c.Filter(func(q bs.Quote) bool { return q.Type == bs.Call })
+	if len(calls.Quotes) != 13 || calls.T != tau || calls.Rate != r {
+		t.Errorf("filtered %+v", calls)
+	}
+	if _, err := calls.Forward(); err != bs.ErrChain {
+		t.Errorf("calls only forward err = %v", err)
+	}
+
+	// a forward above every quoted strike
+	low := c.Filter(func(q bs.Quote) bool { return q.Strike <= 95 })
+	if _, err := low.ATMVol(bs.ATMForward, spot); err != bs.ErrExtrapolation {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func Test_OptionChainErrors(t *testing.T) {
+
+	if _, err := bs.NewOptionChain(0, r, nil); err != bs.ErrNegTimeToExp {
+		t.Errorf("err = %v", err)
+	}
+	for _, qt := range []bs.Quote{
+		{Strike: 0, Bid: 1, Ask: 2, Type: bs.Call},
+		{Strike: 100, Bid: 2, Ask: 1, Type: bs.Call},
+		{Strike: 100, Bid: 1, Ask: 2, Type: bs.Straddle},
+	} {
+		if _, err := bs.NewOptionChain(1, r, []bs.Quote{qt}); err != bs.ErrChain {
+			t.Errorf("%+v: err = %v", qt, err)
+		}
+	}
+
+	// a call premium above spot fails to invert without stopping the other
+	// quotes
+	c, _ := bs.NewOptionChain(1, 0, []bs.Quote{
+		{Strike: 100, Mid: 150, Type: bs.Call},
+		{Strike: 50, Mid: 51, Type: bs.Call},
+	})
+	vols, err := c.ImpliedVols(100, 0, 0)
+	if me, ok := err.(bs.MultiError); !ok || len(me) != 1 || me[0].Index != 1 ||
+		!math.IsNaN(vols[1]) || !(vols[0] > 0) {
+		t.Errorf("vols = %v, err = %v", vols, err)
+	}
+}
+
+// skewed quotes a chain like chain but at the vol skew(k) of each strike
+func skewed(t *testing.T, skew func(k float64) float64) bs.OptionChain {
+
+	var quotes []bs.Quote
+	for k := 70.0; k <= 130; k += 5 {
+		for _, o := range []bs.OptionType{bs.Call, bs.Put} {
+			p, err := bs.Price(&bs.PriceParams{
+				Vol: skew(k), TimeToExpiry: tau, Underlying: spot, Strike: k, Rate: r, Dividend: q, Type: o,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			quotes = append(quotes, bs.Quote{Strike: k, Mid: p, Type: o})
+		}
+	}
+
+	c, err := bs.NewOptionChain(tau, r, quotes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func Test_ATMVolFromChain(t *testing.T) {
+
+	flat := skewed(t, func(float64) float64 { return vol })
+	for _, m := range []bs.ATMInterp{bs.ATMInterpStrike, bs.ATMInterpDelta} {
+		for _, conv := range []bs.ATMConvention{bs.ATMDNS, bs.ATMForward, bs.ATMSpot} {
+			if v, err := bs.ATMVolFromChainWithInterp(flat, spot, r, q, conv, m); err != nil || math.Abs(v-vol) > 1e-8 {
+				t.Errorf("%v %v ATM vol = %v, err = %v", m, conv, v, err)
+			}
+		}
+	}
+
+	// the forward 101.005 is off the listed ATM strike 100
+	skew := func(k float64) float64 { return 0.25 - 0.002*(k-100) }
+	c := skewed(t, skew)
+	f := spot * math.Exp((r-q)*tau)
+
+	if v, err := bs.ATMVolFromChain(c, spot, r, q, bs.ATMForward); err != nil || math.Abs(v-skew(f)) > 1e-8 {
+		t.Errorf("ATM vol = %v, want %v, err = %v", v, skew(f), err)
+	}
+	if v, err := bs.ATMVolFromChain(c, spot, r, q, bs.ATMSpot); err != nil || math.Abs(v-skew(spot)) > 1e-8 {
+		t.Errorf("spot ATM vol = %v, want %v, err = %v", v, skew(spot), err)
+	}
+
+	if v, err := bs.ATMVolFromChainWithInterp(c, spot, r, q, bs.ATMForward, bs.ATMInterpDelta); err != nil || math.Abs(v-skew(f)) > 1e-4 {
+		t.Errorf("delta ATM vol = %v, want about %v, err = %v", v, skew(f), err)
+	}
+
+	// without parity pairs the forward comes from the spot and carry
+	otm := c.Filter(func(q bs.Quote) bool {
+		return q.Type == bs.Put && q.Strike <= 100 || q.Type == bs.Call && q.Strike > 100
+	})
+	if v, err := bs.ATMVolFromChain(otm, spot, r, q, bs.ATMForward); err != nil || math.Abs(v-skew(f)) > 1e-8 {
+		t.Errorf("out of the money ATM vol = %v, err = %v", v, err)
+	}
+
+	index, err := bs.VolIndexSingleExpiry(c, spot, r, q, bs.ATMForward)
+	if err != nil || math.Abs(index-100*skew(f)) > 1e-6 {
+		t.Errorf("index = %v, err = %v", index, err)
+	}
+
+	// a forward above every quoted strike takes the vol of the 95 put
+	low := c.Filter(func(q bs.Quote) bool { return q.Strike <= 95 })
+	v, err := bs.ATMVolFromChain(low, spot, r, q, bs.ATMForward)
+	if w, ok := err.(*bs.ATMExtrapolationWarning); !ok || math.Abs(w.Strike-f) > 1e-8 || math.Abs(v-skew(95)) > 1e-8 {
+		t.Errorf("vol = %v, err = %v", v, err)
+	}
+	if index, err := bs.VolIndexSingleExpiry(low, spot, r, q, bs.ATMForward); math.Abs(index-100*v) > 1e-10 || err == nil {
+		t.Errorf("index = %v, err = %v", index, err)
+	}
+
+	if _, err := bs.ATMVolFromChain(c, spot, r, q, bs.ATMConvention(9)); err != bs.ErrATMConvention {
+		t.Errorf("err = %v", err)
+	}
+	if _, err := bs.ATMVolFromChainWithInterp(c, spot, r, q, bs.ATMForward, bs.ATMInterp(9)); err != bs.ErrATMInterp {
+		t.Errorf("err = %v", err)
+	}
+}
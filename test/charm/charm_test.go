@@ -0,0 +1,90 @@
+package charmtest
+
+import (
+	"math"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+const vol, tau, spot, r, q = 0.3, 0.5, 100.0, 0.05, 0.02
+
+var types = []bs.OptionType{bs.Call, bs.Put, bs.Straddle}
+
+func Test_Charm(t *testing.T) {
+
+	const h = 1e-6
+
+	// the charm against a central difference of the delta in the time to
+	// expiry, which runs the other way to time
+	for _, o := range types {
+		for _, k := range []float64{70, 95, 100, 105, 140} {
+			num := (bs.BSDelta(vol, tau-h, spot, k, r, q, o) - bs.BSDelta(vol, tau+h, spot, k, r, q, o)) / 2 / h
+			charm := bs.BSCharm(vol, tau, spot, k, r, q, o)
+			if math.IsNaN(charm) || math.Abs(charm-num) > 1e-6*math.Max(1, math.Abs(charm)) {
+				t.Errorf("Type = %c, Strike = %v: Charm = %v, want %v", o, k, charm, num)
+			}
+
+			pars := &bs.PriceParams{Vol: vol, TimeToExpiry: tau, Underlying: spot, Strike: k, Rate: r, Dividend: q, Type: o}
+			if got, err := bs.Charm(pars); err != nil || got != charm {
+				t.Errorf("Type = %c, Strike = %v: Charm = %v, %v", o, k, got, err)
+			}
+		}
+
+		// the straddle is the call plus the put
+		c, p := bs.BSCharm(vol, tau, spot, 110, r, q, bs.Call), bs.BSCharm(vol, tau, spot, 110, r, q, bs.Put)
+		if s := bs.BSCharm(vol, tau, spot, 110, r, q, bs.Straddle); math.Abs(s-c-p) > 1e-15 {
+			t.Errorf("straddle charm %v, call + put %v", s, c+p)
+		}
+	}
+
+	pars := &bs.PriceParams{Vol: vol, TimeToExpiry: tau, Underlying: spot, Strike: -1, Rate: r, Dividend: q, Type: bs.Call}
+	if charm, err := bs.Charm(pars); err == nil || !math.IsNaN(charm) {
+		t.Errorf("negative strike: Charm = %v, %v", charm, err)
+	}
+}
+
+func Test_CharmBoundaries(t *testing.T) {
+
+	dfq := math.Exp(-q * tau)
+
+	for _, c := range []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"zero vol call in the money", bs.BSCharm(0, tau, spot, 80, r, q, bs.Call), q * dfq},
+		{"zero vol put in the money", bs.BSCharm(0, tau, spot, 120, r, q, bs.Put), -q * dfq},
+		{"zero vol call out of the money", bs.BSCharm(0, tau, spot, 120, r, q, bs.Call), 0},
+		{"zero strike call", bs.BSCharm(vol, tau, spot, 0, r, q, bs.Call), q * dfq},
+		{"zero spot straddle", bs.BSCharm(vol, tau, 0, 100, r, q, bs.Straddle), -q * dfq},
+		{"expiry call in the money", bs.BSCharm(vol, 0, spot, 90, r, q, bs.Call), q},
+		{"expiry put out of the money", bs.BSCharm(vol, 0, spot, 90, r, q, bs.Put), 0},
+		{"expiry at the money zero vol", bs.BSCharm(0, 0, spot, spot, r, q, bs.Call), q / 2},
+		{"expiry at the money", bs.BSCharm(vol, 0, spot, spot, r, q, bs.Straddle), math.Inf(-1)},
+		{"expiry at the money negative drift", bs.CharmZeroTime(vol, spot, spot, 0, 0.1, bs.Put), math.Inf(1)},
+	} {
+		if c.got != c.want && math.Abs(c.got-c.want) > 1e-15 {
+			t.Errorf("%s: Charm = %v, want %v", c.name, c.got, c.want)
+		}
+	}
+
+	// the boundaries are the limits of the formula, near expiry away from
+	// the money
+	for _, o := range types {
+		for _, k := range []float64{80, 120} {
+			zero, lim := bs.BSCharm(vol, 0, spot, k, r, q, o), bs.BSCharm(vol, 1e-4, spot, k, r, q, o)
+			if math.Abs(zero-lim) > 1e-6 {
+				t.Errorf("Type = %c, Strike = %v: charm at expiry %v, limit %v", o, k, zero, lim)
+			}
+		}
+		zero, lim := bs.BSCharm(0, tau, spot, 80, r, q, o), bs.BSCharm(1e-3, tau, spot, 80, r, q, o)
+		if math.Abs(zero-lim) > 1e-9 {
+			t.Errorf("Type = %c: zero vol charm %v, limit %v", o, zero, lim)
+		}
+	}
+
+	if !math.IsNaN(bs.CharmZeroTime(vol, spot, spot, r, q, bs.OptionType('x'))) {
+		t.Error("unknown option type is not NaN")
+	}
+}
@@ -0,0 +1,164 @@
+package cleantest
+
+import (
+	"math"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+const tau, spot, r, q = 0.5, 100.0, 0.03, 0.01
+
+type key struct {
+	k float64
+	o bs.OptionType
+}
+
+// smile is a downside skew in log-moneyness
+func smile(k float64) float64 {
+	return 0.22 - 0.12*math.Log(k/bs.Forward(spot, r, q, tau))
+}
+
+// chain quotes calls and puts at strikes 60 to 140 on the smile, with a
+// spread of 1% of the premium plus 0.01, applying bad to the mids of its
+// keys
+func chain(t *testing.T, bad map[key]func(float64) float64) bs.OptionChain {
+
+	var qs []bs.Quote
+	for k := 60.0; k <= 140; k += 5 {
+		for _, o := range []bs.OptionType{bs.Call, bs.Put} {
+			p := bs.BSPrice(smile(k), tau, spot, k, r, q, o)
+			if f, ok := bad[key{k: k, o: o}]; ok {
+				p = f(p)
+			}
+			s := 0.005*p + 0.005
+			qs = append(qs, bs.Quote{Strike: k, Bid: math.Max(p-s, 0), Ask: p + s, Mid: p, Type: o})
+		}
+	}
+
+	c, err := bs.NewOptionChain(tau, r, qs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+var bad = map[key]func(float64) float64{
+	// a fat fingered call
+	{k: 110, o: bs.Call}: func(p float64) float64 { return 3 * p },
+	// a stale put from a higher vol
+	{k: 85, o: bs.Put}: func(float64) float64 {
+		return bs.BSPrice(smile(85)+0.2, tau, spot, 85, r, q, bs.Put)
+	},
+	// a put below intrinsic
+	{k: 130, o: bs.Put}: func(p float64) float64 { return p / 2 },
+}
+
+func Test_CleanChain(t *testing.T) {
+
+	good := chain(t, nil)
+
+	// a clean chain is untouched
+	c, rejections, err := bs.CleanChain(good, bs.CleanConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rejections) != 0 || len(c.Quotes) != len(good.Quotes) {
+		t.Errorf("clean chain: %d quotes, rejections %+v", len(c.Quotes), rejections)
+	}
+
+	dirty := chain(t, bad)
+	want := map[key]bs.RejectReason{
+		{k: 110, o: bs.Call}: bs.RejectOutlier,
+		{k: 85, o: bs.Put}:   bs.RejectOutlier,
+		{k: 130, o: bs.Put}:  bs.RejectNoVol,
+	}
+
+	for _, replace := range []bool{false, true} {
+
+		c, rejections, err := bs.CleanChain(dirty, bs.CleanConfig{Replace: replace})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(rejections) != len(want) {
+			t.Errorf("replace %v: rejections %+v", replace, rejections)
+		}
+		for _, rej := range rejections {
+			k := key{k: rej.Quote.Strike, o: rej.Quote.Type}
+			if reason, ok := want[k]; !ok || rej.Reason != reason || rej.Replaced != replace {
+				t.Errorf("replace %v: %+v, want %v", replace, rej, reason)
+			}
+			if rej.Reason == bs.RejectNoVol != math.IsNaN(rej.Vol) {
+				t.Errorf("replace %v: %+v", replace, rej)
+			}
+		}
+
+		// the clean quotes are untouched and the replaced ones match the
+		// chain without bad quotes by parity
+		n := len(good.Quotes)
+		if !replace {
+			n -= len(want)
+		}
+		if len(c.Quotes) != n {
+			t.Fatalf("replace %v: %d quotes, want %d", replace, len(c.Quotes), n)
+		}
+		j := 0
+		for i, g := range good.Quotes {
+			_, isBad := want[key{k: g.Strike, o: g.Type}]
+			if isBad && !replace {
+				continue
+			}
+			got := c.Quotes[j]
+			j++
+			if !isBad && got != dirty.Quotes[i] {
+				t.Errorf("replace %v: %+v, want %+v", replace, got, dirty.Quotes[i])
+			}
+			if isBad && (got.Strike != g.Strike || got.Type != g.Type || math.Abs(got.Mid-g.Mid) > 1e-9) {
+				t.Errorf("replace %v: %+v, want mid %v", replace, got, g.Mid)
+			}
+		}
+	}
+
+	// without the vol screen the fat finger and the stale put break
+	// monotonicity
+	c, rejections, err = bs.CleanChain(dirty, bs.CleanConfig{MADs: 1e9, VolTol: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	arb := map[key]bs.RejectReason{
+		{k: 110, o: bs.Call}: bs.RejectMonotonicity,
+		{k: 85, o: bs.Put}:   bs.RejectMonotonicity,
+		{k: 130, o: bs.Put}:  bs.RejectNoVol,
+	}
+	if len(rejections) != len(arb) || len(c.Quotes) != len(dirty.Quotes)-len(arb) {
+		t.Errorf("no vol screen: rejections %+v", rejections)
+	}
+	for _, rej := range rejections {
+		if reason, ok := arb[key{k: rej.Quote.Strike, o: rej.Quote.Type}]; !ok || rej.Reason != reason {
+			t.Errorf("no vol screen: %+v, want %v", rej, reason)
+		}
+	}
+
+	// an at the money put too rich for its neighbours by less than the vol
+	// screen
+	bump := chain(t, map[key]func(float64) float64{
+		{k: 100, o: bs.Put}: func(p float64) float64 { return p + 1 },
+	})
+	c, rejections, err = bs.CleanChain(bump, bs.CleanConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rejections) != 1 || rejections[0].Quote.Strike != 100 || rejections[0].Reason != bs.RejectConvexity {
+		t.Errorf("convexity: rejections %+v", rejections)
+	}
+	if _, rejections, _ = bs.CleanChain(bump, bs.CleanConfig{PriceTol: 1}); len(rejections) != 0 {
+		t.Errorf("convexity within tolerance: rejections %+v", rejections)
+	}
+
+	for _, cfg := range []bs.CleanConfig{{Window: -1}, {MADs: -1}, {VolTol: -1}, {PriceTol: -1}, {Forward: -1}} {
+		if _, _, err := bs.CleanChain(dirty, cfg); err != bs.ErrCleanConfig {
+			t.Errorf("%+v: got %v, want ErrCleanConfig", cfg, err)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package collartest
+
+import (
+	"math"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+const tau, spot, r, q = 0.5, 100.0, 0.03, 0.01
+
+// packagePrice returns the price of long the put and short the call, each
+// at the vol of the source for its strike
+func packagePrice(t *testing.T, vol bs.VolSource, kp, kc float64) float64 {
+
+	vp, err := vol.Vol(kp, tau)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vc, err := vol.Vol(kc, tau)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return bs.BSPrice(vp, tau, spot, kp, r, q, bs.Put) - bs.BSPrice(vc, tau, spot, kc, r, q, bs.Call)
+}
+
+func Test_SolveCollar(t *testing.T) {
+
+	flat := bs.FlatVol(0.25)
+
+	// a downside skew
+	var smiles []bs.Smile
+	for _, tt := range []float64{0.25, 1} {
+		smiles = append(smiles, bs.Smile{
+			T: tt, Strikes: []float64{70, 85, 100, 115, 130}, Vols: []float64{0.36, 0.3, 0.25, 0.22, 0.21},
+		})
+	}
+	skew, err := bs.NewVolSurface(spot, r, q, smiles, bs.SurfaceConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, kp := range []float64{80, 90, 95} {
+
+		kcFlat, err := bs.SolveCollar(flat, tau, spot, r, q, kp)
+		if err != nil || math.Abs(packagePrice(t, flat, kp, kcFlat)) > 1e-10 {
+			t.Errorf("flat %v: call strike %v, err = %v", kp, kcFlat, err)
+		}
+
+		kcSkew, err := bs.SolveCollar(skew, tau, spot, r, q, kp)
+		if err != nil || math.Abs(packagePrice(t, skew, kp, kcSkew)) > 1e-10 {
+			t.Errorf("skew %v: call strike %v, err = %v", kp, kcSkew, err)
+		}
+		if !(kcSkew > spot && kcSkew < kcFlat) {
+			t.Errorf("put %v: call strike %v with skew, %v flat", kp, kcSkew, kcFlat)
+		}
+
+		// and back
+		if p, err := bs.SolveCollarPut(skew, tau, spot, r, q, kcSkew); err != nil || math.Abs(p-kp) > 1e-8 {
+			t.Errorf("put strike %v, want %v, err = %v", p, kp, err)
+		}
+	}
+}
+
+func Test_ImpliedStrike(t *testing.T) {
+
+	k, err := bs.ImpliedStrike(5, bs.FlatVol(0.2), tau, spot, r, q, bs.Call)
+	if p := bs.BSPrice(0.2, tau, spot, k, r, q, bs.Call); err != nil || math.Abs(p-5) > 1e-10 {
+		t.Errorf("strike %v prices to %v, err = %v", k, p, err)
+	}
+
+	// a put worth more than any strike within range
+	_, err = bs.ImpliedStrike(500, bs.FlatVol(0.2), tau, spot, r, q, bs.Put)
+	if e, ok := err.(*bs.StrikeRangeError); !ok || e.Premium != 500 || !(e.Lo < spot && e.Hi > spot) {
+		t.Errorf("err = %v", err)
+	}
+	// a worthless put
+	if _, err := bs.SolveCollar(bs.FlatVol(0.2), tau, spot, r, q, 1e-3); err != bs.ErrNegPremium {
+		t.Errorf("err = %v", err)
+	}
+
+	if _, err := bs.ImpliedStrike(5, bs.FlatVol(0.2), tau, spot, r, q, bs.Straddle); err != bs.ErrUnknownOptionType {
+		t.Errorf("err = %v", err)
+	}
+	if _, err := bs.ImpliedStrike(0, bs.FlatVol(0.2), tau, spot, r, q, bs.Call); err != bs.ErrNegPremium {
+		t.Errorf("err = %v", err)
+	}
+	if _, err := bs.SolveCollar(bs.FlatVol(-0.2), tau, spot, r, q, 90); err != bs.ErrNegVol {
+		t.Errorf("err = %v", err)
+	}
+}
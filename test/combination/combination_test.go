@@ -0,0 +1,101 @@
+package combinationtest
+
+import (
+	"math"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+const tau, spot, r, q = 0.75, 100.0, 0.04, 0.01
+
+func Test_StripStrap(t *testing.T) {
+
+	for _, v := range []float64{0, 0.05, 0.3, 1.2} {
+		for _, c := range []struct{ t, x, k float64 }{
+			{t: tau, x: spot, k: 80},
+			{t: tau, x: spot, k: 100},
+			{t: tau, x: spot, k: 125},
+			{t: tau, x: 0, k: 100},
+			{t: tau, x: spot, k: 0},
+			{t: 0, x: spot, k: 90},
+		} {
+			strip, err := bs.StripGreeks(v, c.t, c.x, c.k, r, q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			strap, err := bs.StrapGreeks(v, c.t, c.x, c.k, r, q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			straddle := bs.BSGreeks(v, c.t, c.x, c.k, r, q, bs.Straddle)
+			put := bs.BSGreeks(v, c.t, c.x, c.k, r, q, bs.Put)
+
+			for _, g := range []struct {
+				name                 string
+				strip, strap, s, put float64
+			}{
+				{"Price", strip.Price, strap.Price, straddle.Price, put.Price},
+				{"Delta", strip.Delta, strap.Delta, straddle.Delta, put.Delta},
+				{"Gamma", strip.Gamma, strap.Gamma, straddle.Gamma, put.Gamma},
+				{"Vega", strip.Vega, strap.Vega, straddle.Vega, put.Vega},
+				{"Theta", strip.Theta, strap.Theta, straddle.Theta, put.Theta},
+			} {
+				tol := 1e-12 * math.Max(1, math.Abs(g.s))
+				if math.IsInf(g.s, 0) {
+					if g.strip != g.s || g.strap != g.s {
+						t.Errorf("v = %v, %+v: %s strip %v, strap %v, straddle %v", v, c, g.name, g.strip, g.strap, g.s)
+					}
+					continue
+				}
+				if math.Abs(g.strip+g.strap-3*g.s) > tol || math.Abs(g.strip-g.s-g.put) > tol {
+					t.Errorf("v = %v, %+v: %s strip %v, strap %v, straddle %v", v, c, g.name, g.strip, g.strap, g.s)
+				}
+			}
+
+			if p, _ := bs.PriceStrip(v, c.t, c.x, c.k, r, q); p != strip.Price {
+				t.Errorf("PriceStrip = %v, want %v", p, strip.Price)
+			}
+			if p, _ := bs.PriceStrap(v, c.t, c.x, c.k, r, q); p != strap.Price {
+				t.Errorf("PriceStrap = %v, want %v", p, strap.Price)
+			}
+		}
+	}
+
+	if _, err := bs.StripGreeks(-0.1, tau, spot, 100, r, q); err != bs.ErrNegVol {
+		t.Errorf("got %v, want ErrNegVol", err)
+	}
+	if _, err := bs.PriceStrap(0.2, -1, spot, 100, r, q); err != bs.ErrNegTimeToExp {
+		t.Errorf("got %v, want ErrNegTimeToExp", err)
+	}
+}
+
+func Test_ImpliedVolStripStrap(t *testing.T) {
+
+	for _, v := range []float64{0.1, 0.3, 0.8, 2.5} {
+		for _, k := range []float64{80, 95, 100, 110, 130} {
+
+			p, _ := bs.PriceStrip(v, tau, spot, k, r, q)
+			if got, err := bs.ImpliedVolStrip(p, tau, spot, k, r, q); err != nil || math.Abs(got-v) > 1e-8 {
+				t.Errorf("strip v = %v, k = %v: implied %v, err %v", v, k, got, err)
+			}
+
+			p, _ = bs.PriceStrap(v, tau, spot, k, r, q)
+			if got, err := bs.ImpliedVolStrap(p, tau, spot, k, r, q); err != nil || math.Abs(got-v) > 1e-8 {
+				t.Errorf("strap v = %v, k = %v: implied %v, err %v", v, k, got, err)
+			}
+		}
+	}
+
+	lo, _ := bs.PriceStrip(0, tau, spot, 120, r, q)
+	if v, err := bs.ImpliedVolStrip(lo, tau, spot, 120, r, q); v != 0 || err != nil {
+		t.Errorf("intrinsic premium: %v, %v", v, err)
+	}
+	lo, _ = bs.PriceStrap(0, tau, spot, 120, r, q)
+	hi := 2*spot*math.Exp(-q*tau) + 120*math.Exp(-r*tau)
+	for _, p := range []float64{lo - 0.01, hi, math.NaN()} {
+		if _, err := bs.ImpliedVolStrap(p, tau, spot, 120, r, q); err != bs.ErrArbitrage {
+			t.Errorf("premium %v: got %v, want ErrArbitrage", p, err)
+		}
+	}
+}
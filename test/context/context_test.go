@@ -0,0 +1,412 @@
+package contexttest
+
+import (
+	"math"
+	"math/big"
+	"sync"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+	"github.com/uscott/go-blackscholes/bigprice"
+)
+
+func Test_PricingContext(t *testing.T) {
+
+	const tol float64 = 1e-15
+	var tau, x, r, q float64 = 0.4, 100, 0.04, 0.015
+
+	ctx, err := bs.NewPricingContext(tau, x, r, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, o := range []bs.OptionType{bs.Call, bs.Put, bs.Straddle} {
+		for _, v := range []float64{-0.2, 0, 0.15, 0.6} {
+			for k := 0.0; k <= 200; k += 12.5 {
+
+				g := ctx.Greeks(v, k, o)
+
+				for _, c := range []struct {
+					name           string
+					got, want, alt float64
+				}{
+					{"Price", ctx.Price(v, k, o), bs.BSPrice(v, tau, x, k, r, q, o), g.Price},
+					{"Delta", ctx.Delta(v, k, o), bs.BSDelta(v, tau, x, k, r, q, o), g.Delta},
+					{"Gamma", ctx.Gamma(v, k, o), bs.BSGamma(v, tau, x, k, r, q, o), g.Gamma},
+					{"Vega", ctx.Vega(v, k, o), bs.BSVega(v, tau, x, k, r, q, o), g.Vega},
+					{"Theta", ctx.Theta(v, k, o), bs.BSTheta(v, tau, x, k, r, q, o), g.Theta},
+				} {
+					scale := tol * math.Max(1, math.Abs(c.want))
+					if math.Abs(c.got-c.want) > scale || math.Abs(c.alt-c.want) > 10*scale {
+						t.Errorf(
+							"Type = %c, Vol = %v, Strike = %v: %s = %v, %v, want %v",
+							o, v, k, c.name, c.got, c.alt, c.want,
+						)
+					}
+				}
+
+				if want := bs.BSGreeks(v, tau, x, k, r, q, o); g != want {
+					t.Errorf("Greeks = %v, want %v", g, want)
+				}
+			}
+		}
+	}
+
+	if p := ctx.Price(0.2, -1, bs.Call); !math.IsNaN(p) {
+		t.Errorf("Price with negative strike = %v", p)
+	}
+
+	if _, err := bs.NewPricingContext(-1, x, r, q); err != bs.ErrNegTimeToExp {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func Test_PricingContextConcurrent(t *testing.T) {
+
+	ctx, err := bs.NewPricingContext(0.25, 100, 0.03, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := ctx.Price(0.3, 105, bs.Put)
+	wg := new(sync.WaitGroup)
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				if p := ctx.Price(0.3, 105, bs.Put); p != want {
+					t.Errorf("Price = %v, want %v", p, want)
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+// countingNormal counts the evaluations made through it
+type countingNormal struct {
+	bs.StdNormal
+	cdf, pdf, quantile int
+}
+
+func (n *countingNormal) CDF(x float64) float64 {
+	n.cdf++
+	return n.StdNormal.CDF(x)
+}
+
+func (n *countingNormal) PDF(x float64) float64 {
+	n.pdf++
+	return n.StdNormal.PDF(x)
+}
+
+func (n *countingNormal) Quantile(p float64) float64 {
+	n.quantile++
+	return n.StdNormal.Quantile(p)
+}
+
+func Test_WithNormal(t *testing.T) {
+
+	ctx, err := bs.NewPricingContext(0.5, 100, 0.03, 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n := new(countingNormal)
+	counted := ctx.WithNormal(n)
+
+	if _, ok := ctx.Normal().(bs.StdNormal); !ok {
+		t.Errorf("Normal() = %T", ctx.Normal())
+	}
+	if counted.Normal() != bs.Normal(n) {
+		t.Errorf("Normal() = %T", counted.Normal())
+	}
+
+	for _, c := range []struct {
+		name     string
+		f        func()
+		cdf, pdf int
+	}{
+		{"Price", func() { counted.Price(0.2, 105, bs.Put) }, 2, 0},
+		{"Delta", func() { counted.Delta(0.2, 105, bs.Put) }, 1, 0},
+		{"Gamma", func() { counted.Gamma(0.2, 105, bs.Put) }, 0, 1},
+		{"Vega", func() { counted.Vega(0.2, 105, bs.Put) }, 0, 1},
+		{"Greeks", func() { counted.Greeks(0.2, 105, bs.Straddle) }, 2, 1},
+	} {
+		n.cdf, n.pdf = 0, 0
+		c.f()
+		if n.cdf != c.cdf || n.pdf != c.pdf {
+			t.Errorf("%s: %d CDF and %d PDF calls, want %d and %d", c.name, n.cdf, n.pdf, c.cdf, c.pdf)
+		}
+	}
+
+	if g, want := counted.Greeks(0.2, 105, bs.Call), ctx.Greeks(0.2, 105, bs.Call); g != want {
+		t.Errorf("Greeks = %+v, want %+v", g, want)
+	}
+}
+
+func Test_WithNormalFallback(t *testing.T) {
+
+	ctx, err := bs.NewPricingContext(0.5, 100, 0.03, 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n := new(countingNormal)
+	counted := ctx.WithNormal(n)
+
+	// below NearExpiryTotalVol the context falls back to the kernels,
+	// which still evaluate through its Normal
+	for _, o := range []bs.OptionType{bs.Call, bs.Put, bs.Straddle} {
+		n.cdf, n.pdf = 0, 0
+		if p, want := counted.Price(1e-4, 100.5, o), ctx.Price(1e-4, 100.5, o); p != want {
+			t.Errorf("Type = %c: Price = %v, want %v", o, p, want)
+		}
+		if g, want := counted.Greeks(1e-4, 100.5, o), ctx.Greeks(1e-4, 100.5, o); g != want {
+			t.Errorf("Type = %c: Greeks = %+v, want %+v", o, g, want)
+		}
+		if n.cdf == 0 || n.pdf == 0 {
+			t.Errorf("Type = %c: %d CDF and %d PDF calls near expiry", o, n.cdf, n.pdf)
+		}
+	}
+
+	if p := counted.Price(0.2, -1, bs.Call); !math.IsNaN(p) {
+		t.Errorf("Price at a negative strike = %v, want NaN", p)
+	}
+}
+
+func Test_PriceParamsNormal(t *testing.T) {
+
+	n := new(countingNormal)
+	std := bs.PriceParams{
+		Vol: 0.2, TimeToExpiry: 0.5, Underlying: 100, Strike: 105, Rate: 0.03, Dividend: 0.01, Type: bs.Put,
+	}
+	counted := std
+	counted.Normal = n
+
+	for _, f := range []struct {
+		name string
+		f    func(*bs.PriceParams) (float64, error)
+	}{
+		{"Price", bs.Price}, {"Delta", bs.Delta}, {"Gamma", bs.Gamma}, {"Vega", bs.Vega},
+		{"Theta", bs.Theta}, {"Rho", bs.Rho}, {"Epsilon", bs.Epsilon}, {"Vanna", bs.Vanna},
+		{"Volga", bs.Volga}, {"Ultima", bs.Ultima}, {"Veta", bs.Veta}, {"Lambda", bs.Lambda},
+		{"DualDelta", bs.DualDelta}, {"DualGamma", bs.DualGamma}, {"Charm", bs.Charm},
+		{"Speed", bs.Speed}, {"Zomma", bs.Zomma}, {"Color", bs.Color},
+	} {
+		n.cdf, n.pdf = 0, 0
+		got, err := f.f(&counted)
+		if err != nil {
+			t.Fatalf("%s: %v", f.name, err)
+		}
+		if want, _ := f.f(&std); got != want {
+			t.Errorf("%s = %v, want %v", f.name, got, want)
+		}
+		if n.cdf+n.pdf == 0 {
+			t.Errorf("%s: no calls through the Normal", f.name)
+		}
+	}
+
+	n.cdf, n.pdf = 0, 0
+	if g, _ := bs.PriceAndGreeks(&counted); n.cdf != 2 || n.pdf != 1 {
+		t.Errorf("PriceAndGreeks: %d CDF and %d PDF calls, want 2 and 1", n.cdf, n.pdf)
+	} else if want := bs.BSGreeks(0.2, 0.5, 100, 105, 0.03, 0.01, bs.Put); g != want {
+		t.Errorf("PriceAndGreeks = %+v, want %+v", g, want)
+	}
+
+	n.cdf, n.pdf = 0, 0
+	dst := make([]float64, 2)
+	if err := bs.PriceInto(dst, []bs.PriceParams{counted, counted}); err != nil {
+		t.Fatal(err)
+	}
+	if n.cdf != 4 {
+		t.Errorf("PriceInto: %d CDF calls, want 4", n.cdf)
+	}
+}
+
+func Test_ImpliedVolNormal(t *testing.T) {
+
+	var tau, x, k, r, q float64 = 0.5, 100, 105, 0.03, 0.01
+
+	for _, o := range []bs.OptionType{bs.Call, bs.Put, bs.Straddle} {
+		n := new(countingNormal)
+		p := bs.BSPrice(0.25, tau, x, k, r, q, o)
+		pars := bs.ImpliedVolParams{
+			Premium: p, TimeToExpiry: tau, Underlying: x, Strike: k, Rate: r, Dividend: q, Type: o,
+		}
+		want, err := bs.ImpliedVol(&pars)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pars.Normal = n
+		got, err := bs.ImpliedVol(&pars)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(got-want) > 1e-9 {
+			t.Errorf("Type = %c: ImpliedVol = %v, want %v", o, got, want)
+		}
+		// without the NormCDFFast screen every bisection step is priced
+		if n.cdf < 40 {
+			t.Errorf("Type = %c: %d CDF calls", o, n.cdf)
+		}
+	}
+}
+
+func Test_SimConfigNormal(t *testing.T) {
+
+	n := new(countingNormal)
+	pars := &bs.PriceParams{
+		Vol: 0.2, TimeToExpiry: 0.5, Underlying: 100, Strike: 105, Rate: 0.03, Dividend: 0.01, Type: bs.Call,
+	}
+
+	want, err := bs.SimConfig{Seed: 7}.PriceSim(pars, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := bs.SimConfig{Seed: 7, Normal: n}.PriceSim(pars, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("PriceSim = %+v, want %+v", got, want)
+	}
+	if n.quantile != 1000 {
+		t.Errorf("%d quantile calls for 1000 pairs", n.quantile)
+	}
+}
+
+func Test_BatchNormal(t *testing.T) {
+
+	n := new(countingNormal)
+	v := []float64{0.2, 0.25, 0, 1e-4}
+	k := []float64{90, 100, 110, 100}
+	var tau, x, r, q float64 = 0.5, 100, 0.03, 0.01
+
+	want := make([]float64, len(k))
+	if err := bs.PriceChain(want, v, tau, x, k, r, q, bs.Put); err != nil {
+		t.Fatal(err)
+	}
+
+	got := make([]float64, len(k))
+	if err := (bs.Batch{Normal: n}).PriceChain(got, v, tau, x, k, r, q, bs.Put); err != nil {
+		t.Fatal(err)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("PriceChain[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+	// N(d1) and N(d2) for every row, and the near expiry row once more
+	if n.cdf != 2*len(k)+1 {
+		t.Errorf("PriceChain: %d CDF calls, want %d", n.cdf, 2*len(k)+1)
+	}
+
+	n.cdf = 0
+	nd := make([]float64, 3)
+	if err := (bs.Batch{Normal: n}).NormCDFSlice(nd, []float64{-1, 0, 1}); err != nil {
+		t.Fatal(err)
+	}
+	if n.cdf != 3 || nd[1] != 0.5 {
+		t.Errorf("NormCDFSlice: %d CDF calls, nd = %v", n.cdf, nd)
+	}
+}
+
+// bigNormal evaluates the normal distribution in 256 bit floating point
+// with bigprice and rounds the result
+type bigNormal struct{ bs.StdNormal }
+
+func (bigNormal) PDF(x float64) float64 {
+	f, _ := bigprice.NormPDF(new(big.Float).SetPrec(bigprice.DefaultPrec).SetFloat64(x)).Float64()
+	return f
+}
+
+func (bigNormal) CDF(x float64) float64 {
+	f, _ := bigprice.NormCDF(new(big.Float).SetPrec(bigprice.DefaultPrec).SetFloat64(x)).Float64()
+	return f
+}
+
+func Test_DeepITMAccuracy(t *testing.T) {
+
+	precise := bigNormal{}
+
+	for _, x := range []float64{-9, -7.5, -5, -2, 0.3, 4, 8.5} {
+		want := precise.CDF(x)
+		if got := bs.NormCDF(x); math.Abs(got-want) > 1e-14*want {
+			t.Errorf("NormCDF(%v) = %v, want %v", x, got, want)
+		}
+		if got, want := bs.NormPDF(x), precise.PDF(x); math.Abs(got-want) > 1e-14*want {
+			t.Errorf("NormPDF(%v) = %v, want %v", x, got, want)
+		}
+	}
+
+	var tau, x, r, q float64 = 1, 100, 0.05, 0.02
+
+	ctx, err := bs.NewPricingContext(tau, x, r, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref := ctx.WithNormal(precise)
+
+	for _, c := range []struct {
+		v, k float64
+		o    bs.OptionType
+	}{
+		{0.1, 40, bs.Call},
+		{0.15, 60, bs.Call},
+		{0.1, 250, bs.Put},
+		{0.2, 400, bs.Put},
+		{0.1, 30, bs.Straddle},
+	} {
+		got, want := ctx.Greeks(c.v, c.k, c.o), ref.Greeks(c.v, c.k, c.o)
+		for _, f := range []struct {
+			name      string
+			got, want float64
+		}{
+			{"Price", got.Price, want.Price},
+			{"Delta", got.Delta, want.Delta},
+			{"Gamma", got.Gamma, want.Gamma},
+			{"Vega", got.Vega, want.Vega},
+		} {
+			if math.Abs(f.got-f.want) > 1e-13*math.Abs(f.want) {
+				t.Errorf("Type = %c, Vol = %v, Strike = %v: %s = %v, want %v",
+					c.o, c.v, c.k, f.name, f.got, f.want)
+			}
+		}
+	}
+}
+
+var sink float64
+
+func Benchmark_PriceFree(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		sink = bs.BSPrice(0.25, 0.5, 100, float64(80+i%40), 0.05, 0.02, bs.Call)
+	}
+}
+
+func Benchmark_PriceContext(b *testing.B) {
+
+	ctx, _ := bs.NewPricingContext(0.5, 100, 0.05, 0.02)
+
+	for i := 0; i < b.N; i++ {
+		sink = ctx.Price(0.25, float64(80+i%40), bs.Call)
+	}
+}
+
+func Benchmark_VegaFree(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		sink = bs.BSVega(0.25, 0.5, 100, float64(80+i%40), 0.05, 0.02, bs.Call)
+	}
+}
+
+func Benchmark_VegaContext(b *testing.B) {
+
+	ctx, _ := bs.NewPricingContext(0.5, 100, 0.05, 0.02)
+
+	for i := 0; i < b.N; i++ {
+		sink = ctx.Vega(0.25, float64(80+i%40), bs.Call)
+	}
+}
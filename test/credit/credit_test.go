@@ -0,0 +1,99 @@
+package credittest
+
+import (
+	"math"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+const v, tau, x, r, q = 0.25, 1.5, 100.0, 0.04, 0.01
+
+func price(v, t, x, k, r, q float64, o bs.OptionType) float64 {
+	p, _ := bs.Price(&bs.PriceParams{Vol: v, TimeToExpiry: t, Underlying: x, Strike: k, Rate: r, Dividend: q, Type: o})
+	return p
+}
+
+func Test_PriceWithDefault(t *testing.T) {
+
+	for _, conv := range []bs.RecoveryConvention{bs.RecoveryMarketValue, bs.RecoveryFixed} {
+		for _, o := range []bs.OptionType{bs.Call, bs.Put, bs.Straddle} {
+			for _, k := range []float64{80, 100, 120} {
+
+				want := price(v, tau, x, k, r, q, o)
+
+				for _, c := range []struct{ h, rec float64 }{{h: 0, rec: 0.4}, {h: 0.05, rec: 1}} {
+					got, err := bs.PriceWithDefaultConvention(conv, v, tau, x, k, r, q, c.h, c.rec, o)
+					if err != nil || got != want {
+						t.Errorf("%v, %v, k = %v, %+v: %v, %v, want %v", conv, o, k, c, got, err, want)
+					}
+				}
+
+				got, _ := bs.PriceWithDefaultConvention(conv, v, tau, x, k, r, q, 0.05, 0.4, o)
+				if !(got < want) {
+					t.Errorf("%v, %v, k = %v: %v not below %v", conv, o, k, got, want)
+				}
+			}
+		}
+	}
+}
+
+func Test_DefaultSpread(t *testing.T) {
+
+	const h, rec = 0.03, 0.4
+
+	for _, o := range []bs.OptionType{bs.Call, bs.Put} {
+		for _, k := range []float64{80, 100, 120} {
+
+			// under market value recovery the writer's credit spread
+			// h*(1 - rec) is added to the discount rate, with the forward
+			// kept by shifting the dividend yield too
+			s := h * (1 - rec)
+			got, _ := bs.PriceWithDefault(v, tau, x, k, r, q, h, rec, o)
+			if want := price(v, tau, x, k, r+s, q+s, o); math.Abs(got-want) > 1e-12 {
+				t.Errorf("%v, k = %v: %v, spread shifted %v", o, k, got, want)
+			}
+
+			// with no recovery both conventions discount at r + h
+			got, _ = bs.PriceWithDefaultConvention(bs.RecoveryFixed, v, tau, x, k, r, q, h, 0, o)
+			if want := price(v, tau, x, k, r+h, q+h, o); math.Abs(got-want) > 1e-12 {
+				t.Errorf("%v, k = %v: %v, spread shifted %v", o, k, got, want)
+			}
+		}
+	}
+}
+
+func Test_HazardSensitivity(t *testing.T) {
+
+	const eps = 1e-6
+
+	for _, conv := range []bs.RecoveryConvention{bs.RecoveryMarketValue, bs.RecoveryFixed} {
+		for _, h := range []float64{0, 0.02, 0.2} {
+			got, err := bs.HazardSensitivityWithConvention(conv, v, tau, x, 100, r, q, h, 0.4, bs.Put)
+			if err != nil {
+				t.Fatal(err)
+			}
+			up, _ := bs.PriceWithDefaultConvention(conv, v, tau, x, 100, r, q, h+eps, 0.4, bs.Put)
+			dn, _ := bs.PriceWithDefaultConvention(conv, v, tau, x, 100, r, q, math.Abs(h-eps), 0.4, bs.Put)
+			want := (up - dn) / (h + eps - math.Abs(h-eps))
+			if h == 0 {
+				base, _ := bs.PriceWithDefaultConvention(conv, v, tau, x, 100, r, q, 0, 0.4, bs.Put)
+				want = (up - base) / eps
+			}
+			if !(got < 0) || math.Abs(got-want) > 1e-5*math.Abs(want) {
+				t.Errorf("%v, h = %v: %v, numeric %v", conv, h, got, want)
+			}
+		}
+	}
+
+	for _, c := range []struct{ h, rec float64 }{{h: -0.01, rec: 0.4}, {h: 0.01, rec: 1.1}, {h: 0.01, rec: math.NaN()}} {
+		if _, err := bs.PriceWithDefault(v, tau, x, 100, r, q, c.h, c.rec, bs.Call); err != bs.ErrDefault {
+			t.Errorf("%+v: %v", c, err)
+		}
+	}
+
+	unknown := bs.RecoveryConvention(7)
+	if _, err := bs.PriceWithDefaultConvention(unknown, v, tau, x, 100, r, q, 0.01, 0.4, bs.Call); err != bs.ErrRecoveryConvention {
+		t.Errorf("unknown convention: %v", err)
+	}
+}
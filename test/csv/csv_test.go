@@ -0,0 +1,152 @@
+package csvtest
+
+import (
+	"bytes"
+	"encoding/csv"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+func process(t *testing.T, in string, cfg bs.CSVConfig) [][]string {
+
+	var out bytes.Buffer
+	if err := bs.ProcessCSV(strings.NewReader(in), &out, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return rows
+}
+
+func parse(t *testing.T, s string) float64 {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func Test_ProcessCSVPrice(t *testing.T) {
+
+	in := "spot,strike,t,expiry,rate,dividend,type,vol\n" +
+		"100,110,0.5,,0.05,0.01,call,0.2\n" +
+		"100,90,,2025-07-02,,,put,0.3\n" +
+		"100,-5,1,,,,,0.2\n" +
+		"100,100,1,,,,x,0.2\n" +
+		"abc,100,1,,,,,0.2\n" +
+		"100,100,,2025-13-01,,,,0.2\n" +
+		"100,100,1,,,,\n"
+
+	cfg := bs.CSVConfig{
+		Now:   time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		Price: true, Greeks: true,
+	}
+	rows := process(t, in, cfg)
+
+	want := "spot,strike,t,expiry,rate,dividend,type,vol,price,delta,gamma,vega,theta,error"
+	if got := strings.Join(rows[0], ","); got != want {
+		t.Fatalf("header = %s, want %s", got, want)
+	}
+	if len(rows) != 8 {
+		t.Fatalf("%d rows", len(rows))
+	}
+
+	g := bs.BSGreeks(0.2, 0.5, 100, 110, 0.05, 0.01, bs.Call)
+	if parse(t, rows[1][8]) != g.Price || parse(t, rows[1][9]) != g.Delta || rows[1][13] != "" {
+		t.Errorf("row 1 = %v, want %+v", rows[1], g)
+	}
+
+	if want := bs.BSPrice(0.3, 182.0/365, 100, 90, 0, 0, bs.Put); parse(t, rows[2][8]) != want {
+		t.Errorf("row 2 = %v, want price %v", rows[2], want)
+	}
+
+	for i, msg := range map[int]string{
+		3: bs.ErrNegStrike.Error(),
+		4: "type",
+		5: "spot",
+		6: "expiry",
+		7: "vol",
+	} {
+		row := rows[i]
+		if len(row) != 14 || row[8] != "" || !strings.Contains(row[13], msg) {
+			t.Errorf("row %d = %q, want error containing %q", i, row, msg)
+		}
+	}
+}
+
+func Test_ProcessCSVImpliedVol(t *testing.T) {
+
+	premium := bs.BSPrice(0.25, 0.75, 100, 105, 0, 0, bs.Put)
+
+	in := "S,K,T,cp,px\n" +
+		"100,105,0.75,p," + strconv.FormatFloat(premium, 'g', -1, 64) + "\n" +
+		"100,105,0.75,c,200\n"
+
+	rows := process(t, in, bs.CSVConfig{
+		Columns: bs.CSVColumns{
+			Spot: "S", Strike: "K", T: "T", Type: "cp", Premium: "px", Rate: "r",
+		},
+	})
+
+	if got := strings.Join(rows[0], ","); got != "S,K,T,cp,px,implied_vol,error" {
+		t.Fatalf("header = %s", got)
+	}
+
+	if vol := parse(t, rows[1][5]); math.Abs(vol-0.25) > 1e-8 || rows[1][6] != "" {
+		t.Errorf("row 1 = %v", rows[1])
+	}
+
+	if rows[2][5] != "" || rows[2][6] == "" {
+		t.Errorf("row 2 = %v", rows[2])
+	}
+}
+
+func Test_ProcessCSVHeader(t *testing.T) {
+
+	var out bytes.Buffer
+
+	for _, in := range []string{
+		"",
+		"spot,strike,vol\n100,100,0.2\n",
+		"spot,t,vol\n100,1,0.2\n",
+	} {
+		if err := bs.ProcessCSV(strings.NewReader(in), &out, bs.CSVConfig{Price: true}); err == nil {
+			t.Errorf("%q: no error", in)
+		}
+	}
+}
+
+func Test_ProcessCSVVolPeriods(t *testing.T) {
+
+	// daily vols in and out
+	const n = 252.0
+	v := 0.3 / math.Sqrt(n)
+	premium := bs.BSPrice(0.3, 0.5, 100, 95, 0.02, 0, bs.Put)
+
+	in := "spot,strike,t,rate,type,vol,premium\n" +
+		"100,95,0.5,0.02,p," + strconv.FormatFloat(v, 'g', -1, 64) + "," +
+		strconv.FormatFloat(premium, 'g', -1, 64) + "\n"
+
+	rows := process(t, in, bs.CSVConfig{Price: true, ImpliedVol: true, VolPeriodsPerYear: n})
+
+	if p := parse(t, rows[1][7]); math.Abs(p-premium) > 1e-12 {
+		t.Errorf("price = %v, want %v", p, premium)
+	}
+	if vol := parse(t, rows[1][8]); math.Abs(vol-v) > 1e-9 {
+		t.Errorf("implied vol = %v, want %v", vol, v)
+	}
+
+	var out bytes.Buffer
+	if err := bs.ProcessCSV(strings.NewReader(in), &out, bs.CSVConfig{VolPeriodsPerYear: -1}); err == nil {
+		t.Error("negative periods accepted")
+	}
+}
@@ -0,0 +1,106 @@
+package curvetest
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+const spot, r, vol = 100.0, 0.03, 0.25
+
+// dividends is the instantaneous dividend yield term structure the pairs
+// are priced under
+var dividends = bs.Curve{
+	Times: []float64{0.25, 0.5, 1, 2},
+	Rates: []float64{0.01, 0.04, 0.02, -0.005},
+}
+
+func pair(t *testing.T, tau, k float64) bs.ParityPair {
+
+	q := dividends.ZeroRate(tau)
+	p := bs.ParityPair{T: tau, Strike: k, Spot: spot, Rate: r}
+
+	var err error
+	pars := &bs.PriceParams{Vol: vol, TimeToExpiry: tau, Underlying: spot, Strike: k, Rate: r, Dividend: q, Type: bs.Call}
+	if p.Call, err = bs.Price(pars); err != nil {
+		t.Fatal(err)
+	}
+	pars.Type = bs.Put
+	if p.Put, err = bs.Price(pars); err != nil {
+		t.Fatal(err)
+	}
+
+	return p
+}
+
+func Test_ImpliedCarryCurve(t *testing.T) {
+
+	// unsorted, at different strikes
+	pairs := []bs.ParityPair{pair(t, 1, 95), pair(t, 0.25, 100), pair(t, 2, 110), pair(t, 0.5, 100)}
+
+	c, err := bs.ImpliedCarryCurve(pairs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, tau := range dividends.Times {
+		if c.Times[i] != tau || math.Abs(c.Rates[i]-(r-dividends.Rates[i])) > 1e-10 {
+			t.Errorf("pillar %v: carry %v, want %v", c.Times[i], c.Rates[i], r-dividends.Rates[i])
+		}
+	}
+
+	// without the one year pair the carry from 0.5 to 2 is the average
+	c, err = bs.ImpliedCarryCurve([]bs.ParityPair{pairs[1], pairs[2], pairs[3]})
+	if err != nil || len(c.Times) != 3 {
+		t.Fatalf("curve %+v, err = %v", c, err)
+	}
+	if want := r - (0.02*0.5-0.005)/1.5; math.Abs(c.Rates[2]-want) > 1e-10 {
+		t.Errorf("carry %v, want %v", c.Rates[2], want)
+	}
+	if z, want := c.ZeroRate(2), r-dividends.ZeroRate(2); math.Abs(z-want) > 1e-10 {
+		t.Errorf("zero carry %v, want %v", z, want)
+	}
+}
+
+func Test_ImpliedCarryCurveErrors(t *testing.T) {
+
+	p := pair(t, 0.5, 100)
+
+	if _, err := bs.ImpliedCarryCurve([]bs.ParityPair{p, p}); err != bs.ErrCurve {
+		t.Errorf("repeated expiry err = %v", err)
+	}
+	if _, err := bs.ImpliedCarryCurve(nil); err != bs.ErrCurve {
+		t.Errorf("empty err = %v", err)
+	}
+
+	// a put worth more than the discounted strike
+	bad := p
+	bad.T, bad.Put = 1, 150
+	_, err := bs.ImpliedCarryCurve([]bs.ParityPair{p, bad})
+	var fe *bs.ForwardError
+	if !errors.As(err, &fe) || fe.T != 1 || !(fe.Forward <= 0) {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func Test_Curve(t *testing.T) {
+
+	if _, err := bs.NewCurve([]float64{1, 0.5}, []float64{0.01, 0.02}); err != bs.ErrCurve {
+		t.Errorf("err = %v", err)
+	}
+
+	c, err := bs.NewCurve([]float64{1, 2}, []float64{0.01, 0.03})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, x := range [][2]float64{{0.5, 0.005}, {1, 0.01}, {1.5, 0.025}, {3, 0.07}} {
+		if got := c.Integral(x[0]); math.Abs(got-x[1]) > 1e-15 {
+			t.Errorf("Integral(%v) = %v, want %v", x[0], got, x[1])
+		}
+	}
+	if z := c.ZeroRate(2); math.Abs(z-0.02) > 1e-15 {
+		t.Errorf("ZeroRate = %v", z)
+	}
+}
@@ -62,3 +62,121 @@ func Test_Delta(t *testing.T) {
 	}
 
 }
+
+func Test_DeltaWithConvention(t *testing.T) {
+
+	var v, tau, x, k, r, q float64 = 0.2, 1, 100, 105, 0.05, 0.03
+
+	deltas := func(o bs.OptionType) (spot, fwd, spotPA, fwdPA float64) {
+		var err error
+		for _, d := range []struct {
+			conv bs.DeltaConvention
+			dst  *float64
+		}{
+			{bs.SpotDelta, &spot}, {bs.ForwardDelta, &fwd}, {bs.SpotDeltaPA, &spotPA}, {bs.ForwardDeltaPA, &fwdPA},
+		} {
+			if *d.dst, err = bs.DeltaWithConvention(d.conv, v, tau, x, k, r, q, o); err != nil {
+				t.Fatalf("%v: %v", d.conv, err)
+			}
+		}
+		t.Logf("%c: spot %8.5f forward %8.5f spot PA %8.5f forward PA %8.5f", o, spot, fwd, spotPA, fwdPA)
+		return
+	}
+
+	cs, cf, cspa, cfpa := deltas(bs.Call)
+	ps, pf, pspa, pfpa := deltas(bs.Put)
+	ss, sf, sspa, sfpa := deltas(bs.Straddle)
+
+	if cs != bs.BSDelta(v, tau, x, k, r, q, bs.Call) {
+		t.Errorf("spot delta %v", cs)
+	}
+
+	// calls are positive and puts negative, premium adjustment lowers both
+	// and forward deltas are spot deltas undiscounted by exp(q*t)
+	if !(0 < cspa && cspa < cs && cs < cf) || !(cspa < cfpa && cfpa < cf) {
+		t.Errorf("call deltas out of order")
+	}
+	if !(pfpa < pspa && pspa < ps && ps < 0) || !(pfpa < pf && pf < ps) {
+		t.Errorf("put deltas out of order")
+	}
+	dfq := math.Exp(-q * tau)
+	for _, d := range [][2]float64{{cs, cf}, {cspa, cfpa}, {ps, pf}, {pspa, pfpa}} {
+		if math.Abs(d[0]-dfq*d[1]) > 1e-14 {
+			t.Errorf("spot %v != exp(-q*t) * forward %v", d[0], d[1])
+		}
+	}
+
+	// put-call parity of the unadjusted deltas, and the premium adjusted
+	// call delta exp(-q*t)*(k/f)*N(d2)
+	if math.Abs(cf-pf-1) > 1e-14 || math.Abs(cs-ps-dfq) > 1e-14 {
+		t.Errorf("parity: %v, %v", cf-pf, cs-ps)
+	}
+	f := x * math.Exp((r-q)*tau)
+	d2 := (math.Log(f/k) - v*v*tau/2) / v / math.Sqrt(tau)
+	if want := dfq * k / f * bs.NormCDF(d2); math.Abs(cspa-want) > 1e-14 {
+		t.Errorf("spot PA call delta %v, want %v", cspa, want)
+	}
+
+	// the straddle is the call plus the put
+	for _, d := range [][3]float64{{ss, cs, ps}, {sf, cf, pf}, {sspa, cspa, pspa}, {sfpa, cfpa, pfpa}} {
+		if math.Abs(d[0]-d[1]-d[2]) > 1e-14 {
+			t.Errorf("straddle %v != %v + %v", d[0], d[1], d[2])
+		}
+	}
+
+	if _, err := bs.DeltaWithConvention(bs.ForwardDeltaPA+1, v, tau, x, k, r, q, bs.Call); err != bs.ErrDeltaConvention {
+		t.Errorf("err = %v", err)
+	}
+	if _, err := bs.DeltaWithConvention(bs.SpotDeltaPA, v, tau, 0, k, r, q, bs.Call); err != bs.ErrNegPrice {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func Test_Lambda(t *testing.T) {
+
+	var v, tau, x, r, q float64 = 0.25, 0.5, 100, 0.05, 0.02
+
+	for _, o := range []bs.OptionType{bs.Call, bs.Put, bs.Straddle} {
+		for _, k := range []float64{80, 100, 120} {
+
+			pars := &bs.PriceParams{Vol: v, TimeToExpiry: tau, Underlying: x, Strike: k, Rate: r, Dividend: q, Type: o}
+			lambda, err := bs.Lambda(pars)
+			if err != nil {
+				t.Fatalf("Type = %c, Strike = %v: %v", o, k, err)
+			}
+
+			// the elasticity against a central difference in log spot
+			const h = 1e-6
+			pu := bs.BSPrice(v, tau, x*math.Exp(h), k, r, q, o)
+			pd := bs.BSPrice(v, tau, x*math.Exp(-h), k, r, q, o)
+			num := (math.Log(pu) - math.Log(pd)) / 2 / h
+			if math.Abs(lambda-num) > 1e-6*math.Max(1, math.Abs(lambda)) {
+				t.Errorf("Type = %c, Strike = %v: Lambda = %v, want %v", o, k, lambda, num)
+			}
+
+			switch {
+			case o == bs.Call && !(lambda > 1), o == bs.Put && !(lambda < 0):
+				t.Errorf("Type = %c, Strike = %v: Lambda = %v", o, k, lambda)
+			}
+		}
+	}
+
+	// in the money at zero vol the call is the forward less the strike
+	pars := &bs.PriceParams{Vol: 0, TimeToExpiry: tau, Underlying: x, Strike: 80, Rate: r, Dividend: q, Type: bs.Call}
+	xq, kr := x*math.Exp(-q*tau), 80*math.Exp(-r*tau)
+	if lambda, err := bs.Lambda(pars); err != nil || math.Abs(lambda-xq/(xq-kr)) > 1e-12 {
+		t.Errorf("zero vol in the money: Lambda = %v, %v", lambda, err)
+	}
+
+	pars.Strike = 120
+	if lambda, err := bs.Lambda(pars); err != bs.ErrZeroPremium || !math.IsNaN(lambda) {
+		t.Errorf("zero vol out of the money: Lambda = %v, %v", lambda, err)
+	}
+	pars.Vol, pars.Strike = v, -1
+	if lambda, err := bs.Lambda(pars); err != bs.ErrNegStrike || !math.IsNaN(lambda) {
+		t.Errorf("negative strike: Lambda = %v, %v", lambda, err)
+	}
+	if _, err := bs.Lambda(nil); err != bs.ErrNilPtrArg {
+		t.Errorf("nil params: %v", err)
+	}
+}
@@ -0,0 +1,306 @@
+package digitaltest
+
+import (
+	"math"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+func price(t *testing.T, v, tau, x, k, r, q float64, o bs.OptionType) float64 {
+	p, err := bs.PriceDigital(v, tau, x, k, 10, r, q, o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func Test_DigitalGreeks(t *testing.T) {
+
+	const x, payout, r, q = 100.0, 10.0, 0.04, 0.01
+
+	for _, o := range []bs.OptionType{bs.Call, bs.Put, bs.Straddle} {
+		for _, v := range []float64{0.1, 0.3} {
+			for _, tau := range []float64{0.1, 1} {
+				for _, k := range []float64{80, 95, 100, 110, 130} {
+
+					hx, hv, ht, hr := 1e-4*x, 1e-4, 1e-5, 1e-6
+
+					p0 := price(t, v, tau, x, k, r, q, o)
+					pu, pd := price(t, v, tau, x+hx, k, r, q, o), price(t, v, tau, x-hx, k, r, q, o)
+
+					for _, c := range []struct {
+						name string
+						f    func(v, t, x, k, payout, r, q float64, o bs.OptionType) (float64, error)
+						num  float64
+					}{
+						{"Delta", bs.DeltaDigital, (pu - pd) / 2 / hx},
+						{"Gamma", bs.GammaDigital, (pu - 2*p0 + pd) / hx / hx},
+						{"Vega", bs.VegaDigital,
+							(price(t, v+hv, tau, x, k, r, q, o) - price(t, v-hv, tau, x, k, r, q, o)) / 2 / hv},
+						{"Theta", bs.ThetaDigital,
+							(price(t, v, tau-ht, x, k, r, q, o) - price(t, v, tau+ht, x, k, r, q, o)) / 2 / ht},
+						{"Rho", bs.RhoDigital,
+							(price(t, v, tau, x, k, r+hr, q, o) - price(t, v, tau, x, k, r-hr, q, o)) / 2 / hr},
+					} {
+						got, err := c.f(v, tau, x, k, payout, r, q, o)
+						if err != nil {
+							t.Fatal(err)
+						}
+						if math.Abs(got-c.num) > 1e-5*math.Max(1, math.Abs(got)) {
+							t.Errorf("Type = %c, Vol = %v, T = %v, Strike = %v: %s = %v, numeric %v",
+								o, v, tau, k, c.name, got, c.num)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	// call + put = straddle = discounted payout
+	call, put := price(t, 0.2, 0.5, x, 105, r, q, bs.Call), price(t, 0.2, 0.5, x, 105, r, q, bs.Put)
+	if want := payout * math.Exp(-r*0.5); math.Abs(call+put-want) > 1e-14 {
+		t.Errorf("call + put = %v, want %v", call+put, want)
+	}
+}
+
+func Test_DigitalNearExpiry(t *testing.T) {
+
+	const x, v, payout = 100.0, 0.2, 10.0
+
+	// just above TimeFloor the at the money greeks are large but finite
+	tau := 1e-9
+	delta, _ := bs.DeltaDigital(v, tau, x, x, payout, 0, 0, bs.Call)
+	if want := payout * bs.NormPDF(0) / x / v / math.Sqrt(tau); math.Abs(delta/want-1) > 1e-6 {
+		t.Errorf("Delta = %v, want %v", delta, want)
+	}
+
+	// below TimeFloor, and at zero vol, the step limits apply. With r == q
+	// theta is infinite for v > 0 and r times the price at zero vol.
+	for _, tv := range []struct{ tau, v, theta float64 }{{1e-12, v, math.Inf(-1)}, {0.5, 0, 0}} {
+
+		g := make(map[string]float64)
+		for _, f := range []struct {
+			name string
+			f    func(v, t, x, k, payout, r, q float64, o bs.OptionType) (float64, error)
+		}{
+			{"Price", bs.PriceDigital},
+			{"Delta", bs.DeltaDigital},
+			{"Gamma", bs.GammaDigital},
+			{"Vega", bs.VegaDigital},
+			{"Theta", bs.ThetaDigital},
+		} {
+			var err error
+			if g[f.name], err = f.f(tv.v, tv.tau, x, x, payout, 0, 0, bs.Put); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if g["Price"] != payout/2 || !math.IsInf(g["Delta"], -1) || !math.IsInf(g["Gamma"], 1) ||
+			g["Theta"] != tv.theta || math.IsNaN(g["Vega"]) {
+			t.Errorf("T = %v, Vol = %v: at the money put = %v", tv.tau, tv.v, g)
+		}
+
+		for _, k := range []float64{0, 90, 110} {
+			for _, f := range []func(v, t, x, k, payout, r, q float64, o bs.OptionType) (float64, error){
+				bs.DeltaDigital, bs.GammaDigital, bs.VegaDigital,
+			} {
+				if d, _ := f(tv.v, tv.tau, x, k, payout, 0, 0, bs.Call); d != 0 {
+					t.Errorf("T = %v, Vol = %v, Strike = %v: greek = %v, want 0", tv.tau, tv.v, k, d)
+				}
+			}
+		}
+
+		if p, _ := bs.PriceDigital(tv.v, tv.tau, x, 90, payout, 0, 0, bs.Call); p != payout {
+			t.Errorf("in the money price = %v", p)
+		}
+	}
+
+	if _, err := bs.PriceDigital(-0.2, 1, x, x, payout, 0, 0, bs.Call); err != bs.ErrNegVol {
+		t.Errorf("err = %v", err)
+	}
+	if _, err := bs.DeltaDigital(0.2, 1, x, -1, payout, 0, 0, bs.Call); err != bs.ErrNegStrike {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func Test_RangeBinary(t *testing.T) {
+
+	const x, payout, r, q = 100.0, 10.0, 0.04, 0.01
+	const tau = 0.5
+	df := payout * math.Exp(-r*tau)
+
+	for _, v := range []float64{0, 0.1, 0.3} {
+		for _, c := range []struct{ lo, hi float64 }{{lo: 90, hi: 110}, {lo: 100, hi: 140}, {lo: 0, hi: 95}} {
+
+			p, err := bs.PriceRangeBinary(v, tau, x, c.lo, c.hi, payout, r, q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			d, _ := bs.DeltaRangeBinary(v, tau, x, c.lo, c.hi, payout, r, q)
+			vg, _ := bs.VegaRangeBinary(v, tau, x, c.lo, c.hi, payout, r, q)
+
+			for _, g := range []struct {
+				name string
+				got  float64
+				f    func(v, t, x, k, payout, r, q float64, o bs.OptionType) (float64, error)
+			}{
+				{"Price", p, bs.PriceDigital},
+				{"Delta", d, bs.DeltaDigital},
+				{"Vega", vg, bs.VegaDigital},
+			} {
+				lo, _ := g.f(v, tau, x, c.lo, payout, r, q, bs.Call)
+				hi, _ := g.f(v, tau, x, c.hi, payout, r, q, bs.Call)
+				if g.got != lo-hi {
+					t.Errorf("v = %v, %+v: %s = %v, want %v", v, c, g.name, g.got, lo-hi)
+				}
+			}
+		}
+	}
+
+	// a range widening to (0, Inf) pays for sure
+	prev := 0.0
+	for _, w := range []float64{0.5, 0.9, 0.99, 0.9999} {
+		p, err := bs.PriceRangeBinary(0.3, tau, x, x*(1-w), x/(1-w), payout, r, q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !(p >= prev) || p > df {
+			t.Errorf("width %v: %v after %v, discounted payout %v", w, p, prev, df)
+		}
+		prev = p
+	}
+	if math.Abs(prev-df) > 1e-9 {
+		t.Errorf("widest range %v, want %v", prev, df)
+	}
+
+	// at zero vol a range below the forward pays nothing, as do its greeks
+	g := [3]float64{}
+	g[0], _ = bs.PriceRangeBinary(0, tau, x, 80, 95, payout, r, q)
+	g[1], _ = bs.DeltaRangeBinary(0, tau, x, 80, 95, payout, r, q)
+	g[2], _ = bs.VegaRangeBinary(0, tau, x, 80, 95, payout, r, q)
+	if g != [3]float64{} {
+		t.Errorf("range below spot at zero vol: %v", g)
+	}
+	// and one around it pays the discounted payout
+	if p, _ := bs.PriceRangeBinary(0, tau, x, 95, 110, payout, r, q); p != df {
+		t.Errorf("range around spot at zero vol: %v, want %v", p, df)
+	}
+
+	for _, c := range []struct{ lo, hi float64 }{{lo: 110, hi: 90}, {lo: 100, hi: 100}, {lo: math.NaN(), hi: 100}} {
+		if _, err := bs.PriceRangeBinary(0.2, tau, x, c.lo, c.hi, payout, r, q); err != bs.ErrStrikeOrder {
+			t.Errorf("%+v: got %v, want ErrStrikeOrder", c, err)
+		}
+	}
+	if _, err := bs.PriceRangeBinary(-0.2, tau, x, 90, 110, payout, r, q); err != bs.ErrNegVol {
+		t.Errorf("got %v, want ErrNegVol", err)
+	}
+}
+
+func Test_AssetDigital(t *testing.T) {
+
+	const x, r, q, tau = 100.0, 0.04, 0.01, 0.5
+	xq := x * math.Exp(-q*tau)
+
+	for _, v := range []float64{0, 0.1, 0.4} {
+		for _, k := range []float64{0, 80, 100, 125} {
+
+			c, _ := bs.PriceAssetDigital(v, tau, x, k, r, q, bs.Call)
+			p, _ := bs.PriceAssetDigital(v, tau, x, k, r, q, bs.Put)
+			s, _ := bs.PriceAssetDigital(v, tau, x, k, r, q, bs.Straddle)
+			if math.Abs(c+p-xq) > 1e-12 || s != xq {
+				t.Errorf("v = %v, k = %v: call %v + put %v, straddle %v, want %v", v, k, c, p, s, xq)
+			}
+
+			// the vanilla call is the asset digital less k cash digitals
+			if v > 0 && k > 0 {
+				d, _ := bs.PriceDigital(v, tau, x, k, 1, r, q, bs.Call)
+				if want := bs.BSPrice(v, tau, x, k, r, q, bs.Call); math.Abs(c-k*d-want) > 1e-10 {
+					t.Errorf("v = %v, k = %v: %v - k*%v, want %v", v, k, c, d, want)
+				}
+
+				for _, o := range []bs.OptionType{bs.Call, bs.Put} {
+					const h = 1e-4
+					up, _ := bs.PriceAssetDigital(v, tau, x+h, k, r, q, o)
+					dn, _ := bs.PriceAssetDigital(v, tau, x-h, k, r, q, o)
+					delta, _ := bs.DeltaAssetDigital(v, tau, x, k, r, q, o)
+					if num := (up - dn) / 2 / h; math.Abs(delta-num) > 1e-6*math.Max(1, math.Abs(num)) {
+						t.Errorf("v = %v, k = %v, %v: delta %v, want %v", v, k, o, delta, num)
+					}
+				}
+			}
+		}
+	}
+
+	if _, err := bs.PriceAssetDigital(-0.1, tau, x, 100, r, q, bs.Call); err != bs.ErrNegVol {
+		t.Errorf("got %v, want ErrNegVol", err)
+	}
+}
+
+func Test_Supershare(t *testing.T) {
+
+	const v, x, r, q, tau = 0.25, 100.0, 0.04, 0.01, 0.5
+
+	for _, c := range []struct{ lo, hi float64 }{{lo: 90, hi: 110}, {lo: 100, hi: 105}, {lo: 60, hi: 80}} {
+
+		p, err := bs.PriceSupershare(v, tau, x, c.lo, c.hi, r, q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lo, _ := bs.PriceAssetDigital(v, tau, x, c.lo, r, q, bs.Call)
+		hi, _ := bs.PriceAssetDigital(v, tau, x, c.hi, r, q, bs.Call)
+		if want := (lo - hi) / c.lo; math.Abs(p-want) > 1e-14 {
+			t.Errorf("%+v: %v, want %v", c, p, want)
+		}
+
+		payoff := func(s float64) float64 {
+			if c.lo <= s && s < c.hi {
+				return s / c.lo
+			}
+			return 0
+		}
+		mc, err := bs.PayoffSim(v, tau, x, r, q, payoff, 200000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(mc-p) > 1e-4 {
+			t.Errorf("%+v: %v, Monte Carlo %v", c, p, mc)
+		}
+
+		const h = 1e-4
+		up, _ := bs.PriceSupershare(v, tau, x+h, c.lo, c.hi, r, q)
+		dn, _ := bs.PriceSupershare(v, tau, x-h, c.lo, c.hi, r, q)
+		delta, _ := bs.DeltaSupershare(v, tau, x, c.lo, c.hi, r, q)
+		if num := (up - dn) / 2 / h; math.Abs(delta-num) > 1e-7 {
+			t.Errorf("%+v: delta %v, want %v", c, delta, num)
+		}
+	}
+
+	// an unbounded range is the asset digital over the lower strike
+	ad, _ := bs.PriceAssetDigital(v, tau, x, 95, r, q, bs.Call)
+	prev := 0.0
+	for _, hi := range []float64{150, 300, 1000, 1e5} {
+		p, _ := bs.PriceSupershare(v, tau, x, 95, hi, r, q)
+		if !(p >= prev) {
+			t.Errorf("upper strike %v: %v after %v", hi, p, prev)
+		}
+		prev = p
+	}
+	if math.Abs(prev-ad/95) > 1e-14 {
+		t.Errorf("unbounded supershare %v, want %v", prev, ad/95)
+	}
+
+	for _, c := range []struct {
+		lo, hi float64
+		err    error
+	}{
+		{lo: 0, hi: 100, err: bs.ErrNegStrike},
+		{lo: -1, hi: 100, err: bs.ErrNegStrike},
+		{lo: 110, hi: 100, err: bs.ErrStrikeOrder},
+		{lo: 100, hi: 100, err: bs.ErrStrikeOrder},
+	} {
+		if _, err := bs.PriceSupershare(v, tau, x, c.lo, c.hi, r, q); err != c.err {
+			t.Errorf("%+v: got %v", c, err)
+		}
+	}
+}
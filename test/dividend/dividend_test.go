@@ -0,0 +1,206 @@
+package dividendtest
+
+import (
+	"math"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+const vol, tau, spot, r = 0.3, 1.0, 100.0, 0.05
+
+// tree prices a European option in the spot model with one cash dividend
+// d at td: a Cox-Ross-Rubinstein tree of the spot up to the dividend,
+// whose nodes take the Black Scholes value of the ex-dividend spot over
+// the remaining time
+func tree(k, td, d float64, o bs.OptionType, steps int) float64 {
+
+	dt := td / float64(steps)
+	u := math.Exp(vol * math.Sqrt(dt))
+	p := (math.Exp(r*dt) - 1/u) / (u - 1/u)
+	df := math.Exp(-r * dt)
+
+	vals := make([]float64, steps+1)
+	for j := range vals {
+		s := spot * math.Pow(u, float64(2*j-steps))
+		vals[j] = bs.BSPrice(vol, tau-td, math.Max(s-d, 0), k, r, 0, o)
+	}
+	for i := steps - 1; i >= 0; i-- {
+		for j := 0; j <= i; j++ {
+			vals[j] = df * (p*vals[j+1] + (1-p)*vals[j])
+		}
+	}
+
+	return vals[0]
+}
+
+// Test_PriceDiscreteDividend checks the methods against the tree for a
+// dividend of 5 on a spot of 100. Escrowed always underprices and forward
+// overprices, each by up to about 0.5. Vol adjusted is closest for a near
+// dividend, within 2e-4 at a twentieth of the life in, and Bos-Vandermark
+// for a far one, within 3e-3 just before expiry; neither is off by more
+// than about 0.05 anywhere.
+func Test_PriceDiscreteDividend(t *testing.T) {
+
+	const d = 5
+
+	for _, td := range []float64{0.05, 0.5, 0.95} {
+		for _, o := range []bs.OptionType{bs.Call, bs.Put, bs.Straddle} {
+			for _, k := range []float64{80, 100, 120} {
+
+				want := tree(k, td, d, o, 2000)
+
+				var errs [4]float64
+				for m := range errs {
+					p, err := bs.PriceDiscreteDividend(
+						vol, tau, spot, k, r, []bs.Dividend{{Time: td, Amount: d}}, o, bs.DiscreteDivMethod(m))
+					if err != nil {
+						t.Fatal(err)
+					}
+					errs[m] = math.Abs(p - want)
+					if m == int(bs.DivEscrowed) && !(p < want) || m == int(bs.DivForward) && !(p > want) {
+						t.Errorf("td %v %c %v: %v price %v, tree %v", td, o, k, bs.DiscreteDivMethod(m), p, want)
+					}
+				}
+
+				escrowed, forward, bv, adjusted := errs[0], errs[1], errs[2], errs[3]
+				switch {
+				case td == 0.05 && !(adjusted < 2e-3 && adjusted < bv && escrowed < forward):
+					t.Errorf("td %v %c %v: near dividend errors %v", td, o, k, errs)
+				case td == 0.95 && !(bv < 1e-2 && bv < adjusted && forward < escrowed):
+					t.Errorf("td %v %c %v: far dividend errors %v", td, o, k, errs)
+				case !(bv < 0.1 && adjusted < 0.1):
+					t.Errorf("td %v %c %v: errors %v", td, o, k, errs)
+				}
+			}
+		}
+	}
+}
+
+func Test_PriceDiscreteDividendEdges(t *testing.T) {
+
+	// dividends after expiry are ignored and every method agrees at expiry
+	divs := []bs.Dividend{{Time: 0.5, Amount: 2}, {Time: 0.2, Amount: 1}, {Time: 2, Amount: 50}}
+	escrow := 2*math.Exp(-r*0.5) + math.Exp(-r*0.2)
+	p, err := bs.PriceDiscreteDividend(vol, tau, spot, 100, r, divs, bs.Call, bs.DivEscrowed)
+	if want := bs.BSPrice(vol, tau, spot-escrow, 100, r, 0, bs.Call); err != nil || math.Abs(p-want) > 1e-12 {
+		t.Errorf("price %v, want %v, err = %v", p, want, err)
+	}
+	p, err = bs.PriceDiscreteDividend(vol, tau, spot, 100, r, divs, bs.Call, bs.DivForward)
+	if want := bs.BSPrice(vol, tau, spot, 100+escrow*math.Exp(r*tau), r, 0, bs.Call); err != nil || math.Abs(p-want) > 1e-12 {
+		t.Errorf("price %v, want %v, err = %v", p, want, err)
+	}
+	for m := bs.DivEscrowed; m <= bs.DivVolAdjusted; m++ {
+		p, err := bs.PriceDiscreteDividend(vol, 0, spot, 90, r, []bs.Dividend{{Time: 0, Amount: 3}}, bs.Call, m)
+		if err != nil || math.Abs(p-7) > 1e-12 {
+			t.Errorf("%v: price at expiry %v, err = %v", m, p, err)
+		}
+	}
+
+	for _, d := range []bs.Dividend{{Time: -1, Amount: 1}, {Time: 0.5, Amount: -1}, {Time: 0.5, Amount: 200}} {
+		if _, err := bs.PriceDiscreteDividend(vol, tau, spot, 100, r, []bs.Dividend{d}, bs.Put, bs.DivEscrowed); err != bs.ErrDividend {
+			t.Errorf("%+v: err = %v", d, err)
+		}
+	}
+	if _, err := bs.PriceDiscreteDividend(vol, tau, spot, 100, r, nil, bs.Put, bs.DiscreteDivMethod(9)); err != bs.ErrDiscreteDivMethod {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func Test_LatticeDividendsEuropean(t *testing.T) {
+
+	divs := []bs.Dividend{{Time: 0.3, Amount: 2}, {Time: 0.8, Amount: 3}}
+
+	for _, o := range []bs.OptionType{bs.Call, bs.Put, bs.Straddle} {
+		for _, k := range []float64{80, 100, 120} {
+
+			want, err := bs.PriceDiscreteDividend(vol, tau, spot, k, r, divs, o, bs.DivEscrowed)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			lr, err := bs.PriceLeisenReimerDividends(vol, tau, spot, k, r, divs, o, bs.European, 501)
+			if err != nil {
+				t.Fatal(err)
+			}
+			crr, err := bs.PriceBinomialDividends(vol, tau, spot, k, r, divs, o, bs.European, 2000)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(lr-want) > 1e-4 || math.Abs(crr-want) > 1e-2 {
+				t.Errorf("%v %v: Leisen-Reimer %v, binomial %v, escrowed %v", o, k, lr, crr, want)
+			}
+		}
+	}
+}
+
+func Test_LatticeDividendsAmerican(t *testing.T) {
+
+	const k, td = 100.0, 0.5
+
+	// a dividend of 0.1 is below the interest on the strike to expiry, so
+	// the call is never exercised early, while one of 8 is worth exercising
+	// for just before it is paid
+	for _, c := range []struct {
+		d        float64
+		exercise bool
+	}{{d: 0.1, exercise: false}, {d: 8, exercise: true}} {
+
+		divs := []bs.Dividend{{Time: td, Amount: c.d}}
+		am, err := bs.PriceLeisenReimerDividends(vol, tau, spot, k, r, divs, bs.Call, bs.American, 501)
+		if err != nil {
+			t.Fatal(err)
+		}
+		eu, _ := bs.PriceLeisenReimerDividends(vol, tau, spot, k, r, divs, bs.Call, bs.European, 501)
+
+		if premium := am - eu; c.exercise && !(premium > 0.1) || !c.exercise && premium != 0 {
+			t.Errorf("dividend %v: early exercise premium %v", c.d, premium)
+		}
+
+		rgw, _ := bs.PriceAmericanCallRGW(vol, tau, spot, k, r, c.d, td)
+		crr, _ := bs.PriceBinomialDividends(vol, tau, spot, k, r, divs, bs.Call, bs.American, 2000)
+		if math.Abs(am-rgw) > 5e-3 || math.Abs(crr-rgw) > 1e-2 {
+			t.Errorf("dividend %v: Leisen-Reimer %v, binomial %v, Roll-Geske-Whaley %v", c.d, am, crr, rgw)
+		}
+	}
+
+	// the American put gains from the dividend as a European put does
+	divs := []bs.Dividend{{Time: td, Amount: 5}}
+	with, _ := bs.PriceLeisenReimerDividends(vol, tau, spot, k, r, divs, bs.Put, bs.American, 501)
+	without, _ := bs.PriceLeisenReimer(vol, tau, spot, k, r, 0, bs.Put, bs.American, 501)
+	if !(with > without) {
+		t.Errorf("put %v with dividend, %v without", with, without)
+	}
+}
+
+func Test_ImpliedVolAmericanDividends(t *testing.T) {
+
+	divs := []bs.Dividend{{Time: 0.25, Amount: 1.5}, {Time: 0.75, Amount: 4}}
+
+	for _, o := range []bs.OptionType{bs.Call, bs.Put} {
+		for _, v := range []float64{0.1, 0.3, 0.7} {
+			for _, k := range []float64{80, 100, 125} {
+
+				p, err := bs.PriceLeisenReimerDividends(v, tau, spot, k, r, divs, o, bs.American, bs.AmericanIVStepsDefault)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got, err := bs.ImpliedVolAmericanDividends(p, tau, spot, k, r, divs, o, bs.AmericanIVConfig{Tol: 1e-10})
+				if err != nil {
+					t.Fatalf("%v v %v k %v: %v", o, v, k, err)
+				}
+				if math.Abs(got-v) > 1e-6 {
+					t.Errorf("%v v %v k %v: implied %v", o, v, k, got)
+				}
+			}
+		}
+	}
+
+	if _, err := bs.ImpliedVolAmericanDividends(150, tau, spot, 100, r, divs, bs.Call, bs.AmericanIVConfig{}); err != bs.ErrArbitrage {
+		t.Errorf("premium above the spot: %v", err)
+	}
+	bad := []bs.Dividend{{Time: 0.5, Amount: -1}}
+	if _, err := bs.PriceBinomialDividends(vol, tau, spot, 100, r, bad, bs.Call, bs.American, 100); err != bs.ErrDividend {
+		t.Errorf("negative dividend: %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+package dualtest
+
+import (
+	"math"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+const vol, tau, spot, r, q = 0.25, 0.5, 100.0, 0.05, 0.02
+
+var types = []bs.OptionType{bs.Call, bs.Put, bs.Straddle}
+
+func Test_DualGreeks(t *testing.T) {
+
+	dfr := math.Exp(-r * tau)
+
+	for _, k := range []float64{60, 90, 100, 110, 160} {
+
+		h := 1e-4 * k
+		for _, o := range types {
+
+			pu, pm, pd := bs.BSPrice(vol, tau, spot, k+h, r, q, o), bs.BSPrice(vol, tau, spot, k, r, q, o), bs.BSPrice(vol, tau, spot, k-h, r, q, o)
+			dd, dg := bs.BSDualDelta(vol, tau, spot, k, r, q, o), bs.BSDualGamma(vol, tau, spot, k, r, q, o)
+
+			if num := (pu - pd) / 2 / h; math.Abs(dd-num) > 1e-7 {
+				t.Errorf("Type = %c, Strike = %v: DualDelta = %v, want %v", o, k, dd, num)
+			}
+			if num := (pu - 2*pm + pd) / h / h; math.Abs(dg-num) > 1e-6 {
+				t.Errorf("Type = %c, Strike = %v: DualGamma = %v, want %v", o, k, dg, num)
+			}
+
+			pars := &bs.PriceParams{Vol: vol, TimeToExpiry: tau, Underlying: spot, Strike: k, Rate: r, Dividend: q, Type: o}
+			if got, err := bs.DualDelta(pars); err != nil || got != dd {
+				t.Errorf("Type = %c, Strike = %v: DualDelta = %v, %v", o, k, got, err)
+			}
+			if got, err := bs.DualGamma(pars); err != nil || got != dg {
+				t.Errorf("Type = %c, Strike = %v: DualGamma = %v, %v", o, k, got, err)
+			}
+		}
+
+		// put-call parity makes the put dual delta that of the call plus the
+		// discount factor, and their dual gammas equal
+		c, p, s := bs.BSDualDelta(vol, tau, spot, k, r, q, bs.Call), bs.BSDualDelta(vol, tau, spot, k, r, q, bs.Put), bs.BSDualDelta(vol, tau, spot, k, r, q, bs.Straddle)
+		if math.Abs(p-c-dfr) > 1e-15 || math.Abs(s-c-p) > 1e-15 {
+			t.Errorf("Strike = %v: dual deltas call %v, put %v, straddle %v", k, c, p, s)
+		}
+		gc, gp, gs := bs.BSDualGamma(vol, tau, spot, k, r, q, bs.Call), bs.BSDualGamma(vol, tau, spot, k, r, q, bs.Put), bs.BSDualGamma(vol, tau, spot, k, r, q, bs.Straddle)
+		if gc != gp || gs != 2*gc {
+			t.Errorf("Strike = %v: dual gammas call %v, put %v, straddle %v", k, gc, gp, gs)
+		}
+
+		// the price is homogeneous of degree 1 in the spot and strike
+		pc := bs.BSPrice(vol, tau, spot, k, r, q, bs.Call)
+		if euler := spot*bs.BSDelta(vol, tau, spot, k, r, q, bs.Call) + k*c; math.Abs(euler-pc) > 1e-12 {
+			t.Errorf("Strike = %v: x*delta + k*dual delta = %v, price %v", k, euler, pc)
+		}
+	}
+}
+
+func Test_DualGreeksBoundaries(t *testing.T) {
+
+	dfr := math.Exp(-r * tau)
+
+	for _, c := range []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"zero strike call", bs.BSDualDelta(vol, tau, spot, 0, r, q, bs.Call), -dfr},
+		{"zero strike put", bs.BSDualDelta(vol, tau, spot, 0, r, q, bs.Put), 0},
+		{"zero spot put", bs.BSDualDelta(vol, tau, 0, 100, r, q, bs.Put), dfr},
+		{"zero vol call in the money", bs.BSDualDelta(0, tau, spot, 80, r, q, bs.Call), -dfr},
+		{"zero vol straddle out of the money", bs.BSDualDelta(0, tau, spot, 120, r, q, bs.Straddle), dfr},
+		{"expiry call at the money", bs.BSDualDelta(vol, 0, spot, spot, r, q, bs.Call), -0.5},
+		{"expiry put in the money", bs.BSDualDelta(vol, 0, spot, 110, r, q, bs.Put), 1},
+		{"zero strike dual gamma", bs.BSDualGamma(vol, tau, spot, 0, r, q, bs.Call), 0},
+		{"zero vol dual gamma", bs.BSDualGamma(0, tau, spot, 120, r, q, bs.Put), 0},
+		{"expiry dual gamma at the money", bs.BSDualGamma(vol, 0, spot, spot, r, q, bs.Straddle), math.Inf(1)},
+	} {
+		if c.got != c.want && math.Abs(c.got-c.want) > 1e-15 {
+			t.Errorf("%s = %v, want %v", c.name, c.got, c.want)
+		}
+	}
+
+	// the zero vol dual delta is the limit of the formula
+	for _, o := range types {
+		zero, lim := bs.BSDualDelta(0, tau, spot, 80, r, q, o), bs.BSDualDelta(1e-6, tau, spot, 80, r, q, o)
+		if math.Abs(zero-lim) > 1e-12 {
+			t.Errorf("Type = %c: zero vol dual delta %v, limit %v", o, zero, lim)
+		}
+	}
+
+	pars := &bs.PriceParams{Vol: vol, TimeToExpiry: tau, Underlying: spot, Strike: -1, Rate: r, Dividend: q, Type: bs.Call}
+	if dd, err := bs.DualDelta(pars); err == nil || !math.IsNaN(dd) {
+		t.Errorf("negative strike: DualDelta = %v, %v", dd, err)
+	}
+	if dg, err := bs.DualGamma(pars); err == nil || !math.IsNaN(dg) {
+		t.Errorf("negative strike: DualGamma = %v, %v", dg, err)
+	}
+}
@@ -0,0 +1,112 @@
+package esotest
+
+import (
+	"math"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+const (
+	v, tau, x, k, r = 0.3, 4.0, 100.0, 100.0, 0.05
+	steps           = 500
+)
+
+func Test_ESOTree(t *testing.T) {
+
+	for _, q := range []float64{0, 0.03} {
+
+		got, err := bs.PriceESO(v, tau, x, k, r, q, 0, 0, math.Inf(1), steps)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := bs.PriceBinomial(v, tau, x, k, r, q, bs.Call, bs.European, steps)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(got-want) > 1e-9 {
+			t.Errorf("q = %v: ESO %v, European tree %v", q, got, want)
+		}
+
+		if q > 0 {
+			continue
+		}
+
+		// without dividends an American call is never exercised early
+		am, err := bs.PriceBinomial(v, tau, x, k, r, q, bs.Call, bs.American, steps)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(got-am) > 1e-9 {
+			t.Errorf("ESO %v, American tree %v", got, am)
+		}
+	}
+}
+
+func Test_ESOExitRate(t *testing.T) {
+
+	for _, m := range []float64{1.5, 2.5, math.Inf(1)} {
+		for _, vest := range []float64{0, 1, 3} {
+
+			prev := math.Inf(1)
+			for _, exit := range []float64{0, 0.02, 0.05, 0.1, 0.2, 0.5} {
+				p, err := bs.PriceESO(v, tau, x, k, r, 0.01, vest, exit, m, steps)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !(p < prev) {
+					t.Errorf("m = %v, vest = %v, exit = %v: %v not below %v", m, vest, exit, p, prev)
+				}
+				prev = p
+			}
+		}
+	}
+}
+
+func Test_ESOBounds(t *testing.T) {
+
+	bsp := bs.BSPriceNoErrorCheck(v, tau, x, k, r, 0.01, bs.Call)
+
+	for _, m := range []float64{1, 1.5, 3} {
+		for _, vest := range []float64{0, 2} {
+			p, err := bs.PriceESO(v, tau, x, k, r, 0.01, vest, 0.05, m, steps)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !(p > 0 && p < bsp) {
+				t.Errorf("m = %v, vest = %v: %v outside (0, %v)", m, vest, p, bsp)
+			}
+		}
+	}
+
+	p, err := bs.PriceESO(v, tau, x, k, r, 0.01, tau+1, 0, math.Inf(1), steps)
+	if err != nil || p != 0 {
+		t.Errorf("vesting after expiry: %v, %v", p, err)
+	}
+
+	// an option exercised at once is worth its intrinsic value
+	p, err = bs.PriceESO(v, tau, 150, k, r, 0.01, 0, 0, 1.2, steps)
+	if err != nil || p != 50 {
+		t.Errorf("exercise at once: %v, %v", p, err)
+	}
+}
+
+func Test_ESOErrors(t *testing.T) {
+
+	for _, c := range []struct {
+		vest, exit, m float64
+		steps         int
+		err           error
+	}{
+		{vest: -1, exit: 0, m: 2, steps: steps, err: bs.ErrESO},
+		{vest: 0, exit: -0.1, m: 2, steps: steps, err: bs.ErrESO},
+		{vest: 0, exit: 0, m: 0.5, steps: steps, err: bs.ErrESO},
+		{vest: 0, exit: 0, m: math.NaN(), steps: steps, err: bs.ErrESO},
+		{vest: 0, exit: 0, m: 2, steps: 1, err: bs.ErrSteps},
+	} {
+		p, err := bs.PriceESO(v, tau, x, k, r, 0, c.vest, c.exit, c.m, c.steps)
+		if err != c.err || !math.IsNaN(p) {
+			t.Errorf("%+v: got %v, %v", c, p, err)
+		}
+	}
+}
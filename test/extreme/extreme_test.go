@@ -0,0 +1,267 @@
+package extremetest
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+func Test_TinyTimeToExpiry(t *testing.T) {
+
+	const tau float64 = 1e-15
+	v, r, q := 0.5, 0.1, 0.05
+
+	for _, o := range []bs.OptionType{bs.Call, bs.Put, bs.Straddle} {
+		for _, k := range []float64{80, 100, 120} {
+
+			x := 100.0
+			pars := &bs.PriceParams{
+				Vol: v, TimeToExpiry: tau, Underlying: x, Strike: k,
+				Rate: r, Dividend: q, Type: o,
+			}
+
+			price, err := bs.Price(pars)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if intr := bs.Intrinsic(tau, x, k, r, q, o); price != intr {
+				t.Errorf("Price = %v, want intrinsic %v", price, intr)
+			}
+
+			delta, err := bs.Delta(pars)
+			if err != nil || math.IsNaN(delta) {
+				t.Fatalf("Delta = %v, %v", delta, err)
+			}
+			if want := bs.ZeroVolBSDelta(tau, x, k, r, q, o); delta != want {
+				t.Errorf("Delta = %v, want %v", delta, want)
+			}
+
+			for name, f := range map[string]func(*bs.PriceParams) (float64, error){
+				"Gamma": bs.Gamma, "Vega": bs.Vega, "Theta": bs.Theta,
+			} {
+				g, err := f(pars)
+				if err != nil || math.IsNaN(g) {
+					t.Errorf("%s = %v, %v", name, g, err)
+				}
+			}
+
+			implvol, err := bs.ImpliedVol(&bs.ImpliedVolParams{
+				Premium: price, TimeToExpiry: tau, Underlying: x, Strike: k,
+				Rate: r, Dividend: q, Type: o,
+			})
+			if err != nil || implvol != 0 {
+				t.Errorf("ImpliedVol = %v, %v", implvol, err)
+			}
+
+			t.Logf("Type = %c, Strike = %5.1f, Price = %8.4f, Delta = %6.3f",
+				o, k, price, delta)
+		}
+	}
+}
+
+func Test_TimeFloorParam(t *testing.T) {
+
+	const tau float64 = 1e-3
+	x, v, r, q := 100.0, 0.5, 0.1, 0.05
+
+	for _, o := range []bs.OptionType{bs.Call, bs.Put, bs.Straddle} {
+		for _, k := range []float64{98, 102} {
+
+			pars := &bs.PriceParams{
+				Vol: v, TimeToExpiry: tau, Underlying: x, Strike: k,
+				Rate: r, Dividend: q, Type: o, TimeFloor: 1e-2,
+			}
+
+			// above the floor of the call the option is expired
+			price, err := bs.Price(pars)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if intr := bs.Intrinsic(tau, x, k, r, q, o); price != intr {
+				t.Errorf("Type = %c, Strike = %v: Price = %v, want intrinsic %v", o, k, price, intr)
+			}
+			if delta, _ := bs.Delta(pars); delta != bs.ZeroVolBSDelta(tau, x, k, r, q, o) {
+				t.Errorf("Type = %c, Strike = %v: Delta = %v", o, k, delta)
+			}
+			if g, _ := bs.PriceAndGreeks(pars); g.Price != price || g.Vega != 0 {
+				t.Errorf("Type = %c, Strike = %v: PriceAndGreeks = %+v", o, k, g)
+			}
+
+			iv := &bs.ImpliedVolParams{
+				Premium: bs.BSPrice(v, tau, x, k, r, q, o), TimeToExpiry: tau, Underlying: x, Strike: k,
+				Rate: r, Dividend: q, Type: o, TimeFloor: 1e-2,
+			}
+			if vol, err := bs.ImpliedVol(iv); err != nil || vol != 0 {
+				t.Errorf("Type = %c, Strike = %v: ImpliedVol = %v, %v", o, k, vol, err)
+			}
+
+			// floors below TimeFloor keep it
+			pars.TimeFloor, iv.TimeFloor = 1e-20, 1e-20
+			if p, _ := bs.Price(pars); p != bs.BSPrice(v, tau, x, k, r, q, o) {
+				t.Errorf("Type = %c, Strike = %v: Price = %v, want %v", o, k, p, bs.BSPrice(v, tau, x, k, r, q, o))
+			}
+			if vol, err := bs.ImpliedVol(iv); err != nil || math.Abs(vol-v) > 1e-6 {
+				t.Errorf("Type = %c, Strike = %v: ImpliedVol = %v, %v", o, k, vol, err)
+			}
+
+			pars.TimeToExpiry = 1e-15
+			if p, _ := bs.Price(pars); p != bs.Intrinsic(1e-15, x, k, r, q, o) {
+				t.Errorf("Type = %c, Strike = %v: Price at 1e-15 = %v", o, k, p)
+			}
+		}
+	}
+}
+
+func Test_ExtremeDiscountExponents(t *testing.T) {
+
+	cases := []struct {
+		tau, r, q float64
+		term      bs.DiscountTerm
+	}{
+		{100, 8, 0, bs.DiscountRate},
+		{100, 0, -8, bs.DiscountDividend},
+		{1, 800, 0, bs.DiscountRate},
+		{1, 0, -800, bs.DiscountDividend},
+	}
+
+	for _, c := range cases {
+
+		check := func(name string, err error) {
+			var e *bs.DiscountExponentError
+			if !errors.Is(err, bs.ErrDiscountExponent) || !errors.As(err, &e) || e.Term != c.term {
+				t.Errorf("%s: r*t = %v, q*t = %v: err = %v", name, c.r*c.tau, c.q*c.tau, err)
+			}
+		}
+
+		pars := &bs.PriceParams{
+			Vol: 0.2, TimeToExpiry: c.tau, Underlying: 100, Strike: 100,
+			Rate: c.r, Dividend: c.q, Type: bs.Call,
+		}
+
+		for name, f := range map[string]func(*bs.PriceParams) (float64, error){
+			"Price": bs.Price, "Delta": bs.Delta, "Gamma": bs.Gamma,
+			"Vega": bs.Vega, "Theta": bs.Theta,
+		} {
+			_, err := f(pars)
+			check(name, err)
+		}
+
+		_, err := bs.ImpliedVol(&bs.ImpliedVolParams{
+			Premium: 10, TimeToExpiry: c.tau, Underlying: 100, Strike: 100,
+			Rate: c.r, Dividend: c.q, Type: bs.Call,
+		})
+		check("ImpliedVol", err)
+
+		if p := bs.BSPrice(0.2, c.tau, 100, 100, c.r, c.q, bs.Call); !math.IsNaN(p) {
+			t.Errorf("BSPrice = %v, want NaN", p)
+		}
+	}
+}
+
+func Test_DiscountedAmountOverflow(t *testing.T) {
+
+	cases := []struct {
+		tau, x, k, r, q, exponent float64
+		term                      bs.DiscountTerm
+	}{
+		// the discounted underlying, the forward and the discounted strike
+		{100, 1e10, 100, 0, -7, math.Log(1e10) + 700, bs.DiscountUnderlying},
+		{100, 100, 100, 6, -6, math.Log(100) + 1200, bs.DiscountUnderlying},
+		{100, 100, 1e10, -7, 0, math.Log(1e10) + 700, bs.DiscountStrike},
+	}
+
+	for _, c := range cases {
+
+		check := func(name string, err error) {
+			var e *bs.DiscountExponentError
+			if !errors.Is(err, bs.ErrDiscountExponent) || !errors.As(err, &e) ||
+				e.Term != c.term || math.Abs(e.Exponent-c.exponent) > 1e-9 {
+				t.Errorf("%s: %+v: err = %v", name, c, err)
+			}
+		}
+
+		pars := &bs.PriceParams{
+			Vol: 0.2, TimeToExpiry: c.tau, Underlying: c.x, Strike: c.k,
+			Rate: c.r, Dividend: c.q, Type: bs.Call,
+		}
+
+		for name, f := range map[string]func(*bs.PriceParams) (float64, error){
+			"Price": bs.Price, "Delta": bs.Delta, "Gamma": bs.Gamma, "Vega": bs.Vega,
+			"Theta": bs.Theta, "VarianceVega": bs.VarianceVega,
+		} {
+			_, err := f(pars)
+			check(name, err)
+		}
+
+		_, err := bs.PriceAndGreeks(pars)
+		check("PriceAndGreeks", err)
+
+		_, err = bs.ImpliedVol(&bs.ImpliedVolParams{
+			Premium: 10, TimeToExpiry: c.tau, Underlying: c.x, Strike: c.k,
+			Rate: c.r, Dividend: c.q, Type: bs.Call,
+		})
+		check("ImpliedVol", err)
+
+		_, err = bs.DeltaWithConvention(bs.SpotDeltaPA, 0.2, c.tau, c.x, c.k, c.r, c.q, bs.Put)
+		check("DeltaWithConvention", err)
+
+		_, err = bs.PriceAmerican(0.2, c.tau, c.x, c.k, c.r, c.q, bs.Put, bs.AmericanBAW)
+		check("PriceAmerican", err)
+
+		_, err = bs.PriceBarrier(0.2, c.tau, c.x, c.k, c.x/2, c.r, c.q, bs.Call, bs.DownAndOut)
+		check("PriceBarrier", err)
+
+		if p := bs.BSPrice(0.2, c.tau, c.x, c.k, c.r, c.q, bs.Call); !math.IsNaN(p) {
+			t.Errorf("BSPrice = %v, want NaN", p)
+		}
+
+		if c.k != 100 {
+			continue
+		}
+
+		// the underlying alone overflows
+		_, err = bs.ATMStrike(bs.ATMForward, 0.2, c.tau, c.x, c.r, c.q)
+		check("ATMStrike", err)
+		_, err = bs.StrikeFromDelta(0.25, 0.2, c.tau, c.x, c.r, c.q, bs.Call, bs.SpotDelta)
+		check("StrikeFromDelta", err)
+		_, err = bs.NewPricingContext(c.tau, c.x, c.r, c.q)
+		check("NewPricingContext", err)
+	}
+}
+
+func Test_ZeroBoundaryOverflow(t *testing.T) {
+
+	// exp(-q*t) overflows but the zero underlying does not
+	const tau, q = 1.0, -800.0
+
+	for name, got := range map[string]float64{
+		"ZeroStrikeBSPrice": bs.ZeroStrikeBSPrice(tau, 0, q, bs.Call),
+		"ZeroStrikeBSTheta": bs.ZeroStrikeBSTheta(tau, 0, q, bs.Call),
+		"ZeroVolBSVega":     bs.ZeroVolBSVega(tau, 0, 100, 0, q, bs.Call),
+		"AtmApprox":         bs.AtmApprox(0.2, tau, 0, q, bs.Call),
+		"Intrinsic":         bs.Intrinsic(tau, 0, 100, 0, q, bs.Call),
+	} {
+		if got != 0 {
+			t.Errorf("%s = %v, want 0", name, got)
+		}
+	}
+	if p := bs.Intrinsic(tau, 0, 100, 0, q, bs.Put); p != 100 {
+		t.Errorf("Intrinsic put = %v", p)
+	}
+
+	// a tiny underlying keeps the product finite
+	want := math.Exp(math.Log(1e-300) + 710)
+	if p := bs.ZeroStrikeBSPrice(tau, 1e-300, -710, bs.Call); math.Abs(p/want-1) > 1e-12 {
+		t.Errorf("ZeroStrikeBSPrice = %v, want %v", p, want)
+	}
+
+	// and clamps to +Inf and 0 past the float64 range
+	if p := bs.ZeroStrikeBSPrice(tau, 1e10, -710, bs.Call); !math.IsInf(p, 1) {
+		t.Errorf("ZeroStrikeBSPrice = %v, want +Inf", p)
+	}
+	if p := bs.ZeroUnderlyingBSPrice(tau, 100, 800, bs.Put); p != 0 {
+		t.Errorf("ZeroUnderlyingBSPrice = %v, want 0", p)
+	}
+}
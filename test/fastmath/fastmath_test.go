@@ -0,0 +1,94 @@
+package fastmathtest
+
+import (
+	"math"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+func Test_NormCDFFast(t *testing.T) {
+
+	worst := 0.0
+	for x := -10.0; x <= 10; x += 1.0 / 256 {
+		worst = math.Max(worst, math.Abs(bs.NormCDFFast(x)-bs.NormCDF(x)))
+	}
+
+	t.Logf("Worst |NormCDFFast - NormCDF| = %.3g", worst)
+
+	if worst > 1e-10 {
+		t.Errorf("Worst error = %v", worst)
+	}
+}
+
+func Test_FastNormalPrices(t *testing.T) {
+
+	var tau, x, r, q float64 = 0.5, 100, 0.05, 0.02
+
+	exact, err := bs.NewPricingContext(tau, x, r, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fast := exact.WithNormal(bs.FastNormal{})
+
+	// the fast contexts at bumped spots, for the numeric delta
+	const h = 1e-3
+	up, _ := bs.NewPricingContext(tau, x+h, r, q)
+	down, _ := bs.NewPricingContext(tau, x-h, r, q)
+	up, down = up.WithNormal(bs.FastNormal{}), down.WithNormal(bs.FastNormal{})
+
+	for _, o := range []bs.OptionType{bs.Call, bs.Put, bs.Straddle} {
+		for _, v := range []float64{0.05, 0.2, 0.8} {
+			for k := 40.0; k <= 250; k += 5 {
+
+				// Each N(d) is off by at most 1e-10 and a straddle uses two of each
+				p, pf := exact.Price(v, k, o), fast.Price(v, k, o)
+				if bound := 2 * 1e-10 * (x + k); math.Abs(pf-p) > bound {
+					t.Errorf("Type = %c, Vol = %v, Strike = %v: fast = %v, exact = %v", o, v, k, pf, p)
+				}
+
+				// Numeric delta also picks up the derivative of the CDF
+				// interpolation error
+				delta := fast.Delta(v, k, o)
+				deltanum := (up.Price(v, k, o) - down.Price(v, k, o)) / 2 / h
+				if math.Abs(delta-deltanum) > 1e-5 {
+					t.Errorf("Delta = %v, numeric delta = %v", delta, deltanum)
+				}
+
+				vega := fast.Vega(v, k, o)
+				veganum := (fast.Price(v+1e-4, k, o) - fast.Price(v-1e-4, k, o)) / 2e-4
+				if math.Abs(vega-veganum) > 1e-3 {
+					t.Errorf("Vega = %v, numeric vega = %v", vega, veganum)
+				}
+			}
+		}
+	}
+}
+
+var sink float64
+
+func benchmarkPrice(b *testing.B, n bs.Normal) {
+
+	ctx, _ := bs.NewPricingContext(0.5, 100, 0.05, 0.02)
+	ctx = ctx.WithNormal(n)
+
+	for i := 0; i < b.N; i++ {
+		sink = ctx.Price(0.25, float64(80+i%40), bs.Call)
+	}
+}
+
+func benchmarkGreeks(b *testing.B, n bs.Normal) {
+
+	ctx, _ := bs.NewPricingContext(0.5, 100, 0.05, 0.02)
+	ctx = ctx.WithNormal(n)
+
+	for i := 0; i < b.N; i++ {
+		sink = ctx.Greeks(0.25, float64(80+i%40), bs.Call).Theta
+	}
+}
+
+func Benchmark_Price(b *testing.B)           { benchmarkPrice(b, bs.StdNormal{}) }
+func Benchmark_PriceFastNormal(b *testing.B) { benchmarkPrice(b, bs.FastNormal{}) }
+
+func Benchmark_Greeks(b *testing.B)           { benchmarkGreeks(b, bs.StdNormal{}) }
+func Benchmark_GreeksFastNormal(b *testing.B) { benchmarkGreeks(b, bs.FastNormal{}) }
@@ -0,0 +1,84 @@
+package forwardtest
+
+import (
+	"math"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+func Test_PriceFromForward(t *testing.T) {
+
+	const x = 100.0
+
+	for _, v := range []float64{0, 0.05, 0.3, 1.2} {
+		for _, tau := range []float64{0, 0.02, 0.5, 3} {
+			for _, k := range []float64{0, 60, 100, 145} {
+				for _, rq := range [][2]float64{{0.05, 0.01}, {0, 0.03}, {0.02, 0.02}} {
+					for _, o := range []bs.OptionType{bs.Call, bs.Put, bs.Straddle} {
+
+						r, q := rq[0], rq[1]
+						f, df := x*math.Exp((r-q)*tau), math.Exp(-r*tau)
+
+						want, err := bs.Price(&bs.PriceParams{
+							Vol: v, TimeToExpiry: tau, Underlying: x, Strike: k, Rate: r, Dividend: q, Type: o,
+						})
+						if err != nil {
+							t.Fatal(err)
+						}
+						got, err := bs.PriceFromForward(v, tau, f, k, df, o)
+						if err != nil || math.Abs(got-want) > 1e-12*x {
+							t.Errorf("v = %v, t = %v, k = %v, %c: price %v, want %v, err = %v",
+								v, tau, k, o, got, want, err)
+						}
+
+						if v == 0 || tau == 0 || k == 0 {
+							continue
+						}
+
+						// greeks in the forward map to spot greeks through
+						// df/dx = f/x
+						g, _ := bs.GreeksFromForward(v, tau, f, k, df, o)
+						bsg := bs.BSGreeks(v, tau, x, k, r, q, o)
+						if math.Abs(g.Delta*f/x-bsg.Delta) > 1e-12 ||
+							math.Abs(g.Gamma*f*f/x/x-bsg.Gamma) > 1e-12 ||
+							math.Abs(g.Vega-bsg.Vega) > 1e-10 {
+							t.Errorf("v = %v, t = %v, k = %v, %c: %+v vs %+v", v, tau, k, o, g, bsg)
+						}
+
+						h := 1e-5 * tau
+						up, _ := bs.PriceFromForward(v, tau+h, f, k, df, o)
+						dn, _ := bs.PriceFromForward(v, tau-h, f, k, df, o)
+						if num := -(up - dn) / 2 / h; math.Abs(g.Theta-num) > 1e-5*(1+math.Abs(num)) {
+							t.Errorf("v = %v, t = %v, k = %v, %c: theta %v, want %v", v, tau, k, o, g.Theta, num)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func Test_PriceFromForwardDiscountFactor(t *testing.T) {
+
+	for _, df := range []float64{0, -0.5, 1.01, math.NaN(), math.Inf(1)} {
+		if _, err := bs.PriceFromForward(0.2, 1, 100, 100, df, bs.Call); err != bs.ErrDiscountFactor {
+			t.Errorf("df = %v: err = %v", df, err)
+		}
+	}
+
+	c := bs.DiscountConfig{AllowNegativeRates: true}
+
+	p, err := c.PriceFromForward(0.2, 1, 100, 100, math.Exp(0.01), bs.Put)
+	want := bs.BSPrice(0.2, 1, 100, 100, -0.01, -0.01, bs.Put)
+	if err != nil || math.Abs(p-want) > 1e-12 {
+		t.Errorf("price = %v, want %v, err = %v", p, want, err)
+	}
+	p, err = c.PriceTotalVariance(0.04, 100, 100, math.Exp(0.01), math.Exp(0.01), bs.Put)
+	if err != nil || math.Abs(p-want) > 1e-12 {
+		t.Errorf("total variance price = %v, want %v, err = %v", p, want, err)
+	}
+	if _, err := c.PriceFromForward(0.2, 1, 100, 100, math.Inf(1), bs.Put); err != bs.ErrDiscountFactor {
+		t.Errorf("err = %v", err)
+	}
+}
@@ -0,0 +1,123 @@
+package fwdvoltest
+
+import (
+	"math"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+var structures = map[string][]bs.VolPillar{
+	"flat": {
+		{T: 0.25, Vol: 0.2}, {T: 0.5, Vol: 0.2}, {T: 1, Vol: 0.2}, {T: 2, Vol: 0.2},
+	},
+	"upward": {
+		{T: 1.0 / 12, Vol: 0.15}, {T: 0.25, Vol: 0.17}, {T: 1, Vol: 0.2}, {T: 5, Vol: 0.24},
+	},
+	"humped": {
+		{T: 0.1, Vol: 0.3}, {T: 0.5, Vol: 0.35}, {T: 1, Vol: 0.32}, {T: 3, Vol: 0.28},
+	},
+}
+
+func Test_ForwardVolRoundTrip(t *testing.T) {
+
+	for name, pillars := range structures {
+
+		segs, err := bs.BootstrapForwardVols(pillars)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(segs) != len(pillars) || segs[0].Start != 0 {
+			t.Fatalf("%s: segments %v", name, segs)
+		}
+		for i := 1; i < len(segs); i++ {
+			if segs[i].Start != segs[i-1].End {
+				t.Errorf("%s: segments %v not contiguous", name, segs)
+			}
+		}
+		if name == "flat" {
+			for _, s := range segs {
+				if math.Abs(s.Vol-0.2) > 1e-14 {
+					t.Errorf("flat: forward vol %v", s.Vol)
+				}
+			}
+		}
+
+		back, err := bs.VolTermStructure(segs)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		for i, p := range back {
+			if p.T != pillars[i].T || math.Abs(p.Vol-pillars[i].Vol) > 1e-14 {
+				t.Errorf("%s: pillar %d %+v, want %+v", name, i, p, pillars[i])
+			}
+
+			v, err := bs.TermVol(segs, p.T)
+			if err != nil || math.Abs(v-pillars[i].Vol) > 1e-14 {
+				t.Errorf("%s: TermVol(%v) = %v, %v", name, p.T, v, err)
+			}
+		}
+	}
+}
+
+func Test_TermVol(t *testing.T) {
+
+	segs := []bs.ForwardVolSegment{
+		{Start: 0, End: 1, Vol: 0.1},
+		{Start: 1, End: 2, Vol: 0.3},
+	}
+
+	for _, c := range []struct{ t, want float64 }{
+		{t: 0, want: 0.1},
+		{t: 0.5, want: 0.1},
+		{t: 1.5, want: math.Sqrt((0.01 + 0.09*0.5) / 1.5)},
+		{t: 2, want: math.Sqrt(0.1 / 2)},
+		{t: 4, want: math.Sqrt((0.1 + 0.09*2) / 4)},
+	} {
+		v, err := bs.TermVol(segs, c.t)
+		if err != nil || math.Abs(v-c.want) > 1e-15 {
+			t.Errorf("TermVol(%v) = %v, %v, want %v", c.t, v, err, c.want)
+		}
+	}
+
+	if _, err := bs.TermVol(segs, -1); err != bs.ErrNegTimeToExp {
+		t.Errorf("negative time: %v", err)
+	}
+
+	gap := []bs.ForwardVolSegment{{Start: 0, End: 1, Vol: 0.1}, {Start: 1.5, End: 2, Vol: 0.1}}
+	if _, err := bs.TermVol(gap, 1); err != bs.ErrTermStructure {
+		t.Errorf("gap: %v", err)
+	}
+	if _, err := bs.VolTermStructure(gap); err != bs.ErrTermStructure {
+		t.Errorf("gap: %v", err)
+	}
+}
+
+func Test_CalendarArbitrage(t *testing.T) {
+
+	pillars := []bs.VolPillar{
+		{T: 0.25, Vol: 0.2}, {T: 0.5, Vol: 0.3}, {T: 1, Vol: 0.2}, {T: 2, Vol: 0.2},
+	}
+
+	_, err := bs.BootstrapForwardVols(pillars)
+	e, ok := err.(*bs.CalendarArbitrageError)
+	if !ok {
+		t.Fatalf("expected a calendar arbitrage error, got %v", err)
+	}
+	if e.Near != pillars[1] || e.Far != pillars[2] {
+		t.Errorf("offending pair %+v, %+v", e.Near, e.Far)
+	}
+
+	for _, bad := range [][]bs.VolPillar{
+		nil,
+		{{T: 0, Vol: 0.2}},
+		{{T: 1, Vol: 0.2}, {T: 1, Vol: 0.25}},
+	} {
+		if _, err := bs.BootstrapForwardVols(bad); err != bs.ErrTermStructure {
+			t.Errorf("%v: %v", bad, err)
+		}
+	}
+	if _, err := bs.BootstrapForwardVols([]bs.VolPillar{{T: 1, Vol: -0.1}}); err != bs.ErrNegVol {
+		t.Errorf("negative vol: %v", err)
+	}
+}
@@ -0,0 +1,159 @@
+package fxtest
+
+import (
+	"math"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+const tau, spot, rd, rf = 0.75, 1.25, 0.045, 0.02
+
+var conventions = []bs.DeltaConvention{bs.SpotDelta, bs.ForwardDelta, bs.SpotDeltaPA, bs.ForwardDeltaPA}
+
+func Test_FXRoundTrip(t *testing.T) {
+
+	quotes := []bs.FXQuotes{
+		{ATM: 0.1, RR25: -0.012, BF25: 0.003, RR10: -0.025, BF10: 0.011},
+		{ATM: 0.35, RR25: 0.04, BF25: 0.01, RR10: 0.09, BF10: 0.035},
+		{ATM: 0.08},
+	}
+
+	for _, dc := range conventions {
+		for _, atm := range []bs.ATMConvention{bs.ATMDNS, bs.ATMForward} {
+			for _, q := range quotes {
+
+				conv := bs.Conventions{Delta: dc, ATM: atm}
+				points, err := bs.FXSmileFromQuotes(q, spot, rd, rf, tau, conv)
+				if err != nil {
+					t.Fatalf("%+v %+v: %v", conv, q, err)
+				}
+
+				got, err := bs.FXQuotesFromSmile(points, spot, rd, rf, tau, conv)
+				if err != nil {
+					t.Fatalf("%+v %+v: %v", conv, q, err)
+				}
+
+				for _, d := range [][2]float64{
+					{got.ATM, q.ATM}, {got.RR25, q.RR25}, {got.BF25, q.BF25}, {got.RR10, q.RR10}, {got.BF10, q.BF10},
+				} {
+					if math.Abs(d[0]-d[1]) > 1e-12 {
+						t.Errorf("%+v: %+v != %+v", conv, got, q)
+						break
+					}
+				}
+
+				// the ATM pillar sits at the ATM strike of conv
+				k, _ := bs.ATMStrike(atm, q.ATM, tau, spot, rd, rf)
+				if atm == bs.ATMDNS && (dc == bs.SpotDeltaPA || dc == bs.ForwardDeltaPA) {
+					k *= math.Exp(-q.ATM * q.ATM * tau)
+				}
+				if math.Abs(points[2].Strike-k) > 1e-12 {
+					t.Errorf("%+v: ATM strike %v, want %v", conv, points[2].Strike, k)
+				}
+			}
+		}
+	}
+}
+
+func Test_FXPillarDeltas(t *testing.T) {
+
+	q := bs.FXQuotes{ATM: 0.1, RR25: -0.012, BF25: 0.003, RR10: -0.025, BF10: 0.011}
+	points, err := bs.FXSmileFromQuotes(q, spot, rd, rf, tau, bs.Conventions{Delta: bs.SpotDelta})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, d := range []float64{-0.1, -0.25, 0, 0.25, 0.1} {
+		p := points[i]
+		o := bs.Call
+		if d < 0 {
+			o = bs.Put
+		}
+		if d == 0 {
+			// the straddle has zero delta
+			c := bs.BSDelta(p.Vol, tau, spot, p.Strike, rd, rf, bs.Call)
+			if pd := bs.BSDelta(p.Vol, tau, spot, p.Strike, rd, rf, bs.Put); math.Abs(c+pd) > 1e-12 {
+				t.Errorf("straddle delta = %v", c+pd)
+			}
+			continue
+		}
+		if got := bs.BSDelta(p.Vol, tau, spot, p.Strike, rd, rf, o); math.Abs(got-d) > 1e-12 {
+			t.Errorf("pillar %d delta = %v, want %v", i, got, d)
+		}
+	}
+
+	// a pillar off its delta is rejected
+	points[3].Strike *= 1.01
+	if _, err := bs.FXQuotesFromSmile(points, spot, rd, rf, tau, bs.Conventions{Delta: bs.SpotDelta}); err != bs.ErrSmile {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func Test_StrikeFromDeltaPA(t *testing.T) {
+
+	f := spot * math.Exp((rd-rf)*tau)
+
+	for _, v := range []float64{0.1, 0.5, 1.5} {
+
+		vs := v * math.Sqrt(tau)
+
+		for _, d := range []float64{0.05, 0.25, 0.4} {
+
+			k, err := bs.StrikeFromDelta(d, v, tau, spot, rd, rf, bs.Call, bs.ForwardDeltaPA)
+			if err != nil {
+				// the premium adjusted call delta peaks below 0.4 at high vol
+				if err == bs.ErrDelta && d == 0.4 && v == 1.5 {
+					continue
+				}
+				t.Fatalf("v = %v, d = %v: %v", v, d, err)
+			}
+
+			d2 := (math.Log(f/k) - vs*vs/2) / vs
+			if got := k / f * bs.NormCDF(d2); math.Abs(got-d) > 1e-10 {
+				t.Errorf("v = %v: delta = %v, want %v", v, got, d)
+			}
+
+			// the root is on the far side of the maximum, where the delta
+			// falls as the strike grows
+			if vs*bs.NormCDF(d2) > bs.NormPDF(d2) {
+				t.Errorf("v = %v, d = %v: strike %v below the maximum delta", v, d, k)
+			}
+		}
+	}
+
+	if _, err := bs.StrikeFromDelta(0.9, 0.2, tau, spot, rd, rf, bs.Call, bs.SpotDeltaPA); err != bs.ErrDelta {
+		t.Errorf("err = %v", err)
+	}
+	if _, err := bs.StrikeFromDelta(1.1, 0.2, tau, spot, rd, rf, bs.Call, bs.SpotDelta); err != bs.ErrDelta {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func Test_StrikeFromDeltaPut(t *testing.T) {
+
+	for _, conv := range conventions {
+		for _, d := range []float64{-0.05, -0.25, -0.5, -0.9} {
+
+			k, err := bs.StrikeFromDelta(d, 0.2, tau, spot, rd, rf, bs.Put, conv)
+			if err != nil {
+				t.Fatalf("%v %v: %v", conv, d, err)
+			}
+
+			f := spot * math.Exp((rd-rf)*tau)
+			vs := 0.2 * math.Sqrt(tau)
+			d1 := (math.Log(f/k) + vs*vs/2) / vs
+			s := 1.0
+			if conv == bs.SpotDelta || conv == bs.SpotDeltaPA {
+				s = math.Exp(-rf * tau)
+			}
+			got := -s * bs.NormCDF(-d1)
+			if conv == bs.SpotDeltaPA || conv == bs.ForwardDeltaPA {
+				got = -s * k / f * bs.NormCDF(vs-d1)
+			}
+			if math.Abs(got-d) > 1e-10 {
+				t.Errorf("%v: delta = %v, want %v", conv, got, d)
+			}
+		}
+	}
+}
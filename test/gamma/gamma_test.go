@@ -61,3 +61,148 @@ func Test_Gamma(t *testing.T) {
 		eps /= 2
 	}
 }
+
+func Test_Speed(t *testing.T) {
+
+	var v, tau, x, r, q float64 = 0.25, 0.5, 100, 0.04, 0.02
+
+	for _, o := range []bs.OptionType{bs.Call, bs.Put, bs.Straddle} {
+		for _, k := range []float64{70, 95, 100, 105, 140} {
+
+			h := 1e-4 * x
+			num := (bs.BSGamma(v, tau, x+h, k, r, q, o) - bs.BSGamma(v, tau, x-h, k, r, q, o)) / 2 / h
+			speed := bs.BSSpeed(v, tau, x, k, r, q, o)
+
+			if math.IsNaN(speed) || math.Abs(speed-num) > 1e-6*math.Max(1e-3, math.Abs(speed)) {
+				t.Errorf("Type = %c, Strike = %v: Speed = %v, SpeedNum = %v", o, k, speed, num)
+			}
+
+			pars := &bs.PriceParams{Vol: v, TimeToExpiry: tau, Underlying: x, Strike: k, Rate: r, Dividend: q, Type: o}
+			if got, err := bs.Speed(pars); err != nil || got != speed {
+				t.Errorf("Type = %c, Strike = %v: Speed = %v, %v", o, k, got, err)
+			}
+		}
+	}
+
+	if c, s := bs.BSSpeed(v, tau, x, 110, r, q, bs.Call), bs.BSSpeed(v, tau, x, 110, r, q, bs.Straddle); s != 2*c {
+		t.Errorf("straddle speed %v, call %v", s, c)
+	}
+
+	for _, s := range []float64{
+		bs.BSSpeed(v, tau, 0, 100, r, q, bs.Call),
+		bs.BSSpeed(v, tau, x, 0, r, q, bs.Put),
+		bs.BSSpeed(0, tau, x, 100, r, q, bs.Straddle),
+		bs.BSSpeed(v, 0, x, 100, r, q, bs.Call),
+	} {
+		if s != 0 {
+			t.Errorf("boundary speed %v, want 0", s)
+		}
+	}
+
+	pars := &bs.PriceParams{Vol: v, TimeToExpiry: tau, Underlying: -1, Strike: 100, Rate: r, Dividend: q, Type: bs.Call}
+	if speed, err := bs.Speed(pars); err == nil || !math.IsNaN(speed) {
+		t.Errorf("negative spot: Speed = %v, %v", speed, err)
+	}
+}
+
+func Test_Zomma(t *testing.T) {
+
+	const h = 1e-6
+	var v, tau, x, r, q float64 = 0.25, 0.5, 100, 0.04, 0.02
+
+	for _, o := range []bs.OptionType{bs.Call, bs.Put, bs.Straddle} {
+		for _, k := range []float64{70, 95, 100, 105, 140} {
+
+			num := (bs.BSGamma(v+h, tau, x, k, r, q, o) - bs.BSGamma(v-h, tau, x, k, r, q, o)) / 2 / h
+			zomma := bs.BSZomma(v, tau, x, k, r, q, o)
+
+			if math.IsNaN(zomma) || math.Abs(zomma-num) > 1e-6*math.Max(1e-2, math.Abs(zomma)) {
+				t.Errorf("Type = %c, Strike = %v: Zomma = %v, ZommaNum = %v", o, k, zomma, num)
+			}
+			if neg := bs.BSZomma(-v, tau, x, k, r, q, o); neg != zomma {
+				t.Errorf("Type = %c, Strike = %v: Zomma at -vol = %v, want %v", o, k, neg, zomma)
+			}
+
+			pars := &bs.PriceParams{Vol: v, TimeToExpiry: tau, Underlying: x, Strike: k, Rate: r, Dividend: q, Type: o}
+			if got, err := bs.Zomma(pars); err != nil || got != zomma {
+				t.Errorf("Type = %c, Strike = %v: Zomma = %v, %v", o, k, got, err)
+			}
+		}
+	}
+
+	if c, s := bs.BSZomma(v, tau, x, 110, r, q, bs.Call), bs.BSZomma(v, tau, x, 110, r, q, bs.Straddle); s != 2*c {
+		t.Errorf("straddle zomma %v, call %v", s, c)
+	}
+
+	for _, z := range []float64{
+		bs.BSZomma(v, tau, 0, 100, r, q, bs.Call),
+		bs.BSZomma(v, tau, x, 0, r, q, bs.Put),
+		bs.BSZomma(0, tau, x, 120, r, q, bs.Straddle),
+		bs.BSZomma(v, 0, x, 90, r, q, bs.Call),
+	} {
+		if z != 0 {
+			t.Errorf("boundary zomma %v, want 0", z)
+		}
+	}
+
+	// the gamma is pinned at the money at expiry
+	pars := &bs.PriceParams{Vol: v, TimeToExpiry: 0, Underlying: x, Strike: x, Rate: r, Dividend: q, Type: bs.Call}
+	if zomma, err := bs.Zomma(pars); err != bs.ErrZeroVolAtMoney || !math.IsNaN(zomma) {
+		t.Errorf("at the money at expiry: Zomma = %v, %v", zomma, err)
+	}
+	pars.TimeToExpiry, pars.Strike = tau, -1
+	if zomma, err := bs.Zomma(pars); err != bs.ErrNegStrike || !math.IsNaN(zomma) {
+		t.Errorf("negative strike: Zomma = %v, %v", zomma, err)
+	}
+}
+
+func Test_Color(t *testing.T) {
+
+	const h = 1e-6
+	var v, tau, x, r, q float64 = 0.25, 0.5, 100, 0.04, 0.02
+
+	for _, o := range []bs.OptionType{bs.Call, bs.Put, bs.Straddle} {
+		for _, k := range []float64{70, 95, 100, 105, 140} {
+
+			// time runs the other way to the time to expiry, as in BSThetaNum
+			num := (bs.BSGamma(v, tau-h, x, k, r, q, o) - bs.BSGamma(v, tau+h, x, k, r, q, o)) / 2 / h
+			color := bs.BSColor(v, tau, x, k, r, q, o)
+
+			if math.IsNaN(color) || math.Abs(color-num) > 1e-6*math.Max(1e-2, math.Abs(color)) {
+				t.Errorf("Type = %c, Strike = %v: Color = %v, ColorNum = %v", o, k, color, num)
+			}
+
+			pars := &bs.PriceParams{Vol: v, TimeToExpiry: tau, Underlying: x, Strike: k, Rate: r, Dividend: q, Type: o}
+			if got, err := bs.Color(pars); err != nil || got != color {
+				t.Errorf("Type = %c, Strike = %v: Color = %v, %v", o, k, got, err)
+			}
+		}
+	}
+
+	if c, s := bs.BSColor(v, tau, x, 110, r, q, bs.Call), bs.BSColor(v, tau, x, 110, r, q, bs.Straddle); s != 2*c {
+		t.Errorf("straddle color %v, call %v", s, c)
+	}
+
+	for _, c := range []float64{
+		bs.BSColor(v, tau, 0, 100, r, q, bs.Call),
+		bs.BSColor(v, tau, x, 0, r, q, bs.Put),
+		bs.BSColor(0, tau, x, 120, r, q, bs.Straddle),
+	} {
+		if c != 0 {
+			t.Errorf("boundary color %v, want 0", c)
+		}
+	}
+
+	pars := &bs.PriceParams{Vol: v, TimeToExpiry: 0, Underlying: x, Strike: 90, Rate: r, Dividend: q, Type: bs.Call}
+	if color, err := bs.Color(pars); err != bs.ErrZeroTimeToExp || !math.IsNaN(color) {
+		t.Errorf("at expiry: Color = %v, %v", color, err)
+	}
+	pars.TimeToExpiry = -1
+	if color, err := bs.Color(pars); err != bs.ErrNegTimeToExp || !math.IsNaN(color) {
+		t.Errorf("negative time: Color = %v, %v", color, err)
+	}
+	pars.Vol, pars.TimeToExpiry, pars.Strike, pars.Rate = 0, tau, x, q
+	if color, err := bs.Color(pars); err != bs.ErrZeroVolAtMoney || !math.IsNaN(color) {
+		t.Errorf("zero vol at the money: Color = %v, %v", color, err)
+	}
+}
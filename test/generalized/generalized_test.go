@@ -0,0 +1,100 @@
+package generalizedtest
+
+import (
+	"math"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+const tol = 1e-12
+
+func close(a, b bs.Greeks) bool {
+	for _, pair := range [][2]float64{
+		{a.Price, b.Price}, {a.Delta, b.Delta}, {a.Gamma, b.Gamma}, {a.Vega, b.Vega}, {a.Theta, b.Theta},
+	} {
+		if pair[0] != pair[1] && !(math.Abs(pair[0]-pair[1]) <= tol*100) {
+			return false
+		}
+	}
+	return true
+}
+
+func Test_GeneralizedEquity(t *testing.T) {
+
+	const x, r, q = 100.0, 0.05, 0.02
+
+	for _, o := range []bs.OptionType{bs.Call, bs.Put, bs.Straddle} {
+		for _, v := range []float64{0, 0.2, 0.6} {
+			for _, tau := range []float64{0, 0.5, 2} {
+				for _, k := range []float64{0, 80, 100, 120} {
+
+					pars := &bs.PriceParams{Vol: v, TimeToExpiry: tau, Underlying: x, Strike: k, Rate: r, Type: o}
+					for _, div := range []float64{0, q} {
+						pars.Dividend = div
+
+						want, _ := bs.PriceAndGreeks(pars)
+						got, err := bs.GreeksGeneralized(v, tau, x, k, r-div, r, o)
+						if err != nil || !close(got, want) {
+							t.Errorf("%v v %v t %v k %v q %v: %+v, want %+v", o, v, tau, k, div, got, want)
+						}
+						p, _ := bs.PriceGeneralized(v, tau, x, k, r-div, r, o)
+						if math.Abs(p-want.Price) > tol*100 {
+							t.Errorf("%v v %v t %v k %v q %v: price %v, want %v", o, v, tau, k, div, p, want.Price)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func Test_GeneralizedSpecializations(t *testing.T) {
+
+	const f, r, rf = 100.0, 0.05, 0.03
+
+	for _, o := range []bs.OptionType{bs.Call, bs.Put, bs.Straddle} {
+		for _, v := range []float64{0.1, 0.4} {
+			for _, tau := range []float64{0.25, 2} {
+				for _, k := range []float64{80, 100, 120} {
+
+					// Black-76 is the Black model off the future, whose theta
+					// holds the discount factor fixed rather than the rate
+					want, _ := bs.GreeksFromForward(v, tau, f, k, math.Exp(-r*tau), o)
+					want.Theta += r * want.Price
+					got, err := bs.GreeksBlack76(v, tau, f, k, r, o)
+					p, _ := bs.PriceBlack76(v, tau, f, k, r, o)
+					if err != nil || !close(got, want) || math.Abs(p-want.Price) > tol*100 {
+						t.Errorf("Black-76 %v v %v t %v k %v: %+v, want %+v", o, v, tau, k, got, want)
+					}
+
+					// a futures-style option is not discounted
+					want, _ = bs.GreeksFromForward(v, tau, f, k, 1, o)
+					got, err = bs.GreeksFuturesStyle(v, tau, f, k, o)
+					p, _ = bs.PriceFuturesStyle(v, tau, f, k, o)
+					if err != nil || !close(got, want) || math.Abs(p-want.Price) > tol*100 {
+						t.Errorf("futures-style %v v %v t %v k %v: %+v, want %+v", o, v, tau, k, got, want)
+					}
+
+					// Garman-Kohlhagen is Black Scholes with the foreign rate
+					// as the dividend yield
+					want, _ = bs.PriceAndGreeks(&bs.PriceParams{
+						Vol: v, TimeToExpiry: tau, Underlying: f, Strike: k, Rate: r, Dividend: rf, Type: o,
+					})
+					got, err = bs.GreeksGarmanKohlhagen(v, tau, f, k, r, rf, o)
+					p, _ = bs.PriceGarmanKohlhagen(v, tau, f, k, r, rf, o)
+					if err != nil || !close(got, want) || math.Abs(p-want.Price) > tol*100 {
+						t.Errorf("Garman-Kohlhagen %v v %v t %v k %v: %+v, want %+v", o, v, tau, k, got, want)
+					}
+				}
+			}
+		}
+	}
+
+	if _, err := bs.PriceGeneralized(-0.1, 1, f, 100, 0, r, bs.Call); err != bs.ErrNegVol {
+		t.Errorf("negative vol: %v", err)
+	}
+	if _, err := bs.PriceBlack76(0.2, -1, f, 100, r, bs.Call); err != bs.ErrNegTimeToExp {
+		t.Errorf("negative time: %v", err)
+	}
+}
@@ -0,0 +1,145 @@
+package gramcharliertest
+
+import (
+	"math"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+const x, r, q = 100.0, 0.04, 0.01
+
+// integrated returns the call price by trapezoidal integration of the
+// payoff over the Gram-Charlier density with the martingale mean
+func integrated(v, g3, g4, tau, k float64) float64 {
+
+	s := v * math.Sqrt(tau)
+	f := x * math.Exp((r-q)*tau)
+	m := math.Log(f) - s*s/2 - math.Log(1+g3/6*s*s*s+g4/24*s*s*s*s)
+
+	const n, lim = 200000, 12.0
+	h, sum := 2*lim/n, 0.0
+	for i := 0; i <= n; i++ {
+		z := -lim + float64(i)*h
+		dens := math.Exp(-z*z/2) / math.Sqrt(2*math.Pi) *
+			(1 + g3/6*(z*z*z-3*z) + g4/24*(z*z*z*z-6*z*z+3))
+		w := h
+		if i == 0 || i == n {
+			w /= 2
+		}
+		sum += w * math.Max(math.Exp(m+s*z)-k, 0) * dens
+	}
+
+	return math.Exp(-r*tau) * sum
+}
+
+func Test_GramCharlierBS(t *testing.T) {
+
+	for _, o := range []bs.OptionType{bs.Call, bs.Put, bs.Straddle} {
+		for _, v := range []float64{0, 0.1, 0.4} {
+			for _, tau := range []float64{0, 0.25, 2} {
+				for _, k := range []float64{0, 70, 100, 140} {
+
+					got, err := bs.PriceGramCharlier(v, 0, 0, tau, x, k, r, q, o)
+					if err != nil {
+						t.Fatal(err)
+					}
+					want, _ := bs.Price(&bs.PriceParams{
+						Vol: v, TimeToExpiry: tau, Underlying: x, Strike: k, Rate: r, Dividend: q, Type: o,
+					})
+					if got != want {
+						t.Errorf("%v, v = %v, t = %v, k = %v: %v, want %v", o, v, tau, k, got, want)
+					}
+				}
+			}
+		}
+	}
+}
+
+func Test_GramCharlierDensity(t *testing.T) {
+
+	for _, c := range []struct{ g3, g4 float64 }{
+		{g3: -0.5, g4: 1}, {g3: 0.3, g4: 0.5}, {g3: 0, g4: 3}, {g3: -1, g4: 2.4},
+	} {
+		for _, k := range []float64{70, 90, 100, 115, 140} {
+
+			call, err := bs.PriceGramCharlier(0.3, c.g3, c.g4, 1, x, k, r, q, bs.Call)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := integrated(0.3, c.g3, c.g4, 1, k); math.Abs(call-want) > 1e-7 {
+				t.Errorf("%+v, k = %v: %v, integrated %v", c, k, call, want)
+			}
+
+			put, _ := bs.PriceGramCharlier(0.3, c.g3, c.g4, 1, x, k, r, q, bs.Put)
+			parity := x*math.Exp(-q) - k*math.Exp(-r)
+			if math.Abs(call-put-parity) > 1e-12 {
+				t.Errorf("%+v, k = %v: parity off by %v", c, k, call-put-parity)
+			}
+		}
+	}
+
+	// negative skew raises the low strike puts against Black Scholes
+	skewed, _ := bs.PriceGramCharlier(0.3, -0.5, 1, 1, x, 70, r, q, bs.Put)
+	flat, _ := bs.PriceGramCharlier(0.3, 0, 0, 1, x, 70, r, q, bs.Put)
+	if !(skewed > flat) {
+		t.Errorf("skewed put %v not above %v", skewed, flat)
+	}
+}
+
+func Test_GramCharlierRegion(t *testing.T) {
+
+	for _, c := range []struct {
+		g3, g4 float64
+		ok     bool
+	}{
+		{g3: 0, g4: 0, ok: true},
+		{g3: 0, g4: 4, ok: true},
+		{g3: 1, g4: 2.4, ok: true},
+		{g3: -1, g4: 2.4, ok: true},
+		{g3: 0, g4: 4.1, ok: false},
+		{g3: 0.1, g4: 0, ok: false},
+		{g3: 0, g4: -0.1, ok: false},
+		{g3: 1.1, g4: 2.4, ok: false},
+		{g3: 0.5, g4: 0.2, ok: false},
+		{g3: math.NaN(), g4: 1, ok: false},
+	} {
+		_, err := bs.PriceGramCharlier(0.2, c.g3, c.g4, 1, x, 100, r, q, bs.Call)
+		if c.ok && err != nil {
+			t.Errorf("%+v: %v", c, err)
+		}
+		if _, isGC := err.(*bs.GramCharlierError); !c.ok && !isGC {
+			t.Errorf("%+v: %v", c, err)
+		}
+	}
+}
+
+func Test_CalibrateGramCharlier(t *testing.T) {
+
+	const v, g3, g4, tau = 0.25, -0.6, 1.2, 0.5
+
+	var quotes []bs.Quote
+	for _, k := range []float64{70, 80, 90, 100, 110, 120, 130} {
+		o := bs.Put
+		if k >= 100 {
+			o = bs.Call
+		}
+		p, err := bs.PriceGramCharlier(v, g3, g4, tau, x, k, r, q, o)
+		if err != nil {
+			t.Fatal(err)
+		}
+		quotes = append(quotes, bs.Quote{Strike: k, Mid: p, Type: o})
+	}
+
+	fit, err := bs.CalibrateGramCharlier(quotes, tau, x, r, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(fit.Vol-v) > 1e-6 || math.Abs(fit.Skew-g3) > 1e-4 || math.Abs(fit.Kurtosis-g4) > 1e-4 || fit.RMSE > 1e-7 {
+		t.Errorf("fit %+v", fit)
+	}
+
+	if _, err := bs.CalibrateGramCharlier(quotes[:2], tau, x, r, q); err != bs.ErrSmile {
+		t.Errorf("two quotes: %v", err)
+	}
+}
@@ -0,0 +1,111 @@
+package greekstest
+
+import (
+	"math"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+func Test_Greeks(t *testing.T) {
+
+	const tol float64 = 1e-13
+	var tau, x, r, q float64 = 0.75, 100, 0.05, 0.02
+
+	for _, o := range []bs.OptionType{bs.Call, bs.Put, bs.Straddle} {
+		for _, v := range []float64{0, 0.1, 0.5, -0.3} {
+			for _, k := range []float64{0, 50, 100, 150} {
+
+				g := bs.BSGreeks(v, tau, x, k, r, q, o)
+				want := bs.Greeks{
+					Price: bs.BSPrice(v, tau, x, k, r, q, o),
+					Delta: bs.BSDelta(v, tau, x, k, r, q, o),
+					Gamma: bs.BSGamma(v, tau, x, k, r, q, o),
+					Vega:  bs.BSVega(v, tau, x, k, r, q, o),
+					Theta: bs.BSTheta(v, tau, x, k, r, q, o),
+					Rho:   bs.BSRho(v, tau, x, k, r, q, o),
+				}
+
+				if all, err := bs.AllGreeks(v, tau, x, k, r, q, o); err != nil || all != g {
+					t.Errorf("Type = %c, Vol = %v, Strike = %v: AllGreeks = %+v, %v", o, v, k, all, err)
+				}
+
+				for _, c := range []struct {
+					name      string
+					got, want float64
+				}{
+					{"Price", g.Price, want.Price},
+					{"Delta", g.Delta, want.Delta},
+					{"Gamma", g.Gamma, want.Gamma},
+					{"Vega", g.Vega, want.Vega},
+					{"Theta", g.Theta, want.Theta},
+					{"Rho", g.Rho, want.Rho},
+				} {
+					if math.Abs(c.got-c.want) > tol*math.Max(1, math.Abs(c.want)) {
+						t.Errorf(
+							"Type = %c, Vol = %v, Strike = %v: %s = %v, want %v",
+							o, v, k, c.name, c.got, c.want,
+						)
+					}
+				}
+			}
+		}
+	}
+
+	g, err := bs.PriceAndGreeks(nil)
+	if err != bs.ErrNilPtrArg || !math.IsNaN(g.Price) {
+		t.Errorf("PriceAndGreeks(nil) = %v, %v", g, err)
+	}
+
+	g, err = bs.AllGreeks(0.2, tau, x, -1, r, q, bs.Call)
+	if err != bs.ErrNegStrike || !math.IsNaN(g.Rho) {
+		t.Errorf("AllGreeks with a negative strike = %v, %v", g, err)
+	}
+}
+
+var sink bs.Greeks
+
+func Benchmark_FiveCalls(b *testing.B) {
+
+	var v, tau, x, k, r, q float64 = 0.25, 0.5, 100, 105, 0.05, 0.02
+
+	for i := 0; i < b.N; i++ {
+		sink.Price = bs.BSPrice(v, tau, x, k, r, q, bs.Call)
+		sink.Delta = bs.BSDelta(v, tau, x, k, r, q, bs.Call)
+		sink.Gamma = bs.BSGamma(v, tau, x, k, r, q, bs.Call)
+		sink.Vega = bs.BSVega(v, tau, x, k, r, q, bs.Call)
+		sink.Theta = bs.BSTheta(v, tau, x, k, r, q, bs.Call)
+	}
+}
+
+func Benchmark_SixCalls(b *testing.B) {
+
+	var v, tau, x, k, r, q float64 = 0.25, 0.5, 100, 105, 0.05, 0.02
+
+	for i := 0; i < b.N; i++ {
+		sink.Price = bs.BSPrice(v, tau, x, k, r, q, bs.Call)
+		sink.Delta = bs.BSDelta(v, tau, x, k, r, q, bs.Call)
+		sink.Gamma = bs.BSGamma(v, tau, x, k, r, q, bs.Call)
+		sink.Vega = bs.BSVega(v, tau, x, k, r, q, bs.Call)
+		sink.Theta = bs.BSTheta(v, tau, x, k, r, q, bs.Call)
+		sink.Rho = bs.BSRho(v, tau, x, k, r, q, bs.Call)
+	}
+}
+
+func Benchmark_BSGreeks(b *testing.B) {
+
+	var v, tau, x, k, r, q float64 = 0.25, 0.5, 100, 105, 0.05, 0.02
+
+	for i := 0; i < b.N; i++ {
+		sink = bs.BSGreeks(v, tau, x, k, r, q, bs.Call)
+	}
+}
+
+func Benchmark_AllGreeks(b *testing.B) {
+
+	var v, tau, x, k, r, q float64 = 0.25, 0.5, 100, 105, 0.05, 0.02
+
+	for i := 0; i < b.N; i++ {
+		sink, _ = bs.AllGreeks(v, tau, x, k, r, q, bs.Call)
+	}
+}
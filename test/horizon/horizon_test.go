@@ -0,0 +1,135 @@
+package horizontest
+
+import (
+	"math"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+func Test_HorizonGreeks(t *testing.T) {
+
+	const v, tau, x, r, q = 0.25, 0.5, 100.0, 0.04, 0.01
+
+	for _, o := range []bs.OptionType{bs.Call, bs.Put, bs.Straddle} {
+		for _, k := range []float64{85, 100, 120} {
+			for _, days := range []float64{1, 7, 30, 90} {
+
+				g, err := bs.HorizonGreeks(v, tau, x, k, r, q, o, days)
+				if err != nil {
+					t.Fatalf("%c %v %v: err = %v", o, k, days, err)
+				}
+				h := days / bs.DaysPerYear
+
+				// theta is the decay over the horizon
+				if want := bs.BSPrice(v, tau-h, x, k, r, q, o) - bs.BSPrice(v, tau, x, k, r, q, o); math.Abs(g.Theta-want) > 1e-12 {
+					t.Errorf("%c %v %v: theta = %v, want %v", o, k, days, g.Theta, want)
+				}
+				if want := bs.BSPrice(v, tau, x, k, r, q, o); g.Price != want {
+					t.Errorf("%c %v %v: price = %v, want %v", o, k, days, g.Price, want)
+				}
+				if want := bs.BSDelta(v, tau-h, x, k, r, q, o); math.Abs(g.Delta-want) > 1e-14 {
+					t.Errorf("%c %v %v: delta = %v, want %v", o, k, days, g.Delta, want)
+				}
+
+				// the averages against the trapezoid rule in t
+				const n = 20000
+				var gamma, vega float64
+				for i := 0; i <= n; i++ {
+					w := 1.0
+					if i == 0 || i == n {
+						w = 0.5
+					}
+					s := tau - h*float64(i)/n
+					gamma += w * bs.BSGamma(v, s, x, k, r, q, o) / n
+					vega += w * bs.BSVega(v, s, x, k, r, q, o) / n
+				}
+				if math.Abs(g.Gamma-gamma) > 1e-8*gamma || math.Abs(g.Vega-vega) > 1e-8*vega {
+					t.Errorf("%c %v %v: gamma, vega = %v, %v, want %v, %v", o, k, days, g.Gamma, g.Vega, gamma, vega)
+				}
+			}
+		}
+	}
+}
+
+func Test_HorizonGreeksSmallHorizon(t *testing.T) {
+
+	const v, tau, x, k, r, q = 0.25, 0.5, 100.0, 105.0, 0.04, 0.01
+
+	for _, o := range []bs.OptionType{bs.Call, bs.Put, bs.Straddle} {
+
+		want := bs.BSGreeks(v, tau, x, k, r, q, o)
+
+		for _, days := range []float64{1e-2, 1e-3} {
+
+			g, err := bs.HorizonGreeks(v, tau, x, k, r, q, o, days)
+			if err != nil {
+				t.Fatalf("%c %v: err = %v", o, days, err)
+			}
+			h := days / bs.DaysPerYear
+
+			// the errors are first order in h
+			tol := 10 * h
+			if math.Abs(g.Theta/h-want.Theta) > tol*math.Abs(want.Theta) {
+				t.Errorf("%c %v: theta/h = %v, want %v", o, days, g.Theta/h, want.Theta)
+			}
+			if math.Abs(g.Delta-want.Delta) > tol || math.Abs(g.Gamma-want.Gamma) > tol*want.Gamma ||
+				math.Abs(g.Vega-want.Vega) > tol*want.Vega {
+				t.Errorf("%c %v: greeks = %+v, want %+v", o, days, g, want)
+			}
+		}
+
+		if g, err := bs.HorizonGreeks(v, tau, x, k, r, q, o, 0); err != nil || g.Theta != 0 ||
+			g.Price != want.Price || g.Delta != want.Delta || g.Gamma != want.Gamma || g.Vega != want.Vega {
+			t.Errorf("%c: zero horizon = %+v, err = %v", o, g, err)
+		}
+	}
+}
+
+func Test_HorizonGreeksPastExpiry(t *testing.T) {
+
+	const v, tau, x, r, q = 0.25, 10 / bs.DaysPerYear, 100.0, 0.04, 0.01
+
+	for _, o := range []bs.OptionType{bs.Call, bs.Put, bs.Straddle} {
+		for _, k := range []float64{95, 100, 105} {
+
+			g, err := bs.HorizonGreeks(v, tau, x, k, r, q, o, 30)
+			if err != nil {
+				t.Fatalf("%c %v: err = %v", o, k, err)
+			}
+
+			price := bs.BSPrice(v, tau, x, k, r, q, o)
+			if want := bs.PriceZeroTime(x, k, o) - price; math.Abs(g.Theta-want) > 1e-12 {
+				t.Errorf("%c %v: theta = %v, want %v", o, k, g.Theta, want)
+			}
+			if want := bs.DeltaZeroTime(x, k, o); g.Delta != want {
+				t.Errorf("%c %v: delta = %v, want %v", o, k, g.Delta, want)
+			}
+
+			// the averages over the rest of the life, gamma finite at the money
+			if !(g.Gamma > 0 && !math.IsInf(g.Gamma, 0) && g.Vega > 0 && g.Vega < bs.BSVega(v, tau, x, k, r, q, o)) {
+				t.Errorf("%c %v: gamma, vega = %v, %v", o, k, g.Gamma, g.Vega)
+			}
+
+			// the same as holding to expiry
+			if h, err := bs.HorizonGreeks(v, tau, x, k, r, q, o, 10); err != nil || h != g {
+				t.Errorf("%c %v: %+v, want %+v, err = %v", o, k, h, g, err)
+			}
+		}
+	}
+}
+
+func Test_HorizonGreeksErrors(t *testing.T) {
+
+	for _, days := range []float64{-1, math.NaN()} {
+		if _, err := bs.HorizonGreeks(0.2, 0.5, 100, 100, 0, 0, bs.Call, days); err != bs.ErrHorizon {
+			t.Errorf("%v: err = %v, want ErrHorizon", days, err)
+		}
+	}
+	if _, err := bs.HorizonGreeks(-0.2, 0.5, 100, 100, 0, 0, bs.Call, 1); err != bs.ErrNegVol {
+		t.Errorf("err = %v, want ErrNegVol", err)
+	}
+	if _, err := bs.HorizonGreeks(0.2, -0.5, 100, 100, 0, 0, bs.Call, 1); err == nil {
+		t.Error("negative time: nil err")
+	}
+}
@@ -1,6 +1,7 @@
 package implvoltest
 
 import (
+	"math"
 	"math/rand"
 	"testing"
 	"time"
@@ -67,3 +68,228 @@ func Test_ImpliedVol(t *testing.T) {
 
 	}
 }
+
+// referenceImpliedVol is the bisection of ImpliedVol evaluated through
+// full BSPriceNoErrorCheck calls, kept to pin the incremental solver
+func referenceImpliedVol(p, tau, x, k, r, q float64, o bs.OptionType) float64 {
+
+	intrval := bs.Intrinsic(tau, x, k, r, q, o)
+	if math.Abs(p-intrval) <= math.SmallestNonzeroFloat64 {
+		return 0
+	}
+
+	lb, ub, tol := 0.01, 1.99, 1.0/(1<<30)
+	maxit := bs.MaxItDefault
+
+	var plo, phi, pmid, vol float64
+	for it := 0; it < maxit; it++ {
+		if plo = bs.BSPriceNoErrorCheck(lb, tau, x, k, r, q, o); plo <= p {
+			break
+		}
+		lb -= 0.47
+	}
+	for it := 0; it < maxit; it++ {
+		if phi = bs.BSPriceNoErrorCheck(ub, tau, x, k, r, q, o); p <= phi {
+			break
+		}
+		ub += 0.47
+	}
+	for it := 0; it < maxit; it++ {
+		vol = 0.5 * (lb + ub)
+		pmid = bs.BSPriceNoErrorCheck(vol, tau, x, k, r, q, o)
+		switch {
+		case ub-lb < tol, pmid == p:
+			bs.CorrectVolSign(pmid-intrval, &vol)
+			return vol
+		case p < pmid:
+			ub = vol
+		case pmid < p:
+			lb = vol
+		}
+	}
+	return math.NaN()
+}
+
+type chainQuote struct {
+	k, premium float64
+	o          bs.OptionType
+}
+
+func makeChain(n int) []chainQuote {
+
+	const tau, x, r, q = 0.5, 100.0, 0.03, 0.01
+	chain := make([]chainQuote, n)
+	types := []bs.OptionType{bs.Call, bs.Put, bs.Straddle}
+
+	for i := range chain {
+		k := 50 + 100*float64(i)/float64(n)
+		v := 0.15 + 0.3*math.Abs(k-x)/x
+		if i%7 == 3 {
+			v = -0.05
+		}
+		o := types[i%3]
+		chain[i] = chainQuote{k, bs.BSPrice(v, tau, x, k, r, q, o), o}
+	}
+
+	return chain
+}
+
+func Test_ImpliedVolEquivalence(t *testing.T) {
+
+	const tau, x, r, q = 0.5, 100.0, 0.03, 0.01
+
+	for _, c := range makeChain(500) {
+
+		vol, err := bs.ImpliedVol(&bs.ImpliedVolParams{
+			Premium: c.premium, TimeToExpiry: tau, Underlying: x, Strike: c.k,
+			Rate: r, Dividend: q, Type: c.o,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if want := referenceImpliedVol(c.premium, tau, x, c.k, r, q, c.o); vol != want {
+			t.Errorf("Strike = %v, Type = %c: ImplVol = %v, want %v", c.k, c.o, vol, want)
+		}
+	}
+
+	// short and long expiries, and vols beyond the initial bracket
+	for _, tau := range []float64{7.0 / 365, 3} {
+		for _, v := range []float64{0.05, 0.8, 2.5} {
+			for _, k := range []float64{70, 95, 100, 110, 140} {
+				for _, o := range []bs.OptionType{bs.Call, bs.Put, bs.Straddle} {
+
+					// the wings are solved in log price, see Test_ImpliedVolWings
+					p := bs.BSPrice(v, tau, x, k, r, q, o)
+					if p < 2*bs.WingPremium*x {
+						continue
+					}
+					vol, err := bs.ImpliedVol(&bs.ImpliedVolParams{
+						Premium: p, TimeToExpiry: tau, Underlying: x, Strike: k, Rate: r, Dividend: q, Type: o,
+					})
+					want := referenceImpliedVol(p, tau, x, k, r, q, o)
+					if err != nil && !math.IsNaN(want) || err == nil && vol != want {
+						t.Errorf("%v %v %v %c: ImplVol = %v, %v, want %v", tau, v, k, o, vol, err, want)
+					}
+				}
+			}
+		}
+	}
+}
+
+func Test_ImpliedVolOnIteration(t *testing.T) {
+
+	type step struct {
+		iter               int
+		lb, ub, vol, price float64
+	}
+
+	var steps []step
+	premium := bs.BSPrice(0.35, 0.5, 100, 90, 0.02, 0, bs.Put)
+
+	vol, err := bs.ImpliedVol(&bs.ImpliedVolParams{
+		Premium: premium, TimeToExpiry: 0.5, Underlying: 100, Strike: 90,
+		Rate: 0.02, Type: bs.Put,
+		OnIteration: func(iter int, lb, ub, vol, price float64) {
+			steps = append(steps, step{iter, lb, ub, vol, price})
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(steps) == 0 {
+		t.Fatal("OnIteration not called")
+	}
+
+	for i, s := range steps {
+		if s.iter != i || !(s.lb <= s.vol && s.vol <= s.ub) {
+			t.Fatalf("step %d = %+v", i, s)
+		}
+		if i == 0 {
+			continue
+		}
+		if prev := steps[i-1]; s.lb < prev.lb || s.ub > prev.ub || s.ub-s.lb >= prev.ub-prev.lb {
+			t.Fatalf("step %d = %+v does not narrow %+v", i, s, prev)
+		}
+	}
+
+	if last := steps[len(steps)-1]; last.vol != vol {
+		t.Errorf("last vol = %v, want %v", last.vol, vol)
+	}
+
+	// a nil callback is fine
+	if v, err := bs.ImpliedVol(&bs.ImpliedVolParams{
+		Premium: premium, TimeToExpiry: 0.5, Underlying: 100, Strike: 90,
+		Rate: 0.02, Type: bs.Put,
+	}); err != nil || v != vol {
+		t.Errorf("ImpliedVol = %v, %v, want %v", v, err, vol)
+	}
+}
+
+var sink float64
+
+func Test_ImpliedVolWings(t *testing.T) {
+
+	const x, r, q = 100.0, 0.03, 0.01
+
+	newton, _ := bs.NewSolver(bs.SolverConfig{Method: bs.SolverNewton})
+	bisection, _ := bs.NewSolver(bs.SolverConfig{})
+
+	for _, tau := range []float64{7.0 / 365, 1} {
+		for _, v := range []float64{0.1, 0.2, 0.6} {
+			for _, n := range []float64{-10, -8, -6, 6, 8, 10} {
+
+				// n total vols from the forward, out of the money
+				k := x * math.Exp((r-q)*tau+n*v*math.Sqrt(tau))
+				o := bs.Call
+				if n < 0 {
+					o = bs.Put
+				}
+
+				p := bs.BSPrice(v, tau, x, k, r, q, o)
+				if !(p > 0 && p < 1e-7*x) {
+					t.Fatalf("%v %v %v: premium %v not in the wings", tau, v, n, p)
+				}
+
+				vol, err := bs.ImpliedVol(&bs.ImpliedVolParams{
+					Premium: p, TimeToExpiry: tau, Underlying: x, Strike: k, Rate: r, Dividend: q, Type: o,
+				})
+				if err != nil || math.Abs(vol-v) > 1e-9 {
+					t.Errorf("%v %v %v: ImpliedVol = %v, err = %v", tau, v, n, vol, err)
+				}
+				for _, s := range []*bs.Solver{newton, bisection} {
+					if vol, err := s.ImpliedVol(p, tau, x, k, r, q, o); err != nil || math.Abs(vol-v) > 1e-9 {
+						t.Errorf("%v %v %v: %v ImpliedVol = %v, err = %v", tau, v, n, s.Config().Method, vol, err)
+					}
+				}
+			}
+		}
+	}
+}
+
+func Benchmark_ImpliedVolChainReference(b *testing.B) {
+
+	const tau, x, r, q = 0.5, 100.0, 0.03, 0.01
+	chain := makeChain(500)
+
+	for i := 0; i < b.N; i++ {
+		for _, c := range chain {
+			sink = referenceImpliedVol(c.premium, tau, x, c.k, r, q, c.o)
+		}
+	}
+}
+
+func Benchmark_ImpliedVolChain(b *testing.B) {
+
+	const tau, x, r, q = 0.5, 100.0, 0.03, 0.01
+	chain := makeChain(500)
+	pars := &bs.ImpliedVolParams{TimeToExpiry: tau, Underlying: x, Rate: r, Dividend: q}
+
+	for i := 0; i < b.N; i++ {
+		for _, c := range chain {
+			pars.Premium, pars.Strike, pars.Type = c.premium, c.k, c.o
+			sink, _ = bs.ImpliedVol(pars)
+		}
+	}
+}
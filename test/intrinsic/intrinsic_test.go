@@ -0,0 +1,54 @@
+package intrinsictest
+
+import (
+	"math"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+func Test_IntrinsicBadType(t *testing.T) {
+
+	var tau, x, k, r, q float64 = 0.5, 100, 90, 0.05, 0.02
+	bad := bs.OptionType('x')
+
+	if p := bs.Intrinsic(tau, x, k, r, q, bad); !math.IsNaN(p) {
+		t.Errorf("Intrinsic = %v, want NaN", p)
+	}
+
+	for _, v := range []float64{0, 0.2, -0.2} {
+		if p := bs.BSPrice(v, tau, x, k, r, q, bad); !math.IsNaN(p) {
+			t.Errorf("BSPrice(%v) = %v, want NaN", v, p)
+		}
+	}
+
+	pars := &bs.PriceParams{
+		Vol: 0, TimeToExpiry: tau, Underlying: x, Strike: k,
+		Rate: r, Dividend: q, Type: bad,
+	}
+
+	for name, f := range map[string]func(*bs.PriceParams) (float64, error){
+		"Price": bs.Price, "Delta": bs.Delta, "Gamma": bs.Gamma,
+		"Vega": bs.Vega, "Theta": bs.Theta,
+	} {
+		if p, err := f(pars); err != bs.ErrUnknownOptionType || !math.IsNaN(p) {
+			t.Errorf("%s = %v, %v", name, p, err)
+		}
+	}
+
+	if p, err := bs.PriceSim(pars, 16); err != bs.ErrUnknownOptionType || !math.IsNaN(p) {
+		t.Errorf("PriceSim = %v, %v", p, err)
+	}
+
+	if p := bs.BSPriceSim(0.2, tau, x, k, r, q, bad, 16); !math.IsNaN(p) {
+		t.Errorf("BSPriceSim = %v, want NaN", p)
+	}
+
+	vol, err := bs.ImpliedVol(&bs.ImpliedVolParams{
+		Premium: 12, TimeToExpiry: tau, Underlying: x, Strike: k,
+		Rate: r, Dividend: q, Type: bad,
+	})
+	if err != bs.ErrUnknownOptionType || !math.IsNaN(vol) {
+		t.Errorf("ImpliedVol = %v, %v", vol, err)
+	}
+}
@@ -0,0 +1,77 @@
+package laddertest
+
+import (
+	"math"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+const spot, r, q = 100.0, 0.03, 0.01
+
+var (
+	strikes  = []float64{80, 90, 100, 110, 120}
+	expiries = []float64{0.25, 0.5, 1}
+)
+
+func Test_LadderFlat(t *testing.T) {
+
+	l, err := bs.Ladder(bs.FlatVol(0.2), spot, strikes, expiries, r, q, bs.Put)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(l.Prices) != 15 || len(l.Thetas) != 15 || l.Index(1, 2) != 11 {
+		t.Fatalf("%d cells", len(l.Prices))
+	}
+
+	for j, tau := range expiries {
+		for i, k := range strikes {
+			pars := &bs.PriceParams{Vol: 0.2, TimeToExpiry: tau, Underlying: spot, Strike: k, Rate: r, Dividend: q, Type: bs.Put}
+			p, _ := bs.Price(pars)
+			g, _ := bs.PriceAndGreeks(pars)
+			if l.Price(i, j) != p || l.Greeks(i, j) != g || l.Vol(i, j) != 0.2 {
+				t.Errorf("cell %v, %v: %+v, want %+v", k, tau, l.Greeks(i, j), g)
+			}
+		}
+	}
+
+	if _, err := bs.Ladder(bs.FlatVol(-0.2), spot, strikes, expiries, r, q, bs.Put); err == nil {
+		t.Error("no error for a negative vol")
+	}
+}
+
+func Test_LadderSurface(t *testing.T) {
+
+	var smiles []bs.Smile
+	for _, tau := range []float64{0.25, 1} {
+		smiles = append(smiles, bs.Smile{T: tau, Strikes: []float64{80, 100, 120}, Vols: []float64{0.3, 0.2, 0.18}})
+	}
+	s, err := bs.NewVolSurface(spot, r, q, smiles, bs.SurfaceConfig{StrikeExtrap: bs.ExtrapError, TimeExtrap: bs.ExtrapError})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := bs.Ladder(s, spot, strikes, []float64{0.1, 0.5}, r, q, bs.Call)
+
+	// the 0.1 column is before the surface and the wing strikes of the 0.5
+	// column are outside the moneyness range of the one year smile
+	me, ok := err.(bs.MultiError)
+	if !ok || len(me) != 7 || me[4].Index != 4 || me[5].Index != 5 || me[6].Index != 9 || me[0].Err != bs.ErrExtrapolation {
+		t.Fatalf("err = %v", err)
+	}
+	if !math.IsNaN(l.Price(0, 0)) || !math.IsNaN(l.Greeks(0, 1).Vega) {
+		t.Errorf("failed cells priced: %v, %+v", l.Price(0, 0), l.Greeks(0, 1))
+	}
+
+	// the smile is picked up
+	for i, k := range strikes[1:4] {
+		v, _ := s.Vol(k, 0.5)
+		p := bs.BSPrice(v, 0.5, spot, k, r, q, bs.Call)
+		if l.Vol(i+1, 1) != v || l.Price(i+1, 1) != p {
+			t.Errorf("strike %v: vol %v, price %v, want %v, %v", k, l.Vol(i+1, 1), l.Price(i+1, 1), v, p)
+		}
+	}
+	if !(l.Vol(1, 1) > l.Vol(2, 1) && l.Vol(2, 1) > l.Vol(3, 1)) {
+		t.Errorf("no skew: %v", l.Vols[5:])
+	}
+}
@@ -0,0 +1,169 @@
+package latticetest
+
+import (
+	"math"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+type greeksFunc func(
+	v, t, x, k, r, q float64, o bs.OptionType, style bs.ExerciseStyle, steps int,
+) (float64, float64, float64, float64, error)
+
+var lattices = []struct {
+	name  string
+	f     greeksFunc
+	steps int
+}{
+	{"Binomial", bs.GreeksBinomial, 2000},
+	{"Trinomial", bs.GreeksTrinomial, 1000},
+}
+
+func Test_LatticeEuropean(t *testing.T) {
+
+	const x, r, q = 100.0, 0.05, 0.02
+
+	for _, l := range lattices {
+		for _, o := range []bs.OptionType{bs.Call, bs.Put, bs.Straddle} {
+			for _, v := range []float64{0.15, 0.4} {
+				for _, tau := range []float64{0.25, 1} {
+					for _, k := range []float64{80, 100, 120} {
+
+						price, delta, gamma, theta, err := l.f(v, tau, x, k, r, q, o, bs.European, l.steps)
+						if err != nil {
+							t.Fatal(err)
+						}
+
+						// BSTheta uses the call formula for puts, so compare
+						// theta with the numeric derivative of the price
+						for _, c := range []struct {
+							name      string
+							got, want float64
+							tol       float64
+						}{
+							{"Price", price, bs.BSPrice(v, tau, x, k, r, q, o), 5e-3},
+							{"Delta", delta, bs.BSDelta(v, tau, x, k, r, q, o), 1e-3},
+							{"Gamma", gamma, bs.BSGamma(v, tau, x, k, r, q, o), 2e-4},
+							{"Theta", theta, bs.BSThetaNum(v, tau, x, k, r, q, o, 1e-5), 2e-2},
+						} {
+							if math.Abs(c.got-c.want) > c.tol {
+								t.Errorf("%s Type = %c, Vol = %v, T = %v, Strike = %v: %s = %v, want %v",
+									l.name, o, v, tau, k, c.name, c.got, c.want)
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func Test_LatticeAmerican(t *testing.T) {
+
+	const x, v, tau, r = 100.0, 0.25, 1.0, 0.08
+
+	for _, l := range lattices {
+		for _, k := range []float64{110, 130, 160} {
+
+			ep, ed, _, _, err := l.f(v, tau, x, k, r, 0, bs.Put, bs.European, l.steps)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ap, ad, _, _, err := l.f(v, tau, x, k, r, 0, bs.Put, bs.American, l.steps)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// early exercise pulls the American put delta toward -1, below
+			// the European delta
+			if ap < ep || ad > ed || ad < -1 {
+				t.Errorf("%s Strike = %v: American (%v, %v), European (%v, %v)",
+					l.name, k, ap, ad, ep, ed)
+			}
+		}
+
+		// deep in the money the put is exercised at once
+		p, d, g, _, _ := l.f(v, tau, x, 200, r, 0, bs.Put, bs.American, l.steps)
+		if math.Abs(p-100) > 1e-10 || math.Abs(d+1) > 1e-10 || math.Abs(g) > 1e-10 {
+			t.Errorf("%s deep in the money put = %v, %v, %v", l.name, p, d, g)
+		}
+
+		// without dividends an American call is European
+		ac, _ := bsPrice(l.f, bs.American)
+		ec, _ := bsPrice(l.f, bs.European)
+		if math.Abs(ac-ec) > 1e-10 {
+			t.Errorf("%s American call = %v, European %v", l.name, ac, ec)
+		}
+	}
+}
+
+func bsPrice(f greeksFunc, style bs.ExerciseStyle) (float64, error) {
+	p, _, _, _, err := f(0.3, 1, 100, 100, 0.05, 0, bs.Call, style, 500)
+	return p, err
+}
+
+func Test_LatticeErrors(t *testing.T) {
+
+	for _, l := range lattices {
+		for _, c := range []struct {
+			v, tau, k float64
+			steps     int
+			err       error
+		}{
+			{-0.2, 1, 100, 100, bs.ErrNegVol},
+			{0.2, 1, 100, 1, bs.ErrSteps},
+			{0, 1, 100, 100, bs.ErrLattice},
+			{0.2, 1, -1, 100, bs.ErrNegStrike},
+			{0.01, 10, 100, 2, bs.ErrLattice},
+		} {
+			if _, _, _, _, err := l.f(c.v, c.tau, 100, c.k, 0.5, 0, bs.Call, bs.European, c.steps); err != c.err {
+				t.Errorf("%s %+v: err = %v", l.name, c, err)
+			}
+		}
+
+		// below TimeFloor the analytic values are returned
+		p, d, _, _, err := l.f(0.2, 0, 100, 90, 0, 0, bs.Call, bs.American, 100)
+		if err != nil || p != 10 || d != 1 {
+			t.Errorf("%s at expiry: %v, %v, %v", l.name, p, d, err)
+		}
+	}
+}
+
+func Benchmark_GreeksBinomial(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		bs.GreeksBinomial(0.2, 1, 100, 105, 0.03, 0.01, bs.Put, bs.American, 500)
+	}
+}
+
+func Test_Fugit(t *testing.T) {
+
+	const v, tau, x, r = 0.3, 1.0, 100.0, 0.1
+
+	// deeper in the money puts are exercised sooner
+	prev := math.Inf(1)
+	for _, k := range []float64{70, 90, 100, 110, 120} {
+		f, err := bs.Fugit(v, tau, x, k, r, 0, bs.Put, 1000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !(f < prev) || f < 0 || f > tau {
+			t.Errorf("Strike = %v: fugit = %v, previous %v", k, f, prev)
+		}
+		prev = f
+	}
+	if f, _ := bs.Fugit(v, tau, x, 160, r, 0, bs.Put, 1000); f != 0 {
+		t.Errorf("deep in the money put fugit = %v", f)
+	}
+
+	// a call without dividends is never exercised early
+	for _, k := range []float64{50, 100, 150} {
+		if f, err := bs.Fugit(v, tau, x, k, r, 0, bs.Call, 1000); err != nil || f != tau {
+			t.Errorf("Strike = %v: call fugit = %v, err = %v", k, f, err)
+		}
+	}
+
+	if _, err := bs.Fugit(v, tau, x, 100, r, 0, bs.Put, 1); err != bs.ErrSteps {
+		t.Errorf("err = %v", err)
+	}
+}
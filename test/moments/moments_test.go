@@ -0,0 +1,119 @@
+package momentstest
+
+import (
+	"math"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+const tau, spot, r, q = 0.5, 100.0, 0.03, 0.01
+
+// chain quotes calls and puts at the strikes with mids priced at vol(k)
+func chain(t *testing.T, strikes []float64, vol func(k float64) float64) bs.OptionChain {
+
+	var quotes []bs.Quote
+	for _, k := range strikes {
+		for _, o := range []bs.OptionType{bs.Call, bs.Put} {
+			p := bs.BSPrice(vol(k), tau, spot, k, r, q, o)
+			quotes = append(quotes, bs.Quote{Strike: k, Bid: p, Ask: p, Type: o})
+		}
+	}
+
+	c, err := bs.NewOptionChain(tau, r, quotes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func strikes(lo, hi, step float64) (ks []float64) {
+	for k := lo; k <= hi; k += step {
+		ks = append(ks, k)
+	}
+	return
+}
+
+func Test_ImpliedMomentsFlat(t *testing.T) {
+
+	const v = 0.25
+
+	c := chain(t, strikes(60, 160, 5), func(float64) float64 { return v })
+
+	variance, skew, kurt, err := bs.ImpliedMoments(c, tau, spot, r, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the log return is normal
+	if math.Abs(variance-v*v*tau) > 1e-6 || math.Abs(skew) > 1e-4 || math.Abs(kurt) > 1e-3 {
+		t.Errorf("variance, skew, kurtosis = %v, %v, %v, want %v, 0, 0", variance, skew, kurt, v*v*tau)
+	}
+
+	// truncated to the quoted strikes, which are about 2 standard
+	// deviations wide, the variance and kurtosis fall
+	m, err := bs.ChainMoments(c, tau, spot, r, q, bs.MomentsConfig{Extrapolation: bs.MomentsTruncate})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !(m.Variance < variance && m.Variance > 0.9*variance && m.Kurtosis < kurt) {
+		t.Errorf("truncated %+v", m)
+	}
+	if m.LowStrike != 60 || m.HighStrike != 160 || len(m.Strikes) != 21 {
+		t.Errorf("truncated strikes %v, %v, %v", m.LowStrike, m.HighStrike, m.Strikes)
+	}
+
+	m, err = bs.ChainMoments(c, tau, spot, r, q, bs.MomentsConfig{})
+	if err != nil || !(m.LowStrike < 60 && m.HighStrike > 160) || m.Variance != variance {
+		t.Errorf("extrapolated %+v, err = %v", m, err)
+	}
+}
+
+func Test_ImpliedMomentsSkew(t *testing.T) {
+
+	f := spot * math.Exp((r-q)*tau)
+
+	// a put skew, vols falling with the strike
+	c := chain(t, strikes(50, 180, 2.5), func(k float64) float64 {
+		return math.Max(0.25-0.15*math.Log(k/f), 0.05)
+	})
+
+	variance, skew, _, err := bs.ImpliedMoments(c, tau, spot, r, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !(skew < -0.2 && variance > 0.2*0.2*tau) {
+		t.Errorf("variance, skew = %v, %v", variance, skew)
+	}
+
+	// and the mirror image call skew
+	c = chain(t, strikes(50, 180, 2.5), func(k float64) float64 {
+		return math.Max(0.25+0.15*math.Log(k/f), 0.05)
+	})
+	if _, s, _, err := bs.ImpliedMoments(c, tau, spot, r, q); err != nil || !(s > 0.2) {
+		t.Errorf("call skew = %v, err = %v", s, err)
+	}
+}
+
+func Test_ImpliedMomentsErrors(t *testing.T) {
+
+	c := chain(t, strikes(90, 110, 5), func(float64) float64 { return 0.2 })
+
+	if _, err := bs.ChainMoments(c, tau, spot, r, q, bs.MomentsConfig{Extrapolation: bs.MomentsTruncate + 1}); err != bs.ErrMomentsConfig {
+		t.Errorf("err = %v", err)
+	}
+	if _, err := bs.ChainMoments(c, tau, spot, r, q, bs.MomentsConfig{Width: -1}); err != bs.ErrMomentsConfig {
+		t.Errorf("err = %v", err)
+	}
+	if _, _, _, err := bs.ImpliedMoments(c, 0, spot, r, q); err != bs.ErrNegTimeToExp {
+		t.Errorf("err = %v", err)
+	}
+	if _, _, _, err := bs.ImpliedMoments(c, tau, 0, r, q); err != bs.ErrNegPrice {
+		t.Errorf("err = %v", err)
+	}
+
+	one := c.Filter(func(qt bs.Quote) bool { return qt.Strike == 110 })
+	if _, _, _, err := bs.ImpliedMoments(one, tau, spot, r, q); err != bs.ErrChain {
+		t.Errorf("err = %v", err)
+	}
+}
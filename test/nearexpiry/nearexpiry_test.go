@@ -0,0 +1,185 @@
+package nearexpirytest
+
+import (
+	"math"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+const (
+	x, r, q = 100.0, 0.03, 0.01
+	v       = 0.2
+)
+
+// closedForms returns the price, gamma and theta of the Black Scholes
+// formulas, which the BS functions leave below NearExpiryTotalVol
+func closedForms(v, t, x, k, r, q float64, o bs.OptionType) bs.Greeks {
+
+	n := func(z float64) float64 { return math.Erfc(-z/math.Sqrt2) / 2 }
+	s := v * math.Sqrt(t)
+	d1 := (math.Log(x/k) + (r-q+v*v/2)*t) / s
+	d2 := d1 - s
+	xq, kr := x*math.Exp(-q*t), k*math.Exp(-r*t)
+	pdf := math.Exp(-d1*d1/2) / math.Sqrt(2*math.Pi)
+
+	g := bs.Greeks{
+		Price: xq*n(d1) - kr*n(d2),
+		Gamma: xq * pdf / x / x / s,
+		Theta: -v*xq*pdf/2/math.Sqrt(t) + q*xq*n(d1) - r*kr*n(d2),
+	}
+	put := bs.Greeks{
+		Price: g.Price - xq + kr,
+		Gamma: g.Gamma,
+		Theta: g.Theta - q*xq + r*kr,
+	}
+
+	switch o {
+	case bs.Put:
+		return put
+	case bs.Straddle:
+		return bs.Greeks{Price: g.Price + put.Price, Gamma: 2 * g.Gamma, Theta: g.Theta + put.Theta}
+	}
+	return g
+}
+
+func Test_NearExpiryContinuity(t *testing.T) {
+
+	s := bs.NearExpiryTotalVol
+	tc := (s / v) * (s / v)
+
+	for _, m := range []float64{-0.01, -0.002, -1e-4, 0, 1e-4, 0.001, 0.005} {
+		for _, o := range []bs.OptionType{bs.Call, bs.Put, bs.Straddle} {
+
+			k := x * math.Exp((r-q)*tc-m)
+
+			// at the crossover the BS functions take the closed forms
+			for _, c := range []struct {
+				name         string
+				closed, near float64
+			}{
+				{"price", bs.BSPrice(v, tc, x, k, r, q, o), bs.PriceNearExpiry(v, tc, x, k, r, q, o)},
+				{"gamma", bs.BSGamma(v, tc, x, k, r, q, o), bs.GammaNearExpiry(v, tc, x, k, r, q, o)},
+				{"theta", bs.BSTheta(v, tc, x, k, r, q, o), bs.ThetaNearExpiry(v, tc, x, k, r, q, o)},
+			} {
+				if !(math.Abs(c.closed-c.near) <= 1e-10*math.Max(1, math.Abs(c.closed))) {
+					t.Errorf("%v m %v: closed %s %v, near expiry %v", o, m, c.name, c.closed, c.near)
+				}
+			}
+
+			// and just below it the near expiry forms
+			tb := tc * (1 - 1e-9)
+			below := bs.BSGreeks(v, tb, x, k, r, q, o)
+			closed := closedForms(v, tb, x, k, r, q, o)
+
+			for _, c := range [][2]float64{
+				{below.Price, closed.Price}, {below.Gamma, closed.Gamma}, {below.Theta, closed.Theta},
+			} {
+				if !(math.Abs(c[0]-c[1]) <= 1e-10*math.Max(1, math.Abs(c[1]))) {
+					t.Errorf("%v m %v: below the crossover %+v, closed form %+v", o, m, below, closed)
+				}
+			}
+			if below.Price != bs.PriceNearExpiry(v, tb, x, k, r, q, o) ||
+				below.Gamma != bs.BSGamma(v, tb, x, k, r, q, o) ||
+				below.Theta != bs.BSTheta(v, tb, x, k, r, q, o) {
+				t.Errorf("%v m %v: BSGreeks %+v does not switch with the standalone functions", o, m, below)
+			}
+		}
+	}
+}
+
+func Test_NearExpiryCrossoverParam(t *testing.T) {
+
+	for _, o := range []bs.OptionType{bs.Call, bs.Put, bs.Straddle} {
+
+		// a total vol of 0.01, above NearExpiryTotalVol
+		tau := (0.01 / v) * (0.01 / v)
+		pars := &bs.PriceParams{
+			Vol: v, TimeToExpiry: tau, Underlying: x, Strike: 100.5, Rate: r, Dividend: q, Type: o,
+		}
+
+		if p, _ := bs.Price(pars); p != bs.BSPrice(v, tau, x, 100.5, r, q, o) {
+			t.Errorf("%v: Price = %v with the default crossover", o, p)
+		}
+
+		pars.NearExpiryTotalVol = 0.05
+		if p, _ := bs.Price(pars); p != bs.PriceNearExpiry(v, tau, x, 100.5, r, q, o) {
+			t.Errorf("%v: Price = %v below the crossover of the call", o, p)
+		}
+		if g, _ := bs.Gamma(pars); g != bs.GammaNearExpiry(v, tau, x, 100.5, r, q, o) {
+			t.Errorf("%v: Gamma = %v below the crossover of the call", o, g)
+		}
+		if g, _ := bs.PriceAndGreeks(pars); g.Theta != bs.ThetaNearExpiry(v, tau, x, 100.5, r, q, o) {
+			t.Errorf("%v: PriceAndGreeks = %+v below the crossover of the call", o, g)
+		}
+
+		// a negative crossover keeps the closed forms, which lose the
+		// digits of the time value this close to expiry
+		tau = 1e-9
+		pars.TimeToExpiry, pars.Strike, pars.NearExpiryTotalVol = tau, x, -1
+		g, err := bs.PriceAndGreeks(pars)
+		if err != nil {
+			t.Fatal(err)
+		}
+		closed := closedForms(v, tau, x, x, r, q, o)
+		if math.Abs(g.Gamma-closed.Gamma) > 1e-12*closed.Gamma ||
+			math.Abs(g.Price-closed.Price) > 1e-12*x ||
+			g.Price == bs.PriceNearExpiry(v, tau, x, x, r, q, o) {
+			t.Errorf("%v: PriceAndGreeks = %+v, closed form %+v", o, g, closed)
+		}
+	}
+}
+
+func Test_NearExpiryATMScaling(t *testing.T) {
+
+	for _, tau := range []float64{1e-4, 1e-6, 1e-8, 1e-9} {
+
+		s := v * math.Sqrt(tau)
+		k := x * math.Exp((r-q)*tau)
+		xq := x * math.Exp(-q*tau)
+
+		// gamma ~ 1/(x*v*sqrt(2*Pi*t)) and theta ~ -x*v/(2*sqrt(2*Pi*t))
+		gamma := bs.BSGamma(v, tau, x, k, r, q, bs.Call) * x * v * math.Sqrt(2*math.Pi*tau) / math.Exp(-q*tau)
+		if math.Abs(gamma-1) > s*s {
+			t.Errorf("t %v: gamma scaling %v", tau, gamma)
+		}
+
+		// the carry terms are O(sqrt(t)) relative to the decay
+		decay := -xq * v / 2 / math.Sqrt(2*math.Pi*tau)
+		theta := bs.BSTheta(v, tau, x, k, r, q, bs.Straddle) / (2 * decay)
+		if math.Abs(theta-1) > math.Sqrt(tau) {
+			t.Errorf("t %v: theta scaling %v", tau, theta)
+		}
+
+		// the time value keeps its digits, x*v*sqrt(t/(2*Pi)) to O(s^2)
+		price := bs.BSPrice(v, tau, x, k, r, q, bs.Call) / bs.AtmApprox(v, tau, x, q, bs.Call)
+		if math.Abs(price-1) > s*s {
+			t.Errorf("t %v: price scaling %v", tau, price)
+		}
+	}
+}
+
+func Test_NearExpiryBoundaries(t *testing.T) {
+
+	for _, o := range []bs.OptionType{bs.Call, bs.Put} {
+		for _, c := range []struct{ v, t, k float64 }{
+			{v: 0, t: 1e-6, k: 100},
+			{v: 0.2, t: 0, k: 100},
+			{v: 0.2, t: 1e-6, k: 0},
+		} {
+			if p, want := bs.PriceNearExpiry(c.v, c.t, x, c.k, r, q, o), bs.BSPrice(c.v, c.t, x, c.k, r, q, o); p != want {
+				t.Errorf("%v %+v: price %v, want %v", o, c, p, want)
+			}
+			if g, want := bs.GammaNearExpiry(c.v, c.t, x, c.k, r, q, o), bs.BSGamma(c.v, c.t, x, c.k, r, q, o); g != want {
+				t.Errorf("%v %+v: gamma %v, want %v", o, c, g, want)
+			}
+			if th, want := bs.ThetaNearExpiry(c.v, c.t, x, c.k, r, q, o), bs.BSTheta(c.v, c.t, x, c.k, r, q, o); th != want {
+				t.Errorf("%v %+v: theta %v, want %v", o, c, th, want)
+			}
+		}
+	}
+
+	if p := bs.PriceNearExpiry(0.2, -1, x, 100, r, q, bs.Call); !math.IsNaN(p) {
+		t.Errorf("negative time: %v", p)
+	}
+}
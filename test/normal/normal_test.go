@@ -0,0 +1,152 @@
+package normaltest
+
+import (
+	"math"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+func Test_NormCDF(t *testing.T) {
+
+	// Reference values of the standard normal CDF to 17 significant digits
+	cases := []struct{ x, p float64 }{
+		{-8, 6.2209605742717841e-16},
+		{-6, 9.8658764503769458e-10},
+		{-5, 2.8665157187919391e-07},
+		{-3, 1.3498980316300946e-03},
+		{-1, 1.5865525393145705e-01},
+		{0, 0.5},
+		{1, 8.4134474606854295e-01},
+		{3, 9.9865010196836990e-01},
+		{5, 9.9999971334842808e-01},
+		{8, 1},
+	}
+
+	for _, c := range cases {
+		p := bs.NormCDF(c.x)
+		if math.Abs(p-c.p) > 1e-15 || math.Abs(p-c.p) > 1e-13*c.p {
+			t.Errorf("NormCDF(%v) = %.17g, want %.17g", c.x, p, c.p)
+		}
+	}
+
+	for x := -8.0; x <= 8; x += 1.0 / 64 {
+		if d := bs.NormCDF(x) + bs.NormCDF(-x) - 1; math.Abs(d) > 1e-15 {
+			t.Errorf("NormCDF(%v) + NormCDF(%v) - 1 = %v", x, -x, d)
+		}
+		pdf := math.Exp(-x*x/2) / math.Sqrt(2*math.Pi)
+		if d := bs.NormPDF(x) - pdf; math.Abs(d) > 1e-15*math.Max(1, pdf) {
+			t.Errorf("NormPDF(%v) = %v, want %v", x, bs.NormPDF(x), pdf)
+		}
+	}
+}
+
+func Test_NormCDFMonotone(t *testing.T) {
+
+	prev := bs.NormCDF(-40)
+	for x := -40.0; x <= 40; x += 1.0 / 128 {
+		p := bs.NormCDF(x)
+		if p < prev || p < 0 || p > 1 {
+			t.Fatalf("NormCDF(%v) = %v, previous %v", x, p, prev)
+		}
+		prev = p
+	}
+
+	prev = bs.NormPDF(0)
+	for x := 0.0; x <= 40; x += 1.0 / 128 {
+		p := bs.NormPDF(x)
+		if p > prev || p < 0 {
+			t.Fatalf("NormPDF(%v) = %v, previous %v", x, p, prev)
+		}
+		prev = p
+	}
+}
+
+var sink float64
+
+func Benchmark_NormCDF(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		sink = bs.NormCDF(float64(i%256)/32 - 4)
+	}
+}
+
+func Benchmark_NormPDF(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		sink = bs.NormPDF(float64(i%256)/32 - 4)
+	}
+}
+
+func Benchmark_Price(b *testing.B) {
+
+	var v, tau, x, r, q float64 = 0.25, 0.5, 100, 0.05, 0.02
+
+	for i := 0; i < b.N; i++ {
+		sink = bs.BSPrice(v, tau, x, float64(80+i%40), r, q, bs.Call)
+	}
+}
+
+func Test_NormCDFInverse(t *testing.T) {
+
+	for i := 1; i < 1<<14; i++ {
+		p := float64(i) / (1 << 14)
+		x := bs.NormCDFInverse(p)
+		erfinv := math.Sqrt2 * math.Erfinv(2*p-1)
+		if d := x - erfinv; math.Abs(d) > 1e-14*math.Max(1, math.Abs(x)) {
+			t.Errorf("NormCDFInverse(%v) = %.17g, Erfinv version %.17g", p, x, erfinv)
+		}
+	}
+
+	for e := -300.0; e < -0.31; e += 0.25 {
+		p := math.Pow(10, e)
+		x := bs.NormCDFInverse(p)
+		if d := bs.NormCDF(x)/p - 1; math.Abs(d) > 1e-13*math.Max(1, math.Abs(x)) {
+			t.Errorf("NormCDF(NormCDFInverse(%v)) / p - 1 = %v", p, d)
+		}
+		// 1 - (1 - p) is exact so the upper tail must mirror it exactly
+		if u := 1 - p; u < 1 {
+			y, z := bs.NormCDFInverse(u), bs.NormCDFInverse(1-u)
+			if math.Abs(y+z) > 1e-15*math.Abs(y) {
+				t.Errorf("NormCDFInverse(%v) = %v, want %v", u, y, -z)
+			}
+		}
+	}
+
+	if x := bs.NormCDFInverse(0); !math.IsInf(x, -1) {
+		t.Errorf("NormCDFInverse(0) = %v", x)
+	}
+	if x := bs.NormCDFInverse(1); !math.IsInf(x, 1) {
+		t.Errorf("NormCDFInverse(1) = %v", x)
+	}
+	for _, p := range []float64{-1e-300, 1.5, math.NaN()} {
+		if x := bs.NormCDFInverse(p); !math.IsNaN(x) {
+			t.Errorf("NormCDFInverse(%v) = %v, want NaN", p, x)
+		}
+	}
+}
+
+// Test_StdNormal checks the default backend against the reference values
+// of Test_NormCDF through the Normal interface. The package uses only the
+// standard library for these, so the test is the same under any build
+// tags or GOOS, e.g. go test -tags purego or GOOS=js GOARCH=wasm.
+func Test_StdNormal(t *testing.T) {
+
+	var n bs.Normal = bs.StdNormal{}
+
+	for _, c := range []struct{ x, p float64 }{
+		{-8, 6.2209605742717841e-16},
+		{-3, 1.3498980316300946e-03},
+		{0, 0.5},
+		{1, 8.4134474606854295e-01},
+		{5, 9.9999971334842808e-01},
+	} {
+		if p := n.CDF(c.x); math.Abs(p-c.p) > 1e-13*c.p {
+			t.Errorf("CDF(%v) = %.17g, want %.17g", c.x, p, c.p)
+		}
+		if x := n.Quantile(c.p); c.p < 0.5 && math.Abs(x-c.x) > 1e-13*math.Max(1, math.Abs(c.x)) {
+			t.Errorf("Quantile(%v) = %.17g, want %v", c.p, x, c.x)
+		}
+		if n.CDF(c.x) != bs.NormCDF(c.x) || n.PDF(c.x) != bs.NormPDF(c.x) {
+			t.Errorf("StdNormal(%v) differs from NormCDF and NormPDF", c.x)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package pairtest
+
+import (
+	"math"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+func price(v, t, x, k, r, q float64, o bs.OptionType) float64 {
+	p, err := bs.Price(&bs.PriceParams{
+		Vol: v, TimeToExpiry: t, Underlying: x, Strike: k, Rate: r, Dividend: q, Type: o,
+	})
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+func Test_ImpliedVolAndDividend(t *testing.T) {
+
+	const x, r = 100.0, 0.03
+
+	for _, v := range []float64{0.15, 0.3, 0.6} {
+		for _, q := range []float64{-0.02, 0, 0.015, 0.08} {
+			for _, tau := range []float64{0.25, 1, 3} {
+				for _, k := range []float64{80, 100, 125} {
+
+					c, p := price(v, tau, x, k, r, q, bs.Call), price(v, tau, x, k, r, q, bs.Put)
+
+					gotV, gotQ, err := bs.ImpliedVolAndDividend(c, p, tau, x, k, r)
+					if err != nil {
+						t.Fatalf("v = %v, q = %v, t = %v, k = %v: %v", v, q, tau, k, err)
+					}
+					if math.Abs(gotV-v) > 1e-9 || math.Abs(gotQ-q) > 1e-12 {
+						t.Errorf(
+							"v = %v, q = %v, t = %v, k = %v: got %v, %v", v, q, tau, k, gotV, gotQ,
+						)
+					}
+				}
+			}
+		}
+	}
+}
+
+func Test_ImpliedVolAndDividendErrors(t *testing.T) {
+
+	const tau, x, k, r = 1.0, 100.0, 100.0, 0.03
+
+	c, p := price(0.2, tau, x, k, r, 0.01, bs.Call), price(0.2, tau, x, k, r, 0.01, bs.Put)
+
+	// a put far above the call has a negative forward
+	_, _, err := bs.ImpliedVolAndDividend(1, 200, tau, x, k, r)
+	if _, ok := err.(*bs.ForwardError); !ok {
+		t.Errorf("crossed pair: %v", err)
+	}
+
+	// a leg with no time value or with both above their upper bounds
+	for _, pair := range [][2]float64{{c, 0}, {0, p}, {c + 200, p + 200}} {
+		_, _, err = bs.ImpliedVolAndDividend(pair[0], pair[1], tau, x, k, r)
+		if _, ok := err.(*bs.StalePairError); !ok {
+			t.Errorf("stale pair %v: %v", pair, err)
+		}
+	}
+
+	if _, _, err = bs.ImpliedVolAndDividend(-1, p, tau, x, k, r); err != bs.ErrNegPremium {
+		t.Errorf("negative premium: %v", err)
+	}
+	if _, _, err = bs.ImpliedVolAndDividend(c, p, 0, x, k, r); err != bs.ErrArbitrage {
+		t.Errorf("zero time: %v", err)
+	}
+}
+
+func Test_VolGapWarning(t *testing.T) {
+
+	const tau, x, k, r = 1.0, 100.0, 100.0, 0.03
+
+	c, p := price(0.2, tau, x, k, r, 0.01, bs.Call), price(0.2, tau, x, k, r, 0.01, bs.Put)
+
+	v, q, err := bs.ImpliedVolAndDividendWithTol(c, p, tau, x, k, r, -1)
+	w, ok := err.(*bs.VolGapWarning)
+	if !ok {
+		t.Fatalf("expected a warning, got %v", err)
+	}
+	if math.Abs(v-0.2) > 1e-9 || math.Abs(q-0.01) > 1e-12 {
+		t.Errorf("got %v, %v with warning", v, q)
+	}
+	if math.Abs(w.CallVol-w.PutVol) > 1e-9 {
+		t.Errorf("gap %v", w)
+	}
+}
@@ -0,0 +1,331 @@
+package portfoliotest
+
+import (
+	"math"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+const spot, r, q, vol, tau = 100.0, 0.04, 0.0, 0.3, 0.5
+
+func Test_PayoffProfile(t *testing.T) {
+
+	grid, err := bs.SpotGridAround(spot, 50, 101)
+	if err != nil || len(grid) != 101 || grid[0] != 50 || grid[50] != 100 || grid[100] != 150 {
+		t.Fatalf("grid = %v, err = %v", grid, err)
+	}
+
+	// a long call breaks even at the strike plus the premium carried to
+	// expiry
+	const k = 105
+	c := bs.BSPrice(vol, tau, spot, k, r, q, bs.Call)
+	breakeven := k + c*math.Exp(r*tau)
+
+	profile, err := bs.OptionPayoffProfile(vol, tau, spot, k, r, q, bs.Call, append(grid, breakeven))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range profile[:101] {
+		if want := math.Max(p.Spot-k, 0); math.Abs(p.Expiry-want) > 1e-12 {
+			t.Errorf("spot %v: expiry value %v, want %v", p.Spot, p.Expiry, want)
+		}
+		if want := bs.BSPrice(vol, tau, p.Spot, k, r, q, bs.Call); math.Abs(p.Value-want) > 1e-12 {
+			t.Errorf("spot %v: value %v, want %v", p.Spot, p.Value, want)
+		}
+		if (p.PnL > 0) != (p.Spot > breakeven) {
+			t.Errorf("spot %v: P&L %v", p.Spot, p.PnL)
+		}
+	}
+	if pnl := profile[101].PnL; math.Abs(pnl) > 1e-12 {
+		t.Errorf("P&L at breakeven %v", pnl)
+	}
+
+	// a covered call is min(S, K) at expiry
+	covered := bs.Portfolio{
+		Spot: spot, Rate: r, Dividend: q, Shares: 1,
+		Positions: []bs.Position{{Quantity: -1, Type: bs.Call, Strike: k, TimeToExpiry: tau, Vol: vol}},
+	}
+	profile, err = bs.PayoffProfile(covered, grid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cost := (spot - c) * math.Exp(r*tau)
+	for _, p := range profile {
+		if want := math.Min(p.Spot, k); math.Abs(p.Expiry-want) > 1e-12 || math.Abs(p.PnL-(want-cost)) > 1e-12 {
+			t.Errorf("spot %v: expiry value %v, P&L %v", p.Spot, p.Expiry, p.PnL)
+		}
+	}
+}
+
+func Test_PayoffProfileMixedExpiries(t *testing.T) {
+
+	// a calendar spread at its near expiry
+	cal := bs.Portfolio{
+		Spot: spot, Rate: r, Dividend: 0.02,
+		Positions: []bs.Position{
+			{Quantity: 1, Type: bs.Put, Strike: 100, TimeToExpiry: 1, Vol: 0.25},
+			{Quantity: -1, Type: bs.Put, Strike: 100, TimeToExpiry: 0.25, Vol: 0.3},
+		},
+	}
+
+	value, err := cal.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	far, near := bs.BSPrice(0.25, 1, spot, 100, r, 0.02, bs.Put), bs.BSPrice(0.3, 0.25, spot, 100, r, 0.02, bs.Put)
+	if math.Abs(value-(far-near)) > 1e-12 {
+		t.Errorf("value %v, want %v", value, far-near)
+	}
+
+	profile, err := bs.PayoffProfile(cal, []float64{80, 100, 120})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range profile {
+		want := bs.BSPrice(0.25, 0.75, p.Spot, 100, r, 0.02, bs.Put) - math.Max(100-p.Spot, 0)
+		if math.Abs(p.Expiry-want) > 1e-12 || math.Abs(p.PnL-(want-value*math.Exp(r*0.25))) > 1e-12 {
+			t.Errorf("spot %v: expiry value %v, want %v, P&L %v", p.Spot, p.Expiry, want, p.PnL)
+		}
+	}
+	if profile[1].Expiry <= profile[0].Expiry || profile[1].Expiry <= profile[2].Expiry {
+		t.Errorf("calendar not peaked at the strike: %+v", profile)
+	}
+}
+
+func Test_PayoffProfileErrors(t *testing.T) {
+
+	p := bs.Portfolio{Spot: spot, Positions: []bs.Position{{Quantity: 1, Type: bs.Call, Strike: 100, TimeToExpiry: 1, Vol: -1}}}
+	if _, err := bs.PayoffProfile(p, []float64{100}); err != bs.ErrNegVol {
+		t.Errorf("err = %v", err)
+	}
+	p.Positions[0].Vol = 0.2
+	if _, err := bs.PayoffProfile(p, []float64{-1}); err != bs.ErrNegPrice {
+		t.Errorf("err = %v", err)
+	}
+	p.Shares = math.NaN()
+	if _, err := bs.PayoffProfile(p, []float64{100}); err != bs.ErrPortfolio {
+		t.Errorf("err = %v", err)
+	}
+	for _, c := range [][2]float64{{0, 10}, {101, 10}, {50, 1}} {
+		if _, err := bs.SpotGridAround(spot, c[0], int(c[1])); err != bs.ErrSpotGrid {
+			t.Errorf("%v: err = %v", c, err)
+		}
+	}
+}
+
+func Test_ProbabilityOfProfit(t *testing.T) {
+
+	straddle := bs.Portfolio{
+		Spot: spot, Rate: r, Dividend: 0.01,
+		Positions: []bs.Position{
+			{Quantity: 1, Type: bs.Call, Strike: 100, TimeToExpiry: tau, Vol: vol},
+			{Quantity: 1, Type: bs.Put, Strike: 100, TimeToExpiry: tau, Vol: vol},
+		},
+	}
+	long, err := bs.ProbabilityOfProfit(straddle, vol, math.NaN(), tau)
+	if err != nil || !(long < 0.45) {
+		t.Errorf("long straddle PoP = %v, err = %v", long, err)
+	}
+
+	straddle.Positions[0].Quantity, straddle.Positions[1].Quantity = -1, -1
+	short, err := bs.ProbabilityOfProfit(straddle, vol, math.NaN(), tau)
+	if err != nil || !(short > 0.55) || math.Abs(long+short-1) > 1e-12 {
+		t.Errorf("short straddle PoP = %v, err = %v", short, err)
+	}
+
+	// a long call is profitable above its breakeven
+	const k, drift = 110, 0.08
+	call := bs.Portfolio{
+		Spot: spot, Rate: r,
+		Positions: []bs.Position{{Quantity: 2, Type: bs.Call, Strike: k, TimeToExpiry: tau, Vol: vol}},
+	}
+	breakeven := k + bs.BSPrice(vol, tau, spot, k, r, 0, bs.Call)*math.Exp(r*tau)
+	for _, mu := range []float64{math.NaN(), drift} {
+		m := r
+		if !math.IsNaN(mu) {
+			m = mu
+		}
+		want := 1 - bs.NormCDF((math.Log(breakeven/spot)-(m-vol*vol/2)*tau)/vol/math.Sqrt(tau))
+		if pop, err := bs.ProbabilityOfProfit(call, vol, mu, tau); err != nil || math.Abs(pop-want) > 1e-10 {
+			t.Errorf("drift %v: PoP = %v, want %v, err = %v", mu, pop, want, err)
+		}
+	}
+
+	// at zero vol the call pays off only if the spot drifts past breakeven
+	if pop, err := bs.ProbabilityOfProfit(call, 0, 0.3, tau); err != nil || pop != 1 {
+		t.Errorf("PoP = %v, err = %v", pop, err)
+	}
+	if pop, err := bs.ProbabilityOfProfit(call, 0, 0, tau); err != nil || pop != 0 {
+		t.Errorf("PoP = %v, err = %v", pop, err)
+	}
+
+	if _, err := bs.ProbabilityOfProfit(call, -1, 0, tau); err != bs.ErrNegVol {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func Test_ProbabilityOfProfitIntervals(t *testing.T) {
+
+	// a long call butterfly bought above its intrinsic value is profitable
+	// only between its two breakevens, a short one outside them
+	fly := bs.Portfolio{
+		Spot: spot, Rate: r,
+		Positions: []bs.Position{
+			{Quantity: 1, Type: bs.Call, Strike: 90, TimeToExpiry: tau, Vol: vol},
+			{Quantity: -2, Type: bs.Call, Strike: 100, TimeToExpiry: tau, Vol: vol},
+			{Quantity: 1, Type: bs.Call, Strike: 110, TimeToExpiry: tau, Vol: vol},
+		},
+	}
+	cost, _ := fly.Value()
+	cost *= math.Exp(r * tau)
+
+	cdf := func(x float64) float64 {
+		return bs.NormCDF((math.Log(x/spot) - (r-vol*vol/2)*tau) / vol / math.Sqrt(tau))
+	}
+	want := cdf(110-cost) - cdf(90+cost)
+
+	pop, err := bs.ProbabilityOfProfit(fly, vol, math.NaN(), tau)
+	if err != nil || math.Abs(pop-want) > 1e-10 {
+		t.Errorf("PoP = %v, want %v, err = %v", pop, want, err)
+	}
+
+	for i := range fly.Positions {
+		fly.Positions[i].Quantity *= -1
+	}
+	pop, err = bs.ProbabilityOfProfit(fly, vol, math.NaN(), tau)
+	if err != nil || math.Abs(pop-(1-want)) > 1e-10 {
+		t.Errorf("short PoP = %v, want %v, err = %v", pop, 1-want, err)
+	}
+}
+
+// uniform is a terminal spot uniform on [lo, hi]
+type uniform struct{ lo, hi float64 }
+
+func (u uniform) PDF(x float64) float64 {
+	if x < u.lo || x > u.hi {
+		return 0
+	}
+	return 1 / (u.hi - u.lo)
+}
+func (u uniform) CDF(x float64) float64      { return math.Max(0, math.Min(1, (x-u.lo)/(u.hi-u.lo))) }
+func (u uniform) Quantile(p float64) float64 { return u.lo + p*(u.hi-u.lo) }
+
+func Test_ExpectedPnL(t *testing.T) {
+
+	const k, q = 110, 0.02
+
+	call := bs.Portfolio{
+		Spot: spot, Rate: r, Dividend: q,
+		Positions: []bs.Position{{Quantity: 1, Type: bs.Call, Strike: k, TimeToExpiry: tau, Vol: vol}},
+	}
+
+	// a fairly priced option has no expected P&L under the risk neutral
+	// distribution, and the standard deviation of its payoff
+	rn := bs.Lognormal{Spot: spot, Drift: r - q, Vol: vol, T: tau}
+	mean, stdev, err := bs.ExpectedPnL(call, rn)
+
+	s := vol * math.Sqrt(tau)
+	d1 := (math.Log(spot/k) + (r-q+vol*vol/2)*tau) / s
+	f := spot * math.Exp((r-q)*tau)
+	m1 := f*bs.NormCDF(d1) - k*bs.NormCDF(d1-s)
+	m2 := f*f*math.Exp(s*s)*bs.NormCDF(d1+s) - 2*k*f*bs.NormCDF(d1) + k*k*bs.NormCDF(d1-s)
+	if err != nil || math.Abs(mean) > 1e-8 || math.Abs(stdev-math.Sqrt(m2-m1*m1)) > 1e-8 {
+		t.Errorf("mean = %v, stdev = %v, want 0, %v, err = %v", mean, stdev, math.Sqrt(m2-m1*m1), err)
+	}
+
+	// a straddle and shares
+	straddle := bs.Portfolio{
+		Spot: spot, Rate: r, Dividend: q, Shares: -0.5,
+		Positions: []bs.Position{
+			{Quantity: 1, Type: bs.Straddle, Strike: 100, TimeToExpiry: tau, Vol: vol},
+		},
+	}
+	if mean, _, err := bs.ExpectedPnL(straddle, rn); err != nil || math.Abs(mean) > 1e-8 {
+		t.Errorf("straddle mean = %v, err = %v", mean, err)
+	}
+
+	// a view of a spot uniform on 80 to 120
+	c := bs.BSPrice(vol, tau, spot, k, r, q, bs.Call) * math.Exp(r*tau)
+	mean, stdev, err = bs.ExpectedPnL(call, uniform{80, 120})
+	if want := 100.0/2/40 - c; err != nil || math.Abs(mean-want) > 1e-8 {
+		t.Errorf("uniform mean = %v, want %v, err = %v", mean, want, err)
+	}
+	if want := math.Sqrt(1000.0/3/40 - (100.0/2/40)*(100.0/2/40)); math.Abs(stdev-want) > 1e-8 {
+		t.Errorf("uniform stdev = %v, want %v", stdev, want)
+	}
+
+	if _, _, err := bs.ExpectedPnL(call, uniform{-10, 10}); err != bs.ErrDistribution {
+		t.Errorf("err = %v", err)
+	}
+	if _, _, err := bs.ExpectedPnL(call, nil); err != bs.ErrNilPtrArg {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func Test_GreeksByExpiry(t *testing.T) {
+
+	book := bs.Portfolio{
+		Spot: spot, Rate: r, Dividend: 0.01, Shares: 3,
+		Positions: []bs.Position{
+			{Quantity: 10, Type: bs.Call, Strike: 100, TimeToExpiry: 20.0 / 365, Vol: 0.25},
+			{Quantity: -4, Type: bs.Put, Strike: 95, TimeToExpiry: 60.0 / 365, Vol: 0.28},
+			{Quantity: 2, Type: bs.Call, Strike: 110, TimeToExpiry: 20.0 / 365, Vol: 0.22},
+		},
+	}
+	leg := func(i int) bs.Greeks {
+		ps := book.Positions[i]
+		return bs.BSGreeks(ps.Vol, ps.TimeToExpiry, spot, ps.Strike, r, 0.01, ps.Type)
+	}
+	nearVega := 10*leg(0).Vega + 2*leg(2).Vega
+	farVega := -4 * leg(1).Vega
+
+	total, err := book.Greeks()
+	if err != nil || math.Abs(total.Vega-(nearVega+farVega)) > 1e-10 ||
+		math.Abs(total.Delta-(3+10*leg(0).Delta-4*leg(1).Delta+2*leg(2).Delta)) > 1e-12 {
+		t.Errorf("greeks = %+v, err = %v", total, err)
+	}
+
+	exact, err := book.GreeksByExpiry(nil)
+	if err != nil || len(exact) != 2 || exact[0].From != 20.0/365 || exact[1].To != 60.0/365 {
+		t.Fatalf("buckets = %+v, err = %v", exact, err)
+	}
+	if math.Abs(exact[0].Greeks.Vega-nearVega) > 1e-10 || math.Abs(exact[1].Greeks.Vega-farVega) > 1e-10 {
+		t.Errorf("vegas %v, %v, want %v, %v", exact[0].Greeks.Vega, exact[1].Greeks.Vega, nearVega, farVega)
+	}
+
+	days := []float64{7.0 / 365, 30.0 / 365, 45.0 / 365}
+	buckets, err := book.GreeksByExpiry(days)
+	if err != nil || len(buckets) != 4 || buckets[3].To != math.Inf(1) {
+		t.Fatalf("buckets = %+v, err = %v", buckets, err)
+	}
+	var sum bs.Greeks
+	for _, b := range buckets {
+		sum.Vega += b.Greeks.Vega
+		sum.Gamma += b.Greeks.Gamma
+		sum.Theta += b.Greeks.Theta
+	}
+	if buckets[0].Greeks != (bs.Greeks{}) || buckets[2].Greeks != (bs.Greeks{}) ||
+		math.Abs(buckets[1].Greeks.Vega-nearVega) > 1e-10 || math.Abs(buckets[3].Greeks.Vega-farVega) > 1e-10 {
+		t.Errorf("buckets = %+v", buckets)
+	}
+	if math.Abs(sum.Vega-total.Vega) > 1e-10 || math.Abs(sum.Gamma-total.Gamma) > 1e-12 || math.Abs(sum.Theta-total.Theta) > 1e-10 {
+		t.Errorf("bucketed %+v, total %+v", sum, total)
+	}
+
+	pv, err := book.ParallelVega()
+	if err != nil || math.Abs(pv-total.Vega) > 1e-6 {
+		t.Errorf("parallel vega = %v, want %v, err = %v", pv, total.Vega, err)
+	}
+
+	ladder, err := book.VegaLadder([]float64{-0.01, 0, 1e-6})
+	if err != nil || ladder[1] != 0 || math.Abs(ladder[2]/1e-6-total.Vega) > 1e-3 || !(ladder[0]*total.Vega < 0) {
+		t.Errorf("ladder = %v, err = %v", ladder, err)
+	}
+	if _, err := book.VegaLadder([]float64{-0.23}); err != bs.ErrNegVol {
+		t.Errorf("err = %v", err)
+	}
+	if _, err := book.GreeksByExpiry([]float64{0.1, 0.1}); err != bs.ErrPortfolio {
+		t.Errorf("err = %v", err)
+	}
+}
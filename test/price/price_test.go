@@ -1,7 +1,9 @@
 package pricetest
 
 import (
+	"errors"
 	"math"
+	"math/big"
 	"testing"
 
 	bs "github.com/uscott/go-blackscholes"
@@ -39,3 +41,226 @@ func Test_Price(t *testing.T) {
 	}
 
 }
+
+func Test_PriceSimCompensated(t *testing.T) {
+
+	// at zero vol every path pays the forward intrinsic value, so the
+	// estimate is exact but for the rounding of the sum
+	const n = 1 << 24
+	tau, x, k, r, q := 0.5, 100.0, 90.1, 0.03, 0.01
+
+	payoff := x*math.Exp((r-q)*tau) - k
+	want := bs.BSPrice(0, tau, x, k, r, q, bs.Call)
+
+	var naive float64
+	for i := 0; i < 2*n; i++ {
+		naive += payoff
+	}
+	naive = math.Exp(-r*tau) * naive / (2 * n)
+
+	sim := bs.BSPriceSim(0, tau, x, k, r, q, bs.Call, n)
+	if e, en := math.Abs(sim-want), math.Abs(naive-want); !(e <= 1e-13*want && e < en) {
+		t.Errorf("sim %v, naive %v, want %v", sim, naive, want)
+	}
+
+	// a small premium out of the money: the payoffs of the simulation are
+	// summed naively and exactly alongside it, and the compensated sum is
+	// nearer the exact one
+	v, k := 0.2, 130.0
+	exact := new(big.Float).SetPrec(256)
+	naive = 0
+	payoffs := func(s float64) float64 {
+		p := math.Max(s-k, 0)
+		naive += p
+		exact.Add(exact, big.NewFloat(p))
+		return p
+	}
+	est, err := bs.SimConfig{Seed: 1}.PayoffSim(v, tau, x, r, q, payoffs, 1<<22)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	df := math.Exp(-r * tau)
+	ex, _ := exact.Float64()
+	ex, naive = df*ex/(1<<23), df*naive/(1<<23)
+	if e, en := math.Abs(est.Value-ex), math.Abs(naive-ex); !(e <= 1e-15*ex && e < en) {
+		t.Errorf("out of the money: sim %v, naive %v, exact %v", est.Value, naive, ex)
+	}
+
+	// and the estimate is within a few standard errors of the price
+	if want := bs.BSPrice(v, tau, x, k, r, q, bs.Call); math.Abs(est.Value-want) > 5*est.StdErr {
+		t.Errorf("out of the money: sim %v, standard error %v, want %v", est.Value, est.StdErr, want)
+	}
+}
+
+func Test_PriceSimUnbiased(t *testing.T) {
+
+	const reps = 400
+	v, tau, x, r, q := 0.5, 1.0/12, 100.0, 0.1, 0.05
+
+	for _, o := range []bs.OptionType{bs.Call, bs.Put} {
+		for _, k := range []float64{90, 100, 120} {
+			for _, n := range []uint{1, 2, 7, 8, 31, 64} {
+
+				want := bs.BSPrice(v, tau, x, k, r, q, o)
+				pars := &bs.PriceParams{
+					Vol: v, TimeToExpiry: tau, Underlying: x, Strike: k, Rate: r, Dividend: q, Type: o,
+				}
+
+				var sum, sumsq, stated float64
+				for i := 0; i < reps; i++ {
+					est, err := bs.SimConfig{Seed: int64(i)}.PriceSim(pars, n)
+					if err != nil {
+						t.Fatal(err)
+					}
+					e := est.Value - want
+					sum += e
+					sumsq += e * e
+					stated += est.StdErr * est.StdErr / reps
+				}
+				mean := sum / reps
+				sd := math.Sqrt((sumsq/reps - mean*mean) * reps / (reps - 1))
+
+				// the mean error is within 5 standard errors of 0, and the
+				// spread of the estimates within the root mean square of the
+				// stated standard error, which ignores the stratification
+				if stderr := sd / math.Sqrt(reps); math.Abs(mean) > 5*stderr+1e-12*want {
+					t.Errorf("%v, strike %v, %d pairs: mean error %v, standard error %v", o, k, n, mean, stderr)
+				}
+				if stated = math.Sqrt(stated); n > 1 && !(sd <= 1.2*stated) {
+					t.Errorf("%v, strike %v, %d pairs: spread %v, stated standard error %v", o, k, n, sd, stated)
+				}
+			}
+		}
+	}
+}
+
+func Test_PriceSimStdErr(t *testing.T) {
+
+	v, tau, x, r, q := 0.5, 1.0/12, 100.0, 0.1, 0.05
+
+	for _, o := range []bs.OptionType{bs.Call, bs.Put, bs.Straddle} {
+		for _, k := range []float64{80, 100, 130} {
+
+			pars := &bs.PriceParams{
+				Vol: v, TimeToExpiry: tau, Underlying: x, Strike: k, Rate: r, Dividend: q, Type: o,
+			}
+			want := bs.BSPrice(v, tau, x, k, r, q, o)
+
+			est, err := bs.SimConfig{Seed: 7}.PriceSim(pars, 1<<14)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !(est.StdErr > 0) || math.Abs(est.Value-want) > 5*est.StdErr {
+				t.Errorf("%v, strike %v: sim %v, standard error %v, want %v", o, k, est.Value, est.StdErr, want)
+			}
+
+			// the seed repeats the estimate, and the package function
+			// draws under the zero SimConfig
+			if again, _ := (bs.SimConfig{Seed: 7}).PriceSim(pars, 1<<14); again != est {
+				t.Errorf("%v, strike %v: seed 7 gave %+v and %+v", o, k, est, again)
+			}
+			zero, _ := bs.SimConfig{}.PriceSim(pars, 1<<14)
+			if p, _ := bs.PriceSim(pars, 1<<14); p != zero.Value {
+				t.Errorf("%v, strike %v: PriceSim %v, zero SimConfig %v", o, k, p, zero.Value)
+			}
+		}
+	}
+
+	est, _ := bs.SimConfig{}.PriceSim(&bs.PriceParams{
+		Vol: v, TimeToExpiry: tau, Underlying: x, Strike: 100, Type: bs.Call,
+	}, 1)
+	if math.IsNaN(est.Value) || !math.IsNaN(est.StdErr) {
+		t.Errorf("single pair %+v", est)
+	}
+}
+
+func Test_PriceSimOnBatch(t *testing.T) {
+
+	type batch struct {
+		paths         int
+		value, stdErr float64
+	}
+
+	var batches []batch
+	c := bs.SimConfig{Seed: 3, OnBatch: func(paths int, value, stdErr float64) {
+		batches = append(batches, batch{paths, value, stdErr})
+	}}
+
+	pars := &bs.PriceParams{Vol: 0.3, TimeToExpiry: 0.25, Underlying: 100, Strike: 105, Rate: 0.02, Type: bs.Put}
+
+	est, err := c.PriceSim(pars, 256)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(batches) != 256 {
+		t.Fatalf("OnBatch called %d times, want 256", len(batches))
+	}
+	for i, b := range batches {
+		if b.paths != i+1 {
+			t.Fatalf("batch %d = %+v", i, b)
+		}
+	}
+	if last := batches[len(batches)-1]; last.value != est.Value || last.stdErr != est.StdErr {
+		t.Errorf("last batch %+v, estimate %+v", last, est)
+	}
+
+	// observing leaves the estimate unchanged
+	if plain, _ := (bs.SimConfig{Seed: 3}).PriceSim(pars, 256); plain != est {
+		t.Errorf("OnBatch estimate %+v, without %+v", est, plain)
+	}
+
+	batches = batches[:0]
+	payoff := func(s float64) float64 { return s * s }
+	pe, err := c.PayoffSim(0.3, 0.25, 100, 0.02, 0, payoff, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(batches) != 64 {
+		t.Fatalf("PayoffSim: OnBatch called %d times, want 64", len(batches))
+	}
+	if last := batches[len(batches)-1]; last.value != pe.Value || last.stdErr != pe.StdErr {
+		t.Errorf("PayoffSim: last batch %+v, estimate %+v", last, pe)
+	}
+}
+
+func Test_PriceSimOverflow(t *testing.T) {
+
+	pars := &bs.PriceParams{Vol: 1000, TimeToExpiry: 1, Underlying: 100, Strike: 100, Rate: 0.01, Type: bs.Call}
+
+	p, err := bs.PriceSim(pars, 1000)
+	var e *bs.SimOverflowError
+	if !errors.As(err, &e) || !math.IsNaN(p) {
+		t.Fatalf("PriceSim = %v, %v", p, err)
+	}
+	if e.Vol != 1000 || e.Strike != 100 || e.Path < 0 || e.Path >= 1000 {
+		t.Errorf("error %+v", e)
+	}
+
+	if p := bs.BSPriceSim(1000, 1, 100, 100, 0.01, 0, bs.Put, 1000); !math.IsNaN(p) {
+		t.Errorf("BSPriceSim = %v, want NaN", p)
+	}
+
+	p, err = bs.PayoffSim(1000, 1, 100, 0.01, 0, func(s float64) float64 { return s }, 1000)
+	if !errors.As(err, &e) || !math.IsNaN(p) {
+		t.Errorf("PayoffSim = %v, %v", p, err)
+	}
+}
+
+var sink float64
+
+func Benchmark_PriceSim(b *testing.B) {
+
+	v, tau, x, k, r, q := 0.5, 1.0/12, 100.0, 120.0, 0.1, 0.05
+
+	for i := 0; i < b.N; i++ {
+		sink = bs.BSPriceSim(v, tau, x, k, r, q, bs.Call, 1<<12)
+	}
+}
+
+func Benchmark_NormCDFInverse(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		sink = bs.NormCDFInverse((float64(i%1024) + 0.5) / 1024)
+	}
+}
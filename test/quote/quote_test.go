@@ -0,0 +1,114 @@
+package quotetest
+
+import (
+	"math"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+const tau, spot, k, r, q = 0.5, 100.0, 105.0, 0.03, 0.01
+
+func Test_ImpliedVolQuote(t *testing.T) {
+
+	bid := bs.BSPrice(0.24, tau, spot, k, r, q, bs.Call)
+	ask := bs.BSPrice(0.26, tau, spot, k, r, q, bs.Call)
+
+	bv, av, mv, err := bs.ImpliedVolQuote(bs.Quote{Bid: bid, Ask: ask}, tau, spot, k, r, q, bs.Call)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(bv-0.24) > 1e-8 || math.Abs(av-0.26) > 1e-8 || !(mv > bv && mv < av) {
+		t.Errorf("vols = %v, %v, %v", bv, av, mv)
+	}
+
+	s, err := bs.VolSpread(bs.Quote{Bid: bid, Ask: ask}, tau, spot, k, r, q, bs.Call)
+	if err != nil || math.Abs(s-2) > 1e-6 {
+		t.Errorf("spread = %v vol points, err = %v", s, err)
+	}
+
+	// an explicit mid is used over the average of bid and ask
+	mid := bs.BSPrice(0.255, tau, spot, k, r, q, bs.Call)
+	if _, _, mv, _ := bs.ImpliedVolQuote(bs.Quote{Bid: bid, Ask: ask, Mid: mid}, tau, spot, k, r, q, bs.Call); math.Abs(mv-0.255) > 1e-8 {
+		t.Errorf("mid vol = %v", mv)
+	}
+}
+
+func Test_ImpliedVolQuoteBounds(t *testing.T) {
+
+	// a zero bid on a far out of the money put
+	ask := bs.BSPrice(0.4, tau, spot, 50, r, q, bs.Put)
+	bv, av, mv, err := bs.ImpliedVolQuote(bs.Quote{Ask: ask}, tau, spot, 50, r, q, bs.Put)
+	if err != nil || bv != 0 || math.Abs(av-0.4) > 1e-8 || !(mv > 0 && mv < av) {
+		t.Errorf("vols = %v, %v, %v, err = %v", bv, av, mv, err)
+	}
+
+	// an ask above the discounted underlying
+	bid := bs.BSPrice(0.3, tau, spot, k, r, q, bs.Call)
+	bv, av, _, err = bs.ImpliedVolQuote(bs.Quote{Bid: bid, Ask: 101}, tau, spot, k, r, q, bs.Call)
+	if err != nil || math.Abs(bv-0.3) > 1e-8 || !math.IsInf(av, 1) {
+		t.Errorf("vols = %v, %v, err = %v", bv, av, err)
+	}
+	if s, _ := bs.VolSpread(bs.Quote{Bid: bid, Ask: 101}, tau, spot, k, r, q, bs.Call); !math.IsInf(s, 1) {
+		t.Errorf("spread = %v", s)
+	}
+}
+
+func Test_ImpliedVolQuoteCrossed(t *testing.T) {
+
+	_, _, _, err := bs.ImpliedVolQuote(bs.Quote{Bid: 5, Ask: 4}, tau, spot, k, r, q, bs.Call)
+	if e, ok := err.(*bs.CrossedQuoteError); !ok || e.Bid != 5 || e.Ask != 4 {
+		t.Errorf("err = %v", err)
+	}
+
+	if _, _, _, err := bs.ImpliedVolQuote(bs.Quote{Bid: 4, Ask: 5}, tau, spot, -1, r, q, bs.Call); err != bs.ErrNegStrike {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func Test_ImpliedVolInterval(t *testing.T) {
+
+	for _, o := range []bs.OptionType{bs.Call, bs.Put, bs.Straddle} {
+
+		// tight and wide quotes, the ask vol above the default upper bound
+		for _, vols := range [][2]float64{{0.25, 0.2501}, {0.1, 0.6}, {0.3, 2.5}} {
+
+			bid := bs.BSPrice(vols[0], tau, spot, k, r, q, o)
+			ask := bs.BSPrice(vols[1], tau, spot, k, r, q, o)
+
+			lo, hi, err := bs.ImpliedVolInterval(bid, ask, tau, spot, k, r, q, o)
+			if err != nil || math.Abs(lo-vols[0]) > 1e-8 || math.Abs(hi-vols[1]) > 1e-8 {
+				t.Errorf("%c %v: interval = %v, %v, err = %v", o, vols, lo, hi, err)
+			}
+
+			// the same vols as the quote
+			bv, av, _, _ := bs.ImpliedVolQuote(bs.Quote{Bid: bid, Ask: ask}, tau, spot, k, r, q, o)
+			if math.Abs(lo-bv) > 1e-8 || math.Abs(hi-av) > 1e-8 {
+				t.Errorf("%c %v: interval = %v, %v, quote vols %v, %v", o, vols, lo, hi, bv, av)
+			}
+		}
+	}
+
+	// a zero bid and an ask above the discounted underlying
+	ask := bs.BSPrice(0.4, tau, spot, 50, r, q, bs.Put)
+	if lo, hi, err := bs.ImpliedVolInterval(0, ask, tau, spot, 50, r, q, bs.Put); err != nil || lo != 0 || math.Abs(hi-0.4) > 1e-8 {
+		t.Errorf("zero bid: interval = %v, %v, err = %v", lo, hi, err)
+	}
+	bid := bs.BSPrice(0.3, tau, spot, k, r, q, bs.Call)
+	if lo, hi, err := bs.ImpliedVolInterval(bid, 101, tau, spot, k, r, q, bs.Call); err != nil || math.Abs(lo-0.3) > 1e-8 || !math.IsInf(hi, 1) {
+		t.Errorf("high ask: interval = %v, %v, err = %v", lo, hi, err)
+	}
+
+	// a locked quote has one vol
+	if lo, hi, err := bs.ImpliedVolInterval(bid, bid, tau, spot, k, r, q, bs.Call); err != nil || lo != hi {
+		t.Errorf("locked: interval = %v, %v, err = %v", lo, hi, err)
+	}
+
+	_, _, err := bs.ImpliedVolInterval(5, 4, tau, spot, k, r, q, bs.Call)
+	if e, ok := err.(*bs.CrossedQuoteError); !ok || e.Bid != 5 || e.Ask != 4 {
+		t.Errorf("crossed: err = %v", err)
+	}
+	if _, _, err := bs.ImpliedVolInterval(1, 2, -tau, spot, k, r, q, bs.Call); err == nil {
+		t.Error("negative time: nil err")
+	}
+}
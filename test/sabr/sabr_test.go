@@ -0,0 +1,108 @@
+package sabrtest
+
+import (
+	"math"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+func vol(t *testing.T, alpha, beta, rho, nu, f, k, tau float64) float64 {
+	v, err := bs.SABRImpliedVol(alpha, beta, rho, nu, f, k, tau)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func Test_SABRATM(t *testing.T) {
+
+	const f, tau = 0.03, 2.0
+
+	for _, beta := range []float64{0, 0.5, 1} {
+		for _, rho := range []float64{-0.7, 0, 0.4} {
+
+			alpha, nu := 0.2*math.Pow(f, 1-beta), 0.6
+
+			b1 := 1 - beta
+			fb := math.Pow(f, b1)
+			atm := alpha / fb * (1 + (b1*b1/24*alpha*alpha/fb/fb+rho*beta*nu*alpha/4/fb+(2-3*rho*rho)/24*nu*nu)*tau)
+
+			if v := vol(t, alpha, beta, rho, nu, f, f, tau); math.Abs(v-atm) > 1e-15 {
+				t.Errorf("Beta = %v, Rho = %v: ATM vol = %v, want %v", beta, rho, v, atm)
+			}
+
+			// the vol is continuous through the switch to the series
+			for _, e := range []float64{1e-9, 1e-6, 1e-4, 1e-3} {
+				for _, k := range []float64{f * (1 + e), f * (1 - e)} {
+					v := vol(t, alpha, beta, rho, nu, f, k, tau)
+					if math.Abs(v-atm) > 2*e {
+						t.Errorf("Beta = %v, Rho = %v, Strike = %v: vol = %v, ATM %v", beta, rho, k, v, atm)
+					}
+				}
+			}
+		}
+	}
+}
+
+func Test_SABRNuZero(t *testing.T) {
+
+	const f, tau, alpha = 100.0, 1.0, 0.25
+
+	// lognormal SABR without vol of vol is Black with vol alpha
+	for _, k := range []float64{50, 100, 150} {
+		if v := vol(t, alpha, 1, 0.3, 0, f, k, tau); math.Abs(v-alpha) > 1e-15 {
+			t.Errorf("Strike = %v: vol = %v, want %v", k, v, alpha)
+		}
+		if v := vol(t, alpha, 1, 0.3, 1e-6, f, k, tau); math.Abs(v-alpha) > 1e-6 {
+			t.Errorf("Strike = %v: vol = %v, want %v", k, v, alpha)
+		}
+	}
+
+	// for beta < 1 it is the CEV smile, skewed down in strike
+	a := 0.25 * math.Sqrt(f)
+	lo, atm, hi := vol(t, a, 0.5, 0, 0, f, 80, tau), vol(t, a, 0.5, 0, 0, f, f, tau), vol(t, a, 0.5, 0, 0, f, 120, tau)
+	if !(lo > atm && atm > hi) {
+		t.Errorf("CEV vols = %v, %v, %v", lo, atm, hi)
+	}
+}
+
+func Test_CalibrateSABR(t *testing.T) {
+
+	const f, tau, beta = 100.0, 1.5, 0.7
+	want := bs.SABRParams{Alpha: 0.25 * math.Pow(f, 1-beta), Beta: beta, Rho: -0.35, Nu: 0.8, Forward: f, T: tau}
+
+	var points []bs.SmilePoint
+	for k := 60.0; k <= 160; k += 10 {
+		points = append(points, bs.SmilePoint{Strike: k, Vol: want.Vol(k)})
+	}
+
+	got, err := bs.CalibrateSABR(points, beta, f, tau)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if math.Abs(got.Alpha/want.Alpha-1) > 1e-5 || math.Abs(got.Rho-want.Rho) > 1e-5 ||
+		math.Abs(got.Nu-want.Nu) > 1e-5 || got.RMSE > 1e-8 {
+		t.Errorf("calibrated %+v, want %+v", got, want)
+	}
+}
+
+func Test_SABRErrors(t *testing.T) {
+
+	for _, c := range []struct {
+		alpha, beta, rho, nu, f, k float64
+		err                        error
+	}{
+		{0, 0.5, 0, 0.5, 1, 1, bs.ErrSABR},
+		{0.2, 1.5, 0, 0.5, 1, 1, bs.ErrSABR},
+		{0.2, 0.5, 1, 0.5, 1, 1, bs.ErrSABR},
+		{0.2, 0.5, 0, -0.1, 1, 1, bs.ErrSABR},
+		{0.2, 0.5, 0, 0.5, 0, 1, bs.ErrNegPrice},
+		{0.2, 0.5, 0, 0.5, 1, -1, bs.ErrNegStrike},
+	} {
+		if _, err := bs.SABRImpliedVol(c.alpha, c.beta, c.rho, c.nu, c.f, c.k, 1); err != c.err {
+			t.Errorf("%+v: err = %v", c, err)
+		}
+	}
+}
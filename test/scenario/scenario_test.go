@@ -0,0 +1,249 @@
+package scenariotest
+
+import (
+	"math"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+const spot, r, q = 100.0, 0.03, 0.01
+
+var shocks = []float64{80, 90, 95, 100, 105, 110, 120}
+
+func skew(t *testing.T) *bs.VolSurface {
+	var smiles []bs.Smile
+	for _, tau := range []float64{0.25, 1} {
+		smiles = append(smiles, bs.Smile{
+			T: tau, Strikes: []float64{60, 80, 100, 120, 150}, Vols: []float64{0.4, 0.3, 0.22, 0.18, 0.17},
+		})
+	}
+	s, err := bs.NewVolSurface(spot, r, q, smiles, bs.SurfaceConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func Test_ScenariosFlat(t *testing.T) {
+
+	ss, err := bs.SpotScenarios(bs.FlatVol(0.2), spot, shocks, 100, 0.5, r, q, bs.Call, bs.StickyStrike)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sd, err := bs.SpotScenarios(bs.FlatVol(0.2), spot, shocks, 100, 0.5, r, q, bs.Call, bs.StickyDelta)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range shocks {
+		if ss[i] != sd[i] || ss[i].Spot != shocks[i] || ss[i].Vol != 0.2 {
+			t.Errorf("spot %v: sticky strike %+v, sticky delta %+v", shocks[i], ss[i], sd[i])
+		}
+	}
+
+	a, err := bs.LadderShifted(bs.FlatVol(0.2), spot, 110, []float64{90, 100, 110}, []float64{0.5, 1}, r, q, bs.Put, bs.StickyStrike)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := bs.LadderShifted(bs.FlatVol(0.2), spot, 110, []float64{90, 100, 110}, []float64{0.5, 1}, r, q, bs.Put, bs.StickyDelta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for n := range a.Prices {
+		if a.Prices[n] != b.Prices[n] || a.Deltas[n] != b.Deltas[n] {
+			t.Errorf("cell %d: %v, %v", n, a.Deltas[n], b.Deltas[n])
+		}
+	}
+}
+
+func Test_ScenariosSkew(t *testing.T) {
+
+	s := skew(t)
+	const k, tau = 100.0, 0.5
+
+	ss, err := bs.SpotScenarios(s, spot, shocks, k, tau, r, q, bs.Call, bs.StickyStrike)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sd, err := bs.SpotScenarios(s, spot, shocks, k, tau, r, q, bs.Call, bs.StickyDelta)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v0, _ := s.Vol(k, tau)
+
+	for i, x := range shocks {
+
+		if ss[i].Vol != v0 {
+			t.Errorf("spot %v: sticky strike vol %v, want %v", x, ss[i].Vol, v0)
+		}
+		want, _ := s.Vol(k*(spot/x), tau)
+		if sd[i].Vol != want {
+			t.Errorf("spot %v: sticky delta vol %v, want %v", x, sd[i].Vol, want)
+		}
+
+		if x == spot {
+			if ss[i] != sd[i] {
+				t.Errorf("unshocked: %+v, %+v", ss[i], sd[i])
+			}
+			continue
+		}
+
+		// with a downward skew the strike moves up the smile to lower vols
+		// as the spot falls under sticky delta, and the deltas part by a
+		// measurable amount
+		if x < spot && !(sd[i].Vol < ss[i].Vol) || x > spot && !(sd[i].Vol > ss[i].Vol) {
+			t.Errorf("spot %v: sticky delta vol %v, sticky strike vol %v", x, sd[i].Vol, ss[i].Vol)
+		}
+		if math.Abs(sd[i].Greeks.Delta-ss[i].Greeks.Delta) < 1e-3 {
+			t.Errorf("spot %v: deltas %v and %v", x, sd[i].Greeks.Delta, ss[i].Greeks.Delta)
+		}
+	}
+
+	l, err := bs.LadderShifted(s, spot, 90, []float64{100}, []float64{tau}, r, q, bs.Call, bs.StickyDelta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Greeks(0, 0) != sd[1].Greeks {
+		t.Errorf("ladder %+v, scenario %+v", l.Greeks(0, 0), sd[1].Greeks)
+	}
+}
+
+func Test_ScenarioErrors(t *testing.T) {
+
+	if _, err := bs.SpotScenarios(bs.FlatVol(0.2), spot, shocks, 100, 0.5, r, q, bs.Call, bs.ShiftMode(9)); err != bs.ErrShiftMode {
+		t.Errorf("unknown mode: %v", err)
+	}
+
+	pts, err := bs.SpotScenarios(bs.FlatVol(0.2), spot, []float64{90, 0, 110}, 100, 0.5, r, q, bs.Call, bs.StickyDelta)
+	me, ok := err.(bs.MultiError)
+	if !ok || len(me) != 1 || me[0].Index != 1 || me[0].Err != bs.ErrNegPrice {
+		t.Fatalf("err = %v", err)
+	}
+	if !math.IsNaN(pts[1].Greeks.Price) || math.IsNaN(pts[2].Greeks.Price) {
+		t.Errorf("points %+v", pts)
+	}
+
+	if _, err := bs.LadderShifted(nil, spot, 90, []float64{100}, []float64{1}, r, q, bs.Call, bs.StickyDelta); err != bs.ErrNilPtrArg {
+		t.Errorf("nil source: %v", err)
+	}
+	if bs.StickyDelta.String() != "sticky delta" || bs.ShiftMode(9).String() != "ShiftMode(9)" {
+		t.Error(bs.StickyDelta, bs.ShiftMode(9))
+	}
+}
+
+// linearSkew is a vol linear in moneyness strike/spot
+type linearSkew struct{ atm, slope float64 }
+
+func (s linearSkew) Vol(strike, timeToExpiry float64) (float64, error) {
+	return s.atm + s.slope*(strike/spot-1), nil
+}
+
+func Test_SmileDelta(t *testing.T) {
+
+	const tau = 0.5
+	sk := linearSkew{atm: 0.25, slope: -0.3}
+
+	for _, o := range []bs.OptionType{bs.Call, bs.Put, bs.Straddle} {
+		for _, k := range []float64{80, 100, 125} {
+
+			v, _ := sk.Vol(k, tau)
+			delta, vega := bs.BSDelta(v, tau, spot, k, r, q, o), bs.BSVega(v, tau, spot, k, r, q, o)
+
+			// sticky strike leaves the vol and so the delta alone
+			d, err := bs.SmileDelta(sk, spot, k, tau, r, q, o, bs.StickyStrike)
+			if err != nil || d != delta {
+				t.Errorf("%v, strike %v: sticky strike delta %v, %v, want %v", o, k, d, err, delta)
+			}
+
+			// sticky delta moves the vol of the strike by -slope*k/spot^2
+			d, err = bs.SmileDelta(sk, spot, k, tau, r, q, o, bs.StickyDelta)
+			want := delta - vega*sk.slope*k/spot/spot
+			if err != nil || math.Abs(d-want) > 1e-8 {
+				t.Errorf("%v, strike %v: sticky delta delta %v, %v, want %v", o, k, d, err, want)
+			}
+
+			// the gamma is the slope of the smile delta, each spot
+			// reading the source shifted to it
+			const h = 0.01
+			var ds [2]float64
+			for i, x := range []float64{spot - h, spot + h} {
+				src, _ := bs.ShiftedVolSource(sk, spot, x, bs.StickyDelta)
+				ds[i], _ = bs.SmileDelta(src, x, k, tau, r, q, o, bs.StickyDelta)
+			}
+			g, err := bs.SmileGamma(sk, spot, k, tau, r, q, o, bs.StickyDelta)
+			if want := (ds[1] - ds[0]) / 2 / h; err != nil || math.Abs(g-want) > 1e-5 {
+				t.Errorf("%v, strike %v: sticky delta gamma %v, %v, want %v", o, k, g, err, want)
+			}
+
+			// a flat surface has no correction in either mode
+			for _, mode := range []bs.ShiftMode{bs.StickyStrike, bs.StickyDelta} {
+				d, _ := bs.SmileDelta(bs.FlatVol(0.2), spot, k, tau, r, q, o, mode)
+				g, _ := bs.SmileGamma(bs.FlatVol(0.2), spot, k, tau, r, q, o, mode)
+				if d != bs.BSDelta(0.2, tau, spot, k, r, q, o) || math.Abs(g-bs.BSGamma(0.2, tau, spot, k, r, q, o)) > 1e-6 {
+					t.Errorf("%v, strike %v, %v: flat delta %v, gamma %v", o, k, mode, d, g)
+				}
+			}
+		}
+	}
+
+	if _, err := bs.SmileDelta(sk, spot, 100, tau, r, q, bs.Call, bs.ShiftMode(7)); err != bs.ErrShiftMode {
+		t.Errorf("unknown mode: %v", err)
+	}
+	if _, err := bs.SmileGamma(nil, spot, 100, tau, r, q, bs.Call, bs.StickyDelta); err != bs.ErrNilPtrArg {
+		t.Errorf("nil source: %v", err)
+	}
+	if _, err := bs.SmileDelta(bs.FlatVol(-0.1), spot, 100, tau, r, q, bs.Call, bs.StickyStrike); err != bs.ErrNegVol {
+		t.Errorf("negative vol: %v", err)
+	}
+}
+
+func Test_ShadowGamma(t *testing.T) {
+
+	const tau = 0.5
+	surf := skew(t)
+
+	for _, o := range []bs.OptionType{bs.Call, bs.Put, bs.Straddle} {
+		for _, k := range []float64{90, 100, 110} {
+
+			// a flat surface gives the analytic gamma in either mode, to
+			// the O(h^2) error of the difference
+			for _, mode := range []bs.ShiftMode{bs.StickyStrike, bs.StickyDelta} {
+				want, _ := bs.Gamma(&bs.PriceParams{Vol: 0.2, TimeToExpiry: tau, Underlying: spot, Strike: k, Rate: r, Dividend: q, Type: o})
+				for _, pct := range []float64{0, 0.1} {
+					g, err := bs.ShadowGamma(bs.FlatVol(0.2), spot, k, tau, r, q, o, pct, mode)
+					if err != nil || math.Abs(g-want) > 1e-3*want {
+						t.Errorf("%v, strike %v, %v, bump %v%%: flat %v, %v, want %v", o, k, mode, pct, g, err, want)
+					}
+				}
+			}
+
+			// sticky strike on the skew is the gamma at the vol of the strike
+			v, _ := surf.Vol(k, tau)
+			gamma := bs.BSGamma(v, tau, spot, k, r, q, o)
+			g, err := bs.ShadowGamma(surf, spot, k, tau, r, q, o, 0, bs.StickyStrike)
+			if err != nil || math.Abs(g-gamma) > 1e-3*gamma {
+				t.Errorf("%v, strike %v: sticky strike %v, %v, want %v", o, k, g, err, gamma)
+			}
+		}
+
+		// under sticky delta the vol of a fixed strike on a straight put
+		// skew is concave in spot, which takes gamma away at the money
+		sk := linearSkew{atm: 0.25, slope: -0.3}
+		gamma := bs.BSGamma(sk.atm, tau, spot, 100, r, q, o)
+		g, err := bs.ShadowGamma(sk, spot, 100, tau, r, q, o, 1, bs.StickyDelta)
+		if err != nil || !(g < gamma) {
+			t.Errorf("%v: sticky delta shadow gamma %v, %v, analytic %v", o, g, err, gamma)
+		}
+		if d, _ := bs.ShadowGamma(sk, spot, 100, tau, r, q, o, 0, bs.StickyDelta); d != g {
+			t.Errorf("%v: default bump %v, 1%% bump %v", o, d, g)
+		}
+	}
+
+	for _, pct := range []float64{-1, 100, math.NaN()} {
+		if _, err := bs.ShadowGamma(surf, spot, 100, tau, r, q, bs.Call, pct, bs.StickyDelta); err != bs.ErrSpotBump {
+			t.Errorf("bump %v%%: %v", pct, err)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package slicetest
+
+import (
+	"math"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+func makeChain(n int) (v, k []float64) {
+
+	v, k = make([]float64, n), make([]float64, n)
+
+	for i := 0; i < n; i++ {
+		k[i] = 50 + 100*float64(i)/float64(n)
+		v[i] = 0.15 + 0.2*math.Abs(k[i]-100)/100
+	}
+
+	return
+}
+
+func Test_D1D2Slice(t *testing.T) {
+
+	const N = 500
+	var tau, x, r, q float64 = 0.3, 100, 0.05, 0.01
+
+	v, k := makeChain(N)
+	k[10] = -5
+
+	d1, d2 := make([]float64, N), make([]float64, N)
+	nd1 := make([]float64, N)
+
+	err := bs.D1D2Slice(v, tau, x, k, r, q, d1, d2)
+	if m, ok := err.(bs.MultiError); !ok || len(m) != 1 || m[0].Index != 10 {
+		t.Fatalf("err = %v", err)
+	}
+
+	if err := bs.NormCDFSlice(nd1, d1); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < N; i++ {
+		if i == 10 {
+			if !math.IsNaN(d1[i]) || !math.IsNaN(d2[i]) {
+				t.Errorf("row %d: %v, %v", i, d1[i], d2[i])
+			}
+			continue
+		}
+		want1, want2 := bs.D1(v[i], tau, x, k[i], r, q), bs.D2(v[i], tau, x, k[i], r, q)
+		if math.Abs(d1[i]-want1) > 1e-13 || math.Abs(d2[i]-want2) > 1e-13 {
+			t.Errorf("row %d: %v, %v, want %v, %v", i, d1[i], d2[i], want1, want2)
+		}
+		if math.Abs(nd1[i]-bs.NormCDF(want1)) > 1e-13 {
+			t.Errorf("row %d: N(d1) = %v", i, nd1[i])
+		}
+	}
+
+	if err := bs.D1D2Slice(v, tau, x, k[1:], r, q, d1, d2); err != bs.ErrLengthMismatch {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func Test_PriceChain(t *testing.T) {
+
+	const N = 500
+	var tau, x, r, q float64 = 0.3, 100, 0.05, 0.01
+
+	v, k := makeChain(N)
+	v[3], k[4], k[5] = 0, 0, -1
+
+	for _, o := range []bs.OptionType{bs.Call, bs.Put, bs.Straddle} {
+
+		prices := make([]float64, N)
+		err := bs.PriceChain(prices, v, tau, x, k, r, q, o)
+		if m, ok := err.(bs.MultiError); !ok || len(m) != 1 || m[0].Index != 5 {
+			t.Fatalf("err = %v", err)
+		}
+
+		for i := 0; i < N; i++ {
+			want := bs.BSPrice(v[i], tau, x, k[i], r, q, o)
+			if i == 5 {
+				if !math.IsNaN(prices[i]) {
+					t.Errorf("row %d: %v", i, prices[i])
+				}
+				continue
+			}
+			if math.Abs(prices[i]-want) > 1e-12*math.Max(1, want) {
+				t.Errorf("Type = %c, row %d: %v, want %v", o, i, prices[i], want)
+			}
+		}
+	}
+}
+
+var sink []float64
+
+func Benchmark_D1D2Scalar(b *testing.B) {
+
+	v, k := makeChain(1000)
+	d1, d2 := make([]float64, len(k)), make([]float64, len(k))
+
+	for i := 0; i < b.N; i++ {
+		for j := range k {
+			d1[j] = bs.D1(v[j], 0.3, 100, k[j], 0.05, 0.01)
+			d2[j] = bs.D2fromD1(d1[j], v[j], 0.3)
+		}
+	}
+
+	sink = d2
+}
+
+func Benchmark_D1D2Slice(b *testing.B) {
+
+	v, k := makeChain(1000)
+	d1, d2 := make([]float64, len(k)), make([]float64, len(k))
+
+	for i := 0; i < b.N; i++ {
+		bs.D1D2Slice(v, 0.3, 100, k, 0.05, 0.01, d1, d2)
+	}
+
+	sink = d2
+}
+
+func Benchmark_PriceScalar(b *testing.B) {
+
+	v, k := makeChain(1000)
+	prices := make([]float64, len(k))
+
+	for i := 0; i < b.N; i++ {
+		for j := range k {
+			prices[j] = bs.BSPrice(v[j], 0.3, 100, k[j], 0.05, 0.01, bs.Call)
+		}
+	}
+
+	sink = prices
+}
+
+func Benchmark_PriceChain(b *testing.B) {
+
+	v, k := makeChain(1000)
+	prices := make([]float64, len(k))
+
+	for i := 0; i < b.N; i++ {
+		bs.PriceChain(prices, v, 0.3, 100, k, 0.05, 0.01, bs.Call)
+	}
+
+	sink = prices
+}
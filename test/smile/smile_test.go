@@ -0,0 +1,127 @@
+package smiletest
+
+import (
+	"math"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+const forward, tau = 100.0, 0.5
+
+var methods = []bs.InterpMethod{
+	bs.InterpLinear, bs.InterpLinearVol, bs.InterpNaturalSpline, bs.InterpClampedSpline, bs.InterpMonotone,
+}
+
+func points(strikes, vols []float64) []bs.SmilePoint {
+	ps := make([]bs.SmilePoint, len(strikes))
+	for i := range ps {
+		ps[i] = bs.SmilePoint{Strike: strikes[i], Vol: vols[i]}
+	}
+	return ps
+}
+
+func Test_SmileInterpNodes(t *testing.T) {
+
+	strikes := []float64{60, 75, 90, 100, 110, 130, 160}
+	vols := []float64{0.42, 0.33, 0.26, 0.22, 0.2, 0.21, 0.25}
+
+	for _, m := range methods {
+		s, err := bs.NewSmileInterp(points(strikes, vols), bs.InterpConfig{Method: m, Forward: forward, T: tau})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, k := range strikes {
+			if v := s.Vol(k); math.Abs(v-vols[i]) > 1e-15 {
+				t.Errorf("Method = %v, Strike = %v: vol = %v, want %v", m, k, v, vols[i])
+			}
+		}
+		// flat beyond the wings
+		if v := s.Vol(20); v != vols[0] {
+			t.Errorf("Method = %v: left wing vol = %v", m, v)
+		}
+		if v := s.Vol(400); v != vols[len(vols)-1] {
+			t.Errorf("Method = %v: right wing vol = %v", m, v)
+		}
+	}
+}
+
+func Test_SmileInterpMonotone(t *testing.T) {
+
+	// a step in the quotes makes the splines overshoot but not Steffen's
+	// interpolant
+	strikes := []float64{70, 80, 90, 100, 110, 120, 130}
+	vols := []float64{0.3, 0.3, 0.3, 0.2, 0.2, 0.2, 0.2}
+
+	overshoot := func(m bs.InterpMethod) bool {
+		s, err := bs.NewSmileInterp(points(strikes, vols), bs.InterpConfig{Method: m, Forward: forward, T: tau})
+		if err != nil {
+			t.Fatal(err)
+		}
+		prev := math.Inf(1)
+		for k := 70.0; k <= 130; k += 0.25 {
+			v := s.Vol(k)
+			if v > prev+1e-15 || v < 0.2-1e-15 || v > 0.3+1e-15 {
+				return true
+			}
+			prev = v
+		}
+		return false
+	}
+
+	if overshoot(bs.InterpMonotone) {
+		t.Error("monotone interpolation overshoots")
+	}
+	if !overshoot(bs.InterpNaturalSpline) {
+		t.Error("natural spline does not overshoot a step")
+	}
+}
+
+func Test_SmileInterpSmooth(t *testing.T) {
+
+	strikes := []float64{60, 75, 90, 100, 110, 130, 160}
+	vols := []float64{0.42, 0.33, 0.26, 0.22, 0.2, 0.21, 0.25}
+
+	for _, m := range []bs.InterpMethod{bs.InterpNaturalSpline, bs.InterpClampedSpline, bs.InterpMonotone} {
+
+		s, err := bs.NewSmileInterp(points(strikes, vols), bs.InterpConfig{Method: m, Forward: forward, T: tau})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// total variance in log-moneyness
+		w := func(k float64) float64 { v := s.Vol(forward * math.Exp(k)); return v * v * tau }
+
+		// one sided slopes agree at the interior nodes
+		const h = 1e-6
+		for _, strike := range strikes[1 : len(strikes)-1] {
+			k := math.Log(strike / forward)
+			left, right := (w(k)-w(k-h))/h, (w(k+h)-w(k))/h
+			if math.Abs(left-right) > 1e-5 {
+				t.Errorf("Method = %v, Strike = %v: slopes %v, %v", m, strike, left, right)
+			}
+		}
+	}
+
+	// the clamped spline also joins the flat wings smoothly
+	s, _ := bs.NewSmileInterp(points(strikes, vols), bs.InterpConfig{Method: bs.InterpClampedSpline, Forward: forward, T: tau})
+	if d := (s.Vol(60*1.0001) - s.Vol(60)) / 60e-4; math.Abs(d) > 1e-5 {
+		t.Errorf("clamped slope at the left wing = %v", d)
+	}
+}
+
+func Test_SmileInterpErrors(t *testing.T) {
+
+	cfg := bs.InterpConfig{Forward: forward, T: tau}
+
+	if _, err := bs.NewSmileInterp(nil, cfg); err != bs.ErrSmile {
+		t.Errorf("err = %v", err)
+	}
+	if _, err := bs.NewSmileInterp(points([]float64{100, 90}, []float64{0.2, 0.2}), cfg); err != bs.ErrSmile {
+		t.Errorf("err = %v", err)
+	}
+	cfg.T = 0
+	if _, err := bs.NewSmileInterp(points([]float64{100}, []float64{0.2}), cfg); err != bs.ErrNegTimeToExp {
+		t.Errorf("err = %v", err)
+	}
+}
@@ -0,0 +1,195 @@
+package solvertest
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+const tau, x, r, q = 0.75, 100.0, 0.04, 0.01
+
+type point struct {
+	premium, k float64
+	o          bs.OptionType
+}
+
+// points returns premia at strikes from 50 to 150 and vols from -0.2 to
+// 1.5, negative vols pricing below intrinsic value
+func points(n int) []point {
+
+	rng := rand.New(rand.NewSource(1))
+	types := []bs.OptionType{bs.Call, bs.Put, bs.Straddle}
+
+	pts := make([]point, n)
+	for i := range pts {
+		k := 50 + 100*rng.Float64()
+		v := -0.2 + 1.7*rng.Float64()
+		o := types[i%3]
+		pts[i] = point{premium: bs.BSPrice(v, tau, x, k, r, q, o), k: k, o: o}
+	}
+
+	return pts
+}
+
+func Test_SolverMatchesImpliedVol(t *testing.T) {
+
+	lb, ub, tol := 0.1, 0.5, 1e-12
+	for _, cfg := range []bs.SolverConfig{
+		{},
+		{LB: lb, UB: ub, Tol: tol},
+	} {
+
+		s, err := bs.NewSolver(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, p := range points(300) {
+
+			pars := &bs.ImpliedVolParams{
+				Premium: p.premium, TimeToExpiry: tau, Underlying: x, Strike: p.k, Rate: r, Dividend: q, Type: p.o,
+			}
+			if cfg.LB != 0 {
+				pars.LB, pars.UB, pars.Tol = &lb, &ub, &tol
+			}
+
+			want, err := bs.ImpliedVol(pars)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := s.ImpliedVol(p.premium, tau, x, p.k, r, q, p.o)
+			if err != nil || got != want {
+				t.Errorf("%+v, %v: %v, %v, want %v", cfg, p, got, err, want)
+			}
+		}
+	}
+}
+
+func Test_SolverNewton(t *testing.T) {
+
+	s, err := bs.NewSolver(bs.SolverConfig{Method: bs.SolverNewton, Tol: 1e-12})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range points(300) {
+		v, err := s.ImpliedVol(p.premium, tau, x, p.k, r, q, p.o)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := bs.BSPrice(v, tau, x, p.k, r, q, p.o); math.Abs(got-p.premium) > 1e-9 {
+			t.Errorf("%v: vol %v prices at %v", p, v, got)
+		}
+	}
+}
+
+func Test_SolverErrors(t *testing.T) {
+
+	if _, err := bs.NewSolver(bs.SolverConfig{Method: bs.SolverMethod(9)}); err != bs.ErrSolverConfig {
+		t.Errorf("unknown method: %v", err)
+	}
+	if _, err := bs.NewSolverPool(bs.SolverConfig{Bounds: bs.BoundsPolicy(9)}); err != bs.ErrSolverConfig {
+		t.Errorf("unknown bounds policy: %v", err)
+	}
+
+	s, _ := bs.NewSolver(bs.SolverConfig{Bounds: bs.BoundsFixed, LB: 0.1, UB: 0.5})
+	for _, v := range []float64{0.05, 0.8} {
+		p := bs.BSPrice(v, tau, x, 100, r, q, bs.Call)
+		if _, err := s.ImpliedVol(p, tau, x, 100, r, q, bs.Call); err != bs.ErrVolBracket {
+			t.Errorf("vol %v outside fixed bounds: %v", v, err)
+		}
+	}
+	if v, err := s.ImpliedVol(bs.BSPrice(0.3, tau, x, 100, r, q, bs.Call), tau, x, 100, r, q, bs.Call); err != nil || math.Abs(v-0.3) > 1e-8 {
+		t.Errorf("vol inside fixed bounds: %v, %v", v, err)
+	}
+
+	s, _ = bs.NewSolver(bs.SolverConfig{MaxIt: 5})
+	if _, err := s.ImpliedVol(bs.BSPrice(0.3, tau, x, 100, r, q, bs.Call), tau, x, 100, r, q, bs.Call); err != bs.ErrNoncovergence {
+		t.Errorf("5 iterations: %v", err)
+	}
+	if _, err := s.ImpliedVol(1, -1, x, 100, r, q, bs.Call); err != bs.ErrNegTimeToExp {
+		t.Errorf("negative time: %v", err)
+	}
+}
+
+func Test_SolverAllocs(t *testing.T) {
+
+	pts := points(30)
+	for _, m := range []bs.SolverMethod{bs.SolverBisection, bs.SolverNewton} {
+
+		s, _ := bs.NewSolver(bs.SolverConfig{Method: m})
+		i := 0
+		allocs := testing.AllocsPerRun(100, func() {
+			p := pts[i%len(pts)]
+			s.ImpliedVol(p.premium, tau, x, p.k, r, q, p.o)
+			i++
+		})
+		if allocs != 0 {
+			t.Errorf("%v: %v allocations per solve", m, allocs)
+		}
+	}
+}
+
+func Test_SolverPool(t *testing.T) {
+
+	sp, err := bs.NewSolverPool(bs.SolverConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, _ := bs.NewSolver(bs.SolverConfig{})
+
+	pts := points(200)
+	want := make([]float64, len(pts))
+	for i, p := range pts {
+		want[i], _ = s.ImpliedVol(p.premium, tau, x, p.k, r, q, p.o)
+	}
+
+	var wg sync.WaitGroup
+	got := make([]float64, len(pts))
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := g; i < len(pts); i += 4 {
+				p := pts[i]
+				got[i], _ = sp.ImpliedVol(p.premium, tau, x, p.k, r, q, p.o)
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	for i := range pts {
+		if got[i] != want[i] {
+			t.Errorf("%v: pool %v, solver %v", pts[i], got[i], want[i])
+		}
+	}
+}
+
+func benchmarkSolver(b *testing.B, m bs.SolverMethod) {
+	pts := points(64)
+	s, _ := bs.NewSolver(bs.SolverConfig{Method: m})
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p := pts[i%len(pts)]
+		s.ImpliedVol(p.premium, tau, x, p.k, r, q, p.o)
+	}
+}
+
+func Benchmark_SolverBisection(b *testing.B) { benchmarkSolver(b, bs.SolverBisection) }
+func Benchmark_SolverNewton(b *testing.B)    { benchmarkSolver(b, bs.SolverNewton) }
+
+func Benchmark_ImpliedVol(b *testing.B) {
+	pts := points(64)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p := pts[i%len(pts)]
+		bs.ImpliedVol(&bs.ImpliedVolParams{
+			Premium: p.premium, TimeToExpiry: tau, Underlying: x, Strike: p.k, Rate: r, Dividend: q, Type: p.o,
+		})
+	}
+}
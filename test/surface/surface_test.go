@@ -0,0 +1,205 @@
+package surfacetest
+
+import (
+	"math"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+const spot, r, q = 100.0, 0.03, 0.01
+
+func Test_VolSurfaceFlat(t *testing.T) {
+
+	const vol = 0.22
+
+	smiles := []bs.Smile{
+		{T: 0.25, Strikes: []float64{80, 100, 120}, Vols: []float64{vol, vol, vol}},
+		{T: 1, Deltas: []float64{-0.1, 0.75, 0.5, 0.25}, Vols: []float64{vol, vol, vol, vol}},
+		{T: 2, Strikes: []float64{100}, Vols: []float64{vol}},
+	}
+
+	for _, cfg := range []bs.SurfaceConfig{{}, {StrikeInterp: bs.InterpLinearVol}} {
+
+		s, err := bs.NewVolSurface(spot, r, q, smiles, cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, k := range []float64{10, 70, 95, 100, 133, 500} {
+			for _, tau := range []float64{0, 0.1, 0.25, 0.6, 1, 1.7, 2, 5} {
+				if v, err := s.Vol(k, tau); err != nil || math.Abs(v-vol) > 1e-15 {
+					t.Errorf("Strike = %v, T = %v: vol = %v, err = %v", k, tau, v, err)
+				}
+			}
+		}
+
+		p, err := s.PriceFromSurface(spot, 105, 0.6, r, q, bs.Put)
+		if want := bs.BSPrice(vol, 0.6, spot, 105, r, q, bs.Put); err != nil || math.Abs(p-want) > 1e-12 {
+			t.Errorf("price = %v, want %v, err = %v", p, want, err)
+		}
+	}
+}
+
+func Test_VolSurfaceDeltaConvention(t *testing.T) {
+
+	deltas := []float64{-0.1, -0.25, 0.25, 0.1}
+	vols := []float64{0.3, 0.25, 0.21, 0.23}
+
+	for _, conv := range []bs.DeltaConvention{bs.SpotDelta, bs.ForwardDelta, bs.SpotDeltaPA, bs.ForwardDeltaPA} {
+
+		s, err := bs.NewVolSurface(spot, r, q, []bs.Smile{
+			{T: 1, Deltas: deltas, Vols: vols, Convention: conv},
+		}, bs.SurfaceConfig{})
+		if err != nil {
+			t.Fatalf("%v: %v", conv, err)
+		}
+
+		// each pillar vol sits at the strike of its delta under conv
+		for i, d := range deltas {
+			o := bs.Call
+			if d < 0 {
+				o = bs.Put
+			}
+			k, err := bs.StrikeFromDelta(d, vols[i], 1, spot, r, q, o, conv)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v, err := s.Vol(k, 1); err != nil || math.Abs(v-vols[i]) > 1e-12 {
+				t.Errorf("%v delta %v: vol = %v, want %v", conv, d, v, vols[i])
+			}
+		}
+	}
+}
+
+func Test_VolSurfaceInterpolation(t *testing.T) {
+
+	smiles := []bs.Smile{
+		{T: 0.5, Strikes: []float64{80, 100, 120}, Vols: []float64{0.3, 0.2, 0.25}},
+		{T: 1.5, Strikes: []float64{70, 100, 130}, Vols: []float64{0.28, 0.21, 0.23}},
+	}
+	s, err := bs.NewVolSurface(spot, r, q, smiles, bs.SurfaceConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// quoted nodes are reproduced
+	for _, sm := range smiles {
+		for i, k := range sm.Strikes {
+			if v, _ := s.Vol(k, sm.T); math.Abs(v-sm.Vols[i]) > 1e-15 {
+				t.Errorf("T = %v, Strike = %v: vol = %v, want %v", sm.T, k, v, sm.Vols[i])
+			}
+		}
+	}
+
+	// at fixed moneyness, total variance is linear in time between the
+	// pillars so call prices at forward scaled strikes increase with time
+	for _, m := range []float64{0.8, 0.95, 1, 1.1, 1.25} {
+		prev := 0.0
+		for tau := 0.5; tau <= 1.5; tau += 0.1 {
+			f := spot * math.Exp((r-q)*tau)
+			c, err := s.PriceFromSurface(spot, m*f, tau, r, q, bs.Call)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// undiscounted price per unit of forward
+			c *= math.Exp(r*tau) / f
+			if c < prev {
+				t.Errorf("moneyness = %v, T = %v: forward price %v below %v", m, tau, c, prev)
+			}
+			prev = c
+		}
+	}
+
+	// strike interpolation is linear in total variance
+	v, _ := s.Vol(90, 0.5)
+	a := math.Log(90.0/80) / math.Log(100.0/80)
+	if want := math.Sqrt(0.09 + a*(0.04-0.09)); math.Abs(v-want) > 1e-12 {
+		t.Errorf("vol = %v, want %v", v, want)
+	}
+}
+
+func Test_VolSurfaceErrors(t *testing.T) {
+
+	ok := bs.Smile{T: 1, Strikes: []float64{90, 110}, Vols: []float64{0.2, 0.2}}
+
+	for _, smiles := range [][]bs.Smile{
+		nil,
+		{{T: 1, Strikes: []float64{110, 90}, Vols: []float64{0.2, 0.2}}},
+		{{T: 1, Strikes: []float64{90, 110}, Vols: []float64{0.2}}},
+		{ok, {T: 0.5, Strikes: []float64{100}, Vols: []float64{0.2}}},
+		{ok, ok},
+		{{T: 1, Deltas: []float64{0.25, 0.5}, Vols: []float64{0.2, 0.2}}},
+	} {
+		if _, err := bs.NewVolSurface(spot, r, q, smiles, bs.SurfaceConfig{}); err != bs.ErrSurface {
+			t.Errorf("%+v: err = %v", smiles, err)
+		}
+	}
+
+	s, err := bs.NewVolSurface(spot, r, q, []bs.Smile{ok},
+		bs.SurfaceConfig{StrikeExtrap: bs.ExtrapError, TimeExtrap: bs.ExtrapError})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := spot * math.Exp(r-q)
+	for _, c := range []struct{ k, tau float64 }{{f, 0.5}, {f, 2}, {80, 1}, {120, 1}} {
+		if _, err := s.Vol(c.k, c.tau); err != bs.ErrExtrapolation {
+			t.Errorf("%+v: err = %v", c, err)
+		}
+	}
+	if _, err := s.Vol(100, 1); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_CheckSurfaceArbitrage(t *testing.T) {
+
+	surface := func(smiles []bs.Smile) bs.VolSurface {
+		s, err := bs.NewVolSurface(spot, r, q, smiles, bs.SurfaceConfig{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return *s
+	}
+
+	flat := surface([]bs.Smile{
+		{T: 0.5, Strikes: []float64{80, 100, 120}, Vols: []float64{0.2, 0.2, 0.2}},
+		{T: 1, Strikes: []float64{80, 100, 120}, Vols: []float64{0.2, 0.2, 0.2}},
+	})
+	if arbs := bs.CheckSurfaceArbitrage(flat, spot, r, q); len(arbs) != 0 {
+		t.Errorf("flat surface violations: %+v", arbs)
+	}
+
+	// total variance 0.045 at 6 months falls to 0.04 at a year
+	calendar := surface([]bs.Smile{
+		{T: 0.5, Strikes: []float64{100}, Vols: []float64{0.3}},
+		{T: 1, Strikes: []float64{100}, Vols: []float64{0.2}},
+	})
+	arbs := bs.CheckSurfaceArbitrage(calendar, spot, r, q)
+	if len(arbs) != 41 {
+		t.Errorf("%d calendar violations", len(arbs))
+	}
+	for _, a := range arbs {
+		if a.Kind != bs.CalendarArb || a.T != 1 || math.Abs(a.Magnitude-0.005) > 1e-12 {
+			t.Errorf("violation %+v", a)
+		}
+	}
+
+	// a vol hump at the money makes call prices concave in strike
+	hump := surface([]bs.Smile{
+		{T: 0.5, Strikes: []float64{90, 100, 110}, Vols: []float64{0.15, 0.6, 0.15}},
+	})
+	arbs = bs.CheckSurfaceArbitrage(hump, spot, r, q)
+	found := false
+	for _, a := range arbs {
+		if a.Kind != bs.ButterflyArb || a.T != 0.5 || !(a.Magnitude > 0) {
+			t.Errorf("violation %+v", a)
+		}
+		if math.Abs(a.Moneyness) < 0.15 {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("no butterfly violation near the money: %+v", arbs)
+	}
+}
@@ -0,0 +1,113 @@
+package svitest
+
+import (
+	"math"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+func Test_CalibrateSVI(t *testing.T) {
+
+	const forward, tau = 100.0, 0.75
+	want := bs.SVIParams{A: 0.02, B: 0.15, Rho: -0.5, M: 0.05, Sigma: 0.2, Forward: forward, T: tau}
+
+	var points []bs.SmilePoint
+	for k := 60.0; k <= 150; k += 6 {
+		points = append(points, bs.SmilePoint{Strike: k, Vol: want.Vol(k)})
+	}
+
+	got, err := bs.CalibrateSVI(points, forward, tau, bs.SVIConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		name      string
+		got, want float64
+	}{
+		{"A", got.A, want.A},
+		{"B", got.B, want.B},
+		{"Rho", got.Rho, want.Rho},
+		{"M", got.M, want.M},
+		{"Sigma", got.Sigma, want.Sigma},
+	} {
+		if math.Abs(c.got-c.want) > 1e-6 {
+			t.Errorf("%s = %v, want %v", c.name, c.got, c.want)
+		}
+	}
+	if got.RMSE > 1e-10 || !got.ButterflyFree {
+		t.Errorf("RMSE = %v, ButterflyFree = %v", got.RMSE, got.ButterflyFree)
+	}
+	if v := got.Vol(forward); math.Abs(v-want.Vol(forward)) > 1e-8 {
+		t.Errorf("ATM vol = %v, want %v", v, want.Vol(forward))
+	}
+}
+
+func Test_CalibrateSVIFlat(t *testing.T) {
+
+	const forward, tau, vol = 50.0, 0.5, 0.3
+
+	// premia rather than vols
+	var points []bs.SmilePoint
+	for k := 30.0; k <= 80; k += 5 {
+		o := bs.Put
+		if k > forward {
+			o = bs.Call
+		}
+		points = append(points, bs.SmilePoint{
+			Strike:  k,
+			Premium: bs.BSPrice(vol, tau, forward, k, 0, 0, o),
+			Type:    o,
+		})
+	}
+
+	p, err := bs.CalibrateSVI(points, forward, tau, bs.SVIConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.RMSE > 1e-8 || !p.ButterflyFree {
+		t.Errorf("RMSE = %v, ButterflyFree = %v", p.RMSE, p.ButterflyFree)
+	}
+	for _, k := range []float64{20, 40, 50, 70, 100} {
+		if v := p.Vol(k); math.Abs(v-vol) > 1e-6 {
+			t.Errorf("Strike = %v: vol = %v, want %v", k, v, vol)
+		}
+	}
+}
+
+func Test_CalibrateSVIButterfly(t *testing.T) {
+
+	// a smile whose wings are steeper than Lee's bound allows
+	arb := bs.SVIParams{A: 0.01, B: 3, Rho: 0.9, M: 0, Sigma: 0.05, Forward: 1, T: 1}
+	var points []bs.SmilePoint
+	for k := 0.6; k <= 1.5; k += 0.1 {
+		points = append(points, bs.SmilePoint{Strike: k, Vol: arb.Vol(k)})
+	}
+
+	p, err := bs.CalibrateSVI(points, 1, 1, bs.SVIConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.ButterflyFree {
+		t.Errorf("ButterflyFree for %+v", p)
+	}
+
+	if _, err := bs.CalibrateSVI(points[:4], 1, 1, bs.SVIConfig{}); err != bs.ErrSmile {
+		t.Errorf("err = %v", err)
+	}
+	if _, err := bs.CalibrateSVI(points, 1, 0, bs.SVIConfig{}); err != bs.ErrNegTimeToExp {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func Benchmark_CalibrateSVI(b *testing.B) {
+	p := bs.SVIParams{A: 0.02, B: 0.15, Rho: -0.5, M: 0.05, Sigma: 0.2, Forward: 100, T: 1}
+	var points []bs.SmilePoint
+	for k := 60.0; k <= 150; k += 6 {
+		points = append(points, bs.SmilePoint{Strike: k, Vol: p.Vol(k)})
+	}
+	for i := 0; i < b.N; i++ {
+		bs.CalibrateSVI(points, 100, 1, bs.SVIConfig{})
+	}
+}
@@ -36,3 +36,67 @@ func Test_Theta(t *testing.T) {
 		eps /= 2
 	}
 }
+
+func Test_ThetaSchedule(t *testing.T) {
+
+	var v, x, k, r, q float64 = 0.25, 100, 95, 0.03, 0.01
+
+	for _, o := range []bs.OptionType{bs.Call, bs.Put, bs.Straddle} {
+
+		// 30.5 days leaves a half day last step
+		tau := 30.5 / 365
+		points, err := bs.ThetaSchedule(v, tau, x, k, r, q, o, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(points) != 32 || points[0].TimeToExpiry != tau {
+			t.Fatalf("%c: %d points from %v", o, len(points), points[0].TimeToExpiry)
+		}
+
+		last := points[len(points)-1]
+		if want := bs.Intrinsic(0, x, k, 0, 0, o); last.TimeToExpiry != 0 || last.Price != want || last.Decay != 0 {
+			t.Errorf("%c: last point %+v, want price %v", o, last, want)
+		}
+		if s := points[30].TimeToExpiry * 365; math.Abs(s-0.5) > 1e-12 {
+			t.Errorf("%c: last step %v days", o, s)
+		}
+
+		for i, p := range points[:len(points)-1] {
+
+			want, err := bs.Price(&bs.PriceParams{
+				Vol: v, TimeToExpiry: p.TimeToExpiry, Underlying: x, Strike: k, Rate: r, Dividend: q, Type: o,
+			})
+			if err != nil || p.Price != want {
+				t.Errorf("%c %d: price %v, want %v", o, i, p.Price, want)
+			}
+
+			// theta against its finite difference
+			if num := bs.BSThetaNum(v, p.TimeToExpiry, x, k, r, q, o, 1e-5) / 365; math.Abs(p.Theta-num) > 1e-6 {
+				t.Errorf("%c %d: theta %v, want %v", o, i, p.Theta, num)
+			}
+			// theta times the step is off the price difference at first order
+			// in the step over the time to expiry
+			diff := points[i+1].Price - p.Price
+			step := p.TimeToExpiry - points[i+1].TimeToExpiry
+			if p.TimeToExpiry > 10.0/365 && math.Abs(p.Decay-diff) > step/p.TimeToExpiry*math.Abs(diff) {
+				t.Errorf("%c %d: decay %v, price difference %v", o, i, p.Decay, diff)
+			}
+			if p.TimeToExpiry <= 10.0/365 && p.Decay != diff {
+				t.Errorf("%c %d: decay %v, price difference %v", o, i, p.Decay, diff)
+			}
+		}
+	}
+
+	// a whole number of steps
+	points, err := bs.ThetaSchedule(v, 0.25, x, k, r, q, bs.Call, 18.25)
+	if err != nil || len(points) != 6 || points[5].TimeToExpiry != 0 || math.Abs(points[4].TimeToExpiry-0.05) > 1e-15 {
+		t.Errorf("%d points, err = %v", len(points), err)
+	}
+
+	if _, err := bs.ThetaSchedule(v, 0.25, x, k, r, q, bs.Call, 0); err != bs.ErrThetaStep {
+		t.Errorf("err = %v", err)
+	}
+	if _, err := bs.ThetaSchedule(-v, 0.25, x, k, r, q, bs.Call, 1); err != bs.ErrNegVol {
+		t.Errorf("err = %v", err)
+	}
+}
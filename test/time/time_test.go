@@ -0,0 +1,65 @@
+package timetest
+
+import (
+	"math"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+func Test_AnnualizeVol(t *testing.T) {
+
+	for _, v := range []float64{0, 0.01, 0.2} {
+		for _, n := range []float64{1, 12, 52, 252, 365} {
+			a := bs.AnnualizeVol(v, n)
+			if math.Abs(a-v*math.Sqrt(n)) > 1e-15 || math.Abs(bs.DeannualizeVol(a, n)-v) > 1e-15 {
+				t.Errorf("v = %v, n = %v: annualized %v", v, n, a)
+			}
+			if r := bs.RescaleVolTime(v, 1/n, 1); math.Abs(r-a) > 1e-15 {
+				t.Errorf("v = %v, n = %v: rescaled %v, want %v", v, n, r, a)
+			}
+		}
+	}
+
+	// a weekly vol to a daily one and back, in days
+	w := 0.03
+	d := bs.RescaleVolTime(w, 7, 1)
+	if math.Abs(d-w/math.Sqrt(7)) > 1e-15 || math.Abs(bs.RescaleVolTime(d, 1, 7)-w) > 1e-15 {
+		t.Errorf("daily vol %v", d)
+	}
+
+	if s := bs.VolForHorizon(0.2, 0.25); s != 0.1 {
+		t.Errorf("VolForHorizon = %v", s)
+	}
+
+	for name, got := range map[string]float64{
+		"AnnualizeVol negative vol":    bs.AnnualizeVol(-0.1, 252),
+		"AnnualizeVol NaN vol":         bs.AnnualizeVol(math.NaN(), 252),
+		"AnnualizeVol zero periods":    bs.AnnualizeVol(0.1, 0),
+		"DeannualizeVol negative":      bs.DeannualizeVol(0.1, -252),
+		"RescaleVolTime zero unit":     bs.RescaleVolTime(0.1, 0, 1),
+		"RescaleVolTime NaN unit":      bs.RescaleVolTime(0.1, 1, math.NaN()),
+		"VolForHorizon negative years": bs.VolForHorizon(0.1, -1),
+	} {
+		if !math.IsNaN(got) {
+			t.Errorf("%s = %v, want NaN", name, got)
+		}
+	}
+}
+
+func Test_DailyUnits(t *testing.T) {
+
+	// a daily vol, time in days and daily rates price as the annual ones
+	const n = 252.0
+	v, tau, r, q := 0.3, 0.5, 0.04, 0.01
+
+	for _, o := range []bs.OptionType{bs.Call, bs.Put, bs.Straddle} {
+		for _, k := range []float64{80, 100, 120} {
+			annual := bs.BSPrice(v, tau, 100, k, r, q, o)
+			daily := bs.BSPrice(bs.DeannualizeVol(v, n), tau*n, 100, k, r/n, q/n, o)
+			if math.Abs(annual-daily) > 1e-12 {
+				t.Errorf("%c k = %v: daily %v, annual %v", o, k, daily, annual)
+			}
+		}
+	}
+}
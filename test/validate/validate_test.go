@@ -0,0 +1,85 @@
+package validatetest
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+// logUniform draws from lo to hi uniformly in log
+func logUniform(rng *rand.Rand, lo, hi float64) float64 {
+	return lo * math.Exp(rng.Float64()*math.Log(hi/lo))
+}
+
+func Test_CheckIdentitiesRandom(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 100000; i++ {
+
+		v := logUniform(rng, 0.005, 3)
+		tau := logUniform(rng, 1e-4, 30)
+		x := logUniform(rng, 1e-2, 1e5)
+		k := x * logUniform(rng, 0.2, 5)
+		r := -0.05 + 0.3*rng.Float64()
+		q := -0.05 + 0.3*rng.Float64()
+
+		for _, e := range bs.CheckIdentities(v, tau, x, k, r, q) {
+			t.Errorf("v = %v, t = %v, x = %v, k = %v, r = %v, q = %v: %v", v, tau, x, k, r, q, e)
+		}
+	}
+}
+
+func Test_CheckIdentitiesBoundaries(t *testing.T) {
+
+	const tau, x, k, r, q = 0.5, 100.0, 105.0, 0.05, 0.02
+
+	for _, c := range []struct {
+		v, t, x, k float64
+	}{
+		{v: 0, t: tau, x: x, k: k},
+		{v: 0.2, t: 0, x: x, k: k},
+		{v: 0.2, t: 1e-12, x: x, k: k},
+		{v: 0.2, t: tau, x: 0, k: k},
+		{v: 0.2, t: tau, x: x, k: 0},
+		{v: 0.2, t: tau, x: x, k: x * math.Exp((r-q)*tau)},
+		{v: 0, t: tau, x: x, k: x * math.Exp((r-q)*tau)},
+	} {
+		for _, e := range bs.CheckIdentities(c.v, c.t, c.x, c.k, r, q) {
+			t.Errorf("%+v: %v", c, e)
+		}
+	}
+
+	for _, c := range []struct {
+		v, t, x, k float64
+	}{
+		{v: -0.2, t: tau, x: x, k: k},
+		{v: 0.2, t: -1, x: x, k: k},
+		{v: 0.2, t: tau, x: -1, k: k},
+		{v: 0.2, t: tau, x: x, k: math.NaN()},
+		{v: math.NaN(), t: tau, x: x, k: k},
+	} {
+		if got := bs.CheckIdentities(c.v, c.t, c.x, c.k, r, q); got != nil {
+			t.Errorf("%+v: got %v, want nil", c, got)
+		}
+	}
+}
+
+func Test_CheckIdentitiesReports(t *testing.T) {
+
+	// with no tolerance the finite differences cannot match exactly
+	found := false
+	for _, e := range bs.CheckIdentitiesWithTol(0.2, 0.5, 100, 105, 0.05, 0.02, 0, 0) {
+		if e.Identity == "gamma = d2price/dx2" && e.Type == bs.Call {
+			found = true
+			if e.Got != bs.BSGreeks(0.2, 0.5, 100, 105, 0.05, 0.02, bs.Call).Gamma || !(math.Abs(e.Got-e.Want) > e.Tol) {
+				t.Errorf("%+v", e)
+			}
+		}
+	}
+	if !found {
+		t.Error("no finite difference gamma violation at zero tolerance")
+	}
+}
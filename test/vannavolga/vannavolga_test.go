@@ -0,0 +1,116 @@
+package vannavolgatest
+
+import (
+	"math"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+const tau, spot, r, q = 0.5, 1.25, 0.03, 0.01
+
+// pillars returns 25 delta put, ATM forward and 25 delta call pillars
+func pillars(put, atm, call float64) [3]bs.SmilePoint {
+
+	f := spot * math.Exp((r-q)*tau)
+	strike := func(v, d float64) float64 {
+		return f * math.Exp(-d*v*math.Sqrt(tau)+v*v*tau/2)
+	}
+	z := 0.6744897501960817 // N(z) = 0.75
+
+	return [3]bs.SmilePoint{
+		{Strike: strike(put, z), Vol: put},
+		{Strike: f, Vol: atm},
+		{Strike: strike(call, -z), Vol: call},
+	}
+}
+
+func Test_VannaVolgaGreeks(t *testing.T) {
+
+	const h = 1e-5
+
+	for _, o := range []bs.OptionType{bs.Call, bs.Put, bs.Straddle} {
+		for _, k := range []float64{1.0, 1.25, 1.5} {
+
+			v := 0.12
+			vanna := (bs.BSDelta(v+h, tau, spot, k, r, q, o) - bs.BSDelta(v-h, tau, spot, k, r, q, o)) / 2 / h
+			volga := (bs.BSVega(v+h, tau, spot, k, r, q, o) - bs.BSVega(v-h, tau, spot, k, r, q, o)) / 2 / h
+
+			if got := bs.BSVanna(v, tau, spot, k, r, q, o); math.Abs(got-vanna) > 1e-6*math.Max(1, math.Abs(vanna)) {
+				t.Errorf("%c %v: vanna = %v, want %v", o, k, got, vanna)
+			}
+			if got := bs.BSVolga(v, tau, spot, k, r, q, o); math.Abs(got-volga) > 1e-6*math.Max(1, math.Abs(volga)) {
+				t.Errorf("%c %v: volga = %v, want %v", o, k, got, volga)
+			}
+		}
+	}
+
+	if v := bs.BSVanna(0, tau, spot, 1, r, q, bs.Call); v != 0 {
+		t.Errorf("zero vol vanna = %v", v)
+	}
+	if v := bs.BSVolga(0.1, tau, spot, -1, r, q, bs.Call); !math.IsNaN(v) {
+		t.Errorf("negative strike volga = %v", v)
+	}
+}
+
+func Test_PriceVannaVolga(t *testing.T) {
+
+	p := pillars(0.115, 0.10, 0.108)
+
+	// the pillars reprice at their own vols
+	for _, pl := range p {
+		v, err := bs.VannaVolgaImpliedVol(p, tau, spot, pl.Strike, r, q)
+		if err != nil || math.Abs(v-pl.Vol) > 1e-9 {
+			t.Errorf("pillar %v: vol = %v, want %v, err = %v", pl.Strike, v, pl.Vol, err)
+		}
+	}
+
+	// a smile, the wings above the ATM vol and the put wing above the call
+	// wing beyond the pillars
+	vlo, _ := bs.VannaVolgaImpliedVol(p, tau, spot, 1.05, r, q)
+	vhi, _ := bs.VannaVolgaImpliedVol(p, tau, spot, 1.5, r, q)
+	if !(vlo > p[0].Vol && vhi > p[2].Vol) {
+		t.Errorf("wing vols %v, %v", vlo, vhi)
+	}
+
+	// call and put have the same smile cost, so parity holds
+	for _, k := range []float64{1.1, 1.3, 1.45} {
+		c, err := bs.PriceVannaVolga(p, tau, spot, k, r, q, bs.Call)
+		pt, _ := bs.PriceVannaVolga(p, tau, spot, k, r, q, bs.Put)
+		s, _ := bs.PriceVannaVolga(p, tau, spot, k, r, q, bs.Straddle)
+		parity := spot*math.Exp(-q*tau) - k*math.Exp(-r*tau)
+		if err != nil || math.Abs(c-pt-parity) > 1e-12 || math.Abs(s-c-pt) > 1e-12 {
+			t.Errorf("%v: call %v, put %v, straddle %v, err = %v", k, c, pt, s, err)
+		}
+	}
+
+	// a flat smile prices at Black Scholes
+	flat := pillars(0.1, 0.1, 0.1)
+	for _, o := range []bs.OptionType{bs.Call, bs.Put, bs.Straddle} {
+		for _, k := range []float64{0.9, 1.1, 1.25, 1.4, 1.7} {
+			got, err := bs.PriceVannaVolga(flat, tau, spot, k, r, q, o)
+			if want := bs.BSPrice(0.1, tau, spot, k, r, q, o); err != nil || got != want {
+				t.Errorf("%c %v: flat = %v, want %v, err = %v", o, k, got, want, err)
+			}
+		}
+	}
+}
+
+func Test_PriceVannaVolgaErrors(t *testing.T) {
+
+	p := pillars(0.115, 0.10, 0.108)
+
+	bad := p
+	bad[1].Strike = bad[2].Strike
+	if _, err := bs.PriceVannaVolga(bad, tau, spot, 1.2, r, q, bs.Call); err != bs.ErrSmile {
+		t.Errorf("err = %v", err)
+	}
+	bad = p
+	bad[0].Vol = 0
+	if _, err := bs.VannaVolgaImpliedVol(bad, tau, spot, 1.2, r, q); err != bs.ErrSmile {
+		t.Errorf("err = %v", err)
+	}
+	if _, err := bs.PriceVannaVolga(p, tau, spot, -1, r, q, bs.Call); err != bs.ErrNegStrike {
+		t.Errorf("err = %v", err)
+	}
+}
@@ -37,3 +37,116 @@ func Test_Vega(t *testing.T) {
 	}
 
 }
+
+func Test_VegaZeroVol(t *testing.T) {
+
+	const eps float64 = 1e-7
+	var tau, x, r, q float64 = 0.5, 100, 0.03, 0.03
+
+	for _, o := range []bs.OptionType{bs.Call, bs.Put, bs.Straddle} {
+		for _, k := range []float64{80, 100, 120} {
+
+			vega := bs.BSVega(0, tau, x, k, r, q, o)
+			veganum := bs.BSVegaNum(0, tau, x, k, r, q, o, eps)
+
+			if math.IsNaN(vega) || math.Abs(vega-veganum) > 1e-4*math.Max(1, vega) {
+				t.Errorf(
+					"Type = %c, Strike = %v: Vega = %v, VegaNum = %v",
+					o, k, vega, veganum,
+				)
+			}
+
+			t.Logf("Type = %c, Strike = %5.1f, Vega = %8.4f, VegaNum = %8.4f",
+				o, k, vega, veganum)
+		}
+	}
+}
+
+func Test_Ultima(t *testing.T) {
+
+	const h = 5e-4
+	var v, tau, x, r, q float64 = 0.3, 0.75, 100, 0.04, 0.01
+
+	for _, o := range []bs.OptionType{bs.Call, bs.Put, bs.Straddle} {
+		for _, k := range []float64{60, 90, 100, 110, 160} {
+
+			// a third central difference of the price, good to O(h*h)
+			p := func(dv float64) float64 { return bs.BSPrice(v+dv, tau, x, k, r, q, o) }
+			num := (p(2*h) - 2*p(h) + 2*p(-h) - p(-2*h)) / 2 / (h * h * h)
+			ultima := bs.BSUltima(v, tau, x, k, r, q, o)
+
+			if math.IsNaN(ultima) || math.Abs(ultima-num) > 1e-3*math.Max(1, math.Abs(ultima)) {
+				t.Errorf("Type = %c, Strike = %v: Ultima = %v, UltimaNum = %v", o, k, ultima, num)
+			}
+			if neg := bs.BSUltima(-v, tau, x, k, r, q, o); neg != -ultima {
+				t.Errorf("Type = %c, Strike = %v: Ultima at -vol = %v, want %v", o, k, neg, -ultima)
+			}
+
+			pars := &bs.PriceParams{Vol: v, TimeToExpiry: tau, Underlying: x, Strike: k, Rate: r, Dividend: q, Type: o}
+			if got, err := bs.Ultima(pars); err != nil || got != ultima {
+				t.Errorf("Type = %c, Strike = %v: Ultima = %v, %v", o, k, got, err)
+			}
+		}
+	}
+
+	// at zero vol only the money forward has an ultima, the limit of the
+	// formula
+	for _, o := range []bs.OptionType{bs.Call, bs.Straddle} {
+		zero, lim := bs.BSUltima(0, tau, x, x, q, q, o), bs.BSUltima(1e-4, tau, x, x, q, q, o)
+		if zero >= 0 || math.Abs(zero-lim) > 1e-6*math.Abs(zero) {
+			t.Errorf("Type = %c: zero vol ultima %v, limit %v", o, zero, lim)
+		}
+		if u := bs.BSUltima(0, tau, x, 120, r, q, o); u != 0 {
+			t.Errorf("Type = %c: zero vol ultima out of the money %v", o, u)
+		}
+	}
+
+	pars := &bs.PriceParams{Vol: v, TimeToExpiry: tau, Underlying: x, Strike: -1, Rate: r, Dividend: q, Type: bs.Call}
+	if ultima, err := bs.Ultima(pars); err == nil || !math.IsNaN(ultima) {
+		t.Errorf("negative strike: Ultima = %v, %v", ultima, err)
+	}
+}
+
+func Test_Veta(t *testing.T) {
+
+	const h = 1e-6
+	var v, tau, x, r, q float64 = 0.3, 0.75, 100, 0.04, 0.01
+
+	for _, o := range []bs.OptionType{bs.Call, bs.Put, bs.Straddle} {
+		for _, k := range []float64{60, 90, 100, 110, 160} {
+
+			// time runs the other way to the time to expiry, as in BSThetaNum
+			num := (bs.BSVega(v, tau-h, x, k, r, q, o) - bs.BSVega(v, tau+h, x, k, r, q, o)) / 2 / h
+			veta := bs.BSVeta(v, tau, x, k, r, q, o)
+
+			if math.IsNaN(veta) || math.Abs(veta-num) > 1e-6*math.Max(1, math.Abs(veta)) {
+				t.Errorf("Type = %c, Strike = %v: Veta = %v, VetaNum = %v", o, k, veta, num)
+			}
+			if neg := bs.BSVeta(-v, tau, x, k, r, q, o); neg != -veta {
+				t.Errorf("Type = %c, Strike = %v: Veta at -vol = %v, want %v", o, k, neg, -veta)
+			}
+
+			pars := &bs.PriceParams{Vol: v, TimeToExpiry: tau, Underlying: x, Strike: k, Rate: r, Dividend: q, Type: o}
+			if got, err := bs.Veta(pars); err != nil || got != veta {
+				t.Errorf("Type = %c, Strike = %v: Veta = %v, %v", o, k, got, err)
+			}
+		}
+	}
+
+	// at zero vol only the money forward has a veta, the limit of the
+	// formula
+	for _, o := range []bs.OptionType{bs.Call, bs.Straddle} {
+		zero, lim := bs.BSVeta(0, tau, x, x, q, q, o), bs.BSVeta(1e-4, tau, x, x, q, q, o)
+		if zero == 0 || math.Abs(zero-lim) > 1e-6*math.Abs(zero) {
+			t.Errorf("Type = %c: zero vol veta %v, limit %v", o, zero, lim)
+		}
+		if u := bs.BSVeta(0, tau, x, 120, r, q, o); u != 0 {
+			t.Errorf("Type = %c: zero vol veta out of the money %v", o, u)
+		}
+	}
+
+	pars := &bs.PriceParams{Vol: v, TimeToExpiry: tau, Underlying: x, Strike: 100, Rate: r, Dividend: q, Type: bs.OptionType('x')}
+	if veta, err := bs.Veta(pars); err == nil || !math.IsNaN(veta) {
+		t.Errorf("unknown type: Veta = %v, %v", veta, err)
+	}
+}
@@ -0,0 +1,118 @@
+package vgtest
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+func Test_VarianceGammaReference(t *testing.T) {
+
+	// Fang and Oosterlee (2008), the COS method, table 7
+	for _, c := range []struct{ tau, want float64 }{
+		{tau: 0.1, want: 10.993703187},
+		{tau: 1, want: 19.099354724},
+	} {
+		got, err := bs.PriceVarianceGamma(0.12, -0.14, 0.2, c.tau, 100, 90, 0.1, 0, bs.Call)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(got-c.want) > 1e-8 {
+			t.Errorf("t = %v: %v, want %v", c.tau, got, c.want)
+		}
+	}
+}
+
+func Test_VarianceGammaLimit(t *testing.T) {
+
+	const sigma, tau, x, r, q = 0.2, 0.5, 100.0, 0.03, 0.01
+
+	for _, o := range []bs.OptionType{bs.Call, bs.Put, bs.Straddle} {
+		for _, k := range []float64{80, 100, 120} {
+
+			want, _ := bs.Price(&bs.PriceParams{
+				Vol: sigma, TimeToExpiry: tau, Underlying: x, Strike: k, Rate: r, Dividend: q, Type: o,
+			})
+
+			got, err := bs.PriceVarianceGamma(sigma, 0.1, 0, tau, x, k, r, q, o)
+			if err != nil || got != want {
+				t.Errorf("%v, k = %v, nu = 0: %v, %v, want %v", o, k, got, err, want)
+			}
+
+			prev := math.Inf(1)
+			for _, nu := range []float64{1e-1, 1e-2, 1e-3, 1e-4} {
+				got, err := bs.PriceVarianceGamma(sigma, 0, nu, tau, x, k, r, q, o)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if d := math.Abs(got - want); !(d < prev) {
+					t.Errorf("%v, k = %v, nu = %v: error %v after %v", o, k, nu, d, prev)
+				} else {
+					prev = d
+				}
+			}
+			if prev > 1e-3 {
+				t.Errorf("%v, k = %v: error %v at the smallest nu", o, k, prev)
+			}
+		}
+	}
+}
+
+func Test_VarianceGammaSmallNu(t *testing.T) {
+
+	const sigma, tau, x, r, q = 0.2, 0.5, 100.0, 0.03, 0.01
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, o := range []bs.OptionType{bs.Call, bs.Put, bs.Straddle} {
+			for _, k := range []float64{80, 100, 120} {
+				want := bs.BSPrice(sigma, tau, x, k, r, q, o)
+				for _, nu := range []float64{1e-6, 1e-8, 1e-12, 1e-300} {
+					got, err := bs.PriceVarianceGamma(sigma, -0.1, nu, tau, x, k, r, q, o)
+					if err != nil || !(math.Abs(got-want) <= 10*nu*math.Max(1, want)+1e-12*x) {
+						t.Errorf("%v, k = %v, nu = %v: %v, %v, want %v", o, k, nu, got, err, want)
+					}
+				}
+			}
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("PriceVarianceGamma did not return for small nu")
+	}
+}
+
+func Test_VarianceGammaParity(t *testing.T) {
+
+	const tau, x, r, q = 0.75, 100.0, 0.05, 0.02
+
+	for _, nu := range []float64{0.05, 0.5, 2} {
+		for _, k := range []float64{70, 100, 130} {
+			c, err := bs.PriceVarianceGamma(0.25, -0.2, nu, tau, x, k, r, q, bs.Call)
+			if err != nil {
+				t.Fatal(err)
+			}
+			p, _ := bs.PriceVarianceGamma(0.25, -0.2, nu, tau, x, k, r, q, bs.Put)
+			s, _ := bs.PriceVarianceGamma(0.25, -0.2, nu, tau, x, k, r, q, bs.Straddle)
+			parity := x*math.Exp(-q*tau) - k*math.Exp(-r*tau)
+			if math.Abs(c-p-parity) > 1e-9 || math.Abs(c+p-s) > 1e-9 {
+				t.Errorf("nu = %v, k = %v: call %v, put %v, straddle %v", nu, k, c, p, s)
+			}
+		}
+	}
+
+	for _, c := range []struct{ sigma, theta, nu float64 }{
+		{sigma: 0.2, theta: 0, nu: -0.1},
+		{sigma: 0.2, theta: 3, nu: 0.5},
+		{sigma: 0.2, theta: math.NaN(), nu: 0.5},
+	} {
+		if _, err := bs.PriceVarianceGamma(c.sigma, c.theta, c.nu, tau, x, 100, r, q, bs.Call); err != bs.ErrVarianceGamma {
+			t.Errorf("%+v: %v", c, err)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package volgatest
+
+import (
+	"math"
+	"testing"
+
+	bs "github.com/uscott/go-blackscholes"
+)
+
+func Test_Volga(t *testing.T) {
+
+	const eps float64 = 1e-4
+	var v, tau, x, r, q float64 = 0.3, 0.75, 100, 0.04, 0.01
+
+	for _, o := range []bs.OptionType{bs.Call, bs.Put, bs.Straddle} {
+		for _, k := range []float64{60, 90, 100, 110, 160} {
+
+			volga := bs.BSVolga(v, tau, x, k, r, q, o)
+			volganum := bs.BSVolgaNum(v, tau, x, k, r, q, o, eps)
+
+			if math.IsNaN(volga) || math.Abs(volga-volganum) > 1e-4*math.Max(1, math.Abs(volga)) {
+				t.Errorf("Type = %c, Strike = %v: Volga = %v, VolgaNum = %v", o, k, volga, volganum)
+			}
+			if neg := bs.BSVolga(-v, tau, x, k, r, q, o); neg != volga {
+				t.Errorf("Type = %c, Strike = %v: Volga at -vol = %v, want %v", o, k, neg, volga)
+			}
+
+			pars := &bs.PriceParams{Vol: v, TimeToExpiry: tau, Underlying: x, Strike: k, Rate: r, Dividend: q, Type: o}
+			if got, err := bs.Volga(pars); err != nil || got != volga {
+				t.Errorf("Type = %c, Strike = %v: Volga = %v, %v", o, k, got, err)
+			}
+		}
+	}
+
+	if c, s := bs.BSVolga(v, tau, x, 120, r, q, bs.Call), bs.BSVolga(v, tau, x, 120, r, q, bs.Straddle); s != 2*c {
+		t.Errorf("straddle volga %v, call %v", s, c)
+	}
+
+	for _, k := range []float64{0, 90, 100 * math.Exp((r-q)*tau)} {
+		if volga := bs.BSVolga(0, tau, x, k, r, q, bs.Call); volga != 0 {
+			t.Errorf("Strike = %v: zero vol Volga = %v", k, volga)
+		}
+	}
+
+	pars := &bs.PriceParams{Vol: v, TimeToExpiry: -1, Underlying: x, Strike: 100, Rate: r, Dividend: q, Type: bs.Call}
+	if volga, err := bs.Volga(pars); err == nil || !math.IsNaN(volga) {
+		t.Errorf("negative time: Volga = %v, %v", volga, err)
+	}
+}
@@ -0,0 +1,76 @@
+package blackscholes
+
+import (
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+var ErrThetaStep = errors.New("Non-positive theta schedule step")
+
+// ThetaPoint is one step of a ThetaSchedule: the time to expiry in years,
+// the option price, the per day theta and the change in price over the
+// step to the next point
+type ThetaPoint struct {
+	TimeToExpiry float64
+	Price        float64
+	Theta        float64
+	Decay        float64
+}
+
+// ThetaSchedule returns the price of the option at every stepDays until
+// expiry with spot and vol unchanged, starting at timeToExpiry and ending
+// on expiry itself at intrinsic value, where the last step may be
+// shorter. Theta is per day, the annual theta over DaysPerYear, taken from
+// the Black Scholes equation. Decay is theta times the step while the
+// step is at most a tenth of the time to expiry and the price difference
+// to the next point closer to expiry, where theta changes too fast over a
+// step. The last point has zero decay.
+func ThetaSchedule(
+	vol, timeToExpiry, spot, strike, interestRate, dividendYield float64,
+	optionType OptionType, stepDays float64,
+) ([]ThetaPoint, error) {
+
+	v, t, x, k, r, q, o := vol, timeToExpiry, spot, strike, interestRate, dividendYield, optionType
+
+	if v < 0 {
+		return nil, ErrNegVol
+	}
+	if err := checkParams(t, x, k, r, q, o); err != nil {
+		return nil, err
+	}
+	if !(stepDays > 0) || stepDays == inf(1) {
+		return nil, ErrThetaStep
+	}
+
+	step := stepDays / DaysPerYear
+
+	// the relative tolerance keeps a whole number of steps from leaving a
+	// rounding error sized last step
+	n := int(math.Ceil(t / step * (1 - 1e-12)))
+
+	points := make([]ThetaPoint, n+1)
+	for i := range points {
+		ti := max(t-float64(i)*step, 0)
+		if i == n {
+			ti = 0
+		}
+		g := BSGreeks(v, ti, x, k, r, q, o)
+		points[i] = ThetaPoint{
+			TimeToExpiry: ti,
+			Price:        g.Price,
+			Theta:        pdeTheta(g, v, x, r, q) / DaysPerYear,
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		p := &points[i]
+		if s := p.TimeToExpiry - points[i+1].TimeToExpiry; s <= p.TimeToExpiry/10 {
+			p.Decay = p.Theta * s * DaysPerYear
+		} else {
+			p.Decay = points[i+1].Price - p.Price
+		}
+	}
+
+	return points, nil
+}
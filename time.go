@@ -0,0 +1,53 @@
+package blackscholes
+
+import "time"
+
+// DaysPerYear is the day count basis of YearFraction
+const DaysPerYear float64 = 365
+
+// DateLayout is the ISO date layout accepted for expiry dates
+const DateLayout = "2006-01-02"
+
+// YearFraction returns the time from now to expiry in years on an
+// actual/365 basis, negative if expiry is before now
+func YearFraction(now, expiry time.Time) float64 {
+	return float64(expiry.Sub(now)) / float64(24*time.Hour) / DaysPerYear
+}
+
+// AnnualizeVol converts a vol per period to an annual vol,
+// vol*sqrt(periodsPerYear), e.g. 252 periods for a daily vol over trading
+// days. Negative or NaN vols and non-positive periods return NaN.
+func AnnualizeVol(vol, periodsPerYear float64) float64 {
+	if !(vol >= 0) || !(periodsPerYear > 0) {
+		return nan()
+	}
+	return vol * sqrt(periodsPerYear)
+}
+
+// DeannualizeVol is the inverse of AnnualizeVol, vol/sqrt(periodsPerYear)
+func DeannualizeVol(vol, periodsPerYear float64) float64 {
+	if !(vol >= 0) || !(periodsPerYear > 0) {
+		return nan()
+	}
+	return vol / sqrt(periodsPerYear)
+}
+
+// RescaleVolTime converts a vol per fromTimeUnit to a vol per toTimeUnit,
+// vol*sqrt(toTimeUnit/fromTimeUnit), with both units measured in the same
+// terms, e.g. years. Negative or NaN vols and non-positive units return
+// NaN.
+func RescaleVolTime(vol, fromTimeUnit, toTimeUnit float64) float64 {
+	if !(vol >= 0) || !(fromTimeUnit > 0) || !(toTimeUnit > 0) {
+		return nan()
+	}
+	return vol * sqrt(toTimeUnit/fromTimeUnit)
+}
+
+// VolForHorizon returns the total vol annualVol*sqrt(horizonYears) over a
+// horizon. Negative or NaN inputs return NaN.
+func VolForHorizon(annualVol, horizonYears float64) float64 {
+	if !(annualVol >= 0) || !(horizonYears >= 0) {
+		return nan()
+	}
+	return annualVol * sqrt(horizonYears)
+}
@@ -0,0 +1,175 @@
+package blackscholes
+
+import (
+	"fmt"
+	"math"
+)
+
+// IdentityTol is the tolerance of the closed form identities checked by
+// CheckIdentities, relative to the scale of each quantity
+const IdentityTol float64 = 1e-10
+
+// NumericGreekTol is the tolerance of the finite difference greeks checked
+// by CheckIdentities, relative to the scale of each greek, on top of the
+// rounding error of the difference
+const NumericGreekTol float64 = 1e-5
+
+// IdentityViolation is an identity between package functions that fails
+// to hold at some inputs: Got and Want are its two sides, or the value and
+// its bound, and Tol is the largest difference accepted
+type IdentityViolation struct {
+	Identity string
+	Type     OptionType
+	Got      float64
+	Want     float64
+	Tol      float64
+}
+
+func (e IdentityViolation) String() string {
+	return fmt.Sprintf("%s (%v): got %v, want %v within %v", e.Identity, e.Type, e.Got, e.Want, e.Tol)
+}
+
+// CheckIdentities checks the identities the pricers should satisfy at the
+// given inputs and returns those that fail, or nil if all hold or the
+// inputs are invalid. The identities are
+//
+//   - the standalone BSPrice, BSDelta, BSGamma, BSVega, BSTheta and BSRho
+//     match BSGreeks
+//   - put-call parity, call - put = x*exp(-q*t) - k*exp(-r*t)
+//   - the straddle price and every greek are those of call plus put
+//   - the no-arbitrage bounds of the prices and deltas, and non-negative
+//     gamma and vega
+//   - call = x*delta - k*digital and put = x*delta + k*digital, with unit
+//     digitals, whose call and put add up to the discount factor
+//   - the greeks, and the digital as minus the strike derivative of the
+//     vanilla, match central differences of the price
+//
+// The digital and finite difference checks are skipped at zero vol,
+// below TimeFloor and at zero spot or strike, where the prices have kinks.
+// The tolerances are IdentityTol and NumericGreekTol.
+func CheckIdentities(vol, t, spot, strike, r, q float64) []IdentityViolation {
+	return CheckIdentitiesWithTol(vol, t, spot, strike, r, q, IdentityTol, NumericGreekTol)
+}
+
+// CheckIdentitiesWithTol is CheckIdentities with the tolerances of the
+// closed form identities and of the finite difference greeks given in
+// place of IdentityTol and NumericGreekTol
+func CheckIdentitiesWithTol(
+	vol, t, spot, strike, r, q, identityTol, numericGreekTol float64,
+) []IdentityViolation {
+
+	v, x, k := vol, spot, strike
+
+	if !(v >= 0) || checkParams(t, x, k, r, q, Call) != nil {
+		return nil
+	}
+	for _, a := range []float64{t, x, k, r, q} {
+		if math.IsNaN(a) {
+			return nil
+		}
+	}
+
+	var out []IdentityViolation
+
+	check := func(identity string, o OptionType, got, want, tol float64) {
+		if got != want && !(abs(got-want) <= tol) {
+			out = append(out, IdentityViolation{Identity: identity, Type: o, Got: got, Want: want, Tol: tol})
+		}
+	}
+	atLeast := func(identity string, o OptionType, got, bound, tol float64) {
+		if !(got >= bound-tol) {
+			out = append(out, IdentityViolation{Identity: identity, Type: o, Got: got, Want: bound, Tol: tol})
+		}
+	}
+	rel := func(a float64) float64 { return identityTol * max(1, abs(a)) }
+
+	xq, kr := discounted(x, q, t), discounted(k, r, t)
+	scale := identityTol * (xq + kr)
+
+	types := []OptionType{Call, Put, Straddle}
+	greeks := make(map[OptionType]Greeks, len(types))
+
+	for _, o := range types {
+
+		g := BSGreeks(v, t, x, k, r, q, o)
+		greeks[o] = g
+
+		check("BSPrice = BSGreeks", o, BSPrice(v, t, x, k, r, q, o), g.Price, scale)
+		check("BSDelta = BSGreeks", o, BSDelta(v, t, x, k, r, q, o), g.Delta, rel(g.Delta))
+		check("BSGamma = BSGreeks", o, BSGamma(v, t, x, k, r, q, o), g.Gamma, rel(g.Gamma))
+		check("BSVega = BSGreeks", o, BSVega(v, t, x, k, r, q, o), g.Vega, rel(g.Vega))
+		check("BSTheta = BSGreeks", o, BSTheta(v, t, x, k, r, q, o), g.Theta, rel(g.Theta))
+		check("BSRho = BSGreeks", o, BSRho(v, t, x, k, r, q, o), g.Rho, rel(g.Rho))
+
+		atLeast("gamma >= 0", o, g.Gamma, 0, 0)
+		atLeast("vega >= 0", o, g.Vega, 0, 0)
+	}
+
+	c, p, s := greeks[Call], greeks[Put], greeks[Straddle]
+	dfq := DiscountFactor(q, t)
+
+	check("put-call parity", Call, c.Price-p.Price, xq-kr, scale)
+
+	atLeast("call >= forward intrinsic", Call, c.Price, max(xq-kr, 0), scale)
+	atLeast("call <= discounted spot", Call, xq, c.Price, scale)
+	atLeast("put >= forward intrinsic", Put, p.Price, max(kr-xq, 0), scale)
+	atLeast("put <= discounted strike", Put, kr, p.Price, scale)
+	atLeast("call delta >= 0", Call, c.Delta, 0, 0)
+	atLeast("call delta <= exp(-q*t)", Call, dfq, c.Delta, rel(dfq))
+	atLeast("put delta >= -exp(-q*t)", Put, p.Delta, -dfq, rel(dfq))
+	atLeast("put delta <= 0", Put, 0, p.Delta, 0)
+
+	check("straddle price = call + put", Straddle, s.Price, c.Price+p.Price, scale)
+	check("straddle delta = call + put", Straddle, s.Delta, c.Delta+p.Delta, rel(s.Delta))
+	check("straddle gamma = call + put", Straddle, s.Gamma, c.Gamma+p.Gamma, rel(s.Gamma))
+	check("straddle vega = call + put", Straddle, s.Vega, c.Vega+p.Vega, rel(s.Vega))
+	check("straddle theta = call + put", Straddle, s.Theta, c.Theta+p.Theta, rel(s.Theta))
+	check("straddle rho = call + put", Straddle, s.Rho, c.Rho+p.Rho, rel(s.Rho))
+
+	if v == 0 || t < TimeFloor || x == 0 || k == 0 {
+		return out
+	}
+
+	df := DiscountFactor(r, t)
+	dc, _ := PriceDigital(v, t, x, k, 1, r, q, Call)
+	dp, _ := PriceDigital(v, t, x, k, 1, r, q, Put)
+
+	check("call = x*delta - k*digital", Call, c.Price, x*c.Delta-k*dc, scale)
+	check("put = x*delta + k*digital", Put, p.Price, x*p.Delta+k*dp, scale)
+	check("digital call + put = exp(-r*t)", Put, dc+dp, df, rel(df))
+
+	// spot and strike bumps in units of the width v*sqrt(t) of the log
+	// spot distribution, at most 1, with tolerances relative to the at the
+	// money size of each greek plus the rounding error of the prices over
+	// the bump
+	vs := v * sqrt(t)
+	w := min(vs, 1)
+	hx, hg, hv, ht, hk := 1e-3*x*w, 2e-3*x*w, 1e-4*v, 1e-4*t, 1e-3*k*w
+	round := 1e-15 * (xq + kr)
+	tol := numericGreekTol
+
+	atmGamma := dfq * InvSqrt2PI / x / vs
+	atmVega := xq * sqrt(t) * InvSqrt2PI
+	atmTheta := v*xq/2/sqrt(t)*InvSqrt2PI + abs(q)*xq + abs(r)*kr
+
+	dk := BSPrice(v, t, x, k-hk, r, q, Call) - BSPrice(v, t, x, k+hk, r, q, Call)
+	check("digital = -dcall/dk", Call, dc, dk/2/hk, tol*df+round/hk)
+
+	for _, o := range types {
+
+		g := greeks[o]
+		price := func(v, t, x float64) float64 { return BSPrice(v, t, x, k, r, q, o) }
+
+		delta := (price(v, t, x+hx) - price(v, t, x-hx)) / 2 / hx
+		gamma := (price(v, t, x+hg) - 2*g.Price + price(v, t, x-hg)) / hg / hg
+		vega := (price(v+hv, t, x) - price(v-hv, t, x)) / 2 / hv
+		theta := (price(v, t-ht, x) - price(v, t+ht, x)) / 2 / ht
+
+		check("delta = dprice/dx", o, g.Delta, delta, tol*(abs(g.Delta)+dfq)+round/hx)
+		check("gamma = d2price/dx2", o, g.Gamma, gamma, tol*(abs(g.Gamma)+atmGamma)+4*round/hg/hg)
+		check("vega = dprice/dv", o, g.Vega, vega, tol*(abs(g.Vega)+atmVega)+round/hv)
+		check("theta = -dprice/dt", o, g.Theta, theta, tol*(abs(g.Theta)+atmTheta)+round/ht)
+	}
+
+	return out
+}
@@ -0,0 +1,95 @@
+package blackscholes
+
+// PriceVannaVolga returns the vanna-volga price of an option from three
+// pillars of its expiry's smile, in increasing strike order with the at
+// the money pillar in the middle, usually the 25 delta put, the ATM
+// straddle and the 25 delta call. The price is BSPrice at the ATM vol
+// plus the smile cost of the portfolio of pillar options with the same
+// vega, vanna and volga at the ATM vol, each pillar's cost being its
+// price at its own vol less its price at the ATM vol. At a pillar strike
+// the portfolio is that pillar and the price is its smile price, and on a
+// flat smile the cost is 0. The pillars need vols and distinct positive
+// strikes, otherwise ErrSmile is returned.
+func PriceVannaVolga(
+	pillars [3]SmilePoint, timeToExpiry, spot, strike, r, q float64, optionType OptionType,
+) (float64, error) {
+
+	t, x, k, o := timeToExpiry, spot, strike, optionType
+
+	if err := checkParams(t, x, k, r, q, o); err != nil {
+		return nan(), err
+	}
+	if err := checkPillars(pillars); err != nil {
+		return nan(), err
+	}
+
+	atm := pillars[1].Vol
+	price := BSPriceNoErrorCheck(atm, t, x, k, r, q, o)
+	if x == 0 || k == 0 || t < TimeFloor {
+		return price, nil
+	}
+
+	// the vega, vanna and volga of each pillar in the columns of A and of
+	// the option in b, all at the ATM vol
+	var A [3][3]float64
+	for j, p := range pillars {
+		A[0][j] = BSVega(atm, t, x, p.Strike, r, q, Call)
+		A[1][j] = BSVanna(atm, t, x, p.Strike, r, q, Call)
+		A[2][j] = BSVolga(atm, t, x, p.Strike, r, q, Call)
+	}
+	b := [3]float64{
+		BSVega(atm, t, x, k, r, q, o),
+		BSVanna(atm, t, x, k, r, q, o),
+		BSVolga(atm, t, x, k, r, q, o),
+	}
+
+	w0, w1, w2 := solve3(A, b)
+	for j, w := range [3]float64{w0, w1, w2} {
+		p := pillars[j]
+		price += w * (BSPriceNoErrorCheck(p.Vol, t, x, p.Strike, r, q, Call) -
+			BSPriceNoErrorCheck(atm, t, x, p.Strike, r, q, Call))
+	}
+
+	if price != price || abs(price) == inf(1) {
+		return nan(), ErrSmile
+	}
+	return price, nil
+}
+
+// VannaVolgaImpliedVol returns the Black Scholes vol of the vanna-volga
+// price of PriceVannaVolga at strike, inverted from the out of the money
+// forward call or put; the vanna-volga smile cost is the same for both.
+// At the pillar strikes it is the pillar vol.
+func VannaVolgaImpliedVol(
+	pillars [3]SmilePoint, timeToExpiry, spot, strike, r, q float64,
+) (float64, error) {
+
+	t, x, k := timeToExpiry, spot, strike
+
+	o := Call
+	if discounted(x, q, t) > discounted(k, r, t) {
+		o = Put
+	}
+
+	p, err := PriceVannaVolga(pillars, t, x, k, r, q, o)
+	if err != nil {
+		return nan(), err
+	}
+
+	return ImpliedVol(&ImpliedVolParams{
+		Premium: p, TimeToExpiry: t, Underlying: x, Strike: k, Rate: r, Dividend: q, Type: o,
+	})
+}
+
+// checkPillars returns ErrSmile unless the pillars have positive vols
+// and strikes in increasing order
+func checkPillars(pillars [3]SmilePoint) error {
+
+	for j, p := range pillars {
+		if !(p.Vol > 0 && p.Strike > 0) || j > 0 && !(p.Strike > pillars[j-1].Strike) {
+			return ErrSmile
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,125 @@
+package blackscholes
+
+import (
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+var ErrVarianceGamma = errors.New("Invalid variance gamma parameters")
+
+// PriceVarianceGamma returns the price of an option when the log of the
+// underlying is a variance gamma process: a Brownian motion with drift
+// theta and vol sigma run on a gamma clock with mean t and variance
+// nu*t. Given the clock g the underlying is lognormal, so the price is
+// the Black price at total vol sigma*sqrt(g), integrated over the gamma
+// density of g by adaptive Simpson. The drift of the underlying carries
+// the martingale correction log(1 - theta*nu - sigma*sigma*nu/2)/nu, which
+// needs theta*nu + sigma*sigma*nu/2 < 1; otherwise, or for a negative nu,
+// ErrVarianceGamma is returned. At nu = 0 the clock is t and the price is
+// BSPrice at sigma, whatever theta, which is also the limit as nu falls to
+// 0. Zero spot or strike and times below TimeFloor take the BSPrice
+// boundary values, which do not depend on the distribution.
+func PriceVarianceGamma(
+	sigma, theta, nu, timeToExpiry, spot, strike, interestRate, dividendYield float64,
+	optionType OptionType,
+) (float64, error) {
+
+	t, x, k, r, q, o := timeToExpiry, spot, strike, interestRate, dividendYield, optionType
+
+	if sigma < 0 {
+		return nan(), ErrNegVol
+	}
+	if err := checkParams(t, x, k, r, q, o); err != nil {
+		return nan(), err
+	}
+	if !(nu >= 0) || math.IsNaN(theta) || math.IsInf(theta, 0) || nu == inf(1) {
+		return nan(), ErrVarianceGamma
+	}
+	if !(theta*nu+sigma*sigma*nu/2 < 1) {
+		return nan(), ErrVarianceGamma
+	}
+
+	// a clock of spread sqrt(nu*t) below the rounding of t is t
+	if nu == 0 || t < TimeFloor || x == 0 || k == 0 || t+12*sqrt(nu*t) == t {
+		return BSPrice(sigma, t, x, k, r, q, o), nil
+	}
+
+	// the clock has shape a and scale nu, and the forward given the clock
+	// g is f*exp(omega*t + c*g), whose mean over g is f
+	a := t / nu
+	c := theta + sigma*sigma/2
+	omega := math.Log1p(-c*nu) / nu
+	f := Forward(x, r, q, t)
+	lg, _ := math.Lgamma(a)
+
+	// the option value given the clock g = t + h, smooth in h however
+	// small h is next to t
+	wt, st := (omega+c)*t, sigma*sqrt(t)
+	value := func(h float64) float64 {
+		fg := f * exp(wt+c*h)
+		return fg * NormalizedBlack(log(fg/k), st*exp(math.Log1p(h/t)/2), o)
+	}
+	// the density of the clock at g = t + h, in terms of h so that the
+	// bulk of the clock is resolved for small nu: with d = h/t it is
+	// exp(a*(log(1 + d) - d))/(1 + d) times a^a*exp(-a)/(Gamma(a)*t),
+	// the log of a^a*exp(-a)/Gamma(a) taken from the Stirling series for
+	// the large shapes of small nu
+	ln := a*log(a) - a - lg
+	if a > 100 {
+		ln = log(a/2/math.Pi)/2 - 1/(12*a) + 1/(360*a*a*a)
+	}
+	norm := exp(ln) / t
+	integrand := func(h float64) float64 {
+		d := h / t
+		if d == -1 {
+			return 0
+		}
+		return value(h) * norm * exp(a*log1pmx(d)) / (1 + d)
+	}
+
+	// the integrand decays as exp(-rate*h) beyond the bulk of the clock
+	sd := sqrt(nu * t)
+	rate := 1/nu - max(c, 0)
+	lo, hi := max(-12*sd, -t), 12*sd+60/rate
+	tol := 1e-12 * max(1, f)
+
+	var sum float64
+	if a < 1 {
+		// y = g^a takes out the g^(a-1) singularity of the density at 0,
+		// dy = a*g^(a-1)*dg
+		sum = adaptiveSimpson(func(y float64) float64 {
+			g := pow(y, 1/a)
+			if g == 0 {
+				return value(-t) * exp(-a*log(nu)-lg) / a
+			}
+			return value(g-t) * exp(-g/nu-a*log(nu)-lg) / a
+		}, 0, pow(t, a), tol, vgDepth)
+	} else {
+		sum = adaptiveSimpson(integrand, lo, 0, tol, vgDepth)
+	}
+	sum += adaptiveSimpson(integrand, 0, hi, tol, vgDepth)
+
+	return DiscountFactor(r, t) * sum, nil
+}
+
+// log1pmx returns log(1 + d) - d, by its series for small d where the
+// difference cancels
+func log1pmx(d float64) float64 {
+
+	if abs(d) > 0.01 {
+		return math.Log1p(d) - d
+	}
+
+	var s float64
+	for n := 12.0; n > 2; n-- {
+		s = d * (1/n - s)
+	}
+
+	return -d * d * (0.5 - s)
+}
+
+// vgDepth bounds the recursion of the adaptive Simpson integrals of
+// PriceVarianceGamma, so that a tolerance kept out of reach by rounding
+// costs at most 2^vgDepth evaluations
+const vgDepth = 20
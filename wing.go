@@ -0,0 +1,76 @@
+package blackscholes
+
+// Far out of the money the premium of an option is a vanishing fraction
+// of the forward, about 1e-11 of it six total vols out at a week, and the bisection of ImpliedVol, which compares prices in
+// absolute terms, stops resolving it: vols come back whole points off.
+// ImpliedVol and the Solver instead solve such premia in log price, for
+// the root in the total vol s of
+//
+//   log(NormalizedBlack(m, s, o)) - log(c)
+//
+// where m = log(f/k) and c is the undiscounted premium as a fraction of
+// the forward. Out of the money NormalizedBlack subtracts two normal
+// tails, so it keeps its relative accuracy, and the log keeps the Newton
+// steps of the solve on the scale of the premium.
+
+// WingPremium is the premium of an out of the money forward call or put,
+// as a fraction of the discounted underlying exp(-q*t)*x, below which
+// ImpliedVol and the Solver solve in log price. In the money options and
+// straddles keep the bisection, their time value being lost in the
+// rounding of the premium long before it is this small. 0 turns the
+// wing solve off.
+const WingPremium float64 = 1e-7
+
+// wingImpliedVol returns the vol of premium p from its log price when p
+// is in the wings, and reports whether it was; x and k must be positive
+// and t at least TimeFloor
+func wingImpliedVol(p, t, x, k, r, q float64, o OptionType) (float64, bool) {
+
+	xq, kr := discounted(x, q, t), discounted(k, r, t)
+	if o == Call && xq >= kr || o == Put && xq <= kr || o == Straddle {
+		return nan(), false
+	}
+
+	c := p / xq
+	if !(c > 0 && c < WingPremium) {
+		return nan(), false
+	}
+
+	// g is increasing in s from -Inf at s = 0
+	m, lc := log(xq/kr), log(c)
+	g := func(s float64) float64 { return log(NormalizedBlack(m, s, o)) - lc }
+
+	lo, hi := 0.0, 1.0
+	for g(hi) < 0 {
+		if lo, hi = hi, 2*hi; hi > 1e3 {
+			return nan(), false
+		}
+	}
+
+	// Newton in s from the top of the bracket, bisecting whenever a step
+	// leaves it
+	s := hi
+	for i := 0; i < 200; i++ {
+
+		gs := g(s)
+		switch {
+		case gs == 0:
+			return s / sqrt(t), true
+		case gs < 0:
+			lo = s
+		default:
+			hi = s
+		}
+
+		next := s - gs*NormalizedBlack(m, s, o)/NormalizedBlackVega(m, s, o)
+		if !(next > lo && next < hi) {
+			next = (lo + hi) / 2
+		}
+		if abs(next-s) <= 1e-14*s || hi-lo <= 1e-14*hi {
+			return next / sqrt(t), true
+		}
+		s = next
+	}
+
+	return (lo + hi) / 2 / sqrt(t), true
+}